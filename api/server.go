@@ -13,7 +13,9 @@ import (
 	"nofx/crypto"
 	"nofx/decision"
 	"nofx/hook"
+	"nofx/logger"
 	"nofx/manager"
+	"nofx/market"
 	"nofx/trader"
 	"strconv"
 	"strings"
@@ -132,6 +134,12 @@ func (s *Server) setupRoutes() {
 			protected.DELETE("/traders/:id", s.handleDeleteTrader)
 			protected.POST("/traders/:id/start", s.handleStartTrader)
 			protected.POST("/traders/:id/stop", s.handleStopTrader)
+
+			// 人工干预（break-glass）：经由运行中的交易员下单/撤单/调整止损，保持内部状态一致
+			protected.POST("/traders/:id/manual/order", s.handleManualOrder)
+			protected.POST("/traders/:id/manual/cancel", s.handleManualCancel)
+			protected.PUT("/traders/:id/manual/stops", s.handleManualStops)
+			protected.PUT("/traders/:id/positions/metadata", s.handlePositionMetadata)
 			protected.PUT("/traders/:id/prompt", s.handleUpdateTraderPrompt)
 
 			// AI模型配置
@@ -150,10 +158,23 @@ func (s *Server) setupRoutes() {
 			protected.GET("/status", s.handleStatus)
 			protected.GET("/account", s.handleAccount)
 			protected.GET("/positions", s.handlePositions)
+			protected.GET("/exposure", s.handleExposure)
+			protected.GET("/reconciliation", s.handleReconciliation)
 			protected.GET("/decisions", s.handleDecisions)
 			protected.GET("/decisions/latest", s.handleLatestDecisions)
 			protected.GET("/statistics", s.handleStatistics)
 			protected.GET("/performance", s.handlePerformance)
+
+			// 交易日志（?trader_id=xxx）
+			protected.GET("/journal", s.handleJournalList)
+			protected.GET("/journal/attribution", s.handleJournalAttribution)
+			protected.GET("/journal/:entry_id", s.handleJournalEntry)
+			protected.POST("/journal/:entry_id/tags", s.handleJournalAddTags)
+			protected.DELETE("/journal/:entry_id/tags/:tag", s.handleJournalRemoveTag)
+			protected.POST("/journal/:entry_id/notes", s.handleJournalAddNote)
+
+			// K线缓存指标
+			protected.GET("/kline-cache/metrics", s.handleKlineCacheMetrics)
 		}
 	}
 }
@@ -1286,6 +1307,11 @@ func (s *Server) handleStatus(c *gin.Context) {
 	c.JSON(http.StatusOK, status)
 }
 
+// handleKlineCacheMetrics K线缓存指标（每个交易对/周期的更新时间、K线年龄、失败次数、缓存大小）
+func (s *Server) handleKlineCacheMetrics(c *gin.Context) {
+	c.JSON(http.StatusOK, market.GetKlineCache().Metrics())
+}
+
 // handleAccount 账户信息
 func (s *Server) handleAccount(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
@@ -1344,6 +1370,54 @@ func (s *Server) handlePositions(c *gin.Context) {
 	c.JSON(http.StatusOK, positions)
 }
 
+// handleExposure 净方向敞口（?beta=true 时按BTC贝塔加权）
+func (s *Server) handleExposure(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	exposure, err := trader.GetNetExposure(c.Query("beta") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": fmt.Sprintf("计算净敞口失败: %v", err),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, exposure)
+}
+
+// handleReconciliation 最近一次账户对账结果（尚未完成对账时为 null）
+func (s *Server) handleReconciliation(c *gin.Context) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	trader, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	report, err := trader.GetStatementReport()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
 // handleDecisions 决策日志列表
 func (s *Server) handleDecisions(c *gin.Context) {
 	_, traderID, err := s.getTraderFromQuery(c)
@@ -1572,6 +1646,335 @@ func (s *Server) handlePerformance(c *gin.Context) {
 	c.JSON(http.StatusOK, performance)
 }
 
+// getTraderJournal 获取 query 参数指定trader的交易日志（失败时已写入响应）
+func (s *Server) getTraderJournal(c *gin.Context) (*logger.TradeJournal, bool) {
+	_, traderID, err := s.getTraderFromQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+
+	// 交易日志包含完整的交易记录和备注，只允许交易员的所有者读写
+	trader, ok := s.ownedTrader(c, traderID)
+	if !ok {
+		return nil, false
+	}
+
+	journal := trader.GetTradeJournal()
+	if journal == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "该trader未启用交易日志"})
+		return nil, false
+	}
+	return journal, true
+}
+
+// handleJournalList 查询交易日志（支持 symbol、side、tag、since、until、limit 过滤）
+func (s *Server) handleJournalList(c *gin.Context) {
+	journal, ok := s.getTraderJournal(c)
+	if !ok {
+		return
+	}
+
+	query := logger.JournalQuery{
+		Symbol: c.Query("symbol"),
+		Side:   c.Query("side"),
+		Tag:    c.Query("tag"),
+		Limit:  100,
+	}
+	if limitStr := c.Query("limit"); limitStr != "" {
+		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 && l <= 1000 {
+			query.Limit = l
+		}
+	}
+	if !bindJournalFilters(c, &query) {
+		return
+	}
+
+	entries, err := journal.Query(query)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("查询交易日志失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, entries)
+}
+
+// handleJournalAttribution 按信号类型和周期归因的盈亏报表
+// ?period=day|week，?group_by=<元数据键> 按元数据取值分组（A/B对比），可选 symbol/side/tag/meta/since/until
+func (s *Server) handleJournalAttribution(c *gin.Context) {
+	journal, ok := s.getTraderJournal(c)
+	if !ok {
+		return
+	}
+
+	period, err := logger.ParseAttributionPeriod(c.Query("period"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query := logger.JournalQuery{
+		Symbol: c.Query("symbol"),
+		Side:   c.Query("side"),
+		Tag:    c.Query("tag"),
+	}
+	if !bindJournalFilters(c, &query) {
+		return
+	}
+
+	rows, err := journal.Attribution(query, period, c.Query("group_by"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("生成归因报表失败: %v", err)})
+		return
+	}
+	c.JSON(http.StatusOK, rows)
+}
+
+// bindJournalFilters 解析 meta（可重复，key:value）和 since/until（RFC3339）查询参数，格式错误时返回400并返回false
+func bindJournalFilters(c *gin.Context, query *logger.JournalQuery) bool {
+	for _, pair := range c.QueryArray("meta") {
+		key, value, ok := strings.Cut(pair, ":")
+		if !ok || key == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("meta 格式错误（应为 key:value）: %q", pair)})
+			return false
+		}
+		if query.Meta == nil {
+			query.Meta = make(map[string]string)
+		}
+		query.Meta[key] = value
+	}
+
+	for param, target := range map[string]*time.Time{"since": &query.Since, "until": &query.Until} {
+		if value := c.Query(param); value != "" {
+			t, err := time.Parse(time.RFC3339, value)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("%s 时间格式错误（应为RFC3339）: %v", param, err)})
+				return false
+			}
+			*target = t
+		}
+	}
+	return true
+}
+
+// handleJournalEntry 获取单条交易日志
+func (s *Server) handleJournalEntry(c *gin.Context) {
+	journal, ok := s.getTraderJournal(c)
+	if !ok {
+		return
+	}
+	entry, err := journal.Get(c.Param("entry_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// handleJournalAddTags 为交易日志添加标签
+func (s *Server) handleJournalAddTags(c *gin.Context) {
+	journal, ok := s.getTraderJournal(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Tags []string `json:"tags" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := journal.AddTags(c.Param("entry_id"), req.Tags...)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// handleJournalRemoveTag 移除交易日志的标签
+func (s *Server) handleJournalRemoveTag(c *gin.Context) {
+	journal, ok := s.getTraderJournal(c)
+	if !ok {
+		return
+	}
+	entry, err := journal.RemoveTag(c.Param("entry_id"), c.Param("tag"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// handleJournalAddNote 为交易日志追加备注
+func (s *Server) handleJournalAddNote(c *gin.Context) {
+	journal, ok := s.getTraderJournal(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Note string `json:"note" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := journal.AddNote(c.Param("entry_id"), req.Note)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, entry)
+}
+
+// getOwnedTrader 获取属于当前用户的交易员（路径参数 :id）
+func (s *Server) getOwnedTrader(c *gin.Context) (*trader.AutoTrader, bool) {
+	return s.ownedTrader(c, c.Param("id"))
+}
+
+// ownedTrader 获取当前用户拥有的指定交易员（失败时已写入响应）
+func (s *Server) ownedTrader(c *gin.Context, traderID string) (*trader.AutoTrader, bool) {
+	userID := c.GetString("user_id")
+	if _, _, _, err := s.database.GetTraderConfig(userID, traderID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在或无访问权限"})
+		return nil, false
+	}
+	at, err := s.traderManager.GetTrader(traderID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "交易员不存在"})
+		return nil, false
+	}
+	return at, true
+}
+
+// manualOperator 人工干预的操作人（优先使用邮箱）
+func manualOperator(c *gin.Context) string {
+	if email := c.GetString("email"); email != "" {
+		return email
+	}
+	return c.GetString("user_id")
+}
+
+// handleManualOrder 人工下单/平仓/调整止损止盈（与AI决策走同一执行路径）
+func (s *Server) handleManualOrder(c *gin.Context) {
+	at, ok := s.getOwnedTrader(c)
+	if !ok {
+		return
+	}
+
+	var req trader.ManualOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Operator = manualOperator(c)
+
+	action, err := at.ExecuteManualOrder(req)
+	if err != nil {
+		if action == nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "action": action})
+		return
+	}
+	c.JSON(http.StatusOK, action)
+}
+
+// handleManualCancel 人工撤单（order_id 为空时撤销该交易对全部挂单）
+func (s *Server) handleManualCancel(c *gin.Context) {
+	at, ok := s.getOwnedTrader(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Symbol  string `json:"symbol" binding:"required"`
+		OrderID string `json:"order_id"`
+		Reason  string `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := at.CancelManualOrders(req.Symbol, req.OrderID, manualOperator(c), req.Reason); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "撤单成功"})
+}
+
+// handleManualStops 人工调整持仓的止损和/或止盈
+func (s *Server) handleManualStops(c *gin.Context) {
+	at, ok := s.getOwnedTrader(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Symbol     string  `json:"symbol" binding:"required"`
+		StopLoss   float64 `json:"stop_loss"`
+		TakeProfit float64 `json:"take_profit"`
+		Reason     string  `json:"reason" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.StopLoss <= 0 && req.TakeProfit <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "stop_loss 和 take_profit 至少需要一个"})
+		return
+	}
+
+	results := []*logger.DecisionAction{}
+	manual := trader.ManualOrderRequest{Symbol: req.Symbol, Operator: manualOperator(c), Reason: req.Reason}
+	if req.StopLoss > 0 {
+		manual.Action, manual.StopLoss = "update_stop_loss", req.StopLoss
+		action, err := at.ExecuteManualOrder(manual)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "actions": append(results, action)})
+			return
+		}
+		results = append(results, action)
+	}
+	if req.TakeProfit > 0 {
+		manual.Action, manual.StopLoss, manual.TakeProfit = "update_take_profit", 0, req.TakeProfit
+		action, err := at.ExecuteManualOrder(manual)
+		if err != nil {
+			c.JSON(http.StatusBadGateway, gin.H{"error": err.Error(), "actions": append(results, action)})
+			return
+		}
+		results = append(results, action)
+	}
+	c.JSON(http.StatusOK, gin.H{"actions": results})
+}
+
+// handlePositionMetadata 为持仓设置元数据标签（与已有标签合并，值为空删除），平仓时写入交易日志
+func (s *Server) handlePositionMetadata(c *gin.Context) {
+	at, ok := s.getOwnedTrader(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Symbol   string            `json:"symbol" binding:"required"`
+		Side     string            `json:"side" binding:"required"`
+		Metadata map[string]string `json:"metadata" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := at.SetPositionMetadata(req.Symbol, req.Side, req.Metadata); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "持仓元数据已更新"})
+}
+
 // authMiddleware JWT认证中间件
 func (s *Server) authMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -2004,6 +2407,10 @@ func (s *Server) Start() error {
 	log.Printf("  • DELETE /api/traders/:id    - 删除AI交易员")
 	log.Printf("  • POST /api/traders/:id/start - 启动AI交易员")
 	log.Printf("  • POST /api/traders/:id/stop  - 停止AI交易员")
+	log.Printf("  • POST /api/traders/:id/manual/order  - 人工下单/平仓（经由交易员执行）")
+	log.Printf("  • POST /api/traders/:id/manual/cancel - 人工撤单")
+	log.Printf("  • PUT  /api/traders/:id/manual/stops  - 人工调整止损止盈")
+	log.Printf("  • PUT  /api/traders/:id/positions/metadata - 设置持仓元数据标签")
 	log.Printf("  • GET  /api/models           - 获取AI模型配置")
 	log.Printf("  • PUT  /api/models           - 更新AI模型配置")
 	log.Printf("  • GET  /api/exchanges        - 获取交易所配置")
@@ -0,0 +1,144 @@
+package backtest
+
+import (
+	"fmt"
+	"time"
+
+	"nofx/market"
+)
+
+// SameCandlePolicy 同一根K线同时触及止损和止盈时的处理方式（K线内价格路径未知）
+type SameCandlePolicy string
+
+const (
+	SameCandleStopFirst       SameCandlePolicy = "stop_first"        // 保守：按先触发止损处理（默认）
+	SameCandleTakeProfitFirst SameCandlePolicy = "take_profit_first" // 乐观：按先触发止盈处理
+	SameCandleOHLCPath        SameCandlePolicy = "ohlc_path"         // 阳线按 开→低→高→收，阴线按 开→高→低→收 推断先后
+)
+
+// TriggerKind 触发的保护单类型
+type TriggerKind string
+
+const (
+	TriggerStopLoss   TriggerKind = "stop_loss"
+	TriggerTakeProfit TriggerKind = "take_profit"
+)
+
+// Bracket 开仓附带的止损止盈触发单
+// 与 Backpack 开仓时的 stopLossTriggerPrice/takeProfitTriggerPrice 相同：价格触及后按市价平仓，任一触发即撤销另一个
+type Bracket struct {
+	Side       string  // long/short
+	Quantity   float64 // 持仓数量
+	StopLoss   float64 // 止损触发价（0 表示不设置）
+	TakeProfit float64 // 止盈触发价（0 表示不设置）
+}
+
+// Validate 按开仓价检查触发价方向（与交易所一致：多仓止损须低于开仓价、止盈须高于开仓价，空仓相反）
+func (b Bracket) Validate(entryPrice float64) error {
+	if b.Side != "long" && b.Side != "short" {
+		return fmt.Errorf("无效的持仓方向: %q", b.Side)
+	}
+	if b.Quantity <= 0 {
+		return fmt.Errorf("持仓数量必须大于0")
+	}
+	long := b.Side == "long"
+	if b.StopLoss > 0 && (long && b.StopLoss >= entryPrice || !long && b.StopLoss <= entryPrice) {
+		return fmt.Errorf("%s 止损触发价 %.4f 与开仓价 %.4f 方向不符", b.Side, b.StopLoss, entryPrice)
+	}
+	if b.TakeProfit > 0 && (long && b.TakeProfit <= entryPrice || !long && b.TakeProfit >= entryPrice) {
+		return fmt.Errorf("%s 止盈触发价 %.4f 与开仓价 %.4f 方向不符", b.Side, b.TakeProfit, entryPrice)
+	}
+	return nil
+}
+
+// TriggerFill 保护单触发后的平仓成交
+type TriggerFill struct {
+	SimFill
+	Kind      TriggerKind
+	Time      time.Time // 触发K线的开盘时间
+	Gapped    bool      // 开盘价已越过触发价（跳空），按开盘价成交
+	Ambiguous bool      // 同一根K线同时触及止损和止盈，按 SameCandlePolicy 决定
+}
+
+// stopHit/takeProfitHit 判断K线是否触及触发价
+func (b Bracket) stopHit(k market.Kline) bool {
+	if b.StopLoss <= 0 {
+		return false
+	}
+	if b.Side == "long" {
+		return k.Low <= b.StopLoss
+	}
+	return k.High >= b.StopLoss
+}
+
+func (b Bracket) takeProfitHit(k market.Kline) bool {
+	if b.TakeProfit <= 0 {
+		return false
+	}
+	if b.Side == "long" {
+		return k.High >= b.TakeProfit
+	}
+	return k.Low <= b.TakeProfit
+}
+
+// stopFirst 同一根K线同时触及时是否按止损先触发处理
+func (p SameCandlePolicy) stopFirst(side string, k market.Kline) bool {
+	switch p {
+	case SameCandleTakeProfitFirst:
+		return false
+	case SameCandleOHLCPath:
+		// 阳线先到最低价：多仓止损先触发；阴线先到最高价：空仓止损先触发
+		lowFirst := k.Close >= k.Open
+		return lowFirst == (side == "long")
+	}
+	return true
+}
+
+// CheckBracket 用一根K线检查保护单是否触发（开仓K线之后逐根调用），未触发时返回 nil
+// 触发后按市价平仓：以触发价（跳空时为开盘价）为参考价计算滑点，按Taker费率收费
+func (m ExecutionModel) CheckBracket(b Bracket, k market.Kline) *TriggerFill {
+	long := b.Side == "long"
+
+	// 开盘即越过触发价：触发单在开盘时按市价成交
+	if b.StopLoss > 0 && (long && k.Open <= b.StopLoss || !long && k.Open >= b.StopLoss) {
+		return m.triggerFill(b, k, TriggerStopLoss, k.Open, true, false)
+	}
+	if b.TakeProfit > 0 && (long && k.Open >= b.TakeProfit || !long && k.Open <= b.TakeProfit) {
+		return m.triggerFill(b, k, TriggerTakeProfit, k.Open, true, false)
+	}
+
+	stop, take := b.stopHit(k), b.takeProfitHit(k)
+	switch {
+	case stop && take:
+		if m.SameCandle.stopFirst(b.Side, k) {
+			return m.triggerFill(b, k, TriggerStopLoss, b.StopLoss, false, true)
+		}
+		return m.triggerFill(b, k, TriggerTakeProfit, b.TakeProfit, false, true)
+	case stop:
+		return m.triggerFill(b, k, TriggerStopLoss, b.StopLoss, false, false)
+	case take:
+		return m.triggerFill(b, k, TriggerTakeProfit, b.TakeProfit, false, false)
+	}
+	return nil
+}
+
+func (m ExecutionModel) triggerFill(b Bracket, k market.Kline, kind TriggerKind, price float64, gapped, ambiguous bool) *TriggerFill {
+	fill := m.MarketFill(b.Side == "short", b.Quantity, MarketContext{Price: price, Volume: k.Volume})
+	return &TriggerFill{
+		SimFill:   fill,
+		Kind:      kind,
+		Time:      time.UnixMilli(k.OpenTime),
+		Gapped:    gapped,
+		Ambiguous: ambiguous,
+	}
+}
+
+// SimulateBracket 按顺序逐根K线检查保护单，返回第一次触发的成交及K线下标；全部未触发时返回 nil, -1
+func (m ExecutionModel) SimulateBracket(b Bracket, klines []market.Kline) (*TriggerFill, int) {
+	for i, k := range klines {
+		if fill := m.CheckBracket(b, k); fill != nil {
+			return fill, i
+		}
+	}
+	return nil, -1
+}
@@ -0,0 +1,127 @@
+package backtest
+
+import (
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func kline(open, high, low, close float64) market.Kline {
+	return market.Kline{OpenTime: 1735689600000, Open: open, High: high, Low: low, Close: close, Volume: 1000}
+}
+
+func TestCheckBracket_TriggersAtTriggerPrice(t *testing.T) {
+	model := ExecutionModel{Slippage: FixedBpsSlippage{Bps: 10}, Fees: FeeSchedule{TakerRate: 0.0005}}
+	long := Bracket{Side: "long", Quantity: 2, StopLoss: 95, TakeProfit: 110}
+
+	assert.Nil(t, model.CheckBracket(long, kline(100, 105, 96, 102)), "未触及")
+
+	fill := model.CheckBracket(long, kline(100, 101, 94, 96))
+	require.NotNil(t, fill)
+	assert.Equal(t, TriggerStopLoss, fill.Kind)
+	assert.InDelta(t, 95*0.999, fill.Price, 1e-9, "多仓止损卖出，按触发价加滑点成交")
+	assert.InDelta(t, fill.Notional*0.0005, fill.Fee, 1e-9, "按Taker费率")
+	assert.False(t, fill.Maker)
+	assert.False(t, fill.Gapped)
+	assert.Equal(t, int64(1735689600000), fill.Time.UnixMilli())
+
+	fill = model.CheckBracket(long, kline(105, 111, 104, 109))
+	require.NotNil(t, fill)
+	assert.Equal(t, TriggerTakeProfit, fill.Kind)
+	assert.InDelta(t, 110*0.999, fill.Price, 1e-9)
+
+	short := Bracket{Side: "short", Quantity: 1, StopLoss: 105, TakeProfit: 90}
+	fill = model.CheckBracket(short, kline(100, 106, 99, 104))
+	require.NotNil(t, fill)
+	assert.Equal(t, TriggerStopLoss, fill.Kind)
+	assert.InDelta(t, 105*1.001, fill.Price, 1e-9, "空仓止损买入")
+	fill = model.CheckBracket(short, kline(95, 96, 89, 91))
+	require.NotNil(t, fill)
+	assert.Equal(t, TriggerTakeProfit, fill.Kind)
+
+	assert.Nil(t, model.CheckBracket(Bracket{Side: "long", Quantity: 1}, kline(100, 200, 1, 100)), "未设置触发价")
+}
+
+func TestCheckBracket_GapFillsAtOpen(t *testing.T) {
+	model := ExecutionModel{}
+	long := Bracket{Side: "long", Quantity: 1, StopLoss: 95, TakeProfit: 110}
+
+	fill := model.CheckBracket(long, kline(90, 112, 88, 111))
+	require.NotNil(t, fill)
+	assert.Equal(t, TriggerStopLoss, fill.Kind, "开盘已越过止损，不再看K线内路径")
+	assert.True(t, fill.Gapped)
+	assert.False(t, fill.Ambiguous)
+	assert.Equal(t, 90.0, fill.Price, "按开盘价成交而非触发价")
+
+	fill = model.CheckBracket(long, kline(115, 116, 93, 94))
+	require.NotNil(t, fill)
+	assert.Equal(t, TriggerTakeProfit, fill.Kind)
+	assert.True(t, fill.Gapped)
+	assert.Equal(t, 115.0, fill.Price)
+}
+
+func TestCheckBracket_SameCandlePolicies(t *testing.T) {
+	long := Bracket{Side: "long", Quantity: 1, StopLoss: 95, TakeProfit: 110}
+	short := Bracket{Side: "short", Quantity: 1, StopLoss: 110, TakeProfit: 95}
+	bullish := kline(100, 111, 94, 108)
+	bearish := kline(100, 111, 94, 96)
+
+	tests := []struct {
+		policy SameCandlePolicy
+		b      Bracket
+		k      market.Kline
+		want   TriggerKind
+	}{
+		{"", long, bullish, TriggerStopLoss},
+		{SameCandleStopFirst, short, bearish, TriggerStopLoss},
+		{SameCandleTakeProfitFirst, long, bearish, TriggerTakeProfit},
+		{SameCandleTakeProfitFirst, short, bullish, TriggerTakeProfit},
+		// 阳线 开→低→高→收：先到低点
+		{SameCandleOHLCPath, long, bullish, TriggerStopLoss},
+		{SameCandleOHLCPath, short, bullish, TriggerTakeProfit},
+		// 阴线 开→高→低→收：先到高点
+		{SameCandleOHLCPath, long, bearish, TriggerTakeProfit},
+		{SameCandleOHLCPath, short, bearish, TriggerStopLoss},
+	}
+	for _, tt := range tests {
+		fill := ExecutionModel{SameCandle: tt.policy}.CheckBracket(tt.b, tt.k)
+		require.NotNil(t, fill)
+		assert.Equal(t, tt.want, fill.Kind, "%q %s open=%v close=%v", tt.policy, tt.b.Side, tt.k.Open, tt.k.Close)
+		assert.True(t, fill.Ambiguous)
+	}
+}
+
+func TestSimulateBracket(t *testing.T) {
+	model := ExecutionModel{}
+	b := Bracket{Side: "long", Quantity: 1, StopLoss: 95, TakeProfit: 110}
+	klines := []market.Kline{
+		kline(100, 103, 97, 102),
+		kline(102, 108, 100, 107),
+		kline(107, 112, 106, 111),
+		kline(111, 113, 90, 92),
+	}
+
+	fill, i := model.SimulateBracket(b, klines)
+	require.NotNil(t, fill)
+	assert.Equal(t, 2, i, "第一次触发后不再检查后续K线（OCO）")
+	assert.Equal(t, TriggerTakeProfit, fill.Kind)
+
+	fill, i = model.SimulateBracket(b, klines[:2])
+	assert.Nil(t, fill)
+	assert.Equal(t, -1, i)
+}
+
+func TestBracket_Validate(t *testing.T) {
+	assert.NoError(t, Bracket{Side: "long", Quantity: 1, StopLoss: 95, TakeProfit: 110}.Validate(100))
+	assert.NoError(t, Bracket{Side: "short", Quantity: 1, StopLoss: 105}.Validate(100))
+
+	assert.Error(t, Bracket{Side: "long", Quantity: 1, StopLoss: 101}.Validate(100))
+	assert.Error(t, Bracket{Side: "long", Quantity: 1, TakeProfit: 99}.Validate(100))
+	assert.Error(t, Bracket{Side: "short", Quantity: 1, StopLoss: 99}.Validate(100))
+	assert.Error(t, Bracket{Side: "short", Quantity: 1, TakeProfit: 101}.Validate(100))
+	assert.Error(t, Bracket{Side: "flat", Quantity: 1}.Validate(100))
+	assert.Error(t, Bracket{Side: "long"}.Validate(100))
+}
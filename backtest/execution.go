@@ -0,0 +1,229 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// MarketContext 模拟成交时的市场状态
+type MarketContext struct {
+	Price  float64 // 参考价（K线收盘价或最新成交价）
+	Bid    float64 // 买一价（0 表示未知）
+	Ask    float64 // 卖一价（0 表示未知）
+	Volume float64 // 参考成交量（基础币数量，如当前K线成交量）
+}
+
+// mid 中间价（缺少盘口时使用参考价）
+func (c MarketContext) mid() float64 {
+	if c.Bid > 0 && c.Ask >= c.Bid {
+		return (c.Bid + c.Ask) / 2
+	}
+	return c.Price
+}
+
+// SlippageModel 滑点模型，返回相对中间价的不利滑点（基点）
+type SlippageModel interface {
+	SlippageBps(isBuy bool, quantity float64, ctx MarketContext) float64
+}
+
+// FixedBpsSlippage 固定滑点
+type FixedBpsSlippage struct {
+	Bps float64
+}
+
+func (s FixedBpsSlippage) SlippageBps(isBuy bool, quantity float64, ctx MarketContext) float64 {
+	return s.Bps
+}
+
+// SpreadSlippage 按买卖价差计算滑点：吃掉 SpreadFraction 倍的价差（0.5 即从中间价到对手价）再加 ExtraBps
+// 缺少盘口时退化为 FallbackBps
+type SpreadSlippage struct {
+	SpreadFraction float64
+	ExtraBps       float64
+	FallbackBps    float64
+}
+
+func (s SpreadSlippage) SlippageBps(isBuy bool, quantity float64, ctx MarketContext) float64 {
+	mid := ctx.mid()
+	if ctx.Bid <= 0 || ctx.Ask < ctx.Bid || mid <= 0 {
+		return s.FallbackBps
+	}
+	spreadBps := (ctx.Ask - ctx.Bid) / mid * 10000
+	return spreadBps*s.SpreadFraction + s.ExtraBps
+}
+
+// VolumeImpactSlippage 按订单占成交量的比例计算冲击成本：
+// BaseBps + ImpactBps × (数量/成交量)^Exponent，Exponent 默认0.5（平方根冲击模型），结果不超过 MaxBps
+type VolumeImpactSlippage struct {
+	BaseBps   float64
+	ImpactBps float64
+	Exponent  float64
+	MaxBps    float64
+}
+
+func (s VolumeImpactSlippage) SlippageBps(isBuy bool, quantity float64, ctx MarketContext) float64 {
+	bps := s.BaseBps
+	if ctx.Volume > 0 && quantity > 0 {
+		exponent := s.Exponent
+		if exponent <= 0 {
+			exponent = 0.5
+		}
+		bps += s.ImpactBps * math.Pow(quantity/ctx.Volume, exponent)
+	} else if s.MaxBps > 0 {
+		// 不知道成交量时按最坏情况处理
+		bps = s.MaxBps
+	}
+	if s.MaxBps > 0 && bps > s.MaxBps {
+		bps = s.MaxBps
+	}
+	return bps
+}
+
+// FeeSchedule 手续费费率（0.0002 = 0.02%）
+type FeeSchedule struct {
+	MakerRate float64 `json:"maker_rate"`
+	TakerRate float64 `json:"taker_rate"`
+}
+
+// BackpackFeeSchedule Backpack 永续合约默认费率（Maker 0.02%，Taker 0.05%），实际档位不同时通过 ExecutionConfig.Fees 覆盖
+var BackpackFeeSchedule = FeeSchedule{MakerRate: 0.0002, TakerRate: 0.0005}
+
+// SimFill 一笔模拟成交
+type SimFill struct {
+	Price       float64 // 成交价（已含滑点）
+	Quantity    float64
+	Notional    float64 // 成交额
+	Fee         float64 // 手续费（USDT）
+	SlippageBps float64 // 实际滑点（基点）
+	Maker       bool
+}
+
+// ExecutionModel 模拟成交模型（纸面交易和回测共用）
+type ExecutionModel struct {
+	Slippage   SlippageModel // nil 表示无滑点
+	Fees       FeeSchedule
+	SameCandle SameCandlePolicy // 同一根K线同时触及止损止盈时的处理（空表示先止损）
+}
+
+// DefaultExecutionModel 默认模型：Backpack 基础档费率 + 2bps 固定滑点
+func DefaultExecutionModel() ExecutionModel {
+	return ExecutionModel{Slippage: FixedBpsSlippage{Bps: 2}, Fees: BackpackFeeSchedule}
+}
+
+// MarketFill 模拟市价单（吃单）成交：买入价格上移、卖出价格下移，按Taker费率收费
+func (m ExecutionModel) MarketFill(isBuy bool, quantity float64, ctx MarketContext) SimFill {
+	var bps float64
+	if m.Slippage != nil {
+		bps = math.Max(0, m.Slippage.SlippageBps(isBuy, quantity, ctx))
+	}
+	price := ctx.mid()
+	if isBuy {
+		price *= 1 + bps/10000
+	} else {
+		price *= 1 - bps/10000
+	}
+	notional := price * quantity
+	return SimFill{
+		Price:       price,
+		Quantity:    quantity,
+		Notional:    notional,
+		Fee:         notional * m.Fees.TakerRate,
+		SlippageBps: bps,
+	}
+}
+
+// LimitFill 模拟限价单（挂单）按挂单价成交，无滑点，按Maker费率收费
+func (m ExecutionModel) LimitFill(quantity, price float64) SimFill {
+	notional := price * quantity
+	return SimFill{
+		Price:    price,
+		Quantity: quantity,
+		Notional: notional,
+		Fee:      notional * m.Fees.MakerRate,
+		Maker:    true,
+	}
+}
+
+// ClosedTrade 由开仓和平仓成交生成扣除手续费后的交易记录
+func ClosedTrade(symbol, side, signalType string, openTime, closeTime time.Time, entry, exit SimFill) Trade {
+	qty := math.Min(entry.Quantity, exit.Quantity)
+	gross := (exit.Price - entry.Price) * qty
+	if side == "short" {
+		gross = -gross
+	}
+	return Trade{
+		Symbol:     symbol,
+		Side:       side,
+		SignalType: signalType,
+		OpenTime:   openTime,
+		CloseTime:  closeTime,
+		EntryPrice: entry.Price,
+		ExitPrice:  exit.Price,
+		Quantity:   qty,
+		Fee:        entry.Fee + exit.Fee,
+		PnL:        gross - entry.Fee - exit.Fee,
+	}
+}
+
+// 滑点模型类型
+const (
+	SlippageFixed  = "fixed"
+	SlippageSpread = "spread"
+	SlippageVolume = "volume"
+)
+
+// ExecutionConfig 模拟成交配置
+type ExecutionConfig struct {
+	SlippageModel  string       `json:"slippage_model"`  // fixed / spread / volume（空表示无滑点）
+	SlippageBps    float64      `json:"slippage_bps"`    // fixed: 固定滑点；spread: 额外滑点；volume: 基础滑点
+	SpreadFraction float64      `json:"spread_fraction"` // spread: 吃掉的价差比例（默认0.5）
+	FallbackBps    float64      `json:"fallback_bps"`    // spread: 缺少盘口时的滑点
+	ImpactBps      float64      `json:"impact_bps"`      // volume: 冲击系数
+	ImpactExponent float64      `json:"impact_exponent"` // volume: 冲击指数（默认0.5）
+	MaxSlippageBps float64      `json:"max_slippage_bps"`
+	Fees           *FeeSchedule `json:"fees,omitempty"`     // 为空时使用 Backpack 基础档费率
+	SameCandle     string       `json:"same_candle_policy"` // stop_first / take_profit_first / ohlc_path（空表示 stop_first）
+}
+
+// Build 根据配置创建模拟成交模型
+func (c ExecutionConfig) Build() (ExecutionModel, error) {
+	if c.SlippageBps < 0 || c.SpreadFraction < 0 || c.FallbackBps < 0 || c.ImpactBps < 0 || c.MaxSlippageBps < 0 {
+		return ExecutionModel{}, fmt.Errorf("滑点参数不能为负数")
+	}
+
+	model := ExecutionModel{Fees: BackpackFeeSchedule}
+	if c.Fees != nil {
+		if c.Fees.MakerRate < -0.01 || c.Fees.TakerRate < 0 || c.Fees.TakerRate > 0.01 {
+			return ExecutionModel{}, fmt.Errorf("手续费率超出合理范围: maker=%.4f taker=%.4f", c.Fees.MakerRate, c.Fees.TakerRate)
+		}
+		model.Fees = *c.Fees
+	}
+
+	switch policy := SameCandlePolicy(c.SameCandle); policy {
+	case "", SameCandleStopFirst, SameCandleTakeProfitFirst, SameCandleOHLCPath:
+		model.SameCandle = policy
+	default:
+		return ExecutionModel{}, fmt.Errorf("未知的同K线触发策略: %q", c.SameCandle)
+	}
+
+	switch c.SlippageModel {
+	case "":
+	case SlippageFixed:
+		model.Slippage = FixedBpsSlippage{Bps: c.SlippageBps}
+	case SlippageSpread:
+		fraction := c.SpreadFraction
+		if fraction == 0 {
+			fraction = 0.5
+		}
+		model.Slippage = SpreadSlippage{SpreadFraction: fraction, ExtraBps: c.SlippageBps, FallbackBps: c.FallbackBps}
+	case SlippageVolume:
+		if c.ImpactBps <= 0 {
+			return ExecutionModel{}, fmt.Errorf("volume 滑点模型的冲击系数必须大于0")
+		}
+		model.Slippage = VolumeImpactSlippage{BaseBps: c.SlippageBps, ImpactBps: c.ImpactBps, Exponent: c.ImpactExponent, MaxBps: c.MaxSlippageBps}
+	default:
+		return ExecutionModel{}, fmt.Errorf("未知的滑点模型: %q", c.SlippageModel)
+	}
+	return model, nil
+}
@@ -0,0 +1,79 @@
+package backtest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSlippageModels(t *testing.T) {
+	ctx := MarketContext{Price: 100, Bid: 99.9, Ask: 100.1, Volume: 1000}
+
+	assert.Equal(t, 3.0, FixedBpsSlippage{Bps: 3}.SlippageBps(true, 1, ctx))
+
+	// 价差 0.2/100 = 20bps，吃一半 + 1bps
+	spread := SpreadSlippage{SpreadFraction: 0.5, ExtraBps: 1, FallbackBps: 7}
+	assert.InDelta(t, 11, spread.SlippageBps(true, 1, ctx), 1e-9)
+	assert.Equal(t, 7.0, spread.SlippageBps(true, 1, MarketContext{Price: 100}))
+
+	// 占成交量 1%：1 + 100 × sqrt(0.01) = 11bps
+	volume := VolumeImpactSlippage{BaseBps: 1, ImpactBps: 100, MaxBps: 50}
+	assert.InDelta(t, 11, volume.SlippageBps(true, 10, ctx), 1e-9)
+	assert.Equal(t, 50.0, volume.SlippageBps(true, 10000, ctx), "不超过上限")
+	assert.Equal(t, 50.0, volume.SlippageBps(true, 10, MarketContext{Price: 100}), "未知成交量按上限")
+}
+
+func TestExecutionModel_Fills(t *testing.T) {
+	model := ExecutionModel{Slippage: FixedBpsSlippage{Bps: 10}, Fees: FeeSchedule{MakerRate: 0.0002, TakerRate: 0.0005}}
+	ctx := MarketContext{Price: 100}
+
+	buy := model.MarketFill(true, 2, ctx)
+	assert.InDelta(t, 100.1, buy.Price, 1e-9)
+	assert.InDelta(t, 200.2*0.0005, buy.Fee, 1e-9)
+	assert.False(t, buy.Maker)
+
+	sell := model.MarketFill(false, 2, ctx)
+	assert.InDelta(t, 99.9, sell.Price, 1e-9)
+
+	limit := model.LimitFill(2, 99)
+	assert.Equal(t, 99.0, limit.Price)
+	assert.InDelta(t, 198*0.0002, limit.Fee, 1e-9)
+	assert.True(t, limit.Maker)
+}
+
+func TestClosedTrade_NetOfFees(t *testing.T) {
+	model := ExecutionModel{Fees: FeeSchedule{TakerRate: 0.001}}
+	open := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	entry := model.MarketFill(false, 1, MarketContext{Price: 100})
+	exit := model.MarketFill(true, 1, MarketContext{Price: 90})
+
+	trade := ClosedTrade("BTCUSDT", "short", "breakout", open, open.Add(time.Hour), entry, exit)
+	assert.InDelta(t, 0.19, trade.Fee, 1e-9)
+	assert.InDelta(t, 10-0.19, trade.PnL, 1e-9)
+}
+
+func TestExecutionConfig_Build(t *testing.T) {
+	model, err := ExecutionConfig{}.Build()
+	require.NoError(t, err)
+	assert.Nil(t, model.Slippage)
+	assert.Equal(t, BackpackFeeSchedule, model.Fees)
+
+	model, err = ExecutionConfig{SlippageModel: SlippageSpread, FallbackBps: 5}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, SpreadSlippage{SpreadFraction: 0.5, FallbackBps: 5}, model.Slippage)
+
+	_, err = ExecutionConfig{SlippageModel: SlippageVolume}.Build()
+	assert.Error(t, err)
+	_, err = ExecutionConfig{SlippageModel: "magic"}.Build()
+	assert.Error(t, err)
+	_, err = ExecutionConfig{Fees: &FeeSchedule{TakerRate: 0.5}}.Build()
+	assert.Error(t, err)
+
+	model, err = ExecutionConfig{SameCandle: "ohlc_path"}.Build()
+	require.NoError(t, err)
+	assert.Equal(t, SameCandleOHLCPath, model.SameCandle)
+	_, err = ExecutionConfig{SameCandle: "coin_flip"}.Build()
+	assert.Error(t, err)
+}
@@ -0,0 +1,389 @@
+package backtest
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// DrawdownPoint 回撤曲线上的一个点（百分比，<=0）
+type DrawdownPoint struct {
+	Time     time.Time
+	Drawdown float64
+}
+
+// MonthlyReturn 单月收益
+type MonthlyReturn struct {
+	Month     string  // 2006-01
+	ReturnPct float64 // 月收益率（百分比）
+}
+
+// PnLBucket 交易盈亏分布的一个区间
+type PnLBucket struct {
+	Low   float64
+	High  float64
+	Count int
+}
+
+// SignalStats 按信号类型统计的交易表现
+type SignalStats struct {
+	SignalType string
+	Trades     int
+	Wins       int
+	WinRate    float64 // 百分比
+	TotalPnL   float64
+	AvgPnL     float64
+}
+
+// Summary 汇总指标
+type Summary struct {
+	InitialEquity  float64
+	FinalEquity    float64
+	TotalReturnPct float64
+	MaxDrawdownPct float64
+	Trades         int
+	WinRate        float64
+	ProfitFactor   float64
+}
+
+// Report 由回测结果计算出的报告数据
+type Report struct {
+	Name     string
+	Summary  Summary
+	Equity   []EquityPoint
+	Drawdown []DrawdownPoint
+	Monthly  []MonthlyReturn
+	PnLDist  []PnLBucket
+	Signals  []SignalStats
+}
+
+// pnlBucketCount 盈亏分布的区间数
+const pnlBucketCount = 10
+
+// BuildReport 计算报告所需的全部统计
+func BuildReport(result *Result) *Report {
+	report := &Report{
+		Name:     result.Name,
+		Equity:   result.Equity,
+		Drawdown: drawdownSeries(result.Equity),
+		Monthly:  monthlyReturns(result.initialEquity(), result.Equity),
+		PnLDist:  pnlDistribution(result.Trades, pnlBucketCount),
+		Signals:  signalBreakdown(result.Trades),
+	}
+
+	s := &report.Summary
+	s.InitialEquity = result.initialEquity()
+	s.FinalEquity = s.InitialEquity
+	if n := len(result.Equity); n > 0 {
+		s.FinalEquity = result.Equity[n-1].Equity
+	}
+	if s.InitialEquity > 0 {
+		s.TotalReturnPct = (s.FinalEquity/s.InitialEquity - 1) * 100
+	}
+	for _, p := range report.Drawdown {
+		s.MaxDrawdownPct = math.Min(s.MaxDrawdownPct, p.Drawdown)
+	}
+
+	var wins int
+	var grossWin, grossLoss float64
+	for _, t := range result.Trades {
+		if t.PnL > 0 {
+			wins++
+			grossWin += t.PnL
+		} else {
+			grossLoss -= t.PnL
+		}
+	}
+	s.Trades = len(result.Trades)
+	if s.Trades > 0 {
+		s.WinRate = float64(wins) / float64(s.Trades) * 100
+	}
+	if grossLoss > 0 {
+		s.ProfitFactor = grossWin / grossLoss
+	}
+	return report
+}
+
+// drawdownSeries 计算相对历史最高权益的回撤
+func drawdownSeries(equity []EquityPoint) []DrawdownPoint {
+	out := make([]DrawdownPoint, len(equity))
+	peak := 0.0
+	for i, p := range equity {
+		peak = math.Max(peak, p.Equity)
+		dd := 0.0
+		if peak > 0 {
+			dd = (p.Equity/peak - 1) * 100
+		}
+		out[i] = DrawdownPoint{Time: p.Time, Drawdown: dd}
+	}
+	return out
+}
+
+// monthlyReturns 按自然月（UTC）计算收益率，以上月末权益为基准
+func monthlyReturns(initial float64, equity []EquityPoint) []MonthlyReturn {
+	var out []MonthlyReturn
+	base := initial
+	for i, p := range equity {
+		month := p.Time.UTC().Format("2006-01")
+		last := i == len(equity)-1 || equity[i+1].Time.UTC().Format("2006-01") != month
+		if !last {
+			continue
+		}
+		ret := 0.0
+		if base > 0 {
+			ret = (p.Equity/base - 1) * 100
+		}
+		out = append(out, MonthlyReturn{Month: month, ReturnPct: ret})
+		base = p.Equity
+	}
+	return out
+}
+
+// pnlDistribution 将交易盈亏均分到 buckets 个区间
+func pnlDistribution(trades []Trade, buckets int) []PnLBucket {
+	if len(trades) == 0 {
+		return nil
+	}
+	low, high := trades[0].PnL, trades[0].PnL
+	for _, t := range trades {
+		low = math.Min(low, t.PnL)
+		high = math.Max(high, t.PnL)
+	}
+	if low == high {
+		return []PnLBucket{{Low: low, High: high, Count: len(trades)}}
+	}
+
+	width := (high - low) / float64(buckets)
+	out := make([]PnLBucket, buckets)
+	for i := range out {
+		out[i] = PnLBucket{Low: low + float64(i)*width, High: low + float64(i+1)*width}
+	}
+	for _, t := range trades {
+		idx := int((t.PnL - low) / width)
+		if idx >= buckets {
+			idx = buckets - 1
+		}
+		out[idx].Count++
+	}
+	return out
+}
+
+// signalBreakdown 按信号类型汇总，按总盈亏降序
+func signalBreakdown(trades []Trade) []SignalStats {
+	bySignal := make(map[string]*SignalStats)
+	for _, t := range trades {
+		signal := t.SignalType
+		if signal == "" {
+			signal = "unknown"
+		}
+		st := bySignal[signal]
+		if st == nil {
+			st = &SignalStats{SignalType: signal}
+			bySignal[signal] = st
+		}
+		st.Trades++
+		st.TotalPnL += t.PnL
+		if t.PnL > 0 {
+			st.Wins++
+		}
+	}
+
+	out := make([]SignalStats, 0, len(bySignal))
+	for _, st := range bySignal {
+		st.WinRate = float64(st.Wins) / float64(st.Trades) * 100
+		st.AvgPnL = st.TotalPnL / float64(st.Trades)
+		out = append(out, *st)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].TotalPnL != out[j].TotalPnL {
+			return out[i].TotalPnL > out[j].TotalPnL
+		}
+		return out[i].SignalType < out[j].SignalType
+	})
+	return out
+}
+
+// 图表尺寸（SVG坐标）
+const (
+	chartWidth  = 900
+	chartHeight = 240
+)
+
+// svgBar 柱状图中的一根柱子
+type svgBar struct {
+	X, Y, W, H float64
+	Label      string
+	Negative   bool
+}
+
+// polyline 将数值序列映射为 SVG polyline 的 points 属性
+func polyline(values []float64) string {
+	if len(values) == 0 {
+		return ""
+	}
+	low, high := values[0], values[0]
+	for _, v := range values {
+		low = math.Min(low, v)
+		high = math.Max(high, v)
+	}
+	span := high - low
+	if span == 0 {
+		span = 1
+	}
+	step := 0.0
+	if len(values) > 1 {
+		step = float64(chartWidth) / float64(len(values)-1)
+	}
+
+	var sb strings.Builder
+	for i, v := range values {
+		if i > 0 {
+			sb.WriteByte(' ')
+		}
+		y := chartHeight - (v-low)/span*chartHeight
+		fmt.Fprintf(&sb, "%.1f,%.1f", float64(i)*step, y)
+	}
+	return sb.String()
+}
+
+// histogramBars 将盈亏分布转换为柱状图
+func histogramBars(buckets []PnLBucket) []svgBar {
+	if len(buckets) == 0 {
+		return nil
+	}
+	maxCount := 0
+	for _, b := range buckets {
+		maxCount = max(maxCount, b.Count)
+	}
+	width := float64(chartWidth) / float64(len(buckets))
+	bars := make([]svgBar, len(buckets))
+	for i, b := range buckets {
+		h := float64(b.Count) / float64(maxCount) * (chartHeight - 20)
+		bars[i] = svgBar{
+			X:        float64(i)*width + 2,
+			Y:        chartHeight - 20 - h,
+			W:        width - 4,
+			H:        h,
+			Label:    fmt.Sprintf("%.0f~%.0f (%d)", b.Low, b.High, b.Count),
+			Negative: b.High <= 0,
+		}
+	}
+	return bars
+}
+
+// WriteHTML 将报告渲染为独立的HTML（无外部依赖，图表为内联SVG）
+func (r *Report) WriteHTML(w io.Writer) error {
+	equity := make([]float64, len(r.Equity))
+	for i, p := range r.Equity {
+		equity[i] = p.Equity
+	}
+	drawdown := make([]float64, len(r.Drawdown))
+	for i, p := range r.Drawdown {
+		drawdown[i] = p.Drawdown
+	}
+
+	data := map[string]interface{}{
+		"Report":       r,
+		"EquityLine":   polyline(equity),
+		"DrawdownLine": polyline(drawdown),
+		"Histogram":    histogramBars(r.PnLDist),
+		"ChartWidth":   chartWidth,
+		"ChartHeight":  chartHeight,
+		"GeneratedAt":  time.Now().UTC().Format(time.RFC3339),
+	}
+	if err := reportTemplate.Execute(w, data); err != nil {
+		return fmt.Errorf("渲染回测报告失败: %w", err)
+	}
+	return nil
+}
+
+// WriteHTMLReport 生成回测报告并写入 path
+func WriteHTMLReport(result *Result, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建报告文件失败: %w", err)
+	}
+	if err := BuildReport(result).WriteHTML(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"pct": func(v float64) string { return fmt.Sprintf("%+.2f%%", v) },
+	"num": func(v float64) string { return fmt.Sprintf("%.2f", v) },
+}).Parse(`<!DOCTYPE html>
+<html lang="zh-CN">
+<head>
+<meta charset="utf-8">
+<title>回测报告 - {{.Report.Name}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", sans-serif; background: #0b0e11; color: #eaecef; margin: 24px; }
+h1, h2 { font-weight: 600; }
+.cards { display: flex; gap: 12px; flex-wrap: wrap; }
+.card { background: #1e2329; border-radius: 8px; padding: 12px 16px; min-width: 140px; }
+.card .label { color: #848e9c; font-size: 12px; }
+.card .value { font-size: 20px; margin-top: 4px; }
+svg { background: #1e2329; border-radius: 8px; width: 100%; max-width: {{.ChartWidth}}px; }
+table { border-collapse: collapse; margin-top: 8px; }
+th, td { padding: 6px 12px; border-bottom: 1px solid #2b3139; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+.pos { color: #0ecb81; }
+.neg { color: #f6465d; }
+footer { color: #848e9c; font-size: 12px; margin-top: 24px; }
+</style>
+</head>
+<body>
+<h1>回测报告 - {{.Report.Name}}</h1>
+{{with .Report.Summary}}
+<div class="cards">
+  <div class="card"><div class="label">初始权益</div><div class="value">{{num .InitialEquity}}</div></div>
+  <div class="card"><div class="label">最终权益</div><div class="value">{{num .FinalEquity}}</div></div>
+  <div class="card"><div class="label">总收益</div><div class="value {{if ge .TotalReturnPct 0.0}}pos{{else}}neg{{end}}">{{pct .TotalReturnPct}}</div></div>
+  <div class="card"><div class="label">最大回撤</div><div class="value neg">{{pct .MaxDrawdownPct}}</div></div>
+  <div class="card"><div class="label">交易数</div><div class="value">{{.Trades}}</div></div>
+  <div class="card"><div class="label">胜率</div><div class="value">{{num .WinRate}}%</div></div>
+  <div class="card"><div class="label">盈亏比</div><div class="value">{{num .ProfitFactor}}</div></div>
+</div>
+{{end}}
+
+<h2>权益曲线</h2>
+<svg viewBox="0 0 {{.ChartWidth}} {{.ChartHeight}}" preserveAspectRatio="none">
+  <polyline fill="none" stroke="#f0b90b" stroke-width="2" points="{{.EquityLine}}"/>
+</svg>
+
+<h2>回撤</h2>
+<svg viewBox="0 0 {{.ChartWidth}} {{.ChartHeight}}" preserveAspectRatio="none">
+  <polyline fill="none" stroke="#f6465d" stroke-width="2" points="{{.DrawdownLine}}"/>
+</svg>
+
+<h2>月度收益</h2>
+<table>
+  <tr><th>月份</th><th>收益率</th></tr>
+  {{range .Report.Monthly}}<tr><td>{{.Month}}</td><td class="{{if ge .ReturnPct 0.0}}pos{{else}}neg{{end}}">{{pct .ReturnPct}}</td></tr>
+  {{end}}
+</table>
+
+<h2>交易盈亏分布</h2>
+<svg viewBox="0 0 {{.ChartWidth}} {{.ChartHeight}}">
+  {{range .Histogram}}<rect x="{{.X}}" y="{{.Y}}" width="{{.W}}" height="{{.H}}" fill="{{if .Negative}}#f6465d{{else}}#0ecb81{{end}}"><title>{{.Label}}</title></rect>
+  {{end}}
+</svg>
+
+<h2>按信号类型</h2>
+<table>
+  <tr><th>信号</th><th>交易数</th><th>胜率</th><th>总盈亏</th><th>平均盈亏</th></tr>
+  {{range .Report.Signals}}<tr><td>{{.SignalType}}</td><td>{{.Trades}}</td><td>{{num .WinRate}}%</td><td class="{{if ge .TotalPnL 0.0}}pos{{else}}neg{{end}}">{{num .TotalPnL}}</td><td>{{num .AvgPnL}}</td></tr>
+  {{end}}
+</table>
+
+<footer>生成于 {{.GeneratedAt}}</footer>
+</body>
+</html>
+`))
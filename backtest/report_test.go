@@ -0,0 +1,80 @@
+package backtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sampleResult() *Result {
+	day := func(month time.Month, d int) time.Time { return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC) }
+	return &Result{
+		Name:          "breakout-1h",
+		InitialEquity: 1000,
+		Equity: []EquityPoint{
+			{Time: day(1, 1), Equity: 1000},
+			{Time: day(1, 15), Equity: 1200},
+			{Time: day(1, 31), Equity: 1100},
+			{Time: day(2, 10), Equity: 900},
+			{Time: day(2, 28), Equity: 990},
+		},
+		Trades: []Trade{
+			{Symbol: "BTCUSDT", SignalType: "breakout", PnL: 200, CloseTime: day(1, 15)},
+			{Symbol: "BTCUSDT", SignalType: "breakout", PnL: -100, CloseTime: day(1, 31)},
+			{Symbol: "ETHUSDT", SignalType: "mean_reversion", PnL: -200, CloseTime: day(2, 10)},
+			{Symbol: "ETHUSDT", SignalType: "mean_reversion", PnL: 90, CloseTime: day(2, 28)},
+		},
+	}
+}
+
+func TestBuildReport(t *testing.T) {
+	r := BuildReport(sampleResult())
+
+	assert.InDelta(t, -1, r.Summary.TotalReturnPct, 1e-9)
+	assert.InDelta(t, -25, r.Summary.MaxDrawdownPct, 1e-9) // 1200 -> 900
+	assert.Equal(t, 4, r.Summary.Trades)
+	assert.InDelta(t, 50, r.Summary.WinRate, 1e-9)
+	assert.InDelta(t, 290.0/300.0, r.Summary.ProfitFactor, 1e-9)
+
+	require.Len(t, r.Monthly, 2)
+	assert.Equal(t, "2025-01", r.Monthly[0].Month)
+	assert.InDelta(t, 10, r.Monthly[0].ReturnPct, 1e-9)
+	assert.InDelta(t, -10, r.Monthly[1].ReturnPct, 1e-9)
+
+	total := 0
+	for _, b := range r.PnLDist {
+		total += b.Count
+	}
+	assert.Equal(t, 4, total)
+
+	require.Len(t, r.Signals, 2)
+	assert.Equal(t, "breakout", r.Signals[0].SignalType)
+	assert.InDelta(t, 100, r.Signals[0].TotalPnL, 1e-9)
+	assert.InDelta(t, -55, r.Signals[1].AvgPnL, 1e-9)
+}
+
+func TestWriteHTMLReport(t *testing.T) {
+	dir := t.TempDir()
+	data, err := json.Marshal(sampleResult())
+	require.NoError(t, err)
+	resultPath := filepath.Join(dir, "result.json")
+	require.NoError(t, os.WriteFile(resultPath, data, 0644))
+
+	result, err := LoadResult(resultPath)
+	require.NoError(t, err)
+	reportPath := filepath.Join(dir, "report.html")
+	require.NoError(t, WriteHTMLReport(result, reportPath))
+
+	html, err := os.ReadFile(reportPath)
+	require.NoError(t, err)
+	assert.True(t, bytes.Contains(html, []byte("<polyline")))
+	assert.True(t, bytes.Contains(html, []byte("2025-02")))
+	assert.True(t, bytes.Contains(html, []byte("mean_reversion")))
+	assert.False(t, bytes.Contains(html, []byte("ZgotmplZ")), "模板输出被 html/template 过滤")
+}
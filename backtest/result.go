@@ -0,0 +1,65 @@
+package backtest
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// EquityPoint 权益曲线上的一个点
+type EquityPoint struct {
+	Time   time.Time `json:"time"`
+	Equity float64   `json:"equity"`
+}
+
+// Trade 一笔已平仓交易
+type Trade struct {
+	Symbol     string    `json:"symbol"`
+	Side       string    `json:"side"`        // long/short
+	SignalType string    `json:"signal_type"` // 触发开仓的信号类型（如 breakout、mean_reversion）
+	OpenTime   time.Time `json:"open_time"`
+	CloseTime  time.Time `json:"close_time"`
+	EntryPrice float64   `json:"entry_price"`
+	ExitPrice  float64   `json:"exit_price"`
+	Quantity   float64   `json:"quantity"`
+	Fee        float64   `json:"fee,omitempty"` // 开平仓手续费合计（USDT）
+	PnL        float64   `json:"pnl"`           // 扣除手续费后的盈亏（USDT）
+}
+
+// Result 回测结果
+type Result struct {
+	Name          string        `json:"name"`
+	InitialEquity float64       `json:"initial_equity"`
+	Equity        []EquityPoint `json:"equity"`
+	Trades        []Trade       `json:"trades"`
+}
+
+// LoadResult 从JSON文件加载回测结果，并按时间排序
+func LoadResult(path string) (*Result, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取回测结果失败: %w", err)
+	}
+	var result Result
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("解析回测结果失败: %w", err)
+	}
+	result.sort()
+	return &result, nil
+}
+
+// sort 按时间排序权益曲线和交易
+func (r *Result) sort() {
+	sort.SliceStable(r.Equity, func(i, j int) bool { return r.Equity[i].Time.Before(r.Equity[j].Time) })
+	sort.SliceStable(r.Trades, func(i, j int) bool { return r.Trades[i].CloseTime.Before(r.Trades[j].CloseTime) })
+}
+
+// initialEquity 初始权益（未填写时取权益曲线第一个点）
+func (r *Result) initialEquity() float64 {
+	if r.InitialEquity > 0 || len(r.Equity) == 0 {
+		return r.InitialEquity
+	}
+	return r.Equity[0].Equity
+}
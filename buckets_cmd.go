@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+
+	"nofx/strategy"
+)
+
+// runBucketsCommand 资金桶命令行：
+//
+//	nofx buckets <状态文件> list
+//	nofx buckets <状态文件> transfer <来源桶> <目标桶> <金额>
+func runBucketsCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("用法: nofx buckets <状态文件> list | transfer <来源桶> <目标桶> <金额>")
+	}
+
+	buckets, err := strategy.NewCapitalBuckets(strategy.CapitalBucketsConfig{StatePath: args[0]})
+	if err != nil {
+		return err
+	}
+
+	switch args[1] {
+	case "list":
+	case "transfer":
+		if len(args) != 5 {
+			return fmt.Errorf("用法: nofx buckets <状态文件> transfer <来源桶> <目标桶> <金额>")
+		}
+		amount, err := strconv.ParseFloat(args[4], 64)
+		if err != nil {
+			return fmt.Errorf("无效的金额 %q: %w", args[4], err)
+		}
+		if err := buckets.Transfer(args[2], args[3], amount); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("未知的子命令: %s", args[1])
+	}
+
+	fmt.Printf("%-20s %12s %12s %12s\n", "资金桶", "分配", "已实现盈亏", "权益")
+	for _, s := range buckets.States() {
+		fmt.Printf("%-20s %12.2f %12.2f %12.2f\n", s.Name, s.Allocated, s.RealizedPnL, s.Equity())
+	}
+	return nil
+}
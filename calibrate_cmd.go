@@ -0,0 +1,93 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"nofx/config"
+	"nofx/market"
+)
+
+// runCalibrateCommand 信号强度校准命令行：
+//
+//	nofx calibrate [-timeframes 5m,1h] [-horizon 5] [-min-samples 30] <数据目录> <校准文件> [交易对...]
+//
+// 在 nofx download 下载的历史K线上回放各检测器，统计每种形态之后 horizon 根K线的前瞻收益，
+// 把按胜率换算的基础强度写入校准文件（已有文件时只替换本次校准的交易对/周期）；
+// 交易员配置 ConfidenceCalibrationFile 后用校准值替换手工设定的基础强度。
+// 未指定交易对时使用 config.db 中配置的币种
+func runCalibrateCommand(args []string) error {
+	fs := flag.NewFlagSet("calibrate", flag.ContinueOnError)
+	timeFramesFlag := fs.String("timeframes", "", "逗号分隔的时间周期（默认全部周期）")
+	horizon := fs.Int("horizon", 5, "前瞻K线数")
+	minSamples := fs.Int("min-samples", 30, "最少样本数，不足时保留手工设定的强度")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) < 2 {
+		return fmt.Errorf("用法: nofx calibrate [-timeframes 5m,1h] [-horizon 5] [-min-samples 30] <数据目录> <校准文件> [交易对...]")
+	}
+
+	timeFrames := market.AllTimeFrames
+	if *timeFramesFlag != "" {
+		timeFrames = nil
+		for _, s := range strings.Split(*timeFramesFlag, ",") {
+			tf := market.TimeFrame(strings.TrimSpace(s))
+			if _, ok := market.TimeFrameMinutes[tf]; !ok {
+				return fmt.Errorf("不支持的时间周期 %q", s)
+			}
+			timeFrames = append(timeFrames, tf)
+		}
+	}
+
+	symbols := args[2:]
+	if len(symbols) == 0 {
+		database, err := config.NewDatabase("config.db")
+		if err != nil {
+			return fmt.Errorf("读取配置币种失败: %w", err)
+		}
+		symbols = database.GetCustomCoins()
+		database.Close()
+	}
+
+	calibration, err := market.LoadOrNewConfidenceCalibration(args[1])
+	if err != nil {
+		return err
+	}
+	store := market.NewFileKlineStore(args[0])
+	cfg := market.CalibrationConfig{Horizon: *horizon, MinSamples: *minSamples}
+
+	var entries []market.CalibrationEntry
+	for _, symbol := range symbols {
+		for _, tf := range timeFrames {
+			klines, err := store.Load(market.Normalize(symbol), tf, 0)
+			if err != nil {
+				return err
+			}
+			if len(klines) == 0 {
+				continue
+			}
+			entries = append(entries, market.CalibrateConfidence(symbol, tf, klines, cfg)...)
+		}
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("没有样本数足够的形态，请先用 nofx download 下载更长的历史或降低 -min-samples")
+	}
+
+	fmt.Printf("%-14s %-5s %-16s %8s %8s %10s %6s\n", "交易对", "周期", "检测器", "样本", "胜率", "平均收益", "强度")
+	for _, e := range entries {
+		fmt.Printf("%-14s %-5s %-16s %8d %7.1f%% %9.3f%% %6d\n", e.Symbol, e.TimeFrame, e.Detector, e.Samples, e.WinRate*100, e.AvgReturn, e.Confidence)
+	}
+
+	calibration.Horizon = *horizon
+	calibration.GeneratedAt = time.Now()
+	calibration.Merge(entries)
+	if err := calibration.Save(args[1]); err != nil {
+		return err
+	}
+	fmt.Printf("✓ 已写入 %d 条校准结果到 %s\n", len(entries), args[1])
+	return nil
+}
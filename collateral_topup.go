@@ -0,0 +1,108 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"nofx/strategy"
+	"nofx/trader"
+)
+
+// collateralTopUpFile 自动补充保证金配置文件（NOFX_COLLATERAL_TOPUP_CONFIG 指定路径）
+type collateralTopUpFile struct {
+	BackpackAPIKey     string                        `json:"backpack_api_key"`
+	BackpackPrivateKey string                        `json:"backpack_private_key"`
+	Asset              string                        `json:"asset"` // 划转的抵押品币种，默认 USDC
+	IntervalSeconds    int                           `json:"interval_seconds"`
+	Reserve            collateralTopUpAccountEntry   `json:"reserve"`
+	Accounts           []collateralTopUpAccountEntry `json:"accounts"`
+
+	TriggerUsagePct   float64 `json:"trigger_usage_pct"`
+	TargetUsagePct    float64 `json:"target_usage_pct"`
+	MinTransfer       float64 `json:"min_transfer"`
+	MaxTransfer       float64 `json:"max_transfer"`
+	MaxDailyTransfer  float64 `json:"max_daily_transfer"`
+	MinReserveBalance float64 `json:"min_reserve_balance"`
+	CooldownSeconds   int     `json:"cooldown_seconds"`
+}
+
+// collateralTopUpAccountEntry 配置中的账户：名称和 Backpack 子账户ID（0 表示主账户）
+type collateralTopUpAccountEntry struct {
+	Name         string `json:"name"`
+	SubaccountID int    `json:"subaccount_id"`
+}
+
+// startCollateralTopUp 按配置文件启动 Backpack 子账户的自动补充保证金服务，返回停止函数
+func startCollateralTopUp(path string) (func(), error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取配置文件失败: %w", err)
+	}
+	var cfg collateralTopUpFile
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("解析配置文件失败: %w", err)
+	}
+	if cfg.Asset == "" {
+		cfg.Asset = "USDC"
+	}
+
+	// 每个账户使用独立的交易器查询余额和持仓，划转由主账户发起
+	var traders []*trader.BackpackTrader
+	stopTraders := func() {
+		for _, t := range traders {
+			t.Stop()
+		}
+	}
+	newAccount := func(entry collateralTopUpAccountEntry) (strategy.TopUpAccount, error) {
+		t, err := trader.NewBackpackTrader(cfg.BackpackAPIKey, cfg.BackpackPrivateKey, "collateral-topup")
+		if err != nil {
+			return strategy.TopUpAccount{}, err
+		}
+		t.SetSubaccount(entry.SubaccountID)
+		traders = append(traders, t)
+		return strategy.TopUpAccount{Name: entry.Name, Trader: t}, nil
+	}
+
+	subaccounts := map[string]int{cfg.Reserve.Name: cfg.Reserve.SubaccountID}
+	reserve, err := newAccount(cfg.Reserve)
+	if err != nil {
+		return nil, err
+	}
+	var accounts []strategy.TopUpAccount
+	for _, entry := range cfg.Accounts {
+		if _, dup := subaccounts[entry.Name]; dup {
+			stopTraders()
+			return nil, fmt.Errorf("账户名称重复: %s", entry.Name)
+		}
+		subaccounts[entry.Name] = entry.SubaccountID
+		account, err := newAccount(entry)
+		if err != nil {
+			stopTraders()
+			return nil, err
+		}
+		accounts = append(accounts, account)
+	}
+
+	service, err := strategy.NewCollateralTopUpService(strategy.CollateralTopUpConfig{
+		TriggerUsagePct:   cfg.TriggerUsagePct,
+		TargetUsagePct:    cfg.TargetUsagePct,
+		MinTransfer:       cfg.MinTransfer,
+		MaxTransfer:       cfg.MaxTransfer,
+		MaxDailyTransfer:  cfg.MaxDailyTransfer,
+		MinReserveBalance: cfg.MinReserveBalance,
+		Cooldown:          time.Duration(cfg.CooldownSeconds) * time.Second,
+	}, reserve, strategy.BackpackSubaccountTransfer(traders[0], cfg.Asset, subaccounts), accounts...)
+	if err != nil {
+		stopTraders()
+		return nil, err
+	}
+
+	stop := make(chan struct{})
+	go service.Run(time.Duration(cfg.IntervalSeconds)*time.Second, stop)
+	return func() {
+		close(stop)
+		stopTraders()
+	}, nil
+}
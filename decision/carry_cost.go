@@ -0,0 +1,106 @@
+package decision
+
+import (
+	"fmt"
+	"log"
+)
+
+// minRiskRewardRatio 开仓最低风险回报比
+const minRiskRewardRatio = 3.0
+
+// defaultFundingIntervalHours 默认资金费率结算周期（币安为8小时）
+const defaultFundingIntervalHours = 8.0
+
+// CarryCostConfig 持仓成本（资金费用）预估配置
+type CarryCostConfig struct {
+	ExpectedHoldingHours float64 // 预期持仓时长（小时），0 表示不计入持仓成本
+	FundingIntervalHours float64 // 资金费率结算周期（小时），0 使用默认8小时
+}
+
+// Enabled 是否启用持仓成本预估
+func (c CarryCostConfig) Enabled() bool {
+	return c.ExpectedHoldingHours > 0
+}
+
+func (c CarryCostConfig) intervalHours() float64 {
+	if c.FundingIntervalHours > 0 {
+		return c.FundingIntervalHours
+	}
+	return defaultFundingIntervalHours
+}
+
+// EstimateCarryPercent 预估持仓期间的资金费用（占名义价值的百分比）
+// fundingRate 为单个结算周期的资金费率：正费率多头支付、空头收取，负费率相反。
+// 返回正数表示需要支付的成本；收取的资金费不确定性高，不计为收益，返回0。
+func (c CarryCostConfig) EstimateCarryPercent(action string, fundingRate float64) float64 {
+	if !c.Enabled() {
+		return 0
+	}
+	periods := c.ExpectedHoldingHours / c.intervalHours()
+	cost := fundingRate * periods * 100
+	if action == "open_short" {
+		cost = -cost
+	}
+	if cost < 0 {
+		return 0
+	}
+	return cost
+}
+
+// estimateRiskReward 按止损止盈估算开仓的风险和收益百分比
+// 入场价假设在止损到止盈的20%位置
+func estimateRiskReward(d *Decision) (riskPercent, rewardPercent float64) {
+	if d.Action == "open_long" {
+		entryPrice := d.StopLoss + (d.TakeProfit-d.StopLoss)*0.2
+		riskPercent = (entryPrice - d.StopLoss) / entryPrice * 100
+		rewardPercent = (d.TakeProfit - entryPrice) / entryPrice * 100
+	} else {
+		entryPrice := d.StopLoss - (d.StopLoss-d.TakeProfit)*0.2
+		riskPercent = (d.StopLoss - entryPrice) / entryPrice * 100
+		rewardPercent = (entryPrice - d.TakeProfit) / entryPrice * 100
+	}
+	return riskPercent, rewardPercent
+}
+
+// validateCarryCost 将预期资金费用计入风险回报比：
+// 收益扣除持仓成本后，风险回报比仍须≥3.0，否则拒绝开仓
+func validateCarryCost(d *Decision, cfg CarryCostConfig, fundingRate float64) error {
+	if d.Action != "open_long" && d.Action != "open_short" {
+		return nil
+	}
+	carry := cfg.EstimateCarryPercent(d.Action, fundingRate)
+	if carry <= 0 {
+		return nil
+	}
+
+	riskPercent, rewardPercent := estimateRiskReward(d)
+	if riskPercent <= 0 {
+		return nil
+	}
+	netRewardPercent := rewardPercent - carry
+	netRatio := netRewardPercent / riskPercent
+	if netRatio < minRiskRewardRatio {
+		return fmt.Errorf("%s 扣除预期资金费用后风险回报比过低(%.2f:1)，必须≥%.1f:1 [收益:%.2f%% 资金费用:%.2f%%/%.0fh 风险:%.2f%%]",
+			d.Symbol, netRatio, minRiskRewardRatio, rewardPercent, carry, cfg.ExpectedHoldingHours, riskPercent)
+	}
+	return nil
+}
+
+// validateCarryCosts 对所有开仓决策校验持仓成本（资金费率取自上下文市场数据）
+func validateCarryCosts(decisions []Decision, ctx *Context) error {
+	if !ctx.CarryCost.Enabled() {
+		return nil
+	}
+	for i := range decisions {
+		d := &decisions[i]
+		data, ok := ctx.MarketDataMap[d.Symbol]
+		if !ok || data == nil {
+			continue
+		}
+		if err := validateCarryCost(d, ctx.CarryCost, data.FundingRate); err != nil {
+			log.Printf("⚠️  [Carry Cost] %v", err)
+			return fmt.Errorf("决策 #%d 验证失败: %w", i+1, err)
+		}
+	}
+	return nil
+}
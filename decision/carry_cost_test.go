@@ -0,0 +1,91 @@
+package decision
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"nofx/market"
+)
+
+func TestEstimateCarryPercent(t *testing.T) {
+	cfg := CarryCostConfig{ExpectedHoldingHours: 24}
+
+	// 0.01%/8h × 3 个周期 = 0.03%
+	if got := cfg.EstimateCarryPercent("open_long", 0.0001); math.Abs(got-0.03) > 1e-9 {
+		t.Errorf("多头支付资金费: got %.4f, want 0.03", got)
+	}
+	// 正费率空头收取资金费，不计为收益
+	if got := cfg.EstimateCarryPercent("open_short", 0.0001); got != 0 {
+		t.Errorf("空头收取资金费应返回0, got %.4f", got)
+	}
+	if got := cfg.EstimateCarryPercent("open_short", -0.0002); math.Abs(got-0.06) > 1e-9 {
+		t.Errorf("负费率空头支付资金费: got %.4f, want 0.06", got)
+	}
+
+	hourly := CarryCostConfig{ExpectedHoldingHours: 24, FundingIntervalHours: 1}
+	if got := hourly.EstimateCarryPercent("open_long", 0.0001); math.Abs(got-0.24) > 1e-9 {
+		t.Errorf("1小时结算周期: got %.4f, want 0.24", got)
+	}
+
+	if got := (CarryCostConfig{}).EstimateCarryPercent("open_long", 0.01); got != 0 {
+		t.Errorf("未启用时应返回0, got %.4f", got)
+	}
+}
+
+func TestValidateCarryCosts(t *testing.T) {
+	// 入场价估算为 100，风险 1%，收益 4%（4:1）
+	decisions := []Decision{
+		{Symbol: "SOLUSDT", Action: "open_long", Leverage: 5, PositionSizeUSD: 100, StopLoss: 99, TakeProfit: 104},
+	}
+	// 校验估算的入场价
+	risk, reward := estimateRiskReward(&decisions[0])
+	if math.Abs(risk-1) > 1e-9 || math.Abs(reward-4) > 1e-9 {
+		t.Fatalf("风险/收益估算错误: risk=%.4f reward=%.4f", risk, reward)
+	}
+
+	tests := []struct {
+		name        string
+		carry       CarryCostConfig
+		fundingRate float64
+		wantErr     bool
+	}{
+		{name: "未启用持仓成本", carry: CarryCostConfig{}, fundingRate: 0.01},
+		{name: "资金费用较小_允许开仓", carry: CarryCostConfig{ExpectedHoldingHours: 24}, fundingRate: 0.0001},
+		// 0.2%/8h × 3 = 0.6%，净收益 3.4% / 风险 1% = 3.4 仍≥3
+		{name: "资金费用吃掉部分收益_仍满足", carry: CarryCostConfig{ExpectedHoldingHours: 24}, fundingRate: 0.002},
+		// 0.5%/8h × 3 = 1.5%，净收益 2.5% / 风险 1% = 2.5 < 3
+		{name: "资金费用吃掉收益_拒绝开仓", carry: CarryCostConfig{ExpectedHoldingHours: 24}, fundingRate: 0.005, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := &Context{
+				CarryCost:     tt.carry,
+				MarketDataMap: map[string]*market.Data{"SOLUSDT": {Symbol: "SOLUSDT", FundingRate: tt.fundingRate}},
+			}
+			err := validateCarryCosts(decisions, ctx)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("validateCarryCosts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), "资金费用") {
+				t.Errorf("错误信息应说明资金费用: %v", err)
+			}
+		})
+	}
+}
+
+func TestValidateCarryCosts_SkipsNonOpenAndMissingData(t *testing.T) {
+	ctx := &Context{
+		CarryCost:     CarryCostConfig{ExpectedHoldingHours: 240},
+		MarketDataMap: map[string]*market.Data{"SOLUSDT": {Symbol: "SOLUSDT", FundingRate: 0.01}},
+	}
+	decisions := []Decision{
+		{Symbol: "SOLUSDT", Action: "close_long"},
+		{Symbol: "SOLUSDT", Action: "hold"},
+		{Symbol: "BTCUSDT", Action: "open_long", StopLoss: 99, TakeProfit: 104}, // 无市场数据，跳过
+	}
+	if err := validateCarryCosts(decisions, ctx); err != nil {
+		t.Fatalf("不应拒绝: %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"nofx/config"
+	"nofx/market"
+)
+
+// runDownloadCommand 历史K线批量下载命令行（回测数据集和指标预热）：
+//
+//	nofx download [-timeframes 5m,1h] [-weight 1200] <数据目录> <天数> [交易对...]
+//
+// 数据写入与K线缓存相同格式的存储（目录为 kline_cache 时启动即可预热）；
+// 未指定交易对时使用 config.db 中配置的币种；重复执行会从已下载数据之后续传
+func runDownloadCommand(args []string) error {
+	fs := flag.NewFlagSet("download", flag.ContinueOnError)
+	timeFramesFlag := fs.String("timeframes", "", "逗号分隔的时间周期（默认全部周期）")
+	weight := fs.Int("weight", 1200, "每分钟请求权重上限（<=0 表示不限制）")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	args = fs.Args()
+	if len(args) < 2 {
+		return fmt.Errorf("用法: nofx download [-timeframes 5m,1h] [-weight 1200] <数据目录> <天数> [交易对...]")
+	}
+	days, err := strconv.Atoi(args[1])
+	if err != nil || days <= 0 {
+		return fmt.Errorf("无效的天数 %q", args[1])
+	}
+
+	timeFrames := market.AllTimeFrames
+	if *timeFramesFlag != "" {
+		timeFrames = nil
+		for _, s := range strings.Split(*timeFramesFlag, ",") {
+			tf := market.TimeFrame(strings.TrimSpace(s))
+			if _, ok := market.BinanceIntervalMap[tf]; !ok {
+				return fmt.Errorf("不支持的时间周期 %q", s)
+			}
+			timeFrames = append(timeFrames, tf)
+		}
+	}
+
+	symbols := args[2:]
+	if len(symbols) == 0 {
+		database, err := config.NewDatabase("config.db")
+		if err != nil {
+			return fmt.Errorf("读取配置币种失败: %w", err)
+		}
+		symbols = database.GetCustomCoins()
+		database.Close()
+	}
+
+	downloader := market.NewKlineDownloader(market.NewFileKlineStore(args[0]))
+	downloader.SetWeightPerMinute(*weight)
+	since := time.Now().AddDate(0, 0, -days)
+
+	failed, total := 0, 0
+	for _, symbol := range symbols {
+		for _, tf := range timeFrames {
+			total++
+			added, err := downloader.Download(symbol, tf, since)
+			if err != nil {
+				log.Printf("⚠️  %v（已保存 %d 根，重新执行可续传）", err, added)
+				failed++
+				continue
+			}
+			log.Printf("✓ %s %s 新增K线 %d 根", market.Normalize(symbol), tf, added)
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个交易对周期下载失败", failed, total)
+	}
+	return nil
+}
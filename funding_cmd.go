@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"nofx/config"
+	"nofx/market"
+)
+
+// runFundingCommand 历史资金费率/基差下载命令行（用于资金费套利和持仓成本策略回测）：
+//
+//	nofx funding <数据目录> <天数> [交易对...]
+//
+// 未指定交易对时使用 config.db 中配置的币种；重复执行会从已下载数据之后续传
+func runFundingCommand(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("用法: nofx funding <数据目录> <天数> [交易对...]")
+	}
+	days, err := strconv.Atoi(args[1])
+	if err != nil || days <= 0 {
+		return fmt.Errorf("无效的天数 %q", args[1])
+	}
+
+	symbols := args[2:]
+	if len(symbols) == 0 {
+		database, err := config.NewDatabase("config.db")
+		if err != nil {
+			return fmt.Errorf("读取配置币种失败: %w", err)
+		}
+		symbols = database.GetCustomCoins()
+		database.Close()
+	}
+
+	downloader, err := market.NewFundingHistoryDownloader(market.NewFileFundingStore(args[0]), market.TimeFrame1h)
+	if err != nil {
+		return err
+	}
+	since := time.Now().AddDate(0, 0, -days)
+
+	failed := 0
+	for _, symbol := range symbols {
+		funding, basis, err := downloader.Download(symbol, since)
+		if err != nil {
+			log.Printf("⚠️  %v", err)
+			failed++
+			continue
+		}
+		log.Printf("✓ %s 新增资金费率 %d 条，基差 %d 条", market.Normalize(symbol), funding, basis)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个交易对下载失败", failed, len(symbols))
+	}
+	return nil
+}
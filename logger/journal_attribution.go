@@ -0,0 +1,144 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// AttributionPeriod 归因报表的统计周期
+type AttributionPeriod string
+
+const (
+	AttributionAll    AttributionPeriod = ""     // 不分周期
+	AttributionDaily  AttributionPeriod = "day"  // 按自然日（UTC）
+	AttributionWeekly AttributionPeriod = "week" // 按ISO周（UTC）
+)
+
+// UnattributedSignal 没有归属强信号（AI自主开仓）的交易在报表中的信号类型
+const UnattributedSignal = "ai"
+
+// ParseAttributionPeriod 解析统计周期（空字符串表示不分周期）
+func ParseAttributionPeriod(s string) (AttributionPeriod, error) {
+	switch p := AttributionPeriod(s); p {
+	case AttributionAll, AttributionDaily, AttributionWeekly:
+		return p, nil
+	}
+	return "", fmt.Errorf("无效的统计周期: %q（应为 day 或 week）", s)
+}
+
+// key 平仓时间所属的周期标识：2026-01-05 或 2026-W02
+func (p AttributionPeriod) key(t time.Time) string {
+	t = t.UTC()
+	switch p {
+	case AttributionDaily:
+		return t.Format("2006-01-02")
+	case AttributionWeekly:
+		year, week := t.ISOWeek()
+		return fmt.Sprintf("%d-W%02d", year, week)
+	}
+	return ""
+}
+
+// AttributionRow 某周期内某信号类型+周期的交易表现
+type AttributionRow struct {
+	Period       string  `json:"period,omitempty"`  // 周期标识（不分周期时为空）
+	Variant      string  `json:"variant,omitempty"` // 按元数据分组时该组的取值（如实验ID）
+	SignalType   string  `json:"signal_type"`
+	TimeFrame    string  `json:"timeframe"`
+	Trades       int     `json:"trades"`
+	Wins         int     `json:"wins"`
+	Losses       int     `json:"losses"`
+	WinRate      float64 `json:"win_rate"`      // 胜率（%）
+	TotalPnL     float64 `json:"total_pnl"`     // 总盈亏（USDT）
+	AvgPnL       float64 `json:"avg_pnl"`       // 平均每笔盈亏
+	AvgR         float64 `json:"avg_r"`         // 平均R倍数（只统计有初始止损的交易）
+	ProfitFactor float64 `json:"profit_factor"` // 总盈利/总亏损（无亏损时为0）
+}
+
+// AttributePerformance 按平仓周期、信号类型和信号周期汇总交易盈亏
+// 结果按周期倒序，同一周期内按总盈亏从低到高排列（亏损最多的检测器排在最前）
+func AttributePerformance(entries []*JournalEntry, period AttributionPeriod) []AttributionRow {
+	return AttributePerformanceBy(entries, period, "")
+}
+
+// AttributePerformanceBy 同 AttributePerformance，metaKey 非空时再按该元数据键的取值分组（A/B对比不同配置）
+func AttributePerformanceBy(entries []*JournalEntry, period AttributionPeriod, metaKey string) []AttributionRow {
+	type group struct {
+		row                 AttributionRow
+		grossWin, grossLoss float64
+		rSum                float64
+		rCount              int
+	}
+	groups := make(map[[4]string]*group)
+	for _, entry := range entries {
+		signalType := entry.SignalType
+		if signalType == "" {
+			signalType = UnattributedSignal
+		}
+		var variant string
+		if metaKey != "" {
+			variant = entry.Metadata[metaKey]
+		}
+		key := [4]string{period.key(entry.CloseTime), variant, signalType, entry.TimeFrame}
+		g, ok := groups[key]
+		if !ok {
+			g = &group{row: AttributionRow{Period: key[0], Variant: key[1], SignalType: key[2], TimeFrame: key[3]}}
+			groups[key] = g
+		}
+		g.row.Trades++
+		g.row.TotalPnL += entry.PnL
+		if entry.PnL > 0 {
+			g.row.Wins++
+			g.grossWin += entry.PnL
+		} else if entry.PnL < 0 {
+			g.row.Losses++
+			g.grossLoss -= entry.PnL
+		}
+		if entry.StopLoss > 0 {
+			g.rSum += entry.RMultiple
+			g.rCount++
+		}
+	}
+
+	rows := make([]AttributionRow, 0, len(groups))
+	for _, g := range groups {
+		row := g.row
+		row.WinRate = float64(row.Wins) / float64(row.Trades) * 100
+		row.AvgPnL = row.TotalPnL / float64(row.Trades)
+		if g.rCount > 0 {
+			row.AvgR = g.rSum / float64(g.rCount)
+		}
+		if g.grossLoss > 0 {
+			row.ProfitFactor = g.grossWin / g.grossLoss
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Period != rows[j].Period {
+			return rows[i].Period > rows[j].Period
+		}
+		if rows[i].Variant != rows[j].Variant {
+			return rows[i].Variant < rows[j].Variant
+		}
+		if rows[i].TotalPnL != rows[j].TotalPnL {
+			return rows[i].TotalPnL < rows[j].TotalPnL
+		}
+		if rows[i].SignalType != rows[j].SignalType {
+			return rows[i].SignalType < rows[j].SignalType
+		}
+		return rows[i].TimeFrame < rows[j].TimeFrame
+	})
+	return rows
+}
+
+// Attribution 查询符合条件的日志并按信号归因（忽略 q.Limit），metaKey 非空时再按该元数据分组
+func (j *TradeJournal) Attribution(q JournalQuery, period AttributionPeriod, metaKey string) ([]AttributionRow, error) {
+	q.Limit = 0
+	entries, err := j.Query(q)
+	if err != nil {
+		return nil, err
+	}
+	return AttributePerformanceBy(entries, period, metaKey), nil
+}
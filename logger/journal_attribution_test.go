@@ -0,0 +1,80 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAttributePerformance(t *testing.T) {
+	monday := time.Date(2026, 1, 5, 10, 0, 0, 0, time.UTC)
+	entries := []*JournalEntry{
+		{SignalType: "bullish_pin_bar", TimeFrame: "15m", PnL: 30, StopLoss: 95, RMultiple: 2, CloseTime: monday},
+		{SignalType: "bullish_pin_bar", TimeFrame: "15m", PnL: -10, StopLoss: 95, RMultiple: -1, CloseTime: monday.Add(time.Hour)},
+		{SignalType: "engulfing", TimeFrame: "1h", PnL: -20, StopLoss: 95, RMultiple: -1, CloseTime: monday.Add(2 * time.Hour)},
+		{SignalType: "engulfing", TimeFrame: "1h", PnL: -5, CloseTime: monday.Add(24 * time.Hour)},
+		{PnL: 8, CloseTime: monday.Add(7 * 24 * time.Hour)},
+	}
+
+	rows := AttributePerformance(entries, AttributionAll)
+	if len(rows) != 3 {
+		t.Fatalf("len(rows) = %d, want 3", len(rows))
+	}
+	// 亏损最多的排在最前
+	if rows[0].SignalType != "engulfing" || rows[0].Trades != 2 || rows[0].TotalPnL != -25 {
+		t.Errorf("rows[0] = %+v", rows[0])
+	}
+	if rows[0].AvgR != -1 {
+		t.Errorf("AvgR 只统计有止损的交易: got %v, want -1", rows[0].AvgR)
+	}
+	if rows[1].SignalType != UnattributedSignal || rows[1].TotalPnL != 8 {
+		t.Errorf("rows[1] = %+v", rows[1])
+	}
+	pin := rows[2]
+	if pin.Wins != 1 || pin.Losses != 1 || pin.WinRate != 50 || pin.ProfitFactor != 3 || math.Abs(pin.AvgR-0.5) > 1e-9 {
+		t.Errorf("pin bar row = %+v", pin)
+	}
+
+	daily := AttributePerformance(entries, AttributionDaily)
+	if len(daily) != 4 || daily[0].Period != "2026-01-12" || daily[len(daily)-1].Period != "2026-01-05" {
+		t.Errorf("daily rows = %+v", daily)
+	}
+
+	weekly := AttributePerformance(entries, AttributionWeekly)
+	if len(weekly) != 3 || weekly[0].Period != "2026-W03" || weekly[1].Period != "2026-W02" || weekly[1].TotalPnL != -25 {
+		t.Errorf("weekly rows = %+v", weekly)
+	}
+}
+
+func TestAttributePerformanceBy(t *testing.T) {
+	entries := []*JournalEntry{
+		{SignalType: "engulfing", TimeFrame: "1h", PnL: 10, Metadata: map[string]string{"experiment": "a"}},
+		{SignalType: "engulfing", TimeFrame: "1h", PnL: -4, Metadata: map[string]string{"experiment": "b"}},
+		{SignalType: "engulfing", TimeFrame: "1h", PnL: -6, Metadata: map[string]string{"experiment": "b"}},
+		{SignalType: "engulfing", TimeFrame: "1h", PnL: 1},
+	}
+	rows := AttributePerformanceBy(entries, AttributionAll, "experiment")
+	if len(rows) != 3 {
+		t.Fatalf("len(rows) = %d, want 3: %+v", len(rows), rows)
+	}
+	if rows[0].Variant != "" || rows[1].Variant != "a" || rows[2].Variant != "b" {
+		t.Errorf("应按元数据取值分组: %+v", rows)
+	}
+	if rows[2].Trades != 2 || rows[2].TotalPnL != -10 {
+		t.Errorf("experiment=b row = %+v", rows[2])
+	}
+	if merged := AttributePerformance(entries, AttributionAll); len(merged) != 1 || merged[0].Variant != "" {
+		t.Errorf("不分组时应合并: %+v", merged)
+	}
+}
+
+func TestParseAttributionPeriod(t *testing.T) {
+	for _, s := range []string{"", "day", "week"} {
+		if _, err := ParseAttributionPeriod(s); err != nil {
+			t.Errorf("ParseAttributionPeriod(%q) error: %v", s, err)
+		}
+	}
+	if _, err := ParseAttributionPeriod("month"); err == nil {
+		t.Error("ParseAttributionPeriod(\"month\") 应返回错误")
+	}
+}
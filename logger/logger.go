@@ -1,6 +1,7 @@
 package logger
 
 import (
+	"log"
 	"nofx/config"
 	"os"
 
@@ -193,6 +194,15 @@ func Errorf(format string, args ...interface{}) {
 	Log.Errorf(format, args...)
 }
 
+// Alertf 发送告警：以Error级别记录（配置Telegram时会推送）；logger未初始化时退化为标准日志
+func Alertf(format string, args ...interface{}) {
+	if Log == nil {
+		log.Printf("🚨 "+format, args...)
+		return
+	}
+	Log.Errorf("🚨 "+format, args...)
+}
+
 func Fatal(args ...interface{}) {
 	Log.Fatal(args...)
 }
@@ -0,0 +1,109 @@
+package logger
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sync"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+const (
+	confirmPollSeconds = 5           // getUpdates 长轮询时长（秒），决定取消/超时的响应延迟
+	confirmRetryDelay  = time.Second // 轮询失败后的重试间隔
+)
+
+// TelegramConfirmer 通过 Telegram 确认按钮进行人工确认（如提现等资金转出操作）
+// 发送带「确认/拒绝」按钮的消息，并轮询按钮回调；只接受来自配置 chat 的回调
+type TelegramConfirmer struct {
+	bot    *tgbotapi.BotAPI
+	chatID int64
+
+	// 同一时间只等待一个确认（getUpdates 的偏移量在多次确认之间共享）
+	mu     sync.Mutex
+	offset int
+}
+
+// NewTelegramConfirmer 创建 Telegram 确认器
+// 同一个 bot 不能同时配置 webhook 或被其他程序 getUpdates，否则收不到按钮回调
+func NewTelegramConfirmer(botToken string, chatID int64) (*TelegramConfirmer, error) {
+	bot, err := tgbotapi.NewBotAPI(botToken)
+	if err != nil {
+		return nil, fmt.Errorf("创建telegram bot失败: %w", err)
+	}
+	bot.Debug = false
+	return newTelegramConfirmer(bot, chatID), nil
+}
+
+func newTelegramConfirmer(bot *tgbotapi.BotAPI, chatID int64) *TelegramConfirmer {
+	return &TelegramConfirmer{bot: bot, chatID: chatID}
+}
+
+// Confirm 发送确认消息并等待按钮回调：确认返回 true，拒绝返回 false；ctx 取消或超时时返回错误
+func (c *TelegramConfirmer) Confirm(ctx context.Context, summary string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	nonce := make([]byte, 8)
+	if _, err := rand.Read(nonce); err != nil {
+		return false, fmt.Errorf("生成确认标识失败: %w", err)
+	}
+	id := hex.EncodeToString(nonce)
+	confirmData, rejectData := "confirm:"+id, "reject:"+id
+
+	// 纯文本发送：地址等内容可能包含 Markdown 特殊字符
+	msg := tgbotapi.NewMessage(c.chatID, summary)
+	msg.ReplyMarkup = tgbotapi.NewInlineKeyboardMarkup(tgbotapi.NewInlineKeyboardRow(
+		tgbotapi.NewInlineKeyboardButtonData("✅ 确认", confirmData),
+		tgbotapi.NewInlineKeyboardButtonData("❌ 拒绝", rejectData),
+	))
+	sent, err := c.bot.Send(msg)
+	if err != nil {
+		return false, fmt.Errorf("发送确认消息失败: %w", err)
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			c.finish(sent.MessageID, summary, "⌛ 未在时限内确认，已取消")
+			return false, fmt.Errorf("等待确认超时: %w", err)
+		}
+		updates, err := c.bot.GetUpdates(tgbotapi.UpdateConfig{
+			Offset:         c.offset,
+			Timeout:        confirmPollSeconds,
+			AllowedUpdates: []string{"callback_query"},
+		})
+		if err != nil {
+			// 网络抖动时继续轮询，直到超时
+			select {
+			case <-ctx.Done():
+			case <-time.After(confirmRetryDelay):
+			}
+			continue
+		}
+		for _, update := range updates {
+			c.offset = update.UpdateID + 1
+			cb := update.CallbackQuery
+			if cb == nil || cb.Message == nil || cb.Message.Chat.ID != c.chatID {
+				continue
+			}
+			switch cb.Data {
+			case confirmData:
+				c.bot.Request(tgbotapi.NewCallback(cb.ID, "已确认"))
+				c.finish(sent.MessageID, summary, "✅ 已确认")
+				return true, nil
+			case rejectData:
+				c.bot.Request(tgbotapi.NewCallback(cb.ID, "已拒绝"))
+				c.finish(sent.MessageID, summary, "❌ 已拒绝")
+				return false, nil
+			}
+		}
+	}
+}
+
+// finish 更新确认消息的结果并移除按钮（尽力而为）
+func (c *TelegramConfirmer) finish(messageID int, summary, result string) {
+	c.bot.Request(tgbotapi.NewEditMessageText(c.chatID, messageID, summary+"\n\n"+result))
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	tgbotapi "github.com/go-telegram-bot-api/telegram-bot-api/v5"
+)
+
+// fakeTelegram 模拟 Bot API：记录确认消息的按钮，按 press 指定的按钮返回回调
+type fakeTelegram struct {
+	mu     sync.Mutex
+	press  string // confirm / reject / 空（不点击）
+	data   map[string]string
+	edits  []string
+	answer int
+}
+
+func (f *fakeTelegram) handler(w http.ResponseWriter, r *http.Request) {
+	r.ParseForm()
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var result interface{} = true
+	switch r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:] {
+	case "getMe":
+		result = map[string]interface{}{"id": 1, "is_bot": true, "username": "nofx_bot"}
+	case "sendMessage":
+		var markup tgbotapi.InlineKeyboardMarkup
+		json.Unmarshal([]byte(r.Form.Get("reply_markup")), &markup)
+		f.data = map[string]string{}
+		for _, button := range markup.InlineKeyboard[0] {
+			f.data[strings.SplitN(*button.CallbackData, ":", 2)[0]] = *button.CallbackData
+		}
+		result = map[string]interface{}{"message_id": 7, "chat": map[string]interface{}{"id": 42}, "date": 0}
+	case "getUpdates":
+		var updates []map[string]interface{}
+		if f.press != "" {
+			callback := func(id int, chat int64, data string) map[string]interface{} {
+				return map[string]interface{}{"update_id": id, "callback_query": map[string]interface{}{
+					"id": fmt.Sprint(id), "from": map[string]interface{}{"id": 5}, "data": data,
+					"message": map[string]interface{}{"message_id": 7, "chat": map[string]interface{}{"id": chat}, "date": 0},
+				}}
+			}
+			updates = append(updates,
+				callback(100, 99, f.data["confirm"]), // 其他 chat 的回调不接受
+				callback(101, 42, "confirm:stale"),   // 之前确认消息的按钮
+				callback(102, 42, f.data[f.press]))
+		} else {
+			time.Sleep(10 * time.Millisecond)
+		}
+		result = updates
+	case "answerCallbackQuery":
+		f.answer++
+	case "editMessageText":
+		f.edits = append(f.edits, r.Form.Get("text"))
+		result = map[string]interface{}{"message_id": 7, "chat": map[string]interface{}{"id": 42}, "date": 0}
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"ok": true, "result": result})
+}
+
+func newFakeConfirmer(t *testing.T, f *fakeTelegram) *TelegramConfirmer {
+	server := httptest.NewServer(http.HandlerFunc(f.handler))
+	t.Cleanup(server.Close)
+	bot, err := tgbotapi.NewBotAPIWithClient("token", server.URL+"/bot%s/%s", server.Client())
+	if err != nil {
+		t.Fatalf("创建bot失败: %v", err)
+	}
+	return newTelegramConfirmer(bot, 42)
+}
+
+func TestTelegramConfirmer(t *testing.T) {
+	for _, tc := range []struct {
+		press string
+		want  bool
+		edit  string
+	}{
+		{"confirm", true, "✅ 已确认"},
+		{"reject", false, "❌ 已拒绝"},
+	} {
+		f := &fakeTelegram{press: tc.press}
+		c := newFakeConfirmer(t, f)
+		confirmed, err := c.Confirm(context.Background(), "提现 100 USDC")
+		if err != nil {
+			t.Fatalf("%s: %v", tc.press, err)
+		}
+		if confirmed != tc.want {
+			t.Errorf("%s: confirmed = %v, want %v", tc.press, confirmed, tc.want)
+		}
+		if len(f.edits) != 1 || !strings.HasSuffix(f.edits[0], tc.edit) || !strings.HasPrefix(f.edits[0], "提现 100 USDC") {
+			t.Errorf("%s: edits = %q", tc.press, f.edits)
+		}
+		if f.answer != 1 {
+			t.Errorf("%s: 应应答一次回调，实际 %d", tc.press, f.answer)
+		}
+		if c.offset != 103 {
+			t.Errorf("%s: offset = %d，已处理的回调应被确认", tc.press, c.offset)
+		}
+	}
+}
+
+func TestTelegramConfirmerTimeout(t *testing.T) {
+	f := &fakeTelegram{}
+	c := newFakeConfirmer(t, f)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	confirmed, err := c.Confirm(ctx, "提现 100 USDC")
+	if err == nil || confirmed {
+		t.Fatalf("超时应返回错误, got %v %v", confirmed, err)
+	}
+	if len(f.edits) != 1 || !strings.Contains(f.edits[0], "已取消") {
+		t.Errorf("超时后应更新消息, edits = %q", f.edits)
+	}
+}
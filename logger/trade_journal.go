@@ -0,0 +1,281 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// JournalCandle 交易日志中保存的K线快照
+type JournalCandle struct {
+	OpenTime int64   `json:"open_time"`
+	Open     float64 `json:"open"`
+	High     float64 `json:"high"`
+	Low      float64 `json:"low"`
+	Close    float64 `json:"close"`
+	Volume   float64 `json:"volume"`
+}
+
+// JournalNote 用户备注
+type JournalNote struct {
+	Text      string    `json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// JournalEntry 单笔已平仓交易的日志条目
+type JournalEntry struct {
+	ID          string            `json:"id"`
+	Symbol      string            `json:"symbol"`
+	Side        string            `json:"side"` // long/short
+	Quantity    float64           `json:"quantity"`
+	Leverage    int               `json:"leverage"`
+	EntryPrice  float64           `json:"entry_price"`
+	ExitPrice   float64           `json:"exit_price"`
+	StopLoss    float64           `json:"stop_loss"`             // 开仓时的初始止损（用于计算R倍数）
+	PnL         float64           `json:"pnl"`                   // 盈亏（USDT，未扣手续费）
+	RMultiple   float64           `json:"r_multiple"`            // 盈亏 / 初始风险（无止损时为0）
+	Signal      string            `json:"signal"`                // 开仓信号/理由
+	SignalType  string            `json:"signal_type,omitempty"` // 归属的强信号类型（如 bullish_pin_bar，AI自主开仓时为空）
+	TimeFrame   string            `json:"timeframe,omitempty"`   // 归属的强信号周期（如 15m）
+	CloseReason string            `json:"close_reason"`          // 平仓原因
+	OpenTime    time.Time         `json:"open_time"`
+	CloseTime   time.Time         `json:"close_time"`
+	Candles     []JournalCandle   `json:"candles,omitempty"` // 持仓期间及开仓前的K线快照
+	Charts      []string          `json:"charts,omitempty"`  // 开仓信号的K线图文件
+	Tags        []string          `json:"tags,omitempty"`
+	Metadata    map[string]string `json:"metadata,omitempty"` // 键值元数据（策略名、实验ID、配置哈希等），用于A/B对比
+	Notes       []JournalNote     `json:"notes,omitempty"`
+}
+
+// CalculateRMultiple 按初始止损计算R倍数（止损无效时返回0）
+func CalculateRMultiple(side string, entry, exit, stopLoss float64) float64 {
+	risk := math.Abs(entry - stopLoss)
+	if stopLoss <= 0 || risk == 0 {
+		return 0
+	}
+	if side == "short" {
+		return (entry - exit) / risk
+	}
+	return (exit - entry) / risk
+}
+
+// JournalQuery 日志查询条件（零值字段不参与过滤）
+type JournalQuery struct {
+	Symbol string
+	Side   string
+	Tag    string
+	Meta   map[string]string // 元数据需全部匹配
+	Since  time.Time
+	Until  time.Time
+	Limit  int // 最多返回条数（按平仓时间倒序）
+}
+
+// TradeJournal 交易日志：每笔平仓交易保存为一个JSON文件，支持打标签和备注
+type TradeJournal struct {
+	dir string
+	mu  sync.Mutex
+}
+
+// NewTradeJournal 创建交易日志
+func NewTradeJournal(dir string) (*TradeJournal, error) {
+	if dir == "" {
+		dir = "trade_journal"
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("创建交易日志目录失败: %w", err)
+	}
+	return &TradeJournal{dir: dir}, nil
+}
+
+// Record 保存一笔平仓交易（自动生成ID并计算R倍数）
+func (j *TradeJournal) Record(entry *JournalEntry) error {
+	if entry.Symbol == "" || entry.Side == "" {
+		return fmt.Errorf("交易日志缺少交易对或方向")
+	}
+	if entry.CloseTime.IsZero() {
+		entry.CloseTime = time.Now()
+	}
+	if entry.ID == "" {
+		entry.ID = fmt.Sprintf("%s_%s_%d", entry.Symbol, entry.Side, entry.CloseTime.UnixMilli())
+	}
+	if entry.RMultiple == 0 {
+		entry.RMultiple = CalculateRMultiple(entry.Side, entry.EntryPrice, entry.ExitPrice, entry.StopLoss)
+	}
+
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	if err := j.saveLocked(entry); err != nil {
+		return err
+	}
+	fmt.Printf("📓 交易日志已记录: %s (R=%.2f)\n", entry.ID, entry.RMultiple)
+	return nil
+}
+
+// Get 按ID读取日志条目
+func (j *TradeJournal) Get(id string) (*JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.loadLocked(id)
+}
+
+// AddTags 为日志条目添加标签（自动去重）
+func (j *TradeJournal) AddTags(id string, tags ...string) (*JournalEntry, error) {
+	return j.update(id, func(entry *JournalEntry) {
+		for _, tag := range tags {
+			tag = strings.TrimSpace(tag)
+			if tag == "" || containsString(entry.Tags, tag) {
+				continue
+			}
+			entry.Tags = append(entry.Tags, tag)
+		}
+	})
+}
+
+// RemoveTag 移除日志条目的标签
+func (j *TradeJournal) RemoveTag(id, tag string) (*JournalEntry, error) {
+	return j.update(id, func(entry *JournalEntry) {
+		kept := entry.Tags[:0]
+		for _, t := range entry.Tags {
+			if t != tag {
+				kept = append(kept, t)
+			}
+		}
+		entry.Tags = kept
+	})
+}
+
+// AddNote 为日志条目追加备注
+func (j *TradeJournal) AddNote(id, text string) (*JournalEntry, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil, fmt.Errorf("备注不能为空")
+	}
+	return j.update(id, func(entry *JournalEntry) {
+		entry.Notes = append(entry.Notes, JournalNote{Text: text, CreatedAt: time.Now()})
+	})
+}
+
+// Query 按条件查询日志条目，按平仓时间倒序返回
+func (j *TradeJournal) Query(q JournalQuery) ([]*JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	files, err := os.ReadDir(j.dir)
+	if err != nil {
+		return nil, fmt.Errorf("读取交易日志目录失败: %w", err)
+	}
+
+	var entries []*JournalEntry
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+		entry, err := j.loadLocked(strings.TrimSuffix(file.Name(), ".json"))
+		if err != nil {
+			continue
+		}
+		if q.matches(entry) {
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.Slice(entries, func(a, b int) bool { return entries[a].CloseTime.After(entries[b].CloseTime) })
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+	return entries, nil
+}
+
+func (q JournalQuery) matches(entry *JournalEntry) bool {
+	if q.Symbol != "" && entry.Symbol != q.Symbol {
+		return false
+	}
+	if q.Side != "" && entry.Side != q.Side {
+		return false
+	}
+	if q.Tag != "" && !containsString(entry.Tags, q.Tag) {
+		return false
+	}
+	for k, v := range q.Meta {
+		if entry.Metadata[k] != v {
+			return false
+		}
+	}
+	if !q.Since.IsZero() && entry.CloseTime.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.CloseTime.After(q.Until) {
+		return false
+	}
+	return true
+}
+
+// update 读取-修改-写回日志条目
+func (j *TradeJournal) update(id string, modify func(entry *JournalEntry)) (*JournalEntry, error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	entry, err := j.loadLocked(id)
+	if err != nil {
+		return nil, err
+	}
+	modify(entry)
+	if err := j.saveLocked(entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// path 返回条目文件路径（拒绝包含路径分隔符的ID）
+func (j *TradeJournal) path(id string) (string, error) {
+	if id == "" || strings.ContainsAny(id, `/\`) || strings.Contains(id, "..") {
+		return "", fmt.Errorf("无效的交易日志ID: %q", id)
+	}
+	return filepath.Join(j.dir, id+".json"), nil
+}
+
+func (j *TradeJournal) loadLocked(id string) (*JournalEntry, error) {
+	path, err := j.path(id)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取交易日志 %s 失败: %w", id, err)
+	}
+	var entry JournalEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, fmt.Errorf("解析交易日志 %s 失败: %w", id, err)
+	}
+	return &entry, nil
+}
+
+func (j *TradeJournal) saveLocked(entry *JournalEntry) error {
+	path, err := j.path(entry.ID)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entry, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化交易日志失败: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("写入交易日志失败: %w", err)
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package logger
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestCalculateRMultiple(t *testing.T) {
+	tests := []struct {
+		name                    string
+		side                    string
+		entry, exit, stop, want float64
+	}{
+		{"多单盈利2R", "long", 100, 110, 95, 2},
+		{"多单止损-1R", "long", 100, 95, 95, -1},
+		{"空单盈利1.5R", "short", 100, 94, 104, 1.5},
+		{"无止损", "long", 100, 110, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CalculateRMultiple(tt.side, tt.entry, tt.exit, tt.stop); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("CalculateRMultiple() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTradeJournal_RecordTagQuery(t *testing.T) {
+	journal, err := NewTradeJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTradeJournal: %v", err)
+	}
+
+	base := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	entries := []*JournalEntry{
+		{Symbol: "BTCUSDT", Side: "long", EntryPrice: 100, ExitPrice: 110, StopLoss: 95, CloseTime: base},
+		{Symbol: "ETHUSDT", Side: "short", EntryPrice: 50, ExitPrice: 52, StopLoss: 52, CloseTime: base.Add(time.Hour),
+			Metadata: map[string]string{"experiment": "b", "strategy": "scalp"}},
+	}
+	for _, entry := range entries {
+		if err := journal.Record(entry); err != nil {
+			t.Fatalf("Record: %v", err)
+		}
+	}
+	if entries[0].RMultiple != 2 || entries[1].RMultiple != -1 {
+		t.Fatalf("R倍数计算错误: %v %v", entries[0].RMultiple, entries[1].RMultiple)
+	}
+
+	if _, err := journal.AddTags(entries[0].ID, "breakout", " breakout ", "a+"); err != nil {
+		t.Fatalf("AddTags: %v", err)
+	}
+	updated, err := journal.AddNote(entries[0].ID, "入场过早")
+	if err != nil {
+		t.Fatalf("AddNote: %v", err)
+	}
+	if len(updated.Tags) != 2 || len(updated.Notes) != 1 {
+		t.Fatalf("标签/备注未正确保存: %+v", updated)
+	}
+
+	all, err := journal.Query(JournalQuery{})
+	if err != nil {
+		t.Fatalf("Query: %v", err)
+	}
+	if len(all) != 2 || all[0].Symbol != "ETHUSDT" {
+		t.Fatalf("应按平仓时间倒序返回全部条目, got %d", len(all))
+	}
+
+	tagged, _ := journal.Query(JournalQuery{Tag: "breakout"})
+	if len(tagged) != 1 || tagged[0].ID != entries[0].ID {
+		t.Fatalf("按标签过滤失败: %+v", tagged)
+	}
+	meta, _ := journal.Query(JournalQuery{Meta: map[string]string{"experiment": "b"}})
+	if len(meta) != 1 || meta[0].Metadata["strategy"] != "scalp" {
+		t.Fatalf("按元数据过滤失败: %+v", meta)
+	}
+	if none, _ := journal.Query(JournalQuery{Meta: map[string]string{"experiment": "b", "strategy": "swing"}}); len(none) != 0 {
+		t.Fatalf("元数据应全部匹配: %+v", none)
+	}
+	since, _ := journal.Query(JournalQuery{Since: base.Add(time.Minute)})
+	if len(since) != 1 || since[0].Symbol != "ETHUSDT" {
+		t.Fatalf("按时间过滤失败: %+v", since)
+	}
+
+	removed, err := journal.RemoveTag(entries[0].ID, "breakout")
+	if err != nil || len(removed.Tags) != 1 {
+		t.Fatalf("RemoveTag: %v %+v", err, removed)
+	}
+}
+
+func TestTradeJournal_RejectsPathTraversal(t *testing.T) {
+	journal, err := NewTradeJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTradeJournal: %v", err)
+	}
+	if _, err := journal.Get("../secret"); err == nil {
+		t.Fatal("包含路径的ID应被拒绝")
+	}
+}
@@ -151,6 +151,46 @@ func loadBetaCodesToDatabase(database *config.Database) error {
 }
 
 func main() {
+	// 资金桶管理子命令（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "buckets" {
+		if err := runBucketsCommand(os.Args[2:]); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// 历史资金费率/基差下载子命令（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "funding" {
+		if err := runFundingCommand(os.Args[2:]); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// 历史K线批量下载子命令（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "download" {
+		if err := runDownloadCommand(os.Args[2:]); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// 信号强度校准子命令（不启动交易系统）
+	if len(os.Args) > 1 && os.Args[1] == "calibrate" {
+		if err := runCalibrateCommand(os.Args[2:]); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
+	// 合成行情浸泡测试子命令（不连接交易所）
+	if len(os.Args) > 1 && os.Args[1] == "soak" {
+		if err := runSoakCommand(os.Args[2:]); err != nil {
+			log.Fatalf("❌ %v", err)
+		}
+		return
+	}
+
 	fmt.Println("╔════════════════════════════════════════════════════════════╗")
 	fmt.Println("║    🤖 AI多模型交易系统 - 支持 DeepSeek & Qwen            ║")
 	fmt.Println("╚════════════════════════════════════════════════════════════╝")
@@ -345,9 +385,48 @@ func main() {
 		}
 	}()
 
+	// K线缓存从磁盘预热，重启时只需拉取增量
+	market.GetKlineCache().SetStore(market.NewFileKlineStore("kline_cache"))
+
+	// K线收盘推送：把收盘K线及指标 POST 给外部分析服务
+	if webhookURL := strings.TrimSpace(os.Getenv("NOFX_CANDLE_WEBHOOK_URL")); webhookURL != "" {
+		candleWebhook, err := market.NewCandleWebhook(market.CandleWebhookConfig{
+			URL:    webhookURL,
+			Secret: os.Getenv("NOFX_CANDLE_WEBHOOK_SECRET"),
+		})
+		if err != nil {
+			log.Printf("⚠️  启用K线收盘推送失败: %v", err)
+		} else {
+			market.GetKlineCache().SetCandleCloseHandler(candleWebhook.Handle)
+			defer candleWebhook.Stop()
+			log.Printf("📤 已启用K线收盘推送: %s", webhookURL)
+		}
+	}
+
 	// 启动流行情数据 - 默认使用所有交易员设置的币种 如果没有设置币种 则优先使用系统默认
 	go market.NewWSMonitor(150).Start(database.GetCustomCoins())
 	//go market.NewWSMonitor(150).Start([]string{}) //这里是一个使用方式 传入空的话 则使用market市场的所有币种
+
+	// 双价格源（币安 + Backpack）：币安价格停滞时自动切换到 Backpack，并记录两者偏离
+	if os.Getenv("NOFX_DUAL_PRICE_FEED") == "true" {
+		priceFeed := market.NewDualPriceFeed(market.NewBinancePriceSource(), market.NewBackpackPriceSource(), market.DualPriceConfig{})
+		priceFeed.Track(database.GetCustomCoins()...)
+		priceFeed.Start()
+		market.SetPriceFeed(priceFeed)
+		defer priceFeed.Stop()
+		log.Printf("🔀 已启用双价格源（币安 + Backpack），跟踪 %d 个币种", len(priceFeed.Symbols()))
+	}
+
+	// 自动补充保证金：Backpack 子账户保证金使用率过高时从备用账户划转抵押品
+	if topUpConfig := strings.TrimSpace(os.Getenv("NOFX_COLLATERAL_TOPUP_CONFIG")); topUpConfig != "" {
+		stopTopUp, err := startCollateralTopUp(topUpConfig)
+		if err != nil {
+			log.Printf("⚠️  启用自动补充保证金失败: %v", err)
+		} else {
+			defer stopTopUp()
+			log.Printf("🛟 已启用自动补充保证金: %s", topUpConfig)
+		}
+	}
 	// 设置优雅退出
 	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt, syscall.SIGTERM)
@@ -366,6 +445,11 @@ func main() {
 	traderManager.StopAll()
 	log.Println("✅ 所有交易员已停止")
 
+	// 持久化K线缓存，供下次启动预热
+	if err := market.GetKlineCache().PersistAll(); err != nil {
+		log.Printf("⚠️  持久化K线缓存失败: %v", err)
+	}
+
 	// 步骤 2: 关闭 API 服务器
 	log.Println("🛑 停止 API 服务器...")
 	if err := apiServer.Shutdown(); err != nil {
@@ -6,6 +6,7 @@ import (
 	"io"
 	"log"
 	"net/http"
+	"net/url"
 	"nofx/hook"
 	"strconv"
 	"time"
@@ -57,7 +58,25 @@ func (c *APIClient) GetExchangeInfo() (*ExchangeInfo, error) {
 }
 
 func (c *APIClient) GetKlines(symbol, interval string, limit int) ([]Kline, error) {
-	url := fmt.Sprintf("%s/fapi/v1/klines", baseURL)
+	return c.fetchKlines("/fapi/v1/klines", symbol, interval, limit)
+}
+
+// GetMarkPriceKlines 获取标记价格K线（成交量相关字段为0）
+func (c *APIClient) GetMarkPriceKlines(symbol, interval string, limit int) ([]Kline, error) {
+	return c.fetchKlines("/fapi/v1/markPriceKlines", symbol, interval, limit)
+}
+
+// GetBookTicker 获取单个交易对的最优挂单
+func (c *APIClient) GetBookTicker(symbol string) (*BookTicker, error) {
+	var ticker BookTicker
+	if err := c.getJSON("/fapi/v1/ticker/bookTicker?symbol="+url.QueryEscape(symbol), &ticker); err != nil {
+		return nil, err
+	}
+	return &ticker, nil
+}
+
+func (c *APIClient) fetchKlines(path, symbol, interval string, limit int) ([]Kline, error) {
+	url := baseURL + path
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
@@ -158,3 +177,48 @@ func (c *APIClient) GetCurrentPrice(symbol string) (float64, error) {
 
 	return price, nil
 }
+
+// getJSON 请求公开接口并解析JSON响应
+func (c *APIClient) getJSON(path string, out interface{}) error {
+	resp, err := c.client.Get(baseURL + path)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回 %d: %s", path, resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
+
+// GetAllTickers24hr 获取全部交易对的24小时行情
+func (c *APIClient) GetAllTickers24hr() ([]Ticker24hr, error) {
+	var tickers []Ticker24hr
+	if err := c.getJSON("/fapi/v1/ticker/24hr", &tickers); err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
+
+// GetAllBookTickers 获取全部交易对的最优挂单
+func (c *APIClient) GetAllBookTickers() ([]BookTicker, error) {
+	var tickers []BookTicker
+	if err := c.getJSON("/fapi/v1/ticker/bookTicker", &tickers); err != nil {
+		return nil, err
+	}
+	return tickers, nil
+}
+
+// GetAllPremiumIndex 获取全部交易对的标记价格和资金费率
+func (c *APIClient) GetAllPremiumIndex() ([]PremiumIndex, error) {
+	var items []PremiumIndex
+	if err := c.getJSON("/fapi/v1/premiumIndex", &items); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,115 @@
+package market
+
+import (
+	"fmt"
+	"strconv"
+)
+
+// CandleSource 信号检测和止损计算使用的K线价格来源
+type CandleSource string
+
+const (
+	CandleSourceLast CandleSource = "last" // 最新成交价（默认）
+	CandleSourceMark CandleSource = "mark" // 标记价格：OHLC 取标记价格K线，成交量取成交价K线
+	// CandleSourceMid 盘口中间价：交易所不提供中间价K线，历史K线同 mark，
+	// 形成中的最后一根K线收盘价用当前盘口中间价（并扩展其最高/最低价）
+	CandleSourceMid CandleSource = "mid"
+)
+
+// ParseCandleSource 解析K线价格来源（空字符串为 last）
+func ParseCandleSource(s string) (CandleSource, error) {
+	switch src := CandleSource(s); src {
+	case "":
+		return CandleSourceLast, nil
+	case CandleSourceLast, CandleSourceMark, CandleSourceMid:
+		return src, nil
+	}
+	return "", fmt.Errorf("无效的K线价格来源: %q（应为 last、mark 或 mid）", s)
+}
+
+// SetCandleSource 设置K线价格来源
+// 缓存为全局共享，所有交易员使用同一来源；应在初始化交易对之前设置，已缓存的K线不会重新拉取
+func (kc *KlineCache) SetCandleSource(src CandleSource) {
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	kc.source = src
+}
+
+func (kc *KlineCache) candleSource() CandleSource {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	if kc.source == "" {
+		return CandleSourceLast
+	}
+	return kc.source
+}
+
+// fetchKlines 按配置的价格来源拉取K线
+func (kc *KlineCache) fetchKlines(symbol string, tf TimeFrame, limit int) ([]Kline, error) {
+	interval := BinanceIntervalMap[tf]
+	last, err := kc.client.GetKlines(symbol, interval, limit)
+	src := kc.candleSource()
+	if err != nil || src == CandleSourceLast {
+		return last, err
+	}
+
+	mark, err := kc.client.GetMarkPriceKlines(symbol, interval, limit)
+	if err != nil {
+		return nil, fmt.Errorf("获取标记价格K线失败: %w", err)
+	}
+	var mid float64
+	if src == CandleSourceMid {
+		ticker, err := kc.client.GetBookTicker(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("获取盘口失败: %w", err)
+		}
+		if mid, err = ticker.Mid(); err != nil {
+			return nil, err
+		}
+	}
+	return composeCandles(last, mark, mid), nil
+}
+
+// composeCandles 用标记价格K线的 OHLC 替换成交价K线的 OHLC（按开盘时间对齐，保留成交量等字段）
+// 标记价格缺失的K线保持成交价；mid > 0 时用它作为最后一根K线的收盘价
+func composeCandles(last, mark []Kline, mid float64) []Kline {
+	markByOpen := make(map[int64]Kline, len(mark))
+	for _, k := range mark {
+		markByOpen[k.OpenTime] = k
+	}
+
+	out := make([]Kline, len(last))
+	for i, k := range last {
+		if m, ok := markByOpen[k.OpenTime]; ok && m.Close > 0 {
+			k.Open, k.High, k.Low, k.Close = m.Open, m.High, m.Low, m.Close
+		}
+		out[i] = k
+	}
+	if n := len(out); n > 0 && mid > 0 {
+		k := &out[n-1]
+		k.Close = mid
+		if mid > k.High {
+			k.High = mid
+		}
+		if mid < k.Low {
+			k.Low = mid
+		}
+	}
+	return out
+}
+
+// Mid 盘口中间价
+func (t *BookTicker) Mid() (float64, error) {
+	bid, err := strconv.ParseFloat(t.BidPrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析 %s 买一价失败: %w", t.Symbol, err)
+	}
+	ask, err := strconv.ParseFloat(t.AskPrice, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析 %s 卖一价失败: %w", t.Symbol, err)
+	}
+	if bid <= 0 || ask <= 0 {
+		return 0, fmt.Errorf("%s 盘口价格无效: bid=%s ask=%s", t.Symbol, t.BidPrice, t.AskPrice)
+	}
+	return (bid + ask) / 2, nil
+}
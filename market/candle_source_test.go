@@ -0,0 +1,53 @@
+package market
+
+import "testing"
+
+func TestComposeCandles(t *testing.T) {
+	last := []Kline{
+		{OpenTime: 1, Open: 100, High: 120, Low: 80, Close: 101, Volume: 50},
+		{OpenTime: 2, Open: 101, High: 103, Low: 99, Close: 102, Volume: 60},
+		{OpenTime: 3, Open: 102, High: 104, Low: 101, Close: 103, Volume: 70},
+	}
+	mark := []Kline{
+		{OpenTime: 1, Open: 100, High: 102, Low: 98, Close: 100.5},
+		{OpenTime: 2, Open: 100.5, High: 102, Low: 100, Close: 101.5},
+	}
+
+	got := composeCandles(last, mark, 0)
+	if got[0].High != 102 || got[0].Low != 98 || got[0].Volume != 50 {
+		t.Errorf("插针K线应使用标记价格 OHLC 并保留成交量: %+v", got[0])
+	}
+	if got[2] != last[2] {
+		t.Errorf("缺少标记价格的K线应保持成交价: %+v", got[2])
+	}
+	if last[0].High != 120 {
+		t.Error("composeCandles 不应修改输入")
+	}
+
+	got = composeCandles(last, mark, 105)
+	if k := got[2]; k.Close != 105 || k.High != 105 || k.Low != 101 {
+		t.Errorf("最后一根K线收盘价应为盘口中间价: %+v", k)
+	}
+}
+
+func TestParseCandleSource(t *testing.T) {
+	if src, err := ParseCandleSource(""); err != nil || src != CandleSourceLast {
+		t.Errorf("ParseCandleSource(\"\") = %q, %v", src, err)
+	}
+	if src, err := ParseCandleSource("mid"); err != nil || src != CandleSourceMid {
+		t.Errorf("ParseCandleSource(\"mid\") = %q, %v", src, err)
+	}
+	if _, err := ParseCandleSource("index"); err == nil {
+		t.Error("ParseCandleSource(\"index\") 应返回错误")
+	}
+}
+
+func TestBookTickerMid(t *testing.T) {
+	mid, err := (&BookTicker{Symbol: "BTCUSDT", BidPrice: "100", AskPrice: "101"}).Mid()
+	if err != nil || mid != 100.5 {
+		t.Errorf("Mid() = %v, %v", mid, err)
+	}
+	if _, err := (&BookTicker{Symbol: "BTCUSDT", BidPrice: "0", AskPrice: "101"}).Mid(); err == nil {
+		t.Error("买一价为0时应返回错误")
+	}
+}
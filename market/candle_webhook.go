@@ -0,0 +1,257 @@
+package market
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCandleWebhookQueue    = 256 // 待发送队列长度，满了丢弃最新事件
+	defaultCandleWebhookAttempts = 3   // 每个事件的最大发送次数
+)
+
+// CandleIndicators 收盘时计算的指标（K线不足时为0）
+type CandleIndicators struct {
+	EMA20      float64 `json:"ema20"`
+	MACD       float64 `json:"macd"`
+	RSI14      float64 `json:"rsi14"`
+	ATR14      float64 `json:"atr14"`
+	BollUpper  float64 `json:"bollUpper"`
+	BollMiddle float64 `json:"bollMiddle"`
+	BollLower  float64 `json:"bollLower"`
+}
+
+// CandleClose 一根已收盘的K线及其指标
+type CandleClose struct {
+	Symbol     string           `json:"symbol"`
+	TimeFrame  TimeFrame        `json:"timeframe"`
+	Kline      Kline            `json:"kline"`
+	Indicators CandleIndicators `json:"indicators"`
+}
+
+// CandleCloseHandler K线收盘回调（在锁外调用，不应长时间阻塞）
+type CandleCloseHandler func(CandleClose)
+
+// newCandleClose 用截止到该K线（含）的历史计算指标
+func newCandleClose(symbol string, tf TimeFrame, history []Kline) CandleClose {
+	upper, middle, lower := CalculateBollinger(history, 20, 2)
+	return CandleClose{
+		Symbol:    symbol,
+		TimeFrame: tf,
+		Kline:     history[len(history)-1],
+		Indicators: CandleIndicators{
+			EMA20:      calculateEMA(history, 20),
+			MACD:       calculateMACD(history),
+			RSI14:      calculateRSI(history, 14),
+			ATR14:      calculateATR(history, 14),
+			BollUpper:  upper,
+			BollMiddle: middle,
+			BollLower:  lower,
+		},
+	}
+}
+
+// candleClosesLocked 为新收盘的K线构造事件（调用方需持有 mtk.mu）
+func (mtk *MultiTimeFrameKline) candleClosesLocked(tf TimeFrame, closed []Kline) []CandleClose {
+	ring := mtk.series[tf]
+	if ring == nil || len(closed) == 0 {
+		return nil
+	}
+	history := ring.Snapshot(0)
+	events := make([]CandleClose, 0, len(closed))
+	for _, k := range closed {
+		for i := len(history) - 1; i >= 0; i-- {
+			if history[i].OpenTime == k.OpenTime {
+				events = append(events, newCandleClose(mtk.Symbol, tf, history[:i+1]))
+				break
+			}
+		}
+	}
+	return events
+}
+
+// CandleWebhookConfig K线收盘推送配置
+type CandleWebhookConfig struct {
+	URL        string        // 推送地址
+	Secret     string        // 非空时用 HMAC-SHA256 签名请求体，放在 X-Nofx-Signature 头
+	TimeFrames []TimeFrame   // 只推送这些周期（为空表示全部）
+	Symbols    []string      // 只推送这些交易对（为空表示全部）
+	QueueSize  int           // 待发送队列长度（默认256）
+	Timeout    time.Duration // 单次请求超时（默认10秒）
+	Client     *http.Client  // 自定义HTTP客户端（可选）
+}
+
+// CandleWebhook 把收盘K线（含指标）异步 POST 到外部地址
+type CandleWebhook struct {
+	cfg        CandleWebhookConfig
+	client     *http.Client
+	timeFrames map[TimeFrame]bool // 周期过滤（nil 表示全部）
+	symbols    map[string]bool    // 交易对过滤（nil 表示全部）
+	queue      chan CandleClose
+	done       chan struct{}
+	wg         sync.WaitGroup
+
+	mu      sync.Mutex
+	sent    int
+	failed  int
+	dropped int
+}
+
+// NewCandleWebhook 创建并启动推送（需调用 Stop 释放）
+func NewCandleWebhook(cfg CandleWebhookConfig) (*CandleWebhook, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("推送地址不能为空")
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = defaultCandleWebhookQueue
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	client := cfg.Client
+	if client == nil {
+		client = &http.Client{Timeout: cfg.Timeout}
+	}
+
+	w := &CandleWebhook{
+		cfg:    cfg,
+		client: client,
+		queue:  make(chan CandleClose, cfg.QueueSize),
+		done:   make(chan struct{}),
+	}
+	if len(cfg.TimeFrames) > 0 {
+		w.timeFrames = make(map[TimeFrame]bool, len(cfg.TimeFrames))
+		for _, tf := range cfg.TimeFrames {
+			if _, ok := TimeFrameMinutes[tf]; !ok {
+				return nil, fmt.Errorf("不支持的周期: %s", tf)
+			}
+			w.timeFrames[tf] = true
+		}
+	}
+	if len(cfg.Symbols) > 0 {
+		w.symbols = make(map[string]bool, len(cfg.Symbols))
+		for _, s := range cfg.Symbols {
+			w.symbols[Normalize(s)] = true
+		}
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w, nil
+}
+
+// Handle 入队一个收盘事件（可直接作为 CandleCloseHandler）
+func (w *CandleWebhook) Handle(event CandleClose) {
+	if w.timeFrames != nil && !w.timeFrames[event.TimeFrame] {
+		return
+	}
+	if w.symbols != nil && !w.symbols[Normalize(event.Symbol)] {
+		return
+	}
+	select {
+	case w.queue <- event:
+	default:
+		w.mu.Lock()
+		w.dropped++
+		w.mu.Unlock()
+		log.Printf("⚠️ [CandleWebhook] 队列已满，丢弃 %s %s", event.Symbol, event.TimeFrame)
+	}
+}
+
+// Stop 发送完队列中剩余事件后停止
+func (w *CandleWebhook) Stop() {
+	close(w.done)
+	w.wg.Wait()
+}
+
+// Stats 返回已发送、发送失败和丢弃的事件数
+func (w *CandleWebhook) Stats() (sent, failed, dropped int) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.sent, w.failed, w.dropped
+}
+
+func (w *CandleWebhook) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case event := <-w.queue:
+			w.deliver(event)
+		case <-w.done:
+			for {
+				select {
+				case event := <-w.queue:
+					w.deliver(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver 发送一个事件，失败时按 1s/2s 退避重试
+func (w *CandleWebhook) deliver(event CandleClose) {
+	body, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("⚠️ [CandleWebhook] 序列化失败: %v", err)
+		return
+	}
+
+	backoff := time.Second
+	for attempt := 1; attempt <= defaultCandleWebhookAttempts; attempt++ {
+		if err = w.post(body); err == nil {
+			w.mu.Lock()
+			w.sent++
+			w.mu.Unlock()
+			return
+		}
+		if attempt == defaultCandleWebhookAttempts {
+			break
+		}
+		select {
+		case <-time.After(backoff):
+		case <-w.done:
+			// 停止时不再等待重试
+			attempt = defaultCandleWebhookAttempts
+		}
+		backoff *= 2
+	}
+
+	w.mu.Lock()
+	w.failed++
+	w.mu.Unlock()
+	log.Printf("⚠️ [CandleWebhook] 推送 %s %s 失败: %v", event.Symbol, event.TimeFrame, err)
+}
+
+func (w *CandleWebhook) post(body []byte) error {
+	req, err := http.NewRequest(http.MethodPost, w.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.cfg.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(w.cfg.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Nofx-Signature", hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("返回 %d", resp.StatusCode)
+	}
+	return nil
+}
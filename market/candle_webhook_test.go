@@ -0,0 +1,124 @@
+package market
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMergeLocked_ReturnsClosedKlines(t *testing.T) {
+	mtk := newTestMTK()
+	if closed := mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: 1}, {OpenTime: 2}}); len(closed) != 1 || closed[0].OpenTime != 1 {
+		t.Fatalf("首次加载应确认第1根收盘, got %+v", closed)
+	}
+
+	// 形成中的K线更新不触发收盘
+	if closed := mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: 2, Close: 101}}); len(closed) != 0 {
+		t.Fatalf("expected no closed klines, got %+v", closed)
+	}
+
+	// 新K线出现：上一根以最终数据收盘
+	closed := mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: 2, Close: 102}, {OpenTime: 3, Close: 103}})
+	if len(closed) != 1 || closed[0].OpenTime != 2 || closed[0].Close != 102 {
+		t.Fatalf("expected kline 2 closed at 102, got %+v", closed)
+	}
+
+	events := mtk.candleClosesLocked(TimeFrame5m, closed)
+	if len(events) != 1 || events[0].Symbol != "BTCUSDT" || events[0].TimeFrame != TimeFrame5m || events[0].Kline.Close != 102 {
+		t.Fatalf("unexpected events %+v", events)
+	}
+}
+
+func TestNewCandleClose_Indicators(t *testing.T) {
+	history := make([]Kline, 30)
+	for i := range history {
+		p := 100 + float64(i)
+		history[i] = Kline{OpenTime: int64(i), Open: p - 0.5, High: p + 1, Low: p - 1, Close: p}
+	}
+	event := newCandleClose("ETHUSDT", TimeFrame1h, history)
+	if event.Kline.OpenTime != 29 {
+		t.Fatalf("expected last kline, got %d", event.Kline.OpenTime)
+	}
+	ind := event.Indicators
+	if ind.EMA20 <= 0 || ind.ATR14 <= 0 || ind.RSI14 <= 50 || ind.BollUpper <= ind.BollLower {
+		t.Fatalf("unexpected indicators %+v", ind)
+	}
+}
+
+func TestCandleWebhook_PostsSignedAndFilters(t *testing.T) {
+	var mu sync.Mutex
+	var received []CandleClose
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, []byte("secret"))
+		mac.Write(body)
+		if r.Header.Get("X-Nofx-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event CandleClose
+		json.Unmarshal(body, &event)
+		mu.Lock()
+		received = append(received, event)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	w, err := NewCandleWebhook(CandleWebhookConfig{URL: srv.URL, Secret: "secret", TimeFrames: []TimeFrame{TimeFrame1h}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Handle(CandleClose{Symbol: "BTCUSDT", TimeFrame: TimeFrame5m})
+	w.Handle(CandleClose{Symbol: "BTCUSDT", TimeFrame: TimeFrame1h, Kline: Kline{Close: 42}})
+	w.Stop()
+
+	sent, failed, dropped := w.Stats()
+	if sent != 1 || failed != 0 || dropped != 0 {
+		t.Fatalf("stats = %d/%d/%d, want 1/0/0", sent, failed, dropped)
+	}
+	if len(received) != 1 || received[0].Kline.Close != 42 {
+		t.Fatalf("received %+v", received)
+	}
+}
+
+func TestCandleWebhook_RetriesFailures(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		n := calls
+		mu.Unlock()
+		if n == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	w, err := NewCandleWebhook(CandleWebhookConfig{URL: srv.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	w.Handle(CandleClose{Symbol: "BTCUSDT", TimeFrame: TimeFrame1h})
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if sent, _, _ := w.Stats(); sent == 1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("重试后应发送成功")
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	w.Stop()
+	if calls != 2 {
+		t.Fatalf("calls = %d, want 2", calls)
+	}
+}
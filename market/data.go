@@ -58,6 +58,9 @@ func Get(symbol string) (*Data, error) {
 
 	// 计算当前指标 (基于3分钟最新数据)
 	currentPrice := klines3m[len(klines3m)-1].Close
+	if price, ok := feedPrice(symbol); ok {
+		currentPrice = price // 启用双价格源时使用当前有效来源的价格
+	}
 	currentEMA20 := calculateEMA(klines3m, 20)
 	currentMACD := calculateMACD(klines3m)
 	currentRSI7 := calculateRSI(klines3m, 7)
@@ -356,6 +359,11 @@ func getOpenInterestData(symbol string) (*OIData, error) {
 	}, nil
 }
 
+// GetFundingRate 获取币安合约最新资金费率（每8小时结算，结果缓存1小时）
+func GetFundingRate(symbol string) (float64, error) {
+	return getFundingRate(Normalize(symbol))
+}
+
 // getFundingRate 获取资金费率（优化：使用 1 小时缓存）
 func getFundingRate(symbol string) (float64, error) {
 	// 检查缓存（有效期 1 小时）
@@ -524,44 +532,107 @@ func formatFloatSlice(values []float64) string {
 	return "[" + strings.Join(strValues, ", ") + "]"
 }
 
+// usdQuotes 视为与USDT等价的美元计价币种（按长度从长到短排列，保证优先匹配长后缀）
+var usdQuotes = []string{"FDUSD", "USDT", "USDC", "BUSD", "USD"}
+
+// contractSuffixes 合约类型后缀（如 Backpack 的 _PERP）
+var contractSuffixes = map[string]bool{"PERP": true, "SWAP": true}
+
 // Normalize 标准化symbol,确保是USDT交易对
-// 支持多交易所格式转换为币安格式
+// 支持多交易所格式转换为币安格式:
+//   - Backpack永续: ETH_USDC_PERP -> ETHUSDT
+//   - Backpack现货: SOL_USDC -> SOLUSDT
+//   - 千倍资产: kPEPE_USDC_PERP -> 1000PEPEUSDT（Backpack使用小写k前缀）
+//   - 其他分隔符: ETH-USDT / ETH/USDT / ETH-PERP -> ETHUSDT
+//   - 美元计价: ETHUSDC / ETHUSD -> ETHUSDT
+//   - 非美元计价保留原计价币种: ETH_BTC -> ETHBTC
+//   - 仅有币种: eth -> ETHUSDT
 func Normalize(symbol string) string {
-	symbol = strings.ToUpper(symbol)
+	symbol = strings.TrimSpace(symbol)
+	if symbol == "" {
+		return ""
+	}
+
+	base, quote := splitSymbol(symbol)
+	if isUSDQuote(quote) {
+		quote = "USDT"
+	}
+	return base + quote
+}
+
+// splitSymbol 拆分为（大写）基础币种和计价币种，无法识别计价币种时默认USDT
+func splitSymbol(symbol string) (base, quote string) {
+	parts := strings.FieldsFunc(symbol, func(r rune) bool {
+		return r == '_' || r == '-' || r == '/'
+	})
+
+	// 无分隔符：只识别美元计价后缀，避免把 WBTC、STETH 之类的币名拆错
+	if len(parts) == 1 {
+		s := normalizeBase(parts[0])
+		for _, q := range usdQuotes {
+			if len(s) > len(q) && strings.HasSuffix(s, q) {
+				return s[:len(s)-len(q)], q
+			}
+		}
+		return s, "USDT"
+	}
 
-	// Backpack格式转换: ETH_USDC_PERP -> ETHUSDT
-	if strings.Contains(symbol, "_") && strings.HasSuffix(symbol, "_PERP") {
-		// 移除 _PERP 后缀
-		symbol = strings.TrimSuffix(symbol, "_PERP")
-		// 移除下划线: ETH_USDC -> ETHUSDC
-		symbol = strings.ReplaceAll(symbol, "_", "")
-		// 将 USDC 替换为 USDT (币安只有USDT交易对)
-		symbol = strings.ReplaceAll(symbol, "USDC", "USDT")
-		return symbol
+	// 去掉合约类型后缀
+	if contractSuffixes[strings.ToUpper(parts[len(parts)-1])] {
+		parts = parts[:len(parts)-1]
+	}
+	switch len(parts) {
+	case 0:
+		return "", "USDT"
+	case 1:
+		return normalizeBase(parts[0]), "USDT"
 	}
 
-	// 如果已经是币安USDT格式，直接返回
-	if strings.HasSuffix(symbol, "USDT") {
-		return symbol
+	// 多段符号：最后一段为计价币种，其余拼接为基础币种
+	quote = strings.ToUpper(parts[len(parts)-1])
+	for i, p := range parts[:len(parts)-1] {
+		if i == 0 {
+			base = normalizeBase(p)
+		} else {
+			base += strings.ToUpper(p)
+		}
 	}
+	return base, quote
+}
 
-	// 默认添加USDT后缀
-	return symbol + "USDT"
+// normalizeBase 基础币种转大写，Backpack 的千倍资产前缀 k（如 kPEPE）转换为币安的 1000
+func normalizeBase(base string) string {
+	if len(base) > 1 && base[0] == 'k' && base[1] >= 'A' && base[1] <= 'Z' {
+		return "1000" + strings.ToUpper(base[1:])
+	}
+	return strings.ToUpper(base)
+}
+
+// isUSDQuote 是否为美元计价币种
+func isUSDQuote(quote string) bool {
+	for _, q := range usdQuotes {
+		if quote == q {
+			return true
+		}
+	}
+	return false
 }
 
 // ConvertToBackpackSymbol 将币安格式转换为Backpack格式
 // ETHUSDT -> ETH_USDC_PERP
 // BTCUSDT -> BTC_USDC_PERP
+// 1000PEPEUSDT -> kPEPE_USDC_PERP
 func ConvertToBackpackSymbol(binanceSymbol string) string {
-	binanceSymbol = strings.ToUpper(binanceSymbol)
-
-	// 如果已经是Backpack格式，直接返回
-	if strings.Contains(binanceSymbol, "_") && strings.HasSuffix(binanceSymbol, "_PERP") {
+	// 已经是Backpack永续格式，直接返回（保留小写k前缀）
+	if strings.HasSuffix(binanceSymbol, "_PERP") {
 		return binanceSymbol
 	}
 
 	// 移除USDT后缀
-	baseCurrency := strings.TrimSuffix(binanceSymbol, "USDT")
+	baseCurrency := strings.TrimSuffix(Normalize(binanceSymbol), "USDT")
+	if strings.HasPrefix(baseCurrency, "1000") && len(baseCurrency) > 4 {
+		baseCurrency = "k" + strings.TrimPrefix(baseCurrency, "1000")
+	}
 
 	// 转换为Backpack永续合约格式
 	return baseCurrency + "_USDC_PERP"
@@ -500,3 +500,75 @@ func TestIsStaleData_EmptyKlines(t *testing.T) {
 		t.Error("Expected false for empty klines, got true")
 	}
 }
+
+// TestNormalize 测试多交易所symbol标准化
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		// 币安格式
+		{"BTCUSDT", "BTCUSDT"},
+		{"btcusdt", "BTCUSDT"},
+		{"1000PEPEUSDT", "1000PEPEUSDT"},
+		{"ETHUSDC", "ETHUSDT"},
+		{"ETHUSD", "ETHUSDT"},
+		{"ETHFDUSD", "ETHUSDT"},
+		// 仅有币种
+		{"eth", "ETHUSDT"},
+		{"WBTC", "WBTCUSDT"},
+		{"STETH", "STETHUSDT"},
+		// Backpack 永续与现货
+		{"ETH_USDC_PERP", "ETHUSDT"},
+		{"SOL_USDC", "SOLUSDT"},
+		{"kPEPE_USDC_PERP", "1000PEPEUSDT"},
+		{"kBONK_USDC", "1000BONKUSDT"},
+		// 多段符号
+		{"BTC_DOM_USDC_PERP", "BTCDOMUSDT"},
+		// 其他分隔符
+		{"ETH-USDT", "ETHUSDT"},
+		{"ETH/USDT", "ETHUSDT"},
+		{"ETH-PERP", "ETHUSDT"},
+		// 非美元计价保留原计价币种
+		{"ETH_BTC", "ETHBTC"},
+		{"SOL_EUR", "SOLEUR"},
+		// 异常输入
+		{"", ""},
+		{"  ", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			if got := Normalize(tt.input); got != tt.expected {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+// TestConvertToBackpackSymbol 测试转换为Backpack格式（与 Normalize 互逆）
+func TestConvertToBackpackSymbol(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"ETHUSDT", "ETH_USDC_PERP"},
+		{"eth", "ETH_USDC_PERP"},
+		{"BTC_USDC_PERP", "BTC_USDC_PERP"},
+		{"kPEPE_USDC_PERP", "kPEPE_USDC_PERP"},
+		{"1000PEPEUSDT", "kPEPE_USDC_PERP"},
+		{"sol_usdc_perp", "SOL_USDC_PERP"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got := ConvertToBackpackSymbol(tt.input)
+			if got != tt.expected {
+				t.Errorf("ConvertToBackpackSymbol(%q) = %q, want %q", tt.input, got, tt.expected)
+			}
+			if Normalize(got) != Normalize(tt.input) {
+				t.Errorf("round trip mismatch: %q -> %q", tt.input, got)
+			}
+		})
+	}
+}
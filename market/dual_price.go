@@ -0,0 +1,393 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// PriceSource 单一价格来源
+type PriceSource interface {
+	Name() string
+	GetPrice(symbol string) (float64, error)
+}
+
+// PriceSourceFunc 函数形式的价格来源
+type PriceSourceFunc struct {
+	SourceName string
+	Fn         func(symbol string) (float64, error)
+}
+
+// Name 来源名称
+func (f PriceSourceFunc) Name() string { return f.SourceName }
+
+// GetPrice 获取价格
+func (f PriceSourceFunc) GetPrice(symbol string) (float64, error) { return f.Fn(symbol) }
+
+// NewBinancePriceSource 币安合约最新成交价
+func NewBinancePriceSource() PriceSource {
+	client := NewAPIClient()
+	return PriceSourceFunc{SourceName: "binance", Fn: func(symbol string) (float64, error) {
+		return client.GetCurrentPrice(Normalize(symbol))
+	}}
+}
+
+// BackpackPriceSource Backpack 永续合约最新成交价（公开接口，无需签名）
+type BackpackPriceSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+// NewBackpackPriceSource 创建 Backpack 价格来源
+func NewBackpackPriceSource() *BackpackPriceSource {
+	return &BackpackPriceSource{
+		baseURL: "https://api.backpack.exchange",
+		client:  NewAPIClient().client,
+	}
+}
+
+// Name 来源名称
+func (s *BackpackPriceSource) Name() string { return "backpack" }
+
+// GetPrice 获取最新成交价（/api/v1/ticker）
+func (s *BackpackPriceSource) GetPrice(symbol string) (float64, error) {
+	endpoint := fmt.Sprintf("%s/api/v1/ticker?symbol=%s", s.baseURL, url.QueryEscape(ConvertToBackpackSymbol(symbol)))
+	resp, err := s.client.Get(endpoint)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("Backpack ticker 返回 %d: %s", resp.StatusCode, string(body))
+	}
+	var ticker struct {
+		LastPrice string `json:"lastPrice"`
+	}
+	if err := json.Unmarshal(body, &ticker); err != nil {
+		return 0, fmt.Errorf("解析 Backpack ticker 失败: %w", err)
+	}
+	return strconv.ParseFloat(ticker.LastPrice, 64)
+}
+
+// DualPriceConfig 双价格源配置
+type DualPriceConfig struct {
+	PollInterval          time.Duration // 轮询间隔（默认2秒）
+	StallAfter            time.Duration // 超过该时长未成功更新视为停滞（默认10秒）
+	DivergenceBps         float64       // 两个来源价差超过该值（基点）时记录日志（默认50）
+	DivergenceLogInterval time.Duration // 同一交易对价差日志的最小间隔（默认1分钟）
+}
+
+func (c DualPriceConfig) withDefaults() DualPriceConfig {
+	if c.PollInterval <= 0 {
+		c.PollInterval = 2 * time.Second
+	}
+	if c.StallAfter <= 0 {
+		c.StallAfter = 10 * time.Second
+	}
+	if c.DivergenceBps <= 0 {
+		c.DivergenceBps = 50
+	}
+	if c.DivergenceLogInterval <= 0 {
+		c.DivergenceLogInterval = time.Minute
+	}
+	return c
+}
+
+// PriceQuote 价格报价
+type PriceQuote struct {
+	Price     float64
+	Source    string
+	UpdatedAt time.Time
+	Latency   time.Duration // 获取该价格的请求耗时
+}
+
+// SourceStatus 单个来源对某交易对的状态
+type SourceStatus struct {
+	Source     string
+	Price      float64
+	UpdatedAt  time.Time
+	AvgLatency time.Duration // 请求耗时的指数移动平均
+	LastError  string
+	Stalled    bool
+}
+
+// sourceState 单个来源对某交易对的内部状态
+type sourceState struct {
+	quote      PriceQuote
+	avgLatency time.Duration
+	lastErr    error
+}
+
+// symbolPrices 某交易对在两个来源上的状态
+type symbolPrices struct {
+	sources       [2]sourceState
+	active        int // 当前使用的来源下标
+	lastDivergeAt time.Time
+}
+
+// DualPriceFeed 同一交易对跟踪两个价格来源（主/备），主来源停滞时自动切换到备用来源，
+// 两个来源都正常时记录价差
+type DualPriceFeed struct {
+	cfg     DualPriceConfig
+	sources [2]PriceSource
+	symbols map[string]*symbolPrices
+	now     func() time.Time
+	mu      sync.RWMutex
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewDualPriceFeed 创建双价格源
+func NewDualPriceFeed(primary, secondary PriceSource, cfg DualPriceConfig) *DualPriceFeed {
+	return &DualPriceFeed{
+		cfg:     cfg.withDefaults(),
+		sources: [2]PriceSource{primary, secondary},
+		symbols: make(map[string]*symbolPrices),
+		now:     time.Now,
+		stopCh:  make(chan struct{}),
+	}
+}
+
+// Track 开始跟踪交易对
+func (f *DualPriceFeed) Track(symbols ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range symbols {
+		s = Normalize(s)
+		if _, ok := f.symbols[s]; !ok {
+			f.symbols[s] = &symbolPrices{}
+		}
+	}
+}
+
+// Untrack 停止跟踪交易对
+func (f *DualPriceFeed) Untrack(symbols ...string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range symbols {
+		delete(f.symbols, Normalize(s))
+	}
+}
+
+// Start 后台按间隔轮询两个来源
+func (f *DualPriceFeed) Start() {
+	go func() {
+		ticker := time.NewTicker(f.cfg.PollInterval)
+		defer ticker.Stop()
+		for {
+			f.Poll()
+			select {
+			case <-f.stopCh:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// Stop 停止轮询
+func (f *DualPriceFeed) Stop() {
+	f.stopOnce.Do(func() { close(f.stopCh) })
+}
+
+// Poll 对所有跟踪的交易对并发请求两个来源各一次
+func (f *DualPriceFeed) Poll() {
+	f.mu.RLock()
+	symbols := make([]string, 0, len(f.symbols))
+	for s := range f.symbols {
+		symbols = append(symbols, s)
+	}
+	f.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, symbol := range symbols {
+		for i, source := range f.sources {
+			wg.Add(1)
+			go func(symbol string, i int, source PriceSource) {
+				defer wg.Done()
+				start := f.now()
+				price, err := source.GetPrice(symbol)
+				latency := f.now().Sub(start)
+				if err == nil && price <= 0 {
+					err = fmt.Errorf("无效的价格: %.8f", price)
+				}
+				f.record(symbol, i, price, latency, err)
+			}(symbol, i, source)
+		}
+	}
+	wg.Wait()
+
+	for _, symbol := range symbols {
+		f.evaluate(symbol)
+	}
+}
+
+// Update 推送某来源的最新价格（供 WebSocket 等推送式来源使用）
+func (f *DualPriceFeed) Update(source, symbol string, price float64) {
+	for i, s := range f.sources {
+		if s.Name() == source {
+			f.record(Normalize(symbol), i, price, 0, nil)
+			f.evaluate(Normalize(symbol))
+			return
+		}
+	}
+}
+
+func (f *DualPriceFeed) record(symbol string, i int, price float64, latency time.Duration, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sp, ok := f.symbols[symbol]
+	if !ok {
+		return
+	}
+	st := &sp.sources[i]
+	if err != nil {
+		st.lastErr = err
+		return
+	}
+	st.lastErr = nil
+	st.quote = PriceQuote{Price: price, Source: f.sources[i].Name(), UpdatedAt: f.now(), Latency: latency}
+	if st.avgLatency == 0 {
+		st.avgLatency = latency
+	} else {
+		st.avgLatency = (st.avgLatency*4 + latency) / 5
+	}
+}
+
+func (f *DualPriceFeed) stalledLocked(st *sourceState) bool {
+	return st.quote.UpdatedAt.IsZero() || f.now().Sub(st.quote.UpdatedAt) > f.cfg.StallAfter
+}
+
+// evaluate 切换主备来源并检查价差
+func (f *DualPriceFeed) evaluate(symbol string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sp, ok := f.symbols[symbol]
+	if !ok {
+		return
+	}
+	primary, secondary := &sp.sources[0], &sp.sources[1]
+	primaryStalled, secondaryStalled := f.stalledLocked(primary), f.stalledLocked(secondary)
+
+	// 主来源恢复后切回，主来源停滞且备用正常时切到备用
+	want := sp.active
+	switch {
+	case !primaryStalled:
+		want = 0
+	case !secondaryStalled:
+		want = 1
+	}
+	if want != sp.active {
+		from, to := f.sources[sp.active].Name(), f.sources[want].Name()
+		log.Printf("🔀 [PriceFeed] %s 价格来源切换: %s → %s", symbol, from, to)
+		sp.active = want
+	}
+
+	if primaryStalled || secondaryStalled {
+		return
+	}
+	a, b := primary.quote.Price, secondary.quote.Price
+	divergence := math.Abs(a-b) / ((a + b) / 2) * 10000
+	if divergence > f.cfg.DivergenceBps && f.now().Sub(sp.lastDivergeAt) >= f.cfg.DivergenceLogInterval {
+		sp.lastDivergeAt = f.now()
+		log.Printf("⚠️ [PriceFeed] %s 价格来源偏离 %.1fbps: %s=%.6f %s=%.6f",
+			symbol, divergence, f.sources[0].Name(), a, f.sources[1].Name(), b)
+	}
+}
+
+// Price 返回交易对当前使用的来源的价格；两个来源都停滞时返回错误
+func (f *DualPriceFeed) Price(symbol string) (PriceQuote, error) {
+	symbol = Normalize(symbol)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	sp, ok := f.symbols[symbol]
+	if !ok {
+		return PriceQuote{}, fmt.Errorf("%s 未被跟踪", symbol)
+	}
+	st := &sp.sources[sp.active]
+	if f.stalledLocked(st) {
+		return PriceQuote{}, fmt.Errorf("%s 的价格来源均已停滞", symbol)
+	}
+	return st.quote, nil
+}
+
+// Status 返回交易对在两个来源上的状态
+func (f *DualPriceFeed) Status(symbol string) []SourceStatus {
+	symbol = Normalize(symbol)
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	sp, ok := f.symbols[symbol]
+	if !ok {
+		return nil
+	}
+	out := make([]SourceStatus, 0, 2)
+	for i := range sp.sources {
+		st := &sp.sources[i]
+		status := SourceStatus{
+			Source:     f.sources[i].Name(),
+			Price:      st.quote.Price,
+			UpdatedAt:  st.quote.UpdatedAt,
+			AvgLatency: st.avgLatency,
+			Stalled:    f.stalledLocked(st),
+		}
+		if st.lastErr != nil {
+			status.LastError = st.lastErr.Error()
+		}
+		out = append(out, status)
+	}
+	return out
+}
+
+// Symbols 返回正在跟踪的交易对
+func (f *DualPriceFeed) Symbols() []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	out := make([]string, 0, len(f.symbols))
+	for s := range f.symbols {
+		out = append(out, s)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// priceFeed Get 使用的双价格源（nil 表示直接使用K线收盘价）
+var (
+	priceFeed   *DualPriceFeed
+	priceFeedMu sync.RWMutex
+)
+
+// SetPriceFeed 设置 Get 使用的双价格源：跟踪中的交易对以双价格源的当前价格作为 CurrentPrice
+func SetPriceFeed(feed *DualPriceFeed) {
+	priceFeedMu.Lock()
+	defer priceFeedMu.Unlock()
+	priceFeed = feed
+}
+
+// feedPrice 从双价格源获取价格（未设置或不可用时返回 false）
+func feedPrice(symbol string) (float64, bool) {
+	priceFeedMu.RLock()
+	feed := priceFeed
+	priceFeedMu.RUnlock()
+	if feed == nil {
+		return 0, false
+	}
+	quote, err := feed.Price(symbol)
+	if err != nil {
+		return 0, false
+	}
+	return quote.Price, true
+}
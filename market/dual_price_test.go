@@ -0,0 +1,123 @@
+package market
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakePriceSource 可控的价格来源
+type fakePriceSource struct {
+	name  string
+	mu    sync.Mutex
+	price float64
+	err   error
+}
+
+func (s *fakePriceSource) Name() string { return s.name }
+
+func (s *fakePriceSource) GetPrice(symbol string) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.price, s.err
+}
+
+func (s *fakePriceSource) set(price float64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.price, s.err = price, err
+}
+
+func TestDualPriceFeed_FailoverAndRecovery(t *testing.T) {
+	binance := &fakePriceSource{name: "binance", price: 100}
+	backpack := &fakePriceSource{name: "backpack", price: 100.2}
+	feed := NewDualPriceFeed(binance, backpack, DualPriceConfig{StallAfter: 10 * time.Second})
+	now := time.Unix(1700000000, 0)
+	feed.now = func() time.Time { return now }
+	feed.Track("BTC_USDC_PERP")
+
+	if _, err := feed.Price("BTCUSDT"); err == nil {
+		t.Fatal("尚未获取价格时应返回错误")
+	}
+
+	feed.Poll()
+	quote, err := feed.Price("BTCUSDT")
+	if err != nil || quote.Source != "binance" || quote.Price != 100 {
+		t.Fatalf("应使用主来源: %+v %v", quote, err)
+	}
+
+	// 主来源持续失败，超过停滞时间后切到备用来源
+	binance.set(0, errors.New("timeout"))
+	backpack.set(101, nil)
+	now = now.Add(5 * time.Second)
+	feed.Poll()
+	if quote, _ := feed.Price("BTCUSDT"); quote.Source != "binance" {
+		t.Fatalf("未超过停滞时间时不应切换: %+v", quote)
+	}
+	now = now.Add(6 * time.Second)
+	feed.Poll()
+	quote, err = feed.Price("BTCUSDT")
+	if err != nil || quote.Source != "backpack" || quote.Price != 101 {
+		t.Fatalf("主来源停滞后应切到备用来源: %+v %v", quote, err)
+	}
+	status := feed.Status("BTCUSDT")
+	if len(status) != 2 || !status[0].Stalled || status[0].LastError != "timeout" || status[1].Stalled {
+		t.Fatalf("来源状态不符合预期: %+v", status)
+	}
+
+	// 主来源恢复后切回
+	binance.set(101.1, nil)
+	now = now.Add(time.Second)
+	feed.Poll()
+	if quote, _ := feed.Price("BTCUSDT"); quote.Source != "binance" || quote.Price != 101.1 {
+		t.Fatalf("主来源恢复后应切回: %+v", quote)
+	}
+
+	// 两个来源都停滞
+	binance.set(0, errors.New("down"))
+	backpack.set(0, errors.New("down"))
+	now = now.Add(time.Minute)
+	feed.Poll()
+	if _, err := feed.Price("BTCUSDT"); err == nil {
+		t.Fatal("两个来源都停滞时应返回错误")
+	}
+}
+
+func TestDualPriceFeed_UpdateAndUntrack(t *testing.T) {
+	feed := NewDualPriceFeed(&fakePriceSource{name: "binance"}, &fakePriceSource{name: "backpack"}, DualPriceConfig{})
+	feed.Track("ETHUSDT")
+	feed.Update("backpack", "ETH_USDC_PERP", 3000)
+	quote, err := feed.Price("ETHUSDT")
+	if err != nil || quote.Source != "backpack" || quote.Price != 3000 {
+		t.Fatalf("推送价格应生效: %+v %v", quote, err)
+	}
+
+	feed.Untrack("ETHUSDT")
+	if len(feed.Symbols()) != 0 {
+		t.Fatalf("应已停止跟踪: %v", feed.Symbols())
+	}
+	feed.Update("backpack", "ETHUSDT", 3001) // 未跟踪的交易对忽略
+	if _, err := feed.Price("ETHUSDT"); err == nil {
+		t.Fatal("未跟踪的交易对应返回错误")
+	}
+}
+
+func TestBackpackPriceSource_GetPrice(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/ticker" || r.URL.Query().Get("symbol") != "SOL_USDC_PERP" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(`{"symbol":"SOL_USDC_PERP","lastPrice":"152.37"}`))
+	}))
+	defer server.Close()
+
+	source := &BackpackPriceSource{baseURL: server.URL, client: server.Client()}
+	price, err := source.GetPrice("SOLUSDT")
+	if err != nil || price != 152.37 {
+		t.Fatalf("GetPrice() = %v, %v", price, err)
+	}
+}
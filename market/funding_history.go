@@ -0,0 +1,301 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	spotBaseURL = "https://api.binance.com"
+
+	fundingHistoryPageLimit = 1000                   // 资金费率历史单页条数上限
+	basisKlinePageLimit     = 1000                   // 现货K线单页条数上限（合约为1500，取较小值）
+	defaultHistoryPageDelay = 250 * time.Millisecond // 翻页间隔，避免触发接口限频
+)
+
+// FundingRecord 一次资金费率结算
+type FundingRecord struct {
+	Time      int64   `json:"time"` // 结算时间（毫秒）
+	Rate      float64 `json:"rate"`
+	MarkPrice float64 `json:"markPrice"`
+}
+
+// BasisRecord 某根K线收盘时的合约/现货基差
+type BasisRecord struct {
+	Time      int64   `json:"time"` // K线开盘时间（毫秒）
+	PerpClose float64 `json:"perpClose"`
+	SpotClose float64 `json:"spotClose"`
+	BasisPct  float64 `json:"basisPct"` // (合约 - 现货) / 现货 × 100
+}
+
+// FileFundingStore 基于本地JSON文件的资金费率/基差存储（与 FileKlineStore 同一目录结构）
+type FileFundingStore struct {
+	dir string
+}
+
+// NewFileFundingStore 创建资金费率/基差存储
+func NewFileFundingStore(dir string) *FileFundingStore {
+	return &FileFundingStore{dir: dir}
+}
+
+func (s *FileFundingStore) fundingPath(symbol string) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_funding.json", strings.ToUpper(symbol)))
+}
+
+func (s *FileFundingStore) basisPath(symbol string, tf TimeFrame) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_basis_%s.json", strings.ToUpper(symbol), tf))
+}
+
+// LoadFunding 读取资金费率历史（按时间升序），没有数据时返回空切片
+func (s *FileFundingStore) LoadFunding(symbol string) ([]FundingRecord, error) {
+	var records []FundingRecord
+	if err := readJSONFile(s.fundingPath(symbol), &records); err != nil {
+		return nil, fmt.Errorf("读取资金费率历史失败: %w", err)
+	}
+	return records, nil
+}
+
+// SaveFunding 覆盖保存资金费率历史
+func (s *FileFundingStore) SaveFunding(symbol string, records []FundingRecord) error {
+	if err := writeJSONFile(s.dir, s.fundingPath(symbol), records); err != nil {
+		return fmt.Errorf("保存资金费率历史失败: %w", err)
+	}
+	return nil
+}
+
+// LoadBasis 读取基差历史（按时间升序），没有数据时返回空切片
+func (s *FileFundingStore) LoadBasis(symbol string, tf TimeFrame) ([]BasisRecord, error) {
+	var records []BasisRecord
+	if err := readJSONFile(s.basisPath(symbol, tf), &records); err != nil {
+		return nil, fmt.Errorf("读取基差历史失败: %w", err)
+	}
+	return records, nil
+}
+
+// SaveBasis 覆盖保存基差历史
+func (s *FileFundingStore) SaveBasis(symbol string, tf TimeFrame, records []BasisRecord) error {
+	if err := writeJSONFile(s.dir, s.basisPath(symbol, tf), records); err != nil {
+		return fmt.Errorf("保存基差历史失败: %w", err)
+	}
+	return nil
+}
+
+// readJSONFile 读取JSON文件，文件不存在时保持 out 不变
+func readJSONFile(path string, out interface{}) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+// writeJSONFile 先写临时文件再重命名，避免中断时留下半截文件
+func writeJSONFile(dir, path string, v interface{}) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// FundingHistoryDownloader 下载历史资金费率和合约/现货基差，支持断点续传
+type FundingHistoryDownloader struct {
+	client     *http.Client
+	futuresURL string
+	spotURL    string
+	store      *FileFundingStore
+	basisTF    TimeFrame
+	pageDelay  time.Duration
+}
+
+// NewFundingHistoryDownloader 创建下载器，basisTF 为基差采样周期
+func NewFundingHistoryDownloader(store *FileFundingStore, basisTF TimeFrame) (*FundingHistoryDownloader, error) {
+	if _, ok := BinanceIntervalMap[basisTF]; !ok {
+		return nil, fmt.Errorf("不支持的基差周期: %s", basisTF)
+	}
+	return &FundingHistoryDownloader{
+		client:     &http.Client{Timeout: 30 * time.Second},
+		futuresURL: baseURL,
+		spotURL:    spotBaseURL,
+		store:      store,
+		basisTF:    basisTF,
+		pageDelay:  defaultHistoryPageDelay,
+	}, nil
+}
+
+// Download 下载 since 以来的资金费率和基差，已有数据时从最后一条之后继续
+// 返回本次新增的资金费率和基差条数
+func (d *FundingHistoryDownloader) Download(symbol string, since time.Time) (int, int, error) {
+	symbol = Normalize(symbol)
+
+	funding, err := d.store.LoadFunding(symbol)
+	if err != nil {
+		return 0, 0, err
+	}
+	start := since.UnixMilli()
+	if n := len(funding); n > 0 && funding[n-1].Time >= start {
+		start = funding[n-1].Time + 1
+	}
+	newFunding, err := d.fetchFunding(symbol, start)
+	if err != nil {
+		return 0, 0, fmt.Errorf("%s 下载资金费率失败: %w", symbol, err)
+	}
+	if len(newFunding) > 0 {
+		if err := d.store.SaveFunding(symbol, append(funding, newFunding...)); err != nil {
+			return 0, 0, err
+		}
+	}
+
+	basis, err := d.store.LoadBasis(symbol, d.basisTF)
+	if err != nil {
+		return len(newFunding), 0, err
+	}
+	start = since.UnixMilli()
+	if n := len(basis); n > 0 && basis[n-1].Time >= start {
+		start = basis[n-1].Time + 1
+	}
+	newBasis, err := d.fetchBasis(symbol, start)
+	if err != nil {
+		return len(newFunding), 0, fmt.Errorf("%s 下载基差失败: %w", symbol, err)
+	}
+	if len(newBasis) > 0 {
+		if err := d.store.SaveBasis(symbol, d.basisTF, append(basis, newBasis...)); err != nil {
+			return len(newFunding), 0, err
+		}
+	}
+	return len(newFunding), len(newBasis), nil
+}
+
+// fetchFunding 从 start 开始分页拉取资金费率历史
+func (d *FundingHistoryDownloader) fetchFunding(symbol string, start int64) ([]FundingRecord, error) {
+	var records []FundingRecord
+	for {
+		var page []struct {
+			FundingTime int64  `json:"fundingTime"`
+			FundingRate string `json:"fundingRate"`
+			MarkPrice   string `json:"markPrice"`
+		}
+		query := url.Values{
+			"symbol":    {symbol},
+			"startTime": {strconv.FormatInt(start, 10)},
+			"limit":     {strconv.Itoa(fundingHistoryPageLimit)},
+		}
+		if err := d.getJSON(d.futuresURL+"/fapi/v1/fundingRate", query, &page); err != nil {
+			return nil, err
+		}
+		for _, item := range page {
+			rate, _ := strconv.ParseFloat(item.FundingRate, 64)
+			mark, _ := strconv.ParseFloat(item.MarkPrice, 64)
+			records = append(records, FundingRecord{Time: item.FundingTime, Rate: rate, MarkPrice: mark})
+		}
+		if len(page) < fundingHistoryPageLimit {
+			return records, nil
+		}
+		start = page[len(page)-1].FundingTime + 1
+		time.Sleep(d.pageDelay)
+	}
+}
+
+// fetchBasis 从 start 开始分页拉取合约和现货K线，按开盘时间对齐计算基差
+// 最后一根K线仍在形成中，不写入
+func (d *FundingHistoryDownloader) fetchBasis(symbol string, start int64) ([]BasisRecord, error) {
+	interval := BinanceIntervalMap[d.basisTF]
+	now := time.Now().UnixMilli()
+
+	var records []BasisRecord
+	for {
+		perp, err := d.fetchKlines(d.futuresURL+"/fapi/v1/klines", symbol, interval, start)
+		if err != nil {
+			return nil, err
+		}
+		spot, err := d.fetchKlines(d.spotURL+"/api/v3/klines", symbol, interval, start)
+		if err != nil {
+			return nil, err
+		}
+
+		spotClose := make(map[int64]float64, len(spot))
+		for _, k := range spot {
+			spotClose[k.OpenTime] = k.Close
+		}
+		for _, k := range perp {
+			s, ok := spotClose[k.OpenTime]
+			if !ok || s <= 0 || k.CloseTime >= now {
+				continue
+			}
+			records = append(records, BasisRecord{
+				Time:      k.OpenTime,
+				PerpClose: k.Close,
+				SpotClose: s,
+				BasisPct:  (k.Close - s) / s * 100,
+			})
+		}
+
+		if len(perp) < basisKlinePageLimit {
+			return records, nil
+		}
+		start = perp[len(perp)-1].OpenTime + 1
+		time.Sleep(d.pageDelay)
+	}
+}
+
+// fetchKlines 拉取一页K线
+func (d *FundingHistoryDownloader) fetchKlines(endpoint, symbol, interval string, start int64) ([]Kline, error) {
+	var raw []KlineResponse
+	query := url.Values{
+		"symbol":    {symbol},
+		"interval":  {interval},
+		"startTime": {strconv.FormatInt(start, 10)},
+		"limit":     {strconv.Itoa(basisKlinePageLimit)},
+	}
+	if err := d.getJSON(endpoint, query, &raw); err != nil {
+		return nil, err
+	}
+	klines := make([]Kline, 0, len(raw))
+	for _, kr := range raw {
+		kline, err := parseKline(kr)
+		if err != nil {
+			log.Printf("解析K线数据失败: %v", err)
+			continue
+		}
+		klines = append(klines, kline)
+	}
+	return klines, nil
+}
+
+// getJSON 请求公开接口并解析JSON响应
+func (d *FundingHistoryDownloader) getJSON(endpoint string, query url.Values, out interface{}) error {
+	resp, err := d.client.Get(endpoint + "?" + query.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s 返回 %d: %s", endpoint, resp.StatusCode, string(body))
+	}
+	return json.Unmarshal(body, out)
+}
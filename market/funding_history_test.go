@@ -0,0 +1,100 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newFundingHistoryServer 模拟币安资金费率和K线接口，按 startTime 过滤返回
+func newFundingHistoryServer(t *testing.T, fundingTimes, klineTimes []int64, spotOffset float64) (*httptest.Server, *[]int64) {
+	t.Helper()
+	var fundingStarts []int64
+	hour := int64(time.Hour / time.Millisecond)
+	klines := func(w http.ResponseWriter, start int64, offset float64) {
+		var out [][]interface{}
+		for _, ts := range klineTimes {
+			if ts < start {
+				continue
+			}
+			c := fmt.Sprint(100 + offset)
+			out = append(out, []interface{}{ts, c, c, c, c, "1", ts + hour - 1, "100", 1, "0.5", "50"})
+		}
+		json.NewEncoder(w).Encode(out)
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start, _ := strconv.ParseInt(r.URL.Query().Get("startTime"), 10, 64)
+		switch r.URL.Path {
+		case "/fapi/v1/fundingRate":
+			fundingStarts = append(fundingStarts, start)
+			var out []map[string]interface{}
+			for _, ts := range fundingTimes {
+				if ts >= start {
+					out = append(out, map[string]interface{}{"symbol": "BTCUSDT", "fundingTime": ts, "fundingRate": "0.0001", "markPrice": "100"})
+				}
+			}
+			json.NewEncoder(w).Encode(out)
+		case "/fapi/v1/klines":
+			klines(w, start, 0)
+		case "/api/v3/klines":
+			klines(w, start, spotOffset)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &fundingStarts
+}
+
+func TestFundingHistoryDownloader_DownloadAndResume(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	base := int64(1700000000000)
+	fundingTimes := []int64{base, base + 8*hour}
+	klineTimes := []int64{base, base + hour, base + 2*hour}
+	srv, fundingStarts := newFundingHistoryServer(t, fundingTimes, klineTimes, -1)
+
+	store := NewFileFundingStore(t.TempDir())
+	d, err := NewFundingHistoryDownloader(store, TimeFrame1h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.futuresURL, d.spotURL = srv.URL, srv.URL
+
+	nf, nb, err := d.Download("BTCUSDT", time.UnixMilli(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nf != 2 || nb != 3 {
+		t.Fatalf("首次下载条数 = %d/%d, 期望 2/3", nf, nb)
+	}
+	basis, _ := store.LoadBasis("BTCUSDT", TimeFrame1h)
+	if want := 1.0 / 99 * 100; basis[0].BasisPct < want-1e-9 || basis[0].BasisPct > want+1e-9 {
+		t.Fatalf("基差 = %v, 期望 %v", basis[0].BasisPct, want)
+	}
+
+	// 再次下载：从最后一条之后继续，不重复写入
+	nf, nb, err = d.Download("BTCUSDT", time.UnixMilli(base))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if nf != 0 || nb != 0 {
+		t.Fatalf("续传下载条数 = %d/%d, 期望 0/0", nf, nb)
+	}
+	if got := (*fundingStarts)[1]; got != base+8*hour+1 {
+		t.Fatalf("续传起点 = %d, 期望 %d", got, base+8*hour+1)
+	}
+	funding, _ := store.LoadFunding("BTCUSDT")
+	if len(funding) != 2 || funding[1].Rate != 0.0001 {
+		t.Fatalf("资金费率历史 = %+v", funding)
+	}
+}
+
+func TestNewFundingHistoryDownloader_InvalidTimeFrame(t *testing.T) {
+	if _, err := NewFundingHistoryDownloader(NewFileFundingStore(t.TempDir()), TimeFrame("2h")); err == nil {
+		t.Fatal("不支持的周期应返回错误")
+	}
+}
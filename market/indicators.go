@@ -0,0 +1,246 @@
+package market
+
+import "math"
+
+// CalculateATR 计算ATR（Wilder平滑），K线不足时返回0
+func CalculateATR(klines []Kline, period int) float64 {
+	return calculateATR(klines, period)
+}
+
+// CalculateRSI 计算RSI（Wilder平滑），K线不足时返回0
+func CalculateRSI(klines []Kline, period int) float64 {
+	return calculateRSI(klines, period)
+}
+
+// CalculateBollinger 计算布林带（以最近 period 根收盘价的均值为中轨，numStdDev 倍总体标准差为带宽）
+// K线不足时全部返回0
+func CalculateBollinger(klines []Kline, period int, numStdDev float64) (upper, middle, lower float64) {
+	if period <= 0 || len(klines) < period {
+		return 0, 0, 0
+	}
+	window := klines[len(klines)-period:]
+	for _, k := range window {
+		middle += k.Close
+	}
+	middle /= float64(period)
+
+	var variance float64
+	for _, k := range window {
+		variance += (k.Close - middle) * (k.Close - middle)
+	}
+	std := math.Sqrt(variance / float64(period))
+	return middle + numStdDev*std, middle, middle - numStdDev*std
+}
+
+// CalculateROC 计算变动率 ROC = (最新收盘价 - period根前收盘价) / period根前收盘价 × 100
+// K线不足或基准价为0时返回0
+func CalculateROC(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < period+1 {
+		return 0
+	}
+	base := klines[len(klines)-1-period].Close
+	if base == 0 {
+		return 0
+	}
+	return (klines[len(klines)-1].Close - base) / base * 100
+}
+
+// CalculateMomentum 计算动量 = 最新收盘价 - period根前收盘价，K线不足时返回0
+func CalculateMomentum(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < period+1 {
+		return 0
+	}
+	return klines[len(klines)-1].Close - klines[len(klines)-1-period].Close
+}
+
+// CalculateWilliamsR 计算威廉指标 %R（-100 到 0，低于-80超卖，高于-20超买）
+// K线不足时返回0；区间无波动时返回-50
+func CalculateWilliamsR(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < period {
+		return 0
+	}
+	window := klines[len(klines)-period:]
+	high, low := window[0].High, window[0].Low
+	for _, k := range window[1:] {
+		high = math.Max(high, k.High)
+		low = math.Min(low, k.Low)
+	}
+	if high == low {
+		return -50
+	}
+	return (high - window[len(window)-1].Close) / (high - low) * -100
+}
+
+// CalculateRealizedVolatility 计算最近 period 个收盘价对数收益的样本标准差（单根K线周期，未年化）
+// K线不足或价格无效时返回0
+func CalculateRealizedVolatility(klines []Kline, period int) float64 {
+	if period < 2 || len(klines) < period+1 {
+		return 0
+	}
+	window := klines[len(klines)-period-1:]
+	returns := make([]float64, 0, period)
+	var mean float64
+	for i := 1; i < len(window); i++ {
+		if window[i-1].Close <= 0 || window[i].Close <= 0 {
+			return 0
+		}
+		r := math.Log(window[i].Close / window[i-1].Close)
+		returns = append(returns, r)
+		mean += r
+	}
+	mean /= float64(len(returns))
+
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	return math.Sqrt(variance / float64(len(returns)-1))
+}
+
+// CalculateBeta 计算资产相对基准的贝塔（按开盘时间对齐的对数收益：cov(资产, 基准) / var(基准)）
+// 对齐后的收益少于2个或基准无波动时返回0
+func CalculateBeta(asset, benchmark []Kline) float64 {
+	benchClose := make(map[int64]float64, len(benchmark))
+	for _, k := range benchmark {
+		benchClose[k.OpenTime] = k.Close
+	}
+
+	var assetReturns, benchReturns []float64
+	for i := 1; i < len(asset); i++ {
+		prevBench, ok1 := benchClose[asset[i-1].OpenTime]
+		curBench, ok2 := benchClose[asset[i].OpenTime]
+		if !ok1 || !ok2 || prevBench <= 0 || curBench <= 0 || asset[i-1].Close <= 0 || asset[i].Close <= 0 {
+			continue
+		}
+		assetReturns = append(assetReturns, math.Log(asset[i].Close/asset[i-1].Close))
+		benchReturns = append(benchReturns, math.Log(curBench/prevBench))
+	}
+	n := len(assetReturns)
+	if n < 2 {
+		return 0
+	}
+
+	var meanA, meanB float64
+	for i := 0; i < n; i++ {
+		meanA += assetReturns[i]
+		meanB += benchReturns[i]
+	}
+	meanA /= float64(n)
+	meanB /= float64(n)
+
+	var cov, variance float64
+	for i := 0; i < n; i++ {
+		cov += (assetReturns[i] - meanA) * (benchReturns[i] - meanB)
+		variance += (benchReturns[i] - meanB) * (benchReturns[i] - meanB)
+	}
+	if variance == 0 {
+		return 0
+	}
+	return cov / variance
+}
+
+// CalculateADX 计算ADX（Wilder平滑的趋势强度指标，0-100）
+// 需要至少 2*period+1 根K线，不足时返回0
+func CalculateADX(klines []Kline, period int) float64 {
+	if period <= 0 || len(klines) < 2*period+1 {
+		return 0
+	}
+
+	// 计算每根K线的TR、+DM、-DM
+	n := len(klines)
+	trs := make([]float64, n)
+	plusDM := make([]float64, n)
+	minusDM := make([]float64, n)
+	for i := 1; i < n; i++ {
+		high, low, prevClose := klines[i].High, klines[i].Low, klines[i-1].Close
+		trs[i] = math.Max(high-low, math.Max(math.Abs(high-prevClose), math.Abs(low-prevClose)))
+
+		up := high - klines[i-1].High
+		down := klines[i-1].Low - low
+		if up > down && up > 0 {
+			plusDM[i] = up
+		}
+		if down > up && down > 0 {
+			minusDM[i] = down
+		}
+	}
+
+	// 初始平滑值
+	var smTR, smPlus, smMinus float64
+	for i := 1; i <= period; i++ {
+		smTR += trs[i]
+		smPlus += plusDM[i]
+		smMinus += minusDM[i]
+	}
+
+	dx := func() float64 {
+		if smTR == 0 {
+			return 0
+		}
+		plusDI := 100 * smPlus / smTR
+		minusDI := 100 * smMinus / smTR
+		if plusDI+minusDI == 0 {
+			return 0
+		}
+		return 100 * math.Abs(plusDI-minusDI) / (plusDI + minusDI)
+	}
+
+	// 前 period 个DX的均值作为初始ADX，之后Wilder平滑
+	p := float64(period)
+	dxSum, count := dx(), 1
+	var adx float64
+	ready := count == period
+	if ready {
+		adx = dxSum
+	}
+	for i := period + 1; i < n; i++ {
+		smTR = smTR - smTR/p + trs[i]
+		smPlus = smPlus - smPlus/p + plusDM[i]
+		smMinus = smMinus - smMinus/p + minusDM[i]
+
+		if ready {
+			adx = (adx*(p-1) + dx()) / p
+			continue
+		}
+		dxSum += dx()
+		count++
+		if count == period {
+			adx = dxSum / p
+			ready = true
+		}
+	}
+	return adx
+}
+
+// RangeBreakout 区间突破
+type RangeBreakout struct {
+	Direction string  // "long"（向上突破）或 "short"（向下突破）
+	RangeHigh float64 // 区间上沿
+	RangeLow  float64 // 区间下沿
+	Close     float64 // 突破K线收盘价
+}
+
+// DetectRangeBreakout 检测最新一根K线是否收盘突破此前 lookback 根K线的高低点区间
+// 未突破或K线不足时返回 nil
+func DetectRangeBreakout(klines []Kline, lookback int) *RangeBreakout {
+	if lookback <= 0 || len(klines) < lookback+1 {
+		return nil
+	}
+
+	last := klines[len(klines)-1]
+	window := klines[len(klines)-1-lookback : len(klines)-1]
+	high, low := window[0].High, window[0].Low
+	for _, k := range window[1:] {
+		high = math.Max(high, k.High)
+		low = math.Min(low, k.Low)
+	}
+
+	switch {
+	case last.Close > high:
+		return &RangeBreakout{Direction: "long", RangeHigh: high, RangeLow: low, Close: last.Close}
+	case last.Close < low:
+		return &RangeBreakout{Direction: "short", RangeHigh: high, RangeLow: low, Close: last.Close}
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,217 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+// trendKlines 生成每根上涨 step 的K线（step=0 时横盘震荡）
+func trendKlines(count int, start, step float64) []Kline {
+	klines := make([]Kline, count)
+	price := start
+	for i := range klines {
+		wiggle := 0.5
+		if step == 0 && i%2 == 1 {
+			wiggle = -0.5
+		}
+		open := price
+		close := price + step + wiggle*0.2
+		klines[i] = Kline{
+			OpenTime: int64(i) * 60000,
+			Open:     open,
+			High:     max(open, close) + 0.3,
+			Low:      min(open, close) - 0.3,
+			Close:    close,
+			Volume:   100,
+		}
+		price += step
+	}
+	return klines
+}
+
+func TestCalculateADX(t *testing.T) {
+	if adx := CalculateADX(trendKlines(10, 100, 1), 14); adx != 0 {
+		t.Errorf("K线不足时应返回0，实际 %.2f", adx)
+	}
+
+	trending := CalculateADX(trendKlines(60, 100, 1), 14)
+	ranging := CalculateADX(trendKlines(60, 100, 0), 14)
+	if trending < 25 {
+		t.Errorf("单边趋势 ADX 应 >= 25，实际 %.2f", trending)
+	}
+	if ranging >= trending {
+		t.Errorf("震荡行情 ADX (%.2f) 应低于趋势行情 (%.2f)", ranging, trending)
+	}
+	if trending > 100 || ranging < 0 {
+		t.Errorf("ADX 超出 [0,100]: %.2f / %.2f", trending, ranging)
+	}
+}
+
+func TestDetectRangeBreakout(t *testing.T) {
+	klines := trendKlines(21, 100, 0)
+	if b := DetectRangeBreakout(klines, 20); b != nil {
+		t.Fatalf("横盘不应判定突破: %+v", b)
+	}
+
+	up := append(klines[:20:20], Kline{Open: 100, High: 105, Low: 100, Close: 104})
+	if b := DetectRangeBreakout(up, 20); b == nil || b.Direction != "long" {
+		t.Fatalf("应判定向上突破: %+v", b)
+	}
+
+	down := append(klines[:20:20], Kline{Open: 100, High: 100, Low: 95, Close: 96})
+	if b := DetectRangeBreakout(down, 20); b == nil || b.Direction != "short" {
+		t.Fatalf("应判定向下突破: %+v", b)
+	}
+
+	if b := DetectRangeBreakout(klines[:5], 20); b != nil {
+		t.Fatalf("K线不足时应返回nil")
+	}
+}
+
+func TestCalculateROCAndMomentum(t *testing.T) {
+	klines := trendKlines(20, 100, 1)
+	base := klines[len(klines)-11].Close
+	last := klines[len(klines)-1].Close
+
+	if got, want := CalculateMomentum(klines, 10), last-base; got != want {
+		t.Errorf("动量应为 %.4f，实际 %.4f", want, got)
+	}
+	if got, want := CalculateROC(klines, 10), (last-base)/base*100; got != want {
+		t.Errorf("ROC应为 %.4f，实际 %.4f", want, got)
+	}
+	if CalculateROC(trendKlines(20, 100, -1), 10) >= 0 {
+		t.Error("下跌行情 ROC 应为负")
+	}
+	if CalculateROC(klines[:5], 10) != 0 || CalculateMomentum(klines[:5], 10) != 0 {
+		t.Error("K线不足时应返回0")
+	}
+}
+
+func TestCalculateRealizedVolatility(t *testing.T) {
+	// 收盘价交替 +1%/-1%：对数收益绝对值相同，标准差接近 1%
+	closes := []float64{100}
+	for i := 0; i < 20; i++ {
+		if i%2 == 0 {
+			closes = append(closes, closes[len(closes)-1]*1.01)
+		} else {
+			closes = append(closes, closes[len(closes)-1]/1.01)
+		}
+	}
+	klines := make([]Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = Kline{Close: c}
+	}
+
+	vol := CalculateRealizedVolatility(klines, 20)
+	if vol < 0.0099 || vol > 0.0104 {
+		t.Errorf("波动率应接近 1%%，实际 %.5f", vol)
+	}
+	flat := make([]Kline, 12)
+	for i := range flat {
+		flat[i] = Kline{Close: 100}
+	}
+	if CalculateRealizedVolatility(flat, 10) != 0 {
+		t.Error("价格不变时波动率应为0")
+	}
+	if CalculateRealizedVolatility(klines[:5], 20) != 0 {
+		t.Error("K线不足时应返回0")
+	}
+}
+
+func TestCalculateBeta(t *testing.T) {
+	// 资产收益恒为基准收益的2倍（对数收益）
+	bench := []Kline{{OpenTime: 1, Close: 100}}
+	asset := []Kline{{OpenTime: 1, Close: 50}}
+	for i := 2; i <= 20; i++ {
+		r := 0.01
+		if i%3 == 0 {
+			r = -0.015
+		}
+		prevB, prevA := bench[len(bench)-1].Close, asset[len(asset)-1].Close
+		bench = append(bench, Kline{OpenTime: int64(i), Close: prevB * math.Exp(r)})
+		asset = append(asset, Kline{OpenTime: int64(i), Close: prevA * math.Exp(2*r)})
+	}
+
+	if beta := CalculateBeta(asset, bench); math.Abs(beta-2) > 1e-9 {
+		t.Errorf("贝塔应为2，实际 %.6f", beta)
+	}
+	if beta := CalculateBeta(bench, bench); math.Abs(beta-1) > 1e-9 {
+		t.Errorf("基准自身贝塔应为1，实际 %.6f", beta)
+	}
+	// 时间不重叠
+	if CalculateBeta([]Kline{{OpenTime: 100, Close: 1}, {OpenTime: 101, Close: 2}}, bench) != 0 {
+		t.Error("无法对齐时应返回0")
+	}
+}
+
+func TestCalculateWilliamsR(t *testing.T) {
+	klines := []Kline{
+		{High: 110, Low: 100, Close: 105},
+		{High: 120, Low: 105, Close: 118},
+		{High: 115, Low: 102, Close: 104},
+	}
+	// 区间 [100, 120]，收盘104：(120-104)/20 × -100 = -80
+	if got := CalculateWilliamsR(klines, 3); got != -80 {
+		t.Errorf("%%R 应为 -80，实际 %.2f", got)
+	}
+	if got := CalculateWilliamsR([]Kline{{High: 1, Low: 1, Close: 1}}, 1); got != -50 {
+		t.Errorf("无波动时应返回 -50，实际 %.2f", got)
+	}
+	if got := CalculateWilliamsR(klines, 5); got != 0 {
+		t.Errorf("K线不足时应返回0，实际 %.2f", got)
+	}
+}
+
+// newTestDetector 创建使用给定K线的信号检测器
+func newTestDetector(symbol string, tf TimeFrame, klines []Kline) *SignalDetector {
+	mtk := newMultiTimeFrameKline(symbol, len(klines))
+	mtk.mergeLocked(tf, klines)
+	kc := &KlineCache{cache: map[string]*MultiTimeFrameKline{symbol: mtk}}
+	return &SignalDetector{cache: kc}
+}
+
+func TestDetectWilliamsR(t *testing.T) {
+	// 持续下跌进入超卖，最后一根大阳线收回区间上方
+	klines := trendKlines(15, 130, -2)
+	last := klines[len(klines)-1]
+	klines = append(klines, Kline{OpenTime: last.OpenTime + 60000, Open: last.Close, High: last.Close + 8, Low: last.Close - 0.5, Close: last.Close + 7.5})
+
+	signals := newTestDetector("BTCUSDT", TimeFrame5m, klines).DetectWilliamsR("BTCUSDT", TimeFrame5m)
+	if len(signals) != 1 || signals[0].Direction != "long" || signals[0].SignalType != SignalWilliamsR {
+		t.Fatalf("应产生做多的威廉指标信号: %+v", signals)
+	}
+
+	flat := newTestDetector("BTCUSDT", TimeFrame5m, trendKlines(16, 100, 0))
+	if signals := flat.DetectWilliamsR("BTCUSDT", TimeFrame5m); len(signals) != 0 {
+		t.Fatalf("横盘不应产生信号: %+v", signals[0])
+	}
+}
+
+func TestDetectMomentum(t *testing.T) {
+	// 下跌后反转上涨，ROC 由负转正
+	klines := trendKlines(14, 120, -1)
+	last := klines[len(klines)-1]
+	rising := trendKlines(14, last.Close, 1.5)
+	for i := range rising {
+		rising[i].OpenTime = last.OpenTime + int64(i+1)*60000
+	}
+	klines = append(klines, rising...)
+
+	var found bool
+	for n := rocPeriod + 2; n <= len(klines); n++ {
+		signals := newTestDetector("ETHUSDT", TimeFrame15m, klines[:n]).DetectMomentum("ETHUSDT", TimeFrame15m)
+		if len(signals) == 0 {
+			continue
+		}
+		if found {
+			t.Fatalf("零轴只应穿越一次，第%d根再次触发", n)
+		}
+		if signals[0].Direction != "long" {
+			t.Fatalf("应为做多信号: %+v", signals[0])
+		}
+		found = true
+	}
+	if !found {
+		t.Fatal("ROC 由负转正时应产生做多信号")
+	}
+}
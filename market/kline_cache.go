@@ -1,8 +1,10 @@
 package market
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 )
@@ -39,18 +41,52 @@ var BinanceIntervalMap = map[TimeFrame]string{
 	TimeFrame1d:  "1d",
 }
 
+// AllTimeFrames 缓存维护的全部时间周期（按从小到大排序）
+var AllTimeFrames = []TimeFrame{TimeFrame5m, TimeFrame15m, TimeFrame30m, TimeFrame1h, TimeFrame4h, TimeFrame1d}
+
+const (
+	defaultUpdateWorkers = 8  // 批量更新时默认的并发worker数量
+	defaultMaxKlines     = 20 // 每个周期默认保留的K线数量
+)
+
 // MultiTimeFrameKline 多周期K线数据缓存
+// 每个交易对独立加锁，每个周期的K线存放在固定容量的环形缓冲区中，
+// 读取时拷贝快照，读者拿到的切片与后续更新互不影响
 type MultiTimeFrameKline struct {
-	Symbol string
-	Data   map[TimeFrame][]Kline // 每个周期的K线数据
-	mu     sync.RWMutex
+	Symbol      string
+	series      map[TimeFrame]*klineRing // 每个周期的K线数据
+	lastFetched map[TimeFrame]time.Time  // 每个周期最近一次成功拉取的时间
+	fetchErrors map[TimeFrame]int        // 每个周期累计拉取失败次数
+	lastError   map[TimeFrame]string     // 每个周期最近一次拉取失败的原因
+	capacity    int                      // 每个周期保留的K线数量
+	mu          sync.RWMutex
+}
+
+// newMultiTimeFrameKline 创建交易对的多周期缓存
+func newMultiTimeFrameKline(symbol string, capacity int) *MultiTimeFrameKline {
+	if capacity <= 0 {
+		capacity = defaultMaxKlines
+	}
+	return &MultiTimeFrameKline{
+		Symbol:      symbol,
+		series:      make(map[TimeFrame]*klineRing),
+		lastFetched: make(map[TimeFrame]time.Time),
+		fetchErrors: make(map[TimeFrame]int),
+		lastError:   make(map[TimeFrame]string),
+		capacity:    capacity,
+	}
 }
 
 // KlineCache 全局K线缓存
 type KlineCache struct {
-	cache  map[string]*MultiTimeFrameKline // key: symbol
-	client *APIClient
-	mu     sync.RWMutex
+	cache   map[string]*MultiTimeFrameKline // key: symbol
+	client  *APIClient
+	workers int                // 批量更新时的最大并发数
+	planner *RefreshPlanner    // 增量刷新计划器
+	store   KlineStore         // K线持久化存储（可选，用于启动预热）
+	onClose CandleCloseHandler // K线收盘回调（可选）
+	source  CandleSource       // K线价格来源（空为 last）
+	mu      sync.RWMutex
 }
 
 var (
@@ -61,132 +97,454 @@ var (
 // GetKlineCache 获取全局K线缓存实例
 func GetKlineCache() *KlineCache {
 	once.Do(func() {
-		globalKlineCache = &KlineCache{
-			cache:  make(map[string]*MultiTimeFrameKline),
-			client: NewAPIClient(),
-		}
+		globalKlineCache = NewKlineCache()
 	})
 	return globalKlineCache
 }
 
-// InitSymbol 初始化某个交易对的多周期K线数据
-func (kc *KlineCache) InitSymbol(symbol string, maxKlines int) error {
+// NewKlineCache 创建独立的K线缓存（不与全局实例共享，用于合成行情和回放）
+func NewKlineCache() *KlineCache {
+	return &KlineCache{
+		cache:   make(map[string]*MultiTimeFrameKline),
+		client:  NewAPIClient(),
+		workers: defaultUpdateWorkers,
+		planner: NewRefreshPlanner(),
+	}
+}
+
+// AddSymbol 登记交易对但不拉取历史K线，K线通过 Ingest 写入
+func (kc *KlineCache) AddSymbol(symbol string, maxKlines int) {
 	kc.mu.Lock()
 	defer kc.mu.Unlock()
+	if _, exists := kc.cache[symbol]; !exists {
+		kc.cache[symbol] = newMultiTimeFrameKline(symbol, maxKlines)
+	}
+}
 
-	if _, exists := kc.cache[symbol]; exists {
-		log.Printf("✓ [KlineCache] %s 已初始化，跳过", symbol)
-		return nil
+// Ingest 把外部来源（合成行情、推送等）的K线合并到缓存，与增量更新使用相同的合并和收盘事件逻辑
+func (kc *KlineCache) Ingest(symbol string, tf TimeFrame, klines []Kline) error {
+	if _, ok := TimeFrameMinutes[tf]; !ok {
+		return fmt.Errorf("不支持的周期: %s", tf)
 	}
+	mtk := kc.getSymbol(symbol)
+	if mtk == nil {
+		return fmt.Errorf("symbol %s not initialized", symbol)
+	}
+
+	kc.mu.RLock()
+	onClose := kc.onClose
+	kc.mu.RUnlock()
 
-	mtk := &MultiTimeFrameKline{
-		Symbol: symbol,
-		Data:   make(map[TimeFrame][]Kline),
+	var events []CandleClose
+	mtk.mu.Lock()
+	closed := mtk.mergeLocked(tf, klines)
+	mtk.lastFetched[tf] = time.Now()
+	if onClose != nil {
+		events = mtk.candleClosesLocked(tf, closed)
 	}
+	mtk.mu.Unlock()
 
-	// 为每个时间周期获取初始K线数据
-	timeFrames := []TimeFrame{TimeFrame5m, TimeFrame15m, TimeFrame30m, TimeFrame1h, TimeFrame4h, TimeFrame1d}
+	for _, event := range events {
+		onClose(event)
+	}
+	return nil
+}
 
-	for _, tf := range timeFrames {
-		interval := BinanceIntervalMap[tf]
-		klines, err := kc.client.GetKlines(symbol, interval, maxKlines)
+// InitSymbol 初始化某个交易对的多周期K线数据
+// 网络请求在锁外执行，全局锁只用于登记交易对
+func (kc *KlineCache) InitSymbol(symbol string, maxKlines int) error {
+	if kc.getSymbol(symbol) != nil {
+		log.Printf("✓ [KlineCache] %s 已初始化，跳过", symbol)
+		return nil
+	}
+
+	mtk := newMultiTimeFrameKline(symbol, maxKlines)
+	store := kc.getStore()
+
+	// 为每个时间周期获取初始K线数据：先从存储预热，再只拉取缺失的部分
+	for _, tf := range AllTimeFrames {
+		limit := mtk.capacity
+		if store != nil {
+			persisted, err := store.Load(symbol, tf, mtk.capacity)
+			if err != nil {
+				log.Printf("⚠️ [KlineCache] 读取 %s %s 持久化K线失败: %v", symbol, tf, err)
+			} else if n := len(persisted); n > 0 {
+				mtk.mergeLocked(tf, persisted)
+				limit = deltaKlineLimit(persisted[n-1].OpenTime, tf, time.Now(), mtk.capacity)
+			}
+		}
+
+		klines, err := kc.fetchKlines(symbol, tf, limit)
 		if err != nil {
 			log.Printf("⚠️ [KlineCache] 获取 %s %s K线失败: %v", symbol, tf, err)
+			mtk.recordFetchErrorLocked(tf, err)
 			continue
 		}
 
-		mtk.Data[tf] = klines
-		log.Printf("✓ [KlineCache] 加载 %s %s: %d根K线", symbol, tf, len(klines))
+		mtk.mergeLocked(tf, klines)
+		mtk.lastFetched[tf] = time.Now()
+		cached := 0
+		if ring := mtk.series[tf]; ring != nil {
+			cached = ring.Len()
+		}
+		log.Printf("✓ [KlineCache] 加载 %s %s: %d根K线 (拉取 %d 根)", symbol, tf, cached, len(klines))
 	}
 
-	kc.cache[symbol] = mtk
+	kc.mu.Lock()
+	defer kc.mu.Unlock()
+	// 并发初始化时以先完成者为准
+	if _, exists := kc.cache[symbol]; !exists {
+		kc.cache[symbol] = mtk
+	}
 	return nil
 }
 
-// UpdateSymbol 更新某个交易对的K线数据（增量更新）
-func (kc *KlineCache) UpdateSymbol(symbol string) error {
+// SetStore 设置K线持久化存储（nil 表示关闭持久化）
+func (kc *KlineCache) SetStore(store KlineStore) {
 	kc.mu.Lock()
-	defer kc.mu.Unlock()
+	kc.store = store
+	kc.mu.Unlock()
+}
 
-	mtk, exists := kc.cache[symbol]
-	if !exists {
-		return fmt.Errorf("symbol %s not initialized", symbol)
+// getStore 当前的持久化存储
+func (kc *KlineCache) getStore() KlineStore {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	return kc.store
+}
+
+// SetCandleCloseHandler 设置K线收盘回调（nil 表示关闭），只在增量更新中发现新收盘K线时触发
+func (kc *KlineCache) SetCandleCloseHandler(handler CandleCloseHandler) {
+	kc.mu.Lock()
+	kc.onClose = handler
+	kc.mu.Unlock()
+}
+
+// PersistAll 将全部交易对的K线写入持久化存储（未设置存储时不做任何事）
+func (kc *KlineCache) PersistAll() error {
+	store := kc.getStore()
+	if store == nil {
+		return nil
 	}
 
-	mtk.mu.Lock()
-	defer mtk.mu.Unlock()
+	kc.mu.RLock()
+	symbols := make([]*MultiTimeFrameKline, 0, len(kc.cache))
+	for _, mtk := range kc.cache {
+		symbols = append(symbols, mtk)
+	}
+	kc.mu.RUnlock()
+
+	var errs []error
+	for _, mtk := range symbols {
+		mtk.mu.RLock()
+		snapshots := make(map[TimeFrame][]Kline, len(mtk.series))
+		for tf, ring := range mtk.series {
+			snapshots[tf] = ring.Snapshot(0)
+		}
+		mtk.mu.RUnlock()
 
-	// 更新每个时间周期的K线数据
-	timeFrames := []TimeFrame{TimeFrame5m, TimeFrame15m, TimeFrame30m, TimeFrame1h, TimeFrame4h, TimeFrame1d}
+		// 合并而不是覆盖，保留 nofx download 下载的更早的历史K线
+		for tf, klines := range snapshots {
+			if _, err := MergeKlines(store, mtk.Symbol, tf, klines); err != nil {
+				errs = append(errs, fmt.Errorf("%s %s: %w", mtk.Symbol, tf, err))
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	log.Printf("💾 [KlineCache] 已持久化 %d 个交易对的K线", len(symbols))
+	return nil
+}
 
-	for _, tf := range timeFrames {
-		interval := BinanceIntervalMap[tf]
+// RemoveSymbol 停止缓存某个交易对（已设置存储时先持久化，重新加入时可以预热）
+// 返回该交易对此前是否在缓存中
+func (kc *KlineCache) RemoveSymbol(symbol string) bool {
+	kc.mu.Lock()
+	mtk, exists := kc.cache[symbol]
+	delete(kc.cache, symbol)
+	store := kc.store
+	kc.mu.Unlock()
 
-		// 只获取最新的2根K线（最后一根可能还在形成中）
-		newKlines, err := kc.client.GetKlines(symbol, interval, 2)
-		if err != nil {
-			log.Printf("⚠️ [KlineCache] 更新 %s %s K线失败: %v", symbol, tf, err)
-			continue
+	if !exists {
+		return false
+	}
+
+	if store != nil {
+		mtk.mu.RLock()
+		snapshots := make(map[TimeFrame][]Kline, len(mtk.series))
+		for tf, ring := range mtk.series {
+			snapshots[tf] = ring.Snapshot(0)
 		}
+		mtk.mu.RUnlock()
 
-		if len(newKlines) == 0 {
-			continue
+		// 与 PersistAll 一样合并写入，不能覆盖 nofx download 下载的历史
+		for tf, klines := range snapshots {
+			if _, err := MergeKlines(store, symbol, tf, klines); err != nil {
+				log.Printf("⚠️ [KlineCache] 持久化 %s %s K线失败: %v", symbol, tf, err)
+			}
 		}
+	}
+	log.Printf("🗑️ [KlineCache] 已移除 %s", symbol)
+	return true
+}
 
-		existingKlines := mtk.Data[tf]
-		if len(existingKlines) == 0 {
-			mtk.Data[tf] = newKlines
+// ListSymbols 当前缓存的交易对（按字母排序）
+func (kc *KlineCache) ListSymbols() []string {
+	kc.mu.RLock()
+	symbols := make([]string, 0, len(kc.cache))
+	for symbol := range kc.cache {
+		symbols = append(symbols, symbol)
+	}
+	kc.mu.RUnlock()
+
+	sort.Strings(symbols)
+	return symbols
+}
+
+// getSymbol 查找交易对缓存（仅短暂持有全局读锁）
+func (kc *KlineCache) getSymbol(symbol string) *MultiTimeFrameKline {
+	kc.mu.RLock()
+	defer kc.mu.RUnlock()
+	return kc.cache[symbol]
+}
+
+// SetUpdateWorkers 设置批量更新时的最大并发数（<=0 时使用默认值）
+func (kc *KlineCache) SetUpdateWorkers(n int) {
+	if n <= 0 {
+		n = defaultUpdateWorkers
+	}
+	kc.mu.Lock()
+	kc.workers = n
+	kc.mu.Unlock()
+}
+
+// UpdateSymbols 使用有界worker池并发更新多个交易对的全部时间周期
+// 返回每个更新失败的交易对及其错误
+func (kc *KlineCache) UpdateSymbols(symbols []string) map[string]error {
+	tasks := make([]RefreshTask, 0, len(symbols))
+	for _, symbol := range symbols {
+		tasks = append(tasks, RefreshTask{Symbol: symbol, TimeFrames: AllTimeFrames})
+	}
+	return kc.runRefreshTasks(tasks)
+}
+
+// RefreshSymbols 按刷新计划增量更新多个交易对
+// 只拉取K线可能已变化的时间周期，并受单轮请求权重预算限制，超出预算的部分留到下一轮
+func (kc *KlineCache) RefreshSymbols(symbols []string) map[string]error {
+	states := make(map[string]map[TimeFrame]time.Time, len(symbols))
+	errs := make(map[string]error)
+	for _, symbol := range symbols {
+		mtk := kc.getSymbol(symbol)
+		if mtk == nil {
+			errs[symbol] = fmt.Errorf("symbol %s not initialized", symbol)
 			continue
 		}
+		mtk.mu.RLock()
+		fetched := make(map[TimeFrame]time.Time, len(mtk.lastFetched))
+		for tf, t := range mtk.lastFetched {
+			fetched[tf] = t
+		}
+		mtk.mu.RUnlock()
+		states[symbol] = fetched
+	}
+
+	kc.mu.RLock()
+	planner := kc.planner
+	kc.mu.RUnlock()
 
-		// 检查最后一根K线是否已完成
-		lastExisting := existingKlines[len(existingKlines)-1]
-		lastNew := newKlines[len(newKlines)-1]
-
-		if lastNew.OpenTime > lastExisting.OpenTime {
-			// 新K线已生成，追加到数组
-			mtk.Data[tf] = append(existingKlines, newKlines...)
-			log.Printf("🔄 [KlineCache] %s %s: 新增K线 (时间: %s)",
-				symbol, tf, time.UnixMilli(lastNew.OpenTime).Format("15:04"))
-		} else {
-			// 更新最后一根K线（仍在形成中）
-			existingKlines[len(existingKlines)-1] = lastNew
+	tasks := planner.Plan(states, time.Now())
+	for symbol, err := range kc.runRefreshTasks(tasks) {
+		errs[symbol] = err
+	}
+	return errs
+}
+
+// SetRefreshPlanner 替换增量刷新计划器
+func (kc *KlineCache) SetRefreshPlanner(planner *RefreshPlanner) {
+	if planner == nil {
+		planner = NewRefreshPlanner()
+	}
+	kc.mu.Lock()
+	kc.planner = planner
+	kc.mu.Unlock()
+}
+
+// runRefreshTasks 使用有界worker池执行刷新任务
+func (kc *KlineCache) runRefreshTasks(tasks []RefreshTask) map[string]error {
+	kc.mu.RLock()
+	workers := kc.workers
+	kc.mu.RUnlock()
+	if workers <= 0 {
+		workers = defaultUpdateWorkers
+	}
+	if workers > len(tasks) {
+		workers = len(tasks)
+	}
+
+	errs := make(map[string]error)
+	var errMu sync.Mutex
+
+	jobs := make(chan RefreshTask)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for task := range jobs {
+				if err := kc.updateTimeFrames(task.Symbol, task.TimeFrames); err != nil {
+					errMu.Lock()
+					errs[task.Symbol] = err
+					errMu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, task := range tasks {
+		jobs <- task
+	}
+	close(jobs)
+	wg.Wait()
+
+	return errs
+}
+
+// timeFrameUpdate 单个时间周期的拉取结果
+type timeFrameUpdate struct {
+	tf     TimeFrame
+	klines []Kline
+	err    error
+}
+
+// UpdateSymbol 更新某个交易对全部时间周期的K线数据（增量更新）
+func (kc *KlineCache) UpdateSymbol(symbol string) error {
+	return kc.updateTimeFrames(symbol, AllTimeFrames)
+}
+
+// updateTimeFrames 更新某个交易对指定时间周期的K线数据
+// 各时间周期并发拉取，网络请求期间不持有任何锁
+func (kc *KlineCache) updateTimeFrames(symbol string, timeFrames []TimeFrame) error {
+	mtk := kc.getSymbol(symbol)
+	if mtk == nil {
+		return fmt.Errorf("symbol %s not initialized", symbol)
+	}
+
+	// 从缓存的最后一根K线（可能还在形成中）算起补齐：计划器因权重预算跳过若干周期后，
+	// 只拉取最新2根会在环形缓冲中留下永久缺口
+	limits := make(map[TimeFrame]int, len(timeFrames))
+	start := time.Now()
+	mtk.mu.RLock()
+	for _, tf := range timeFrames {
+		limit := mtk.capacity // 还没有K线（初始化拉取失败）时拉满
+		if ring := mtk.series[tf]; ring != nil {
+			if last, ok := ring.Last(); ok {
+				limit = deltaKlineLimit(last.OpenTime, tf, start, mtk.capacity)
+			}
 		}
+		limits[tf] = limit
+	}
+	mtk.mu.RUnlock()
 
-		// 保持K线数量不超过限制（保留最新的20根）
-		maxKeep := 20
-		if len(mtk.Data[tf]) > maxKeep {
-			mtk.Data[tf] = mtk.Data[tf][len(mtk.Data[tf])-maxKeep:]
+	// 并发拉取每个时间周期
+	results := make(chan timeFrameUpdate, len(timeFrames))
+	var wg sync.WaitGroup
+	for _, tf := range timeFrames {
+		wg.Add(1)
+		go func(tf TimeFrame) {
+			defer wg.Done()
+			klines, err := kc.fetchKlines(symbol, tf, limits[tf])
+			results <- timeFrameUpdate{tf: tf, klines: klines, err: err}
+		}(tf)
+	}
+	wg.Wait()
+	close(results)
+
+	kc.mu.RLock()
+	onClose := kc.onClose
+	kc.mu.RUnlock()
+
+	// 合并结果时才加锁
+	var events []CandleClose
+	mtk.mu.Lock()
+	now := time.Now()
+	for res := range results {
+		if res.err != nil {
+			log.Printf("⚠️ [KlineCache] 更新 %s %s K线失败: %v", symbol, res.tf, res.err)
+			mtk.recordFetchErrorLocked(res.tf, res.err)
+			continue
+		}
+		closed := mtk.mergeLocked(res.tf, res.klines)
+		mtk.lastFetched[res.tf] = now
+		if onClose != nil {
+			events = append(events, mtk.candleClosesLocked(res.tf, closed)...)
 		}
 	}
+	mtk.mu.Unlock()
 
+	// 回调在锁外执行
+	for _, event := range events {
+		onClose(event)
+	}
 	return nil
 }
 
-// GetKlines 获取指定交易对和时间周期的K线数据
-func (kc *KlineCache) GetKlines(symbol string, timeFrame TimeFrame, limit int) ([]Kline, error) {
-	kc.mu.RLock()
-	defer kc.mu.RUnlock()
+// mergeLocked 将新拉取的K线合并到缓存（调用方需持有 mtk.mu 写锁）
+// 返回因后续K线出现而确认收盘的K线
+func (mtk *MultiTimeFrameKline) mergeLocked(tf TimeFrame, newKlines []Kline) []Kline {
+	if len(newKlines) == 0 {
+		return nil
+	}
 
-	mtk, exists := kc.cache[symbol]
+	ring, exists := mtk.series[tf]
 	if !exists {
+		ring = newKlineRing(mtk.capacity)
+		mtk.series[tf] = ring
+	}
+
+	lastExisting, hasExisting := ring.Last()
+	appended := false
+	var closed []Kline
+	for _, k := range newKlines {
+		switch {
+		case !hasExisting || k.OpenTime > lastExisting.OpenTime:
+			if hasExisting {
+				closed = append(closed, lastExisting)
+			}
+			ring.Push(k)
+			lastExisting, hasExisting = k, true
+			appended = true
+		case k.OpenTime == lastExisting.OpenTime:
+			// 更新最后一根K线（仍在形成中或刚刚收盘）
+			ring.ReplaceLast(k)
+			lastExisting = k
+		}
+	}
+
+	if appended {
+		log.Printf("🔄 [KlineCache] %s %s: 新增K线 (时间: %s)",
+			mtk.Symbol, tf, time.UnixMilli(lastExisting.OpenTime).Format("15:04"))
+	}
+	return closed
+}
+
+// GetKlines 获取指定交易对和时间周期的K线数据（返回独立拷贝）
+func (kc *KlineCache) GetKlines(symbol string, timeFrame TimeFrame, limit int) ([]Kline, error) {
+	mtk := kc.getSymbol(symbol)
+	if mtk == nil {
 		return nil, fmt.Errorf("symbol %s not initialized", symbol)
 	}
 
 	mtk.mu.RLock()
 	defer mtk.mu.RUnlock()
 
-	klines, exists := mtk.Data[timeFrame]
+	ring, exists := mtk.series[timeFrame]
 	if !exists {
 		return nil, fmt.Errorf("timeframe %s not found for %s", timeFrame, symbol)
 	}
 
 	// 返回最新的limit根K线
-	if len(klines) <= limit {
-		return klines, nil
-	}
-
-	return klines[len(klines)-limit:], nil
+	return ring.Snapshot(limit), nil
 }
 
 // GetLatestKline 获取最新的一根K线
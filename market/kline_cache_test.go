@@ -0,0 +1,358 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func newTestMTK() *MultiTimeFrameKline {
+	return newMultiTimeFrameKline("BTCUSDT", 20)
+}
+
+func TestMergeLocked_UpdatesFormingKline(t *testing.T) {
+	mtk := newTestMTK()
+	mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: 1, Close: 100}, {OpenTime: 2, Close: 101}})
+
+	mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: 1, Close: 100}, {OpenTime: 2, Close: 105}})
+
+	klines := mtk.series[TimeFrame5m].Snapshot(0)
+	if len(klines) != 2 {
+		t.Fatalf("expected 2 klines, got %d", len(klines))
+	}
+	if klines[1].Close != 105 {
+		t.Errorf("expected forming kline close 105, got %.2f", klines[1].Close)
+	}
+}
+
+func TestMergeLocked_AppendsWithoutDuplicates(t *testing.T) {
+	mtk := newTestMTK()
+	mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: 1, Close: 100}, {OpenTime: 2, Close: 101}})
+
+	// 新一根K线生成：返回 [2(已完成), 3(形成中)]
+	mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: 2, Close: 102}, {OpenTime: 3, Close: 103}})
+
+	klines := mtk.series[TimeFrame5m].Snapshot(0)
+	if len(klines) != 3 {
+		t.Fatalf("expected 3 klines, got %d", len(klines))
+	}
+	for i, want := range []int64{1, 2, 3} {
+		if klines[i].OpenTime != want {
+			t.Errorf("kline %d: expected OpenTime %d, got %d", i, want, klines[i].OpenTime)
+		}
+	}
+	if klines[1].Close != 102 {
+		t.Errorf("expected finalized kline close 102, got %.2f", klines[1].Close)
+	}
+}
+
+func TestMergeLocked_TrimsToCapacity(t *testing.T) {
+	mtk := newTestMTK()
+	initial := make([]Kline, 20)
+	for i := range initial {
+		initial[i] = Kline{OpenTime: int64(i + 1)}
+	}
+	mtk.mergeLocked(TimeFrame1h, initial)
+	mtk.mergeLocked(TimeFrame1h, []Kline{{OpenTime: 20}, {OpenTime: 21}})
+
+	klines := mtk.series[TimeFrame1h].Snapshot(0)
+	if len(klines) != 20 {
+		t.Fatalf("expected 20 klines, got %d", len(klines))
+	}
+	if klines[0].OpenTime != 2 || klines[19].OpenTime != 21 {
+		t.Errorf("unexpected window: first=%d last=%d", klines[0].OpenTime, klines[19].OpenTime)
+	}
+}
+
+func TestKlineRing_SnapshotIsIndependent(t *testing.T) {
+	ring := newKlineRing(3)
+	ring.Push(Kline{OpenTime: 1, Close: 100})
+	ring.Push(Kline{OpenTime: 2, Close: 101})
+
+	snapshot := ring.Snapshot(0)
+	ring.ReplaceLast(Kline{OpenTime: 2, Close: 150})
+
+	if snapshot[1].Close != 101 {
+		t.Errorf("snapshot was mutated: close=%.2f", snapshot[1].Close)
+	}
+	if last, _ := ring.Last(); last.Close != 150 {
+		t.Errorf("expected last close 150, got %.2f", last.Close)
+	}
+}
+
+func TestKlineRing_WrapAround(t *testing.T) {
+	ring := newKlineRing(3)
+	for i := int64(1); i <= 5; i++ {
+		ring.Push(Kline{OpenTime: i})
+	}
+
+	if ring.Len() != 3 {
+		t.Fatalf("expected len 3, got %d", ring.Len())
+	}
+	klines := ring.Snapshot(0)
+	for i, want := range []int64{3, 4, 5} {
+		if klines[i].OpenTime != want {
+			t.Errorf("kline %d: expected OpenTime %d, got %d", i, want, klines[i].OpenTime)
+		}
+	}
+
+	latest := ring.Snapshot(2)
+	if len(latest) != 2 || latest[0].OpenTime != 4 || latest[1].OpenTime != 5 {
+		t.Errorf("unexpected limited snapshot: %+v", latest)
+	}
+}
+
+func TestRefreshPlanner_NeedsRefresh(t *testing.T) {
+	p := NewRefreshPlanner()
+	base := time.Date(2025, 1, 1, 10, 1, 0, 0, time.UTC)
+
+	if !p.NeedsRefresh(TimeFrame1d, time.Time{}, base) {
+		t.Error("never fetched timeframe should need refresh")
+	}
+	// 1d 周期刷新间隔被限制为15分钟，刚拉取过不需要刷新
+	if p.NeedsRefresh(TimeFrame1d, base, base.Add(10*time.Second)) {
+		t.Error("1d should not refresh after 10s")
+	}
+	if !p.NeedsRefresh(TimeFrame1d, base, base.Add(16*time.Minute)) {
+		t.Error("1d should refresh after max interval")
+	}
+	// 5m 周期跨过K线边界必须刷新
+	if !p.NeedsRefresh(TimeFrame5m, base.Add(3*time.Minute+50*time.Second), base.Add(4*time.Minute+5*time.Second)) {
+		t.Error("5m should refresh after crossing candle boundary")
+	}
+}
+
+func TestRefreshPlanner_PlanRespectsBudgetAndStaleness(t *testing.T) {
+	p := NewRefreshPlanner()
+	p.WeightBudget = 8
+	now := time.Date(2025, 1, 1, 10, 1, 0, 0, time.UTC)
+
+	fresh := make(map[TimeFrame]time.Time)
+	for _, tf := range AllTimeFrames {
+		fresh[tf] = now.Add(-5 * time.Second)
+	}
+	states := map[string]map[TimeFrame]time.Time{
+		"FRESHUSDT": fresh,
+		"NEWUSDT":   {},
+		"OLDUSDT":   {TimeFrame5m: now.Add(-10 * time.Minute)},
+	}
+
+	tasks := p.Plan(states, now)
+	if len(tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d: %+v", len(tasks), tasks)
+	}
+	if tasks[0].Symbol != "NEWUSDT" || len(tasks[0].TimeFrames) != len(AllTimeFrames) {
+		t.Errorf("expected never-fetched symbol first with all timeframes, got %+v", tasks[0])
+	}
+	if tasks[1].Symbol != "OLDUSDT" || len(tasks[1].TimeFrames) != 2 {
+		t.Errorf("expected OLDUSDT truncated to remaining budget, got %+v", tasks[1])
+	}
+}
+
+func TestFileKlineStore_RoundTrip(t *testing.T) {
+	store := NewFileKlineStore(t.TempDir())
+
+	klines, err := store.Load("BTCUSDT", TimeFrame1h, 10)
+	if err != nil || len(klines) != 0 {
+		t.Fatalf("expected empty load for missing file, got %d klines, err=%v", len(klines), err)
+	}
+
+	saved := []Kline{{OpenTime: 1, Close: 100}, {OpenTime: 2, Close: 101}, {OpenTime: 3, Close: 102}}
+	if err := store.Save("BTCUSDT", TimeFrame1h, saved); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	klines, err = store.Load("BTCUSDT", TimeFrame1h, 2)
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if len(klines) != 2 || klines[0].OpenTime != 2 || klines[1].Close != 102 {
+		t.Errorf("expected the latest 2 klines, got %+v", klines)
+	}
+}
+
+func TestDeltaKlineLimit(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC)
+	hourAgo := now.Add(-90 * time.Minute).UnixMilli()
+
+	tests := []struct {
+		name     string
+		lastOpen int64
+		tf       TimeFrame
+		want     int
+	}{
+		{"仍在形成中", now.Add(-10 * time.Minute).UnixMilli(), TimeFrame1h, refreshKlineLimit},
+		{"缺一根", hourAgo, TimeFrame1h, 2},
+		{"缺多根", hourAgo, TimeFrame5m, 19},
+		{"超过容量", now.Add(-24 * time.Hour).UnixMilli(), TimeFrame5m, 20},
+	}
+	for _, tt := range tests {
+		if got := deltaKlineLimit(tt.lastOpen, tt.tf, now, 20); got != tt.want {
+			t.Errorf("%s: expected %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestMetricsAndStaleness(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 30, 0, 0, time.UTC)
+	mtk := newTestMTK()
+	// 1h: 最新一根是形成中的K线；5m: 最新一根已收盘超过一个周期
+	mtk.mergeLocked(TimeFrame1h, []Kline{
+		{OpenTime: now.Add(-90 * time.Minute).UnixMilli()},
+		{OpenTime: now.Add(-30 * time.Minute).UnixMilli()},
+	})
+	mtk.lastFetched[TimeFrame1h] = now.Add(-time.Minute)
+	mtk.mergeLocked(TimeFrame5m, []Kline{{OpenTime: now.Add(-20 * time.Minute).UnixMilli()}})
+	mtk.recordFetchErrorLocked(TimeFrame5m, errors.New("timeout"))
+	mtk.recordFetchErrorLocked(TimeFrame5m, errors.New("429"))
+
+	kc := &KlineCache{cache: map[string]*MultiTimeFrameKline{"BTCUSDT": mtk}}
+
+	metrics := kc.metricsAt(now)
+	if len(metrics) != 2 {
+		t.Fatalf("expected metrics for 2 timeframes, got %d", len(metrics))
+	}
+
+	m5, m1h := metrics[0], metrics[1]
+	if m5.TimeFrame != TimeFrame5m || m1h.TimeFrame != TimeFrame1h {
+		t.Fatalf("expected metrics ordered by timeframe, got %s, %s", m5.TimeFrame, m1h.TimeFrame)
+	}
+	if m5.CandleAge != 15*time.Minute || !m5.Stale {
+		t.Errorf("5m: expected age 15m and stale, got %v stale=%v", m5.CandleAge, m5.Stale)
+	}
+	if m5.FetchErrors != 2 || m5.LastError != "429" {
+		t.Errorf("5m: expected 2 fetch errors with last '429', got %d %q", m5.FetchErrors, m5.LastError)
+	}
+	if m1h.CandleAge != 0 || m1h.Stale || m1h.Size != 2 || m1h.Capacity != 20 {
+		t.Errorf("1h: unexpected metrics %+v", m1h)
+	}
+	if !m1h.LastUpdate.Equal(now.Add(-time.Minute)) {
+		t.Errorf("1h: expected last update %v, got %v", now.Add(-time.Minute), m1h.LastUpdate)
+	}
+
+	if !kc.isStaleAt("BTCUSDT", TimeFrame5m, now) || kc.isStaleAt("BTCUSDT", TimeFrame1h, now) {
+		t.Error("expected 5m stale and 1h fresh")
+	}
+	if !kc.isStaleAt("BTCUSDT", TimeFrame4h, now) || !kc.isStaleAt("ETHUSDT", TimeFrame1h, now) {
+		t.Error("expected missing timeframe and unknown symbol to be stale")
+	}
+}
+
+func TestRemoveAndListSymbols(t *testing.T) {
+	store := NewFileKlineStore(t.TempDir())
+	btc := newMultiTimeFrameKline("BTCUSDT", 20)
+	btc.mergeLocked(TimeFrame1h, []Kline{{OpenTime: 2, Close: 100}, {OpenTime: 3, Close: 101}})
+	// nofx download 已下载的更早历史
+	if err := store.Save("BTCUSDT", TimeFrame1h, []Kline{{OpenTime: 1, Close: 99}, {OpenTime: 2, Close: 99.5}}); err != nil {
+		t.Fatal(err)
+	}
+	kc := &KlineCache{
+		cache: map[string]*MultiTimeFrameKline{
+			"ETHUSDT": newMultiTimeFrameKline("ETHUSDT", 20),
+			"BTCUSDT": btc,
+		},
+		store: store,
+	}
+
+	if got := kc.ListSymbols(); len(got) != 2 || got[0] != "BTCUSDT" || got[1] != "ETHUSDT" {
+		t.Fatalf("expected sorted [BTCUSDT ETHUSDT], got %v", got)
+	}
+	if !kc.RemoveSymbol("BTCUSDT") {
+		t.Fatal("expected BTCUSDT to be removed")
+	}
+	if kc.RemoveSymbol("BTCUSDT") {
+		t.Error("removing twice should report false")
+	}
+	if got := kc.ListSymbols(); len(got) != 1 || got[0] != "ETHUSDT" {
+		t.Errorf("expected [ETHUSDT], got %v", got)
+	}
+	if _, err := kc.GetKlines("BTCUSDT", TimeFrame1h, 0); err == nil {
+		t.Error("removed symbol should no longer be readable")
+	}
+
+	// 移除前已合并持久化（保留已下载的历史），重新加入时可以预热
+	klines, err := store.Load("BTCUSDT", TimeFrame1h, 10)
+	if err != nil || len(klines) != 3 {
+		t.Fatalf("expected 3 persisted klines, got %d (err=%v)", len(klines), err)
+	}
+	if klines[0].OpenTime != 1 || klines[1].Close != 100 || klines[2].OpenTime != 3 {
+		t.Errorf("expected downloaded history kept and cached klines merged, got %+v", klines)
+	}
+}
+
+// handlerTransport 把 APIClient 的请求直接交给 handler（baseURL 是常量，无法指向测试服务器）
+type handlerTransport struct{ h http.Handler }
+
+func (t handlerTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.h.ServeHTTP(rec, r)
+	return rec.Result(), nil
+}
+
+func TestUpdateTimeFrames_CatchesUpSkippedPeriods(t *testing.T) {
+	period := 5 * time.Minute
+	var limits []int
+	client := &APIClient{client: &http.Client{Transport: handlerTransport{http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		limits = append(limits, limit)
+		// 返回截至形成中K线的最近 limit 根
+		current := time.Now().Truncate(period)
+		var out [][]interface{}
+		for i := limit - 1; i >= 0; i-- {
+			open := current.Add(-time.Duration(i) * period).UnixMilli()
+			out = append(out, []interface{}{open, "100", "101", "99", "100", "1", open + period.Milliseconds() - 1, "100", 1, "0.5", "50"})
+		}
+		json.NewEncoder(w).Encode(out)
+	})}}}
+
+	// 上次拉取时形成中的K线在6个周期前，之后计划器因权重预算一直跳过该周期
+	mtk := newMultiTimeFrameKline("BTCUSDT", 20)
+	current := time.Now().Truncate(period)
+	mtk.mergeLocked(TimeFrame5m, []Kline{
+		{OpenTime: current.Add(-7 * period).UnixMilli()},
+		{OpenTime: current.Add(-6 * period).UnixMilli()},
+	})
+	kc := &KlineCache{cache: map[string]*MultiTimeFrameKline{"BTCUSDT": mtk}, client: client}
+
+	if err := kc.updateTimeFrames("BTCUSDT", []TimeFrame{TimeFrame5m}); err != nil {
+		t.Fatal(err)
+	}
+	if len(limits) != 1 || limits[0] < 7 {
+		t.Fatalf("expected to fetch at least 7 klines to cover skipped periods, got %v", limits)
+	}
+	klines := mtk.series[TimeFrame5m].Snapshot(0)
+	for i := 1; i < len(klines); i++ {
+		if gap := klines[i].OpenTime - klines[i-1].OpenTime; gap != period.Milliseconds() {
+			t.Fatalf("gap of %dms between kline %d and %d", gap, i-1, i)
+		}
+	}
+	if len(klines) < 8 {
+		t.Errorf("expected cached history extended to the forming kline, got %d klines", len(klines))
+	}
+}
+
+func TestRefreshPlanner_WeightsCatchUpFetches(t *testing.T) {
+	now := time.Date(2025, 1, 1, 10, 1, 0, 0, time.UTC)
+	if w := refreshWeight(TimeFrame5m, now.Add(-10*time.Minute), now); w != 1 {
+		t.Errorf("recent refresh weight = %d, want 1", w)
+	}
+	// 跳过了 600 个周期，需要补齐 601 根，按 limit<=1000 计权重5
+	if w := refreshWeight(TimeFrame5m, now.Add(-600*5*time.Minute), now); w != 5 {
+		t.Errorf("catch-up refresh weight = %d, want 5", w)
+	}
+
+	p := NewRefreshPlanner()
+	p.WeightBudget = 4
+	states := map[string]map[TimeFrame]time.Time{
+		"BTCUSDT": {TimeFrame5m: now.Add(-600 * 5 * time.Minute)},
+	}
+	for _, tf := range AllTimeFrames[1:] {
+		states["BTCUSDT"][tf] = now.Add(-5 * time.Second)
+	}
+	if tasks := p.Plan(states, now); len(tasks) != 0 {
+		t.Errorf("catch-up fetch exceeding the budget should wait for the next round, got %+v", tasks)
+	}
+}
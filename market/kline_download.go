@@ -0,0 +1,222 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	klineDownloadPageLimit         = 1500 // 合约K线单页条数上限
+	defaultDownloadWeightPerMinute = 1200 // 默认每分钟请求权重上限（币安为2400，留一半给运行中的交易系统）
+	maxDownloadRetries             = 3    // 限频（429/418）时的最大重试次数
+)
+
+// KlineDownloader 批量下载历史K线到K线存储，支持断点续传
+// 每下载一页就合并写入存储，中断后重新执行会从已有数据之后继续；已有数据之前缺失的部分也会补齐
+type KlineDownloader struct {
+	client          *http.Client
+	futuresURL      string
+	store           KlineStore
+	pageDelay       time.Duration
+	weightPerMinute int
+
+	windowStart  time.Time // 当前权重统计窗口的开始时间
+	windowWeight int       // 当前窗口已使用的权重
+	now          func() time.Time
+	sleep        func(time.Duration)
+}
+
+// NewKlineDownloader 创建K线下载器
+func NewKlineDownloader(store KlineStore) *KlineDownloader {
+	return &KlineDownloader{
+		client:          &http.Client{Timeout: 30 * time.Second},
+		futuresURL:      baseURL,
+		store:           store,
+		pageDelay:       defaultHistoryPageDelay,
+		weightPerMinute: defaultDownloadWeightPerMinute,
+		now:             time.Now,
+		sleep:           time.Sleep,
+	}
+}
+
+// SetWeightPerMinute 设置每分钟请求权重上限（<=0 表示不限制）
+func (d *KlineDownloader) SetWeightPerMinute(weight int) {
+	d.weightPerMinute = weight
+}
+
+// Download 下载 since 以来某交易对某周期的K线，返回本次新增的K线数量
+// 形成中的最后一根K线不写入
+func (d *KlineDownloader) Download(symbol string, tf TimeFrame, since time.Time) (int, error) {
+	symbol = Normalize(symbol)
+	interval, ok := BinanceIntervalMap[tf]
+	if !ok {
+		return 0, fmt.Errorf("不支持的时间周期: %s", tf)
+	}
+
+	existing, err := d.store.Load(symbol, tf, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	start := since.UnixMilli()
+	end := d.now().UnixMilli()
+	added := 0
+	save := func(page []Kline) error {
+		n, err := MergeKlines(d.store, symbol, tf, page)
+		added += n
+		return err
+	}
+
+	if n := len(existing); n > 0 {
+		// 补齐已有数据之前缺失的部分，再从最后一根之后续传
+		if first := existing[0].OpenTime; first > start {
+			if err := d.fetchRange(symbol, interval, start, first, save); err != nil {
+				return added, fmt.Errorf("%s %s 下载K线失败: %w", symbol, tf, err)
+			}
+		}
+		if last := existing[n-1].OpenTime; last >= start {
+			start = last + 1
+		}
+	}
+	if err := d.fetchRange(symbol, interval, start, end, save); err != nil {
+		return added, fmt.Errorf("%s %s 下载K线失败: %w", symbol, tf, err)
+	}
+	return added, nil
+}
+
+// fetchRange 分页拉取 [start, end) 内已收盘的K线，每页回调一次
+func (d *KlineDownloader) fetchRange(symbol, interval string, start, end int64, onPage func([]Kline) error) error {
+	now := d.now().UnixMilli()
+	for start < end {
+		page, err := d.fetchPage(symbol, interval, start)
+		if err != nil {
+			return err
+		}
+
+		var closed []Kline
+		for _, k := range page {
+			if k.OpenTime >= end || k.CloseTime >= now {
+				break
+			}
+			closed = append(closed, k)
+		}
+		if len(closed) > 0 {
+			if err := onPage(closed); err != nil {
+				return err
+			}
+		}
+		if len(page) < klineDownloadPageLimit || len(closed) < len(page) {
+			return nil
+		}
+		start = page[len(page)-1].OpenTime + 1
+		d.sleep(d.pageDelay)
+	}
+	return nil
+}
+
+// fetchPage 拉取一页K线，超过每分钟权重上限时等待，限频时按 Retry-After 重试
+func (d *KlineDownloader) fetchPage(symbol, interval string, start int64) ([]Kline, error) {
+	query := url.Values{
+		"symbol":    {symbol},
+		"interval":  {interval},
+		"startTime": {strconv.FormatInt(start, 10)},
+		"limit":     {strconv.Itoa(klineDownloadPageLimit)},
+	}
+	endpoint := d.futuresURL + "/fapi/v1/klines?" + query.Encode()
+
+	for attempt := 0; ; attempt++ {
+		d.acquireWeight(KlineRequestWeight(klineDownloadPageLimit))
+		resp, err := d.client.Get(endpoint)
+		if err != nil {
+			return nil, err
+		}
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if (resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot) && attempt < maxDownloadRetries {
+			wait := time.Minute
+			if seconds, err := strconv.Atoi(resp.Header.Get("Retry-After")); err == nil && seconds > 0 {
+				wait = time.Duration(seconds) * time.Second
+			}
+			log.Printf("⏳ [KlineDownloader] %s 触发限频 (%d)，%v 后重试", symbol, resp.StatusCode, wait)
+			d.sleep(wait)
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("K线接口返回 %d: %s", resp.StatusCode, string(body))
+		}
+
+		var raw []KlineResponse
+		if err := json.Unmarshal(body, &raw); err != nil {
+			return nil, fmt.Errorf("解析K线失败: %w", err)
+		}
+		klines := make([]Kline, 0, len(raw))
+		for _, kr := range raw {
+			kline, err := parseKline(kr)
+			if err != nil {
+				log.Printf("解析K线数据失败: %v", err)
+				continue
+			}
+			klines = append(klines, kline)
+		}
+		return klines, nil
+	}
+}
+
+// acquireWeight 按固定1分钟窗口统计请求权重，超出上限时等到下一个窗口
+func (d *KlineDownloader) acquireWeight(weight int) {
+	if d.weightPerMinute <= 0 {
+		return
+	}
+	now := d.now()
+	if now.Sub(d.windowStart) >= time.Minute {
+		d.windowStart, d.windowWeight = now, 0
+	}
+	if d.windowWeight+weight > d.weightPerMinute {
+		wait := time.Minute - now.Sub(d.windowStart)
+		log.Printf("⏳ [KlineDownloader] 已用权重 %d/%d，等待 %v", d.windowWeight, d.weightPerMinute, wait.Round(time.Second))
+		d.sleep(wait)
+		d.windowStart, d.windowWeight = d.now(), 0
+	}
+	d.windowWeight += weight
+}
+
+// MergeKlines 将K线按开盘时间合并到存储中已有的K线（相同开盘时间以新数据为准），返回新增的K线数量
+func MergeKlines(store KlineStore, symbol string, tf TimeFrame, klines []Kline) (int, error) {
+	if len(klines) == 0 {
+		return 0, nil
+	}
+	existing, err := store.Load(symbol, tf, 0)
+	if err != nil {
+		return 0, err
+	}
+
+	byOpen := make(map[int64]Kline, len(existing)+len(klines))
+	for _, k := range existing {
+		byOpen[k.OpenTime] = k
+	}
+	before := len(byOpen)
+	for _, k := range klines {
+		byOpen[k.OpenTime] = k
+	}
+
+	merged := make([]Kline, 0, len(byOpen))
+	for _, k := range byOpen {
+		merged = append(merged, k)
+	}
+	sort.Slice(merged, func(i, j int) bool { return merged[i].OpenTime < merged[j].OpenTime })
+	if err := store.Save(symbol, tf, merged); err != nil {
+		return 0, err
+	}
+	return len(byOpen) - before, nil
+}
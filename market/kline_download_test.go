@@ -0,0 +1,128 @@
+package market
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// newKlineDownloadServer 模拟币安合约K线接口：每小时一根，按 startTime 过滤，单页最多 pageSize 根
+func newKlineDownloadServer(t *testing.T, first, now int64, rateLimitFirst bool) (*httptest.Server, *[]int64) {
+	t.Helper()
+	hour := int64(time.Hour / time.Millisecond)
+	var starts []int64
+	limited := !rateLimitFirst
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !limited {
+			limited = true
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		start, _ := strconv.ParseInt(r.URL.Query().Get("startTime"), 10, 64)
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+		starts = append(starts, start)
+		var out [][]interface{}
+		for ts := first; ts <= now && len(out) < limit; ts += hour {
+			if ts < start {
+				continue
+			}
+			c := fmt.Sprint(100 + (ts-first)/hour)
+			out = append(out, []interface{}{ts, c, c, c, c, "1", ts + hour - 1, "100", 1, "0.5", "50"})
+		}
+		json.NewEncoder(w).Encode(out)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &starts
+}
+
+func newTestKlineDownloader(t *testing.T, srvURL string, store KlineStore, now time.Time) (*KlineDownloader, *[]time.Duration) {
+	t.Helper()
+	d := NewKlineDownloader(store)
+	d.futuresURL = srvURL
+	d.pageDelay = 0
+	d.now = func() time.Time { return now }
+	var sleeps []time.Duration
+	d.sleep = func(wait time.Duration) { sleeps = append(sleeps, wait) }
+	return d, &sleeps
+}
+
+func TestKlineDownloader_DownloadAndResume(t *testing.T) {
+	hour := int64(time.Hour / time.Millisecond)
+	now := time.Date(2026, 3, 1, 0, 30, 0, 0, time.UTC)
+	first := now.Add(-100 * 24 * time.Hour).Truncate(time.Hour).UnixMilli()
+	srv, starts := newKlineDownloadServer(t, first, now.UnixMilli(), true)
+
+	store := NewFileKlineStore(t.TempDir())
+	d, sleeps := newTestKlineDownloader(t, srv.URL, store, now)
+
+	// 只下载最近10天
+	since := now.Add(-10 * 24 * time.Hour).Truncate(time.Hour)
+	added, err := d.Download("btcusdt", TimeFrame1h, since)
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if added != 240 {
+		t.Errorf("added = %d, want 240（不含形成中的K线）", added)
+	}
+	if len(*sleeps) == 0 || (*sleeps)[0] != time.Second {
+		t.Errorf("限频时应按 Retry-After 等待: %v", *sleeps)
+	}
+
+	// 再次执行：补齐更早的90天（跨多页），并从最后一根之后续传
+	*starts = nil
+	added, err = d.Download("BTCUSDT", TimeFrame1h, time.UnixMilli(first))
+	if err != nil {
+		t.Fatalf("Download: %v", err)
+	}
+	if added != 2160 {
+		t.Errorf("added = %d, want 2160", added)
+	}
+	klines, err := store.Load("BTCUSDT", TimeFrame1h, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(klines) != 2400 || klines[0].OpenTime != first {
+		t.Fatalf("存储了 %d 根K线，第一根 %d", len(klines), klines[0].OpenTime)
+	}
+	for i := 1; i < len(klines); i++ {
+		if klines[i].OpenTime-klines[i-1].OpenTime != hour {
+			t.Fatalf("K线不连续: %d -> %d", klines[i-1].OpenTime, klines[i].OpenTime)
+		}
+	}
+	if last := (*starts)[len(*starts)-1]; last != klines[len(klines)-1].OpenTime+1 {
+		t.Errorf("续传 startTime = %d, want %d", last, klines[len(klines)-1].OpenTime+1)
+	}
+}
+
+func TestKlineDownloader_WeightLimit(t *testing.T) {
+	now := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	d, sleeps := newTestKlineDownloader(t, "", NewFileKlineStore(t.TempDir()), now)
+	d.SetWeightPerMinute(25)
+
+	for i := 0; i < 3; i++ {
+		d.acquireWeight(KlineRequestWeight(klineDownloadPageLimit))
+	}
+	if len(*sleeps) != 1 || (*sleeps)[0] != time.Minute {
+		t.Errorf("第3页超出每分钟权重时应等待下一个窗口: %v", *sleeps)
+	}
+}
+
+func TestMergeKlines(t *testing.T) {
+	store := NewFileKlineStore(t.TempDir())
+	if err := store.Save("BTCUSDT", TimeFrame1h, []Kline{{OpenTime: 1, Close: 1}, {OpenTime: 3, Close: 3}}); err != nil {
+		t.Fatal(err)
+	}
+	added, err := MergeKlines(store, "BTCUSDT", TimeFrame1h, []Kline{{OpenTime: 3, Close: 30}, {OpenTime: 2, Close: 2}})
+	if err != nil || added != 1 {
+		t.Fatalf("MergeKlines = %d, %v", added, err)
+	}
+	klines, _ := store.Load("BTCUSDT", TimeFrame1h, 0)
+	if len(klines) != 3 || klines[1].OpenTime != 2 || klines[2].Close != 30 {
+		t.Errorf("merged = %+v", klines)
+	}
+}
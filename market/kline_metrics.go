@@ -0,0 +1,105 @@
+package market
+
+import (
+	"sort"
+	"time"
+)
+
+// KlineCacheMetrics 单个 (交易对, 时间周期) 的缓存指标
+type KlineCacheMetrics struct {
+	Symbol      string        `json:"symbol"`
+	TimeFrame   TimeFrame     `json:"timeframe"`
+	LastUpdate  time.Time     `json:"last_update"`          // 最近一次成功拉取的时间（仅从磁盘预热时为零值）
+	LatestOpen  time.Time     `json:"latest_open"`          // 最新一根K线的开盘时间
+	CandleAge   time.Duration `json:"candle_age_ns"`        // 最新一根K线收盘至今的时长（形成中的K线为0）
+	FetchErrors int           `json:"fetch_errors"`         // 累计拉取失败次数
+	LastError   string        `json:"last_error,omitempty"` // 最近一次拉取失败的原因
+	Size        int           `json:"size"`                 // 已缓存的K线数量
+	Capacity    int           `json:"capacity"`             // 缓存容量
+	Stale       bool          `json:"stale"`                // 数据是否已过期（见 IsStale）
+}
+
+// recordFetchErrorLocked 记录一次拉取失败（调用方需持有 mtk.mu 写锁，或 mtk 尚未登记到缓存）
+func (mtk *MultiTimeFrameKline) recordFetchErrorLocked(tf TimeFrame, err error) {
+	mtk.fetchErrors[tf]++
+	mtk.lastError[tf] = err.Error()
+}
+
+// metricsLocked 生成某个时间周期的指标（调用方需持有 mtk.mu 读锁）
+func (mtk *MultiTimeFrameKline) metricsLocked(tf TimeFrame, now time.Time) KlineCacheMetrics {
+	m := KlineCacheMetrics{
+		Symbol:      mtk.Symbol,
+		TimeFrame:   tf,
+		LastUpdate:  mtk.lastFetched[tf],
+		FetchErrors: mtk.fetchErrors[tf],
+		LastError:   mtk.lastError[tf],
+		Capacity:    mtk.capacity,
+		Stale:       true,
+	}
+
+	ring, exists := mtk.series[tf]
+	if !exists {
+		return m
+	}
+	m.Size = ring.Len()
+	last, ok := ring.Last()
+	if !ok {
+		return m
+	}
+
+	period := time.Duration(TimeFrameMinutes[tf]) * time.Minute
+	m.LatestOpen = time.UnixMilli(last.OpenTime)
+	if age := now.Sub(m.LatestOpen.Add(period)); age > 0 {
+		m.CandleAge = age
+	}
+	// 正常情况下最新一根是形成中的K线；收盘后超过一个周期仍没有新K线，说明数据已过期
+	m.Stale = m.CandleAge > period
+	return m
+}
+
+// Metrics 返回全部 (交易对, 时间周期) 的缓存指标，按交易对和周期排序
+func (kc *KlineCache) Metrics() []KlineCacheMetrics {
+	return kc.metricsAt(time.Now())
+}
+
+// metricsAt 以指定时间计算缓存指标
+func (kc *KlineCache) metricsAt(now time.Time) []KlineCacheMetrics {
+	kc.mu.RLock()
+	symbols := make([]*MultiTimeFrameKline, 0, len(kc.cache))
+	for _, mtk := range kc.cache {
+		symbols = append(symbols, mtk)
+	}
+	kc.mu.RUnlock()
+
+	sort.Slice(symbols, func(i, j int) bool { return symbols[i].Symbol < symbols[j].Symbol })
+
+	var metrics []KlineCacheMetrics
+	for _, mtk := range symbols {
+		mtk.mu.RLock()
+		for _, tf := range AllTimeFrames {
+			if _, exists := mtk.series[tf]; !exists && mtk.fetchErrors[tf] == 0 {
+				continue
+			}
+			metrics = append(metrics, mtk.metricsLocked(tf, now))
+		}
+		mtk.mu.RUnlock()
+	}
+	return metrics
+}
+
+// IsStale 判断某个 (交易对, 时间周期) 的数据是否过期
+// 未初始化、没有K线，或最新K线收盘后超过一个周期仍未出现新K线时视为过期
+func (kc *KlineCache) IsStale(symbol string, timeFrame TimeFrame) bool {
+	return kc.isStaleAt(symbol, timeFrame, time.Now())
+}
+
+// isStaleAt 以指定时间判断数据是否过期
+func (kc *KlineCache) isStaleAt(symbol string, timeFrame TimeFrame, now time.Time) bool {
+	mtk := kc.getSymbol(symbol)
+	if mtk == nil {
+		return true
+	}
+	mtk.mu.RLock()
+	defer mtk.mu.RUnlock()
+	return mtk.metricsLocked(timeFrame, now).Stale
+}
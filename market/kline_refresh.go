@@ -0,0 +1,154 @@
+package market
+
+import (
+	"sort"
+	"time"
+)
+
+// refreshKlineLimit 增量刷新时至少拉取的K线数量（最后一根可能还在形成中）
+const refreshKlineLimit = 2
+
+// maxKlineRequestLimit Binance K线接口单次请求的最大数量
+const maxKlineRequestLimit = 1000
+
+// KlineRequestWeight 返回Binance K线接口在给定limit下的请求权重
+func KlineRequestWeight(limit int) int {
+	switch {
+	case limit < 100:
+		return 1
+	case limit < 500:
+		return 2
+	case limit <= 1000:
+		return 5
+	default:
+		return 10
+	}
+}
+
+// RefreshTask 一个交易对需要刷新的时间周期
+type RefreshTask struct {
+	Symbol     string
+	TimeFrames []TimeFrame
+	Staleness  time.Duration // 该交易对最久未刷新周期的距今时长
+}
+
+// RefreshPlanner K线刷新计划器
+// 只有满足以下条件之一的时间周期才会被刷新：
+//  1. 自上次拉取后跨过了K线边界（有新K线收盘）
+//  2. 形成中的K线超过了该周期的刷新间隔（周期时长 × StalenessRatio，限制在 [MinInterval, MaxInterval]）
+//
+// 刷新任务按陈旧程度排序，单轮总权重不超过 WeightBudget
+type RefreshPlanner struct {
+	WeightBudget   int           // 单轮最大请求权重（<=0 表示不限制）
+	StalenessRatio float64       // 形成中K线的刷新间隔占周期时长的比例
+	MinInterval    time.Duration // 刷新间隔下限
+	MaxInterval    time.Duration // 刷新间隔上限
+}
+
+// NewRefreshPlanner 创建默认刷新计划器
+// 默认单轮权重预算600（Binance单IP每分钟上限2400的1/4）
+func NewRefreshPlanner() *RefreshPlanner {
+	return &RefreshPlanner{
+		WeightBudget:   600,
+		StalenessRatio: 0.05,
+		MinInterval:    15 * time.Second,
+		MaxInterval:    15 * time.Minute,
+	}
+}
+
+// refreshInterval 形成中K线的刷新间隔
+func (p *RefreshPlanner) refreshInterval(tf TimeFrame) time.Duration {
+	period := time.Duration(TimeFrameMinutes[tf]) * time.Minute
+	interval := time.Duration(float64(period) * p.StalenessRatio)
+	if interval < p.MinInterval {
+		interval = p.MinInterval
+	}
+	if p.MaxInterval > 0 && interval > p.MaxInterval {
+		interval = p.MaxInterval
+	}
+	return interval
+}
+
+// NeedsRefresh 判断某个时间周期是否需要重新拉取
+func (p *RefreshPlanner) NeedsRefresh(tf TimeFrame, lastFetched, now time.Time) bool {
+	if lastFetched.IsZero() {
+		return true
+	}
+	period := time.Duration(TimeFrameMinutes[tf]) * time.Minute
+	if period <= 0 {
+		return true
+	}
+	// 跨过K线边界：上次拉取时的K线已经收盘
+	if now.Truncate(period).After(lastFetched.Truncate(period)) {
+		return true
+	}
+	return now.Sub(lastFetched) >= p.refreshInterval(tf)
+}
+
+// Plan 根据各交易对各周期的最近拉取时间生成刷新任务
+// states: symbol -> (timeframe -> 最近拉取时间)
+func (p *RefreshPlanner) Plan(states map[string]map[TimeFrame]time.Time, now time.Time) []RefreshTask {
+	tasks := make([]RefreshTask, 0, len(states))
+	for symbol, fetched := range states {
+		var due []TimeFrame
+		var staleness time.Duration
+		for _, tf := range AllTimeFrames {
+			last := fetched[tf]
+			if !p.NeedsRefresh(tf, last, now) {
+				continue
+			}
+			due = append(due, tf)
+			age := now.Sub(last)
+			if last.IsZero() {
+				age = time.Duration(1<<63 - 1)
+			}
+			if age > staleness {
+				staleness = age
+			}
+		}
+		if len(due) > 0 {
+			tasks = append(tasks, RefreshTask{Symbol: symbol, TimeFrames: due, Staleness: staleness})
+		}
+	}
+
+	// 最陈旧的优先
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].Staleness != tasks[j].Staleness {
+			return tasks[i].Staleness > tasks[j].Staleness
+		}
+		return tasks[i].Symbol < tasks[j].Symbol
+	})
+
+	if p.WeightBudget <= 0 {
+		return tasks
+	}
+
+	// 按权重预算截断（同一交易对的周期可以部分入选）
+	remaining := p.WeightBudget
+	planned := make([]RefreshTask, 0, len(tasks))
+	for _, task := range tasks {
+		n := 0
+		for _, tf := range task.TimeFrames {
+			weight := refreshWeight(tf, states[task.Symbol][tf], now)
+			if remaining < weight {
+				break
+			}
+			remaining -= weight
+			n++
+		}
+		if n == 0 {
+			break
+		}
+		task.TimeFrames = task.TimeFrames[:n]
+		planned = append(planned, task)
+	}
+	return planned
+}
+
+// refreshWeight 刷新一个周期的估算权重：被跳过多个周期后需要补齐更多K线
+func refreshWeight(tf TimeFrame, lastFetched, now time.Time) int {
+	if lastFetched.IsZero() {
+		return KlineRequestWeight(refreshKlineLimit)
+	}
+	return KlineRequestWeight(deltaKlineLimit(lastFetched.UnixMilli(), tf, now, maxKlineRequestLimit))
+}
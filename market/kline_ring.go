@@ -0,0 +1,69 @@
+package market
+
+// klineRing 固定容量的K线环形缓冲区
+// 写入只移动游标不重新分配内存，读取通过 snapshot 拷贝出独立切片
+type klineRing struct {
+	buf   []Kline
+	start int // 最旧一根K线的位置
+	size  int // 当前K线数量
+}
+
+// newKlineRing 创建指定容量的环形缓冲区
+func newKlineRing(capacity int) *klineRing {
+	if capacity <= 0 {
+		capacity = defaultMaxKlines
+	}
+	return &klineRing{buf: make([]Kline, capacity)}
+}
+
+// Len 当前缓存的K线数量
+func (r *klineRing) Len() int {
+	return r.size
+}
+
+// Cap 缓冲区容量
+func (r *klineRing) Cap() int {
+	return len(r.buf)
+}
+
+// Push 追加一根K线，缓冲区已满时覆盖最旧的一根
+func (r *klineRing) Push(k Kline) {
+	if r.size < len(r.buf) {
+		r.buf[(r.start+r.size)%len(r.buf)] = k
+		r.size++
+		return
+	}
+	r.buf[r.start] = k
+	r.start = (r.start + 1) % len(r.buf)
+}
+
+// ReplaceLast 替换最新的一根K线（用于更新形成中的K线）
+func (r *klineRing) ReplaceLast(k Kline) {
+	if r.size == 0 {
+		r.Push(k)
+		return
+	}
+	r.buf[(r.start+r.size-1)%len(r.buf)] = k
+}
+
+// Last 返回最新的一根K线
+func (r *klineRing) Last() (Kline, bool) {
+	if r.size == 0 {
+		return Kline{}, false
+	}
+	return r.buf[(r.start+r.size-1)%len(r.buf)], true
+}
+
+// Snapshot 按时间顺序拷贝最新的 limit 根K线（limit<=0 表示全部）
+func (r *klineRing) Snapshot(limit int) []Kline {
+	n := r.size
+	if limit > 0 && limit < n {
+		n = limit
+	}
+	out := make([]Kline, n)
+	offset := r.size - n
+	for i := 0; i < n; i++ {
+		out[i] = r.buf[(r.start+offset+i)%len(r.buf)]
+	}
+	return out
+}
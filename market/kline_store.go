@@ -0,0 +1,96 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// KlineStore K线持久化存储，用于启动时预热缓存
+type KlineStore interface {
+	// Load 读取最近的 limit 根K线（按时间升序），没有数据时返回空切片
+	Load(symbol string, tf TimeFrame, limit int) ([]Kline, error)
+	// Save 覆盖保存某个交易对某个周期的K线
+	Save(symbol string, tf TimeFrame, klines []Kline) error
+}
+
+// FileKlineStore 基于本地JSON文件的K线存储（每个交易对每个周期一个文件）
+type FileKlineStore struct {
+	dir string
+}
+
+// NewFileKlineStore 创建文件存储
+func NewFileKlineStore(dir string) *FileKlineStore {
+	return &FileKlineStore{dir: dir}
+}
+
+// path 存储文件路径
+func (s *FileKlineStore) path(symbol string, tf TimeFrame) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%s_%s.json", strings.ToUpper(symbol), tf))
+}
+
+// Load 读取持久化的K线
+func (s *FileKlineStore) Load(symbol string, tf TimeFrame, limit int) ([]Kline, error) {
+	data, err := os.ReadFile(s.path(symbol, tf))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取K线文件失败: %w", err)
+	}
+
+	var klines []Kline
+	if err := json.Unmarshal(data, &klines); err != nil {
+		return nil, fmt.Errorf("解析K线文件失败: %w", err)
+	}
+	if limit > 0 && len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// Save 先写临时文件再重命名，避免进程退出时留下半截文件
+func (s *FileKlineStore) Save(symbol string, tf TimeFrame, klines []Kline) error {
+	if err := os.MkdirAll(s.dir, 0755); err != nil {
+		return fmt.Errorf("创建K线目录失败: %w", err)
+	}
+	data, err := json.Marshal(klines)
+	if err != nil {
+		return fmt.Errorf("序列化K线失败: %w", err)
+	}
+
+	path := s.path(symbol, tf)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入K线文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("保存K线文件失败: %w", err)
+	}
+	return nil
+}
+
+// deltaKlineLimit 根据已持久化的最后一根K线计算还需拉取的数量
+// 最后一根可能仍在形成中，需要一并刷新，因此至少拉取 refreshKlineLimit 根
+func deltaKlineLimit(lastOpenTime int64, tf TimeFrame, now time.Time, maxKlines int) int {
+	period := time.Duration(TimeFrameMinutes[tf]) * time.Minute
+	if period <= 0 {
+		return maxKlines
+	}
+	elapsed := now.Sub(time.UnixMilli(lastOpenTime))
+	if elapsed < 0 {
+		elapsed = 0
+	}
+	limit := int(elapsed/period) + 1
+	if limit < refreshKlineLimit {
+		limit = refreshKlineLimit
+	}
+	if limit > maxKlines {
+		limit = maxKlines
+	}
+	return limit
+}
@@ -0,0 +1,297 @@
+package market
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCalibrationHorizon    = 5  // 默认前瞻K线数
+	defaultCalibrationMinSamples = 30 // 默认最少样本数，不足时保留手工设定的强度
+	calibrationPriorSamples      = 20 // 胜率向50%收缩的先验样本数，避免小样本得出极端强度
+	calibrationWindow            = 64 // 回放时保留的K线数量（覆盖各检测器和止损模型所需的回看）
+)
+
+// detectorBaseConfidence 各检测器手工设定的基础强度；校准值替换基础强度，形态内部的加分（影线、实体比例等）保留为相对偏移
+var detectorBaseConfidence = map[string]int{
+	DetectorPinBar:      60,
+	DetectorVolumeSpike: 70,
+	DetectorEngulfing:   80,
+	DetectorWilliamsR:   70,
+	DetectorMomentum:    70,
+}
+
+// signalDetectorName 信号类型对应的检测器名称
+func signalDetectorName(t SignalType) string {
+	switch t {
+	case SignalBullishPinBar, SignalBearishPinBar:
+		return DetectorPinBar
+	default:
+		return string(t)
+	}
+}
+
+// CalibrationEntry 某个检测器在某个交易对/周期上的校准结果（Symbol/TimeFrame 为空表示匹配全部）
+type CalibrationEntry struct {
+	Detector   string    `json:"detector"`
+	Symbol     string    `json:"symbol,omitempty"`
+	TimeFrame  TimeFrame `json:"timeframe,omitempty"`
+	Confidence int       `json:"confidence"` // 校准后的基础强度
+	Samples    int       `json:"samples"`
+	WinRate    float64   `json:"win_rate"`   // 前瞻收益为正的比例
+	AvgReturn  float64   `json:"avg_return"` // 按信号方向计的平均前瞻收益（%）
+}
+
+// ConfidenceCalibration 信号强度校准配置（由 nofx calibrate 生成）
+type ConfidenceCalibration struct {
+	Horizon     int                `json:"horizon"` // 前瞻K线数
+	GeneratedAt time.Time          `json:"generated_at"`
+	Entries     []CalibrationEntry `json:"entries"`
+}
+
+// LoadConfidenceCalibration 读取校准文件
+func LoadConfidenceCalibration(path string) (*ConfidenceCalibration, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取校准文件失败: %w", err)
+	}
+	var c ConfidenceCalibration
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("解析校准文件失败: %w", err)
+	}
+	return &c, nil
+}
+
+// LoadOrNewConfidenceCalibration 读取校准文件，文件不存在时返回空配置
+func LoadOrNewConfidenceCalibration(path string) (*ConfidenceCalibration, error) {
+	c, err := LoadConfidenceCalibration(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return &ConfidenceCalibration{}, nil
+	}
+	return c, err
+}
+
+// Save 先写临时文件再重命名，避免留下半截文件
+func (c *ConfidenceCalibration) Save(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("创建校准目录失败: %w", err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化校准结果失败: %w", err)
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入校准文件失败: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("保存校准文件失败: %w", err)
+	}
+	return nil
+}
+
+// Merge 用新的校准结果替换相同检测器/交易对/周期的条目，其余条目保留
+func (c *ConfidenceCalibration) Merge(entries []CalibrationEntry) {
+	index := make(map[calibrationKey]int, len(c.Entries))
+	for i, e := range c.Entries {
+		index[e.key()] = i
+	}
+	for _, e := range entries {
+		if i, ok := index[e.key()]; ok {
+			c.Entries[i] = e
+			continue
+		}
+		index[e.key()] = len(c.Entries)
+		c.Entries = append(c.Entries, e)
+	}
+	sort.Slice(c.Entries, func(i, j int) bool {
+		a, b := c.Entries[i], c.Entries[j]
+		if a.Symbol != b.Symbol {
+			return a.Symbol < b.Symbol
+		}
+		if a.TimeFrame != b.TimeFrame {
+			return TimeFrameMinutes[a.TimeFrame] < TimeFrameMinutes[b.TimeFrame]
+		}
+		return a.Detector < b.Detector
+	})
+}
+
+// calibrationKey 校准查找键
+type calibrationKey struct {
+	detector  string
+	symbol    string
+	timeFrame TimeFrame
+}
+
+func (e CalibrationEntry) key() calibrationKey {
+	symbol := e.Symbol
+	if symbol != "" {
+		symbol = Normalize(symbol)
+	}
+	return calibrationKey{e.Detector, symbol, e.TimeFrame}
+}
+
+// confidenceSet 编译后的校准配置
+type confidenceSet struct {
+	entries map[calibrationKey]int
+	mu      sync.RWMutex
+}
+
+// set 替换全部校准（nil 表示恢复手工设定的强度）
+func (s *confidenceSet) set(c *ConfidenceCalibration) error {
+	var entries map[calibrationKey]int
+	if c != nil {
+		entries = make(map[calibrationKey]int, len(c.Entries))
+		for i, e := range c.Entries {
+			if _, ok := detectorBaseConfidence[e.Detector]; !ok {
+				return fmt.Errorf("校准条目 #%d: 未知的检测器 %q", i+1, e.Detector)
+			}
+			if e.TimeFrame != "" {
+				if _, ok := TimeFrameMinutes[e.TimeFrame]; !ok {
+					return fmt.Errorf("校准条目 #%d: 未知的时间周期 %q", i+1, e.TimeFrame)
+				}
+			}
+			if e.Confidence < 0 || e.Confidence > 100 {
+				return fmt.Errorf("校准条目 #%d: 强度应在 0 ~ 100 之间，实际 %d", i+1, e.Confidence)
+			}
+			entries[e.key()] = e.Confidence
+		}
+	}
+
+	s.mu.Lock()
+	s.entries = entries
+	s.mu.Unlock()
+	return nil
+}
+
+// apply 用校准的基础强度替换手工基础强度，保留 raw 相对手工基础强度的偏移
+// 匹配优先级：检测器+交易对+周期 > 检测器+周期 > 检测器+交易对 > 仅检测器；都没有时返回 raw
+func (s *confidenceSet) apply(detector, symbol string, tf TimeFrame, raw int) int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.entries) == 0 {
+		return raw
+	}
+
+	symbol = Normalize(symbol)
+	for _, key := range []calibrationKey{{detector, symbol, tf}, {detector, "", tf}, {detector, symbol, ""}, {detector, "", ""}} {
+		if base, ok := s.entries[key]; ok {
+			return clampConfidence(base + raw - detectorBaseConfidence[detector])
+		}
+	}
+	return raw
+}
+
+func clampConfidence(c int) int {
+	return max(0, min(100, c))
+}
+
+// SetConfidenceCalibration 设置历史校准的信号强度（配置无效时保留原配置并返回错误，nil 表示恢复手工设定）
+func (sd *SignalDetector) SetConfidenceCalibration(c *ConfidenceCalibration) error {
+	return sd.confidence.set(c)
+}
+
+// calibrated 按校准配置调整检测器给出的信号强度
+func (sd *SignalDetector) calibrated(detector, symbol string, tf TimeFrame, raw int) int {
+	return sd.confidence.apply(detector, symbol, tf, raw)
+}
+
+// CalibrationConfig 信号强度校准参数
+type CalibrationConfig struct {
+	Horizon    int // 前瞻K线数（默认5）：信号K线收盘后第 Horizon 根K线的收盘价相对入场价的收益
+	MinSamples int // 最少样本数（默认30），不足时不输出该检测器的校准结果
+}
+
+func (c CalibrationConfig) horizon() int {
+	if c.Horizon <= 0 {
+		return defaultCalibrationHorizon
+	}
+	return c.Horizon
+}
+
+func (c CalibrationConfig) minSamples() int {
+	if c.MinSamples <= 0 {
+		return defaultCalibrationMinSamples
+	}
+	return c.MinSamples
+}
+
+// calibrationStats 单个检测器的前瞻收益统计
+type calibrationStats struct {
+	samples   int
+	wins      int
+	sumReturn float64
+}
+
+// confidence 由胜率换算强度：胜率先向50%收缩，50%对应强度50，每多1%胜率加2分（75%及以上为100）
+func (s calibrationStats) confidence() int {
+	winRate := (float64(s.wins) + calibrationPriorSamples*0.5) / float64(s.samples+calibrationPriorSamples)
+	return clampConfidence(int(math.Round(50 + (winRate-0.5)*200)))
+}
+
+// CalibrateConfidence 在历史K线（按时间升序）上逐根回放各检测器，统计每种形态之后的前瞻收益，返回样本数足够的检测器的校准结果
+func CalibrateConfidence(symbol string, tf TimeFrame, klines []Kline, cfg CalibrationConfig) []CalibrationEntry {
+	horizon := cfg.horizon()
+	mtk := newMultiTimeFrameKline(symbol, calibrationWindow)
+	ring := newKlineRing(calibrationWindow)
+	mtk.series[tf] = ring
+	sd := &SignalDetector{cache: &KlineCache{cache: map[string]*MultiTimeFrameKline{symbol: mtk}}, quiet: true}
+
+	stats := make(map[string]*calibrationStats)
+	for i := 0; i+horizon < len(klines); i++ {
+		ring.Push(klines[i])
+
+		var signals []*TradingSignal
+		signals = append(signals, sd.DetectPinBar(symbol, tf)...)
+		signals = append(signals, sd.DetectVolumeSpike(symbol, tf)...)
+		signals = append(signals, sd.DetectEngulfing(symbol, tf)...)
+		signals = append(signals, sd.DetectWilliamsR(symbol, tf)...)
+		signals = append(signals, sd.DetectMomentum(symbol, tf)...)
+
+		for _, sig := range signals {
+			if sig.Price <= 0 {
+				continue
+			}
+			ret := (klines[i+horizon].Close - sig.Price) / sig.Price * 100
+			if sig.Direction == "short" {
+				ret = -ret
+			}
+			detector := signalDetectorName(sig.SignalType)
+			s := stats[detector]
+			if s == nil {
+				s = &calibrationStats{}
+				stats[detector] = s
+			}
+			s.samples++
+			s.sumReturn += ret
+			if ret > 0 {
+				s.wins++
+			}
+		}
+	}
+
+	var entries []CalibrationEntry
+	for detector, s := range stats {
+		if s.samples < cfg.minSamples() {
+			continue
+		}
+		entries = append(entries, CalibrationEntry{
+			Detector:   detector,
+			Symbol:     Normalize(symbol),
+			TimeFrame:  tf,
+			Confidence: s.confidence(),
+			Samples:    s.samples,
+			WinRate:    float64(s.wins) / float64(s.samples),
+			AvgReturn:  s.sumReturn / float64(s.samples),
+		})
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Detector < entries[j].Detector })
+	return entries
+}
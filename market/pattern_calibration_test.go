@@ -0,0 +1,143 @@
+package market
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// engulfingRallyKlines 重复 n 次：阴线 + 看涨吞没 + 6根小阳线上涨
+func engulfingRallyKlines(n int) []Kline {
+	var klines []Kline
+	p := 100.0
+	add := func(open, close float64) {
+		klines = append(klines, Kline{
+			OpenTime: int64(len(klines)) * 300000,
+			Open:     open, Close: close,
+			High: max(open, close) + 0.1, Low: min(open, close) - 0.1,
+			Volume: 100,
+		})
+	}
+	for i := 0; i < n; i++ {
+		add(p+1, p)
+		add(p-0.5, p+2)
+		p += 2
+		for j := 0; j < 6; j++ {
+			add(p, p+0.5)
+			p += 0.5
+		}
+	}
+	return klines
+}
+
+func TestCalibrateConfidence(t *testing.T) {
+	klines := engulfingRallyKlines(40)
+
+	entries := CalibrateConfidence("btcusdt", TimeFrame5m, klines, CalibrationConfig{})
+	var engulfing *CalibrationEntry
+	for i := range entries {
+		if entries[i].Detector == DetectorEngulfing {
+			engulfing = &entries[i]
+		}
+	}
+	if engulfing == nil {
+		t.Fatalf("应输出吞没形态的校准结果: %+v", entries)
+	}
+	if engulfing.Symbol != "BTCUSDT" || engulfing.TimeFrame != TimeFrame5m {
+		t.Errorf("校准键错误: %+v", engulfing)
+	}
+	if engulfing.Samples != 40 || engulfing.WinRate != 1 {
+		t.Errorf("40次吞没后均上涨，实际样本%d 胜率%.2f", engulfing.Samples, engulfing.WinRate)
+	}
+	if engulfing.Confidence != 100 {
+		t.Errorf("全部获胜时强度应为100，实际 %d", engulfing.Confidence)
+	}
+
+	for _, e := range CalibrateConfidence("BTCUSDT", TimeFrame5m, klines, CalibrationConfig{MinSamples: 41}) {
+		if e.Detector == DetectorEngulfing {
+			t.Errorf("样本不足时不应输出校准结果: %+v", e)
+		}
+	}
+}
+
+func TestCalibrationStats_Confidence(t *testing.T) {
+	cases := []struct {
+		samples, wins, want int
+	}{
+		{100, 50, 50},
+		{100, 60, 67}, // (60+10)/120 = 58.3%
+		{100, 30, 17}, // (30+10)/120 = 33.3%
+		{4, 4, 67},    // 小样本向50%收缩：(4+10)/24 = 58.3%
+	}
+	for _, c := range cases {
+		if got := (calibrationStats{samples: c.samples, wins: c.wins}).confidence(); got != c.want {
+			t.Errorf("样本%d 胜%d: 强度应为 %d，实际 %d", c.samples, c.wins, c.want, got)
+		}
+	}
+}
+
+func TestSignalDetector_ConfidenceCalibration(t *testing.T) {
+	klines := []Kline{
+		{OpenTime: 0, Open: 101, High: 101.1, Low: 99.9, Close: 100},
+		{OpenTime: 300000, Open: 99.5, High: 102.1, Low: 99.4, Close: 102},
+	}
+	sd := newTestDetector("BTCUSDT", TimeFrame5m, klines)
+	signals := sd.DetectEngulfing("BTCUSDT", TimeFrame5m)
+	if len(signals) != 1 || signals[0].Confidence != 90 {
+		t.Fatalf("未校准时应为手工强度90: %+v", signals)
+	}
+
+	err := sd.SetConfidenceCalibration(&ConfidenceCalibration{Entries: []CalibrationEntry{
+		{Detector: DetectorEngulfing, Confidence: 70},
+		{Detector: DetectorEngulfing, Symbol: "btcusdt", TimeFrame: TimeFrame5m, Confidence: 55},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 校准基础强度55，保留实体放大的 +10
+	if signals := sd.DetectEngulfing("BTCUSDT", TimeFrame5m); signals[0].Confidence != 65 {
+		t.Errorf("校准后强度应为65，实际 %d", signals[0].Confidence)
+	}
+	if got := sd.calibrated(DetectorEngulfing, "ETHUSDT", TimeFrame1h, 80); got != 70 {
+		t.Errorf("应回退到只按检测器的校准，实际 %d", got)
+	}
+	if got := sd.calibrated(DetectorPinBar, "BTCUSDT", TimeFrame5m, 75); got != 75 {
+		t.Errorf("未校准的检测器应保持原强度，实际 %d", got)
+	}
+
+	if err := sd.SetConfidenceCalibration(&ConfidenceCalibration{Entries: []CalibrationEntry{{Detector: "unknown", Confidence: 50}}}); err == nil {
+		t.Error("未知检测器应返回错误")
+	}
+	if got := sd.calibrated(DetectorEngulfing, "ETHUSDT", TimeFrame1h, 80); got != 70 {
+		t.Errorf("配置无效时应保留原校准，实际 %d", got)
+	}
+}
+
+func TestConfidenceCalibration_SaveMerge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "calibration.json")
+	c, err := LoadOrNewConfidenceCalibration(path)
+	if err != nil || len(c.Entries) != 0 {
+		t.Fatalf("文件不存在时应返回空配置: %+v %v", c, err)
+	}
+	c.Merge([]CalibrationEntry{
+		{Detector: DetectorEngulfing, Symbol: "BTCUSDT", TimeFrame: TimeFrame1h, Confidence: 60},
+		{Detector: DetectorPinBar, Symbol: "BTCUSDT", TimeFrame: TimeFrame1h, Confidence: 55},
+	})
+	if err := c.Save(path); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadConfidenceCalibration(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded.Merge([]CalibrationEntry{
+		{Detector: DetectorEngulfing, Symbol: "BTCUSDT", TimeFrame: TimeFrame1h, Confidence: 72},
+		{Detector: DetectorEngulfing, Symbol: "ETHUSDT", TimeFrame: TimeFrame1h, Confidence: 48},
+	})
+	if len(loaded.Entries) != 3 {
+		t.Fatalf("应替换相同键并追加新键，实际 %+v", loaded.Entries)
+	}
+	if loaded.Entries[0].Detector != DetectorEngulfing || loaded.Entries[0].Confidence != 72 {
+		t.Errorf("相同键应被替换: %+v", loaded.Entries[0])
+	}
+}
@@ -0,0 +1,392 @@
+package market
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MarketStat 筛选所需的单个交易对行情统计
+type MarketStat struct {
+	Symbol      string
+	QuoteVolume float64 // 24小时成交额（USDT）
+	Bid         float64 // 买一价
+	Ask         float64 // 卖一价
+	FundingRate float64 // 当前资金费率（0.0001 = 0.01%）
+}
+
+// SpreadBps 买卖价差（基点），缺少报价时返回-1
+func (s MarketStat) SpreadBps() float64 {
+	if s.Bid <= 0 || s.Ask <= 0 || s.Ask < s.Bid {
+		return -1
+	}
+	mid := (s.Bid + s.Ask) / 2
+	return (s.Ask - s.Bid) / mid * 10000
+}
+
+// ScreenerSource 筛选器数据源
+type ScreenerSource interface {
+	// MarketStats 全部交易对的行情统计（一次批量请求，用于廉价的初筛）
+	MarketStats() ([]MarketStat, error)
+	// Klines 获取K线（只对通过初筛的交易对调用，用于计算ATR）
+	Klines(symbol string, tf TimeFrame, limit int) ([]Kline, error)
+}
+
+// ScreenerConfig 交易对筛选配置（每个策略一份）
+type ScreenerConfig struct {
+	Name              string    `json:"name"`                 // 策略名称（用于日志）
+	QuoteAsset        string    `json:"quote_asset"`          // 计价币种（默认USDT）
+	MinQuoteVolume    float64   `json:"min_quote_volume"`     // 24小时成交额下限（0 表示不限制）
+	MaxSpreadBps      float64   `json:"max_spread_bps"`       // 买卖价差上限（基点，0 表示不限制）
+	MinATRPct         float64   `json:"min_atr_pct"`          // ATR占价格百分比下限（0 表示不限制）
+	MaxATRPct         float64   `json:"max_atr_pct"`          // ATR占价格百分比上限（0 表示不限制）
+	MaxAbsFundingRate float64   `json:"max_abs_funding_rate"` // 资金费率绝对值上限（0 表示不限制）
+	ATRTimeFrame      TimeFrame `json:"atr_timeframe"`        // 计算ATR的周期（默认1h）
+	ATRPeriod         int       `json:"atr_period"`           // ATR周期（默认14）
+	MaxSymbols        int       `json:"max_symbols"`          // 最多保留的交易对数量（按成交额排序，0 表示不限制）
+	Exclude           []string  `json:"exclude"`              // 始终排除的交易对
+}
+
+// Validate 校验配置
+func (c ScreenerConfig) Validate() error {
+	if c.MinQuoteVolume < 0 || c.MaxSpreadBps < 0 || c.MinATRPct < 0 || c.MaxATRPct < 0 || c.MaxAbsFundingRate < 0 {
+		return fmt.Errorf("筛选阈值不能为负数")
+	}
+	if c.MaxATRPct > 0 && c.MinATRPct > c.MaxATRPct {
+		return fmt.Errorf("ATR百分比下限 %.2f 大于上限 %.2f", c.MinATRPct, c.MaxATRPct)
+	}
+	if c.ATRTimeFrame != "" {
+		if _, ok := TimeFrameMinutes[c.ATRTimeFrame]; !ok {
+			return fmt.Errorf("未知的ATR周期: %q", c.ATRTimeFrame)
+		}
+	}
+	if c.ATRPeriod < 0 || c.MaxSymbols < 0 {
+		return fmt.Errorf("ATR周期和交易对数量不能为负数")
+	}
+	return nil
+}
+
+// usesATR 是否需要计算ATR
+func (c ScreenerConfig) usesATR() bool {
+	return c.MinATRPct > 0 || c.MaxATRPct > 0
+}
+
+// ScreenedSymbol 通过筛选的交易对
+type ScreenedSymbol struct {
+	Symbol      string  `json:"symbol"`
+	QuoteVolume float64 `json:"quote_volume"`
+	SpreadBps   float64 `json:"spread_bps"`
+	ATRPct      float64 `json:"atr_pct"` // 未配置ATR条件时为0
+	FundingRate float64 `json:"funding_rate"`
+}
+
+// ScreenResult 一次筛选的结果
+type ScreenResult struct {
+	Name     string            `json:"name"`
+	At       time.Time         `json:"at"`
+	Symbols  []ScreenedSymbol  `json:"symbols"`  // 按成交额从大到小排序
+	Rejected map[string]string `json:"rejected"` // 被过滤的交易对及原因
+}
+
+// SymbolList 通过筛选的交易对列表
+func (r ScreenResult) SymbolList() []string {
+	symbols := make([]string, len(r.Symbols))
+	for i, s := range r.Symbols {
+		symbols[i] = s.Symbol
+	}
+	return symbols
+}
+
+// ScreenerUpdate 活跃交易对变化通知
+type ScreenerUpdate struct {
+	Result  ScreenResult
+	Added   []string // 新进入活跃列表的交易对
+	Removed []string // 移出活跃列表的交易对
+}
+
+// Screener 交易对筛选器
+// 每轮按成交额、价差、资金费率做初筛，再对剩余交易对计算ATR百分比，
+// 得到的活跃列表变化时通知订阅者（如K线缓存、策略）
+type Screener struct {
+	cfg    ScreenerConfig
+	source ScreenerSource
+
+	mu          sync.Mutex
+	active      []string
+	last        ScreenResult
+	subscribers []func(ScreenerUpdate)
+}
+
+// NewScreener 创建筛选器
+func NewScreener(cfg ScreenerConfig, source ScreenerSource) (*Screener, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("筛选配置无效: %w", err)
+	}
+	if cfg.QuoteAsset == "" {
+		cfg.QuoteAsset = "USDT"
+	}
+	if cfg.ATRTimeFrame == "" {
+		cfg.ATRTimeFrame = TimeFrame1h
+	}
+	if cfg.ATRPeriod == 0 {
+		cfg.ATRPeriod = 14
+	}
+	return &Screener{cfg: cfg, source: source}, nil
+}
+
+// Subscribe 订阅活跃交易对变化（每轮筛选后只要有增减就回调）
+func (s *Screener) Subscribe(fn func(ScreenerUpdate)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, fn)
+}
+
+// Active 当前活跃交易对列表
+func (s *Screener) Active() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.active...)
+}
+
+// LastResult 最近一次筛选结果
+func (s *Screener) LastResult() ScreenResult {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.last
+}
+
+// Screen 执行一轮筛选，更新活跃列表并通知订阅者
+func (s *Screener) Screen() (ScreenResult, error) {
+	stats, err := s.source.MarketStats()
+	if err != nil {
+		return ScreenResult{}, fmt.Errorf("获取行情统计失败: %w", err)
+	}
+
+	result := ScreenResult{Name: s.cfg.Name, At: time.Now(), Rejected: make(map[string]string)}
+	excluded := make(map[string]bool, len(s.cfg.Exclude))
+	for _, symbol := range s.cfg.Exclude {
+		excluded[strings.ToUpper(symbol)] = true
+	}
+
+	var candidates []ScreenedSymbol
+	for _, stat := range stats {
+		if !strings.HasSuffix(stat.Symbol, s.cfg.QuoteAsset) {
+			continue
+		}
+		if reason := s.rejectStat(stat, excluded); reason != "" {
+			result.Rejected[stat.Symbol] = reason
+			continue
+		}
+		candidates = append(candidates, ScreenedSymbol{
+			Symbol:      stat.Symbol,
+			QuoteVolume: stat.QuoteVolume,
+			SpreadBps:   stat.SpreadBps(),
+			FundingRate: stat.FundingRate,
+		})
+	}
+
+	// 成交额大的优先，MaxSymbols 截断时保留流动性最好的
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].QuoteVolume > candidates[j].QuoteVolume
+	})
+
+	for _, c := range candidates {
+		if s.cfg.MaxSymbols > 0 && len(result.Symbols) >= s.cfg.MaxSymbols {
+			result.Rejected[c.Symbol] = "超出数量上限"
+			continue
+		}
+		if s.cfg.usesATR() {
+			atrPct, reason := s.checkATR(c.Symbol)
+			if reason != "" {
+				result.Rejected[c.Symbol] = reason
+				continue
+			}
+			c.ATRPct = atrPct
+		}
+		result.Symbols = append(result.Symbols, c)
+	}
+
+	s.apply(result)
+	return result, nil
+}
+
+// rejectStat 按行情统计初筛，返回拒绝原因（空字符串表示通过）
+func (s *Screener) rejectStat(stat MarketStat, excluded map[string]bool) string {
+	switch {
+	case excluded[stat.Symbol]:
+		return "在排除列表中"
+	case s.cfg.MinQuoteVolume > 0 && stat.QuoteVolume < s.cfg.MinQuoteVolume:
+		return fmt.Sprintf("成交额 %.0f 低于 %.0f", stat.QuoteVolume, s.cfg.MinQuoteVolume)
+	case s.cfg.MaxAbsFundingRate > 0 && (stat.FundingRate > s.cfg.MaxAbsFundingRate || stat.FundingRate < -s.cfg.MaxAbsFundingRate):
+		return fmt.Sprintf("资金费率 %.4f%% 超出 ±%.4f%%", stat.FundingRate*100, s.cfg.MaxAbsFundingRate*100)
+	}
+	if s.cfg.MaxSpreadBps > 0 {
+		spread := stat.SpreadBps()
+		if spread < 0 {
+			return "缺少买卖报价"
+		}
+		if spread > s.cfg.MaxSpreadBps {
+			return fmt.Sprintf("价差 %.2fbps 超过 %.2fbps", spread, s.cfg.MaxSpreadBps)
+		}
+	}
+	return ""
+}
+
+// checkATR 计算ATR百分比并检查区间，返回拒绝原因（空字符串表示通过）
+func (s *Screener) checkATR(symbol string) (float64, string) {
+	klines, err := s.source.Klines(symbol, s.cfg.ATRTimeFrame, s.cfg.ATRPeriod+1)
+	if err != nil {
+		return 0, fmt.Sprintf("获取K线失败: %v", err)
+	}
+	atr := CalculateATR(klines, s.cfg.ATRPeriod)
+	if atr <= 0 {
+		return 0, "K线不足，无法计算ATR"
+	}
+	atrPct := atr / klines[len(klines)-1].Close * 100
+	if s.cfg.MinATRPct > 0 && atrPct < s.cfg.MinATRPct {
+		return atrPct, fmt.Sprintf("ATR %.2f%% 低于 %.2f%%", atrPct, s.cfg.MinATRPct)
+	}
+	if s.cfg.MaxATRPct > 0 && atrPct > s.cfg.MaxATRPct {
+		return atrPct, fmt.Sprintf("ATR %.2f%% 高于 %.2f%%", atrPct, s.cfg.MaxATRPct)
+	}
+	return atrPct, ""
+}
+
+// apply 更新活跃列表，有变化时通知订阅者
+func (s *Screener) apply(result ScreenResult) {
+	symbols := result.SymbolList()
+
+	s.mu.Lock()
+	added, removed := diffSymbols(s.active, symbols)
+	s.active = symbols
+	s.last = result
+	subscribers := make([]func(ScreenerUpdate), len(s.subscribers))
+	copy(subscribers, s.subscribers)
+	s.mu.Unlock()
+
+	log.Printf("🔎 [Screener:%s] 活跃交易对 %d 个（新增 %d，移除 %d，过滤 %d）",
+		s.cfg.Name, len(symbols), len(added), len(removed), len(result.Rejected))
+
+	if len(added) == 0 && len(removed) == 0 {
+		return
+	}
+	update := ScreenerUpdate{Result: result, Added: added, Removed: removed}
+	for _, fn := range subscribers {
+		fn(update)
+	}
+}
+
+// Run 每个交易时段（interval）执行一次筛选，直到 stop 关闭
+func (s *Screener) Run(interval time.Duration, stop <-chan struct{}) {
+	if _, err := s.Screen(); err != nil {
+		log.Printf("⚠️ [Screener:%s] 筛选失败: %v", s.cfg.Name, err)
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			if _, err := s.Screen(); err != nil {
+				log.Printf("⚠️ [Screener:%s] 筛选失败: %v（保留上一轮活跃列表）", s.cfg.Name, err)
+			}
+		}
+	}
+}
+
+// diffSymbols 计算两个交易对列表的增减
+func diffSymbols(before, after []string) (added, removed []string) {
+	prev := make(map[string]bool, len(before))
+	for _, s := range before {
+		prev[s] = true
+	}
+	next := make(map[string]bool, len(after))
+	for _, s := range after {
+		next[s] = true
+		if !prev[s] {
+			added = append(added, s)
+		}
+	}
+	for _, s := range before {
+		if !next[s] {
+			removed = append(removed, s)
+		}
+	}
+	return added, removed
+}
+
+// SubscribeScreener 让K线缓存跟随筛选器的活跃列表，自动初始化新增的交易对并移除被剔除的交易对
+func (kc *KlineCache) SubscribeScreener(s *Screener, maxKlines int) {
+	s.Subscribe(func(update ScreenerUpdate) {
+		for _, symbol := range update.Removed {
+			kc.RemoveSymbol(symbol)
+		}
+		for _, symbol := range update.Added {
+			if err := kc.InitSymbol(symbol, maxKlines); err != nil {
+				log.Printf("⚠️ [KlineCache] 初始化筛选出的交易对 %s 失败: %v", symbol, err)
+			}
+		}
+	})
+}
+
+// binanceScreenerSource 基于Binance合约公开接口的筛选数据源
+type binanceScreenerSource struct {
+	client *APIClient
+}
+
+// NewBinanceScreenerSource 创建Binance筛选数据源
+func NewBinanceScreenerSource(client *APIClient) ScreenerSource {
+	if client == nil {
+		client = NewAPIClient()
+	}
+	return &binanceScreenerSource{client: client}
+}
+
+// MarketStats 合并24小时行情、最优挂单和资金费率
+func (b *binanceScreenerSource) MarketStats() ([]MarketStat, error) {
+	tickers, err := b.client.GetAllTickers24hr()
+	if err != nil {
+		return nil, fmt.Errorf("获取24小时行情失败: %w", err)
+	}
+	books, err := b.client.GetAllBookTickers()
+	if err != nil {
+		return nil, fmt.Errorf("获取最优挂单失败: %w", err)
+	}
+	premiums, err := b.client.GetAllPremiumIndex()
+	if err != nil {
+		return nil, fmt.Errorf("获取资金费率失败: %w", err)
+	}
+
+	bookBySymbol := make(map[string]BookTicker, len(books))
+	for _, book := range books {
+		bookBySymbol[book.Symbol] = book
+	}
+	fundingBySymbol := make(map[string]float64, len(premiums))
+	for _, p := range premiums {
+		rate, _ := strconv.ParseFloat(p.LastFundingRate, 64)
+		fundingBySymbol[p.Symbol] = rate
+	}
+
+	stats := make([]MarketStat, 0, len(tickers))
+	for _, t := range tickers {
+		stat := MarketStat{Symbol: t.Symbol, FundingRate: fundingBySymbol[t.Symbol]}
+		stat.QuoteVolume, _ = strconv.ParseFloat(t.QuoteVolume, 64)
+		if book, ok := bookBySymbol[t.Symbol]; ok {
+			stat.Bid, _ = strconv.ParseFloat(book.BidPrice, 64)
+			stat.Ask, _ = strconv.ParseFloat(book.AskPrice, 64)
+		}
+		stats = append(stats, stat)
+	}
+	return stats, nil
+}
+
+// Klines 获取K线
+func (b *binanceScreenerSource) Klines(symbol string, tf TimeFrame, limit int) ([]Kline, error) {
+	return b.client.GetKlines(symbol, BinanceIntervalMap[tf], limit)
+}
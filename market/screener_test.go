@@ -0,0 +1,155 @@
+package market
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeScreenerSource 测试用筛选数据源
+type fakeScreenerSource struct {
+	stats      []MarketStat
+	klines     map[string][]Kline
+	klineCalls []string
+	statsErr   error
+}
+
+func (f *fakeScreenerSource) MarketStats() ([]MarketStat, error) {
+	return f.stats, f.statsErr
+}
+
+func (f *fakeScreenerSource) Klines(symbol string, tf TimeFrame, limit int) ([]Kline, error) {
+	f.klineCalls = append(f.klineCalls, symbol)
+	klines, ok := f.klines[symbol]
+	if !ok {
+		return nil, errors.New("no klines")
+	}
+	return klines, nil
+}
+
+// volatileKlines 每根K线振幅为 rangePct% 的横盘K线
+func volatileKlines(count int, price, rangePct float64) []Kline {
+	klines := make([]Kline, count)
+	half := price * rangePct / 200
+	for i := range klines {
+		klines[i] = Kline{Open: price, High: price + half, Low: price - half, Close: price}
+	}
+	return klines
+}
+
+func TestScreener_Filters(t *testing.T) {
+	source := &fakeScreenerSource{
+		stats: []MarketStat{
+			{Symbol: "BTCUSDT", QuoteVolume: 9e9, Bid: 99.99, Ask: 100.01, FundingRate: 0.0001},
+			{Symbol: "ETHUSDT", QuoteVolume: 5e9, Bid: 99.99, Ask: 100.01, FundingRate: 0.0001},
+			{Symbol: "THINUSDT", QuoteVolume: 1e5, Bid: 99.99, Ask: 100.01},
+			{Symbol: "WIDEUSDT", QuoteVolume: 2e9, Bid: 99, Ask: 101},
+			{Symbol: "HOTUSDT", QuoteVolume: 3e9, Bid: 99.99, Ask: 100.01, FundingRate: -0.003},
+			{Symbol: "CALMUSDT", QuoteVolume: 4e9, Bid: 99.99, Ask: 100.01},
+			{Symbol: "SOLUSDT", QuoteVolume: 1e9, Bid: 99.99, Ask: 100.01},
+			{Symbol: "BTCBUSD", QuoteVolume: 9e9, Bid: 99.99, Ask: 100.01},
+		},
+		klines: map[string][]Kline{
+			"BTCUSDT":  volatileKlines(15, 100, 2),
+			"ETHUSDT":  volatileKlines(15, 100, 3),
+			"CALMUSDT": volatileKlines(15, 100, 0.2),
+			"SOLUSDT":  volatileKlines(15, 100, 4),
+		},
+	}
+	screener, err := NewScreener(ScreenerConfig{
+		Name:              "test",
+		MinQuoteVolume:    1e6,
+		MaxSpreadBps:      10,
+		MinATRPct:         1,
+		MaxATRPct:         5,
+		MaxAbsFundingRate: 0.001,
+		Exclude:           []string{"solusdt"},
+	}, source)
+	if err != nil {
+		t.Fatalf("new screener: %v", err)
+	}
+
+	result, err := screener.Screen()
+	if err != nil {
+		t.Fatalf("screen: %v", err)
+	}
+
+	if got := result.SymbolList(); !reflect.DeepEqual(got, []string{"BTCUSDT", "ETHUSDT"}) {
+		t.Fatalf("expected [BTCUSDT ETHUSDT] ordered by volume, got %v", got)
+	}
+	for _, symbol := range []string{"THINUSDT", "WIDEUSDT", "HOTUSDT", "CALMUSDT", "SOLUSDT"} {
+		if result.Rejected[symbol] == "" {
+			t.Errorf("expected %s to be rejected", symbol)
+		}
+	}
+	if _, ok := result.Rejected["BTCBUSD"]; ok {
+		t.Error("non-quote-asset symbols should be skipped silently")
+	}
+	// ATR只对通过初筛的交易对计算
+	if !reflect.DeepEqual(source.klineCalls, []string{"BTCUSDT", "ETHUSDT", "CALMUSDT"}) {
+		t.Errorf("unexpected kline requests: %v", source.klineCalls)
+	}
+	if atr := result.Symbols[0].ATRPct; atr < 1.9 || atr > 2.1 {
+		t.Errorf("expected BTC ATR%% ~2, got %.2f", atr)
+	}
+}
+
+func TestScreener_MaxSymbolsAndUpdates(t *testing.T) {
+	source := &fakeScreenerSource{stats: []MarketStat{
+		{Symbol: "AUSDT", QuoteVolume: 3},
+		{Symbol: "BUSDT", QuoteVolume: 2},
+		{Symbol: "CUSDT", QuoteVolume: 1},
+	}}
+	screener, err := NewScreener(ScreenerConfig{Name: "top2", MaxSymbols: 2}, source)
+	if err != nil {
+		t.Fatalf("new screener: %v", err)
+	}
+
+	var updates []ScreenerUpdate
+	screener.Subscribe(func(u ScreenerUpdate) { updates = append(updates, u) })
+
+	if _, err := screener.Screen(); err != nil {
+		t.Fatalf("screen: %v", err)
+	}
+	if !reflect.DeepEqual(screener.Active(), []string{"AUSDT", "BUSDT"}) {
+		t.Fatalf("unexpected active list: %v", screener.Active())
+	}
+
+	// 无变化不通知
+	screener.Screen()
+	if len(updates) != 1 {
+		t.Fatalf("expected 1 update, got %d", len(updates))
+	}
+
+	source.stats[2].QuoteVolume = 10
+	screener.Screen()
+	if len(updates) != 2 {
+		t.Fatalf("expected 2 updates, got %d", len(updates))
+	}
+	if !reflect.DeepEqual(updates[1].Added, []string{"CUSDT"}) || !reflect.DeepEqual(updates[1].Removed, []string{"BUSDT"}) {
+		t.Errorf("unexpected diff: added=%v removed=%v", updates[1].Added, updates[1].Removed)
+	}
+
+	// 数据源失败时保留上一轮活跃列表
+	source.statsErr = errors.New("down")
+	if _, err := screener.Screen(); err == nil {
+		t.Fatal("expected error")
+	}
+	if !reflect.DeepEqual(screener.Active(), []string{"CUSDT", "AUSDT"}) {
+		t.Errorf("active list should be kept on error, got %v", screener.Active())
+	}
+}
+
+func TestScreenerConfig_Validate(t *testing.T) {
+	invalid := []ScreenerConfig{
+		{MinQuoteVolume: -1},
+		{MinATRPct: 5, MaxATRPct: 1},
+		{ATRTimeFrame: "2h"},
+		{MaxSymbols: -1},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+}
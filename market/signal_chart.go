@@ -0,0 +1,188 @@
+package market
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	defaultChartCandles = 40 // 图中展示的K线根数
+	defaultChartHeight  = 16 // ASCII 图的行数
+)
+
+// chartRange 计算K线和关键价位的价格范围
+func chartRange(klines []Kline, levels ...float64) (low, high float64) {
+	low, high = math.Inf(1), math.Inf(-1)
+	for _, k := range klines {
+		low = math.Min(low, k.Low)
+		high = math.Max(high, k.High)
+	}
+	for _, level := range levels {
+		if level > 0 {
+			low = math.Min(low, level)
+			high = math.Max(high, level)
+		}
+	}
+	if high <= low {
+		high = low + 1
+	}
+	return low, high
+}
+
+// lastCandles 截取最后 n 根K线
+func lastCandles(klines []Kline, n int) []Kline {
+	if n > 0 && len(klines) > n {
+		return klines[len(klines)-n:]
+	}
+	return klines
+}
+
+// RenderSignalASCII 用ASCII字符绘制信号附近的K线和入场/止损价位
+// '#' 阳线实体，'O' 阴线实体，'|' 影线，'-' 入场价，'~' 止损价
+func RenderSignalASCII(klines []Kline, sig *TradingSignal, height int) string {
+	klines = lastCandles(klines, defaultChartCandles)
+	if len(klines) == 0 || sig == nil {
+		return ""
+	}
+	if height < 4 {
+		height = defaultChartHeight
+	}
+
+	low, high := chartRange(klines, sig.Price, sig.StopLoss)
+	rowOf := func(price float64) int {
+		return int(math.Round((high - price) / (high - low) * float64(height-1)))
+	}
+	priceRow, stopRow := -1, -1
+	if sig.Price > 0 {
+		priceRow = rowOf(sig.Price)
+	}
+	if sig.StopLoss > 0 {
+		stopRow = rowOf(sig.StopLoss)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s %s %s %s (强度%d%%)\n", sig.Symbol, sig.TimeFrame, sig.SignalType, sig.Direction, sig.Confidence))
+	for row := 0; row < height; row++ {
+		for _, k := range klines {
+			bodyTop, bodyBottom := rowOf(math.Max(k.Open, k.Close)), rowOf(math.Min(k.Open, k.Close))
+			switch {
+			case row >= bodyTop && row <= bodyBottom:
+				if k.Close >= k.Open {
+					sb.WriteByte('#')
+				} else {
+					sb.WriteByte('O')
+				}
+			case row >= rowOf(k.High) && row <= rowOf(k.Low):
+				sb.WriteByte('|')
+			case row == priceRow:
+				sb.WriteByte('-')
+			case row == stopRow:
+				sb.WriteByte('~')
+			default:
+				sb.WriteByte(' ')
+			}
+		}
+
+		label := high - float64(row)*(high-low)/float64(height-1)
+		sb.WriteString(fmt.Sprintf(" %.4f", label))
+		if row == priceRow {
+			sb.WriteString(fmt.Sprintf(" < 入场 %.4f", sig.Price))
+		}
+		if row == stopRow {
+			sb.WriteString(fmt.Sprintf(" < 止损 %.4f", sig.StopLoss))
+		}
+		sb.WriteByte('\n')
+	}
+	return sb.String()
+}
+
+// RenderSignalSVG 绘制信号附近的K线和入场/止损价位（SVG）
+func RenderSignalSVG(klines []Kline, sig *TradingSignal) []byte {
+	klines = lastCandles(klines, defaultChartCandles)
+	if len(klines) == 0 || sig == nil {
+		return nil
+	}
+
+	const (
+		candleWidth = 8
+		plotHeight  = 240
+		padTop      = 24
+		padRight    = 90
+	)
+	width := len(klines)*candleWidth + padRight
+	height := plotHeight + padTop + 8
+	low, high := chartRange(klines, sig.Price, sig.StopLoss)
+	y := func(price float64) float64 {
+		return padTop + (high-price)/(high-low)*plotHeight
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" font-family="monospace" font-size="11">`+"\n", width, height)
+	fmt.Fprintf(&buf, `<rect width="100%%" height="100%%" fill="#ffffff"/>`+"\n")
+	fmt.Fprintf(&buf, `<text x="4" y="14">%s %s %s %s (%d%%)</text>`+"\n",
+		sig.Symbol, sig.TimeFrame, sig.SignalType, sig.Direction, sig.Confidence)
+
+	for i, k := range klines {
+		x := float64(i*candleWidth) + candleWidth/2
+		color := "#26a69a"
+		if k.Close < k.Open {
+			color = "#ef5350"
+		}
+		top, bottom := y(math.Max(k.Open, k.Close)), y(math.Min(k.Open, k.Close))
+		if bottom-top < 1 {
+			bottom = top + 1
+		}
+		fmt.Fprintf(&buf, `<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="%s"/>`+"\n", x, y(k.High), x, y(k.Low), color)
+		fmt.Fprintf(&buf, `<rect x="%.1f" y="%.1f" width="%d" height="%.1f" fill="%s"/>`+"\n",
+			x-candleWidth/2+1, top, candleWidth-2, bottom-top, color)
+	}
+
+	plotRight := len(klines) * candleWidth
+	level := func(price float64, color, label string) {
+		if price <= 0 {
+			return
+		}
+		fmt.Fprintf(&buf, `<line x1="0" y1="%.1f" x2="%d" y2="%.1f" stroke="%s" stroke-dasharray="4,3"/>`+"\n",
+			y(price), plotRight, y(price), color)
+		fmt.Fprintf(&buf, `<text x="%d" y="%.1f" fill="%s">%s %.4f</text>`+"\n", plotRight+4, y(price)+4, color, label, price)
+	}
+	level(sig.Price, "#1e88e5", "entry")
+	level(sig.StopLoss, "#d81b60", "stop")
+	buf.WriteString("</svg>\n")
+	return buf.Bytes()
+}
+
+// SignalChartDumper 信号触发时把K线图写入目录（用于核对形态识别结果）
+type SignalChartDumper struct {
+	dir string
+}
+
+// NewSignalChartDumper 创建信号图输出器
+func NewSignalChartDumper(dir string) (*SignalChartDumper, error) {
+	if dir == "" {
+		return nil, fmt.Errorf("信号图目录不能为空")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建信号图目录失败: %w", err)
+	}
+	return &SignalChartDumper{dir: dir}, nil
+}
+
+// Dump 写入SVG信号图，返回文件路径和ASCII渲染结果
+func (d *SignalChartDumper) Dump(sig *TradingSignal, klines []Kline) (string, string, error) {
+	svg := RenderSignalSVG(klines, sig)
+	if svg == nil {
+		return "", "", fmt.Errorf("%s %s 没有可绘制的K线", sig.Symbol, sig.TimeFrame)
+	}
+	name := fmt.Sprintf("%s_%s_%s_%d.svg", sig.Symbol, sig.TimeFrame, sig.SignalType, time.Now().UnixMilli())
+	path := filepath.Join(d.dir, name)
+	if err := os.WriteFile(path, svg, 0644); err != nil {
+		return "", "", fmt.Errorf("写入信号图失败: %w", err)
+	}
+	return path, RenderSignalASCII(klines, sig, defaultChartHeight), nil
+}
@@ -0,0 +1,76 @@
+package market
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func chartTestKlines() []Kline {
+	return []Kline{
+		{Open: 100, High: 103, Low: 99, Close: 102},
+		{Open: 102, High: 104, Low: 100, Close: 101},
+		{Open: 101, High: 102, Low: 94, Close: 101.5}, // 长下影线
+	}
+}
+
+func TestRenderSignalASCII(t *testing.T) {
+	sig := &TradingSignal{Symbol: "BTCUSDT", TimeFrame: TimeFrame1h, SignalType: SignalBullishPinBar,
+		Direction: "long", Price: 101.5, StopLoss: 93, Confidence: 85}
+	chart := RenderSignalASCII(chartTestKlines(), sig, 12)
+
+	lines := strings.Split(strings.TrimRight(chart, "\n"), "\n")
+	if len(lines) != 13 {
+		t.Fatalf("应输出标题+12行，实际 %d 行:\n%s", len(lines), chart)
+	}
+	if !strings.Contains(lines[0], "bullish_pin_bar") {
+		t.Errorf("标题应包含信号类型: %q", lines[0])
+	}
+	if !strings.Contains(chart, "< 入场 101.5000") || !strings.Contains(chart, "< 止损 93.0000") {
+		t.Errorf("应标注入场和止损价位:\n%s", chart)
+	}
+	// 止损在最低处（最后一行）
+	if !strings.HasPrefix(lines[12], "~~~") {
+		t.Errorf("最后一行应为止损线: %q", lines[12])
+	}
+	for _, c := range []string{"#", "O", "|"} {
+		if !strings.Contains(chart, c) {
+			t.Errorf("图中应包含 %q:\n%s", c, chart)
+		}
+	}
+	if RenderSignalASCII(nil, sig, 12) != "" {
+		t.Error("没有K线时应返回空字符串")
+	}
+}
+
+func TestSignalChartDumper(t *testing.T) {
+	dumper, err := NewSignalChartDumper(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	sig := &TradingSignal{Symbol: "ETHUSDT", TimeFrame: TimeFrame15m, SignalType: SignalEngulfing,
+		Direction: "short", Price: 101, StopLoss: 105, Confidence: 90}
+
+	path, ascii, err := dumper.Dump(sig, chartTestKlines())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasSuffix(path, ".svg") || !strings.Contains(path, "ETHUSDT_15m_engulfing") {
+		t.Errorf("文件名不符合预期: %s", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	svg := string(data)
+	if !strings.HasPrefix(svg, "<svg") || strings.Count(svg, "<rect") != 4 || !strings.Contains(svg, "stop 105.0000") {
+		t.Errorf("SVG内容不符合预期:\n%s", svg)
+	}
+	if ascii == "" {
+		t.Error("应同时返回ASCII渲染结果")
+	}
+
+	if _, _, err := dumper.Dump(sig, nil); err == nil {
+		t.Error("没有K线时应返回错误")
+	}
+}
@@ -14,6 +14,8 @@ const (
 	SignalBearishPinBar SignalType = "bearish_pin_bar"  // 看跌针状线
 	SignalVolumeSpike   SignalType = "volume_spike"     // 成交量激增
 	SignalEngulfing     SignalType = "engulfing"        // 吞没形态
+	SignalWilliamsR     SignalType = "williams_r"       // 威廉指标超买超卖反转
+	SignalMomentum      SignalType = "momentum"         // ROC动量穿越零轴
 )
 
 // TradingSignal 交易信号
@@ -30,7 +32,11 @@ type TradingSignal struct {
 
 // SignalDetector 信号检测器
 type SignalDetector struct {
-	cache *KlineCache
+	cache      *KlineCache
+	stops      stopModelSet  // 止损模型配置
+	trend      *TrendFilter  // 高周期趋势过滤（nil 表示不过滤）
+	confidence confidenceSet // 历史校准的信号强度（未配置时使用手工设定）
+	quiet      bool          // 不输出信号日志（历史回放校准时使用）
 }
 
 // NewSignalDetector 创建信号检测器
@@ -40,11 +46,24 @@ func NewSignalDetector() *SignalDetector {
 	}
 }
 
+// NewSignalDetectorWithCache 创建使用指定K线缓存的信号检测器（如合成行情的独立缓存）
+func NewSignalDetectorWithCache(cache *KlineCache) *SignalDetector {
+	return &SignalDetector{
+		cache: cache,
+	}
+}
+
 // DetectAllSignals 检测所有信号（锤子线 + 成交量放大）
 func (sd *SignalDetector) DetectAllSignals(symbol string, timeFrames []TimeFrame) []*TradingSignal {
 	var signals []*TradingSignal
 
 	for _, tf := range timeFrames {
+		// 数据过期时不产生信号，避免基于旧K线开仓
+		if sd.cache.IsStale(symbol, tf) {
+			log.Printf("⏸️ [Signal] %s %s K线数据已过期，跳过信号检测", symbol, tf)
+			continue
+		}
+
 		// 检测Pin Bar（锤子线）
 		pinBarSignals := sd.DetectPinBar(symbol, tf)
 		signals = append(signals, pinBarSignals...)
@@ -58,6 +77,9 @@ func (sd *SignalDetector) DetectAllSignals(symbol string, timeFrames []TimeFrame
 		signals = append(signals, engulfingSignals...)
 	}
 
+	if sd.trend != nil {
+		signals = sd.trend.Filter(signals)
+	}
 	return signals
 }
 
@@ -91,7 +113,7 @@ func (sd *SignalDetector) DetectPinBar(symbol string, timeFrame TimeFrame) []*Tr
 	// 2. 实体 < K线总长度的30%
 	// 3. 上影线很短（< 实体长度）
 	if lowerShadow > body*1.5 && body < totalRange*0.3 && upperShadow < body {
-		confidence := calculatePinBarConfidence(lowerShadow, body, upperShadow, totalRange)
+		confidence := sd.calibrated(DetectorPinBar, symbol, timeFrame, calculatePinBarConfidence(lowerShadow, body, upperShadow, totalRange))
 
 		signal := &TradingSignal{
 			Symbol:     symbol,
@@ -99,13 +121,13 @@ func (sd *SignalDetector) DetectPinBar(symbol string, timeFrame TimeFrame) []*Tr
 			SignalType: SignalBullishPinBar,
 			Direction:  "long",
 			Price:      kline.Close,
-			StopLoss:   kline.Low * 0.997, // 止损设在最低点下方0.3%
+			StopLoss:   sd.stopLoss(DetectorPinBar, symbol, timeFrame, kline, "long"),
 			Confidence: confidence,
 			Reason:     fmt.Sprintf("看涨Pin Bar: 下影线%.2f%%, 实体%.2f%%", (lowerShadow/totalRange)*100, (body/totalRange)*100),
 		}
 		signals = append(signals, signal)
 
-		log.Printf("🔔 [Signal] %s %s - 看涨Pin Bar (强度:%d%%) | 价格:%.2f | 止损:%.2f",
+		sd.logSignal("🔔 [Signal] %s %s - 看涨Pin Bar (强度:%d%%) | 价格:%.2f | 止损:%.2f",
 			symbol, timeFrame, confidence, signal.Price, signal.StopLoss)
 	}
 
@@ -115,7 +137,7 @@ func (sd *SignalDetector) DetectPinBar(symbol string, timeFrame TimeFrame) []*Tr
 	// 2. 实体 < K线总长度的30%
 	// 3. 下影线很短（< 实体长度）
 	if upperShadow > body*1.5 && body < totalRange*0.3 && lowerShadow < body {
-		confidence := calculatePinBarConfidence(upperShadow, body, lowerShadow, totalRange)
+		confidence := sd.calibrated(DetectorPinBar, symbol, timeFrame, calculatePinBarConfidence(upperShadow, body, lowerShadow, totalRange))
 
 		signal := &TradingSignal{
 			Symbol:     symbol,
@@ -123,19 +145,26 @@ func (sd *SignalDetector) DetectPinBar(symbol string, timeFrame TimeFrame) []*Tr
 			SignalType: SignalBearishPinBar,
 			Direction:  "short",
 			Price:      kline.Close,
-			StopLoss:   kline.High * 1.003, // 止损设在最高点上方0.3%
+			StopLoss:   sd.stopLoss(DetectorPinBar, symbol, timeFrame, kline, "short"),
 			Confidence: confidence,
 			Reason:     fmt.Sprintf("看跌Pin Bar: 上影线%.2f%%, 实体%.2f%%", (upperShadow/totalRange)*100, (body/totalRange)*100),
 		}
 		signals = append(signals, signal)
 
-		log.Printf("🔔 [Signal] %s %s - 看跌Pin Bar (强度:%d%%) | 价格:%.2f | 止损:%.2f",
+		sd.logSignal("🔔 [Signal] %s %s - 看跌Pin Bar (强度:%d%%) | 价格:%.2f | 止损:%.2f",
 			symbol, timeFrame, confidence, signal.Price, signal.StopLoss)
 	}
 
 	return signals
 }
 
+// logSignal 输出信号日志（历史回放时不输出）
+func (sd *SignalDetector) logSignal(format string, args ...interface{}) {
+	if !sd.quiet {
+		log.Printf(format, args...)
+	}
+}
+
 // calculatePinBarConfidence 计算Pin Bar信号强度
 func calculatePinBarConfidence(shadowLength, body, oppositeShadow, totalRange float64) int {
 	// 基础分数
@@ -213,6 +242,7 @@ func (sd *SignalDetector) DetectVolumeSpike(symbol string, timeFrame TimeFrame)
 		} else if volumeRatio >= 1.8 {
 			confidence = 80
 		}
+		confidence = sd.calibrated(DetectorVolumeSpike, symbol, timeFrame, confidence)
 
 		signal := &TradingSignal{
 			Symbol:     symbol,
@@ -220,13 +250,13 @@ func (sd *SignalDetector) DetectVolumeSpike(symbol string, timeFrame TimeFrame)
 			SignalType: SignalVolumeSpike,
 			Direction:  direction,
 			Price:      currentKline.Close,
-			StopLoss:   calculateStopLoss(currentKline, direction),
+			StopLoss:   sd.stopLoss(DetectorVolumeSpike, symbol, timeFrame, currentKline, direction),
 			Confidence: confidence,
 			Reason:     fmt.Sprintf("成交量放大%.1fx (%.0f -> %.0f)", volumeRatio, prevKline.Volume, currentKline.Volume),
 		}
 		signals = append(signals, signal)
 
-		log.Printf("🔔 [Signal] %s %s - 成交量放大%.1fx (强度:%d%%) | 方向:%s | 价格:%.2f",
+		sd.logSignal("🔔 [Signal] %s %s - 成交量放大%.1fx (强度:%d%%) | 方向:%s | 价格:%.2f",
 			symbol, timeFrame, volumeRatio, confidence, direction, signal.Price)
 	}
 
@@ -261,6 +291,7 @@ func (sd *SignalDetector) DetectEngulfing(symbol string, timeFrame TimeFrame) []
 		if currentBody > prevBody*1.5 {
 			confidence = 90
 		}
+		confidence = sd.calibrated(DetectorEngulfing, symbol, timeFrame, confidence)
 
 		signal := &TradingSignal{
 			Symbol:     symbol,
@@ -268,13 +299,13 @@ func (sd *SignalDetector) DetectEngulfing(symbol string, timeFrame TimeFrame) []
 			SignalType: SignalEngulfing,
 			Direction:  "long",
 			Price:      currentKline.Close,
-			StopLoss:   currentKline.Low * 0.995, // 止损设在当前K线最低点下方0.5%
+			StopLoss:   sd.stopLoss(DetectorEngulfing, symbol, timeFrame, currentKline, "long"),
 			Confidence: confidence,
 			Reason:     "看涨吞没形态",
 		}
 		signals = append(signals, signal)
 
-		log.Printf("🔔 [Signal] %s %s - 看涨吞没 (强度:%d%%) | 价格:%.2f",
+		sd.logSignal("🔔 [Signal] %s %s - 看涨吞没 (强度:%d%%) | 价格:%.2f",
 			symbol, timeFrame, confidence, signal.Price)
 	}
 
@@ -290,6 +321,7 @@ func (sd *SignalDetector) DetectEngulfing(symbol string, timeFrame TimeFrame) []
 		if currentBody > prevBody*1.5 {
 			confidence = 90
 		}
+		confidence = sd.calibrated(DetectorEngulfing, symbol, timeFrame, confidence)
 
 		signal := &TradingSignal{
 			Symbol:     symbol,
@@ -297,25 +329,130 @@ func (sd *SignalDetector) DetectEngulfing(symbol string, timeFrame TimeFrame) []
 			SignalType: SignalEngulfing,
 			Direction:  "short",
 			Price:      currentKline.Close,
-			StopLoss:   currentKline.High * 1.005, // 止损设在当前K线最高点上方0.5%
+			StopLoss:   sd.stopLoss(DetectorEngulfing, symbol, timeFrame, currentKline, "short"),
 			Confidence: confidence,
 			Reason:     "看跌吞没形态",
 		}
 		signals = append(signals, signal)
 
-		log.Printf("🔔 [Signal] %s %s - 看跌吞没 (强度:%d%%) | 价格:%.2f",
+		sd.logSignal("🔔 [Signal] %s %s - 看跌吞没 (强度:%d%%) | 价格:%.2f",
 			symbol, timeFrame, confidence, signal.Price)
 	}
 
 	return signals
 }
 
-// calculateStopLoss 计算止损价格
-func calculateStopLoss(kline Kline, direction string) float64 {
-	if direction == "long" {
-		return kline.Low * 0.997 // 做多止损在最低点下方0.3%
+// 振荡指标参数
+const (
+	williamsRPeriod     = 14
+	williamsROversold   = -80.0
+	williamsROverbought = -20.0
+	rocPeriod           = 12
+)
+
+// DetectWilliamsR 检测威廉指标超买超卖反转
+// 标准：%R 从 -80 以下回升到 -80 以上做多；从 -20 以上回落到 -20 以下做空
+func (sd *SignalDetector) DetectWilliamsR(symbol string, timeFrame TimeFrame) []*TradingSignal {
+	var signals []*TradingSignal
+
+	klines, err := sd.cache.GetKlines(symbol, timeFrame, williamsRPeriod+1)
+	if err != nil || len(klines) < williamsRPeriod+1 {
+		return signals
+	}
+
+	prev := CalculateWilliamsR(klines[:len(klines)-1], williamsRPeriod)
+	curr := CalculateWilliamsR(klines, williamsRPeriod)
+	currentKline := klines[len(klines)-1]
+
+	var direction, reason string
+	var extreme float64
+	switch {
+	case prev < williamsROversold && curr >= williamsROversold:
+		direction, extreme = "long", prev
+		reason = fmt.Sprintf("威廉指标超卖回升 (%.1f -> %.1f)", prev, curr)
+	case prev > williamsROverbought && curr <= williamsROverbought:
+		direction, extreme = "short", prev
+		reason = fmt.Sprintf("威廉指标超买回落 (%.1f -> %.1f)", prev, curr)
+	default:
+		return signals
+	}
+
+	// 前值越极端（接近-100或0），信号越强
+	confidence := 70
+	if extreme <= -95 || extreme >= -5 {
+		confidence = 85
+	} else if extreme <= -90 || extreme >= -10 {
+		confidence = 80
+	}
+	confidence = sd.calibrated(DetectorWilliamsR, symbol, timeFrame, confidence)
+
+	signal := &TradingSignal{
+		Symbol:     symbol,
+		TimeFrame:  timeFrame,
+		SignalType: SignalWilliamsR,
+		Direction:  direction,
+		Price:      currentKline.Close,
+		StopLoss:   sd.stopLoss(DetectorWilliamsR, symbol, timeFrame, currentKline, direction),
+		Confidence: confidence,
+		Reason:     reason,
+	}
+	signals = append(signals, signal)
+
+	sd.logSignal("🔔 [Signal] %s %s - %s (强度:%d%%) | 方向:%s | 价格:%.2f",
+		symbol, timeFrame, reason, confidence, direction, signal.Price)
+
+	return signals
+}
+
+// DetectMomentum 检测ROC动量穿越零轴
+// 标准：ROC 由负转正做多，由正转负做空
+func (sd *SignalDetector) DetectMomentum(symbol string, timeFrame TimeFrame) []*TradingSignal {
+	var signals []*TradingSignal
+
+	klines, err := sd.cache.GetKlines(symbol, timeFrame, rocPeriod+2)
+	if err != nil || len(klines) < rocPeriod+2 {
+		return signals
+	}
+
+	prev := CalculateROC(klines[:len(klines)-1], rocPeriod)
+	curr := CalculateROC(klines, rocPeriod)
+	currentKline := klines[len(klines)-1]
+
+	var direction string
+	switch {
+	case prev <= 0 && curr > 0:
+		direction = "long"
+	case prev >= 0 && curr < 0:
+		direction = "short"
+	default:
+		return signals
+	}
+
+	// 穿越后的动量越大，信号越强
+	confidence := 70
+	if math.Abs(curr) >= 2 {
+		confidence = 85
+	} else if math.Abs(curr) >= 1 {
+		confidence = 80
+	}
+	confidence = sd.calibrated(DetectorMomentum, symbol, timeFrame, confidence)
+
+	signal := &TradingSignal{
+		Symbol:     symbol,
+		TimeFrame:  timeFrame,
+		SignalType: SignalMomentum,
+		Direction:  direction,
+		Price:      currentKline.Close,
+		StopLoss:   sd.stopLoss(DetectorMomentum, symbol, timeFrame, currentKline, direction),
+		Confidence: confidence,
+		Reason:     fmt.Sprintf("ROC(%d)穿越零轴 (%.2f%% -> %.2f%%)", rocPeriod, prev, curr),
 	}
-	return kline.High * 1.003 // 做空止损在最高点上方0.3%
+	signals = append(signals, signal)
+
+	sd.logSignal("🔔 [Signal] %s %s - ROC穿越零轴 %.2f%% (强度:%d%%) | 方向:%s | 价格:%.2f",
+		symbol, timeFrame, curr, confidence, direction, signal.Price)
+
+	return signals
 }
 
 // FilterStrongSignals 过滤强信号（信心度>=80的信号）
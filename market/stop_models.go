@@ -0,0 +1,248 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+// 信号检测器名称（用于按检测器配置止损模型）
+const (
+	DetectorPinBar      = "pin_bar"
+	DetectorVolumeSpike = "volume_spike"
+	DetectorEngulfing   = "engulfing"
+	DetectorWilliamsR   = "williams_r"
+	DetectorMomentum    = "momentum"
+)
+
+// 止损模型类型
+const (
+	StopModelPercent   = "percent"   // 信号K线影线极值外固定百分比
+	StopModelWickATR   = "wick_atr"  // 信号K线影线极值外加 ATR 缓冲
+	StopModelStructure = "structure" // 最近 N 根K线的摆动低点/高点外加百分比缓冲
+)
+
+// StopModel 止损价计算模型
+type StopModel interface {
+	// Required 计算所需的K线数量（含信号K线）
+	Required() int
+	// StopLoss 根据K线（最后一根为信号K线）计算止损价，无法计算时返回0
+	StopLoss(klines []Kline, direction string) float64
+}
+
+// percentStopModel 影线极值外固定百分比
+type percentStopModel struct {
+	percent float64
+}
+
+func (m percentStopModel) Required() int { return 1 }
+
+func (m percentStopModel) StopLoss(klines []Kline, direction string) float64 {
+	if len(klines) == 0 {
+		return 0
+	}
+	k := klines[len(klines)-1]
+	if direction == "long" {
+		return k.Low * (1 - m.percent/100)
+	}
+	return k.High * (1 + m.percent/100)
+}
+
+// wickATRStopModel 影线极值外加 multiplier × ATR
+type wickATRStopModel struct {
+	period     int
+	multiplier float64
+}
+
+func (m wickATRStopModel) Required() int { return m.period + 1 }
+
+func (m wickATRStopModel) StopLoss(klines []Kline, direction string) float64 {
+	atr := CalculateATR(klines, m.period)
+	if atr <= 0 {
+		return 0
+	}
+	k := klines[len(klines)-1]
+	if direction == "long" {
+		return k.Low - m.multiplier*atr
+	}
+	return k.High + m.multiplier*atr
+}
+
+// structureStopModel 最近 lookback 根K线的摆动低点（做多）/高点（做空）外加百分比缓冲
+type structureStopModel struct {
+	lookback int
+	buffer   float64
+}
+
+func (m structureStopModel) Required() int { return m.lookback }
+
+func (m structureStopModel) StopLoss(klines []Kline, direction string) float64 {
+	if len(klines) == 0 {
+		return 0
+	}
+	window := klines[max(0, len(klines)-m.lookback):]
+	if direction == "long" {
+		low := window[0].Low
+		for _, k := range window[1:] {
+			low = math.Min(low, k.Low)
+		}
+		return low * (1 - m.buffer/100)
+	}
+	high := window[0].High
+	for _, k := range window[1:] {
+		high = math.Max(high, k.High)
+	}
+	return high * (1 + m.buffer/100)
+}
+
+// StopModelConfig 止损模型配置
+type StopModelConfig struct {
+	Type          string  `json:"type"`           // percent / wick_atr / structure
+	Percent       float64 `json:"percent"`        // percent: 距影线极值的百分比；structure: 距摆动高低点的缓冲百分比
+	ATRPeriod     int     `json:"atr_period"`     // wick_atr: ATR周期（默认14）
+	ATRMultiplier float64 `json:"atr_multiplier"` // wick_atr: ATR倍数
+	Lookback      int     `json:"lookback"`       // structure: 回看K线数量
+}
+
+// Build 根据配置创建止损模型
+func (c StopModelConfig) Build() (StopModel, error) {
+	switch c.Type {
+	case StopModelPercent:
+		if c.Percent <= 0 {
+			return nil, fmt.Errorf("percent 止损模型的百分比必须大于0")
+		}
+		return percentStopModel{percent: c.Percent}, nil
+	case StopModelWickATR:
+		period := c.ATRPeriod
+		if period == 0 {
+			period = 14
+		}
+		if period < 0 || c.ATRMultiplier <= 0 {
+			return nil, fmt.Errorf("wick_atr 止损模型的ATR周期和倍数必须大于0")
+		}
+		return wickATRStopModel{period: period, multiplier: c.ATRMultiplier}, nil
+	case StopModelStructure:
+		if c.Lookback < 2 {
+			return nil, fmt.Errorf("structure 止损模型的回看数量不能小于2")
+		}
+		if c.Percent < 0 {
+			return nil, fmt.Errorf("structure 止损模型的缓冲百分比不能为负数")
+		}
+		return structureStopModel{lookback: c.Lookback, buffer: c.Percent}, nil
+	default:
+		return nil, fmt.Errorf("未知的止损模型: %q", c.Type)
+	}
+}
+
+// StopModelRule 按检测器和时间周期指定止损模型（Detector/TimeFrame 为空表示匹配全部）
+type StopModelRule struct {
+	Detector  string          `json:"detector"`
+	TimeFrame TimeFrame       `json:"timeframe"`
+	Model     StopModelConfig `json:"model"`
+}
+
+// StopModelsConfig 信号检测器的止损模型配置
+// 匹配优先级：检测器+周期 > 仅检测器 > 仅周期 > Default > 内置默认（影线外0.3%，吞没形态0.5%）
+type StopModelsConfig struct {
+	Default *StopModelConfig `json:"default,omitempty"`
+	Rules   []StopModelRule  `json:"rules,omitempty"`
+}
+
+// stopModelKey 止损模型查找键
+type stopModelKey struct {
+	detector  string
+	timeFrame TimeFrame
+}
+
+// stopModelSet 编译后的止损模型配置
+type stopModelSet struct {
+	fallback StopModel
+	models   map[stopModelKey]StopModel
+	mu       sync.RWMutex
+}
+
+// builtinStopModels 各检测器原有的止损偏移
+var builtinStopModels = map[string]StopModel{
+	DetectorEngulfing: percentStopModel{percent: 0.5},
+}
+
+// defaultStopModel 未配置时使用的止损模型（影线极值外0.3%）
+var defaultStopModel StopModel = percentStopModel{percent: 0.3}
+
+// set 替换全部配置
+func (s *stopModelSet) set(cfg StopModelsConfig) error {
+	models := make(map[stopModelKey]StopModel, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		model, err := rule.Model.Build()
+		if err != nil {
+			return fmt.Errorf("止损规则 #%d (%s/%s): %w", i+1, rule.Detector, rule.TimeFrame, err)
+		}
+		if rule.TimeFrame != "" {
+			if _, ok := TimeFrameMinutes[rule.TimeFrame]; !ok {
+				return fmt.Errorf("止损规则 #%d: 未知的时间周期 %q", i+1, rule.TimeFrame)
+			}
+		}
+		models[stopModelKey{rule.Detector, rule.TimeFrame}] = model
+	}
+
+	var fallback StopModel
+	if cfg.Default != nil {
+		model, err := cfg.Default.Build()
+		if err != nil {
+			return fmt.Errorf("默认止损模型: %w", err)
+		}
+		fallback = model
+	}
+
+	s.mu.Lock()
+	s.models = models
+	s.fallback = fallback
+	s.mu.Unlock()
+	return nil
+}
+
+// resolve 查找检测器在指定周期使用的止损模型
+func (s *stopModelSet) resolve(detector string, tf TimeFrame) StopModel {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, key := range []stopModelKey{{detector, tf}, {detector, ""}, {"", tf}} {
+		if model, ok := s.models[key]; ok {
+			return model
+		}
+	}
+	if s.fallback != nil {
+		return s.fallback
+	}
+	if model, ok := builtinStopModels[detector]; ok {
+		return model
+	}
+	return defaultStopModel
+}
+
+// SetStopModels 设置止损模型配置（配置无效时保留原配置并返回错误）
+func (sd *SignalDetector) SetStopModels(cfg StopModelsConfig) error {
+	return sd.stops.set(cfg)
+}
+
+// stopLoss 按配置的止损模型计算信号止损价
+// 历史K线不足或模型无法计算时退回到影线极值外0.3%
+func (sd *SignalDetector) stopLoss(detector, symbol string, timeFrame TimeFrame, kline Kline, direction string) float64 {
+	model := sd.stops.resolve(detector, timeFrame)
+
+	klines := []Kline{kline}
+	if n := model.Required(); n > 1 {
+		if history, err := sd.cache.GetKlines(symbol, timeFrame, n); err == nil && len(history) > 0 {
+			klines = history
+			// 确保最后一根是信号K线
+			if last := history[len(history)-1]; last.OpenTime != kline.OpenTime {
+				klines = append(history, kline)
+			}
+		}
+	}
+
+	if stop := model.StopLoss(klines, direction); stop > 0 {
+		return stop
+	}
+	return defaultStopModel.StopLoss(klines, direction)
+}
@@ -0,0 +1,118 @@
+package market
+
+import (
+	"math"
+	"testing"
+)
+
+func almostEqual(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9
+}
+
+func TestStopModelConfig_Build(t *testing.T) {
+	invalid := []StopModelConfig{
+		{Type: "unknown"},
+		{Type: StopModelPercent},
+		{Type: StopModelWickATR},
+		{Type: StopModelStructure, Lookback: 1},
+		{Type: StopModelStructure, Lookback: 5, Percent: -1},
+	}
+	for _, cfg := range invalid {
+		if _, err := cfg.Build(); err == nil {
+			t.Errorf("expected error for %+v", cfg)
+		}
+	}
+
+	model, err := StopModelConfig{Type: StopModelWickATR, ATRMultiplier: 1}.Build()
+	if err != nil {
+		t.Fatalf("build failed: %v", err)
+	}
+	if model.Required() != 15 {
+		t.Errorf("expected default ATR period 14 (15 klines), got %d", model.Required())
+	}
+}
+
+func TestStopModels_Calculation(t *testing.T) {
+	klines := []Kline{
+		{High: 105, Low: 95, Close: 100},
+		{High: 104, Low: 90, Close: 101},
+		{High: 108, Low: 98, Close: 106},
+	}
+
+	percent := percentStopModel{percent: 1}
+	if got := percent.StopLoss(klines, "long"); !almostEqual(got, 98*0.99) {
+		t.Errorf("percent long: got %.4f", got)
+	}
+	if got := percent.StopLoss(klines, "short"); !almostEqual(got, 108*1.01) {
+		t.Errorf("percent short: got %.4f", got)
+	}
+
+	structure := structureStopModel{lookback: 3, buffer: 0}
+	if got := structure.StopLoss(klines, "long"); got != 90 {
+		t.Errorf("structure long should use swing low 90, got %.4f", got)
+	}
+	if got := structure.StopLoss(klines, "short"); got != 108 {
+		t.Errorf("structure short should use swing high 108, got %.4f", got)
+	}
+
+	wick := wickATRStopModel{period: 2, multiplier: 0.5}
+	atr := CalculateATR(klines, 2)
+	if atr <= 0 {
+		t.Fatalf("expected positive ATR")
+	}
+	if got := wick.StopLoss(klines, "long"); !almostEqual(got, 98-0.5*atr) {
+		t.Errorf("wick_atr long: got %.4f", got)
+	}
+	if got := wick.StopLoss(klines[:1], "long"); got != 0 {
+		t.Errorf("wick_atr without enough klines should return 0, got %.4f", got)
+	}
+}
+
+func TestSignalDetector_StopModelResolution(t *testing.T) {
+	klines := trendKlines(20, 100, 1)
+	sd := newTestDetector("BTCUSDT", TimeFrame1h, klines)
+	signal := klines[len(klines)-1]
+
+	// 未配置时保持原有偏移
+	if got := sd.stopLoss(DetectorPinBar, "BTCUSDT", TimeFrame1h, signal, "long"); !almostEqual(got, signal.Low*0.997) {
+		t.Errorf("builtin pin bar stop: got %.4f", got)
+	}
+	if got := sd.stopLoss(DetectorEngulfing, "BTCUSDT", TimeFrame1h, signal, "short"); !almostEqual(got, signal.High*1.005) {
+		t.Errorf("builtin engulfing stop: got %.4f", got)
+	}
+
+	err := sd.SetStopModels(StopModelsConfig{
+		Default: &StopModelConfig{Type: StopModelPercent, Percent: 2},
+		Rules: []StopModelRule{
+			{Detector: DetectorPinBar, Model: StopModelConfig{Type: StopModelStructure, Lookback: 5}},
+			{Detector: DetectorPinBar, TimeFrame: TimeFrame1h, Model: StopModelConfig{Type: StopModelWickATR, ATRPeriod: 5, ATRMultiplier: 1}},
+			{TimeFrame: TimeFrame4h, Model: StopModelConfig{Type: StopModelPercent, Percent: 1}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("set stop models: %v", err)
+	}
+
+	atr := CalculateATR(klines[len(klines)-6:], 5)
+	if got := sd.stopLoss(DetectorPinBar, "BTCUSDT", TimeFrame1h, signal, "long"); !almostEqual(got, signal.Low-atr) {
+		t.Errorf("detector+timeframe rule should use wick_atr, got %.4f want %.4f", got, signal.Low-atr)
+	}
+	// 4h 无K线数据：structure 模型只能使用信号K线本身
+	if got := sd.stopLoss(DetectorPinBar, "BTCUSDT", TimeFrame4h, signal, "long"); got != signal.Low {
+		t.Errorf("detector rule should use structure, got %.4f", got)
+	}
+	if got := sd.stopLoss(DetectorVolumeSpike, "BTCUSDT", TimeFrame4h, signal, "long"); !almostEqual(got, signal.Low*0.99) {
+		t.Errorf("timeframe rule should use 1%%, got %.4f", got)
+	}
+	if got := sd.stopLoss(DetectorEngulfing, "BTCUSDT", TimeFrame1h, signal, "long"); !almostEqual(got, signal.Low*0.98) {
+		t.Errorf("default should override builtin, got %.4f", got)
+	}
+
+	// 无效配置不影响现有配置
+	if err := sd.SetStopModels(StopModelsConfig{Rules: []StopModelRule{{TimeFrame: "2h", Model: StopModelConfig{Type: StopModelPercent, Percent: 1}}}}); err == nil {
+		t.Fatal("expected error for unknown timeframe")
+	}
+	if got := sd.stopLoss(DetectorEngulfing, "BTCUSDT", TimeFrame1h, signal, "long"); !almostEqual(got, signal.Low*0.98) {
+		t.Errorf("invalid config should keep previous models, got %.4f", got)
+	}
+}
@@ -0,0 +1,260 @@
+package market
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// 合成行情注入的形态
+const (
+	SyntheticPinBar      = "pin_bar"
+	SyntheticEngulfing   = "engulfing"
+	SyntheticVolumeSpike = "volume_spike"
+)
+
+// syntheticPatterns 依次轮流注入的形态
+var syntheticPatterns = []string{SyntheticPinBar, SyntheticEngulfing, SyntheticVolumeSpike}
+
+// SyntheticConfig 合成行情配置
+type SyntheticConfig struct {
+	Symbols      []string    // 交易对（默认 BTCUSDT）
+	TimeFrames   []TimeFrame // 生成的周期（默认全部周期）
+	StartPrice   float64     // 起始价格（默认100）
+	Volatility   float64     // 每分钟对数收益率的标准差（默认0.001）
+	BaseVolume   float64     // 每分钟的基础成交量（默认1000）
+	PatternEvery int         // 每隔多少分钟注入一次形态（默认30，<0 不注入）
+	Seed         int64       // 随机种子（相同种子生成相同行情）
+	Start        time.Time   // 模拟起始时间（默认下一个UTC零点，保证合成K线不会被缓存判为过期）
+}
+
+// SyntheticMarket 不依赖交易所的合成行情：按分钟随机游走，并周期性注入形态，聚合为各周期K线
+type SyntheticMarket struct {
+	cfg     SyntheticConfig
+	rng     *rand.Rand
+	now     time.Time // 下一分钟的开盘时间
+	minutes int
+	symbols []string // 标准格式，按字母排序
+	series  map[string]*syntheticSeries
+}
+
+// syntheticSeries 单个交易对的生成状态
+type syntheticSeries struct {
+	price   float64
+	pending []Kline             // 已排定的形态分钟K线（吞没形态需要连续两根）
+	forming map[TimeFrame]Kline // 各周期形成中的K线
+}
+
+// NewSyntheticMarket 创建合成行情
+func NewSyntheticMarket(cfg SyntheticConfig) (*SyntheticMarket, error) {
+	if len(cfg.Symbols) == 0 {
+		cfg.Symbols = []string{"BTCUSDT"}
+	}
+	if len(cfg.TimeFrames) == 0 {
+		cfg.TimeFrames = AllTimeFrames
+	}
+	for _, tf := range cfg.TimeFrames {
+		if _, ok := TimeFrameMinutes[tf]; !ok {
+			return nil, fmt.Errorf("不支持的周期: %s", tf)
+		}
+	}
+	if cfg.StartPrice <= 0 {
+		cfg.StartPrice = 100
+	}
+	if cfg.Volatility <= 0 {
+		cfg.Volatility = 0.001
+	}
+	if cfg.BaseVolume <= 0 {
+		cfg.BaseVolume = 1000
+	}
+	if cfg.PatternEvery == 0 {
+		cfg.PatternEvery = 30
+	}
+	if cfg.Start.IsZero() {
+		cfg.Start = time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	}
+
+	m := &SyntheticMarket{
+		cfg:    cfg,
+		rng:    rand.New(rand.NewSource(cfg.Seed)),
+		now:    cfg.Start.Truncate(time.Minute),
+		series: make(map[string]*syntheticSeries, len(cfg.Symbols)),
+	}
+	for _, symbol := range cfg.Symbols {
+		symbol = Normalize(symbol)
+		if _, exists := m.series[symbol]; exists {
+			continue
+		}
+		m.symbols = append(m.symbols, symbol)
+		m.series[symbol] = &syntheticSeries{
+			price:   cfg.StartPrice,
+			forming: make(map[TimeFrame]Kline, len(cfg.TimeFrames)),
+		}
+	}
+	sort.Strings(m.symbols)
+	return m, nil
+}
+
+// Symbols 生成的交易对（标准格式，按字母排序）
+func (m *SyntheticMarket) Symbols() []string {
+	return append([]string(nil), m.symbols...)
+}
+
+// TimeFrames 生成的周期
+func (m *SyntheticMarket) TimeFrames() []TimeFrame {
+	return m.cfg.TimeFrames
+}
+
+// Now 当前模拟时间（下一分钟的开盘时间）
+func (m *SyntheticMarket) Now() time.Time {
+	return m.now
+}
+
+// Minutes 已生成的分钟数
+func (m *SyntheticMarket) Minutes() int {
+	return m.minutes
+}
+
+// Price 交易对的最新价格
+func (m *SyntheticMarket) Price(symbol string) (float64, bool) {
+	s, ok := m.series[Normalize(symbol)]
+	if !ok {
+		return 0, false
+	}
+	return s.price, true
+}
+
+// Step 生成下一分钟的行情，更新各周期形成中的K线
+func (m *SyntheticMarket) Step() {
+	inject := m.cfg.PatternEvery > 0 && m.minutes > 0 && m.minutes%m.cfg.PatternEvery == 0
+	pattern := ""
+	if inject {
+		pattern = syntheticPatterns[(m.minutes/m.cfg.PatternEvery-1)%len(syntheticPatterns)]
+	}
+	for _, symbol := range m.symbols {
+		s := m.series[symbol]
+		if pattern != "" {
+			s.pending = append(s.pending, m.pattern(pattern, s.price)...)
+		}
+		var minute Kline
+		if len(s.pending) > 0 {
+			minute = s.pending[0]
+			s.pending = s.pending[1:]
+		} else {
+			minute = m.randomMinute(s.price)
+		}
+		minute.OpenTime = m.now.UnixMilli()
+		minute.CloseTime = m.now.Add(time.Minute).UnixMilli() - 1
+		s.price = minute.Close
+		m.aggregate(s, minute)
+	}
+	m.now = m.now.Add(time.Minute)
+	m.minutes++
+}
+
+// randomMinute 随机游走的一分钟K线
+func (m *SyntheticMarket) randomMinute(open float64) Kline {
+	vol := m.cfg.Volatility
+	closePrice := open * math.Exp(vol*m.rng.NormFloat64())
+	high := math.Max(open, closePrice) * (1 + math.Abs(m.rng.NormFloat64())*vol/2)
+	low := math.Min(open, closePrice) * (1 - math.Abs(m.rng.NormFloat64())*vol/2)
+	return m.minuteKline(open, high, low, closePrice, m.cfg.BaseVolume*(0.5+m.rng.Float64()))
+}
+
+// pattern 生成形态的分钟K线（幅度按波动率放大，方向随机）
+func (m *SyntheticMarket) pattern(name string, open float64) []Kline {
+	move := open * m.cfg.Volatility * 5
+	up := m.rng.Intn(2) == 0
+	sign := 1.0
+	if !up {
+		sign = -1
+	}
+	switch name {
+	case SyntheticPinBar:
+		// 实体很小，单侧影线是实体的数倍
+		closePrice := open + sign*move*0.1
+		if up {
+			return []Kline{m.minuteKline(open, math.Max(open, closePrice), open-move*2, closePrice, m.cfg.BaseVolume*2)}
+		}
+		return []Kline{m.minuteKline(open, open+move*2, math.Min(open, closePrice), closePrice, m.cfg.BaseVolume*2)}
+	case SyntheticEngulfing:
+		// 反向小实体后接完全覆盖它的大实体
+		first := open - sign*move*0.5
+		second := first + sign*move*1.5
+		return []Kline{
+			m.minuteKline(open, math.Max(open, first), math.Min(open, first), first, m.cfg.BaseVolume),
+			m.minuteKline(first, math.Max(first, second), math.Min(first, second), second, m.cfg.BaseVolume*1.5),
+		}
+	default:
+		closePrice := open + sign*move
+		return []Kline{m.minuteKline(open, math.Max(open, closePrice), math.Min(open, closePrice), closePrice, m.cfg.BaseVolume*4)}
+	}
+}
+
+func (m *SyntheticMarket) minuteKline(open, high, low, closePrice, volume float64) Kline {
+	return Kline{
+		Open:               open,
+		High:               high,
+		Low:                low,
+		Close:              closePrice,
+		Volume:             volume,
+		QuoteVolume:        volume * closePrice,
+		Trades:             int(volume / 10),
+		TakerBuyBaseVolume: volume / 2,
+	}
+}
+
+// aggregate 把一分钟K线聚合进各周期：跨周期边界时上一根收盘，开始新K线
+func (m *SyntheticMarket) aggregate(s *syntheticSeries, minute Kline) {
+	for _, tf := range m.cfg.TimeFrames {
+		period := time.Duration(TimeFrameMinutes[tf]) * time.Minute
+		openTime := time.UnixMilli(minute.OpenTime).UTC().Truncate(period)
+		k, ok := s.forming[tf]
+		if ok && k.OpenTime == openTime.UnixMilli() {
+			k.High = math.Max(k.High, minute.High)
+			k.Low = math.Min(k.Low, minute.Low)
+			k.Close = minute.Close
+			k.Volume += minute.Volume
+			k.QuoteVolume += minute.QuoteVolume
+			k.Trades += minute.Trades
+			k.TakerBuyBaseVolume += minute.TakerBuyBaseVolume
+		} else {
+			k = minute
+			k.OpenTime = openTime.UnixMilli()
+			k.CloseTime = openTime.Add(period).UnixMilli() - 1
+		}
+		s.forming[tf] = k
+	}
+}
+
+// Klines 交易对各周期形成中的K线
+func (m *SyntheticMarket) Klines(symbol string) map[TimeFrame]Kline {
+	s, ok := m.series[Normalize(symbol)]
+	if !ok {
+		return nil
+	}
+	klines := make(map[TimeFrame]Kline, len(s.forming))
+	for tf, k := range s.forming {
+		klines[tf] = k
+	}
+	return klines
+}
+
+// Feed 生成下一分钟的行情并写入K线缓存（交易对未登记时按 maxKlines 登记）
+// 与增量刷新一样写入形成中的K线，跨周期边界时缓存触发收盘回调
+func (m *SyntheticMarket) Feed(kc *KlineCache, maxKlines int) error {
+	m.Step()
+	for _, symbol := range m.symbols {
+		if kc.getSymbol(symbol) == nil {
+			kc.AddSymbol(symbol, maxKlines)
+		}
+		for tf, k := range m.series[symbol].forming {
+			if err := kc.Ingest(symbol, tf, []Kline{k}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package market
+
+import (
+	"io"
+	"log"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestSyntheticMarket_DeterministicAndAggregated(t *testing.T) {
+	start := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	cfg := SyntheticConfig{Symbols: []string{"ETH_USDC_PERP", "BTCUSDT"}, TimeFrames: []TimeFrame{TimeFrame5m, TimeFrame1h}, Seed: 7, Start: start}
+	a, err := NewSyntheticMarket(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, _ := NewSyntheticMarket(cfg)
+	if got := a.Symbols(); len(got) != 2 || got[0] != "BTCUSDT" || got[1] != "ETHUSDT" {
+		t.Fatalf("symbols = %v", got)
+	}
+
+	var high, low, volume float64
+	low = math.Inf(1)
+	for i := 0; i < 5; i++ {
+		a.Step()
+		b.Step()
+		// 逐分钟还原 5m K线：最高/最低取极值，成交量累加
+		k := a.Klines("BTCUSDT")[TimeFrame5m]
+		if i == 0 {
+			high, low, volume = k.High, k.Low, k.Volume
+		}
+		if k.High < high || k.Low > low || k.Volume < volume {
+			t.Fatalf("minute %d: 聚合K线应单调扩展", i)
+		}
+		high, low, volume = k.High, k.Low, k.Volume
+	}
+	ka, kb := a.Klines("BTCUSDT")[TimeFrame5m], b.Klines("BTCUSDT")[TimeFrame5m]
+	if ka != kb {
+		t.Errorf("相同种子应生成相同行情: %+v != %+v", ka, kb)
+	}
+	if ka.OpenTime != start.UnixMilli() || ka.CloseTime != start.Add(5*time.Minute).UnixMilli()-1 {
+		t.Errorf("5m K线时间错误: %+v", ka)
+	}
+	if price, _ := a.Price("BTCUSDT"); price != ka.Close {
+		t.Errorf("最新价 %.4f 应等于形成中K线收盘价 %.4f", price, ka.Close)
+	}
+
+	a.Step()
+	if k := a.Klines("BTCUSDT")[TimeFrame5m]; k.OpenTime != start.Add(5*time.Minute).UnixMilli() {
+		t.Errorf("跨周期边界应开始新K线, got open %d", k.OpenTime)
+	}
+	if k := a.Klines("BTCUSDT")[TimeFrame1h]; k.OpenTime != start.UnixMilli() {
+		t.Errorf("1h K线仍在形成中, got open %d", k.OpenTime)
+	}
+	if _, err := NewSyntheticMarket(SyntheticConfig{TimeFrames: []TimeFrame{"2m"}}); err == nil {
+		t.Error("不支持的周期应返回错误")
+	}
+}
+
+func TestSyntheticMarket_FeedDrivesCacheAndDetector(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+
+	m, err := NewSyntheticMarket(SyntheticConfig{TimeFrames: []TimeFrame{TimeFrame5m, TimeFrame15m}, PatternEvery: 10, Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	kc := NewKlineCache()
+	closes := map[TimeFrame]int{}
+	kc.SetCandleCloseHandler(func(e CandleClose) { closes[e.TimeFrame]++ })
+	sd := NewSignalDetectorWithCache(kc)
+	sd.quiet = true
+
+	signals := 0
+	for i := 0; i < 24*60; i++ {
+		if err := m.Feed(kc, 50); err != nil {
+			t.Fatal(err)
+		}
+		signals += len(sd.DetectAllSignals("BTCUSDT", []TimeFrame{TimeFrame5m}))
+	}
+
+	if closes[TimeFrame5m] != 24*12-1 || closes[TimeFrame15m] != 24*4-1 {
+		t.Errorf("收盘事件数 = %v", closes)
+	}
+	for _, metric := range kc.Metrics() {
+		if metric.Size > metric.Capacity || metric.Capacity != 50 {
+			t.Errorf("%s 缓存 %d/%d 超出容量", metric.TimeFrame, metric.Size, metric.Capacity)
+		}
+		if metric.Stale {
+			t.Errorf("%s 合成K线不应被判为过期", metric.TimeFrame)
+		}
+	}
+	if signals == 0 {
+		t.Error("注入形态后应检测到信号")
+	}
+	if err := kc.Ingest("DOGEUSDT", TimeFrame5m, nil); err == nil {
+		t.Error("未登记的交易对应返回错误")
+	}
+}
@@ -0,0 +1,142 @@
+package market
+
+import (
+	"fmt"
+	"log"
+)
+
+// defaultTrendEMAPeriod 未配置时高周期EMA的周期
+const defaultTrendEMAPeriod = 20
+
+// Trend 高周期趋势方向
+type Trend int
+
+const (
+	TrendUnknown Trend = iota // K线不足或价格恰好等于EMA
+	TrendUp                   // 价格在EMA之上
+	TrendDown                 // 价格在EMA之下
+)
+
+func (t Trend) String() string {
+	switch t {
+	case TrendUp:
+		return "up"
+	case TrendDown:
+		return "down"
+	}
+	return "unknown"
+}
+
+// Allows 该趋势下是否允许 direction（"long"/"short"）方向的信号；趋势未知时不拦截
+func (t Trend) Allows(direction string) bool {
+	switch t {
+	case TrendUp:
+		return direction != "short"
+	case TrendDown:
+		return direction != "long"
+	}
+	return true
+}
+
+// higherTimeFrames 信号周期默认参考的高周期
+var higherTimeFrames = map[TimeFrame]TimeFrame{
+	TimeFrame5m:  TimeFrame1h,
+	TimeFrame15m: TimeFrame1h,
+	TimeFrame30m: TimeFrame4h,
+	TimeFrame1h:  TimeFrame4h,
+	TimeFrame4h:  TimeFrame1d,
+}
+
+// HigherTimeFrame 返回 tf 默认参考的高周期（1d 没有更高周期，返回 false）
+func HigherTimeFrame(tf TimeFrame) (TimeFrame, bool) {
+	higher, ok := higherTimeFrames[tf]
+	return higher, ok
+}
+
+// TrendFilterConfig 高周期趋势过滤配置
+type TrendFilterConfig struct {
+	EMAPeriod  int                     // 高周期EMA周期（<=0 时为20；缓存的K线数量需不少于该值）
+	TimeFrames map[TimeFrame]TimeFrame // 信号周期 -> 参考周期，未配置的周期使用 HigherTimeFrame
+}
+
+// Enabled 是否启用趋势过滤（配置了EMA周期或参考周期）
+func (c TrendFilterConfig) Enabled() bool {
+	return c.EMAPeriod > 0 || len(c.TimeFrames) > 0
+}
+
+// TrendFilter 高周期趋势过滤：价格在高周期EMA之上只做多，之下只做空
+// 各检测器统一通过 Allows 查询，不必各自实现高周期判断
+type TrendFilter struct {
+	cache  *KlineCache
+	period int
+	higher map[TimeFrame]TimeFrame
+}
+
+// NewTrendFilter 创建基于全局K线缓存的趋势过滤器
+func NewTrendFilter(cfg TrendFilterConfig) (*TrendFilter, error) {
+	period := cfg.EMAPeriod
+	if period <= 0 {
+		period = defaultTrendEMAPeriod
+	}
+	higher := make(map[TimeFrame]TimeFrame, len(higherTimeFrames)+len(cfg.TimeFrames))
+	for tf, h := range higherTimeFrames {
+		higher[tf] = h
+	}
+	for tf, h := range cfg.TimeFrames {
+		_, validSignal := TimeFrameMinutes[tf]
+		if _, ok := TimeFrameMinutes[h]; !ok || !validSignal {
+			return nil, fmt.Errorf("趋势过滤参考周期无效: %s -> %s", tf, h)
+		}
+		if TimeFrameMinutes[h] <= TimeFrameMinutes[tf] {
+			return nil, fmt.Errorf("趋势过滤参考周期必须高于信号周期: %s -> %s", tf, h)
+		}
+		higher[tf] = h
+	}
+	return &TrendFilter{cache: GetKlineCache(), period: period, higher: higher}, nil
+}
+
+// Trend 返回信号周期 tf 对应高周期的趋势，以及实际参考的周期
+func (f *TrendFilter) Trend(symbol string, tf TimeFrame) (Trend, TimeFrame) {
+	higher, ok := f.higher[tf]
+	if !ok {
+		return TrendUnknown, ""
+	}
+	klines, err := f.cache.GetKlines(symbol, higher, 0)
+	if err != nil || len(klines) < f.period {
+		return TrendUnknown, higher
+	}
+	ema := calculateEMA(klines, f.period)
+	price := klines[len(klines)-1].Close
+	switch {
+	case price > ema:
+		return TrendUp, higher
+	case price < ema:
+		return TrendDown, higher
+	}
+	return TrendUnknown, higher
+}
+
+// Allows 信号方向是否与高周期趋势一致（数据不足时不拦截）
+func (f *TrendFilter) Allows(symbol string, tf TimeFrame, direction string) bool {
+	trend, _ := f.Trend(symbol, tf)
+	return trend.Allows(direction)
+}
+
+// Filter 过滤掉与高周期趋势相反的信号
+func (f *TrendFilter) Filter(signals []*TradingSignal) []*TradingSignal {
+	var kept []*TradingSignal
+	for _, sig := range signals {
+		trend, higher := f.Trend(sig.Symbol, sig.TimeFrame)
+		if !trend.Allows(sig.Direction) {
+			log.Printf("🚫 [Signal] %s %s %s 与 %s 趋势(%s)相反，已过滤", sig.Symbol, sig.TimeFrame, sig.SignalType, higher, trend)
+			continue
+		}
+		kept = append(kept, sig)
+	}
+	return kept
+}
+
+// SetTrendFilter 设置高周期趋势过滤（nil 表示不过滤），DetectAllSignals 返回前统一过滤逆势信号
+func (sd *SignalDetector) SetTrendFilter(f *TrendFilter) {
+	sd.trend = f
+}
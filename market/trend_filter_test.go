@@ -0,0 +1,74 @@
+package market
+
+import "testing"
+
+// newTrendTestCache 构造只含一个周期收盘价序列的缓存
+func newTrendTestCache(tf TimeFrame, closes ...float64) *KlineCache {
+	mtk := newMultiTimeFrameKline("BTCUSDT", 50)
+	klines := make([]Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = Kline{OpenTime: int64(i + 1), Close: c}
+	}
+	mtk.mergeLocked(tf, klines)
+	return &KlineCache{cache: map[string]*MultiTimeFrameKline{"BTCUSDT": mtk}}
+}
+
+func TestTrendFilter_Trend(t *testing.T) {
+	f, err := NewTrendFilter(TrendFilterConfig{EMAPeriod: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	f.cache = newTrendTestCache(TimeFrame1h, 100, 101, 102, 103, 110)
+	if trend, higher := f.Trend("BTCUSDT", TimeFrame15m); trend != TrendUp || higher != TimeFrame1h {
+		t.Errorf("expected up on 1h, got %s on %s", trend, higher)
+	}
+	if !f.Allows("BTCUSDT", TimeFrame5m, "long") || f.Allows("BTCUSDT", TimeFrame5m, "short") {
+		t.Error("uptrend should only allow longs")
+	}
+
+	f.cache = newTrendTestCache(TimeFrame1h, 110, 108, 106, 104, 95)
+	if trend, _ := f.Trend("BTCUSDT", TimeFrame15m); trend != TrendDown {
+		t.Errorf("expected down, got %s", trend)
+	}
+
+	// K线不足、没有更高周期、未知交易对时不拦截
+	f.cache = newTrendTestCache(TimeFrame1h, 100, 90)
+	if trend, _ := f.Trend("BTCUSDT", TimeFrame15m); trend != TrendUnknown {
+		t.Errorf("expected unknown with too few klines, got %s", trend)
+	}
+	if trend, higher := f.Trend("BTCUSDT", TimeFrame1d); trend != TrendUnknown || higher != "" {
+		t.Errorf("1d has no higher timeframe, got %s on %q", trend, higher)
+	}
+	if !f.Allows("ETHUSDT", TimeFrame15m, "short") {
+		t.Error("unknown symbol should not be filtered")
+	}
+}
+
+func TestTrendFilter_FilterAndConfig(t *testing.T) {
+	f, err := NewTrendFilter(TrendFilterConfig{EMAPeriod: 3, TimeFrames: map[TimeFrame]TimeFrame{TimeFrame15m: TimeFrame4h}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.cache = newTrendTestCache(TimeFrame4h, 100, 101, 102, 103, 110)
+
+	signals := []*TradingSignal{
+		{Symbol: "BTCUSDT", TimeFrame: TimeFrame15m, Direction: "long"},
+		{Symbol: "BTCUSDT", TimeFrame: TimeFrame15m, Direction: "short"},
+		{Symbol: "BTCUSDT", TimeFrame: TimeFrame5m, Direction: "short"}, // 5m 参考 1h，无数据
+	}
+	kept := f.Filter(signals)
+	if len(kept) != 2 || kept[0] != signals[0] || kept[1] != signals[2] {
+		t.Errorf("expected counter-trend 15m short to be dropped, got %d signals", len(kept))
+	}
+
+	if _, err := NewTrendFilter(TrendFilterConfig{TimeFrames: map[TimeFrame]TimeFrame{TimeFrame4h: TimeFrame1h}}); err == nil {
+		t.Error("expected error for lower reference timeframe")
+	}
+	if _, err := NewTrendFilter(TrendFilterConfig{TimeFrames: map[TimeFrame]TimeFrame{TimeFrame1h: "2h"}}); err == nil {
+		t.Error("expected error for unknown timeframe")
+	}
+	if (TrendFilterConfig{}).Enabled() {
+		t.Error("zero config should be disabled")
+	}
+}
@@ -90,6 +90,18 @@ type Ticker24hr struct {
 	QuoteVolume        string `json:"quoteVolume"`
 }
 
+type BookTicker struct {
+	Symbol   string `json:"symbol"`
+	BidPrice string `json:"bidPrice"`
+	AskPrice string `json:"askPrice"`
+}
+
+type PremiumIndex struct {
+	Symbol          string `json:"symbol"`
+	MarkPrice       string `json:"markPrice"`
+	LastFundingRate string `json:"lastFundingRate"`
+}
+
 // 特征数据结构
 type SymbolFeatures struct {
 	Symbol           string    `json:"symbol"`
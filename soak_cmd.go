@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// runSoakCommand 合成行情浸泡测试命令行：
+//
+//	nofx soak [-minutes 43200] [-duration 24h] [-symbols BTCUSDT,ETHUSDT] [-seed 1] [-tick 0] [-sample-every 1440] [-out report.json] [-v]
+//
+// 用随机游走并周期性注入形态的合成行情驱动 K线缓存 → 收盘事件 → 信号检测 → 路由下单 → 订单/持仓推送 的完整链路，
+// 不依赖任何交易所，用于 CI/浸泡环境验证长时间运行的内存稳定性；发现内存或结构增长时以非零状态退出
+func runSoakCommand(args []string) error {
+	fs := flag.NewFlagSet("soak", flag.ContinueOnError)
+	minutes := fs.Int("minutes", 30*24*60, "模拟分钟数（0 表示运行到 -duration 或收到中断信号）")
+	duration := fs.Duration("duration", 0, "最长真实运行时间（0 表示不限）")
+	symbolsFlag := fs.String("symbols", "BTCUSDT,ETHUSDT,SOLUSDT", "逗号分隔的合成交易对")
+	seed := fs.Int64("seed", 1, "随机种子（相同种子生成相同行情）")
+	tick := fs.Duration("tick", 0, "每模拟一分钟的真实间隔（0 表示尽快运行）")
+	sampleEvery := fs.Int("sample-every", 24*60, "每隔多少模拟分钟采样一次内存")
+	out := fs.String("out", "", "报告输出文件（JSON，默认只打印摘要）")
+	verbose := fs.Bool("v", false, "输出各组件日志")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var symbols []string
+	for _, s := range strings.Split(*symbolsFlag, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			symbols = append(symbols, s)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+
+	// 缓存和检测器每根K线都会打日志，默认只保留浸泡测试自己的输出
+	output := log.Writer()
+	if !*verbose {
+		log.SetOutput(io.Discard)
+	}
+	report, err := trader.RunSoak(ctx, trader.SoakConfig{
+		Market:       market.SyntheticConfig{Symbols: symbols, Seed: *seed},
+		Minutes:      *minutes,
+		TickInterval: *tick,
+		SampleEvery:  *sampleEvery,
+	})
+	log.SetOutput(output)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("模拟 %d 分钟（%.1f 天），耗时 %s\n", report.Minutes, float64(report.Minutes)/(24*60), report.Elapsed)
+	fmt.Printf("收盘事件 %d（丢弃 %d），信号 %d，下单 %d（失败 %d），成交 %d\n",
+		report.Events, report.DroppedEvents, report.Signals, report.Orders, report.OrderErrors, report.Fills)
+	fmt.Printf("%-17s %10s %8s %8s %8s %8s %8s\n", "模拟时间", "堆内存MB", "对象数", "协程", "K线", "挂单", "订阅")
+	for _, s := range report.Samples {
+		fmt.Printf("%-17s %10.2f %8d %8d %8d %8d %8d\n", s.SimTime.Format("2006-01-02 15:04"),
+			float64(s.HeapAlloc)/1024/1024, s.HeapObjects, s.Goroutines, s.CachedKlines, s.PendingOrders, s.Subscribers)
+	}
+
+	if *out != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return fmt.Errorf("序列化浸泡测试报告失败: %w", err)
+		}
+		if err := os.WriteFile(*out, data, 0644); err != nil {
+			return fmt.Errorf("写入浸泡测试报告失败: %w", err)
+		}
+		fmt.Printf("报告已保存: %s\n", *out)
+	}
+	return report.Check()
+}
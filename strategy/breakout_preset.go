@@ -0,0 +1,200 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// BreakoutPresetConfig 动量突破预设配置（可直接从JSON加载，按交易对启用）
+type BreakoutPresetConfig struct {
+	Symbols          []string         `json:"symbols"`            // 启用该预设的交易对
+	TimeFrame        market.TimeFrame `json:"timeframe"`          // K线周期
+	RangeLookback    int              `json:"range_lookback"`     // 突破区间的K线根数
+	ADXPeriod        int              `json:"adx_period"`         // ADX周期
+	MinADX           float64          `json:"min_adx"`            // 入场所需的最小ADX（趋势强度过滤）
+	ATRPeriod        int              `json:"atr_period"`         // ATR周期
+	RiskPerTrade     float64          `json:"risk_per_trade"`     // 单笔风险金额（USDT）
+	StopATRMultiple  float64          `json:"stop_atr_multiple"`  // 初始止损距离 = ATR × 倍数
+	TrailATRMultiple float64          `json:"trail_atr_multiple"` // 移动止损距离 = ATR × 倍数
+	Leverage         int              `json:"leverage"`
+}
+
+// DefaultBreakoutPresetConfig 默认动量突破预设
+func DefaultBreakoutPresetConfig() BreakoutPresetConfig {
+	return BreakoutPresetConfig{
+		TimeFrame:        market.TimeFrame1h,
+		RangeLookback:    20,
+		ADXPeriod:        14,
+		MinADX:           25,
+		ATRPeriod:        14,
+		RiskPerTrade:     10,
+		StopATRMultiple:  2,
+		TrailATRMultiple: 3,
+		Leverage:         3,
+	}
+}
+
+// Validate 校验配置
+func (c BreakoutPresetConfig) Validate() error {
+	if _, ok := market.TimeFrameMinutes[c.TimeFrame]; !ok {
+		return fmt.Errorf("不支持的K线周期: %s", c.TimeFrame)
+	}
+	if c.RangeLookback < 2 || c.ADXPeriod < 1 || c.ATRPeriod < 1 {
+		return fmt.Errorf("区间和指标周期配置无效")
+	}
+	if c.RiskPerTrade <= 0 || c.StopATRMultiple <= 0 || c.TrailATRMultiple <= 0 {
+		return fmt.Errorf("风险金额和ATR倍数必须大于0")
+	}
+	return nil
+}
+
+// requiredKlines 计算指标所需的K线数量
+func (c BreakoutPresetConfig) requiredKlines() int {
+	n := c.RangeLookback + 1
+	if adx := 2*c.ADXPeriod + 1; adx > n {
+		n = adx
+	}
+	if atr := c.ATRPeriod + 1; atr > n {
+		n = atr
+	}
+	return n
+}
+
+// BreakoutPreset 动量突破预设：区间突破 + ADX趋势过滤 + ATR仓位 + ATR移动止损
+type BreakoutPreset struct {
+	cfg       BreakoutPresetConfig
+	trader    trader.Trader
+	klines    KlineSource
+	enabled   map[string]bool
+	positions map[string]*presetPosition
+	mu        sync.Mutex
+}
+
+// NewBreakoutPreset 创建动量突破预设
+// 使用 market.KlineCache 作为K线来源时，InitSymbol 的 maxKlines 需不少于 2*ADXPeriod+1
+func NewBreakoutPreset(cfg BreakoutPresetConfig, t trader.Trader, klines KlineSource) (*BreakoutPreset, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("突破预设配置无效: %w", err)
+	}
+	enabled := make(map[string]bool, len(cfg.Symbols))
+	for _, s := range cfg.Symbols {
+		enabled[market.Normalize(s)] = true
+	}
+	return &BreakoutPreset{
+		cfg:       cfg,
+		trader:    t,
+		klines:    klines,
+		enabled:   enabled,
+		positions: make(map[string]*presetPosition),
+	}, nil
+}
+
+// Enabled 交易对是否启用该预设
+func (p *BreakoutPreset) Enabled(symbol string) bool {
+	return p.enabled[market.Normalize(symbol)]
+}
+
+// Evaluate 从K线来源读取最新K线并执行一次评估（未启用的交易对直接跳过）
+func (p *BreakoutPreset) Evaluate(symbol string) (PresetAction, error) {
+	if !p.Enabled(symbol) {
+		return PresetNone, nil
+	}
+	klines, err := p.klines.GetKlines(symbol, p.cfg.TimeFrame, p.cfg.requiredKlines())
+	if err != nil {
+		return PresetNone, fmt.Errorf("获取K线失败: %w", err)
+	}
+	return p.OnKlines(symbol, klines)
+}
+
+// OnKlines 根据已收盘K线评估入场、移动止损和出场
+func (p *BreakoutPreset) OnKlines(symbol string, klines []market.Kline) (PresetAction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(klines) < p.cfg.requiredKlines() {
+		return PresetNone, fmt.Errorf("K线不足: 需要 %d 根，实际 %d 根", p.cfg.requiredKlines(), len(klines))
+	}
+	atr := market.CalculateATR(klines, p.cfg.ATRPeriod)
+	if atr <= 0 {
+		return PresetNone, nil
+	}
+	last := klines[len(klines)-1]
+
+	if pos := p.positions[symbol]; pos != nil {
+		return p.manageLocked(symbol, pos, last, atr)
+	}
+
+	breakout := market.DetectRangeBreakout(klines, p.cfg.RangeLookback)
+	if breakout == nil {
+		return PresetNone, nil
+	}
+	adx := market.CalculateADX(klines, p.cfg.ADXPeriod)
+	if adx < p.cfg.MinADX {
+		log.Printf("🔕 [Breakout] %s 突破但 ADX %.1f < %.1f，忽略", symbol, adx, p.cfg.MinADX)
+		return PresetNone, nil
+	}
+
+	stopDistance := p.cfg.StopATRMultiple * atr
+	qty, err := riskSizedQuantity(p.trader, symbol, p.cfg.RiskPerTrade, stopDistance)
+	if err != nil {
+		return PresetNone, err
+	}
+	if _, err := openPosition(p.trader, symbol, breakout.Direction, qty, p.cfg.Leverage); err != nil {
+		return PresetNone, fmt.Errorf("开仓失败: %w", err)
+	}
+
+	pos := &presetPosition{Side: breakout.Direction, Entry: last.Close, Quantity: qty, Extreme: last.Close, Entries: 1}
+	if pos.Side == SideLong {
+		pos.StopLoss = last.Close - stopDistance
+	} else {
+		pos.StopLoss = last.Close + stopDistance
+	}
+	p.positions[symbol] = pos
+	replaceStopLoss(p.trader, symbol, pos)
+
+	log.Printf("🚀 [Breakout] %s %s 突破 [%.4f, %.4f]，ADX %.1f，数量 %.4f，止损 %.4f",
+		symbol, pos.Side, breakout.RangeLow, breakout.RangeHigh, adx, qty, pos.StopLoss)
+	if pos.Side == SideLong {
+		return PresetOpenLong, nil
+	}
+	return PresetOpenShort, nil
+}
+
+// manageLocked 持仓管理：收盘触及止损则平仓，否则按ATR上移（下移）止损
+func (p *BreakoutPreset) manageLocked(symbol string, pos *presetPosition, last market.Kline, atr float64) (PresetAction, error) {
+	stopped := (pos.Side == SideLong && last.Close <= pos.StopLoss) || (pos.Side == SideShort && last.Close >= pos.StopLoss)
+	if stopped {
+		if _, err := closePosition(p.trader, symbol, pos.Side, 0); err != nil {
+			return PresetNone, fmt.Errorf("止损平仓失败: %w", err)
+		}
+		if err := p.trader.CancelStopLossOrders(symbol); err != nil {
+			log.Printf("⚠️ [Breakout] %s 取消止损单失败: %v", symbol, err)
+		}
+		delete(p.positions, symbol)
+		log.Printf("🛑 [Breakout] %s 收盘 %.4f 触及移动止损 %.4f，平仓", symbol, last.Close, pos.StopLoss)
+		return PresetClose, nil
+	}
+
+	trail := p.cfg.TrailATRMultiple * atr
+	newStop := pos.StopLoss
+	if pos.Side == SideLong {
+		pos.Extreme = math.Max(pos.Extreme, last.High)
+		newStop = math.Max(pos.StopLoss, pos.Extreme-trail)
+	} else {
+		pos.Extreme = math.Min(pos.Extreme, last.Low)
+		newStop = math.Min(pos.StopLoss, pos.Extreme+trail)
+	}
+	if newStop == pos.StopLoss {
+		return PresetNone, nil
+	}
+
+	log.Printf("📈 [Breakout] %s 移动止损 %.4f → %.4f", symbol, pos.StopLoss, newStop)
+	pos.StopLoss = newStop
+	replaceStopLoss(p.trader, symbol, pos)
+	return PresetTrail, nil
+}
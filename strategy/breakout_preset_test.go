@@ -0,0 +1,64 @@
+package strategy
+
+import (
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// risingKlines 生成稳定上涨的K线（每根上涨 step）
+func risingKlines(count int, start, step float64) []market.Kline {
+	klines := make([]market.Kline, count)
+	price := start
+	for i := range klines {
+		klines[i] = market.Kline{
+			OpenTime: int64(i) * 3600000,
+			Open:     price,
+			High:     price + step + 0.5,
+			Low:      price - 0.5,
+			Close:    price + step,
+			Volume:   100,
+		}
+		price += step
+	}
+	return klines
+}
+
+func TestBreakoutPreset_EntryTrailAndExit(t *testing.T) {
+	mt := newMockTrader()
+	cfg := DefaultBreakoutPresetConfig()
+	cfg.Symbols = []string{"SOLUSDT"}
+	p, err := NewBreakoutPreset(cfg, mt, nil)
+	require.NoError(t, err)
+	assert.True(t, p.Enabled("SOL_USDC_PERP"))
+	assert.False(t, p.Enabled("BTCUSDT"))
+
+	// 持续上涨：收盘突破区间且ADX高，按ATR开多并挂止损
+	klines := risingKlines(40, 100, 1)
+	action, err := p.OnKlines("SOLUSDT", klines)
+	require.NoError(t, err)
+	assert.Equal(t, PresetOpenLong, action)
+	atr := market.CalculateATR(klines, cfg.ATRPeriod)
+	assert.InDelta(t, cfg.RiskPerTrade/(cfg.StopATRMultiple*atr), mt.position("SOLUSDT", SideLong), 1e-5)
+	require.Len(t, mt.ordersOf("stop_loss"), 1)
+	initialStop := mt.ordersOf("stop_loss")[0].Price
+
+	// 继续上涨：止损上移
+	klines = risingKlines(45, 100, 1)
+	action, err = p.OnKlines("SOLUSDT", klines)
+	require.NoError(t, err)
+	assert.Equal(t, PresetTrail, action)
+	stops := mt.ordersOf("stop_loss")
+	assert.Greater(t, stops[len(stops)-1].Price, initialStop)
+
+	// 收盘跌破移动止损：平仓
+	last := klines[len(klines)-1]
+	crash := market.Kline{Open: last.Close, High: last.Close, Low: last.Close - 50, Close: last.Close - 40}
+	action, err = p.OnKlines("SOLUSDT", append(klines, crash))
+	require.NoError(t, err)
+	assert.Equal(t, PresetClose, action)
+	assert.Zero(t, mt.position("SOLUSDT", SideLong))
+}
@@ -0,0 +1,435 @@
+package strategy
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"sync"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// BucketConfig 单个资金桶配置
+type BucketConfig struct {
+	Name       string  `json:"name"`       // 资金桶名称（通常为策略名）
+	Allocation float64 `json:"allocation"` // 分配的资金（USDT）
+}
+
+// CapitalBucketsConfig 资金桶配置
+// 多个策略共用一个交易所账户时，每个策略按自己资金桶的权益计算仓位并单独统计盈亏
+type CapitalBucketsConfig struct {
+	Buckets   []BucketConfig `json:"buckets"`
+	StatePath string         `json:"state_path"` // 状态文件（划转和已实现盈亏持久化），为空时不持久化
+}
+
+// Validate 校验配置
+func (c CapitalBucketsConfig) Validate() error {
+	seen := make(map[string]bool, len(c.Buckets))
+	for _, b := range c.Buckets {
+		if b.Name == "" {
+			return fmt.Errorf("资金桶名称不能为空")
+		}
+		if seen[b.Name] {
+			return fmt.Errorf("资金桶名称重复: %s", b.Name)
+		}
+		seen[b.Name] = true
+		if b.Allocation < 0 {
+			return fmt.Errorf("资金桶 %s 的分配金额不能为负数", b.Name)
+		}
+	}
+	return nil
+}
+
+// BucketState 资金桶状态
+type BucketState struct {
+	Name        string  `json:"name"`
+	Allocated   float64 `json:"allocated"`    // 分配资金（含划转）
+	RealizedPnL float64 `json:"realized_pnl"` // 累计已实现盈亏
+	Reserved    float64 `json:"-"`            // 持仓占用的保证金（仅运行期有效，不持久化）
+}
+
+// Equity 资金桶权益 = 分配资金 + 已实现盈亏
+func (s BucketState) Equity() float64 {
+	return s.Allocated + s.RealizedPnL
+}
+
+// Available 可用资金 = 权益 - 占用保证金
+func (s BucketState) Available() float64 {
+	return s.Equity() - s.Reserved
+}
+
+// ErrBucketInsufficient 资金桶可用资金不足
+var ErrBucketInsufficient = errors.New("资金桶可用资金不足")
+
+// CapitalBuckets 资金桶管理（虚拟子账户）
+type CapitalBuckets struct {
+	buckets   map[string]*BucketState
+	statePath string
+	mu        sync.Mutex
+}
+
+// NewCapitalBuckets 创建资金桶；状态文件存在时以文件中的分配和盈亏为准，配置中新增的资金桶按配置初始化
+func NewCapitalBuckets(cfg CapitalBucketsConfig) (*CapitalBuckets, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("资金桶配置无效: %w", err)
+	}
+
+	cb := &CapitalBuckets{
+		buckets:   make(map[string]*BucketState, len(cfg.Buckets)),
+		statePath: cfg.StatePath,
+	}
+	if err := cb.load(); err != nil {
+		return nil, err
+	}
+	for _, b := range cfg.Buckets {
+		if _, ok := cb.buckets[b.Name]; !ok {
+			cb.buckets[b.Name] = &BucketState{Name: b.Name, Allocated: b.Allocation}
+		}
+	}
+	return cb, nil
+}
+
+// load 从状态文件恢复（文件不存在时跳过）
+func (cb *CapitalBuckets) load() error {
+	if cb.statePath == "" {
+		return nil
+	}
+	data, err := os.ReadFile(cb.statePath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("读取资金桶状态失败: %w", err)
+	}
+	var states []BucketState
+	if err := json.Unmarshal(data, &states); err != nil {
+		return fmt.Errorf("解析资金桶状态失败: %w", err)
+	}
+	for i := range states {
+		cb.buckets[states[i].Name] = &states[i]
+	}
+	return nil
+}
+
+// saveLocked 持久化状态
+func (cb *CapitalBuckets) saveLocked() error {
+	if cb.statePath == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(cb.statesLocked(), "", "  ")
+	if err != nil {
+		return fmt.Errorf("序列化资金桶状态失败: %w", err)
+	}
+	if err := os.WriteFile(cb.statePath, data, 0600); err != nil {
+		return fmt.Errorf("写入资金桶状态失败: %w", err)
+	}
+	return nil
+}
+
+func (cb *CapitalBuckets) statesLocked() []BucketState {
+	states := make([]BucketState, 0, len(cb.buckets))
+	for _, b := range cb.buckets {
+		states = append(states, *b)
+	}
+	sort.Slice(states, func(i, j int) bool { return states[i].Name < states[j].Name })
+	return states
+}
+
+func (cb *CapitalBuckets) getLocked(name string) (*BucketState, error) {
+	b, ok := cb.buckets[name]
+	if !ok {
+		return nil, fmt.Errorf("资金桶不存在: %s", name)
+	}
+	return b, nil
+}
+
+// States 返回全部资金桶状态（按名称排序）
+func (cb *CapitalBuckets) States() []BucketState {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.statesLocked()
+}
+
+// State 返回指定资金桶状态
+func (cb *CapitalBuckets) State(name string) (BucketState, error) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b, err := cb.getLocked(name)
+	if err != nil {
+		return BucketState{}, err
+	}
+	return *b, nil
+}
+
+// Reserve 为开仓占用保证金，可用资金不足时返回 ErrBucketInsufficient
+func (cb *CapitalBuckets) Reserve(name string, margin float64) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b, err := cb.getLocked(name)
+	if err != nil {
+		return err
+	}
+	if margin > b.Available() {
+		return fmt.Errorf("%w: %s 需要 %.2f，可用 %.2f", ErrBucketInsufficient, name, margin, b.Available())
+	}
+	b.Reserved += margin
+	return cb.saveLocked()
+}
+
+// Release 平仓后释放保证金并记入已实现盈亏
+func (cb *CapitalBuckets) Release(name string, margin, pnl float64) error {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	b, err := cb.getLocked(name)
+	if err != nil {
+		return err
+	}
+	b.Reserved -= margin
+	if b.Reserved < 1e-9 {
+		b.Reserved = 0
+	}
+	b.RealizedPnL += pnl
+	return cb.saveLocked()
+}
+
+// Transfer 在资金桶之间划转资金（只能划转来源桶的可用资金）
+func (cb *CapitalBuckets) Transfer(from, to string, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("划转金额必须大于0")
+	}
+	if from == to {
+		return fmt.Errorf("不能向同一资金桶划转")
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	src, err := cb.getLocked(from)
+	if err != nil {
+		return err
+	}
+	dst, err := cb.getLocked(to)
+	if err != nil {
+		return err
+	}
+	if amount > src.Available() {
+		return fmt.Errorf("%w: %s 可划转 %.2f，请求 %.2f", ErrBucketInsufficient, from, src.Available(), amount)
+	}
+	src.Allocated -= amount
+	dst.Allocated += amount
+	log.Printf("💱 [资金桶] %s → %s 划转 %.2f USDT", from, to, amount)
+	return cb.saveLocked()
+}
+
+// CheckAccount 校验全部资金桶权益之和不超过账户钱包余额
+func (cb *CapitalBuckets) CheckAccount(t trader.Trader) error {
+	balance, err := t.GetBalance()
+	if err != nil {
+		return fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+
+	total := 0.0
+	for _, s := range cb.States() {
+		total += s.Equity()
+	}
+	if total > wallet {
+		return fmt.Errorf("资金桶权益合计 %.2f 超过账户余额 %.2f", total, wallet)
+	}
+	return nil
+}
+
+// bucketPosition 资金桶自己持有的仓位
+type bucketPosition struct {
+	Entry    float64
+	Quantity float64
+	Margin   float64
+}
+
+// BucketTrader 按资金桶记账的 trader.Trader 包装
+// GetBalance 返回资金桶的权益和可用资金，开仓占用资金桶保证金，平仓按成交参考价记入资金桶盈亏；
+// 平仓数量为0时只平掉本资金桶持有的数量，不影响同一账户下其他策略的仓位
+type BucketTrader struct {
+	trader.Trader
+	buckets   *CapitalBuckets
+	name      string
+	positions map[string]*bucketPosition // key: symbol|side
+	mu        sync.Mutex
+}
+
+// Trader 返回按指定资金桶记账的交易器
+func (cb *CapitalBuckets) Trader(name string, t trader.Trader) (*BucketTrader, error) {
+	if _, err := cb.State(name); err != nil {
+		return nil, err
+	}
+	return &BucketTrader{
+		Trader:    t,
+		buckets:   cb,
+		name:      name,
+		positions: make(map[string]*bucketPosition),
+	}, nil
+}
+
+// WrapTrader 实现 trader.CapitalBucketSource，供 AutoTraderConfig.CapitalBuckets 使用
+func (cb *CapitalBuckets) WrapTrader(name string, t trader.Trader) (trader.Trader, error) {
+	bt, err := cb.Trader(name, t)
+	if err != nil {
+		return nil, err
+	}
+	return bt, nil
+}
+
+func bucketKey(symbol, side string) string {
+	return market.Normalize(symbol) + "|" + side
+}
+
+// GetBalance 返回资金桶的余额视图
+func (b *BucketTrader) GetBalance() (map[string]interface{}, error) {
+	state, err := b.buckets.State(b.name)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"totalWalletBalance":    state.Equity(),
+		"availableBalance":      state.Available(),
+		"totalUnrealizedProfit": 0.0,
+	}, nil
+}
+
+// GetPositions 只返回本资金桶持有的仓位（数量和均价按资金桶记账）
+func (b *BucketTrader) GetPositions() ([]map[string]interface{}, error) {
+	positions, err := b.Trader.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var out []map[string]interface{}
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		own, ok := b.positions[bucketKey(symbol, side)]
+		if !ok {
+			continue
+		}
+		view := make(map[string]interface{}, len(pos))
+		for k, v := range pos {
+			view[k] = v
+		}
+		view["positionAmt"] = own.Quantity
+		view["entryPrice"] = own.Entry
+		if mark, ok := pos["markPrice"].(float64); ok && mark > 0 {
+			pnl := (mark - own.Entry) * own.Quantity
+			if side == SideShort {
+				pnl = -pnl
+			}
+			view["unRealizedProfit"] = pnl
+		}
+		out = append(out, view)
+	}
+	return out, nil
+}
+
+// OpenLong 开多仓并占用资金桶保证金
+func (b *BucketTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return b.open(symbol, SideLong, quantity, leverage)
+}
+
+// OpenShort 开空仓并占用资金桶保证金
+func (b *BucketTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return b.open(symbol, SideShort, quantity, leverage)
+}
+
+// CloseLong 平多仓（quantity=0 表示平掉本资金桶的全部多仓）
+func (b *BucketTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return b.close(symbol, SideLong, quantity)
+}
+
+// CloseShort 平空仓（quantity=0 表示平掉本资金桶的全部空仓）
+func (b *BucketTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return b.close(symbol, SideShort, quantity)
+}
+
+func (b *BucketTrader) open(symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	if leverage <= 0 {
+		leverage = 1
+	}
+	price, err := b.Trader.GetMarketPrice(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取价格失败: %w", err)
+	}
+	margin := quantity * price / float64(leverage)
+	if err := b.buckets.Reserve(b.name, margin); err != nil {
+		return nil, err
+	}
+
+	result, err := openPosition(b.Trader, symbol, side, quantity, leverage)
+	if err != nil {
+		if releaseErr := b.buckets.Release(b.name, margin, 0); releaseErr != nil {
+			log.Printf("⚠️ [资金桶] %s 释放保证金失败: %v", b.name, releaseErr)
+		}
+		return nil, err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := bucketKey(symbol, side)
+	pos, ok := b.positions[key]
+	if !ok {
+		pos = &bucketPosition{}
+		b.positions[key] = pos
+	}
+	total := pos.Quantity + quantity
+	pos.Entry = (pos.Entry*pos.Quantity + price*quantity) / total
+	pos.Quantity = total
+	pos.Margin += margin
+	return result, nil
+}
+
+func (b *BucketTrader) close(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	key := bucketKey(symbol, side)
+
+	b.mu.Lock()
+	pos, ok := b.positions[key]
+	if !ok || pos.Quantity <= 0 {
+		b.mu.Unlock()
+		return nil, fmt.Errorf("资金桶 %s 没有 %s 的%s持仓", b.name, symbol, side)
+	}
+	if quantity <= 0 || quantity > pos.Quantity {
+		quantity = pos.Quantity
+	}
+	b.mu.Unlock()
+
+	price, err := b.Trader.GetMarketPrice(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取价格失败: %w", err)
+	}
+	result, err := closePosition(b.Trader, symbol, side, quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	b.mu.Lock()
+	fraction := quantity / pos.Quantity
+	margin := pos.Margin * fraction
+	pnl := (price - pos.Entry) * quantity
+	if side == SideShort {
+		pnl = -pnl
+	}
+	pos.Quantity -= quantity
+	pos.Margin -= margin
+	if pos.Quantity < 1e-12 {
+		delete(b.positions, key)
+	}
+	b.mu.Unlock()
+
+	if err := b.buckets.Release(b.name, margin, pnl); err != nil {
+		log.Printf("⚠️ [资金桶] %s 记录盈亏失败: %v", b.name, err)
+	}
+	log.Printf("📒 [资金桶] %s 平仓 %s %s %.4f @ %.4f，盈亏 %+.2f", b.name, symbol, side, quantity, price, pnl)
+	return result, nil
+}
@@ -0,0 +1,120 @@
+package strategy
+
+import (
+	"path/filepath"
+	"testing"
+
+	"nofx/trader"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBuckets(t *testing.T, statePath string) *CapitalBuckets {
+	buckets, err := NewCapitalBuckets(CapitalBucketsConfig{
+		Buckets: []BucketConfig{
+			{Name: "breakout", Allocation: 1000},
+			{Name: "dca", Allocation: 500},
+		},
+		StatePath: statePath,
+	})
+	require.NoError(t, err)
+	return buckets
+}
+
+func TestCapitalBucketsConfig_Validate(t *testing.T) {
+	assert.Error(t, CapitalBucketsConfig{Buckets: []BucketConfig{{Name: "a"}, {Name: "a"}}}.Validate())
+	assert.Error(t, CapitalBucketsConfig{Buckets: []BucketConfig{{Name: ""}}}.Validate())
+	assert.Error(t, CapitalBucketsConfig{Buckets: []BucketConfig{{Name: "a", Allocation: -1}}}.Validate())
+}
+
+func TestBucketTrader_SizesAgainstOwnCapital(t *testing.T) {
+	mt := newMockTrader()
+	mt.setPrice("SOLUSDT", 100)
+	buckets := testBuckets(t, "")
+
+	breakout, err := buckets.Trader("breakout", mt)
+	require.NoError(t, err)
+	dca, err := buckets.Trader("dca", mt)
+	require.NoError(t, err)
+
+	balance, err := dca.GetBalance()
+	require.NoError(t, err)
+	assert.Equal(t, 500.0, balance["totalWalletBalance"])
+
+	// 保证金 20*100/2 = 1000 超过 dca 的 500
+	_, err = dca.OpenLong("SOLUSDT", 20, 2)
+	assert.ErrorIs(t, err, ErrBucketInsufficient)
+	assert.Empty(t, mt.ordersOf("open_long"))
+
+	_, err = breakout.OpenLong("SOLUSDT", 10, 2)
+	require.NoError(t, err)
+	_, err = dca.OpenLong("SOLUSDT", 4, 2)
+	require.NoError(t, err)
+	state, _ := buckets.State("breakout")
+	assert.InDelta(t, 500, state.Available(), 1e-9)
+
+	// 各自只看到自己的仓位
+	positions, err := dca.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, 4.0, positions[0]["positionAmt"])
+
+	// quantity=0 只平掉本资金桶的数量，盈亏记入本资金桶
+	mt.setPrice("SOLUSDT", 110)
+	_, err = dca.CloseLong("SOLUSDT", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, mt.position("SOLUSDT", SideLong))
+
+	dcaState, _ := buckets.State("dca")
+	assert.InDelta(t, 40, dcaState.RealizedPnL, 1e-9)
+	assert.InDelta(t, 540, dcaState.Available(), 1e-9)
+	breakoutState, _ := buckets.State("breakout")
+	assert.Zero(t, breakoutState.RealizedPnL)
+
+	_, err = dca.CloseLong("SOLUSDT", 0)
+	assert.Error(t, err, "资金桶已无持仓")
+}
+
+func TestCapitalBuckets_TransferPersists(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "buckets.json")
+	buckets := testBuckets(t, statePath)
+
+	require.NoError(t, buckets.Reserve("breakout", 800))
+	assert.ErrorIs(t, buckets.Transfer("breakout", "dca", 300), ErrBucketInsufficient)
+	require.NoError(t, buckets.Transfer("breakout", "dca", 200))
+
+	// 重启后以状态文件为准，配置中的初始分配不再覆盖
+	reloaded := testBuckets(t, statePath)
+	states := reloaded.States()
+	require.Len(t, states, 2)
+	assert.Equal(t, "breakout", states[0].Name)
+	assert.Equal(t, 800.0, states[0].Allocated)
+	assert.Equal(t, 700.0, states[1].Allocated)
+	assert.Zero(t, states[0].Reserved)
+}
+
+func TestCapitalBuckets_CheckAccount(t *testing.T) {
+	mt := newMockTrader()
+	buckets := testBuckets(t, "")
+	assert.NoError(t, buckets.CheckAccount(mt))
+
+	mt.balance = 1200
+	assert.Error(t, buckets.CheckAccount(mt))
+}
+
+func TestCapitalBuckets_WrapTrader(t *testing.T) {
+	mt := newMockTrader()
+	buckets := testBuckets(t, "")
+
+	var source trader.CapitalBucketSource = buckets
+	wrapped, err := source.WrapTrader("dca", mt)
+	require.NoError(t, err)
+	balance, err := wrapped.GetBalance()
+	require.NoError(t, err)
+	assert.InDelta(t, 500, balance["totalWalletBalance"].(float64), 1e-9, "按资金桶权益返回余额")
+
+	wrapped, err = source.WrapTrader("missing", mt)
+	assert.Error(t, err)
+	assert.Nil(t, wrapped, "失败时不能返回包含 nil 指针的接口")
+}
@@ -0,0 +1,264 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"nofx/logger"
+	"nofx/trader"
+)
+
+// defaultTopUpLeverage 持仓未返回杠杆时估算保证金使用的杠杆（与 AutoTrader 一致）
+const defaultTopUpLeverage = 10
+
+// CollateralTransferFunc 在账户之间划转抵押品（USDT/USDC），由调用方对接交易所的子账户划转接口
+type CollateralTransferFunc func(from, to string, amount float64) error
+
+// BackpackSubaccountTransfer 返回通过 Backpack 子账户划转实现的 CollateralTransferFunc
+// subaccounts 把账户名映射到子账户ID（0 表示主账户），asset 为划转的抵押品币种（如 USDC）
+func BackpackSubaccountTransfer(t *trader.BackpackTrader, asset string, subaccounts map[string]int) CollateralTransferFunc {
+	return func(from, to string, amount float64) error {
+		fromID, ok := subaccounts[from]
+		if !ok {
+			return fmt.Errorf("未知的划转账户: %s", from)
+		}
+		toID, ok := subaccounts[to]
+		if !ok {
+			return fmt.Errorf("未知的划转账户: %s", to)
+		}
+		return t.TransferBetweenSubaccounts(fromID, toID, asset, amount)
+	}
+}
+
+// TopUpAccount 被监控的子账户
+type TopUpAccount struct {
+	Name   string
+	Trader trader.Trader
+}
+
+// CollateralTopUpConfig 自动补充保证金配置
+type CollateralTopUpConfig struct {
+	TriggerUsagePct   float64       // 保证金使用率超过该值时补充（百分比）
+	TargetUsagePct    float64       // 补充后的目标使用率（百分比，需低于 TriggerUsagePct）
+	MinTransfer       float64       // 单次划转下限，计算结果更小时不划转
+	MaxTransfer       float64       // 单次划转上限（0 表示不限制）
+	MaxDailyTransfer  float64       // 每个UTC日所有账户的划转总额上限（0 表示不限制）
+	MinReserveBalance float64       // 备用账户至少保留的可用余额
+	Cooldown          time.Duration // 同一账户两次划转的最小间隔
+}
+
+// CollateralTopUp 一次补充结果
+type CollateralTopUp struct {
+	Account     string
+	UsagePct    float64 // 划转前保证金使用率
+	Amount      float64 // 实际划转金额（失败或被跳过时为0）
+	TargetUsage float64 // 按划转金额预估的使用率
+	Err         error
+}
+
+// CollateralTopUpService 监控各子账户保证金使用率，超过阈值时从备用账户划转抵押品，避免本可避免的强平
+// 划转受单次、每日和备用账户余额限制，被限制或失败时通过 logger.Alertf 告警
+type CollateralTopUpService struct {
+	cfg      CollateralTopUpConfig
+	reserve  TopUpAccount
+	accounts []TopUpAccount
+	transfer CollateralTransferFunc
+	alert    func(format string, args ...interface{})
+	now      func() time.Time
+
+	mu           sync.Mutex
+	lastTransfer map[string]time.Time
+	day          string
+	dayTotal     float64
+}
+
+// NewCollateralTopUpService 创建自动补充保证金服务
+func NewCollateralTopUpService(cfg CollateralTopUpConfig, reserve TopUpAccount, transfer CollateralTransferFunc, accounts ...TopUpAccount) (*CollateralTopUpService, error) {
+	if reserve.Trader == nil || reserve.Name == "" {
+		return nil, fmt.Errorf("备用账户未配置")
+	}
+	if transfer == nil {
+		return nil, fmt.Errorf("未配置划转函数")
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("至少需要一个被监控账户")
+	}
+	if cfg.TriggerUsagePct <= 0 || cfg.TriggerUsagePct > 100 {
+		return nil, fmt.Errorf("触发使用率必须在 (0, 100] 之间")
+	}
+	if cfg.TargetUsagePct <= 0 || cfg.TargetUsagePct >= cfg.TriggerUsagePct {
+		return nil, fmt.Errorf("目标使用率必须大于0且低于触发使用率")
+	}
+	if cfg.MinTransfer < 0 || cfg.MaxTransfer < 0 || cfg.MaxDailyTransfer < 0 || cfg.MinReserveBalance < 0 {
+		return nil, fmt.Errorf("划转限额不能为负数")
+	}
+	for _, a := range accounts {
+		if a.Trader == nil || a.Name == "" {
+			return nil, fmt.Errorf("被监控账户 %q 未配置交易器", a.Name)
+		}
+		if a.Name == reserve.Name {
+			return nil, fmt.Errorf("备用账户 %q 不能同时被监控", a.Name)
+		}
+	}
+	return &CollateralTopUpService{
+		cfg:          cfg,
+		reserve:      reserve,
+		accounts:     accounts,
+		transfer:     transfer,
+		alert:        logger.Alertf,
+		now:          time.Now,
+		lastTransfer: make(map[string]time.Time),
+	}, nil
+}
+
+// Check 检查一次所有账户，对超过阈值的账户按使用率从高到低依次补充
+func (s *CollateralTopUpService) Check() []CollateralTopUp {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	if day := now.UTC().Format("2006-01-02"); day != s.day {
+		s.day, s.dayTotal = day, 0
+	}
+
+	type pending struct {
+		account      TopUpAccount
+		equity, used float64
+		usage        float64
+	}
+	var queue []pending
+	var results []CollateralTopUp
+	for _, a := range s.accounts {
+		equity, used, err := marginUsage(a.Trader)
+		if err != nil {
+			log.Printf("❌ [TopUp] %s 获取保证金使用率失败: %v", a.Name, err)
+			results = append(results, CollateralTopUp{Account: a.Name, Err: err})
+			continue
+		}
+		usage := 100.0
+		if equity > 0 {
+			usage = used / equity * 100
+		}
+		if usage > s.cfg.TriggerUsagePct {
+			queue = append(queue, pending{account: a, equity: equity, used: used, usage: usage})
+		}
+	}
+	// 使用率最高的账户优先使用备用资金
+	sort.SliceStable(queue, func(i, j int) bool { return queue[i].usage > queue[j].usage })
+
+	for _, p := range queue {
+		result := CollateralTopUp{Account: p.account.Name, UsagePct: p.usage, TargetUsage: p.usage}
+		if last, ok := s.lastTransfer[p.account.Name]; ok && now.Sub(last) < s.cfg.Cooldown {
+			log.Printf("⏳ [TopUp] %s 使用率 %.1f%%，划转冷却中", p.account.Name, p.usage)
+			continue
+		}
+
+		amount, err := s.transferAmount(p.equity, p.used)
+		if err == nil && amount < s.cfg.MinTransfer {
+			err = fmt.Errorf("可划转金额 %.2f 低于下限 %.2f", amount, s.cfg.MinTransfer)
+		}
+		if err == nil {
+			err = s.transfer(s.reserve.Name, p.account.Name, amount)
+		}
+		if err != nil {
+			result.Err = err
+			s.alert("保证金补充失败 [%s]: 使用率 %.1f%% 超过 %.1f%%，%v", p.account.Name, p.usage, s.cfg.TriggerUsagePct, err)
+			results = append(results, result)
+			continue
+		}
+
+		s.lastTransfer[p.account.Name] = now
+		s.dayTotal += amount
+		result.Amount = amount
+		result.TargetUsage = p.used / (p.equity + amount) * 100
+		log.Printf("💸 [TopUp] %s → %s 划转 %.2f，使用率 %.1f%% → %.1f%%",
+			s.reserve.Name, p.account.Name, amount, p.usage, result.TargetUsage)
+		if result.TargetUsage > s.cfg.TriggerUsagePct {
+			s.alert("保证金补充受限 [%s]: 划转 %.2f 后使用率仍为 %.1f%%", p.account.Name, amount, result.TargetUsage)
+		}
+		results = append(results, result)
+	}
+	return results
+}
+
+// Run 按 interval 周期检查所有账户，直到 stop 被关闭
+func (s *CollateralTopUpService) Run(interval time.Duration, stop <-chan struct{}) {
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("🛟 [TopUp] 启动，监控 %d 个账户，周期 %v", len(s.accounts), interval)
+	for {
+		s.Check()
+		select {
+		case <-stop:
+			log.Printf("⏹ [TopUp] 已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// transferAmount 计算使账户回到目标使用率所需的金额，并按单次、每日和备用账户余额限制截断
+func (s *CollateralTopUpService) transferAmount(equity, used float64) (float64, error) {
+	need := used/(s.cfg.TargetUsagePct/100) - equity
+	if need <= 0 {
+		return 0, nil
+	}
+	if s.cfg.MaxTransfer > 0 {
+		need = math.Min(need, s.cfg.MaxTransfer)
+	}
+	if s.cfg.MaxDailyTransfer > 0 {
+		remaining := s.cfg.MaxDailyTransfer - s.dayTotal
+		if remaining <= 0 {
+			return 0, fmt.Errorf("今日划转总额已达上限 %.2f", s.cfg.MaxDailyTransfer)
+		}
+		need = math.Min(need, remaining)
+	}
+
+	balance, err := s.reserve.Trader.GetBalance()
+	if err != nil {
+		return 0, fmt.Errorf("获取备用账户余额失败: %w", err)
+	}
+	available, _ := balance["availableBalance"].(float64)
+	spare := available - s.cfg.MinReserveBalance
+	if spare <= 0 {
+		return 0, fmt.Errorf("备用账户可用余额 %.2f 不足（需保留 %.2f）", available, s.cfg.MinReserveBalance)
+	}
+	return math.Min(need, spare), nil
+}
+
+// marginUsage 返回账户权益和持仓占用的保证金
+// 持仓提供 initialMargin 时直接使用，否则按 数量×价格/杠杆 估算
+func marginUsage(t trader.Trader) (equity, used float64, err error) {
+	if equity, err = accountEquity(t); err != nil {
+		return 0, 0, err
+	}
+	positions, err := t.GetPositions()
+	if err != nil {
+		return 0, 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	for _, pos := range positions {
+		if margin, ok := pos["initialMargin"].(float64); ok && margin > 0 {
+			used += margin
+			continue
+		}
+		qty, _ := pos["positionAmt"].(float64)
+		price, _ := pos["entryPrice"].(float64)
+		if price <= 0 {
+			price, _ = pos["markPrice"].(float64)
+		}
+		leverage := float64(defaultTopUpLeverage)
+		if lev, ok := pos["leverage"].(float64); ok && lev > 0 {
+			leverage = lev
+		}
+		used += math.Abs(qty) * price / leverage
+	}
+	return equity, used, nil
+}
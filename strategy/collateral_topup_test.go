@@ -0,0 +1,194 @@
+package strategy
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"nofx/trader"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// transferLog 记录划转并同步调整 mockTrader 余额
+type transferLog struct {
+	accounts map[string]*mockTrader
+	calls    []string
+	err      error
+}
+
+func (l *transferLog) transfer(from, to string, amount float64) error {
+	if l.err != nil {
+		return l.err
+	}
+	l.calls = append(l.calls, fmt.Sprintf("%s->%s:%.0f", from, to, amount))
+	l.accounts[from].balance -= amount
+	l.accounts[to].balance += amount
+	return nil
+}
+
+func newTopUpFixture(t *testing.T, cfg CollateralTopUpConfig) (*CollateralTopUpService, *transferLog, *mockTrader, *mockTrader, *[]string) {
+	reserve := newMockTrader()
+	sub := newMockTrader()
+	sub.balance = 1000
+	sub.setPrice("BTCUSDT", 50000)
+	// 保证金 = 0.18 × 50000 / 10 = 900，使用率 90%
+	sub.positions["BTCUSDT|long"] = 0.18
+
+	log := &transferLog{accounts: map[string]*mockTrader{"reserve": reserve, "sub1": sub}}
+	svc, err := NewCollateralTopUpService(cfg, TopUpAccount{Name: "reserve", Trader: reserve}, log.transfer,
+		TopUpAccount{Name: "sub1", Trader: sub})
+	require.NoError(t, err)
+
+	var alerts []string
+	svc.alert = func(format string, args ...interface{}) {
+		alerts = append(alerts, fmt.Sprintf(format, args...))
+	}
+	return svc, log, reserve, sub, &alerts
+}
+
+func TestCollateralTopUp_TransfersToTargetUsage(t *testing.T) {
+	svc, log, _, sub, alerts := newTopUpFixture(t, CollateralTopUpConfig{TriggerUsagePct: 80, TargetUsagePct: 50})
+
+	results := svc.Check()
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err)
+	assert.InDelta(t, 90, results[0].UsagePct, 1e-9)
+	assert.InDelta(t, 800, results[0].Amount, 1e-9) // 900 / 0.5 - 1000
+	assert.InDelta(t, 50, results[0].TargetUsage, 1e-9)
+	assert.Equal(t, []string{"reserve->sub1:800"}, log.calls)
+	assert.InDelta(t, 1800, sub.balance, 1e-9)
+	assert.Empty(t, *alerts)
+
+	// 使用率已回到阈值以下，不再划转
+	assert.Empty(t, svc.Check())
+}
+
+func TestCollateralTopUp_CapsAndAlerts(t *testing.T) {
+	svc, log, reserve, sub, alerts := newTopUpFixture(t, CollateralTopUpConfig{
+		TriggerUsagePct:   80,
+		TargetUsagePct:    50,
+		MaxTransfer:       100,
+		MaxDailyTransfer:  150,
+		MinReserveBalance: 9000,
+	})
+	sub.positions["BTCUSDT|long"] = 0.19 // 保证金 950，使用率 95%
+	now := time.Date(2025, 1, 1, 8, 0, 0, 0, time.UTC)
+	svc.now = func() time.Time { return now }
+
+	results := svc.Check()
+	require.Len(t, results, 1)
+	assert.InDelta(t, 100, results[0].Amount, 1e-9)
+	require.Len(t, *alerts, 1, "划转后仍高于阈值应告警")
+	assert.Contains(t, (*alerts)[0], "受限")
+
+	// 每日上限只剩50
+	results = svc.Check()
+	require.Len(t, results, 1)
+	assert.InDelta(t, 50, results[0].Amount, 1e-9)
+
+	// 今日额度已用完
+	results = svc.Check()
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "上限")
+	assert.Len(t, log.calls, 2)
+
+	// 次日额度恢复，但备用账户需保留9000
+	now = now.Add(24 * time.Hour)
+	reserve.balance = 9000
+	results = svc.Check()
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "不足")
+}
+
+func TestCollateralTopUp_CooldownAndTransferError(t *testing.T) {
+	svc, log, _, _, alerts := newTopUpFixture(t, CollateralTopUpConfig{
+		TriggerUsagePct: 80,
+		TargetUsagePct:  50,
+		MaxTransfer:     100,
+		Cooldown:        time.Hour,
+	})
+	now := time.Now()
+	svc.now = func() time.Time { return now }
+
+	require.Len(t, svc.Check(), 1)
+	assert.Empty(t, svc.Check(), "冷却期内不应再次划转")
+
+	now = now.Add(2 * time.Hour)
+	log.err = errors.New("transfer disabled")
+	results := svc.Check()
+	require.Len(t, results, 1)
+	assert.ErrorContains(t, results[0].Err, "transfer disabled")
+	assert.Contains(t, (*alerts)[len(*alerts)-1], "补充失败")
+}
+
+func TestNewCollateralTopUpService_Validation(t *testing.T) {
+	reserve := TopUpAccount{Name: "reserve", Trader: newMockTrader()}
+	sub := TopUpAccount{Name: "sub1", Trader: newMockTrader()}
+	noop := func(string, string, float64) error { return nil }
+
+	_, err := NewCollateralTopUpService(CollateralTopUpConfig{TriggerUsagePct: 80, TargetUsagePct: 90}, reserve, noop, sub)
+	assert.Error(t, err)
+	_, err = NewCollateralTopUpService(CollateralTopUpConfig{TriggerUsagePct: 80, TargetUsagePct: 50}, reserve, nil, sub)
+	assert.Error(t, err)
+	_, err = NewCollateralTopUpService(CollateralTopUpConfig{TriggerUsagePct: 80, TargetUsagePct: 50}, reserve, noop, reserve)
+	assert.Error(t, err)
+	_, err = NewCollateralTopUpService(CollateralTopUpConfig{TriggerUsagePct: 80, TargetUsagePct: 50}, reserve, noop, sub)
+	assert.NoError(t, err)
+}
+
+// roundTripFunc 拦截交易器发出的请求
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestBackpackSubaccountTransfer(t *testing.T) {
+	var bodies []map[string]interface{}
+	rt := roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		assert.Equal(t, "/wapi/v1/subaccounts/transfer", r.URL.Path)
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		bodies = append(bodies, body)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{}`)), Header: http.Header{}}, nil
+	})
+	bp, err := trader.NewBackpackTrader("test-api-key", base64.StdEncoding.EncodeToString(make([]byte, ed25519.SeedSize)), "test-user", trader.WithTransport(rt))
+	require.NoError(t, err)
+	defer bp.Stop()
+
+	transfer := BackpackSubaccountTransfer(bp, "USDC", map[string]int{"reserve": 0, "sub1": 4})
+	require.NoError(t, transfer("reserve", "sub1", 120))
+	require.Len(t, bodies, 1)
+	assert.Equal(t, float64(0), bodies[0]["fromSubaccountId"])
+	assert.Equal(t, float64(4), bodies[0]["toSubaccountId"])
+	assert.Equal(t, "USDC", bodies[0]["symbol"])
+	assert.Equal(t, "120", bodies[0]["quantity"])
+
+	assert.ErrorContains(t, transfer("reserve", "sub2", 10), "未知的划转账户")
+	assert.Len(t, bodies, 1)
+}
+
+func TestCollateralTopUp_RunChecksUntilStopped(t *testing.T) {
+	svc, log, _, _, _ := newTopUpFixture(t, CollateralTopUpConfig{TriggerUsagePct: 80, TargetUsagePct: 50})
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		svc.Run(time.Hour, stop)
+		close(done)
+	}()
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run 未在 stop 关闭后退出")
+	}
+	assert.Equal(t, []string{"reserve->sub1:800"}, log.calls, "启动时立即检查一次")
+}
@@ -0,0 +1,269 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"nofx/trader"
+)
+
+// DCAConfig 定投（DCA）/有上限的马丁加仓策略配置
+type DCAConfig struct {
+	Symbol   string
+	Side     string // "long" 或 "short"
+	Leverage int
+
+	BaseOrderQuote   float64 // 首单金额（USDT）
+	SafetyOrderQuote float64 // 第一笔安全单金额（USDT）
+	MaxSafetyOrders  int     // 安全单数量上限
+	PriceDeviation   float64 // 第一笔安全单相对首单价格的偏离（百分比，如 1.5 表示 1.5%）
+	StepScale        float64 // 安全单间距倍数（>=1，每一级偏离 = 上一级 × StepScale）
+	VolumeScale      float64 // 安全单金额倍数（>=1，马丁系数）
+	MaxTotalQuote    float64 // 总投入上限（USDT，0 表示不限制）
+	TakeProfitPct    float64 // 相对持仓均价的整体止盈百分比
+}
+
+// Validate 校验配置
+func (c DCAConfig) Validate() error {
+	if c.Symbol == "" {
+		return fmt.Errorf("symbol 不能为空")
+	}
+	if err := validateSide(c.Side); err != nil {
+		return err
+	}
+	if c.BaseOrderQuote <= 0 {
+		return fmt.Errorf("首单金额必须大于0")
+	}
+	if c.MaxSafetyOrders < 0 {
+		return fmt.Errorf("安全单数量不能为负数")
+	}
+	if c.MaxSafetyOrders > 0 {
+		if c.SafetyOrderQuote <= 0 {
+			return fmt.Errorf("安全单金额必须大于0")
+		}
+		if c.PriceDeviation <= 0 || c.PriceDeviation >= 100 {
+			return fmt.Errorf("价格偏离必须在 (0, 100) 之间")
+		}
+		if c.StepScale < 1 || c.VolumeScale < 1 {
+			return fmt.Errorf("间距倍数和金额倍数不能小于1")
+		}
+	}
+	if c.TakeProfitPct <= 0 {
+		return fmt.Errorf("止盈百分比必须大于0")
+	}
+	if c.MaxTotalQuote > 0 && c.MaxTotalQuote < c.BaseOrderQuote {
+		return fmt.Errorf("总投入上限 %.2f 小于首单金额 %.2f", c.MaxTotalQuote, c.BaseOrderQuote)
+	}
+	return nil
+}
+
+// DCALevel 安全单档位
+type DCALevel struct {
+	Index int     // 第几笔安全单（从1开始）
+	Price float64 // 触发价格
+	Quote float64 // 下单金额（USDT）
+}
+
+// SafetyLevels 根据首单成交价计算全部安全单档位
+// 超出总投入上限的档位会被截断
+func (c DCAConfig) SafetyLevels(basePrice float64) []DCALevel {
+	levels := make([]DCALevel, 0, c.MaxSafetyOrders)
+	deviation := 0.0
+	step := c.PriceDeviation
+	quote := c.SafetyOrderQuote
+	total := c.BaseOrderQuote
+
+	for i := 1; i <= c.MaxSafetyOrders; i++ {
+		deviation += step
+		if deviation >= 100 {
+			break
+		}
+		if c.MaxTotalQuote > 0 && total+quote > c.MaxTotalQuote {
+			break
+		}
+
+		price := basePrice * (1 - deviation/100)
+		if c.Side == SideShort {
+			price = basePrice * (1 + deviation/100)
+		}
+		levels = append(levels, DCALevel{Index: i, Price: price, Quote: quote})
+
+		total += quote
+		step *= c.StepScale
+		quote *= c.VolumeScale
+	}
+	return levels
+}
+
+// DCAAction OnPrice 执行的动作
+type DCAAction string
+
+const (
+	DCAActionNone       DCAAction = "none"
+	DCAActionSafety     DCAAction = "safety_order"
+	DCAActionTakeProfit DCAAction = "take_profit"
+)
+
+// DCAStrategy 定投/马丁加仓策略
+// 首单市价开仓，价格每触及一个安全单档位就市价加仓，并按新的持仓均价重设整体止盈
+type DCAStrategy struct {
+	cfg    DCAConfig
+	trader trader.Trader
+
+	levels    []DCALevel // 安全单档位（首单成交后确定）
+	nextLevel int        // 下一笔待触发的安全单下标
+	quantity  float64    // 累计持仓数量
+	cost      float64    // 累计持仓成本（USDT）
+	active    bool
+	mu        sync.Mutex
+}
+
+// NewDCAStrategy 创建DCA策略
+func NewDCAStrategy(cfg DCAConfig, t trader.Trader) (*DCAStrategy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("DCA配置无效: %w", err)
+	}
+	return &DCAStrategy{cfg: cfg, trader: t}, nil
+}
+
+// Start 市价开首单并挂出整体止盈
+func (s *DCAStrategy) Start() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active {
+		return fmt.Errorf("DCA策略已在运行: %s", s.cfg.Symbol)
+	}
+
+	price, err := s.trader.GetMarketPrice(s.cfg.Symbol)
+	if err != nil {
+		return fmt.Errorf("获取价格失败: %w", err)
+	}
+	if err := s.buyLocked(s.cfg.BaseOrderQuote, price); err != nil {
+		return fmt.Errorf("首单失败: %w", err)
+	}
+
+	s.levels = s.cfg.SafetyLevels(price)
+	s.nextLevel = 0
+	s.active = true
+	log.Printf("📐 [DCA] %s %s 首单成交 @ %.4f，安全单 %d 档", s.cfg.Symbol, s.cfg.Side, price, len(s.levels))
+
+	s.resetTakeProfitLocked()
+	return nil
+}
+
+// Tick 获取最新价并执行 OnPrice
+func (s *DCAStrategy) Tick() (DCAAction, error) {
+	price, err := s.trader.GetMarketPrice(s.cfg.Symbol)
+	if err != nil {
+		return DCAActionNone, fmt.Errorf("获取价格失败: %w", err)
+	}
+	return s.OnPrice(price)
+}
+
+// OnPrice 根据最新价格判断是否触发安全单或整体止盈
+func (s *DCAStrategy) OnPrice(price float64) (DCAAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active {
+		return DCAActionNone, nil
+	}
+
+	// 整体止盈（交易所止盈单未触发或未能挂出时兜底）
+	tp := s.takeProfitPriceLocked()
+	if (s.cfg.Side == SideLong && price >= tp) || (s.cfg.Side == SideShort && price <= tp) {
+		if _, err := closePosition(s.trader, s.cfg.Symbol, s.cfg.Side, 0); err != nil {
+			return DCAActionNone, fmt.Errorf("止盈平仓失败: %w", err)
+		}
+		log.Printf("💰 [DCA] %s 触发整体止盈 @ %.4f（均价 %.4f）", s.cfg.Symbol, price, s.averagePriceLocked())
+		s.active = false
+		return DCAActionTakeProfit, nil
+	}
+
+	if s.nextLevel >= len(s.levels) {
+		return DCAActionNone, nil
+	}
+	level := s.levels[s.nextLevel]
+	if (s.cfg.Side == SideLong && price > level.Price) || (s.cfg.Side == SideShort && price < level.Price) {
+		return DCAActionNone, nil
+	}
+
+	if err := s.buyLocked(level.Quote, price); err != nil {
+		return DCAActionNone, fmt.Errorf("安全单 #%d 失败: %w", level.Index, err)
+	}
+	s.nextLevel++
+	log.Printf("📐 [DCA] %s 安全单 #%d 成交 @ %.4f，新均价 %.4f", s.cfg.Symbol, level.Index, price, s.averagePriceLocked())
+
+	s.resetTakeProfitLocked()
+	return DCAActionSafety, nil
+}
+
+// buyLocked 按金额市价加仓
+func (s *DCAStrategy) buyLocked(quote, price float64) error {
+	qty, err := quoteToQuantity(s.trader, s.cfg.Symbol, quote, price)
+	if err != nil {
+		return err
+	}
+	if _, err := openPosition(s.trader, s.cfg.Symbol, s.cfg.Side, qty, s.cfg.Leverage); err != nil {
+		return err
+	}
+	s.quantity += qty
+	s.cost += qty * price
+	return nil
+}
+
+// resetTakeProfitLocked 按最新均价重挂整体止盈单
+func (s *DCAStrategy) resetTakeProfitLocked() {
+	if err := s.trader.CancelTakeProfitOrders(s.cfg.Symbol); err != nil {
+		log.Printf("⚠️ [DCA] %s 取消旧止盈单失败: %v", s.cfg.Symbol, err)
+	}
+	tp := s.takeProfitPriceLocked()
+	if err := s.trader.SetTakeProfit(s.cfg.Symbol, positionSide(s.cfg.Side), s.quantity, tp); err != nil {
+		log.Printf("⚠️ [DCA] %s 设置止盈失败: %v（将由 OnPrice 兜底）", s.cfg.Symbol, err)
+	}
+}
+
+// averagePriceLocked 持仓均价
+func (s *DCAStrategy) averagePriceLocked() float64 {
+	if s.quantity == 0 {
+		return 0
+	}
+	return s.cost / s.quantity
+}
+
+// takeProfitPriceLocked 整体止盈价
+func (s *DCAStrategy) takeProfitPriceLocked() float64 {
+	avg := s.averagePriceLocked()
+	if s.cfg.Side == SideShort {
+		return avg * (1 - s.cfg.TakeProfitPct/100)
+	}
+	return avg * (1 + s.cfg.TakeProfitPct/100)
+}
+
+// DCAState 策略状态快照
+type DCAState struct {
+	Active           bool
+	Quantity         float64
+	AveragePrice     float64
+	TakeProfitPrice  float64
+	SafetyOrdersUsed int
+	SafetyOrdersLeft int
+	InvestedQuote    float64
+}
+
+// State 获取当前状态
+func (s *DCAStrategy) State() DCAState {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return DCAState{
+		Active:           s.active,
+		Quantity:         s.quantity,
+		AveragePrice:     s.averagePriceLocked(),
+		TakeProfitPrice:  s.takeProfitPriceLocked(),
+		SafetyOrdersUsed: s.nextLevel,
+		SafetyOrdersLeft: len(s.levels) - s.nextLevel,
+		InvestedQuote:    s.cost,
+	}
+}
@@ -0,0 +1,109 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testDCAConfig() DCAConfig {
+	return DCAConfig{
+		Symbol:           "SOLUSDT",
+		Side:             SideLong,
+		Leverage:         3,
+		BaseOrderQuote:   100,
+		SafetyOrderQuote: 100,
+		MaxSafetyOrders:  5,
+		PriceDeviation:   2,
+		StepScale:        1.5,
+		VolumeScale:      2,
+		MaxTotalQuote:    1000,
+		TakeProfitPct:    1,
+	}
+}
+
+func TestDCAConfig_SafetyLevels(t *testing.T) {
+	levels := testDCAConfig().SafetyLevels(100)
+
+	// 金额 100, 200, 400 累计 800；第4档 800 会超过总投入上限 1000
+	require.Len(t, levels, 3)
+	assert.InDelta(t, 98, levels[0].Price, 1e-9)   // -2%
+	assert.InDelta(t, 95, levels[1].Price, 1e-9)   // -2% -3%
+	assert.InDelta(t, 90.5, levels[2].Price, 1e-9) // -2% -3% -4.5%
+	assert.Equal(t, []float64{100, 200, 400}, []float64{levels[0].Quote, levels[1].Quote, levels[2].Quote})
+
+	short := testDCAConfig()
+	short.Side = SideShort
+	assert.InDelta(t, 102, short.SafetyLevels(100)[0].Price, 1e-9)
+}
+
+func TestDCAConfig_Validate(t *testing.T) {
+	cfg := testDCAConfig()
+	cfg.Side = "up"
+	assert.Error(t, cfg.Validate())
+
+	cfg = testDCAConfig()
+	cfg.VolumeScale = 0.5
+	assert.Error(t, cfg.Validate())
+
+	cfg = testDCAConfig()
+	cfg.MaxTotalQuote = 50
+	assert.Error(t, cfg.Validate())
+
+	assert.NoError(t, testDCAConfig().Validate())
+}
+
+func TestDCAStrategy_SafetyOrdersAndTakeProfit(t *testing.T) {
+	mt := newMockTrader()
+	mt.setPrice("SOLUSDT", 100)
+
+	s, err := NewDCAStrategy(testDCAConfig(), mt)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+	assert.InDelta(t, 1.0, mt.position("SOLUSDT", SideLong), 1e-9)
+	assert.InDelta(t, 101, s.State().TakeProfitPrice, 1e-9)
+
+	// 未触及第一档
+	action, err := s.OnPrice(99)
+	require.NoError(t, err)
+	assert.Equal(t, DCAActionNone, action)
+
+	// 触及第一档：加仓 100 USDT，均价下移，止盈重算
+	action, err = s.OnPrice(98)
+	require.NoError(t, err)
+	assert.Equal(t, DCAActionSafety, action)
+	state := s.State()
+	assert.Equal(t, 1, state.SafetyOrdersUsed)
+	assert.InDelta(t, 200/(1+100.0/98), state.AveragePrice, 1e-6)
+	tps := mt.ordersOf("take_profit")
+	require.Len(t, tps, 2)
+	assert.InDelta(t, state.TakeProfitPrice, tps[1].Price, 1e-9)
+	assert.InDelta(t, state.Quantity, tps[1].Quantity, 1e-9)
+
+	// 价格回到整体止盈价以上：全部平仓
+	action, err = s.OnPrice(state.TakeProfitPrice + 0.01)
+	require.NoError(t, err)
+	assert.Equal(t, DCAActionTakeProfit, action)
+	assert.Zero(t, mt.position("SOLUSDT", SideLong))
+	assert.False(t, s.State().Active)
+}
+
+func TestDCAStrategy_HardCapStopsAveraging(t *testing.T) {
+	mt := newMockTrader()
+	mt.setPrice("SOLUSDT", 100)
+
+	s, err := NewDCAStrategy(testDCAConfig(), mt)
+	require.NoError(t, err)
+	require.NoError(t, s.Start())
+
+	for _, price := range []float64{98, 95, 90.5, 80, 60} {
+		_, err := s.OnPrice(price)
+		require.NoError(t, err)
+	}
+	state := s.State()
+	assert.Equal(t, 3, state.SafetyOrdersUsed)
+	assert.Zero(t, state.SafetyOrdersLeft)
+	assert.LessOrEqual(t, state.InvestedQuote, 1000.0)
+	assert.Len(t, mt.ordersOf("open_long"), 4)
+}
@@ -0,0 +1,256 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"nofx/trader"
+)
+
+// FundingRateSource 资金费率来源（如 BackpackTrader、market.GetFundingRate）
+type FundingRateSource interface {
+	GetFundingRate(symbol string) (float64, error)
+}
+
+// FundingRateFunc 将普通函数适配为 FundingRateSource
+type FundingRateFunc func(symbol string) (float64, error)
+
+// GetFundingRate 实现 FundingRateSource
+func (f FundingRateFunc) GetFundingRate(symbol string) (float64, error) {
+	return f(symbol)
+}
+
+// Venue 交易场所：下单用的交易器 + 资金费率来源
+type Venue struct {
+	Name                 string
+	Trader               trader.Trader
+	Funding              FundingRateSource
+	FundingIntervalHours float64 // 资金费结算周期（小时），用于换算为每小时费率；0 表示默认8小时
+}
+
+// hourlyFundingRate 获取换算为每小时的资金费率
+func (v *Venue) hourlyFundingRate(symbol string) (float64, error) {
+	if v.Funding == nil {
+		return 0, fmt.Errorf("%s 未配置资金费率来源", v.Name)
+	}
+	rate, err := v.Funding.GetFundingRate(symbol)
+	if err != nil {
+		return 0, fmt.Errorf("%s 获取资金费率失败: %w", v.Name, err)
+	}
+	interval := v.FundingIntervalHours
+	if interval <= 0 {
+		interval = 8
+	}
+	return rate / interval, nil
+}
+
+// FundingArbConfig 跨交易所资金费率套利配置
+type FundingArbConfig struct {
+	Symbol             string
+	NotionalQuote      float64 // 每条腿的名义价值（USDT）
+	Leverage           int
+	EntrySpread        float64 // 开仓所需的每小时费率差（如 0.0001 表示每小时 0.01%）
+	ExitSpread         float64 // 持仓方向的费率差回落到该值以下时平仓
+	RebalanceTolerance float64 // 两腿数量偏差超过该比例时补齐（如 0.05 表示5%）
+}
+
+// Validate 校验配置
+func (c FundingArbConfig) Validate() error {
+	if c.Symbol == "" {
+		return fmt.Errorf("symbol 不能为空")
+	}
+	if c.NotionalQuote <= 0 {
+		return fmt.Errorf("名义价值必须大于0")
+	}
+	if c.EntrySpread <= 0 {
+		return fmt.Errorf("开仓费率差必须大于0")
+	}
+	if c.ExitSpread >= c.EntrySpread {
+		return fmt.Errorf("平仓费率差 %.6f 必须小于开仓费率差 %.6f", c.ExitSpread, c.EntrySpread)
+	}
+	if c.RebalanceTolerance < 0 {
+		return fmt.Errorf("再平衡容差不能为负数")
+	}
+	return nil
+}
+
+// FundingArbAction Evaluate 执行的动作
+type FundingArbAction string
+
+const (
+	FundingArbNone      FundingArbAction = "none"
+	FundingArbOpen      FundingArbAction = "open"
+	FundingArbRebalance FundingArbAction = "rebalance"
+	FundingArbUnwind    FundingArbAction = "unwind"
+)
+
+// FundingArbStrategy 跨交易所资金费率套利
+// 在资金费率高的交易所做空、费率低的交易所做多，保持整体 delta 中性收取费率差；
+// 费率差回落后同时平掉两条腿
+type FundingArbStrategy struct {
+	cfg   FundingArbConfig
+	a, b  *Venue
+	long  *Venue // 当前做多的交易所（nil 表示无持仓）
+	short *Venue // 当前做空的交易所
+	mu    sync.Mutex
+}
+
+// NewFundingArbStrategy 创建资金费率套利策略
+func NewFundingArbStrategy(cfg FundingArbConfig, a, b Venue) (*FundingArbStrategy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("资金费率套利配置无效: %w", err)
+	}
+	if a.Trader == nil || b.Trader == nil {
+		return nil, fmt.Errorf("两个交易所都必须配置交易器")
+	}
+	return &FundingArbStrategy{cfg: cfg, a: &a, b: &b}, nil
+}
+
+// Evaluate 根据最新资金费率开仓、再平衡或平仓
+func (s *FundingArbStrategy) Evaluate() (FundingArbAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rateA, err := s.a.hourlyFundingRate(s.cfg.Symbol)
+	if err != nil {
+		return FundingArbNone, err
+	}
+	rateB, err := s.b.hourlyFundingRate(s.cfg.Symbol)
+	if err != nil {
+		return FundingArbNone, err
+	}
+
+	if s.long == nil {
+		spread := rateA - rateB
+		if math.Abs(spread) < s.cfg.EntrySpread {
+			return FundingArbNone, nil
+		}
+		// 在费率高的一边做空（收取资金费），费率低的一边做多
+		long, short := s.b, s.a
+		if spread < 0 {
+			long, short = s.a, s.b
+		}
+		if err := s.openLocked(long, short); err != nil {
+			return FundingArbNone, err
+		}
+		log.Printf("⚖️ [FundingArb] %s 开仓: 多 %s / 空 %s，每小时费率差 %.6f",
+			s.cfg.Symbol, long.Name, short.Name, math.Abs(spread))
+		return FundingArbOpen, nil
+	}
+
+	// 持仓方向上的费率差 = 空头腿费率 - 多头腿费率
+	held := rateA - rateB
+	if s.short == s.b {
+		held = -held
+	}
+	if held < s.cfg.ExitSpread {
+		log.Printf("⚖️ [FundingArb] %s 费率差回落至 %.6f，平仓", s.cfg.Symbol, held)
+		if err := s.unwindLocked(); err != nil {
+			return FundingArbNone, err
+		}
+		return FundingArbUnwind, nil
+	}
+
+	return s.rebalanceLocked()
+}
+
+// openLocked 按相同数量开出两条腿；第二条腿失败时回滚第一条腿
+func (s *FundingArbStrategy) openLocked(long, short *Venue) error {
+	priceLong, err := long.Trader.GetMarketPrice(s.cfg.Symbol)
+	if err != nil {
+		return fmt.Errorf("%s 获取价格失败: %w", long.Name, err)
+	}
+	priceShort, err := short.Trader.GetMarketPrice(s.cfg.Symbol)
+	if err != nil {
+		return fmt.Errorf("%s 获取价格失败: %w", short.Name, err)
+	}
+	price := (priceLong + priceShort) / 2
+
+	// 两边精度可能不同，取较小的数量保证两腿一致
+	qtyLong, err := quoteToQuantity(long.Trader, s.cfg.Symbol, s.cfg.NotionalQuote, price)
+	if err != nil {
+		return fmt.Errorf("%s 计算数量失败: %w", long.Name, err)
+	}
+	qtyShort, err := quoteToQuantity(short.Trader, s.cfg.Symbol, s.cfg.NotionalQuote, price)
+	if err != nil {
+		return fmt.Errorf("%s 计算数量失败: %w", short.Name, err)
+	}
+	qty := math.Min(qtyLong, qtyShort)
+
+	if _, err := long.Trader.OpenLong(s.cfg.Symbol, qty, s.cfg.Leverage); err != nil {
+		return fmt.Errorf("%s 开多失败: %w", long.Name, err)
+	}
+	if _, err := short.Trader.OpenShort(s.cfg.Symbol, qty, s.cfg.Leverage); err != nil {
+		log.Printf("❌ [FundingArb] %s 开空失败，回滚 %s 多仓: %v", short.Name, long.Name, err)
+		if _, rbErr := long.Trader.CloseLong(s.cfg.Symbol, qty); rbErr != nil {
+			return fmt.Errorf("%s 开空失败: %v；回滚 %s 多仓也失败: %w", short.Name, err, long.Name, rbErr)
+		}
+		return fmt.Errorf("%s 开空失败（已回滚）: %w", short.Name, err)
+	}
+
+	s.long, s.short = long, short
+	return nil
+}
+
+// rebalanceLocked 两腿数量偏差超过容差时补齐较小的一腿；任一腿消失（如被强平）时整体平仓
+func (s *FundingArbStrategy) rebalanceLocked() (FundingArbAction, error) {
+	qtyLong, err := positionQuantity(s.long.Trader, s.cfg.Symbol, SideLong)
+	if err != nil {
+		return FundingArbNone, fmt.Errorf("%s: %w", s.long.Name, err)
+	}
+	qtyShort, err := positionQuantity(s.short.Trader, s.cfg.Symbol, SideShort)
+	if err != nil {
+		return FundingArbNone, fmt.Errorf("%s: %w", s.short.Name, err)
+	}
+
+	if qtyLong == 0 || qtyShort == 0 {
+		log.Printf("⚠️ [FundingArb] %s 单腿持仓消失（多 %.6f / 空 %.6f），整体平仓", s.cfg.Symbol, qtyLong, qtyShort)
+		if err := s.unwindLocked(); err != nil {
+			return FundingArbNone, err
+		}
+		return FundingArbUnwind, nil
+	}
+
+	diff := math.Abs(qtyLong - qtyShort)
+	if diff/math.Max(qtyLong, qtyShort) <= s.cfg.RebalanceTolerance {
+		return FundingArbNone, nil
+	}
+
+	if qtyLong < qtyShort {
+		_, err = s.long.Trader.OpenLong(s.cfg.Symbol, diff, s.cfg.Leverage)
+	} else {
+		_, err = s.short.Trader.OpenShort(s.cfg.Symbol, diff, s.cfg.Leverage)
+	}
+	if err != nil {
+		return FundingArbNone, fmt.Errorf("再平衡失败: %w", err)
+	}
+	log.Printf("⚖️ [FundingArb] %s 再平衡: 多 %.6f / 空 %.6f，补齐 %.6f", s.cfg.Symbol, qtyLong, qtyShort, diff)
+	return FundingArbRebalance, nil
+}
+
+// unwindLocked 平掉两条腿（两边都尝试，汇总错误）
+func (s *FundingArbStrategy) unwindLocked() error {
+	var errs []error
+	if qty, err := positionQuantity(s.long.Trader, s.cfg.Symbol, SideLong); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", s.long.Name, err))
+	} else if qty > 0 {
+		if _, err := s.long.Trader.CloseLong(s.cfg.Symbol, 0); err != nil {
+			errs = append(errs, fmt.Errorf("%s 平多失败: %w", s.long.Name, err))
+		}
+	}
+	if qty, err := positionQuantity(s.short.Trader, s.cfg.Symbol, SideShort); err != nil {
+		errs = append(errs, fmt.Errorf("%s: %w", s.short.Name, err))
+	} else if qty > 0 {
+		if _, err := s.short.Trader.CloseShort(s.cfg.Symbol, 0); err != nil {
+			errs = append(errs, fmt.Errorf("%s 平空失败: %w", s.short.Name, err))
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("平仓未完成: %v", errs)
+	}
+
+	s.long, s.short = nil, nil
+	return nil
+}
@@ -0,0 +1,95 @@
+package strategy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVenues(rateA, rateB *float64) (Venue, Venue, *mockTrader, *mockTrader) {
+	ta, tb := newMockTrader(), newMockTrader()
+	ta.setPrice("ETHUSDT", 3000)
+	tb.setPrice("ETHUSDT", 3002)
+	a := Venue{Name: "backpack", Trader: ta, FundingIntervalHours: 1,
+		Funding: FundingRateFunc(func(string) (float64, error) { return *rateA, nil })}
+	b := Venue{Name: "binance", Trader: tb, FundingIntervalHours: 8,
+		Funding: FundingRateFunc(func(string) (float64, error) { return *rateB, nil })}
+	return a, b, ta, tb
+}
+
+func testFundingArbConfig() FundingArbConfig {
+	return FundingArbConfig{
+		Symbol:             "ETHUSDT",
+		NotionalQuote:      3001,
+		Leverage:           2,
+		EntrySpread:        0.0001,
+		ExitSpread:         0.00002,
+		RebalanceTolerance: 0.05,
+	}
+}
+
+func TestFundingArb_OpensAndUnwinds(t *testing.T) {
+	rateA, rateB := 0.0002, 0.0001 // 每小时: A=0.0002, B=0.0000125
+	a, b, ta, tb := newTestVenues(&rateA, &rateB)
+	s, err := NewFundingArbStrategy(testFundingArbConfig(), a, b)
+	require.NoError(t, err)
+
+	action, err := s.Evaluate()
+	require.NoError(t, err)
+	assert.Equal(t, FundingArbOpen, action)
+	// A 费率高：A 做空，B 做多，两腿数量相同
+	assert.InDelta(t, 1.0, ta.position("ETHUSDT", SideShort), 1e-9)
+	assert.InDelta(t, 1.0, tb.position("ETHUSDT", SideLong), 1e-9)
+
+	action, err = s.Evaluate()
+	require.NoError(t, err)
+	assert.Equal(t, FundingArbNone, action)
+
+	// 费率差回落：两腿同时平仓
+	rateA = 0.00002
+	action, err = s.Evaluate()
+	require.NoError(t, err)
+	assert.Equal(t, FundingArbUnwind, action)
+	assert.Zero(t, ta.position("ETHUSDT", SideShort))
+	assert.Zero(t, tb.position("ETHUSDT", SideLong))
+}
+
+func TestFundingArb_RollbackWhenSecondLegFails(t *testing.T) {
+	rateA, rateB := 0.0002, 0.0
+	a, b, ta, tb := newTestVenues(&rateA, &rateB)
+	ta.errOn["open_short"] = fmt.Errorf("insufficient margin")
+	s, err := NewFundingArbStrategy(testFundingArbConfig(), a, b)
+	require.NoError(t, err)
+
+	_, err = s.Evaluate()
+	assert.ErrorContains(t, err, "已回滚")
+	assert.Zero(t, tb.position("ETHUSDT", SideLong))
+	assert.Len(t, tb.ordersOf("close_long"), 1)
+}
+
+func TestFundingArb_RebalanceAndLostLeg(t *testing.T) {
+	rateA, rateB := 0.0002, 0.0
+	a, b, ta, tb := newTestVenues(&rateA, &rateB)
+	s, err := NewFundingArbStrategy(testFundingArbConfig(), a, b)
+	require.NoError(t, err)
+	_, err = s.Evaluate()
+	require.NoError(t, err)
+
+	// 多头腿被部分平掉：补齐
+	_, err = tb.CloseLong("ETHUSDT", 0.2)
+	require.NoError(t, err)
+	action, err := s.Evaluate()
+	require.NoError(t, err)
+	assert.Equal(t, FundingArbRebalance, action)
+	assert.InDelta(t, 1.0, tb.position("ETHUSDT", SideLong), 1e-9)
+
+	// 空头腿消失（如被强平）：整体平仓
+	_, err = ta.CloseShort("ETHUSDT", 0)
+	require.NoError(t, err)
+	action, err = s.Evaluate()
+	require.NoError(t, err)
+	assert.Equal(t, FundingArbUnwind, action)
+	assert.Zero(t, tb.position("ETHUSDT", SideLong))
+}
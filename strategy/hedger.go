@@ -0,0 +1,222 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// HedgeConfig 跨账户/交易所对冲配置
+type HedgeConfig struct {
+	Symbols     []string // 需要对冲的交易对（为空表示对冲主账户的全部持仓）
+	HedgeRatio  float64  // 对冲比例（默认1.0，即完全对冲）
+	Band        float64  // 允许的对冲偏差（相对目标对冲量的比例，如 0.1 表示偏差10%以内不调整）
+	MinNotional float64  // 调整量的名义价值低于该值时不调整（USDT）
+	Leverage    int
+}
+
+// netPosition 单个交易对的双向持仓
+type netPosition struct {
+	long, short float64
+	markPrice   float64
+}
+
+func (p netPosition) net() float64 {
+	return p.long - p.short
+}
+
+// netPositions 按标准化symbol汇总账户持仓
+func netPositions(t trader.Trader) (map[string]*netPosition, error) {
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	result := make(map[string]*netPosition)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		amt, _ := pos["positionAmt"].(float64)
+		mark, _ := pos["markPrice"].(float64)
+		symbol = market.Normalize(symbol)
+
+		np := result[symbol]
+		if np == nil {
+			np = &netPosition{}
+			result[symbol] = np
+		}
+		switch side {
+		case SideLong:
+			np.long += math.Abs(amt)
+		case SideShort:
+			np.short += math.Abs(amt)
+		}
+		if mark > 0 {
+			np.markPrice = mark
+		}
+	}
+	return result, nil
+}
+
+// HedgeAdjustment 一次对冲调整
+type HedgeAdjustment struct {
+	Symbol     string
+	PrimaryNet float64 // 主账户净持仓（多为正，空为负）
+	HedgeNet   float64 // 调整前对冲账户净持仓
+	TargetNet  float64 // 对冲账户目标净持仓
+	Err        error
+}
+
+// Hedger 对冲服务：在对冲账户上以相反方向复制主账户的净敞口
+// 适用于在 Backpack 刷交易量、同时在另一账户保持整体市场中性的场景
+type Hedger struct {
+	cfg     HedgeConfig
+	primary trader.Trader
+	hedge   trader.Trader
+}
+
+// NewHedger 创建对冲服务
+func NewHedger(cfg HedgeConfig, primary, hedge trader.Trader) (*Hedger, error) {
+	if primary == nil || hedge == nil {
+		return nil, fmt.Errorf("主账户和对冲账户都必须配置交易器")
+	}
+	if cfg.HedgeRatio == 0 {
+		cfg.HedgeRatio = 1
+	}
+	if cfg.HedgeRatio < 0 || cfg.Band < 0 || cfg.MinNotional < 0 {
+		return nil, fmt.Errorf("对冲比例、偏差和最小名义价值不能为负数")
+	}
+	return &Hedger{cfg: cfg, primary: primary, hedge: hedge}, nil
+}
+
+// Sync 比较两个账户的净持仓，将超出偏差范围的交易对调整到目标对冲量
+// 单个交易对调整失败不影响其他交易对，错误记录在返回结果中
+func (h *Hedger) Sync() ([]HedgeAdjustment, error) {
+	primary, err := netPositions(h.primary)
+	if err != nil {
+		return nil, fmt.Errorf("主账户: %w", err)
+	}
+	hedged, err := netPositions(h.hedge)
+	if err != nil {
+		return nil, fmt.Errorf("对冲账户: %w", err)
+	}
+
+	var adjustments []HedgeAdjustment
+	for _, symbol := range h.symbols(primary, hedged) {
+		p := primary[symbol]
+		if p == nil {
+			p = &netPosition{}
+		}
+		hp := hedged[symbol]
+		if hp == nil {
+			hp = &netPosition{}
+		}
+
+		target := -p.net() * h.cfg.HedgeRatio
+		diff := target - hp.net()
+		if !h.needsAdjust(symbol, diff, target, p, hp) {
+			continue
+		}
+
+		adj := HedgeAdjustment{Symbol: symbol, PrimaryNet: p.net(), HedgeNet: hp.net(), TargetNet: target}
+		adj.Err = h.adjust(symbol, hp, diff)
+		if adj.Err != nil {
+			log.Printf("❌ [Hedger] %s 调整失败: %v", symbol, adj.Err)
+		} else {
+			log.Printf("🛡️ [Hedger] %s 主账户净持仓 %.6f，对冲 %.6f → %.6f", symbol, p.net(), hp.net(), target)
+		}
+		adjustments = append(adjustments, adj)
+	}
+	return adjustments, nil
+}
+
+// symbols 需要检查的交易对（包含对冲账户上的残留持仓，以便主账户平仓后撤掉对冲）
+func (h *Hedger) symbols(primary, hedged map[string]*netPosition) []string {
+	set := make(map[string]bool)
+	if len(h.cfg.Symbols) > 0 {
+		for _, s := range h.cfg.Symbols {
+			set[market.Normalize(s)] = true
+		}
+	} else {
+		for s := range primary {
+			set[s] = true
+		}
+		for s := range hedged {
+			set[s] = true
+		}
+	}
+	symbols := make([]string, 0, len(set))
+	for s := range set {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// needsAdjust 判断偏差是否超出允许范围
+func (h *Hedger) needsAdjust(symbol string, diff, target float64, p, hp *netPosition) bool {
+	if diff == 0 {
+		return false
+	}
+	if target != 0 && math.Abs(diff) <= h.cfg.Band*math.Abs(target) {
+		return false
+	}
+	if h.cfg.MinNotional > 0 {
+		price := hp.markPrice
+		if price == 0 {
+			price = p.markPrice
+		}
+		if price == 0 {
+			var err error
+			if price, err = h.hedge.GetMarketPrice(symbol); err != nil {
+				log.Printf("⚠️ [Hedger] %s 获取价格失败: %v", symbol, err)
+				return true
+			}
+		}
+		if math.Abs(diff)*price < h.cfg.MinNotional {
+			return false
+		}
+	}
+	return true
+}
+
+// adjust 将对冲账户净持仓调整 diff
+func (h *Hedger) adjust(symbol string, hp *netPosition, diff float64) error {
+	return adjustNetPosition(h.hedge, symbol, hp, diff, h.cfg.Leverage)
+}
+
+// adjustNetPosition 将账户净持仓调整 diff：先平掉反方向持仓，剩余部分再开仓
+func adjustNetPosition(t trader.Trader, symbol string, pos *netPosition, diff float64, leverage int) error {
+	if diff > 0 {
+		// 需要更多多头：先平空，再开多
+		if closeQty := math.Min(pos.short, diff); closeQty > 0 {
+			if _, err := t.CloseShort(symbol, closeQty); err != nil {
+				return fmt.Errorf("平空失败: %w", err)
+			}
+			diff -= closeQty
+		}
+		if diff > 0 {
+			if _, err := t.OpenLong(symbol, diff, leverage); err != nil {
+				return fmt.Errorf("开多失败: %w", err)
+			}
+		}
+		return nil
+	}
+
+	diff = -diff
+	if closeQty := math.Min(pos.long, diff); closeQty > 0 {
+		if _, err := t.CloseLong(symbol, closeQty); err != nil {
+			return fmt.Errorf("平多失败: %w", err)
+		}
+		diff -= closeQty
+	}
+	if diff > 0 {
+		if _, err := t.OpenShort(symbol, diff, leverage); err != nil {
+			return fmt.Errorf("开空失败: %w", err)
+		}
+	}
+	return nil
+}
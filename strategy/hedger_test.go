@@ -0,0 +1,45 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHedger_MirrorsNetExposureWithinBand(t *testing.T) {
+	primary, hedge := newMockTrader(), newMockTrader()
+	h, err := NewHedger(HedgeConfig{Band: 0.1}, primary, hedge)
+	require.NoError(t, err)
+
+	_, err = primary.OpenLong("SOLUSDT", 10, 1)
+	require.NoError(t, err)
+	adjustments, err := h.Sync()
+	require.NoError(t, err)
+	require.Len(t, adjustments, 1)
+	assert.Equal(t, 10.0, hedge.position("SOLUSDT", SideShort))
+
+	// 偏差在 10% 以内不调整
+	_, err = primary.OpenLong("SOLUSDT", 0.5, 1)
+	require.NoError(t, err)
+	adjustments, err = h.Sync()
+	require.NoError(t, err)
+	assert.Empty(t, adjustments)
+
+	// 主账户翻空：先平掉对冲空单，再开多
+	_, err = primary.CloseLong("SOLUSDT", 0)
+	require.NoError(t, err)
+	_, err = primary.OpenShort("SOLUSDT", 4, 1)
+	require.NoError(t, err)
+	_, err = h.Sync()
+	require.NoError(t, err)
+	assert.Zero(t, hedge.position("SOLUSDT", SideShort))
+	assert.Equal(t, 4.0, hedge.position("SOLUSDT", SideLong))
+
+	// 主账户平仓：撤掉残留对冲
+	_, err = primary.CloseShort("SOLUSDT", 0)
+	require.NoError(t, err)
+	_, err = h.Sync()
+	require.NoError(t, err)
+	assert.Zero(t, hedge.position("SOLUSDT", SideLong))
+}
@@ -0,0 +1,251 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sync"
+	"time"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// QuoteVenue 做市需要的挂单/撤单能力（BackpackTrader 已实现）
+type QuoteVenue interface {
+	PlaceQuote(symbol string, isBid bool, quantity, price float64) (string, error)
+	CancelQuote(symbol, orderID string) error
+}
+
+// MarketMakerConfig 做市配置
+type MarketMakerConfig struct {
+	Symbol              string
+	QuoteSize           float64       // 每侧报价数量（基础币种）
+	SpreadBps           float64       // 买卖价差（基点，双边合计）
+	MaxInventory        float64       // 最大库存（基础币种，达到后停止同方向报价）
+	SkewBps             float64       // 满库存时报价整体偏移的基点数
+	MinRequoteInterval  time.Duration // 两次撤单重挂的最小间隔
+	RequoteThresholdBps float64       // 公允价变化超过该基点数才重挂
+	VolatilityWindow    int           // 计算波动率的价格样本数
+	MaxVolatilityBps    float64       // 波动率（对数收益标准差，基点）超过该值时撤出报价
+}
+
+// Validate 校验配置
+func (c MarketMakerConfig) Validate() error {
+	if c.Symbol == "" {
+		return fmt.Errorf("symbol 不能为空")
+	}
+	if c.QuoteSize <= 0 || c.SpreadBps <= 0 || c.MaxInventory <= 0 {
+		return fmt.Errorf("报价数量、价差和最大库存必须大于0")
+	}
+	if c.SkewBps < 0 || c.RequoteThresholdBps < 0 || c.MaxVolatilityBps < 0 {
+		return fmt.Errorf("偏移、重挂阈值和波动率上限不能为负数")
+	}
+	return nil
+}
+
+// MarketMakerAction OnFairValue 执行的动作
+type MarketMakerAction string
+
+const (
+	MarketMakerNone     MarketMakerAction = "none"
+	MarketMakerRequote  MarketMakerAction = "requote"
+	MarketMakerWithdraw MarketMakerAction = "withdraw"
+)
+
+// Quotes 双边报价（价格为0表示该侧不报价）
+type Quotes struct {
+	Bid, Ask float64
+}
+
+// VWAP 成交量加权均价（以典型价 (H+L+C)/3 计算）
+func VWAP(klines []market.Kline) float64 {
+	var pv, vol float64
+	for _, k := range klines {
+		typical := (k.High + k.Low + k.Close) / 3
+		pv += typical * k.Volume
+		vol += k.Volume
+	}
+	if vol == 0 {
+		return 0
+	}
+	return pv / vol
+}
+
+// MarketMaker 基础做市模块
+// 围绕公允价（标记价或VWAP）双边报价，按库存偏移报价，限制撤单重挂频率，波动过大时撤出
+type MarketMaker struct {
+	cfg    MarketMakerConfig
+	trader trader.Trader
+	venue  QuoteVenue
+
+	bidID, askID string
+	lastFair     float64
+	lastQuoteAt  time.Time
+	prices       []float64 // 最近的公允价样本（用于波动率）
+	withdrawn    bool
+	now          func() time.Time
+	mu           sync.Mutex
+}
+
+// NewMarketMaker 创建做市模块；trader 用于查询库存，venue 用于挂撤单
+func NewMarketMaker(cfg MarketMakerConfig, t trader.Trader, venue QuoteVenue) (*MarketMaker, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("做市配置无效: %w", err)
+	}
+	return &MarketMaker{cfg: cfg, trader: t, venue: venue, now: time.Now}, nil
+}
+
+// ComputeQuotes 根据公允价和库存计算报价
+// 库存为多时整体下移报价（更积极地卖出），为空时整体上移；库存达到上限时停止同方向报价
+func (c MarketMakerConfig) ComputeQuotes(fair, inventory float64) Quotes {
+	ratio := math.Max(-1, math.Min(1, inventory/c.MaxInventory))
+	shift := -ratio * c.SkewBps
+	half := c.SpreadBps / 2
+
+	q := Quotes{
+		Bid: fair * (1 + (shift-half)/10000),
+		Ask: fair * (1 + (shift+half)/10000),
+	}
+	if inventory >= c.MaxInventory {
+		q.Bid = 0
+	}
+	if inventory <= -c.MaxInventory {
+		q.Ask = 0
+	}
+	return q
+}
+
+// Tick 以标记价为公允价执行一次报价管理
+func (m *MarketMaker) Tick() (MarketMakerAction, error) {
+	fair, err := m.trader.GetMarketPrice(m.cfg.Symbol)
+	if err != nil {
+		return MarketMakerNone, fmt.Errorf("获取价格失败: %w", err)
+	}
+	return m.OnFairValue(fair)
+}
+
+// OnFairValue 根据最新公允价管理报价
+func (m *MarketMaker) OnFairValue(fair float64) (MarketMakerAction, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if fair <= 0 {
+		return MarketMakerNone, fmt.Errorf("无效的公允价: %.8f", fair)
+	}
+	m.recordPriceLocked(fair)
+
+	// 波动过大：撤出全部报价，直到波动回落
+	if m.cfg.MaxVolatilityBps > 0 {
+		if vol := m.volatilityBpsLocked(); vol > m.cfg.MaxVolatilityBps {
+			if m.withdrawn {
+				return MarketMakerNone, nil
+			}
+			log.Printf("🌪️ [MarketMaker] %s 波动率 %.1fbps 超过上限 %.1fbps，撤出报价", m.cfg.Symbol, vol, m.cfg.MaxVolatilityBps)
+			m.withdrawn = true
+			m.cancelQuotesLocked()
+			return MarketMakerWithdraw, nil
+		}
+	}
+
+	now := m.now()
+	hasQuotes := m.bidID != "" || m.askID != ""
+	if hasQuotes && !m.withdrawn {
+		if now.Sub(m.lastQuoteAt) < m.cfg.MinRequoteInterval {
+			return MarketMakerNone, nil
+		}
+		if moved := math.Abs(fair-m.lastFair) / m.lastFair * 10000; moved < m.cfg.RequoteThresholdBps {
+			return MarketMakerNone, nil
+		}
+	}
+
+	inventory, err := m.inventoryLocked()
+	if err != nil {
+		return MarketMakerNone, err
+	}
+	m.cancelQuotesLocked()
+
+	quotes := m.cfg.ComputeQuotes(fair, inventory)
+	if quotes.Bid > 0 {
+		if m.bidID, err = m.venue.PlaceQuote(m.cfg.Symbol, true, m.cfg.QuoteSize, quotes.Bid); err != nil {
+			return MarketMakerNone, fmt.Errorf("挂买单失败: %w", err)
+		}
+	}
+	if quotes.Ask > 0 {
+		if m.askID, err = m.venue.PlaceQuote(m.cfg.Symbol, false, m.cfg.QuoteSize, quotes.Ask); err != nil {
+			return MarketMakerNone, fmt.Errorf("挂卖单失败: %w", err)
+		}
+	}
+
+	m.lastFair = fair
+	m.lastQuoteAt = now
+	m.withdrawn = false
+	log.Printf("📊 [MarketMaker] %s 公允价 %.4f 库存 %.4f → 买 %.4f / 卖 %.4f", m.cfg.Symbol, fair, inventory, quotes.Bid, quotes.Ask)
+	return MarketMakerRequote, nil
+}
+
+// Stop 撤销全部报价
+func (m *MarketMaker) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.cancelQuotesLocked()
+}
+
+// inventoryLocked 当前库存（多为正，空为负）
+func (m *MarketMaker) inventoryLocked() (float64, error) {
+	long, err := positionQuantity(m.trader, m.cfg.Symbol, SideLong)
+	if err != nil {
+		return 0, err
+	}
+	short, err := positionQuantity(m.trader, m.cfg.Symbol, SideShort)
+	if err != nil {
+		return 0, err
+	}
+	return long - short, nil
+}
+
+// cancelQuotesLocked 撤销现有报价（订单可能已成交，撤单失败只记录日志）
+func (m *MarketMaker) cancelQuotesLocked() {
+	for _, id := range []*string{&m.bidID, &m.askID} {
+		if *id == "" {
+			continue
+		}
+		if err := m.venue.CancelQuote(m.cfg.Symbol, *id); err != nil {
+			log.Printf("⚠️ [MarketMaker] %s 撤单 %s 失败（可能已成交）: %v", m.cfg.Symbol, *id, err)
+		}
+		*id = ""
+	}
+}
+
+// recordPriceLocked 记录公允价样本
+func (m *MarketMaker) recordPriceLocked(price float64) {
+	window := m.cfg.VolatilityWindow
+	if window < 2 {
+		window = 20
+	}
+	m.prices = append(m.prices, price)
+	if len(m.prices) > window {
+		m.prices = m.prices[len(m.prices)-window:]
+	}
+}
+
+// volatilityBpsLocked 最近样本的对数收益标准差（基点）
+func (m *MarketMaker) volatilityBpsLocked() float64 {
+	if len(m.prices) < 3 {
+		return 0
+	}
+	returns := make([]float64, 0, len(m.prices)-1)
+	var mean float64
+	for i := 1; i < len(m.prices); i++ {
+		r := math.Log(m.prices[i] / m.prices[i-1])
+		returns = append(returns, r)
+		mean += r
+	}
+	mean /= float64(len(returns))
+	var variance float64
+	for _, r := range returns {
+		variance += (r - mean) * (r - mean)
+	}
+	variance /= float64(len(returns) - 1)
+	return math.Sqrt(variance) * 10000
+}
@@ -0,0 +1,99 @@
+package strategy
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mockQuoteVenue 记录挂撤单
+type mockQuoteVenue struct {
+	placed    []Quotes
+	cancelled []string
+	next      int
+}
+
+func (v *mockQuoteVenue) PlaceQuote(symbol string, isBid bool, quantity, price float64) (string, error) {
+	v.next++
+	if isBid {
+		v.placed = append(v.placed, Quotes{Bid: price})
+	} else {
+		v.placed = append(v.placed, Quotes{Ask: price})
+	}
+	return fmt.Sprintf("q%d", v.next), nil
+}
+
+func (v *mockQuoteVenue) CancelQuote(symbol, orderID string) error {
+	v.cancelled = append(v.cancelled, orderID)
+	return nil
+}
+
+func testMarketMakerConfig() MarketMakerConfig {
+	return MarketMakerConfig{
+		Symbol:              "SOLUSDT",
+		QuoteSize:           1,
+		SpreadBps:           20,
+		MaxInventory:        10,
+		SkewBps:             10,
+		MinRequoteInterval:  time.Second,
+		RequoteThresholdBps: 5,
+		VolatilityWindow:    5,
+		MaxVolatilityBps:    200,
+	}
+}
+
+func TestMarketMakerConfig_ComputeQuotes(t *testing.T) {
+	cfg := testMarketMakerConfig()
+
+	q := cfg.ComputeQuotes(100, 0)
+	assert.InDelta(t, 99.9, q.Bid, 1e-9)
+	assert.InDelta(t, 100.1, q.Ask, 1e-9)
+
+	// 半仓多头库存：整体下移 5bps
+	q = cfg.ComputeQuotes(100, 5)
+	assert.InDelta(t, 99.85, q.Bid, 1e-9)
+	assert.InDelta(t, 100.05, q.Ask, 1e-9)
+
+	// 满仓多头：停止挂买单
+	q = cfg.ComputeQuotes(100, 10)
+	assert.Zero(t, q.Bid)
+	assert.Greater(t, q.Ask, 0.0)
+}
+
+func TestMarketMaker_ThrottleAndWithdraw(t *testing.T) {
+	mt := newMockTrader()
+	venue := &mockQuoteVenue{}
+	mm, err := NewMarketMaker(testMarketMakerConfig(), mt, venue)
+	require.NoError(t, err)
+	now := time.Unix(0, 0)
+	mm.now = func() time.Time { return now }
+
+	action, err := mm.OnFairValue(100)
+	require.NoError(t, err)
+	assert.Equal(t, MarketMakerRequote, action)
+	assert.Len(t, venue.placed, 2)
+
+	// 间隔不足：不重挂
+	now = now.Add(500 * time.Millisecond)
+	action, _ = mm.OnFairValue(101)
+	assert.Equal(t, MarketMakerNone, action)
+
+	// 间隔足够但价格变化不足阈值：不重挂
+	now = now.Add(time.Second)
+	action, _ = mm.OnFairValue(100.02)
+	assert.Equal(t, MarketMakerNone, action)
+
+	// 价格变化超过阈值：撤单重挂
+	action, _ = mm.OnFairValue(100.2)
+	assert.Equal(t, MarketMakerRequote, action)
+	assert.Equal(t, []string{"q1", "q2"}, venue.cancelled)
+
+	// 剧烈波动：撤出报价
+	now = now.Add(time.Second)
+	action, _ = mm.OnFairValue(110)
+	assert.Equal(t, MarketMakerWithdraw, action)
+	assert.Len(t, venue.cancelled, 4)
+}
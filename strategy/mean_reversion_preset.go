@@ -0,0 +1,235 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// MeanReversionPresetConfig 均值回归预设配置（突破预设的对应策略，可直接从JSON加载）
+type MeanReversionPresetConfig struct {
+	Symbols         []string         `json:"symbols"`           // 启用该预设的交易对
+	TimeFrame       market.TimeFrame `json:"timeframe"`         // K线周期
+	BBPeriod        int              `json:"bb_period"`         // 布林带周期
+	BBStdDev        float64          `json:"bb_std_dev"`        // 布林带标准差倍数
+	RSIPeriod       int              `json:"rsi_period"`        // RSI周期
+	RSIOversold     float64          `json:"rsi_oversold"`      // 超卖阈值（做多）
+	RSIOverbought   float64          `json:"rsi_overbought"`    // 超买阈值（做空）
+	ADXPeriod       int              `json:"adx_period"`        // ADX周期
+	MaxADX          float64          `json:"max_adx"`           // 震荡行情判定：ADX 不高于该值
+	ATRPeriod       int              `json:"atr_period"`        // ATR周期
+	RiskPerTrade    float64          `json:"risk_per_trade"`    // 全部批次合计的风险金额（USDT）
+	ScaleEntries    int              `json:"scale_entries"`     // 分批入场次数
+	ScaleStepATR    float64          `json:"scale_step_atr"`    // 每批加仓间距 = ATR × 倍数
+	StopATRMultiple float64          `json:"stop_atr_multiple"` // 止损设在最后一批价位之外 ATR × 倍数
+	Leverage        int              `json:"leverage"`
+}
+
+// DefaultMeanReversionPresetConfig 默认均值回归预设
+func DefaultMeanReversionPresetConfig() MeanReversionPresetConfig {
+	return MeanReversionPresetConfig{
+		TimeFrame:       market.TimeFrame15m,
+		BBPeriod:        20,
+		BBStdDev:        2,
+		RSIPeriod:       14,
+		RSIOversold:     30,
+		RSIOverbought:   70,
+		ADXPeriod:       14,
+		MaxADX:          20,
+		ATRPeriod:       14,
+		RiskPerTrade:    10,
+		ScaleEntries:    3,
+		ScaleStepATR:    0.5,
+		StopATRMultiple: 1.5,
+		Leverage:        3,
+	}
+}
+
+// Validate 校验配置
+func (c MeanReversionPresetConfig) Validate() error {
+	if _, ok := market.TimeFrameMinutes[c.TimeFrame]; !ok {
+		return fmt.Errorf("不支持的K线周期: %s", c.TimeFrame)
+	}
+	if c.BBPeriod < 2 || c.BBStdDev <= 0 || c.RSIPeriod < 1 || c.ADXPeriod < 1 || c.ATRPeriod < 1 {
+		return fmt.Errorf("指标周期配置无效")
+	}
+	if c.RSIOversold <= 0 || c.RSIOverbought >= 100 || c.RSIOversold >= c.RSIOverbought {
+		return fmt.Errorf("RSI阈值配置无效")
+	}
+	if c.RiskPerTrade <= 0 || c.ScaleEntries < 1 || c.ScaleStepATR < 0 || c.StopATRMultiple <= 0 {
+		return fmt.Errorf("风险与分批配置无效")
+	}
+	return nil
+}
+
+// requiredKlines 计算指标所需的K线数量
+func (c MeanReversionPresetConfig) requiredKlines() int {
+	n := c.BBPeriod
+	for _, m := range []int{c.RSIPeriod + 1, 2*c.ADXPeriod + 1, c.ATRPeriod + 1} {
+		if m > n {
+			n = m
+		}
+	}
+	return n
+}
+
+// MeanReversionPreset 均值回归预设：震荡行情中布林带触边 + RSI极值入场，分批加仓，中轨止盈
+type MeanReversionPreset struct {
+	cfg       MeanReversionPresetConfig
+	trader    trader.Trader
+	klines    KlineSource
+	enabled   map[string]bool
+	positions map[string]*presetPosition
+	mu        sync.Mutex
+}
+
+// NewMeanReversionPreset 创建均值回归预设
+func NewMeanReversionPreset(cfg MeanReversionPresetConfig, t trader.Trader, klines KlineSource) (*MeanReversionPreset, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("均值回归预设配置无效: %w", err)
+	}
+	enabled := make(map[string]bool, len(cfg.Symbols))
+	for _, s := range cfg.Symbols {
+		enabled[market.Normalize(s)] = true
+	}
+	return &MeanReversionPreset{
+		cfg:       cfg,
+		trader:    t,
+		klines:    klines,
+		enabled:   enabled,
+		positions: make(map[string]*presetPosition),
+	}, nil
+}
+
+// Enabled 交易对是否启用该预设
+func (p *MeanReversionPreset) Enabled(symbol string) bool {
+	return p.enabled[market.Normalize(symbol)]
+}
+
+// Evaluate 从K线来源读取最新K线并执行一次评估（未启用的交易对直接跳过）
+func (p *MeanReversionPreset) Evaluate(symbol string) (PresetAction, error) {
+	if !p.Enabled(symbol) {
+		return PresetNone, nil
+	}
+	klines, err := p.klines.GetKlines(symbol, p.cfg.TimeFrame, p.cfg.requiredKlines())
+	if err != nil {
+		return PresetNone, fmt.Errorf("获取K线失败: %w", err)
+	}
+	return p.OnKlines(symbol, klines)
+}
+
+// OnKlines 根据已收盘K线评估入场、分批加仓和出场
+func (p *MeanReversionPreset) OnKlines(symbol string, klines []market.Kline) (PresetAction, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(klines) < p.cfg.requiredKlines() {
+		return PresetNone, fmt.Errorf("K线不足: 需要 %d 根，实际 %d 根", p.cfg.requiredKlines(), len(klines))
+	}
+	upper, middle, lower := market.CalculateBollinger(klines, p.cfg.BBPeriod, p.cfg.BBStdDev)
+	atr := market.CalculateATR(klines, p.cfg.ATRPeriod)
+	if atr <= 0 || middle <= 0 {
+		return PresetNone, nil
+	}
+	last := klines[len(klines)-1]
+
+	if pos := p.positions[symbol]; pos != nil {
+		pos.TakeProfit = middle // 中轨随K线移动
+		return p.manageLocked(symbol, pos, last)
+	}
+
+	if adx := market.CalculateADX(klines, p.cfg.ADXPeriod); adx > p.cfg.MaxADX {
+		return PresetNone, nil // 趋势行情不做均值回归
+	}
+	rsi := market.CalculateRSI(klines, p.cfg.RSIPeriod)
+
+	var side string
+	switch {
+	case last.Low <= lower && rsi <= p.cfg.RSIOversold:
+		side = SideLong
+	case last.High >= upper && rsi >= p.cfg.RSIOverbought:
+		side = SideShort
+	default:
+		return PresetNone, nil
+	}
+
+	// 止损在最后一批价位之外；风险按全部批次都成交、以最大止损距离估算
+	step := p.cfg.ScaleStepATR * atr
+	stopDistance := step*float64(p.cfg.ScaleEntries-1) + p.cfg.StopATRMultiple*atr
+	totalQty, err := riskSizedQuantity(p.trader, symbol, p.cfg.RiskPerTrade, stopDistance)
+	if err != nil {
+		return PresetNone, err
+	}
+	batch, err := roundQuantity(p.trader, symbol, totalQty/float64(p.cfg.ScaleEntries))
+	if err != nil || batch <= 0 {
+		return PresetNone, fmt.Errorf("分批数量过小: %.8f", totalQty/float64(p.cfg.ScaleEntries))
+	}
+	if _, err := openPosition(p.trader, symbol, side, batch, p.cfg.Leverage); err != nil {
+		return PresetNone, fmt.Errorf("开仓失败: %w", err)
+	}
+
+	pos := &presetPosition{Side: side, FirstEntry: last.Close, ScaleStep: step, TakeProfit: middle}
+	pos.addFill(last.Close, batch)
+	if side == SideLong {
+		pos.StopLoss = last.Close - stopDistance
+	} else {
+		pos.StopLoss = last.Close + stopDistance
+	}
+	p.positions[symbol] = pos
+	replaceStopLoss(p.trader, symbol, pos)
+
+	log.Printf("🎯 [MeanReversion] %s %s 入场 1/%d @ %.4f (RSI %.1f，布林 [%.4f, %.4f])，止损 %.4f，目标中轨 %.4f",
+		symbol, side, p.cfg.ScaleEntries, last.Close, rsi, lower, upper, pos.StopLoss, middle)
+	if side == SideLong {
+		return PresetOpenLong, nil
+	}
+	return PresetOpenShort, nil
+}
+
+// manageLocked 持仓管理：回到中轨止盈，触及止损平仓，到达下一批价位则加仓
+func (p *MeanReversionPreset) manageLocked(symbol string, pos *presetPosition, last market.Kline) (PresetAction, error) {
+	long := pos.Side == SideLong
+	reachedMid := (long && last.Close >= pos.TakeProfit) || (!long && last.Close <= pos.TakeProfit)
+	stopped := (long && last.Close <= pos.StopLoss) || (!long && last.Close >= pos.StopLoss)
+
+	if reachedMid || stopped {
+		if _, err := closePosition(p.trader, symbol, pos.Side, 0); err != nil {
+			return PresetNone, fmt.Errorf("平仓失败: %w", err)
+		}
+		if err := p.trader.CancelStopLossOrders(symbol); err != nil {
+			log.Printf("⚠️ [MeanReversion] %s 取消止损单失败: %v", symbol, err)
+		}
+		delete(p.positions, symbol)
+		reason := "回到中轨止盈"
+		if stopped {
+			reason = "触及止损"
+		}
+		log.Printf("🏁 [MeanReversion] %s %s @ %.4f（均价 %.4f）", symbol, reason, last.Close, pos.Entry)
+		return PresetClose, nil
+	}
+
+	if pos.Entries >= p.cfg.ScaleEntries {
+		return PresetNone, nil
+	}
+	offset := pos.ScaleStep * float64(pos.Entries)
+	nextLevel := pos.FirstEntry - offset
+	if !long {
+		nextLevel = pos.FirstEntry + offset
+	}
+	if (long && last.Close > nextLevel) || (!long && last.Close < nextLevel) {
+		return PresetNone, nil
+	}
+
+	batch := pos.Quantity / float64(pos.Entries)
+	if _, err := openPosition(p.trader, symbol, pos.Side, batch, p.cfg.Leverage); err != nil {
+		return PresetNone, fmt.Errorf("加仓失败: %w", err)
+	}
+	pos.addFill(last.Close, batch)
+	replaceStopLoss(p.trader, symbol, pos)
+
+	log.Printf("➕ [MeanReversion] %s 加仓 %d/%d @ %.4f，新均价 %.4f", symbol, pos.Entries, p.cfg.ScaleEntries, last.Close, pos.Entry)
+	return PresetAdd, nil
+}
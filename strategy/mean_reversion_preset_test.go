@@ -0,0 +1,85 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rangingKlines 生成围绕 base 正弦震荡的K线，再追加若干根收盘价
+func rangingKlines(count int, base float64, tail ...float64) []market.Kline {
+	klines := make([]market.Kline, 0, count+len(tail))
+	add := func(close float64) {
+		open := base
+		if n := len(klines); n > 0 {
+			open = klines[n-1].Close
+		}
+		klines = append(klines, market.Kline{
+			OpenTime: int64(len(klines)) * 900000,
+			Open:     open,
+			High:     max(open, close) + 0.2,
+			Low:      min(open, close) - 0.2,
+			Close:    close,
+			Volume:   100,
+		})
+	}
+	for i := 0; i < count; i++ {
+		add(base + 2*math.Sin(float64(i)*math.Pi/4))
+	}
+	for _, c := range tail {
+		add(c)
+	}
+	return klines
+}
+
+func TestMeanReversionPreset_ScaleInAndMidlineExit(t *testing.T) {
+	mt := newMockTrader()
+	cfg := DefaultMeanReversionPresetConfig()
+	cfg.Symbols = []string{"ETHUSDT"}
+	p, err := NewMeanReversionPreset(cfg, mt, nil)
+	require.NoError(t, err)
+
+	// 震荡后急跌触及下轨且RSI超卖：首批开多
+	klines := rangingKlines(40, 100, 97, 95, 92)
+	action, err := p.OnKlines("ETHUSDT", klines)
+	require.NoError(t, err)
+	require.Equal(t, PresetOpenLong, action)
+	first := mt.position("ETHUSDT", SideLong)
+	assert.Greater(t, first, 0.0)
+	require.Len(t, mt.ordersOf("stop_loss"), 1)
+
+	// 继续下跌到下一档：加仓，止损数量随之更新
+	klines = append(klines, rangingKlines(0, 92, 90)...)
+	action, err = p.OnKlines("ETHUSDT", klines)
+	require.NoError(t, err)
+	assert.Equal(t, PresetAdd, action)
+	assert.InDelta(t, 2*first, mt.position("ETHUSDT", SideLong), 1e-6)
+	stops := mt.ordersOf("stop_loss")
+	assert.InDelta(t, 2*first, stops[len(stops)-1].Quantity, 1e-6)
+
+	// 回到中轨：全部平仓
+	klines = append(klines, rangingKlines(0, 90, 100)...)
+	action, err = p.OnKlines("ETHUSDT", klines)
+	require.NoError(t, err)
+	assert.Equal(t, PresetClose, action)
+	assert.Zero(t, mt.position("ETHUSDT", SideLong))
+}
+
+func TestMeanReversionPreset_SkipsTrendingMarket(t *testing.T) {
+	mt := newMockTrader()
+	cfg := DefaultMeanReversionPresetConfig()
+	cfg.Symbols = []string{"ETHUSDT"}
+	p, err := NewMeanReversionPreset(cfg, mt, nil)
+	require.NoError(t, err)
+
+	// 单边下跌：RSI超卖但ADX高，不逆势抄底
+	falling := risingKlines(40, 200, -1)
+	action, err := p.OnKlines("ETHUSDT", falling)
+	require.NoError(t, err)
+	assert.Equal(t, PresetNone, action)
+	assert.Zero(t, mt.position("ETHUSDT", SideLong))
+}
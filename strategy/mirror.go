@@ -0,0 +1,214 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// MirrorFollower 跟随账户及其独立的仓位规模设置
+type MirrorFollower struct {
+	Name         string
+	Trader       trader.Trader
+	Scale        float64 // 跟随数量 = 主账户数量 × Scale（SizeByEquity 时再乘以权益比例）
+	SizeByEquity bool    // 按跟随账户与主账户的权益比例缩放
+	MaxNotional  float64 // 单个交易对的最大名义价值（USDT，0 表示不限制）
+	Leverage     int
+}
+
+// MirrorConfig 多账户镜像配置
+type MirrorConfig struct {
+	Symbols     []string // 需要镜像的交易对（为空表示镜像主账户的全部持仓）
+	Band        float64  // 允许的偏差（相对目标数量的比例），偏差以内不调整
+	MinNotional float64  // 调整量的名义价值低于该值时不调整（USDT）
+}
+
+// MirrorAdjustment 一次镜像调整
+type MirrorAdjustment struct {
+	Follower   string
+	Symbol     string
+	PrimaryNet float64 // 主账户净持仓（多为正，空为负）
+	BeforeNet  float64 // 调整前跟随账户净持仓
+	TargetNet  float64 // 跟随账户目标净持仓
+	Err        error
+}
+
+// Mirror 将主账户的开平仓同方向复制到多个自有账户（如因限额拆分资金的子账户）
+// 每个跟随账户独立计算目标仓位，单个账户失败不影响其他账户
+type Mirror struct {
+	cfg       MirrorConfig
+	primary   trader.Trader
+	followers []MirrorFollower
+}
+
+// NewMirror 创建镜像服务
+func NewMirror(cfg MirrorConfig, primary trader.Trader, followers ...MirrorFollower) (*Mirror, error) {
+	if primary == nil {
+		return nil, fmt.Errorf("主账户不能为空")
+	}
+	if len(followers) == 0 {
+		return nil, fmt.Errorf("至少需要一个跟随账户")
+	}
+	if cfg.Band < 0 || cfg.MinNotional < 0 {
+		return nil, fmt.Errorf("偏差和最小名义价值不能为负数")
+	}
+	for i := range followers {
+		f := &followers[i]
+		if f.Trader == nil {
+			return nil, fmt.Errorf("跟随账户 %q 未配置交易器", f.Name)
+		}
+		if f.Scale == 0 {
+			f.Scale = 1
+		}
+		if f.Scale < 0 || f.MaxNotional < 0 {
+			return nil, fmt.Errorf("跟随账户 %q 的规模设置无效", f.Name)
+		}
+		if f.Name == "" {
+			f.Name = fmt.Sprintf("follower-%d", i+1)
+		}
+	}
+	return &Mirror{cfg: cfg, primary: primary, followers: followers}, nil
+}
+
+// Sync 读取主账户持仓，将每个跟随账户调整到各自的目标仓位
+// 主账户平仓后，跟随账户上的对应持仓也会被平掉
+func (m *Mirror) Sync() ([]MirrorAdjustment, error) {
+	primary, err := netPositions(m.primary)
+	if err != nil {
+		return nil, fmt.Errorf("主账户: %w", err)
+	}
+
+	var primaryEquity float64
+	for _, f := range m.followers {
+		if f.SizeByEquity {
+			if primaryEquity, err = accountEquity(m.primary); err != nil {
+				return nil, fmt.Errorf("主账户: %w", err)
+			}
+			break
+		}
+	}
+
+	var adjustments []MirrorAdjustment
+	for _, f := range m.followers {
+		adjs, err := m.syncFollower(f, primary, primaryEquity)
+		if err != nil {
+			log.Printf("❌ [Mirror] %s 同步失败: %v", f.Name, err)
+			adjustments = append(adjustments, MirrorAdjustment{Follower: f.Name, Err: err})
+			continue
+		}
+		adjustments = append(adjustments, adjs...)
+	}
+	return adjustments, nil
+}
+
+// syncFollower 同步单个跟随账户
+func (m *Mirror) syncFollower(f MirrorFollower, primary map[string]*netPosition, primaryEquity float64) ([]MirrorAdjustment, error) {
+	positions, err := netPositions(f.Trader)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := f.Scale
+	if f.SizeByEquity {
+		equity, err := accountEquity(f.Trader)
+		if err != nil {
+			return nil, err
+		}
+		if primaryEquity <= 0 {
+			return nil, fmt.Errorf("主账户权益为0，无法按权益比例缩放")
+		}
+		scale *= equity / primaryEquity
+	}
+
+	var adjustments []MirrorAdjustment
+	for _, symbol := range m.symbols(primary, positions) {
+		p := primary[symbol]
+		if p == nil {
+			p = &netPosition{}
+		}
+		fp := positions[symbol]
+		if fp == nil {
+			fp = &netPosition{}
+		}
+
+		price := fp.markPrice
+		if price == 0 {
+			price = p.markPrice
+		}
+		if price == 0 {
+			if price, err = f.Trader.GetMarketPrice(symbol); err != nil {
+				adjustments = append(adjustments, MirrorAdjustment{Follower: f.Name, Symbol: symbol, Err: fmt.Errorf("获取价格失败: %w", err)})
+				continue
+			}
+		}
+
+		target := p.net() * scale
+		if f.MaxNotional > 0 && math.Abs(target)*price > f.MaxNotional {
+			target = math.Copysign(f.MaxNotional/price, target)
+		}
+		diff := target - fp.net()
+		if target != 0 {
+			// 平仓时保持精确数量，其余按交易对精度取整
+			rounded, err := roundQuantity(f.Trader, symbol, math.Abs(diff))
+			if err != nil {
+				adjustments = append(adjustments, MirrorAdjustment{Follower: f.Name, Symbol: symbol, Err: err})
+				continue
+			}
+			diff = math.Copysign(rounded, diff)
+		}
+		if diff == 0 || (target != 0 && math.Abs(diff) <= m.cfg.Band*math.Abs(target)) {
+			continue
+		}
+		if target != 0 && math.Abs(diff)*price < m.cfg.MinNotional {
+			continue
+		}
+
+		adj := MirrorAdjustment{Follower: f.Name, Symbol: symbol, PrimaryNet: p.net(), BeforeNet: fp.net(), TargetNet: target}
+		adj.Err = adjustNetPosition(f.Trader, symbol, fp, diff, f.Leverage)
+		if adj.Err != nil {
+			log.Printf("❌ [Mirror] %s %s 调整失败: %v", f.Name, symbol, adj.Err)
+		} else {
+			log.Printf("🪞 [Mirror] %s %s 主账户 %.6f，跟随 %.6f → %.6f", f.Name, symbol, p.net(), fp.net(), target)
+		}
+		adjustments = append(adjustments, adj)
+	}
+	return adjustments, nil
+}
+
+// symbols 需要检查的交易对（包含跟随账户上的残留持仓，以便主账户平仓后同步平仓）
+func (m *Mirror) symbols(primary, follower map[string]*netPosition) []string {
+	set := make(map[string]bool)
+	if len(m.cfg.Symbols) > 0 {
+		for _, s := range m.cfg.Symbols {
+			set[market.Normalize(s)] = true
+		}
+	} else {
+		for s := range primary {
+			set[s] = true
+		}
+		for s := range follower {
+			set[s] = true
+		}
+	}
+	symbols := make([]string, 0, len(set))
+	for s := range set {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
+
+// accountEquity 账户总权益（钱包余额 + 未实现盈亏）
+func accountEquity(t trader.Trader) (float64, error) {
+	balance, err := t.GetBalance()
+	if err != nil {
+		return 0, fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	wallet, _ := balance["totalWalletBalance"].(float64)
+	unrealized, _ := balance["totalUnrealizedProfit"].(float64)
+	return wallet + unrealized, nil
+}
@@ -0,0 +1,55 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMirror_ReplicatesWithIndependentSizing(t *testing.T) {
+	primary := newMockTrader()
+	primary.setPrice("BTCUSDT", 50000)
+	primary.positions["BTCUSDT|long"] = 0.2
+
+	half := newMockTrader()
+	half.setPrice("BTCUSDT", 50000)
+	byEquity := newMockTrader()
+	byEquity.setPrice("BTCUSDT", 50000)
+	byEquity.balance = 2500 // 主账户 10000 的四分之一
+	capped := newMockTrader()
+	capped.setPrice("BTCUSDT", 50000)
+
+	m, err := NewMirror(MirrorConfig{MinNotional: 5}, primary,
+		MirrorFollower{Name: "half", Trader: half, Scale: 0.5},
+		MirrorFollower{Name: "equity", Trader: byEquity, SizeByEquity: true},
+		MirrorFollower{Name: "capped", Trader: capped, MaxNotional: 1000},
+	)
+	require.NoError(t, err)
+
+	adjs, err := m.Sync()
+	require.NoError(t, err)
+	require.Len(t, adjs, 3)
+	for _, adj := range adjs {
+		assert.NoError(t, adj.Err)
+	}
+	assert.InDelta(t, 0.1, half.position("BTCUSDT", SideLong), 1e-9)
+	assert.InDelta(t, 0.05, byEquity.position("BTCUSDT", SideLong), 1e-9)
+	assert.InDelta(t, 0.02, capped.position("BTCUSDT", SideLong), 1e-9)
+
+	// 主账户反手做空：跟随账户先平多再开空
+	delete(primary.positions, "BTCUSDT|long")
+	primary.positions["BTCUSDT|short"] = 0.1
+	_, err = m.Sync()
+	require.NoError(t, err)
+	assert.Zero(t, half.position("BTCUSDT", SideLong))
+	assert.InDelta(t, 0.05, half.position("BTCUSDT", SideShort), 1e-9)
+
+	// 主账户平仓：跟随账户同步平仓
+	delete(primary.positions, "BTCUSDT|short")
+	_, err = m.Sync()
+	require.NoError(t, err)
+	assert.Zero(t, half.position("BTCUSDT", SideShort))
+	assert.Zero(t, byEquity.position("BTCUSDT", SideShort))
+	assert.Zero(t, capped.position("BTCUSDT", SideShort))
+}
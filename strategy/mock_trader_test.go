@@ -0,0 +1,183 @@
+package strategy
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+)
+
+// mockOrder mockTrader 记录的一次下单/设置操作
+type mockOrder struct {
+	Action   string // open_long / open_short / close_long / close_short / stop_loss / take_profit
+	Symbol   string
+	Quantity float64
+	Price    float64 // 止损/止盈价
+	Leverage int     // 开仓杠杆
+}
+
+// mockTrader 内存中的 trader.Trader 实现，按方向记录持仓并记录所有操作
+type mockTrader struct {
+	mu        sync.Mutex
+	prices    map[string]float64
+	positions map[string]float64 // key: symbol|side
+	orders    []mockOrder
+	balance   float64
+	errOn     map[string]error // 指定操作返回错误
+}
+
+func newMockTrader() *mockTrader {
+	return &mockTrader{
+		prices:    make(map[string]float64),
+		positions: make(map[string]float64),
+		balance:   10000,
+		errOn:     make(map[string]error),
+	}
+}
+
+func (m *mockTrader) setPrice(symbol string, price float64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.prices[symbol] = price
+}
+
+func (m *mockTrader) position(symbol, side string) float64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.positions[symbol+"|"+side]
+}
+
+func (m *mockTrader) ordersOf(action string) []mockOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []mockOrder
+	for _, o := range m.orders {
+		if o.Action == action {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+func (m *mockTrader) record(action, symbol string, qty, price float64) error {
+	if err := m.errOn[action]; err != nil {
+		return err
+	}
+	m.orders = append(m.orders, mockOrder{Action: action, Symbol: symbol, Quantity: qty, Price: price})
+	return nil
+}
+
+func (m *mockTrader) GetBalance() (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return map[string]interface{}{
+		"totalWalletBalance": m.balance,
+		"availableBalance":   m.balance,
+	}, nil
+}
+
+func (m *mockTrader) GetPositions() ([]map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []map[string]interface{}
+	for key, qty := range m.positions {
+		if qty <= 0 {
+			continue
+		}
+		var symbol, side string
+		for i := len(key) - 1; i >= 0; i-- {
+			if key[i] == '|' {
+				symbol, side = key[:i], key[i+1:]
+				break
+			}
+		}
+		out = append(out, map[string]interface{}{
+			"symbol":      symbol,
+			"side":        side,
+			"positionAmt": qty,
+			"markPrice":   m.prices[symbol],
+		})
+	}
+	return out, nil
+}
+
+func (m *mockTrader) open(action, symbol, side string, qty float64, leverage int) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.record(action, symbol, qty, 0); err != nil {
+		return nil, err
+	}
+	m.orders[len(m.orders)-1].Leverage = leverage
+	m.positions[symbol+"|"+side] += qty
+	return map[string]interface{}{"orderId": int64(len(m.orders))}, nil
+}
+
+func (m *mockTrader) close(action, symbol, side string, qty float64) (map[string]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	key := symbol + "|" + side
+	if qty == 0 {
+		qty = m.positions[key]
+	}
+	if qty <= 0 {
+		return nil, fmt.Errorf("没有 %s 的%s持仓", symbol, side)
+	}
+	if err := m.record(action, symbol, qty, 0); err != nil {
+		return nil, err
+	}
+	m.positions[key] -= qty
+	if m.positions[key] < 1e-12 {
+		delete(m.positions, key)
+	}
+	return map[string]interface{}{"orderId": int64(len(m.orders))}, nil
+}
+
+func (m *mockTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return m.open("open_long", symbol, SideLong, quantity, leverage)
+}
+
+func (m *mockTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return m.open("open_short", symbol, SideShort, quantity, leverage)
+}
+
+func (m *mockTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return m.close("close_long", symbol, SideLong, quantity)
+}
+
+func (m *mockTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return m.close("close_short", symbol, SideShort, quantity)
+}
+
+func (m *mockTrader) SetLeverage(symbol string, leverage int) error { return nil }
+
+func (m *mockTrader) SetMarginMode(symbol string, isCrossMargin bool) error { return nil }
+
+func (m *mockTrader) GetMarketPrice(symbol string) (float64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	price, ok := m.prices[symbol]
+	if !ok {
+		return 0, fmt.Errorf("no price for %s", symbol)
+	}
+	return price, nil
+}
+
+func (m *mockTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.record("stop_loss", symbol, quantity, stopPrice)
+}
+
+func (m *mockTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.record("take_profit", symbol, quantity, takeProfitPrice)
+}
+
+func (m *mockTrader) CancelStopLossOrders(symbol string) error   { return nil }
+func (m *mockTrader) CancelTakeProfitOrders(symbol string) error { return nil }
+func (m *mockTrader) CancelAllOrders(symbol string) error        { return nil }
+func (m *mockTrader) CancelStopOrders(symbol string) error       { return nil }
+
+func (m *mockTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return strconv.FormatFloat(quantity, 'f', 6, 64), nil
+}
@@ -0,0 +1,89 @@
+package strategy
+
+import (
+	"fmt"
+	"strings"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// 持仓方向（与 trader 包 GetPositions 返回的 side 一致）
+const (
+	SideLong  = "long"
+	SideShort = "short"
+)
+
+// positionSide 转换为 SetStopLoss/SetTakeProfit 使用的 LONG/SHORT
+func positionSide(side string) string {
+	return strings.ToUpper(side)
+}
+
+// oppositeSide 返回相反方向
+func oppositeSide(side string) string {
+	if side == SideLong {
+		return SideShort
+	}
+	return SideLong
+}
+
+// validateSide 校验方向参数
+func validateSide(side string) error {
+	if side != SideLong && side != SideShort {
+		return fmt.Errorf("无效的方向: %q（应为 long 或 short）", side)
+	}
+	return nil
+}
+
+// openPosition 按方向开仓
+func openPosition(t trader.Trader, symbol, side string, quantity float64, leverage int) (map[string]interface{}, error) {
+	if side == SideLong {
+		return t.OpenLong(symbol, quantity, leverage)
+	}
+	return t.OpenShort(symbol, quantity, leverage)
+}
+
+// closePosition 按方向平仓（quantity=0表示全部平仓）
+func closePosition(t trader.Trader, symbol, side string, quantity float64) (map[string]interface{}, error) {
+	if side == SideLong {
+		return t.CloseLong(symbol, quantity)
+	}
+	return t.CloseShort(symbol, quantity)
+}
+
+// quoteToQuantity 将计价金额按价格换算为下单数量，并按交易对精度取整
+func quoteToQuantity(t trader.Trader, symbol string, quote, price float64) (float64, error) {
+	if price <= 0 {
+		return 0, fmt.Errorf("无效的价格: %.8f", price)
+	}
+	qty, err := roundQuantity(t, symbol, quote/price)
+	if err != nil {
+		return 0, err
+	}
+	if qty <= 0 {
+		return 0, fmt.Errorf("金额 %.2f 按价格 %.8f 换算后数量为0", quote, price)
+	}
+	return qty, nil
+}
+
+// positionQuantity 查询指定方向的持仓数量（无持仓返回0）
+func positionQuantity(t trader.Trader, symbol, side string) (float64, error) {
+	positions, err := t.GetPositions()
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	normalized := market.Normalize(symbol)
+	for _, pos := range positions {
+		posSymbol, _ := pos["symbol"].(string)
+		posSide, _ := pos["side"].(string)
+		if market.Normalize(posSymbol) != normalized || posSide != side {
+			continue
+		}
+		amt, _ := pos["positionAmt"].(float64)
+		if amt < 0 {
+			amt = -amt
+		}
+		return amt, nil
+	}
+	return 0, nil
+}
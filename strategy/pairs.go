@@ -0,0 +1,302 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sync"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// PairsConfig 配对交易（价差统计套利）配置
+type PairsConfig struct {
+	SymbolA     string           // 第一腿，如 ETHUSDT
+	SymbolB     string           // 第二腿，如 BTCUSDT
+	TimeFrame   market.TimeFrame // K线周期
+	Lookback    int              // 计算z-score的滚动窗口（K线根数）
+	HedgeRatio  float64          // 对冲比例 β（价差 = ln(A) - β·ln(B)），0 表示按窗口内回归自动估算
+	EntryZ      float64          // |z| 达到该值时开仓
+	ExitZ       float64          // |z| 回落到该值以内时平仓
+	StopZ       float64          // |z| 超过该值时止损平仓（0 表示不设置）
+	LegNotional float64          // A腿名义价值（USDT），B腿按 β 换算
+	Leverage    int
+}
+
+// Validate 校验配置
+func (c PairsConfig) Validate() error {
+	if c.SymbolA == "" || c.SymbolB == "" {
+		return fmt.Errorf("两腿交易对不能为空")
+	}
+	if market.Normalize(c.SymbolA) == market.Normalize(c.SymbolB) {
+		return fmt.Errorf("两腿不能是同一交易对: %s", c.SymbolA)
+	}
+	if _, ok := market.TimeFrameMinutes[c.TimeFrame]; !ok {
+		return fmt.Errorf("不支持的K线周期: %s", c.TimeFrame)
+	}
+	if c.Lookback < 10 {
+		return fmt.Errorf("滚动窗口至少需要10根K线")
+	}
+	if c.HedgeRatio < 0 {
+		return fmt.Errorf("对冲比例不能为负数")
+	}
+	if c.EntryZ <= 0 || c.ExitZ < 0 || c.ExitZ >= c.EntryZ {
+		return fmt.Errorf("z-score阈值无效: 需要 0 <= ExitZ < EntryZ")
+	}
+	if c.StopZ != 0 && c.StopZ <= c.EntryZ {
+		return fmt.Errorf("止损z-score %.2f 必须大于开仓阈值 %.2f", c.StopZ, c.EntryZ)
+	}
+	if c.LegNotional <= 0 {
+		return fmt.Errorf("名义价值必须大于0")
+	}
+	return nil
+}
+
+// PairsAction 每次评估执行的动作
+type PairsAction string
+
+const (
+	PairsNone  PairsAction = "none"
+	PairsOpen  PairsAction = "open"
+	PairsClose PairsAction = "close"
+	PairsStop  PairsAction = "stop"
+)
+
+// SpreadStats 价差统计
+type SpreadStats struct {
+	HedgeRatio float64 // 使用的对冲比例 β
+	Spread     float64 // 最新价差
+	Mean       float64 // 窗口内价差均值
+	StdDev     float64 // 窗口内价差标准差
+	ZScore     float64 // (Spread - Mean) / StdDev
+}
+
+// ComputeSpread 按收盘价计算两腿对数价差的z-score
+// hedgeRatio 为0时用窗口内的最小二乘回归估算 β；两组K线按末尾对齐
+func ComputeSpread(a, b []market.Kline, lookback int, hedgeRatio float64) (SpreadStats, error) {
+	if len(a) < lookback || len(b) < lookback {
+		return SpreadStats{}, fmt.Errorf("K线不足: 需要 %d 根，实际 %d/%d 根", lookback, len(a), len(b))
+	}
+	la := make([]float64, lookback)
+	lb := make([]float64, lookback)
+	for i := 0; i < lookback; i++ {
+		pa := a[len(a)-lookback+i].Close
+		pb := b[len(b)-lookback+i].Close
+		if pa <= 0 || pb <= 0 {
+			return SpreadStats{}, fmt.Errorf("无效的收盘价: %.8f/%.8f", pa, pb)
+		}
+		la[i], lb[i] = math.Log(pa), math.Log(pb)
+	}
+
+	beta := hedgeRatio
+	if beta == 0 {
+		beta = olsBeta(la, lb)
+	}
+
+	spreads := make([]float64, lookback)
+	var mean float64
+	for i := range spreads {
+		spreads[i] = la[i] - beta*lb[i]
+		mean += spreads[i]
+	}
+	mean /= float64(lookback)
+
+	var variance float64
+	for _, s := range spreads {
+		variance += (s - mean) * (s - mean)
+	}
+	stats := SpreadStats{
+		HedgeRatio: beta,
+		Spread:     spreads[lookback-1],
+		Mean:       mean,
+		StdDev:     math.Sqrt(variance / float64(lookback)),
+	}
+	if stats.StdDev > 0 {
+		stats.ZScore = (stats.Spread - mean) / stats.StdDev
+	}
+	return stats, nil
+}
+
+// olsBeta y 对 x 的最小二乘斜率（x 无波动时返回1）
+func olsBeta(y, x []float64) float64 {
+	n := float64(len(x))
+	var mx, my float64
+	for i := range x {
+		mx += x[i]
+		my += y[i]
+	}
+	mx /= n
+	my /= n
+
+	var cov, varX float64
+	for i := range x {
+		cov += (x[i] - mx) * (y[i] - my)
+		varX += (x[i] - mx) * (x[i] - mx)
+	}
+	if varX == 0 {
+		return 1
+	}
+	return cov / varX
+}
+
+// pairsPosition 当前持有的两腿
+type pairsPosition struct {
+	SideA     string
+	QtyA      float64
+	QtyB      float64
+	EntryZ    float64
+	HedgeRate float64
+}
+
+// PairsStrategy 配对交易策略
+// 价差偏离均值 EntryZ 个标准差时做空偏贵的一腿、做多偏便宜的一腿，回归到 ExitZ 以内时平仓
+type PairsStrategy struct {
+	cfg      PairsConfig
+	trader   trader.Trader
+	klines   KlineSource
+	position *pairsPosition
+	mu       sync.Mutex
+}
+
+// NewPairsStrategy 创建配对交易策略
+func NewPairsStrategy(cfg PairsConfig, t trader.Trader, klines KlineSource) (*PairsStrategy, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("配对交易配置无效: %w", err)
+	}
+	return &PairsStrategy{cfg: cfg, trader: t, klines: klines}, nil
+}
+
+// Evaluate 读取两腿最新K线并执行一次评估
+func (s *PairsStrategy) Evaluate() (PairsAction, error) {
+	a, err := s.klines.GetKlines(s.cfg.SymbolA, s.cfg.TimeFrame, s.cfg.Lookback)
+	if err != nil {
+		return PairsNone, fmt.Errorf("获取 %s K线失败: %w", s.cfg.SymbolA, err)
+	}
+	b, err := s.klines.GetKlines(s.cfg.SymbolB, s.cfg.TimeFrame, s.cfg.Lookback)
+	if err != nil {
+		return PairsNone, fmt.Errorf("获取 %s K线失败: %w", s.cfg.SymbolB, err)
+	}
+	return s.OnKlines(a, b)
+}
+
+// OnKlines 根据两腿K线计算价差z-score并决定开平仓
+func (s *PairsStrategy) OnKlines(a, b []market.Kline) (PairsAction, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	hedgeRatio := s.cfg.HedgeRatio
+	if s.position != nil {
+		hedgeRatio = s.position.HedgeRate // 持仓期间沿用开仓时的 β，避免价差定义漂移
+	}
+	stats, err := ComputeSpread(a, b, s.cfg.Lookback, hedgeRatio)
+	if err != nil {
+		return PairsNone, err
+	}
+	if stats.StdDev == 0 {
+		return PairsNone, nil
+	}
+	z := stats.ZScore
+
+	if pos := s.position; pos != nil {
+		stopped := s.cfg.StopZ > 0 && math.Abs(z) >= s.cfg.StopZ
+		if math.Abs(z) > s.cfg.ExitZ && !stopped {
+			return PairsNone, nil
+		}
+		if err := s.closeLocked(); err != nil {
+			return PairsNone, err
+		}
+		if stopped {
+			log.Printf("🛑 [Pairs] %s/%s 价差继续扩大 z=%.2f，止损平仓", s.cfg.SymbolA, s.cfg.SymbolB, z)
+			return PairsStop, nil
+		}
+		log.Printf("💰 [Pairs] %s/%s 价差回归 z=%.2f（开仓 z=%.2f），平仓", s.cfg.SymbolA, s.cfg.SymbolB, z, pos.EntryZ)
+		return PairsClose, nil
+	}
+
+	if math.Abs(z) < s.cfg.EntryZ || (s.cfg.StopZ > 0 && math.Abs(z) >= s.cfg.StopZ) {
+		return PairsNone, nil
+	}
+	// 价差偏高说明A相对B偏贵：空A多B；反之多A空B
+	sideA := SideLong
+	if z > 0 {
+		sideA = SideShort
+	}
+	if err := s.openLocked(sideA, stats, a[len(a)-1].Close, b[len(b)-1].Close); err != nil {
+		return PairsNone, err
+	}
+	log.Printf("⚖️ [Pairs] %s/%s z=%.2f β=%.3f，%s %s / %s %s", s.cfg.SymbolA, s.cfg.SymbolB, z, stats.HedgeRatio,
+		sideA, s.cfg.SymbolA, oppositeSide(sideA), s.cfg.SymbolB)
+	return PairsOpen, nil
+}
+
+// openLocked 开两腿；第二腿失败时回滚第一腿
+func (s *PairsStrategy) openLocked(sideA string, stats SpreadStats, priceA, priceB float64) error {
+	qtyA, err := quoteToQuantity(s.trader, s.cfg.SymbolA, s.cfg.LegNotional, priceA)
+	if err != nil {
+		return fmt.Errorf("%s 计算数量失败: %w", s.cfg.SymbolA, err)
+	}
+	qtyB, err := quoteToQuantity(s.trader, s.cfg.SymbolB, s.cfg.LegNotional*math.Abs(stats.HedgeRatio), priceB)
+	if err != nil {
+		return fmt.Errorf("%s 计算数量失败: %w", s.cfg.SymbolB, err)
+	}
+
+	// β 为负时两腿同向，价差仍按 ln(A) - β·ln(B) 对冲
+	sideB := oppositeSide(sideA)
+	if stats.HedgeRatio < 0 {
+		sideB = sideA
+	}
+
+	if _, err := openPosition(s.trader, s.cfg.SymbolA, sideA, qtyA, s.cfg.Leverage); err != nil {
+		return fmt.Errorf("%s 开仓失败: %w", s.cfg.SymbolA, err)
+	}
+	if _, err := openPosition(s.trader, s.cfg.SymbolB, sideB, qtyB, s.cfg.Leverage); err != nil {
+		log.Printf("❌ [Pairs] %s 开仓失败，回滚 %s: %v", s.cfg.SymbolB, s.cfg.SymbolA, err)
+		if _, rbErr := closePosition(s.trader, s.cfg.SymbolA, sideA, qtyA); rbErr != nil {
+			return fmt.Errorf("%s 开仓失败: %v；回滚 %s 也失败: %w", s.cfg.SymbolB, err, s.cfg.SymbolA, rbErr)
+		}
+		return fmt.Errorf("%s 开仓失败（已回滚）: %w", s.cfg.SymbolB, err)
+	}
+
+	s.position = &pairsPosition{SideA: sideA, QtyA: qtyA, QtyB: qtyB, EntryZ: stats.ZScore, HedgeRate: stats.HedgeRatio}
+	return nil
+}
+
+// closeLocked 平掉两腿；任一腿失败时保留持仓记录以便下次重试
+func (s *PairsStrategy) closeLocked() error {
+	pos := s.position
+	sideB := oppositeSide(pos.SideA)
+	if pos.HedgeRate < 0 {
+		sideB = pos.SideA
+	}
+
+	// 已平掉的腿数量清零，重试时跳过
+	var errs []error
+	if pos.QtyA > 0 {
+		if _, err := closePosition(s.trader, s.cfg.SymbolA, pos.SideA, 0); err != nil {
+			errs = append(errs, fmt.Errorf("%s 平仓失败: %w", s.cfg.SymbolA, err))
+		} else {
+			pos.QtyA = 0
+		}
+	}
+	if pos.QtyB > 0 {
+		if _, err := closePosition(s.trader, s.cfg.SymbolB, sideB, 0); err != nil {
+			errs = append(errs, fmt.Errorf("%s 平仓失败: %w", s.cfg.SymbolB, err))
+		} else {
+			pos.QtyB = 0
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	s.position = nil
+	return nil
+}
+
+// InPosition 是否持有价差仓位
+func (s *PairsStrategy) InPosition() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.position != nil
+}
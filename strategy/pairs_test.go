@@ -0,0 +1,111 @@
+package strategy
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closesToKlines 由收盘价序列构造K线
+func closesToKlines(closes ...float64) []market.Kline {
+	klines := make([]market.Kline, len(closes))
+	for i, c := range closes {
+		klines[i] = market.Kline{OpenTime: int64(i) * 3600000, Open: c, High: c, Low: c, Close: c}
+	}
+	return klines
+}
+
+// pairSeries 生成 n 根小幅震荡的价格，再追加 tail
+func pairSeries(n int, base float64, tail ...float64) []market.Kline {
+	closes := make([]float64, 0, n+len(tail))
+	for i := 0; i < n; i++ {
+		closes = append(closes, base*(1+0.002*math.Sin(float64(i))))
+	}
+	return closesToKlines(append(closes, tail...)...)
+}
+
+// flatSeries 生成 n 根价格不变的K线
+func flatSeries(n int, price float64) []market.Kline {
+	closes := make([]float64, n)
+	for i := range closes {
+		closes[i] = price
+	}
+	return closesToKlines(closes...)
+}
+
+func testPairsConfig() PairsConfig {
+	return PairsConfig{
+		SymbolA:     "ETHUSDT",
+		SymbolB:     "BTCUSDT",
+		TimeFrame:   market.TimeFrame1h,
+		Lookback:    20,
+		HedgeRatio:  1,
+		EntryZ:      2,
+		ExitZ:       0.5,
+		StopZ:       6,
+		LegNotional: 1000,
+		Leverage:    2,
+	}
+}
+
+func TestComputeSpread_EstimatesHedgeRatio(t *testing.T) {
+	// A 的对数收益恒为 B 的两倍：β≈2，价差恒定
+	var a, b []float64
+	for i := 0; i < 30; i++ {
+		pb := 100 * math.Exp(0.01*math.Sin(float64(i)))
+		b = append(b, pb)
+		a = append(a, 50*math.Pow(pb/100, 2))
+	}
+	stats, err := ComputeSpread(closesToKlines(a...), closesToKlines(b...), 20, 0)
+	require.NoError(t, err)
+	assert.InDelta(t, 2, stats.HedgeRatio, 1e-6)
+	assert.InDelta(t, 0, stats.StdDev, 1e-9)
+
+	_, err = ComputeSpread(closesToKlines(a[:5]...), closesToKlines(b...), 20, 0)
+	assert.Error(t, err)
+}
+
+func TestPairsStrategy_OpenAndRevert(t *testing.T) {
+	mt := newMockTrader()
+	s, err := NewPairsStrategy(testPairsConfig(), mt, nil)
+	require.NoError(t, err)
+
+	// A 相对 B 大涨：空A多B
+	a := pairSeries(30, 2000, 2012)
+	b := flatSeries(31, 50000)
+	action, err := s.OnKlines(a, b)
+	require.NoError(t, err)
+	require.Equal(t, PairsOpen, action)
+	assert.InDelta(t, 1000.0/2012, mt.position("ETHUSDT", SideShort), 1e-5)
+	assert.InDelta(t, 1000.0/50000, mt.position("BTCUSDT", SideLong), 1e-5)
+
+	// 价差仍然偏离：保持
+	action, err = s.OnKlines(append(a, closesToKlines(2010)...), flatSeries(32, 50000))
+	require.NoError(t, err)
+	assert.Equal(t, PairsNone, action)
+
+	// 价差回归：两腿平仓
+	action, err = s.OnKlines(append(a, closesToKlines(2010, 2000)...), flatSeries(33, 50000))
+	require.NoError(t, err)
+	assert.Equal(t, PairsClose, action)
+	assert.Zero(t, mt.position("ETHUSDT", SideShort))
+	assert.Zero(t, mt.position("BTCUSDT", SideLong))
+	assert.False(t, s.InPosition())
+}
+
+func TestPairsStrategy_RollsBackFirstLeg(t *testing.T) {
+	mt := newMockTrader()
+	mt.errOn["open_long"] = errors.New("insufficient margin")
+	s, err := NewPairsStrategy(testPairsConfig(), mt, nil)
+	require.NoError(t, err)
+
+	_, err = s.OnKlines(pairSeries(30, 2000, 2012), flatSeries(31, 50000))
+	require.Error(t, err)
+	assert.Zero(t, mt.position("ETHUSDT", SideShort))
+	assert.False(t, s.InPosition())
+}
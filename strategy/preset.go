@@ -0,0 +1,86 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// KlineSource K线来源（market.KlineCache 已实现）
+type KlineSource interface {
+	GetKlines(symbol string, timeFrame market.TimeFrame, limit int) ([]market.Kline, error)
+}
+
+// PresetAction 预设策略执行的动作
+type PresetAction string
+
+const (
+	PresetNone      PresetAction = "none"
+	PresetOpenLong  PresetAction = "open_long"
+	PresetOpenShort PresetAction = "open_short"
+	PresetAdd       PresetAction = "add"   // 分批加仓
+	PresetTrail     PresetAction = "trail" // 移动止损
+	PresetClose     PresetAction = "close"
+)
+
+// presetPosition 预设策略持有的仓位
+type presetPosition struct {
+	Side       string
+	Entry      float64 // 持仓均价
+	Quantity   float64
+	StopLoss   float64
+	TakeProfit float64 // 0 表示不设置
+	Extreme    float64 // 持仓期间的最有利价格（多头最高价/空头最低价），用于移动止损
+	Entries    int     // 已入场批次
+	FirstEntry float64 // 首批入场价（分批加仓的基准）
+	ScaleStep  float64 // 分批加仓的价格间距
+}
+
+// addFill 记录一批成交并更新均价
+func (pos *presetPosition) addFill(price, quantity float64) {
+	total := pos.Quantity + quantity
+	pos.Entry = (pos.Entry*pos.Quantity + price*quantity) / total
+	pos.Quantity = total
+	pos.Entries++
+}
+
+// roundQuantity 按交易对精度取整数量
+func roundQuantity(t trader.Trader, symbol string, quantity float64) (float64, error) {
+	qtyStr, err := t.FormatQuantity(symbol, quantity)
+	if err != nil {
+		return 0, fmt.Errorf("格式化数量失败: %w", err)
+	}
+	qty, err := strconv.ParseFloat(qtyStr, 64)
+	if err != nil {
+		return 0, fmt.Errorf("解析数量失败: %w", err)
+	}
+	return qty, nil
+}
+
+// riskSizedQuantity 按单笔风险金额和止损距离计算下单数量（止损触发时亏损约为 risk）
+func riskSizedQuantity(t trader.Trader, symbol string, risk, stopDistance float64) (float64, error) {
+	if risk <= 0 || stopDistance <= 0 {
+		return 0, fmt.Errorf("无效的风险金额 %.4f 或止损距离 %.8f", risk, stopDistance)
+	}
+	qty, err := roundQuantity(t, symbol, risk/stopDistance)
+	if err != nil {
+		return 0, err
+	}
+	if qty <= 0 {
+		return 0, fmt.Errorf("风险金额 %.2f 对应的数量过小", risk)
+	}
+	return qty, nil
+}
+
+// replaceStopLoss 撤销旧止损单并按新价格重挂（失败只记录日志，由策略在收盘价触及止损时兜底平仓）
+func replaceStopLoss(t trader.Trader, symbol string, pos *presetPosition) {
+	if err := t.CancelStopLossOrders(symbol); err != nil {
+		log.Printf("⚠️ [Preset] %s 取消旧止损失败: %v", symbol, err)
+	}
+	if err := t.SetStopLoss(symbol, positionSide(pos.Side), pos.Quantity, pos.StopLoss); err != nil {
+		log.Printf("⚠️ [Preset] %s 设置止损 %.4f 失败: %v", symbol, pos.StopLoss, err)
+	}
+}
@@ -0,0 +1,258 @@
+package strategy
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// RebalanceConfig 组合再平衡配置
+type RebalanceConfig struct {
+	Weights       map[string]float64 // 目标名义价值权重（正为多、负为空，相对 Capital×GrossLeverage）
+	Capital       float64            // 组合资金（USDT），0 表示使用账户总权益
+	GrossLeverage float64            // 组合总杠杆（默认1，即权重之和为1时总名义价值等于资金）
+	DriftBand     float64            // 单个交易对偏离目标的名义价值占资金比例低于该值时不调整（如 0.02）
+	MinNotional   float64            // 单笔订单最小名义价值（USDT），低于该值的订单不下
+	CloseUnlisted bool               // 是否平掉不在权重表中的持仓
+	Interval      time.Duration      // Run 的再平衡周期
+	Leverage      int
+}
+
+// Validate 校验配置
+func (c RebalanceConfig) Validate() error {
+	if len(c.Weights) == 0 {
+		return fmt.Errorf("目标权重不能为空")
+	}
+	for symbol, w := range c.Weights {
+		if math.IsNaN(w) || math.IsInf(w, 0) {
+			return fmt.Errorf("%s 的权重无效", symbol)
+		}
+	}
+	if c.Capital < 0 || c.GrossLeverage < 0 || c.DriftBand < 0 || c.MinNotional < 0 {
+		return fmt.Errorf("资金、杠杆、偏离带和最小名义价值不能为负数")
+	}
+	return nil
+}
+
+// RebalanceOrder 再平衡计划中的一笔订单
+type RebalanceOrder struct {
+	Symbol   string
+	Side     string  // long/short：操作的持仓方向
+	Close    bool    // true 表示平仓（减少该方向持仓），false 表示开仓
+	Quantity float64 // 已按交易对精度取整
+	Notional float64 // 按当前价格估算的名义价值（USDT）
+}
+
+func (o RebalanceOrder) String() string {
+	action := "开"
+	if o.Close {
+		action = "平"
+	}
+	return fmt.Sprintf("%s %s%s %.6f (≈%.2f USDT)", o.Symbol, action, o.Side, o.Quantity, o.Notional)
+}
+
+// Rebalancer 组合再平衡器：按周期把一篮子合约持仓拉回目标权重
+// 每个交易对只生成最少的订单：先平掉反方向持仓，剩余部分再开仓
+type Rebalancer struct {
+	cfg     RebalanceConfig
+	trader  trader.Trader
+	weights map[string]float64 // key 为标准化后的symbol
+	mu      sync.Mutex
+}
+
+// NewRebalancer 创建再平衡器
+func NewRebalancer(cfg RebalanceConfig, t trader.Trader) (*Rebalancer, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("再平衡配置无效: %w", err)
+	}
+	if cfg.GrossLeverage == 0 {
+		cfg.GrossLeverage = 1
+	}
+	weights := make(map[string]float64, len(cfg.Weights))
+	for symbol, w := range cfg.Weights {
+		weights[market.Normalize(symbol)] += w
+	}
+	return &Rebalancer{cfg: cfg, trader: t, weights: weights}, nil
+}
+
+// Plan 计算回到目标权重所需的订单（不下单）
+// 所有平仓单排在开仓单之前，以便先释放保证金
+func (r *Rebalancer) Plan() ([]RebalanceOrder, error) {
+	capital, err := r.capital()
+	if err != nil {
+		return nil, err
+	}
+	positions, err := netPositions(r.trader)
+	if err != nil {
+		return nil, err
+	}
+
+	var closes, opens []RebalanceOrder
+	for _, symbol := range r.symbols(positions) {
+		pos := positions[symbol]
+		if pos == nil {
+			pos = &netPosition{}
+		}
+		price := pos.markPrice
+		if price <= 0 {
+			if price, err = r.trader.GetMarketPrice(symbol); err != nil {
+				return nil, fmt.Errorf("%s 获取价格失败: %w", symbol, err)
+			}
+		}
+		if price <= 0 {
+			return nil, fmt.Errorf("%s 价格无效: %.8f", symbol, price)
+		}
+
+		target := r.weights[symbol] * capital * r.cfg.GrossLeverage / price
+		delta := target - pos.net()
+		driftNotional := math.Abs(delta) * price
+		if driftNotional == 0 || driftNotional < r.cfg.DriftBand*capital {
+			continue
+		}
+
+		c, o, err := r.ordersFor(symbol, pos, delta, price)
+		if err != nil {
+			return nil, err
+		}
+		closes = append(closes, c...)
+		opens = append(opens, o...)
+	}
+	return append(closes, opens...), nil
+}
+
+// ordersFor 将净持仓调整量拆分为平仓单和开仓单
+func (r *Rebalancer) ordersFor(symbol string, pos *netPosition, delta, price float64) (closes, opens []RebalanceOrder, err error) {
+	// delta>0 需要更多多头：先平空再开多；反之先平多再开空
+	openSide, opposite := SideLong, pos.short
+	if delta < 0 {
+		openSide, opposite = SideShort, pos.long
+	}
+	remaining := math.Abs(delta)
+	closeSide := oppositeSide(openSide)
+
+	if closeQty := math.Min(opposite, remaining); closeQty > 0 {
+		// 全部平掉时直接使用持仓数量，避免取整后残留
+		if closeQty < opposite {
+			if closeQty, err = roundQuantity(r.trader, symbol, closeQty); err != nil {
+				return nil, nil, err
+			}
+		}
+		if closeQty > 0 && (closeQty == opposite || closeQty*price >= r.cfg.MinNotional) {
+			closes = append(closes, RebalanceOrder{Symbol: symbol, Side: closeSide, Close: true, Quantity: closeQty, Notional: closeQty * price})
+			remaining -= closeQty
+		}
+	}
+
+	if remaining <= 0 {
+		return closes, nil, nil
+	}
+	openQty, err := roundQuantity(r.trader, symbol, remaining)
+	if err != nil {
+		return nil, nil, err
+	}
+	if openQty > 0 && openQty*price >= r.cfg.MinNotional {
+		opens = append(opens, RebalanceOrder{Symbol: symbol, Side: openSide, Quantity: openQty, Notional: openQty * price})
+	}
+	return closes, opens, nil
+}
+
+// Execute 依次执行订单；单笔失败不影响其余订单，所有错误合并返回
+func (r *Rebalancer) Execute(orders []RebalanceOrder) error {
+	var errs []error
+	for _, o := range orders {
+		var err error
+		if o.Close {
+			_, err = closePosition(r.trader, o.Symbol, o.Side, o.Quantity)
+		} else {
+			_, err = openPosition(r.trader, o.Symbol, o.Side, o.Quantity, r.cfg.Leverage)
+		}
+		if err != nil {
+			log.Printf("❌ [Rebalancer] %s 失败: %v", o, err)
+			errs = append(errs, fmt.Errorf("%s: %w", o, err))
+			continue
+		}
+		log.Printf("⚖️ [Rebalancer] %s", o)
+	}
+	return errors.Join(errs...)
+}
+
+// Rebalance 计算并执行一次再平衡，返回执行的订单
+func (r *Rebalancer) Rebalance() ([]RebalanceOrder, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders, err := r.Plan()
+	if err != nil {
+		return nil, err
+	}
+	if len(orders) == 0 {
+		return nil, nil
+	}
+	return orders, r.Execute(orders)
+}
+
+// Run 按 Interval 周期再平衡，直到 stop 被关闭
+func (r *Rebalancer) Run(stop <-chan struct{}) {
+	interval := r.cfg.Interval
+	if interval <= 0 {
+		interval = time.Hour
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	log.Printf("🔄 [Rebalancer] 启动，%d 个交易对，周期 %v", len(r.weights), interval)
+	for {
+		if _, err := r.Rebalance(); err != nil {
+			log.Printf("❌ [Rebalancer] 再平衡失败: %v", err)
+		}
+		select {
+		case <-stop:
+			log.Printf("⏹ [Rebalancer] 已停止")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// capital 组合资金：优先使用配置值，否则读取账户总权益
+func (r *Rebalancer) capital() (float64, error) {
+	if r.cfg.Capital > 0 {
+		return r.cfg.Capital, nil
+	}
+	equity, err := accountEquity(r.trader)
+	if err != nil {
+		return 0, err
+	}
+	if equity <= 0 {
+		return 0, fmt.Errorf("账户权益为0，无法计算目标仓位")
+	}
+	return equity, nil
+}
+
+// symbols 需要检查的交易对（按字母排序，保证订单顺序稳定）
+func (r *Rebalancer) symbols(positions map[string]*netPosition) []string {
+	set := make(map[string]bool, len(r.weights))
+	for s := range r.weights {
+		set[s] = true
+	}
+	if r.cfg.CloseUnlisted {
+		for s, p := range positions {
+			if p.long != 0 || p.short != 0 {
+				set[s] = true
+			}
+		}
+	}
+	symbols := make([]string, 0, len(set))
+	for s := range set {
+		symbols = append(symbols, s)
+	}
+	sort.Strings(symbols)
+	return symbols
+}
@@ -0,0 +1,73 @@
+package strategy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebalancer_PlanMinimalOrders(t *testing.T) {
+	mt := newMockTrader()
+	mt.setPrice("BTCUSDT", 50000)
+	mt.setPrice("ETHUSDT", 2500)
+	mt.setPrice("DOGEUSDT", 0.2)
+	mt.positions["BTCUSDT|long"] = 0.05
+	mt.positions["ETHUSDT|long"] = 0.4
+	mt.positions["DOGEUSDT|long"] = 1000
+
+	r, err := NewRebalancer(RebalanceConfig{
+		Weights:       map[string]float64{"BTCUSDT": 0.5, "ETH_USDC_PERP": -0.3},
+		Capital:       10000,
+		DriftBand:     0.01,
+		MinNotional:   5,
+		CloseUnlisted: true,
+		Leverage:      2,
+	}, mt)
+	require.NoError(t, err)
+
+	orders, err := r.Plan()
+	require.NoError(t, err)
+	require.Len(t, orders, 4)
+
+	// 平仓单在前
+	assert.Equal(t, RebalanceOrder{Symbol: "DOGEUSDT", Side: SideLong, Close: true, Quantity: 1000, Notional: 200}, orders[0])
+	assert.Equal(t, "ETHUSDT", orders[1].Symbol)
+	assert.True(t, orders[1].Close)
+	assert.InDelta(t, 0.4, orders[1].Quantity, 1e-9)
+
+	assert.Equal(t, "BTCUSDT", orders[2].Symbol)
+	assert.Equal(t, SideLong, orders[2].Side)
+	assert.InDelta(t, 0.05, orders[2].Quantity, 1e-9)
+	assert.Equal(t, SideShort, orders[3].Side)
+	assert.InDelta(t, 1.2, orders[3].Quantity, 1e-9)
+
+	_, err = r.Rebalance()
+	require.NoError(t, err)
+	assert.InDelta(t, 0.1, mt.position("BTCUSDT", SideLong), 1e-9)
+	assert.InDelta(t, 1.2, mt.position("ETHUSDT", SideShort), 1e-9)
+	assert.Zero(t, mt.position("ETHUSDT", SideLong))
+	assert.Zero(t, mt.position("DOGEUSDT", SideLong))
+
+	// 已回到目标：不再下单
+	orders, err = r.Plan()
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+}
+
+func TestRebalancer_SkipsDriftInsideBand(t *testing.T) {
+	mt := newMockTrader()
+	mt.setPrice("BTCUSDT", 50000)
+	mt.positions["BTCUSDT|long"] = 0.099 // 目标 0.1，偏离 50 USDT
+
+	r, err := NewRebalancer(RebalanceConfig{
+		Weights:   map[string]float64{"BTCUSDT": 0.5},
+		Capital:   10000,
+		DriftBand: 0.01, // 100 USDT
+	}, mt)
+	require.NoError(t, err)
+
+	orders, err := r.Plan()
+	require.NoError(t, err)
+	assert.Empty(t, orders)
+}
@@ -0,0 +1,156 @@
+package strategy
+
+import (
+	"fmt"
+	"log"
+	"math"
+
+	"nofx/market"
+	"nofx/trader"
+)
+
+// VolTargetMethod 波动率估算方法
+type VolTargetMethod string
+
+const (
+	VolTargetRealized VolTargetMethod = "realized" // 收盘价对数收益标准差
+	VolTargetATR      VolTargetMethod = "atr"      // ATR / 最新收盘价
+)
+
+// VolTargetConfig 波动率目标杠杆配置
+// 按交易对近期波动率选择杠杆，使每个仓位（按保证金计）的年化波动率接近目标值：
+// 杠杆 = 目标年化波动率 / 交易对年化波动率（向下取整并限制在上下限内）
+type VolTargetConfig struct {
+	TargetVolatility float64          `json:"target_volatility"` // 目标年化波动率（0.6 表示60%）
+	Method           VolTargetMethod  `json:"method"`            // 波动率估算方法
+	TimeFrame        market.TimeFrame `json:"timeframe"`         // K线周期
+	Lookback         int              `json:"lookback"`          // 波动率计算的K线根数
+	MinLeverage      int              `json:"min_leverage"`
+	MaxLeverage      int              `json:"max_leverage"`
+}
+
+// DefaultVolTargetConfig 默认配置：1小时K线、48根已实现波动率、目标60%、杠杆1-10倍
+func DefaultVolTargetConfig() VolTargetConfig {
+	return VolTargetConfig{
+		TargetVolatility: 0.6,
+		Method:           VolTargetRealized,
+		TimeFrame:        market.TimeFrame1h,
+		Lookback:         48,
+		MinLeverage:      1,
+		MaxLeverage:      10,
+	}
+}
+
+// Validate 校验配置
+func (c VolTargetConfig) Validate() error {
+	if c.TargetVolatility <= 0 {
+		return fmt.Errorf("目标波动率必须大于0")
+	}
+	if c.Method != VolTargetRealized && c.Method != VolTargetATR {
+		return fmt.Errorf("不支持的波动率估算方法: %q", c.Method)
+	}
+	if _, ok := market.TimeFrameMinutes[c.TimeFrame]; !ok {
+		return fmt.Errorf("不支持的K线周期: %s", c.TimeFrame)
+	}
+	if c.Lookback < 2 {
+		return fmt.Errorf("波动率计算周期至少为2")
+	}
+	if c.MinLeverage < 1 || c.MaxLeverage < c.MinLeverage {
+		return fmt.Errorf("杠杆上下限配置无效: %d-%d", c.MinLeverage, c.MaxLeverage)
+	}
+	return nil
+}
+
+// barsPerYear 每年的K线根数（加密货币全年无休）
+func (c VolTargetConfig) barsPerYear() float64 {
+	return 365 * 24 * 60 / float64(market.TimeFrameMinutes[c.TimeFrame])
+}
+
+// VolTargetLeverage 按波动率目标为交易对选择杠杆
+type VolTargetLeverage struct {
+	cfg    VolTargetConfig
+	klines KlineSource
+}
+
+// NewVolTargetLeverage 创建波动率目标杠杆选择器
+func NewVolTargetLeverage(cfg VolTargetConfig, klines KlineSource) (*VolTargetLeverage, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("波动率目标配置无效: %w", err)
+	}
+	if klines == nil {
+		return nil, fmt.Errorf("K线来源不能为空")
+	}
+	return &VolTargetLeverage{cfg: cfg, klines: klines}, nil
+}
+
+// AnnualizedVolatility 计算交易对的年化波动率
+func (v *VolTargetLeverage) AnnualizedVolatility(symbol string) (float64, error) {
+	klines, err := v.klines.GetKlines(symbol, v.cfg.TimeFrame, 2*v.cfg.Lookback+1)
+	if err != nil {
+		return 0, fmt.Errorf("获取K线失败: %w", err)
+	}
+
+	var perBar float64
+	switch v.cfg.Method {
+	case VolTargetATR:
+		if len(klines) > 0 && klines[len(klines)-1].Close > 0 {
+			perBar = market.CalculateATR(klines, v.cfg.Lookback) / klines[len(klines)-1].Close
+		}
+	default:
+		perBar = market.CalculateRealizedVolatility(klines, v.cfg.Lookback)
+	}
+	if perBar <= 0 {
+		return 0, fmt.Errorf("%s K线不足或无波动，无法计算波动率（%d根）", symbol, len(klines))
+	}
+	return perBar * math.Sqrt(v.cfg.barsPerYear()), nil
+}
+
+// Leverage 计算交易对的目标杠杆
+func (v *VolTargetLeverage) Leverage(symbol string) (int, error) {
+	vol, err := v.AnnualizedVolatility(symbol)
+	if err != nil {
+		return 0, err
+	}
+	leverage := int(math.Floor(v.cfg.TargetVolatility / vol))
+	if leverage < v.cfg.MinLeverage {
+		leverage = v.cfg.MinLeverage
+	}
+	if leverage > v.cfg.MaxLeverage {
+		leverage = v.cfg.MaxLeverage
+	}
+	return leverage, nil
+}
+
+// Trader 包装交易器：开仓时由波动率目标自动选择杠杆
+func (v *VolTargetLeverage) Trader(t trader.Trader) *VolTargetTrader {
+	return &VolTargetTrader{Trader: t, sizer: v}
+}
+
+// VolTargetTrader 开仓时按波动率目标选择杠杆的交易器
+// 调用方传入的 leverage 仅作为额外上限（<=0 表示不限制）
+type VolTargetTrader struct {
+	trader.Trader
+	sizer *VolTargetLeverage
+}
+
+// OpenLong 按目标杠杆开多仓
+func (t *VolTargetTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.open(symbol, SideLong, quantity, leverage)
+}
+
+// OpenShort 按目标杠杆开空仓
+func (t *VolTargetTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.open(symbol, SideShort, quantity, leverage)
+}
+
+func (t *VolTargetTrader) open(symbol, side string, quantity float64, maxLeverage int) (map[string]interface{}, error) {
+	leverage, err := t.sizer.Leverage(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("计算目标杠杆失败: %w", err)
+	}
+	if maxLeverage > 0 && leverage > maxLeverage {
+		leverage = maxLeverage
+	}
+	log.Printf("📐 [VolTarget] %s %s 目标杠杆 %dx", symbol, side, leverage)
+	return openPosition(t.Trader, symbol, side, quantity, leverage)
+}
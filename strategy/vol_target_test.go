@@ -0,0 +1,131 @@
+package strategy
+
+import (
+	"math"
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticKlineSource 按交易对返回固定K线
+type staticKlineSource map[string][]market.Kline
+
+func (s staticKlineSource) GetKlines(symbol string, timeFrame market.TimeFrame, limit int) ([]market.Kline, error) {
+	klines := s[symbol]
+	if len(klines) > limit {
+		klines = klines[len(klines)-limit:]
+	}
+	return klines, nil
+}
+
+// oscillatingKlines 收盘价交替涨跌 move（对数收益标准差约为 move）
+func oscillatingKlines(count int, move float64) []market.Kline {
+	klines := make([]market.Kline, count)
+	price := 100.0
+	for i := range klines {
+		if i%2 == 0 {
+			price *= 1 + move
+		} else {
+			price /= 1 + move
+		}
+		klines[i] = market.Kline{Open: price, High: price, Low: price, Close: price}
+	}
+	return klines
+}
+
+func TestVolTargetConfig_Validate(t *testing.T) {
+	require.NoError(t, DefaultVolTargetConfig().Validate())
+
+	cfg := DefaultVolTargetConfig()
+	cfg.TargetVolatility = 0
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultVolTargetConfig()
+	cfg.Method = "garch"
+	assert.Error(t, cfg.Validate())
+
+	cfg = DefaultVolTargetConfig()
+	cfg.MinLeverage, cfg.MaxLeverage = 5, 3
+	assert.Error(t, cfg.Validate())
+}
+
+func TestVolTargetLeverage_Leverage(t *testing.T) {
+	// 1小时K线每根波动约0.2%：年化 ≈ 0.002 × √8760 ≈ 18.7%
+	// 1%：年化 ≈ 93.6%
+	source := staticKlineSource{
+		"BTCUSDT":  oscillatingKlines(100, 0.002),
+		"DOGEUSDT": oscillatingKlines(100, 0.01),
+		"FLATUSDT": oscillatingKlines(100, 0),
+	}
+	cfg := DefaultVolTargetConfig()
+	cfg.TargetVolatility = 0.6
+	v, err := NewVolTargetLeverage(cfg, source)
+	require.NoError(t, err)
+
+	vol, err := v.AnnualizedVolatility("BTCUSDT")
+	require.NoError(t, err)
+	assert.InDelta(t, 0.002*math.Sqrt(8760), vol, 0.01)
+
+	// 低波动：0.6 / 0.187 ≈ 3.2 → 3x
+	lev, err := v.Leverage("BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, 3, lev)
+
+	// 高波动：0.6 / 0.936 < 1 → 限制为最小杠杆
+	lev, err = v.Leverage("DOGEUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, 1, lev)
+
+	// 目标波动率很高时限制为最大杠杆
+	cfg.TargetVolatility = 10
+	high, err := NewVolTargetLeverage(cfg, source)
+	require.NoError(t, err)
+	lev, err = high.Leverage("BTCUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, cfg.MaxLeverage, lev)
+
+	// 无波动或无K线：拒绝选择杠杆
+	_, err = v.Leverage("FLATUSDT")
+	assert.Error(t, err)
+	_, err = v.Leverage("ETHUSDT")
+	assert.Error(t, err)
+}
+
+func TestVolTargetLeverage_ATRMethod(t *testing.T) {
+	cfg := DefaultVolTargetConfig()
+	cfg.Method = VolTargetATR
+	cfg.Lookback = 14
+	v, err := NewVolTargetLeverage(cfg, staticKlineSource{"SOLUSDT": risingKlines(60, 100, 1)})
+	require.NoError(t, err)
+
+	vol, err := v.AnnualizedVolatility("SOLUSDT")
+	require.NoError(t, err)
+	klines := risingKlines(60, 100, 1)
+	perBar := market.CalculateATR(klines[len(klines)-29:], 14) / klines[len(klines)-1].Close
+	assert.InDelta(t, perBar*math.Sqrt(8760), vol, 1e-9)
+}
+
+func TestVolTargetTrader_OpenUsesTargetLeverage(t *testing.T) {
+	mt := newMockTrader()
+	v, err := NewVolTargetLeverage(DefaultVolTargetConfig(), staticKlineSource{"BTCUSDT": oscillatingKlines(100, 0.002)})
+	require.NoError(t, err)
+	vt := v.Trader(mt)
+
+	// 调用方传入的杠杆被目标杠杆替代
+	_, err = vt.OpenLong("BTCUSDT", 0.1, 20)
+	require.NoError(t, err)
+	// 调用方杠杆作为上限
+	_, err = vt.OpenShort("BTCUSDT", 0.1, 2)
+	require.NoError(t, err)
+
+	assert.Equal(t, 3, mt.ordersOf("open_long")[0].Leverage)
+	assert.Equal(t, 2, mt.ordersOf("open_short")[0].Leverage)
+
+	// 无法计算波动率时不下单
+	_, err = vt.OpenLong("ETHUSDT", 0.1, 5)
+	assert.Error(t, err)
+	assert.Len(t, mt.ordersOf("open_long"), 1)
+}
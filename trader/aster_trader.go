@@ -150,6 +150,15 @@ func (t *AsterTrader) getPrecision(symbol string) (SymbolPrecision, error) {
 	return SymbolPrecision{}, fmt.Errorf("未找到交易对 %s 的精度信息", symbol)
 }
 
+// QuantityStep 返回交易对的数量步进值（用于按金额下单时向下取整）
+func (t *AsterTrader) QuantityStep(symbol string) (float64, error) {
+	prec, err := t.getPrecision(symbol)
+	if err != nil {
+		return 0, err
+	}
+	return prec.StepSize, nil
+}
+
 // roundToTickSize 将价格/数量四舍五入到tick size/step size的整数倍
 func roundToTickSize(value float64, tickSize float64) float64 {
 	if tickSize <= 0 {
@@ -2,6 +2,7 @@ package trader
 
 import (
 	"nofx/decision"
+	"nofx/logger"
 	"testing"
 )
 
@@ -540,3 +541,63 @@ func TestIntegration_AutoCloseWorkflow(t *testing.T) {
 		t.Errorf("Expected no closed positions in cycle 3, got %d", len(closedPositions3))
 	}
 }
+
+// TestJournalClosedTrade_ActiveCloseUsesMarkedPrice tests that an AI close recorded before the
+// position disappears is journaled with its own price and reason instead of the inferred ones
+func TestJournalClosedTrade_ActiveCloseUsesMarkedPrice(t *testing.T) {
+	journal, err := logger.NewTradeJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTradeJournal: %v", err)
+	}
+	at := &AutoTrader{journal: journal}
+
+	at.rememberJournalEntry("BTCUSDT_long", "pin bar 反转", 49000)
+	at.markJournalClose("BTCUSDT_long", 52000, "ai_close")
+	pos := decision.PositionInfo{Symbol: "BTCUSDT", Side: "long", EntryPrice: 50000, MarkPrice: 51000, Quantity: 0.1, StopLoss: 50500}
+	at.journalClosedTrade(pos, 51000, "unknown")
+
+	entries, err := journal.Query(logger.JournalQuery{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 journal entry, got %d (%v)", len(entries), err)
+	}
+	entry := entries[0]
+	if entry.ExitPrice != 52000 || entry.CloseReason != "ai_close" {
+		t.Errorf("Expected marked exit 52000/ai_close, got %.2f/%s", entry.ExitPrice, entry.CloseReason)
+	}
+	if entry.Signal != "pin bar 反转" || entry.RMultiple != 2 {
+		t.Errorf("Expected initial-stop R of 2 with signal, got R=%.2f signal=%q", entry.RMultiple, entry.Signal)
+	}
+	if len(at.positionJournalInfo) != 0 {
+		t.Errorf("Expected journal info to be cleared, got %v", at.positionJournalInfo)
+	}
+}
+
+// TestJournalClosedTrade_AttachesSignalCharts tests that charts dumped for a symbol's strong signal
+// are attached to the journal entry of the position opened afterwards
+func TestJournalClosedTrade_AttachesSignalCharts(t *testing.T) {
+	journal, err := logger.NewTradeJournal(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewTradeJournal: %v", err)
+	}
+	at := &AutoTrader{journal: journal, signalChartPaths: map[string][]string{
+		"ETHUSDT": {"charts/ETHUSDT_1h_engulfing_1.svg"},
+		"BTCUSDT": {"charts/BTCUSDT_1h_bearish_pin_bar_1.svg"},
+	}}
+
+	at.rememberJournalEntry("ETHUSDT_short", "engulfing", 3100)
+	if _, ok := at.signalChartPaths["ETHUSDT"]; ok {
+		t.Errorf("Expected ETHUSDT charts to be consumed on open")
+	}
+	at.journalClosedTrade(decision.PositionInfo{Symbol: "ETHUSDT", Side: "short", EntryPrice: 3000, Quantity: 1}, 2900, "take_profit")
+
+	entries, err := journal.Query(logger.JournalQuery{})
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("Expected 1 journal entry, got %d (%v)", len(entries), err)
+	}
+	if len(entries[0].Charts) != 1 || entries[0].Charts[0] != "charts/ETHUSDT_1h_engulfing_1.svg" {
+		t.Errorf("Expected ETHUSDT chart attached, got %v", entries[0].Charts)
+	}
+	if len(at.signalChartPaths["BTCUSDT"]) != 1 {
+		t.Errorf("Expected other symbols' charts to be kept, got %v", at.signalChartPaths)
+	}
+}
@@ -1,6 +1,7 @@
 package trader
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
@@ -40,8 +41,17 @@ type AutoTraderConfig struct {
 	AsterPrivateKey string // Aster API钱包私钥
 
 	// Backpack配置
-	BackpackAPIKey     string // Backpack API Key
-	BackpackPrivateKey string // Backpack ED25519私钥 (base64编码)
+	BackpackAPIKey     string              // Backpack API Key
+	BackpackPrivateKey string              // Backpack ED25519私钥 (base64编码)
+	BackpackThrottle   ThrottleConfig      // Backpack 账户级下单/撤单频率限制（零值表示不限制）
+	BackpackRateLimit  RateLimitConfig     // Backpack 请求令牌桶限流（零值表示不限流）
+	BackpackRetry      RetryPolicy         // Backpack 请求失败重试（零值表示不重试）
+	BackpackCache      ResponseCacheConfig // Backpack 幂等 GET 响应缓存（nil 使用默认配置，空 map 表示不缓存）
+	BackpackChaos      ChaosConfig         // Backpack 请求故障注入，仅用于测试重试与保护逻辑（零值表示不注入）
+	BackpackAccountWS  bool                // 启用 Backpack 账户 WebSocket 推送（近实时权益）
+	BackpackStopOffset float64             // Backpack 止损触发后限价单的让价百分比（0 表示触发后市价成交）
+	BackpackSubaccount int                 // Backpack 子账户ID，所有请求在该子账户下执行（0 表示主账户）
+	BackpackProxy      string              // Backpack 代理地址（http/https/socks5，REST 和账户推送都经过代理；空表示使用环境变量）
 
 	CoinPoolAPIURL string
 
@@ -70,6 +80,63 @@ type AutoTraderConfig struct {
 	MaxDrawdown     float64       // 最大回撤百分比（提示）
 	StopTradingTime time.Duration // 触发风控后暂停时长
 
+	// 持仓成本预估（按资金费率和预期持仓时长计入风险回报比，零值表示不计入）
+	CarryCost decision.CarryCostConfig
+
+	// 余额与保证金告警
+	BalanceAlerts BalanceAlertConfig
+
+	// 账户对账：定期按成交、资金费、手续费和充提推算权益并与交易所比对（零值表示不启用，目前仅支持Backpack）
+	StatementReconcile StatementReconcilerConfig
+
+	// 资金桶：多个交易员共用一个交易所账户时，按 CapitalBucket 资金桶的权益计算仓位并单独统计盈亏（空表示不使用）
+	CapitalBucket  string
+	CapitalBuckets CapitalBucketSource
+
+	// 信号止损模型（按检测器和时间周期选择，未配置时使用影线极值外固定百分比）
+	StopModels market.StopModelsConfig
+
+	// 高周期趋势过滤：丢弃与高周期EMA方向相反的信号（零值表示不启用）
+	TrendFilter market.TrendFilterConfig
+
+	// 信号强度校准文件（nofx calibrate 生成），按历史前瞻收益替换各检测器手工设定的基础强度（空表示不使用）
+	ConfidenceCalibrationFile string
+
+	// 信号检测和止损计算使用的K线价格来源：last（默认）/mark/mid，Backpack 盘口较薄时用 mark 或 mid 避免插针止损
+	// K线缓存全局共享，多个交易员配置不同来源时以最后创建的为准
+	CandleSource market.CandleSource
+
+	// 元数据标签（策略名、实验ID、配置哈希等）：写入每笔订单的决策记录和每个持仓的交易日志，并附在告警中，
+	// 用于并行运行不同配置做A/B对比
+	Metadata map[string]string
+
+	// 反向强信号减仓：持仓出现高强度反向信号时减仓或平仓（零值表示不启用）
+	OpposingSignal OpposingSignalConfig
+
+	// 按信号周期/检测器限制同时持仓数量（零值表示不限制）
+	TradeLimits TradeLimitsConfig
+
+	// 止损后冷却：同一交易对同一方向在若干根K线内禁止再次开仓（零值表示不启用）
+	StopCooldown StopCooldownConfig
+
+	// 开仓后止损止盈下单失败的重试、告警与紧急平仓（零值表示只尝试一次，失败时告警）
+	ProtectionRetry ProtectionRetryConfig
+
+	// 两阶段入场：开仓→确认成交→挂止损止盈，保护单失败时平仓回滚（Enabled=false 时使用 ProtectionRetry 流程）
+	TwoPhaseEntry TwoPhaseEntryConfig
+
+	// 交易日历：屏蔽期内禁止开仓，可选放宽持仓止损（零值表示不启用）
+	Blackout BlackoutConfig
+
+	// 数据源与执行交易所的价格偏离保护（零值表示不检查）
+	DivergenceGuard DivergenceGuardConfig
+
+	// 波动熔断：ATR/实现波动率急剧放大或出现极端1分钟K线时暂停开仓，可选收紧持仓止损（零值表示不启用）
+	VolatilityCircuit VolatilityCircuitConfig
+
+	// 信号图输出目录：强信号触发时输出K线图（SVG文件 + 告警中的ASCII图），并附加到交易日志；为空表示不输出
+	SignalChartDir string
+
 	// 仓位模式
 	IsCrossMargin bool // true=全仓模式, false=逐仓模式
 
@@ -90,7 +157,9 @@ type AutoTrader struct {
 	config                AutoTraderConfig
 	trader                Trader // 使用Trader接口（支持多平台）
 	mcpClient             *mcp.Client
-	decisionLogger        *logger.DecisionLogger // 决策日志记录器
+	decisionLogger        *logger.DecisionLogger    // 决策日志记录器
+	journal               *logger.TradeJournal      // 交易日志（每笔平仓自动生成条目）
+	signalCharts          *market.SignalChartDumper // 信号图输出（nil 表示不输出）
 	initialBalance        float64
 	dailyPnL              float64
 	customPrompt          string   // 自定义交易策略prompt
@@ -101,21 +170,59 @@ type AutoTrader struct {
 	lastResetTime         time.Time
 	stopUntil             time.Time
 	isRunning             bool
-	startTime             time.Time          // 系统启动时间
-	callCount             int                // AI调用次数
-	positionFirstSeenTime map[string]int64                 // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
-	lastPositions         map[string]decision.PositionInfo // 上一次周期的持仓快照 (用于检测被动平仓)
-	positionStopLoss      map[string]float64               // 持仓止损价格 (symbol_side -> stop_loss_price)
-	positionTakeProfit    map[string]float64               // 持仓止盈价格 (symbol_side -> take_profit_price)
-	stopMonitorCh         chan struct{}                    // 用于停止监控goroutine
-	monitorWg             sync.WaitGroup                   // 用于等待监控goroutine结束
-	peakPnLCache          map[string]float64               // 最高收益缓存 (symbol -> 峰值盈亏百分比)
-	peakPnLCacheMutex     sync.RWMutex                     // 缓存读写锁
-	lastBalanceSyncTime   time.Time                        // 上次余额同步时间
-	database              interface{}                      // 数据库引用（用于自动更新余额）
-	userID                string                           // 用户ID
-	klineCache            *market.KlineCache               // K线缓存
-	signalDetector        *market.SignalDetector           // 信号检测器
+	startTime             time.Time                          // 系统启动时间
+	callCount             int                                // AI调用次数
+	positionFirstSeenTime map[string]int64                   // 持仓首次出现时间 (symbol_side -> timestamp毫秒)
+	lastPositions         map[string]decision.PositionInfo   // 上一次周期的持仓快照 (用于检测被动平仓)
+	positionJournalInfo   map[string]journalEntryInfo        // 开仓理由与初始止损 (symbol_side -> 信息，平仓时写入交易日志)
+	signalChartPaths      map[string][]string                // 最近强信号的图文件 (symbol -> 路径，开仓时附加到交易日志)
+	positionStopLoss      map[string]float64                 // 持仓止损价格 (symbol_side -> stop_loss_price)
+	positionTakeProfit    map[string]float64                 // 持仓止盈价格 (symbol_side -> take_profit_price)
+	blackoutStops         map[string]float64                 // 屏蔽期内被放宽止损的原止损价格 (symbol_side -> stop_loss_price)
+	stopCooldowns         map[string]time.Time               // 止损后冷却结束时间 (symbol_side -> 时间)
+	volatilityHalts       map[string]volatilityHalt          // 波动熔断中的交易对 (symbol -> 熔断状态)
+	volatilityStops       map[string]string                  // 本次熔断已收紧止损的持仓 (symbol_side -> symbol)
+	minuteKlines          minuteKlineFunc                    // 获取1分钟K线（波动熔断检查极端K线时设置）
+	recentSignals         map[string][]*market.TradingSignal // 本周期的强信号 (symbol -> 信号)
+	positionSignals       map[string]tradeSignal             // 持仓归属的开仓信号 (symbol_side -> 信号)
+	opposingHandled       map[string]string                  // 已处理的反向信号 (symbol_side -> 信号标识)
+	lastExposure          *NetExposure                       // 最近一个周期的净敞口
+	exposureMu            sync.RWMutex                       // 净敞口快照读写锁
+	stopMonitorCh         chan struct{}                      // 用于停止监控goroutine
+	monitorWg             sync.WaitGroup                     // 用于等待监控goroutine结束
+	peakPnLCache          map[string]float64                 // 最高收益缓存 (symbol -> 峰值盈亏百分比)
+	peakPnLCacheMutex     sync.RWMutex                       // 缓存读写锁
+	lastBalanceSyncTime   time.Time                          // 上次余额同步时间
+	database              interface{}                        // 数据库引用（用于自动更新余额）
+	userID                string                             // 用户ID
+	klineCache            *market.KlineCache                 // K线缓存
+	signalDetector        *market.SignalDetector             // 信号检测器
+	statusMonitor         *ExchangeStatusMonitor             // 交易所状态监控（交易所支持时启用）
+	balanceAlerter        *BalanceAlerter                    // 余额与保证金告警（配置后启用）
+	positionReconciler    *PositionReconciler                // 外部持仓变化检测
+	statementReconciler   *StatementReconciler               // 账户对账（配置且交易所支持时启用）
+	cycleMu               sync.Mutex                         // 决策周期与人工干预互斥，保证内部状态一致
+}
+
+// CapitalBucketSource 按资金桶包装交易器（由 strategy.CapitalBuckets 实现，避免 trader 包依赖 strategy）
+type CapitalBucketSource interface {
+	WrapTrader(name string, t Trader) (Trader, error)
+}
+
+// wrapCapitalBucket 配置了资金桶时返回按该资金桶记账的交易器，否则原样返回
+func wrapCapitalBucket(config AutoTraderConfig, t Trader) (Trader, error) {
+	if config.CapitalBucket == "" {
+		return t, nil
+	}
+	if config.CapitalBuckets == nil {
+		return nil, fmt.Errorf("配置了资金桶 %s 但未提供资金桶管理", config.CapitalBucket)
+	}
+	wrapped, err := config.CapitalBuckets.WrapTrader(config.CapitalBucket, t)
+	if err != nil {
+		return nil, fmt.Errorf("初始化资金桶 %s 失败: %w", config.CapitalBucket, err)
+	}
+	log.Printf("🪣 [%s] 按资金桶 %s 计算仓位和盈亏", config.Name, config.CapitalBucket)
+	return wrapped, nil
 }
 
 // NewAutoTrader 创建自动交易器
@@ -199,14 +306,101 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		}
 	case "backpack":
 		log.Printf("🏦 [%s] 使用Backpack交易", config.Name)
-		trader, err = NewBackpackTrader(config.BackpackAPIKey, config.BackpackPrivateKey, userID)
+		var backpackOpts []BackpackOption
+		if config.BackpackProxy != "" {
+			backpackOpts = append(backpackOpts, WithProxy(config.BackpackProxy))
+		}
+		backpackTrader, err := NewBackpackTrader(config.BackpackAPIKey, config.BackpackPrivateKey, userID, backpackOpts...)
 		if err != nil {
 			return nil, fmt.Errorf("初始化Backpack交易器失败: %w", err)
 		}
+		if config.BackpackThrottle.MaxOrdersPerMinute > 0 || config.BackpackThrottle.MaxCancelsPerMinute > 0 {
+			backpackTrader.SetOrderThrottle(config.BackpackThrottle)
+		}
+		if config.BackpackRateLimit.Enabled() {
+			backpackTrader.SetRateLimit(config.BackpackRateLimit)
+		}
+		if config.BackpackRetry.Enabled() {
+			backpackTrader.SetRetryPolicy(config.BackpackRetry)
+		}
+		if config.BackpackCache != nil {
+			backpackTrader.SetResponseCache(config.BackpackCache)
+		}
+		if config.BackpackChaos.Enabled() {
+			log.Printf("🧪 [%s] 已启用Backpack请求故障注入，请勿用于实盘", config.Name)
+			backpackTrader.SetTransport(NewChaosTransport(config.BackpackChaos, backpackTrader.client.Transport))
+		}
+		if config.BackpackStopOffset > 0 {
+			backpackTrader.SetStopLimitOffset(config.BackpackStopOffset)
+		}
+		if config.BackpackSubaccount > 0 {
+			backpackTrader.SetSubaccount(config.BackpackSubaccount)
+		}
+		if config.BackpackAccountWS {
+			if err := backpackTrader.EnableAccountStream(); err != nil {
+				log.Printf("⚠️ [%s] 启用Backpack账户推送失败，余额将使用REST查询: %v", config.Name, err)
+			}
+		}
+		trader = backpackTrader
 	default:
 		return nil, fmt.Errorf("不支持的交易平台: %s", config.Exchange)
 	}
 
+	// 交易所支持状态查询时启用维护/降级检测
+	var statusMonitor *ExchangeStatusMonitor
+	if source, ok := trader.(ExchangeStatusSource); ok {
+		statusMonitor = NewExchangeStatusMonitor(source)
+	}
+
+	var balanceAlerter *BalanceAlerter
+	if config.BalanceAlerts.Enabled() {
+		balanceAlerter = NewBalanceAlerter(config.BalanceAlerts)
+	}
+
+	var statementReconciler *StatementReconciler
+	if config.StatementReconcile.Enabled() {
+		if backpackTrader, ok := trader.(*BackpackTrader); ok {
+			statementReconciler = NewStatementReconciler(backpackTrader, config.StatementReconcile)
+		} else {
+			log.Printf("⚠️ [%s] %s 暂不支持账户对账，已忽略", config.Name, config.Exchange)
+		}
+	}
+
+	// 交易所状态、对账等需要具体交易器类型的组件创建完成后再包装资金桶
+	if trader, err = wrapCapitalBucket(config, trader); err != nil {
+		return nil, err
+	}
+
+	signalDetector := market.NewSignalDetector()
+	if err := signalDetector.SetStopModels(config.StopModels); err != nil {
+		return nil, fmt.Errorf("止损模型配置无效: %w", err)
+	}
+	if config.TrendFilter.Enabled() {
+		trendFilter, err := market.NewTrendFilter(config.TrendFilter)
+		if err != nil {
+			return nil, fmt.Errorf("趋势过滤配置无效: %w", err)
+		}
+		signalDetector.SetTrendFilter(trendFilter)
+	}
+	if config.ConfidenceCalibrationFile != "" {
+		calibration, err := market.LoadConfidenceCalibration(config.ConfidenceCalibrationFile)
+		if err != nil {
+			return nil, err
+		}
+		if err := signalDetector.SetConfidenceCalibration(calibration); err != nil {
+			return nil, fmt.Errorf("信号强度校准无效: %w", err)
+		}
+		log.Printf("🎯 [%s] 已加载信号强度校准 %d 条（%s）", config.Name, len(calibration.Entries), config.ConfidenceCalibrationFile)
+	}
+	if config.CandleSource != "" {
+		source, err := market.ParseCandleSource(string(config.CandleSource))
+		if err != nil {
+			return nil, err
+		}
+		market.GetKlineCache().SetCandleSource(source)
+		log.Printf("🕯️ [%s] K线价格来源: %s", config.Name, source)
+	}
+
 	// 验证初始金额配置
 	if config.InitialBalance <= 0 {
 		return nil, fmt.Errorf("初始金额必须大于0，请在配置中设置InitialBalance")
@@ -216,6 +410,28 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 	logDir := fmt.Sprintf("decision_logs/%s", config.ID)
 	decisionLogger := logger.NewDecisionLogger(logDir)
 
+	// 初始化交易日志（失败不影响交易）
+	journal, err := logger.NewTradeJournal(fmt.Sprintf("trade_journal/%s", config.ID))
+	if err != nil {
+		log.Printf("⚠️ [%s] 初始化交易日志失败: %v", config.Name, err)
+	}
+
+	var signalCharts *market.SignalChartDumper
+	if config.SignalChartDir != "" {
+		if signalCharts, err = market.NewSignalChartDumper(config.SignalChartDir); err != nil {
+			log.Printf("⚠️ [%s] 初始化信号图输出失败: %v", config.Name, err)
+		}
+	}
+
+	// 波动熔断检查极端1分钟K线时直接请求行情接口（K线缓存不含1分钟周期）
+	var minuteKlines minuteKlineFunc
+	if config.VolatilityCircuit.ExtremeCandlePct > 0 {
+		client := market.NewAPIClient()
+		minuteKlines = func(symbol string, limit int) ([]market.Kline, error) {
+			return client.GetKlines(symbol, "1m", limit)
+		}
+	}
+
 	// 设置默认系统提示词模板
 	systemPromptTemplate := config.SystemPromptTemplate
 	if systemPromptTemplate == "" {
@@ -232,6 +448,7 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		trader:                trader,
 		mcpClient:             mcpClient,
 		decisionLogger:        decisionLogger,
+		journal:               journal,
 		initialBalance:        config.InitialBalance,
 		systemPromptTemplate:  systemPromptTemplate,
 		defaultCoins:          config.DefaultCoins,
@@ -242,6 +459,9 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		isRunning:             false,
 		positionFirstSeenTime: make(map[string]int64),
 		lastPositions:         make(map[string]decision.PositionInfo),
+		positionJournalInfo:   make(map[string]journalEntryInfo),
+		signalCharts:          signalCharts,
+		signalChartPaths:      make(map[string][]string),
 		positionStopLoss:      make(map[string]float64),
 		positionTakeProfit:    make(map[string]float64),
 		stopMonitorCh:         make(chan struct{}),
@@ -251,8 +471,13 @@ func NewAutoTrader(config AutoTraderConfig, database interface{}, userID string)
 		lastBalanceSyncTime:   time.Now(), // 初始化为当前时间
 		database:              database,
 		userID:                userID,
-		klineCache:            market.GetKlineCache(),    // 初始化K线缓存
-		signalDetector:        market.NewSignalDetector(), // 初始化信号检测器
+		klineCache:            market.GetKlineCache(), // 初始化K线缓存
+		signalDetector:        signalDetector,         // 初始化信号检测器
+		statusMonitor:         statusMonitor,
+		balanceAlerter:        balanceAlerter,
+		positionReconciler:    NewPositionReconciler(),
+		statementReconciler:   statementReconciler,
+		minuteKlines:          minuteKlines,
 	}, nil
 }
 
@@ -272,6 +497,12 @@ func (at *AutoTrader) Run() error {
 	// 启动回撤监控
 	at.startDrawdownMonitor()
 
+	// 启动交易所状态监控
+	at.startStatusMonitor()
+
+	// 启动账户对账
+	at.startStatementReconciler()
+
 	// 初始化候选币种的K线缓存
 	candidateCoins, err := at.getCandidateCoins()
 	if err != nil {
@@ -344,6 +575,8 @@ func (at *AutoTrader) Stop() {
 
 // runCycle 运行一个交易周期（使用AI全权决策）
 func (at *AutoTrader) runCycle() error {
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
 	at.callCount++
 
 	log.Print("\n" + strings.Repeat("=", 70) + "\n")
@@ -440,9 +673,18 @@ func (at *AutoTrader) runCycle() error {
 				action.Price,    // 使用推断的平仓价格
 				pnlPct,
 				reasonCN)
+			at.journalClosedTrade(closed, action.Price, action.Error)
+			if action.Error == "stop_loss" {
+				at.recordStopOut(closed.Symbol, closed.Side, time.Now())
+			}
 		}
 	}
 
+	at.updateExposure(ctx.Positions)
+
+	// 交易日历：进入/离开屏蔽期时调整持仓止损
+	at.applyBlackoutStops(ctx.Positions, time.Now())
+
 	log.Print(strings.Repeat("=", 70))
 	for _, coin := range ctx.CandidateCoins {
 		record.CandidateCoins = append(record.CandidateCoins, coin.Symbol)
@@ -453,12 +695,17 @@ func (at *AutoTrader) runCycle() error {
 
 	// 5. 更新K线缓存并检测交易信号
 	log.Println("🔄 更新K线缓存...")
+	candidateSymbols := make([]string, 0, len(ctx.CandidateCoins))
 	for _, coin := range ctx.CandidateCoins {
-		if err := at.klineCache.UpdateSymbol(coin.Symbol); err != nil {
-			log.Printf("⚠️  更新 %s K线缓存失败: %v", coin.Symbol, err)
-		}
+		candidateSymbols = append(candidateSymbols, coin.Symbol)
+	}
+	for symbol, err := range at.klineCache.RefreshSymbols(candidateSymbols) {
+		log.Printf("⚠️  更新 %s K线缓存失败: %v", symbol, err)
 	}
 
+	// 波动熔断：突发行情下暂停开仓并收紧持仓止损
+	at.updateVolatilityCircuit(candidateSymbols, ctx.Positions, time.Now())
+
 	// 检测所有时间周期的交易信号
 	timeFrames := []market.TimeFrame{
 		market.TimeFrame5m,
@@ -485,12 +732,15 @@ func (at *AutoTrader) runCycle() error {
 			log.Printf("   └─ %s %s | %s | 方向:%s | 价格:%.4f | 止损:%.4f | 强度:%d%%",
 				sig.Symbol, sig.TimeFrame, sig.SignalType, sig.Direction, sig.Price, sig.StopLoss, sig.Confidence)
 		}
+		at.dumpSignalCharts(strongSignals)
 	} else if len(allSignals) > 0 {
 		log.Printf("📊 检测到 %d 个信号，但强度不足80%%", len(allSignals))
 	} else {
 		log.Println("⚪ 未检测到交易信号")
 	}
 
+	at.rememberStrongSignals(strongSignals, ctx.Positions)
+
 	// 决策是否调用AI：有强信号或有持仓需要管理
 	shouldCallAI := len(strongSignals) > 0 || ctx.Account.PositionCount > 0
 
@@ -613,6 +863,16 @@ func (at *AutoTrader) runCycle() error {
 		record.Decisions = append(record.Decisions, actionRecord)
 	}
 
+	// 反向强信号：AI决策执行后仍持有的仓位按配置减仓
+	for _, action := range at.reactToOpposingSignals(strongSignals) {
+		if action.Success {
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("✓ %s %s 成功（反向信号）", action.Symbol, action.Action))
+		} else {
+			record.ExecutionLog = append(record.ExecutionLog, fmt.Sprintf("❌ %s %s 失败（反向信号）: %s", action.Symbol, action.Action, action.Error))
+		}
+		record.Decisions = append(record.Decisions, action)
+	}
+
 	// 9. 更新持仓快照（用于下一周期检测被动平仓）
 	at.updatePositionSnapshot(ctx.Positions)
 
@@ -626,10 +886,10 @@ func (at *AutoTrader) runCycle() error {
 
 // buildTradingContext 构建交易上下文
 func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
-	// 1. 获取账户信息
-	balance, err := at.trader.GetBalance()
+	// 1. 获取账户信息和持仓（支持账户快照的交易器并发获取）
+	balance, positions, err := at.accountState()
 	if err != nil {
-		return nil, fmt.Errorf("获取账户余额失败: %w", err)
+		return nil, err
 	}
 
 	// 获取账户字段
@@ -650,11 +910,8 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 	// Total Equity = 钱包余额 + 未实现盈亏
 	totalEquity := totalWalletBalance + totalUnrealizedProfit
 
-	// 2. 获取持仓信息
-	positions, err := at.trader.GetPositions()
-	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
-	}
+	// 2. 持仓信息
+	at.reconcilePositions(positions)
 
 	var positionInfos []decision.PositionInfo
 	totalMarginUsed := 0.0
@@ -754,6 +1011,15 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		marginUsedPct = (totalMarginUsed / totalEquity) * 100
 	}
 
+	if at.balanceAlerter != nil {
+		at.balanceAlerter.Observe(BalanceSnapshot{
+			Equity:           totalEquity,
+			AvailableBalance: availableBalance,
+			UnrealizedPnL:    totalUnrealizedProfit,
+			MarginUsedPct:    marginUsedPct,
+		})
+	}
+
 	// 5. 分析历史表现（最近100个周期，避免长期持仓的交易记录丢失）
 	// 假设每3分钟一个周期，100个周期 = 5小时，足够覆盖大部分交易
 	performance, err := at.decisionLogger.AnalyzePerformance(100)
@@ -783,6 +1049,7 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 		Positions:      positionInfos,
 		CandidateCoins: candidateCoins,
 		Performance:    performance, // 添加历史表现分析
+		CarryCost:      at.config.CarryCost,
 	}
 
 	return ctx, nil
@@ -790,11 +1057,25 @@ func (at *AutoTrader) buildTradingContext() (*decision.Context, error) {
 
 // executeDecisionWithRecord 执行AI决策并记录详细信息
 func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
+	if at.positionReconciler != nil && decision.Action != "hold" && decision.Action != "wait" {
+		at.positionReconciler.MarkBotAction(decision.Symbol)
+	}
+	// 订单元数据：交易员配置的标签 + 调用方为本次订单设置的标签
+	actionRecord.Metadata = mergeMetadata(at.config.Metadata, actionRecord.Metadata)
+
 	switch decision.Action {
-	case "open_long":
-		return at.executeOpenLongWithRecord(decision, actionRecord)
-	case "open_short":
-		return at.executeOpenShortWithRecord(decision, actionRecord)
+	case "open_long", "open_short":
+		side := strings.TrimPrefix(decision.Action, "open_")
+		var err error
+		if side == "long" {
+			err = at.executeOpenLongWithRecord(decision, actionRecord)
+		} else {
+			err = at.executeOpenShortWithRecord(decision, actionRecord)
+		}
+		if err == nil {
+			at.tagPosition(decision.Symbol+"_"+side, actionRecord.Metadata)
+		}
+		return err
 	case "close_long":
 		return at.executeCloseLongWithRecord(decision, actionRecord)
 	case "close_short":
@@ -817,6 +1098,20 @@ func (at *AutoTrader) executeDecisionWithRecord(decision *decision.Decision, act
 func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	log.Printf("  📈 开多仓: %s", decision.Symbol)
 
+	if err := at.checkEntriesAllowed(); err != nil {
+		return err
+	}
+	if err := at.checkStopCooldown(decision.Symbol, "long", time.Now()); err != nil {
+		return err
+	}
+	if err := at.checkVolatilityCircuit(decision.Symbol, time.Now()); err != nil {
+		return err
+	}
+	tradeSig, err := at.checkTradeLimits(decision.Symbol, "long")
+	if err != nil {
+		return err
+	}
+
 	// ⚠️ 验证止盈止损必须提供
 	if decision.StopLoss <= 0 {
 		return fmt.Errorf("❌ 拒绝开仓: 必须设置止损价格（当前stop_loss=%.2f）", decision.StopLoss)
@@ -843,6 +1138,11 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 
 	// 计算数量
 	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	// 信号价格来自币安行情，在其他交易所执行前核对实时价格偏离
+	quantity, err = at.applyDivergenceGuard(decision, "long", marketData.CurrentPrice, quantity)
+	if err != nil {
+		return err
+	}
 	actionRecord.Quantity = quantity
 	actionRecord.Price = marketData.CurrentPrice
 
@@ -879,18 +1179,24 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 
 	// ✅ Backpack专用：使用带保护的开仓方法（分步执行：开仓→等待成交→设置止盈止损）
 	if backpackTrader, ok := at.trader.(*BackpackTrader); ok {
-		err := backpackTrader.OpenLongWithProtection(decision.Symbol, quantity, decision.Leverage, decision.StopLoss, decision.TakeProfit)
+		ctx := WithOrderTag(context.Background(), at.orderTag(decision.Symbol, "long", tradeSig))
+		err := backpackTrader.OpenLongWithProtectionWithContext(ctx, decision.Symbol, quantity, decision.Leverage, decision.StopLoss, decision.TakeProfit)
 		if err != nil {
 			return err
 		}
 		// 记录止损止盈价格
 		at.positionStopLoss[posKey] = decision.StopLoss
 		at.positionTakeProfit[posKey] = decision.TakeProfit
+		at.rememberJournalEntry(posKey, decision.Reasoning, decision.StopLoss)
+		at.rememberTradeSignal(posKey, tradeSig)
 		log.Printf("  ✓ 开仓成功（Backpack专用流程），数量: %.4f", quantity)
 		return nil
 	}
 
 	// ✅ 其他交易所：使用标准流程
+	if at.config.TwoPhaseEntry.Enabled {
+		return at.openTwoPhase(decision, "long", quantity, posKey, tradeSig, actionRecord)
+	}
 	order, err := at.trader.OpenLong(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
 		return err
@@ -902,26 +1208,43 @@ func (at *AutoTrader) executeOpenLongWithRecord(decision *decision.Decision, act
 	}
 
 	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.rememberJournalEntry(posKey, decision.Reasoning, decision.StopLoss)
+	at.rememberTradeSignal(posKey, tradeSig)
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "LONG", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	// 设置止损止盈（失败时按配置重试、告警，必要时紧急平仓）
+	protection := at.placeProtection(decision.Symbol, "LONG", quantity, decision.StopLoss, decision.TakeProfit)
+	if protection.StopLossErr != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", protection.StopLossErr)
 	} else {
 		at.positionStopLoss[posKey] = decision.StopLoss // 记录止损价格
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "LONG", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	if protection.TakeProfitErr != nil {
+		log.Printf("  ⚠ 设置止盈失败: %v", protection.TakeProfitErr)
 	} else {
 		at.positionTakeProfit[posKey] = decision.TakeProfit // 记录止盈价格
 	}
 
-	return nil
+	return protection.protectionError()
 }
 
 // executeOpenShortWithRecord 执行开空仓并记录详细信息
 func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, actionRecord *logger.DecisionAction) error {
 	log.Printf("  📉 开空仓: %s", decision.Symbol)
 
+	if err := at.checkEntriesAllowed(); err != nil {
+		return err
+	}
+	if err := at.checkStopCooldown(decision.Symbol, "short", time.Now()); err != nil {
+		return err
+	}
+	if err := at.checkVolatilityCircuit(decision.Symbol, time.Now()); err != nil {
+		return err
+	}
+	tradeSig, err := at.checkTradeLimits(decision.Symbol, "short")
+	if err != nil {
+		return err
+	}
+
 	// ⚠️ 验证止盈止损必须提供
 	if decision.StopLoss <= 0 {
 		return fmt.Errorf("❌ 拒绝开仓: 必须设置止损价格（当前stop_loss=%.2f）", decision.StopLoss)
@@ -948,6 +1271,11 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 
 	// 计算数量
 	quantity := decision.PositionSizeUSD / marketData.CurrentPrice
+	// 信号价格来自币安行情，在其他交易所执行前核对实时价格偏离
+	quantity, err = at.applyDivergenceGuard(decision, "short", marketData.CurrentPrice, quantity)
+	if err != nil {
+		return err
+	}
 	actionRecord.Quantity = quantity
 	actionRecord.Price = marketData.CurrentPrice
 
@@ -984,18 +1312,24 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 
 	// ✅ Backpack专用：使用带保护的开仓方法（分步执行：开仓→等待成交→设置止盈止损）
 	if backpackTrader, ok := at.trader.(*BackpackTrader); ok {
-		err := backpackTrader.OpenShortWithProtection(decision.Symbol, quantity, decision.Leverage, decision.StopLoss, decision.TakeProfit)
+		ctx := WithOrderTag(context.Background(), at.orderTag(decision.Symbol, "short", tradeSig))
+		err := backpackTrader.OpenShortWithProtectionWithContext(ctx, decision.Symbol, quantity, decision.Leverage, decision.StopLoss, decision.TakeProfit)
 		if err != nil {
 			return err
 		}
 		// 记录止损止盈价格
 		at.positionStopLoss[posKey] = decision.StopLoss
 		at.positionTakeProfit[posKey] = decision.TakeProfit
+		at.rememberJournalEntry(posKey, decision.Reasoning, decision.StopLoss)
+		at.rememberTradeSignal(posKey, tradeSig)
 		log.Printf("  ✓ 开仓成功（Backpack专用流程），数量: %.4f", quantity)
 		return nil
 	}
 
 	// ✅ 其他交易所：使用标准流程
+	if at.config.TwoPhaseEntry.Enabled {
+		return at.openTwoPhase(decision, "short", quantity, posKey, tradeSig, actionRecord)
+	}
 	order, err := at.trader.OpenShort(decision.Symbol, quantity, decision.Leverage)
 	if err != nil {
 		return err
@@ -1007,20 +1341,23 @@ func (at *AutoTrader) executeOpenShortWithRecord(decision *decision.Decision, ac
 	}
 
 	log.Printf("  ✓ 开仓成功，订单ID: %v, 数量: %.4f", order["orderId"], quantity)
+	at.rememberJournalEntry(posKey, decision.Reasoning, decision.StopLoss)
+	at.rememberTradeSignal(posKey, tradeSig)
 
-	// 设置止损止盈
-	if err := at.trader.SetStopLoss(decision.Symbol, "SHORT", quantity, decision.StopLoss); err != nil {
-		log.Printf("  ⚠ 设置止损失败: %v", err)
+	// 设置止损止盈（失败时按配置重试、告警，必要时紧急平仓）
+	protection := at.placeProtection(decision.Symbol, "SHORT", quantity, decision.StopLoss, decision.TakeProfit)
+	if protection.StopLossErr != nil {
+		log.Printf("  ⚠ 设置止损失败: %v", protection.StopLossErr)
 	} else {
 		at.positionStopLoss[posKey] = decision.StopLoss // 记录止损价格
 	}
-	if err := at.trader.SetTakeProfit(decision.Symbol, "SHORT", quantity, decision.TakeProfit); err != nil {
-		log.Printf("  ⚠ 设置止盈失败: %v", err)
+	if protection.TakeProfitErr != nil {
+		log.Printf("  ⚠ 设置止盈失败: %v", protection.TakeProfitErr)
 	} else {
 		at.positionTakeProfit[posKey] = decision.TakeProfit // 记录止盈价格
 	}
 
-	return nil
+	return protection.protectionError()
 }
 
 // executeCloseLongWithRecord 执行平多仓并记录详细信息
@@ -1039,6 +1376,7 @@ func (at *AutoTrader) executeCloseLongWithRecord(decision *decision.Decision, ac
 	if err != nil {
 		return err
 	}
+	at.markJournalClose(decision.Symbol+"_long", actionRecord.Price, "ai_close")
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
@@ -1065,6 +1403,7 @@ func (at *AutoTrader) executeCloseShortWithRecord(decision *decision.Decision, a
 	if err != nil {
 		return err
 	}
+	at.markJournalClose(decision.Symbol+"_short", actionRecord.Price, "ai_close")
 
 	// 记录订单ID
 	if orderID, ok := order["orderId"].(int64); ok {
@@ -1363,7 +1702,9 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 
 	if stopLossPrice > 0 {
 		log.Printf("  → 为剩余仓位 %.4f 设置止损单: %.2f", remainingQuantity, stopLossPrice)
-		err = at.trader.SetStopLoss(decision.Symbol, positionSide, remainingQuantity, stopLossPrice)
+		err = at.retryProtection("止损", func() error {
+			return at.trader.SetStopLoss(decision.Symbol, positionSide, remainingQuantity, stopLossPrice)
+		})
 		if err != nil {
 			log.Printf("  ⚠️ 设置止损失败: %v（不影响平仓结果）", err)
 		} else {
@@ -1384,7 +1725,9 @@ func (at *AutoTrader) executePartialCloseWithRecord(decision *decision.Decision,
 
 	if takeProfitPrice > 0 {
 		log.Printf("  → 为剩余仓位 %.4f 设置止盈单: %.2f", remainingQuantity, takeProfitPrice)
-		err = at.trader.SetTakeProfit(decision.Symbol, positionSide, remainingQuantity, takeProfitPrice)
+		err = at.retryProtection("止盈", func() error {
+			return at.trader.SetTakeProfit(decision.Symbol, positionSide, remainingQuantity, takeProfitPrice)
+		})
 		if err != nil {
 			log.Printf("  ⚠️ 设置止盈失败: %v（不影响平仓结果）", err)
 		} else {
@@ -1456,7 +1799,7 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		aiProvider = "Qwen"
 	}
 
-	return map[string]interface{}{
+	status := map[string]interface{}{
 		"trader_id":       at.id,
 		"trader_name":     at.name,
 		"ai_model":        at.aiModel,
@@ -1471,13 +1814,21 @@ func (at *AutoTrader) GetStatus() map[string]interface{} {
 		"last_reset_time": at.lastResetTime.Format(time.RFC3339),
 		"ai_provider":     aiProvider,
 	}
+	if at.statusMonitor != nil {
+		status["exchange_status"] = string(at.statusMonitor.Status().State)
+	}
+	if exposure := at.exposureSnapshot(); exposure != nil {
+		status["net_delta"] = exposure.NetDelta
+		status["beta_weighted_delta"] = exposure.BetaWeightedDelta
+	}
+	return status
 }
 
 // GetAccountInfo 获取账户信息（用于API）
 func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
-	balance, err := at.trader.GetBalance()
+	balance, positions, err := at.accountState()
 	if err != nil {
-		return nil, fmt.Errorf("获取余额失败: %w", err)
+		return nil, err
 	}
 
 	// 获取账户字段
@@ -1498,12 +1849,7 @@ func (at *AutoTrader) GetAccountInfo() (map[string]interface{}, error) {
 	// Total Equity = 钱包余额 + 未实现盈亏
 	totalEquity := totalWalletBalance + totalUnrealizedProfit
 
-	// 获取持仓计算总保证金
-	positions, err := at.trader.GetPositions()
-	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
-	}
-
+	// 用持仓计算总保证金
 	totalMarginUsed := 0.0
 	totalUnrealizedPnLCalculated := 0.0
 	for _, pos := range positions {
@@ -1753,6 +2099,32 @@ func (at *AutoTrader) startDrawdownMonitor() {
 	}()
 }
 
+// startStatusMonitor 启动交易所状态监控（交易所不支持时跳过）
+func (at *AutoTrader) startStatusMonitor() {
+	if at.statusMonitor == nil {
+		return
+	}
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+		log.Println("🩺 启动交易所状态监控")
+		at.statusMonitor.Run(at.stopMonitorCh)
+		log.Println("⏹ 停止交易所状态监控")
+	}()
+}
+
+// checkEntriesAllowed 交易所维护或降级时拒绝新开仓
+func (at *AutoTrader) checkEntriesAllowed() error {
+	if err := at.checkBlackout(time.Now()); err != nil {
+		return err
+	}
+	if at.statusMonitor == nil || !at.statusMonitor.EntriesPaused() {
+		return nil
+	}
+	status := at.statusMonitor.Status()
+	return fmt.Errorf("❌ 交易所状态异常（%s: %s），暂停开仓", status.State, status.Message)
+}
+
 // 检查持仓回撤情况
 func (at *AutoTrader) checkPositionDrawdown() {
 	// 获取当前持仓
@@ -1808,8 +2180,12 @@ func (at *AutoTrader) checkPositionDrawdown() {
 			drawdownPct = ((peakPnLPct - currentPnLPct) / peakPnLPct) * 100
 		}
 
-		// 检查平仓条件：收益大于5%且回撤超过40%
-		if currentPnLPct > 5.0 && drawdownPct >= 40.0 {
+		// 检查平仓条件：收益大于5%且回撤超过40%（交易所维护/降级时放宽回撤容差）
+		drawdownLimit := 40.0
+		if at.statusMonitor != nil {
+			drawdownLimit = math.Min(drawdownLimit*at.statusMonitor.ToleranceMultiplier(), 100)
+		}
+		if currentPnLPct > 5.0 && drawdownPct >= drawdownLimit {
 			log.Printf("🚨 触发回撤平仓条件: %s %s | 当前收益: %.2f%% | 最高收益: %.2f%% | 回撤: %.2f%%",
 				symbol, side, currentPnLPct, peakPnLPct, drawdownPct)
 
@@ -1831,6 +2207,10 @@ func (at *AutoTrader) checkPositionDrawdown() {
 
 // 紧急平仓函数
 func (at *AutoTrader) emergencyClosePosition(symbol, side string) error {
+	if at.positionReconciler != nil {
+		at.positionReconciler.MarkBotAction(symbol)
+	}
+
 	switch side {
 	case "long":
 		order, err := at.trader.CloseLong(symbol, 0) // 0 = 全部平仓
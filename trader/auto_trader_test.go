@@ -13,6 +13,8 @@ import (
 	"nofx/pool"
 
 	"github.com/agiledragon/gomonkey/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/stretchr/testify/suite"
 )
 
@@ -1386,3 +1388,37 @@ func (s *AutoTraderTestSuite) TestGetPositions_MarginCalculationRegression() {
 		}
 	})
 }
+
+// fakeBucketSource 记录被包装的交易器，按资金桶名称返回包装结果
+type fakeBucketSource struct {
+	wrapped map[string]Trader
+	inner   Trader
+}
+
+func (f *fakeBucketSource) WrapTrader(name string, t Trader) (Trader, error) {
+	f.inner = t
+	if w, ok := f.wrapped[name]; ok {
+		return w, nil
+	}
+	return nil, fmt.Errorf("资金桶不存在: %s", name)
+}
+
+func TestWrapCapitalBucket(t *testing.T) {
+	base := &MockTrader{}
+	bucketView := &MockTrader{}
+	source := &fakeBucketSource{wrapped: map[string]Trader{"breakout": bucketView}}
+
+	got, err := wrapCapitalBucket(AutoTraderConfig{}, base)
+	require.NoError(t, err)
+	assert.Same(t, base, got, "未配置资金桶时不包装")
+
+	got, err = wrapCapitalBucket(AutoTraderConfig{CapitalBucket: "breakout", CapitalBuckets: source}, base)
+	require.NoError(t, err)
+	assert.Same(t, bucketView, got)
+	assert.Same(t, base, source.inner)
+
+	_, err = wrapCapitalBucket(AutoTraderConfig{CapitalBucket: "dca", CapitalBuckets: source}, base)
+	assert.ErrorContains(t, err, "资金桶不存在")
+	_, err = wrapCapitalBucket(AutoTraderConfig{CapitalBucket: "breakout"}, base)
+	assert.Error(t, err, "配置了资金桶但缺少资金桶管理")
+}
@@ -0,0 +1,316 @@
+package trader
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"nofx/market"
+)
+
+const (
+	backpackWSURL = "wss://ws.backpack.exchange"
+
+	// accountStreamMaxAge 余额快照的最长有效期，超过后 GetBalance 回退到 REST
+	accountStreamMaxAge = 60 * time.Second
+	// accountStreamMinRefresh 两次抵押品 REST 刷新的最小间隔（成交事件密集时合并刷新）
+	accountStreamMinRefresh = time.Second
+)
+
+// backpackWSMessage Backpack WebSocket 推送消息
+type backpackWSMessage struct {
+	Stream string          `json:"stream"`
+	Data   json.RawMessage `json:"data"`
+}
+
+// BackpackAccountStream 通过私有 WebSocket 维护近实时的账户权益和持仓，并分发订单和成交推送
+// 抵押品净值由 REST 定期/成交后刷新，刷新之间按持仓推送的未实现盈亏变化实时修正：
+// 权益 = 刷新时净值 + Σ(当前未实现盈亏 - 刷新时未实现盈亏)
+type BackpackAccountStream struct {
+	trader *BackpackTrader
+	url    string
+
+	mu               sync.RWMutex
+	connected        bool
+	netEquity        float64
+	available        float64
+	refreshedAt      time.Time
+	baseUnrealized   map[string]float64 // 刷新时各交易对的未实现盈亏
+	unrealized       map[string]float64 // 推送的最新未实现盈亏
+	lastRefreshStart time.Time
+
+	refreshCh chan struct{}
+	done      chan struct{}
+	stopOnce  sync.Once
+
+	// 订单和成交推送的订阅者（OnOrderUpdate / OrderUpdates）
+	orders orderUpdateHub
+	// 推送维护的持仓（GetPositionsCached）
+	positions positionTracker
+}
+
+// newBackpackAccountStream 创建账户推送（未连接）
+func newBackpackAccountStream(t *BackpackTrader, url string) *BackpackAccountStream {
+	return &BackpackAccountStream{
+		trader:         t,
+		url:            url,
+		baseUnrealized: make(map[string]float64),
+		unrealized:     make(map[string]float64),
+		refreshCh:      make(chan struct{}, 1),
+		done:           make(chan struct{}),
+	}
+}
+
+// EnableAccountStream 启用账户 WebSocket 推送，启用后 GetBalance 优先使用推送维护的权益
+func (t *BackpackTrader) EnableAccountStream() error {
+	if t.accountStream != nil {
+		return nil
+	}
+	if t.subaccountID > 0 {
+		return fmt.Errorf("账户推送不支持子账户 %d", t.subaccountID)
+	}
+	stream := newBackpackAccountStream(t, backpackWSURL)
+	if err := stream.refresh(); err != nil {
+		return fmt.Errorf("初始化账户权益失败: %w", err)
+	}
+	t.accountStream = stream
+	go stream.run()
+	return nil
+}
+
+// DisableAccountStream 关闭账户推送，GetBalance 恢复为每次请求 REST
+func (t *BackpackTrader) DisableAccountStream() {
+	if t.accountStream == nil {
+		return
+	}
+	t.accountStream.Stop()
+	t.accountStream = nil
+}
+
+// Stop 停止推送
+func (s *BackpackAccountStream) Stop() {
+	s.stopOnce.Do(func() { close(s.done) })
+}
+
+// Balance 返回推送维护的余额；未连接或快照过期时返回 false
+func (s *BackpackAccountStream) Balance() (*Balance, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if !s.connected || time.Since(s.refreshedAt) > accountStreamMaxAge {
+		return nil, false
+	}
+
+	var unrealized, drift float64
+	for symbol, pnl := range s.unrealized {
+		unrealized += pnl
+		drift += pnl - s.baseUnrealized[symbol]
+	}
+	return &Balance{
+		NetEquity:          FlexFloat(s.netEquity + drift),
+		NetEquityAvailable: FlexFloat(s.available + drift),
+		PnlUnrealized:      FlexFloat(unrealized),
+	}, true
+}
+
+// run 连接并在断线后重连，同时处理刷新请求和定期刷新
+func (s *BackpackAccountStream) run() {
+	go s.refreshLoop()
+
+	backoff := time.Second
+	for {
+		start := time.Now()
+		err := s.connectAndRead()
+		s.setConnected(false)
+
+		select {
+		case <-s.done:
+			return
+		default:
+		}
+		if time.Since(start) > time.Minute {
+			backoff = time.Second
+		}
+		log.Printf("⚠️ [Backpack] 账户推送断开: %v，%v 后重连", err, backoff)
+		select {
+		case <-s.done:
+			return
+		case <-time.After(backoff):
+		}
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+// refreshLoop 合并刷新请求，并在快照过期前定期刷新
+func (s *BackpackAccountStream) refreshLoop() {
+	ticker := time.NewTicker(accountStreamMaxAge / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-ticker.C:
+		case <-s.refreshCh:
+			s.mu.RLock()
+			wait := accountStreamMinRefresh - time.Since(s.lastRefreshStart)
+			s.mu.RUnlock()
+			if wait > 0 {
+				select {
+				case <-s.done:
+					return
+				case <-time.After(wait):
+				}
+			}
+		}
+		if err := s.refresh(); err != nil {
+			log.Printf("⚠️ [Backpack] 刷新账户权益失败: %v", err)
+		}
+	}
+}
+
+// requestRefresh 请求一次抵押品刷新（非阻塞）
+func (s *BackpackAccountStream) requestRefresh() {
+	select {
+	case s.refreshCh <- struct{}{}:
+	default:
+	}
+}
+
+// refresh 通过 REST 刷新抵押品净值和各持仓未实现盈亏基准
+func (s *BackpackAccountStream) refresh() error {
+	start := time.Now()
+	s.mu.Lock()
+	s.lastRefreshStart = start
+	s.mu.Unlock()
+
+	balance, err := s.trader.fetchBalance(context.Background())
+	if err != nil {
+		return err
+	}
+	positions, err := s.trader.OpenPositions(context.Background())
+	if err != nil {
+		return err
+	}
+
+	base := make(map[string]float64, len(positions))
+	for _, pos := range positions {
+		base[market.Normalize(pos.Symbol)] += pos.PnlUnrealized.Float64()
+	}
+	s.positions.reset(positions, start)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.netEquity = balance.TotalWalletBalance()
+	s.available = balance.AvailableBalance()
+	s.refreshedAt = time.Now()
+	s.baseUnrealized = base
+	s.unrealized = make(map[string]float64, len(base))
+	for symbol, pnl := range base {
+		s.unrealized[symbol] = pnl
+	}
+	return nil
+}
+
+func (s *BackpackAccountStream) setConnected(connected bool) {
+	s.mu.Lock()
+	s.connected = connected
+	s.mu.Unlock()
+}
+
+// subscribeMessage 构造带签名的私有频道订阅消息
+func (s *BackpackAccountStream) subscribeMessage() map[string]interface{} {
+	timestamp := time.Now().UnixMilli()
+	window := backpackSignatureWindow
+	signingString := buildSigningString("subscribe", nil, nil, timestamp, window)
+	signature := ed25519.Sign(s.trader.privateKey, []byte(signingString))
+	return map[string]interface{}{
+		"method": "SUBSCRIBE",
+		"params": []string{"account.positionUpdate", "account.orderUpdate"},
+		"signature": []string{
+			s.trader.apiKey,
+			base64.StdEncoding.EncodeToString(signature),
+			strconv.FormatInt(timestamp, 10),
+			strconv.FormatInt(window, 10),
+		},
+	}
+}
+
+// connectAndRead 建立连接、订阅并持续读取推送，直到出错或停止
+func (s *BackpackAccountStream) connectAndRead() error {
+	conn, _, err := s.trader.wsDialer().Dial(s.url, nil)
+	if err != nil {
+		return fmt.Errorf("连接失败: %w", err)
+	}
+	defer conn.Close()
+
+	closed := make(chan struct{})
+	defer close(closed)
+	go func() {
+		select {
+		case <-s.done:
+			conn.Close()
+		case <-closed:
+		}
+	}()
+
+	if err := conn.WriteJSON(s.subscribeMessage()); err != nil {
+		return fmt.Errorf("订阅失败: %w", err)
+	}
+	s.setConnected(true)
+	// 重连期间可能漏掉推送，连接后立即刷新一次
+	s.requestRefresh()
+	log.Printf("🔌 [Backpack] 账户推送已连接")
+
+	for {
+		_, payload, err := conn.ReadMessage()
+		if err != nil {
+			return err
+		}
+		s.handleMessage(payload)
+	}
+}
+
+// handleMessage 处理一条推送消息
+func (s *BackpackAccountStream) handleMessage(payload []byte) {
+	var msg backpackWSMessage
+	if err := json.Unmarshal(payload, &msg); err != nil || msg.Stream == "" {
+		return
+	}
+
+	switch msg.Stream {
+	case "account.positionUpdate":
+		var update backpackPositionUpdate
+		if err := json.Unmarshal(msg.Data, &update); err != nil {
+			return
+		}
+		symbol := market.Normalize(update.Symbol)
+		s.mu.Lock()
+		if update.closed() {
+			s.unrealized[symbol] = 0
+		} else {
+			s.unrealized[symbol] = update.PnlUnrealized.Float64()
+		}
+		s.mu.Unlock()
+		s.positions.apply(update, time.Now())
+		// 开平仓会改变已实现盈亏和占用保证金，需要刷新抵押品
+		if update.Event != "positionAdjusted" {
+			s.requestRefresh()
+		}
+	case "account.orderUpdate":
+		var update OrderUpdate
+		if err := json.Unmarshal(msg.Data, &update); err != nil {
+			return
+		}
+		if update.IsFill() {
+			s.requestRefresh()
+		}
+		s.orders.publish(update)
+	}
+}
@@ -0,0 +1,262 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// backpackMaxBatchOrders 单次批量下单请求的最大订单数，超出时拆分为多个请求
+	backpackMaxBatchOrders = 20
+	// backpackBatchFallbackWorkers 批量接口不可用时并发逐个下单的最大并发数
+	backpackBatchFallbackWorkers = 4
+)
+
+// OrderRequest 批量下单中的一个订单
+type OrderRequest struct {
+	Symbol       string  // 标准格式交易对（如 "BTCUSDT"）
+	Side         string  // "buy"/"sell"（也接受 "Bid"/"Ask"）
+	Quantity     float64 // 下单数量
+	Price        float64 // 限价（0 表示市价单）
+	TriggerPrice float64 // 触发价（0 表示普通订单），用于批量挂止损止盈
+	Options      LimitOrderOptions
+	ClientID     uint32 // 0 表示按 ctx 中的归因信息自动生成
+}
+
+// OrderResult 批量下单中一个订单的结果，与请求一一对应
+type OrderResult struct {
+	Request OrderRequest
+	Order   *Order // 失败时为 nil
+	Err     error
+}
+
+// batchItemResponse 批量接口返回数组中的一项：成功时为订单，失败时带错误码
+type batchItemResponse struct {
+	Order
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// orderData 构建单个订单的请求参数
+func (t *BackpackTrader) orderData(ctx context.Context, req OrderRequest) (map[string]string, error) {
+	side, err := orderSide(req.Side)
+	if err != nil {
+		return nil, err
+	}
+	if req.Quantity <= 0 || req.Price < 0 || req.TriggerPrice < 0 {
+		return nil, fmt.Errorf("无效的订单: 数量=%.8f 价格=%.8f 触发价=%.8f", req.Quantity, req.Price, req.TriggerPrice)
+	}
+
+	backpackSymbol := t.mapSymbol(req.Symbol)
+	qtyStr, err := t.FormatQuantityWithContext(ctx, backpackSymbol, req.Quantity)
+	if err != nil {
+		log.Printf("⚠️ [Backpack] 格式化数量失败，使用默认精度: %v", err)
+		qtyStr = formatFloat(req.Quantity, 8)
+	}
+	pricePrecision := 2
+	if precision, err := t.getSymbolPrecision(ctx, backpackSymbol); err == nil {
+		pricePrecision = precision.PricePrecision
+	}
+
+	data := map[string]string{
+		"symbol":    backpackSymbol,
+		"side":      side,
+		"orderType": "Market",
+	}
+	if req.TriggerPrice > 0 {
+		data["triggerPrice"] = formatFloat(req.TriggerPrice, pricePrecision)
+		data["triggerQuantity"] = qtyStr
+	} else {
+		data["quantity"] = qtyStr
+	}
+	if req.Price > 0 {
+		tif, err := req.Options.validate()
+		if err != nil {
+			return nil, err
+		}
+		data["orderType"] = "Limit"
+		data["price"] = formatFloat(req.Price, pricePrecision)
+		data["timeInForce"] = string(tif)
+		if req.Options.PostOnly {
+			data["postOnly"] = "true"
+		}
+	}
+	if req.Options.ReduceOnly {
+		data["reduceOnly"] = "true"
+	}
+	if req.ClientID != 0 {
+		ctx = WithClientID(ctx, req.ClientID)
+	}
+	tagOrder(ctx, data)
+	return data, nil
+}
+
+// PlaceOrders 批量下单，返回与请求一一对应的结果（单个订单失败不影响其他订单）
+// 优先使用 Backpack 批量下单接口（POST /api/v1/orders，每批最多20个）；接口不可用时改为有限并发逐个下单
+func (t *BackpackTrader) PlaceOrders(reqs []OrderRequest) []OrderResult {
+	return t.PlaceOrdersWithContext(context.Background(), reqs)
+}
+
+// PlaceOrdersWithContext 同 PlaceOrders，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) PlaceOrdersWithContext(ctx context.Context, reqs []OrderRequest) []OrderResult {
+	results := make([]OrderResult, len(reqs))
+	var pending []int
+	var items []map[string]string
+	for i, req := range reqs {
+		results[i].Request = req
+		data, err := t.orderData(ctx, req)
+		if err != nil {
+			results[i].Err = err
+			continue
+		}
+		pending = append(pending, i)
+		items = append(items, data)
+	}
+	if len(items) == 0 {
+		return results
+	}
+
+	log.Printf("📤 [Backpack] 批量下单: %d 个订单", len(items))
+	for start := 0; start < len(items); start += backpackMaxBatchOrders {
+		end := start + backpackMaxBatchOrders
+		if end > len(items) {
+			end = len(items)
+		}
+		t.placeBatch(ctx, items[start:end], results, pending[start:end])
+	}
+	t.invalidatePositionCache()
+
+	failed := 0
+	for _, r := range results {
+		if r.Err != nil {
+			failed++
+		}
+	}
+	log.Printf("✓ [Backpack] 批量下单完成: 成功 %d，失败 %d", len(reqs)-failed, failed)
+	return results
+}
+
+// placeBatch 提交一批订单，结果写入 results[index[i]]
+func (t *BackpackTrader) placeBatch(ctx context.Context, items []map[string]string, results []OrderResult, index []int) {
+	if !t.batchUnsupported.Load() {
+		orders, err := t.sendBatchOrders(ctx, items)
+		var apiErr *APIError
+		switch {
+		case err == nil:
+			for i, item := range orders {
+				results[index[i]].Order, results[index[i]].Err = item.result()
+			}
+			return
+		case errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed):
+			log.Printf("⚠️ [Backpack] 批量下单接口不可用 (HTTP %d)，改为并发逐个下单", apiErr.StatusCode)
+			t.batchUnsupported.Store(true)
+		default:
+			// 请求结果不确定（可能部分已提交），不再逐个重发
+			for _, i := range index {
+				results[i].Err = fmt.Errorf("批量下单失败: %w", err)
+			}
+			return
+		}
+	}
+
+	sem := make(chan struct{}, backpackBatchFallbackWorkers)
+	var wg sync.WaitGroup
+	for i, data := range items {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, data map[string]string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			order, err := t.requestOrder(ctx, "POST", "/api/v1/order", nil, data)
+			if err == nil && order.ID == "" {
+				err = fmt.Errorf("下单响应缺少订单ID")
+			}
+			if err != nil {
+				results[index[i]].Err = fmt.Errorf("下单失败: %w", err)
+				return
+			}
+			results[index[i]].Order = order
+		}(i, data)
+	}
+	wg.Wait()
+}
+
+// result 转换为订单或错误
+func (r *batchItemResponse) result() (*Order, error) {
+	if r.ID == "" {
+		if r.Code == "" && r.Message == "" {
+			return nil, fmt.Errorf("下单响应缺少订单ID")
+		}
+		return nil, fmt.Errorf("下单失败: %s %s", r.Code, r.Message)
+	}
+	order := r.Order
+	return &order, nil
+}
+
+// sendBatchOrders 签名并发送批量下单请求，返回与 items 一一对应的结果
+// 与单个下单一样只在明确未被处理的限频错误时重试
+func (t *BackpackTrader) sendBatchOrders(ctx context.Context, items []map[string]string) ([]batchItemResponse, error) {
+	const instruction = "orderExecute"
+	if id := t.subaccountFor(ctx); id > 0 {
+		tagged := make([]map[string]string, len(items))
+		for i, item := range items {
+			tagged[i] = withSubaccountField(item, id)
+		}
+		items = tagged
+	}
+	body := make([]map[string]interface{}, len(items))
+	for i, item := range items {
+		body[i] = requestBodyFields(item)
+	}
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("序列化请求体失败: %w", err)
+	}
+
+	resp, err := t.withRetry(ctx, instruction, func() (*http.Response, error) {
+		if t.throttle != nil {
+			for range items {
+				if err := t.throttle.AcquireContext(ctx, ThrottleOrder); err != nil {
+					return nil, err
+				}
+			}
+		}
+		timestamp := time.Now().UnixMilli()
+		headers := t.signHeaders(buildBatchSigningString(instruction, items, timestamp, backpackSignatureWindow),
+			timestamp, backpackSignatureWindow)
+		req, err := http.NewRequestWithContext(ctx, "POST", strings.TrimSuffix(t.baseURL, "/")+"/api/v1/orders",
+			strings.NewReader(string(jsonData)))
+		if err != nil {
+			return nil, fmt.Errorf("创建请求失败: %w", err)
+		}
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		return t.doRequest(req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("读取响应失败: %w", err)
+	}
+	var orders []batchItemResponse
+	if err := json.Unmarshal(raw, &orders); err != nil {
+		return nil, fmt.Errorf("解析批量下单响应失败: %w, 响应: %s", err, string(raw))
+	}
+	if len(orders) != len(items) {
+		return nil, fmt.Errorf("批量下单响应数量不符: 请求 %d 个，返回 %d 个", len(items), len(orders))
+	}
+	return orders, nil
+}
@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpackTrader_PlaceOrdersBatch(t *testing.T) {
+	var trader *BackpackTrader
+	var batches int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" || r.URL.Path != "/api/v1/orders" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&batches, 1)
+
+		decoder := json.NewDecoder(r.Body)
+		decoder.UseNumber()
+		var body []map[string]interface{}
+		require.NoError(t, decoder.Decode(&body))
+
+		// 按签名规则还原每个订单的字符串参数并验签
+		items := make([]map[string]string, len(body))
+		for i, order := range body {
+			items[i] = make(map[string]string, len(order))
+			for k, v := range order {
+				items[i][k] = fmt.Sprint(v)
+			}
+		}
+		var timestamp, window int64
+		fmt.Sscan(r.Header.Get("X-TIMESTAMP"), &timestamp)
+		fmt.Sscan(r.Header.Get("X-WINDOW"), &window)
+		publicKey := base64.StdEncoding.EncodeToString([]byte(trader.privateKey.Public().(ed25519.PublicKey)))
+		assert.NoError(t, VerifyBackpackSignature(publicKey,
+			buildBatchSigningString("orderExecute", items, timestamp, window), r.Header.Get("X-SIGNATURE")))
+		assert.Equal(t, true, body[0]["postOnly"], "布尔字段以JSON布尔值发送")
+
+		resp := make([]map[string]interface{}, len(body))
+		for i, order := range body {
+			if order["symbol"] == "ETH_USDC_PERP" {
+				resp[i] = map[string]interface{}{"code": "INSUFFICIENT_MARGIN", "message": "Insufficient margin"}
+				continue
+			}
+			resp[i] = map[string]interface{}{"id": fmt.Sprint(i + 1), "symbol": order["symbol"], "status": "New",
+				"clientId": order["clientId"]}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+	trader = newTestBackpackTrader(t, server.URL)
+
+	results := trader.PlaceOrders([]OrderRequest{
+		{Symbol: "SOLUSDT", Side: "buy", Quantity: 1, Price: 140, Options: LimitOrderOptions{PostOnly: true}, ClientID: 42},
+		{Symbol: "ETHUSDT", Side: "sell", Quantity: 0.5, Price: 3000},
+		{Symbol: "BTCUSDT", Side: "sideways", Quantity: 0.1},
+		{Symbol: "BTCUSDT", Side: "sell", Quantity: 0.1, TriggerPrice: 58000, Options: LimitOrderOptions{ReduceOnly: true}},
+	})
+	require.Len(t, results, 4)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&batches), "有效订单在一个请求中提交")
+
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, "1", results[0].Order.ID)
+	assert.Equal(t, json.Number("42"), results[0].Order.ClientID)
+	assert.Equal(t, "SOLUSDT", results[0].Request.Symbol)
+
+	require.Error(t, results[1].Err)
+	assert.Contains(t, results[1].Err.Error(), "INSUFFICIENT_MARGIN")
+	assert.Nil(t, results[1].Order)
+
+	require.Error(t, results[2].Err, "参数无效的订单不提交")
+	assert.Nil(t, results[2].Order)
+
+	require.NoError(t, results[3].Err)
+	assert.Equal(t, "BTC_USDC_PERP", results[3].Order.Symbol)
+}
+
+func TestBackpackTrader_PlaceOrdersFallback(t *testing.T) {
+	exchange := newFakeBackpackExchange()
+	var batchCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" && r.URL.Path == "/api/v1/orders" {
+			atomic.AddInt32(&batchCalls, 1)
+		}
+		exchange.ServeHTTP(w, r)
+	}))
+	defer server.Close()
+	trader := newTestBackpackTrader(t, server.URL)
+
+	reqs := make([]OrderRequest, 10)
+	for i := range reqs {
+		reqs[i] = OrderRequest{Symbol: "SOLUSDT", Side: "buy", Quantity: 0.1, Price: float64(100 + i)}
+	}
+	reqs[9].Symbol = "DOGEUSDT"
+
+	results := trader.PlaceOrdersWithContext(context.Background(), reqs)
+	require.Len(t, results, 10)
+	ids := make(map[string]bool)
+	for i, r := range results[:9] {
+		require.NoError(t, r.Err, "订单 %d", i)
+		ids[r.Order.ID] = true
+	}
+	assert.Len(t, ids, 9)
+	assert.Error(t, results[9].Err, "交易所拒绝的订单单独报错")
+
+	// 记住批量接口不可用，不再尝试
+	results = trader.PlaceOrders(reqs[:1])
+	require.NoError(t, results[0].Err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&batchCalls))
+}
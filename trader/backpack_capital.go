@@ -0,0 +1,178 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Deposit 充值记录（/wapi/v1/capital/deposits）
+type Deposit struct {
+	ID              json.Number `json:"id"`
+	ToAddress       string      `json:"toAddress"`
+	FromAddress     string      `json:"fromAddress"`
+	Source          string      `json:"source"` // 区块链名称或 internal 等
+	Status          string      `json:"status"` // pending/confirmed/...
+	TransactionHash string      `json:"transactionHash"`
+	Symbol          string      `json:"symbol"`
+	Quantity        FlexFloat   `json:"quantity"`
+	CreatedAt       string      `json:"createdAt"`
+}
+
+// Time 充值时间
+func (d Deposit) Time() time.Time {
+	t, _ := parseBackpackTime(d.CreatedAt)
+	return t
+}
+
+// WithdrawalRequest 提现请求
+type WithdrawalRequest struct {
+	Address        string  // 提现地址
+	Blockchain     string  // 区块链，如 Solana、Ethereum
+	Symbol         string  // 币种，如 USDC
+	Quantity       float64 // 提现数量
+	TwoFactorToken string  // 账户开启提现二次验证时必填
+}
+
+// Withdrawal 提现记录（/wapi/v1/capital/withdrawals）
+type Withdrawal struct {
+	ID              json.Number `json:"id"`
+	Blockchain      string      `json:"blockchain"`
+	Identifier      string      `json:"identifier"`
+	Quantity        FlexFloat   `json:"quantity"`
+	Fee             FlexFloat   `json:"fee"`
+	Symbol          string      `json:"symbol"`
+	Status          string      `json:"status"`
+	ToAddress       string      `json:"toAddress"`
+	TransactionHash string      `json:"transactionHash"`
+	CreatedAt       string      `json:"createdAt"`
+}
+
+// Time 提现时间
+func (w Withdrawal) Time() time.Time {
+	t, _ := parseBackpackTime(w.CreatedAt)
+	return t
+}
+
+// GetDepositAddress 获取某条链的充值地址
+func (t *BackpackTrader) GetDepositAddress(blockchain string) (string, error) {
+	return t.GetDepositAddressWithContext(context.Background(), blockchain)
+}
+
+// GetDepositAddressWithContext 同 GetDepositAddress，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetDepositAddressWithContext(ctx context.Context, blockchain string) (string, error) {
+	if blockchain == "" {
+		return "", fmt.Errorf("区块链不能为空")
+	}
+	var result struct {
+		Address string `json:"address"`
+	}
+	if err := t.requestJSON(ctx, "GET", "/wapi/v1/capital/deposit/address", map[string]string{"blockchain": blockchain}, nil, &result); err != nil {
+		return "", fmt.Errorf("获取充值地址失败: %w", err)
+	}
+	if result.Address == "" {
+		return "", fmt.Errorf("获取充值地址失败: 交易所未返回 %s 地址", blockchain)
+	}
+	return result.Address, nil
+}
+
+// GetDeposits 自动翻页获取 [from, to] 内的充值记录（零值表示不限）
+func (t *BackpackTrader) GetDeposits(from, to time.Time) ([]Deposit, error) {
+	return t.GetDepositsWithContext(context.Background(), from, to)
+}
+
+// GetDepositsWithContext 同 GetDeposits，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetDepositsWithContext(ctx context.Context, from, to time.Time) ([]Deposit, error) {
+	params := map[string]string{}
+	if !from.IsZero() {
+		params["from"] = strconv.FormatInt(from.UnixMilli(), 10)
+	}
+	if !to.IsZero() {
+		params["to"] = strconv.FormatInt(to.UnixMilli(), 10)
+	}
+	deposits, err := collectHistory[Deposit](ctx, t, "/wapi/v1/capital/deposits", params, 0)
+	if err != nil {
+		return deposits, fmt.Errorf("获取充值记录失败: %w", err)
+	}
+	return deposits, nil
+}
+
+// GetWithdrawals 自动翻页获取 [from, to] 内的提现记录（零值表示不限）
+func (t *BackpackTrader) GetWithdrawals(from, to time.Time) ([]Withdrawal, error) {
+	return t.GetWithdrawalsWithContext(context.Background(), from, to)
+}
+
+// GetWithdrawalsWithContext 同 GetWithdrawals，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetWithdrawalsWithContext(ctx context.Context, from, to time.Time) ([]Withdrawal, error) {
+	params := map[string]string{}
+	if !from.IsZero() {
+		params["from"] = strconv.FormatInt(from.UnixMilli(), 10)
+	}
+	if !to.IsZero() {
+		params["to"] = strconv.FormatInt(to.UnixMilli(), 10)
+	}
+	withdrawals, err := collectHistory[Withdrawal](ctx, t, "/wapi/v1/capital/withdrawals", params, 0)
+	if err != nil {
+		return withdrawals, fmt.Errorf("获取提现记录失败: %w", err)
+	}
+	return withdrawals, nil
+}
+
+// RequestWithdrawal 发起提现（如定期划出利润）
+// 提现结果不确定时不会自动重试，失败后应先查询提现记录确认，避免重复提现；
+// 启用提现保护（SetWithdrawalGuard）时，签名发出请求前先检查白名单、每日上限并等待人工确认
+func (t *BackpackTrader) RequestWithdrawal(req WithdrawalRequest) (*Withdrawal, error) {
+	return t.RequestWithdrawalWithContext(context.Background(), req)
+}
+
+// RequestWithdrawalWithContext 同 RequestWithdrawal，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) RequestWithdrawalWithContext(ctx context.Context, req WithdrawalRequest) (*Withdrawal, error) {
+	if req.Address == "" || req.Blockchain == "" || req.Symbol == "" {
+		return nil, fmt.Errorf("提现需要地址、区块链和币种")
+	}
+	if req.Quantity <= 0 {
+		return nil, fmt.Errorf("无效的提现数量: %v", req.Quantity)
+	}
+	if guard := t.withdrawalGuard; guard != nil {
+		guard.mu.Lock()
+		defer guard.mu.Unlock()
+		if err := t.checkWithdrawal(ctx, guard, req); err != nil {
+			log.Printf("🛑 [Backpack] %v", err)
+			return nil, err
+		}
+	}
+
+	data := map[string]string{
+		"address":    req.Address,
+		"blockchain": req.Blockchain,
+		"symbol":     req.Symbol,
+		"quantity":   strconv.FormatFloat(req.Quantity, 'f', -1, 64),
+	}
+	if req.TwoFactorToken != "" {
+		data["twoFactorToken"] = req.TwoFactorToken
+	}
+
+	log.Printf("💸 [Backpack] 发起提现: %s %s → %s (%s)", data["quantity"], req.Symbol, req.Address, req.Blockchain)
+	var withdrawal Withdrawal
+	if err := t.requestJSON(ctx, "POST", "/wapi/v1/capital/withdrawals", nil, data, &withdrawal); err != nil {
+		return nil, fmt.Errorf("提现失败: %w", err)
+	}
+	log.Printf("✓ [Backpack] 提现已提交: ID=%s 状态=%s", withdrawal.ID, withdrawal.Status)
+	return &withdrawal, nil
+}
+
+// requestJSON 发送认证请求并把响应解析到 out
+func (t *BackpackTrader) requestJSON(ctx context.Context, method, endpoint string, params, data map[string]string, out interface{}) error {
+	resp, err := t.sendAuthenticatedRequest(ctx, method, endpoint, params, data)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析响应失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package trader
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// verifyCapitalSignature 按请求参数重建签名字符串并验证签名头
+func verifyCapitalSignature(t *testing.T, tr *BackpackTrader, r *http.Request, instruction string, fields map[string]string) {
+	t.Helper()
+	timestamp, _ := strconv.ParseInt(r.Header.Get("X-TIMESTAMP"), 10, 64)
+	window, _ := strconv.ParseInt(r.Header.Get("X-WINDOW"), 10, 64)
+	signingString := buildSigningString(instruction, fields, nil, timestamp, window)
+	publicKey := base64.StdEncoding.EncodeToString(tr.privateKey.Public().(ed25519.PublicKey))
+	assert.NoError(t, VerifyBackpackSignature(publicKey, signingString, r.Header.Get("X-SIGNATURE")), instruction)
+}
+
+func TestBackpackTrader_DepositsAndWithdrawal(t *testing.T) {
+	var tr *BackpackTrader
+	var withdrawals int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wapi/v1/capital/deposit/address":
+			verifyCapitalSignature(t, tr, r, "depositAddressQuery", map[string]string{"blockchain": r.URL.Query().Get("blockchain")})
+			w.Write([]byte(`{"address":"SoLAddr111"}`))
+		case "GET /wapi/v1/capital/deposits":
+			query := map[string]string{}
+			for k := range r.URL.Query() {
+				query[k] = r.URL.Query().Get(k)
+			}
+			verifyCapitalSignature(t, tr, r, "depositQueryAll", query)
+			w.Write([]byte(`[{"id":7,"toAddress":"SoLAddr111","source":"solana","status":"confirmed","symbol":"USDC","quantity":"250.5","createdAt":"2024-05-01T10:00:00"}]`))
+		case "POST /wapi/v1/capital/withdrawals":
+			withdrawals++
+			var body map[string]string
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			verifyCapitalSignature(t, tr, r, "withdraw", body)
+			assert.Equal(t, "100.25", body["quantity"])
+			assert.Equal(t, "123456", body["twoFactorToken"])
+			if withdrawals > 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			w.Write([]byte(`{"id":99,"blockchain":"Solana","quantity":"100.25","fee":"0.5","symbol":"USDC","status":"pending","toAddress":"ExtAddr"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr = newTestBackpackTrader(t, server.URL)
+
+	address, err := tr.GetDepositAddress("Solana")
+	require.NoError(t, err)
+	assert.Equal(t, "SoLAddr111", address)
+
+	deposits, err := tr.GetDeposits(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), time.Time{})
+	require.NoError(t, err)
+	require.Len(t, deposits, 1)
+	assert.Equal(t, "USDC", deposits[0].Symbol)
+	assert.InDelta(t, 250.5, deposits[0].Quantity.Float64(), 1e-9)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), deposits[0].Time())
+
+	req := WithdrawalRequest{Address: "ExtAddr", Blockchain: "Solana", Symbol: "USDC", Quantity: 100.25, TwoFactorToken: "123456"}
+	withdrawal, err := tr.RequestWithdrawal(req)
+	require.NoError(t, err)
+	assert.Equal(t, "pending", withdrawal.Status)
+	assert.InDelta(t, 0.5, withdrawal.Fee.Float64(), 1e-9)
+
+	// 提现结果不确定（5xx）时不重试，避免重复提现
+	tr.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	_, err = tr.RequestWithdrawal(req)
+	assert.Error(t, err)
+	assert.Equal(t, 2, withdrawals)
+
+	_, err = tr.RequestWithdrawal(WithdrawalRequest{Address: "ExtAddr", Blockchain: "Solana", Symbol: "USDC"})
+	assert.Error(t, err)
+	assert.Equal(t, 2, withdrawals, "参数无效时不发出请求")
+}
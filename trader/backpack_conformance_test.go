@@ -0,0 +1,305 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/require"
+)
+
+// backpackKnownDeviations Backpack 当前不满足的一致性检查项
+var backpackKnownDeviations = map[string]string{
+	ConformanceCancelSeparately: "CancelStopLossOrders/CancelTakeProfitOrders 会撤销该交易对的全部挂单",
+}
+
+type fakeBackpackOrder struct {
+	ID           string
+	Symbol       string
+	Side         string
+	OrderType    string
+	Quantity     float64
+	Price        float64
+	TriggerPrice float64
+}
+
+// fakeBackpackExchange 内存中的 Backpack 模拟交易所：市价单和可立即成交的限价单按最新价成交，
+// 其余限价单和触发单挂在订单簿上；持仓按净持仓计算
+type fakeBackpackExchange struct {
+	mu        sync.Mutex
+	prices    map[string]float64 // 交易所格式交易对 -> 最新价
+	steps     map[string]string  // 交易所格式交易对 -> 数量步进
+	positions map[string]float64 // 交易所格式交易对 -> 净持仓
+	orders    []*fakeBackpackOrder
+	nextID    int
+	failNext  int
+}
+
+func newFakeBackpackExchange() *fakeBackpackExchange {
+	return &fakeBackpackExchange{
+		prices:    map[string]float64{"SOL_USDC_PERP": 150, "BTC_USDC_PERP": 60000},
+		steps:     map[string]string{"SOL_USDC_PERP": "0.01", "BTC_USDC_PERP": "0.0001"},
+		positions: make(map[string]float64),
+	}
+}
+
+// failNextRequest 让下一次请求返回 status
+func (f *fakeBackpackExchange) failNextRequest(status int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.failNext = status
+}
+
+// stopOrders 按挂单价格相对最新价的位置统计止损/止盈单
+func (f *fakeBackpackExchange) stopOrders(symbol string) (stopLoss, takeProfit int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	symbol = market.ConvertToBackpackSymbol(symbol)
+	last := f.prices[symbol]
+	for _, o := range f.orders {
+		if o.Symbol != symbol {
+			continue
+		}
+		ref := o.Price
+		if o.TriggerPrice > 0 {
+			ref = o.TriggerPrice
+		}
+		if (o.Side == "Ask" && ref < last) || (o.Side == "Bid" && ref > last) {
+			stopLoss++
+		} else {
+			takeProfit++
+		}
+	}
+	return stopLoss, takeProfit, nil
+}
+
+func (f *fakeBackpackExchange) writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func (f *fakeBackpackExchange) invalidSymbol(w http.ResponseWriter, symbol string) {
+	f.writeJSON(w, http.StatusBadRequest, map[string]string{"code": "INVALID_SYMBOL", "message": "Invalid symbol " + symbol})
+}
+
+func (f *fakeBackpackExchange) orderJSON(o *fakeBackpackOrder, status string, executed float64) map[string]interface{} {
+	return map[string]interface{}{
+		"id":                    o.ID,
+		"symbol":                o.Symbol,
+		"side":                  o.Side,
+		"orderType":             o.OrderType,
+		"status":                status,
+		"quantity":              strconv.FormatFloat(o.Quantity, 'f', -1, 64),
+		"price":                 strconv.FormatFloat(o.Price, 'f', -1, 64),
+		"triggerPrice":          strconv.FormatFloat(o.TriggerPrice, 'f', -1, 64),
+		"executedQuantity":      strconv.FormatFloat(executed, 'f', -1, 64),
+		"executedQuoteQuantity": strconv.FormatFloat(executed*f.prices[o.Symbol], 'f', -1, 64),
+	}
+}
+
+func (f *fakeBackpackExchange) fill(symbol, side string, qty float64) {
+	if side == "Bid" {
+		f.positions[symbol] += qty
+	} else {
+		f.positions[symbol] -= qty
+	}
+}
+
+func (f *fakeBackpackExchange) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if status := f.failNext; status != 0 {
+		f.failNext = 0
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+		f.writeJSON(w, status, map[string]string{"code": "INJECTED", "message": http.StatusText(status)})
+		return
+	}
+
+	var body map[string]interface{}
+	if r.Body != nil {
+		json.NewDecoder(r.Body).Decode(&body)
+	}
+	bodySymbol, _ := body["symbol"].(string)
+
+	switch r.Method + " " + r.URL.Path {
+	case "GET /api/v1/markets":
+		var markets []map[string]interface{}
+		for symbol, step := range f.steps {
+			markets = append(markets, map[string]interface{}{
+				"symbol": symbol,
+				"filters": map[string]interface{}{
+					"price":    map[string]string{"tickSize": "0.01"},
+					"quantity": map[string]string{"stepSize": step},
+				},
+			})
+		}
+		f.writeJSON(w, http.StatusOK, markets)
+
+	case "GET /api/v1/ticker":
+		symbol := r.URL.Query().Get("symbol")
+		price, ok := f.prices[symbol]
+		if !ok {
+			f.invalidSymbol(w, symbol)
+			return
+		}
+		f.writeJSON(w, http.StatusOK, map[string]string{"symbol": symbol, "lastPrice": strconv.FormatFloat(price, 'f', -1, 64)})
+
+	case "GET /api/v1/capital/collateral":
+		f.writeJSON(w, http.StatusOK, map[string]string{"netEquity": "10000", "netEquityAvailable": "9000", "pnlUnrealized": "0"})
+
+	case "GET /api/v1/position":
+		positions := []map[string]string{}
+		for symbol, qty := range f.positions {
+			if qty == 0 {
+				continue
+			}
+			price := strconv.FormatFloat(f.prices[symbol], 'f', -1, 64)
+			positions = append(positions, map[string]string{
+				"symbol":      symbol,
+				"netQuantity": strconv.FormatFloat(qty, 'f', -1, 64),
+				"entryPrice":  price,
+				"markPrice":   price,
+			})
+		}
+		f.writeJSON(w, http.StatusOK, positions)
+
+	case "GET /api/v1/orders":
+		orders := []map[string]interface{}{}
+		for _, o := range f.orders {
+			orders = append(orders, f.orderJSON(o, "New", 0))
+		}
+		f.writeJSON(w, http.StatusOK, orders)
+
+	case "POST /api/v1/order":
+		last, ok := f.prices[bodySymbol]
+		if !ok {
+			f.invalidSymbol(w, bodySymbol)
+			return
+		}
+		f.nextID++
+		o := &fakeBackpackOrder{ID: strconv.Itoa(f.nextID), Symbol: bodySymbol}
+		o.Side, _ = body["side"].(string)
+		o.OrderType, _ = body["orderType"].(string)
+		o.Quantity, _ = strconv.ParseFloat(fmt.Sprint(body["quantity"]), 64)
+		o.Price, _ = strconv.ParseFloat(fmt.Sprint(body["price"]), 64)
+		o.TriggerPrice, _ = strconv.ParseFloat(fmt.Sprint(body["triggerPrice"]), 64)
+
+		// 只减仓单：没有反向持仓或数量超过持仓时拒单
+		if reduceOnly, _ := body["reduceOnly"].(bool); reduceOnly {
+			pos := f.positions[bodySymbol]
+			if (o.Side == "Ask" && o.Quantity > pos) || (o.Side == "Bid" && o.Quantity > -pos) {
+				f.writeJSON(w, http.StatusBadRequest, map[string]string{"code": "INVALID_ORDER", "message": "Reduce only order not reduced"})
+				return
+			}
+		}
+
+		marketable := o.OrderType == "Market" ||
+			(o.Side == "Bid" && o.Price >= last) || (o.Side == "Ask" && o.Price <= last)
+		if o.TriggerPrice > 0 || !marketable {
+			f.orders = append(f.orders, o)
+			status := "New"
+			if o.TriggerPrice > 0 {
+				status = "TriggerPending"
+			}
+			f.writeJSON(w, http.StatusOK, f.orderJSON(o, status, 0))
+			return
+		}
+
+		f.fill(bodySymbol, o.Side, o.Quantity)
+		// 开仓单附带的止盈止损挂为反向触发单
+		closeSide := "Ask"
+		if o.Side == "Ask" {
+			closeSide = "Bid"
+		}
+		for _, key := range []string{"stopLossTriggerPrice", "takeProfitTriggerPrice"} {
+			if trigger, err := strconv.ParseFloat(fmt.Sprint(body[key]), 64); err == nil && trigger > 0 {
+				f.nextID++
+				f.orders = append(f.orders, &fakeBackpackOrder{ID: strconv.Itoa(f.nextID), Symbol: bodySymbol,
+					Side: closeSide, OrderType: "Market", Quantity: o.Quantity, TriggerPrice: trigger})
+			}
+		}
+		f.writeJSON(w, http.StatusOK, f.orderJSON(o, "Filled", o.Quantity))
+
+	case "DELETE /api/v1/orders":
+		if bodySymbol == "" {
+			f.writeJSON(w, http.StatusBadRequest, map[string]string{"code": "INVALID_CLIENT_REQUEST", "message": "symbol required"})
+			return
+		}
+		cancelled := []map[string]interface{}{}
+		kept := f.orders[:0]
+		for _, o := range f.orders {
+			if o.Symbol == bodySymbol {
+				cancelled = append(cancelled, f.orderJSON(o, "Cancelled", 0))
+				continue
+			}
+			kept = append(kept, o)
+		}
+		f.orders = kept
+		f.writeJSON(w, http.StatusOK, cancelled)
+
+	case "DELETE /api/v1/order":
+		orderID, _ := body["orderId"].(string)
+		for i, o := range f.orders {
+			if o.ID == orderID {
+				f.orders = append(f.orders[:i], f.orders[i+1:]...)
+				f.writeJSON(w, http.StatusOK, f.orderJSON(o, "Cancelled", 0))
+				return
+			}
+		}
+		f.writeJSON(w, http.StatusNotFound, map[string]string{"code": "RESOURCE_NOT_FOUND", "message": "Order not found"})
+
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func TestBackpackTrader_Conformance(t *testing.T) {
+	exchange := newFakeBackpackExchange()
+	server := httptest.NewServer(exchange)
+	defer server.Close()
+
+	RunConformance(t, newTestBackpackTrader(t, server.URL), ConformanceConfig{
+		Symbol:          "SOLUSDT",
+		Quantity:        0.5,
+		Leverage:        5,
+		StopOrders:      exchange.stopOrders,
+		InjectError:     exchange.failNextRequest,
+		KnownDeviations: backpackKnownDeviations,
+	})
+}
+
+// TestBackpackTrader_ConformanceLive 对真实账户运行一致性测试（会真实下单，默认跳过）
+// BACKPACK_CONFORMANCE_API_KEY / BACKPACK_CONFORMANCE_PRIVATE_KEY: 账户密钥
+// BACKPACK_CONFORMANCE_URL: 可选，替换API地址
+// BACKPACK_CONFORMANCE_SYMBOL / BACKPACK_CONFORMANCE_QTY: 可选，默认 SOLUSDT / 0.1
+func TestBackpackTrader_ConformanceLive(t *testing.T) {
+	apiKey, privateKey := os.Getenv("BACKPACK_CONFORMANCE_API_KEY"), os.Getenv("BACKPACK_CONFORMANCE_PRIVATE_KEY")
+	if apiKey == "" || privateKey == "" {
+		t.Skip("未设置 BACKPACK_CONFORMANCE_API_KEY / BACKPACK_CONFORMANCE_PRIVATE_KEY")
+	}
+	trader, err := NewBackpackTrader(apiKey, privateKey, "conformance")
+	require.NoError(t, err)
+	if url := os.Getenv("BACKPACK_CONFORMANCE_URL"); url != "" {
+		trader.baseURL = url
+	}
+
+	cfg := ConformanceConfig{Symbol: "SOLUSDT", Quantity: 0.1, Leverage: 1, KnownDeviations: backpackKnownDeviations}
+	if symbol := os.Getenv("BACKPACK_CONFORMANCE_SYMBOL"); symbol != "" {
+		cfg.Symbol = symbol
+	}
+	if qty, err := strconv.ParseFloat(os.Getenv("BACKPACK_CONFORMANCE_QTY"), 64); err == nil && qty > 0 {
+		cfg.Quantity = qty
+	}
+	RunConformance(t, trader, cfg)
+}
@@ -0,0 +1,178 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// BookLevel 订单簿档位
+type BookLevel struct {
+	Price    float64
+	Quantity float64
+}
+
+// OrderBook 订单簿快照（Bids 价格从高到低，Asks 价格从低到高）
+type OrderBook struct {
+	Symbol    string
+	Bids      []BookLevel
+	Asks      []BookLevel
+	UpdatedAt time.Time
+}
+
+// BestBid 最优买价（无买盘返回0）
+func (b *OrderBook) BestBid() float64 {
+	if len(b.Bids) == 0 {
+		return 0
+	}
+	return b.Bids[0].Price
+}
+
+// BestAsk 最优卖价（无卖盘返回0）
+func (b *OrderBook) BestAsk() float64 {
+	if len(b.Asks) == 0 {
+		return 0
+	}
+	return b.Asks[0].Price
+}
+
+// Spread 买卖价差（任一侧为空时返回0）
+func (b *OrderBook) Spread() float64 {
+	if len(b.Bids) == 0 || len(b.Asks) == 0 {
+		return 0
+	}
+	return b.BestAsk() - b.BestBid()
+}
+
+// Imbalance 前 levels 档的买卖量失衡度，范围 [-1, 1]
+// 正值表示买盘更厚，负值表示卖盘更厚；levels<=0 时统计全部档位
+func (b *OrderBook) Imbalance(levels int) float64 {
+	bidQty := sumLevels(b.Bids, levels)
+	askQty := sumLevels(b.Asks, levels)
+	if bidQty+askQty == 0 {
+		return 0
+	}
+	return (bidQty - askQty) / (bidQty + askQty)
+}
+
+func sumLevels(levels []BookLevel, n int) float64 {
+	if n <= 0 || n > len(levels) {
+		n = len(levels)
+	}
+	total := 0.0
+	for _, level := range levels[:n] {
+		total += level.Quantity
+	}
+	return total
+}
+
+// GetOrderBook 获取订单簿深度
+func (t *BackpackTrader) GetOrderBook(symbol string) (*OrderBook, error) {
+	return t.GetOrderBookWithContext(context.Background(), symbol)
+}
+
+// GetOrderBookWithContext 同 GetOrderBook，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetOrderBookWithContext(ctx context.Context, symbol string) (*OrderBook, error) {
+	backpackSymbol := t.mapSymbol(symbol)
+	resp, err := t.makePublicRequest(ctx, "GET", "/api/v1/depth", map[string]string{
+		"symbol": backpackSymbol,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取订单簿失败: %w", err)
+	}
+
+	depth, ok := resp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("订单簿响应格式错误")
+	}
+
+	bids, err := parseBookLevels(depth["bids"])
+	if err != nil {
+		return nil, fmt.Errorf("解析买盘失败: %w", err)
+	}
+	asks, err := parseBookLevels(depth["asks"])
+	if err != nil {
+		return nil, fmt.Errorf("解析卖盘失败: %w", err)
+	}
+	sort.Slice(bids, func(i, j int) bool { return bids[i].Price > bids[j].Price })
+	sort.Slice(asks, func(i, j int) bool { return asks[i].Price < asks[j].Price })
+
+	return &OrderBook{
+		Symbol:    backpackSymbol,
+		Bids:      bids,
+		Asks:      asks,
+		UpdatedAt: time.Now(),
+	}, nil
+}
+
+// parseBookLevels 解析 [["price","quantity"], ...] 格式的档位
+func parseBookLevels(raw interface{}) ([]BookLevel, error) {
+	if raw == nil {
+		return nil, nil
+	}
+	items, ok := raw.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("档位格式错误")
+	}
+
+	levels := make([]BookLevel, 0, len(items))
+	for _, item := range items {
+		pair, ok := item.([]interface{})
+		if !ok || len(pair) < 2 {
+			return nil, fmt.Errorf("档位格式错误: %v", item)
+		}
+		priceStr, _ := pair[0].(string)
+		qtyStr, _ := pair[1].(string)
+		price, err := strconv.ParseFloat(priceStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("解析价格失败: %w", err)
+		}
+		qty, err := strconv.ParseFloat(qtyStr, 64)
+		if err != nil {
+			return nil, fmt.Errorf("解析数量失败: %w", err)
+		}
+		levels = append(levels, BookLevel{Price: price, Quantity: qty})
+	}
+	return levels, nil
+}
+
+// DepthSource 订单簿数据源
+type DepthSource interface {
+	GetOrderBook(symbol string) (*OrderBook, error)
+}
+
+// DepthCache 按交易对缓存订单簿快照，ttl 内重复请求直接返回缓存
+type DepthCache struct {
+	source DepthSource
+	ttl    time.Duration
+	books  map[string]*OrderBook
+	mu     sync.Mutex
+}
+
+// NewDepthCache 创建订单簿缓存
+func NewDepthCache(source DepthSource, ttl time.Duration) *DepthCache {
+	return &DepthCache{
+		source: source,
+		ttl:    ttl,
+		books:  make(map[string]*OrderBook),
+	}
+}
+
+// Get 获取订单簿（缓存过期时重新拉取）
+func (c *DepthCache) Get(symbol string) (*OrderBook, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if book, ok := c.books[symbol]; ok && time.Since(book.UpdatedAt) < c.ttl {
+		return book, nil
+	}
+	book, err := c.source.GetOrderBook(symbol)
+	if err != nil {
+		return nil, err
+	}
+	c.books[symbol] = book
+	return book, nil
+}
@@ -0,0 +1,142 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// EntryTimeoutAction 限价开仓单超时未成交时的处理方式
+type EntryTimeoutAction int
+
+const (
+	// EntryTimeoutCancel 撤销未成交部分
+	EntryTimeoutCancel EntryTimeoutAction = iota
+	// EntryTimeoutReprice 撤销后按最新价重新挂单（最多 MaxReprices 次），用尽后撤销
+	EntryTimeoutReprice
+)
+
+// LimitEntryOptions 限价开仓选项
+// 超时时长取 Timeout 与 Candles*CandleInterval 中较大者；两者都为0时不设超时
+type LimitEntryOptions struct {
+	Timeout        time.Duration      // 按时间计的超时
+	Candles        int                // 按K线根数计的超时
+	CandleInterval time.Duration      // K线周期（与 Candles 配合使用）
+	Action         EntryTimeoutAction // 超时处理方式
+	MaxReprices    int                // EntryTimeoutReprice 时的最大重挂次数
+	StopLoss       float64            // 止损触发价（0 表示不设置）
+	TakeProfit     float64            // 止盈触发价（0 表示不设置）
+	ExpireAt       time.Time          // 绝对到期时间（GTT，零值表示不设置），到期未成交部分撤销且不再重挂
+}
+
+// timeout 计算超时时长
+func (o LimitEntryOptions) timeout() time.Duration {
+	timeout := o.Timeout
+	if byCandles := time.Duration(o.Candles) * o.CandleInterval; byCandles > timeout {
+		timeout = byCandles
+	}
+	return timeout
+}
+
+// OpenLongLimit 以限价单开多仓，超时未成交时按 opts 自动撤单或重挂
+// 立即返回已创建的订单，超时检查在后台执行
+func (t *BackpackTrader) OpenLongLimit(symbol string, quantity, price float64, opts LimitEntryOptions) (map[string]interface{}, error) {
+	log.Printf("🟢 [Backpack] 限价开多仓: %s 数量=%.4f 价格=%.4f 超时=%v", symbol, quantity, price, opts.timeout())
+	return t.placeLimitEntry(symbol, "Bid", quantity, price, opts)
+}
+
+// OpenShortLimit 以限价单开空仓，超时未成交时按 opts 自动撤单或重挂
+// 立即返回已创建的订单，超时检查在后台执行
+func (t *BackpackTrader) OpenShortLimit(symbol string, quantity, price float64, opts LimitEntryOptions) (map[string]interface{}, error) {
+	log.Printf("🔴 [Backpack] 限价开空仓: %s 数量=%.4f 价格=%.4f 超时=%v", symbol, quantity, price, opts.timeout())
+	return t.placeLimitEntry(symbol, "Ask", quantity, price, opts)
+}
+
+// placeLimitEntry 挂限价开仓单并登记超时检查
+func (t *BackpackTrader) placeLimitEntry(symbol, side string, quantity, price float64, opts LimitEntryOptions) (map[string]interface{}, error) {
+	if !opts.ExpireAt.IsZero() && !time.Now().Before(opts.ExpireAt) {
+		return nil, fmt.Errorf("开仓单已过期: %s", opts.ExpireAt.Format(time.RFC3339))
+	}
+
+	backpackSymbol := t.mapSymbol(symbol)
+	order, err := t.createOrder(context.Background(), backpackSymbol, side, "Limit", quantity, &price, opts.StopLoss, opts.TakeProfit, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if !opts.ExpireAt.IsZero() {
+		t.expiry.Schedule(backpackSymbol, order.ID, opts.ExpireAt)
+	}
+
+	if timeout := opts.timeout(); timeout > 0 {
+		time.AfterFunc(timeout, func() {
+			t.handleEntryTimeout(backpackSymbol, order.ID, opts)
+		})
+	}
+	return order.toMap(), nil
+}
+
+// handleEntryTimeout 处理超时的限价开仓单
+func (t *BackpackTrader) handleEntryTimeout(symbol, orderID string, opts LimitEntryOptions) {
+	order, err := t.GetOrder(symbol, orderID)
+	if err == nil {
+		switch order.Status {
+		case "Filled", "Cancelled", "Expired", "Rejected":
+			return
+		}
+	} else {
+		// 查询失败时仍尝试撤单，宁可多撤一次也不留下过期挂单
+		log.Printf("⚠️ [Backpack] 查询开仓单 %s 失败: %v，直接尝试撤单", orderID, err)
+	}
+
+	cancelled, err := t.CancelOrder(symbol, orderID)
+	if err != nil {
+		log.Printf("❌ [Backpack] 开仓单 %s 超时撤单失败: %v", orderID, err)
+		return
+	}
+	result := newOrderFillResult(cancelled)
+	log.Printf("⏰ [Backpack] 开仓单 %s 超时未成交，已撤销 (已成交 %s，剩余 %s)",
+		orderID, formatFloat(result.FilledQuantity, 8), formatFloat(result.RemainingQuantity, 8))
+
+	if opts.Action != EntryTimeoutReprice || opts.MaxReprices <= 0 || result.RemainingQuantity <= 0 {
+		return
+	}
+
+	if err := t.repriceEntry(symbol, cancelled.Side, result.RemainingQuantity, opts); err != nil {
+		log.Printf("❌ [Backpack] 开仓单 %s 重挂失败: %v", orderID, err)
+	}
+}
+
+// repriceEntry 按最新价重挂剩余数量
+func (t *BackpackTrader) repriceEntry(symbol, side string, quantity float64, opts LimitEntryOptions) error {
+	price, err := t.GetMarketPrice(symbol)
+	if err != nil {
+		return fmt.Errorf("获取最新价失败: %w", err)
+	}
+
+	opts.MaxReprices--
+	log.Printf("🔁 [Backpack] 重挂开仓单: %s %s 数量=%.4f 价格=%.4f (剩余重挂次数 %d)",
+		symbol, side, quantity, price, opts.MaxReprices)
+	_, err = t.placeLimitEntry(symbol, side, quantity, price, opts)
+	return err
+}
+
+// PlaceQuote 挂出做市报价（GTC限价单），返回订单ID
+func (t *BackpackTrader) PlaceQuote(symbol string, isBid bool, quantity, price float64) (string, error) {
+	side := "Ask"
+	if isBid {
+		side = "Bid"
+	}
+	order, err := t.createOrder(context.Background(), t.mapSymbol(symbol), side, "Limit", quantity, &price, 0, 0, false)
+	if err != nil {
+		return "", err
+	}
+	return order.ID, nil
+}
+
+// CancelQuote 撤销做市报价
+func (t *BackpackTrader) CancelQuote(symbol, orderID string) error {
+	_, err := t.CancelOrder(symbol, orderID)
+	return err
+}
@@ -0,0 +1,300 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// orderPollInterval 轮询订单状态的间隔
+const orderPollInterval = 500 * time.Millisecond
+
+// FillTimeoutPolicy 等待订单成交超时后的处理策略
+type FillTimeoutPolicy int
+
+const (
+	// FillTimeoutWait 保留挂单，仅返回当前成交情况
+	FillTimeoutWait FillTimeoutPolicy = iota
+	// FillTimeoutCancelRemainder 撤销未成交部分
+	FillTimeoutCancelRemainder
+	// FillTimeoutConvertToMarket 撤销未成交部分，并以市价单补足剩余数量
+	FillTimeoutConvertToMarket
+)
+
+func (p FillTimeoutPolicy) String() string {
+	switch p {
+	case FillTimeoutWait:
+		return "wait"
+	case FillTimeoutCancelRemainder:
+		return "cancel-remainder"
+	case FillTimeoutConvertToMarket:
+		return "convert-to-market"
+	default:
+		return fmt.Sprintf("FillTimeoutPolicy(%d)", int(p))
+	}
+}
+
+// OrderFillResult 订单成交结果
+type OrderFillResult struct {
+	OrderID           string
+	Status            string  // 订单最终（或超时时）的状态
+	FilledQuantity    float64 // 已成交数量（含市价补单）
+	AvgPrice          float64 // 成交均价（含市价补单）
+	RemainingQuantity float64 // 未成交数量
+	TimedOut          bool    // 是否等待超时
+	MarketOrderID     string  // convert-to-market 策略下补单的订单ID
+}
+
+// Filled 订单是否已全部成交
+func (r *OrderFillResult) Filled() bool {
+	return r.RemainingQuantity <= 0 && r.FilledQuantity > 0
+}
+
+// addFill 累加一笔成交，并按数量加权更新均价
+func (r *OrderFillResult) addFill(quantity, avgPrice float64) {
+	if quantity <= 0 {
+		return
+	}
+	total := r.FilledQuantity + quantity
+	r.AvgPrice = (r.AvgPrice*r.FilledQuantity + avgPrice*quantity) / total
+	r.FilledQuantity = total
+}
+
+// newOrderFillResult 根据订单状态构造成交结果
+func newOrderFillResult(order *Order) *OrderFillResult {
+	result := &OrderFillResult{
+		OrderID: order.ID,
+		Status:  order.Status,
+	}
+	executed := order.ExecutedQuantity.Float64()
+	if executed > 0 {
+		result.addFill(executed, order.ExecutedQuoteQuantity.Float64()/executed)
+	}
+	if remaining := order.Quantity.Float64() - executed; remaining > 0 {
+		result.RemainingQuantity = remaining
+	}
+	return result
+}
+
+// requestOrder 发送认证请求并将响应解析为订单
+func (t *BackpackTrader) requestOrder(ctx context.Context, method, endpoint string, params, data map[string]string) (*Order, error) {
+	resp, err := t.sendAuthenticatedRequest(ctx, method, endpoint, params, data)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var order Order
+	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		return nil, fmt.Errorf("解析订单失败: %w", err)
+	}
+	return &order, nil
+}
+
+// GetOrder 查询单个订单
+func (t *BackpackTrader) GetOrder(symbol, orderID string) (*Order, error) {
+	return t.GetOrderWithContext(context.Background(), symbol, orderID)
+}
+
+// GetOrderWithContext 同 GetOrder，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetOrderWithContext(ctx context.Context, symbol, orderID string) (*Order, error) {
+	params := map[string]string{
+		"symbol":  t.mapSymbol(symbol),
+		"orderId": orderID,
+	}
+	order, err := t.requestOrder(ctx, "GET", "/api/v1/order", params, nil)
+	if err != nil {
+		return nil, fmt.Errorf("查询订单失败: %w", err)
+	}
+	return order, nil
+}
+
+// clientIDHistoryLimit GetOrderByClientID 在历史订单中向前查找的最大条数
+const clientIDHistoryLimit = 200
+
+// GetOrderByClientID 按 clientId 查询订单，用于下单超时后确认订单是否已提交
+// 先查挂单，找不到时在最近的历史订单中查找（已成交/已撤销的订单不在挂单中）；都找不到时返回 nil, nil，表示可以安全地重新下单
+func (t *BackpackTrader) GetOrderByClientID(symbol string, clientID uint32) (*Order, error) {
+	return t.GetOrderByClientIDWithContext(context.Background(), symbol, clientID)
+}
+
+// GetOrderByClientIDWithContext 同 GetOrderByClientID，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetOrderByClientIDWithContext(ctx context.Context, symbol string, clientID uint32) (*Order, error) {
+	id := strconv.FormatUint(uint64(clientID), 10)
+	backpackSymbol := t.mapSymbol(symbol)
+
+	order, err := t.requestOrder(ctx, "GET", "/api/v1/order", map[string]string{
+		"symbol":   backpackSymbol,
+		"clientId": id,
+	}, nil)
+	if err == nil {
+		return order, nil
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound {
+		return nil, fmt.Errorf("按clientId查询订单失败: %w", err)
+	}
+
+	var found *Order
+	_, err = streamHistoryPages(ctx, t, "/wapi/v1/history/orders", map[string]string{"symbol": backpackSymbol},
+		backpackHistoryPageSize, clientIDHistoryLimit, func(o Order) error {
+			if o.ClientID.String() == id {
+				found = &o
+				return errStopPaging
+			}
+			return nil
+		})
+	if err != nil {
+		return nil, fmt.Errorf("按clientId查询历史订单失败: %w", err)
+	}
+	if found == nil {
+		log.Printf("🔍 [Backpack] 未找到 clientId=%s 的订单 (%s)", id, backpackSymbol)
+	}
+	return found, nil
+}
+
+// CancelOrder 撤销单个订单，返回撤销时订单的最终状态
+func (t *BackpackTrader) CancelOrder(symbol, orderID string) (*Order, error) {
+	return t.CancelOrderWithContext(context.Background(), symbol, orderID)
+}
+
+// CancelOrderWithContext 同 CancelOrder，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) CancelOrderWithContext(ctx context.Context, symbol, orderID string) (*Order, error) {
+	data := map[string]string{
+		"symbol":  t.mapSymbol(symbol),
+		"orderId": orderID,
+	}
+	order, err := t.requestOrder(ctx, "DELETE", "/api/v1/order", nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("撤销订单失败: %w", err)
+	}
+	t.expiry.Unschedule(orderID)
+	log.Printf("✓ [Backpack] 已撤销订单 %s (已成交 %s)", orderID, formatFloat(order.ExecutedQuantity.Float64(), 8))
+	return order, nil
+}
+
+// PendingExpiries 返回已登记到期撤销（GTT）且尚未到期的订单
+func (t *BackpackTrader) PendingExpiries() []OrderExpiry {
+	return t.expiry.Pending()
+}
+
+// WaitForOrderFill 等待订单成交，返回成交数量、均价和剩余数量
+// 部分成交时继续等待；超时后按 policy 处理未成交部分。
+// 账户推送已连接时按订单推送判断成交，只以较长间隔查询兜底；否则每 500ms 查询一次订单状态
+func (t *BackpackTrader) WaitForOrderFill(symbol, orderID string, maxWaitSeconds int, policy FillTimeoutPolicy) (*OrderFillResult, error) {
+	return t.WaitForOrderFillWithContext(context.Background(), symbol, orderID, maxWaitSeconds, policy)
+}
+
+// WaitForOrderFillWithContext 同 WaitForOrderFill，ctx 取消时立即停止等待（不再撤单或转市价），
+// 并用于超时后的撤单和市价补单请求
+func (t *BackpackTrader) WaitForOrderFillWithContext(ctx context.Context, symbol, orderID string, maxWaitSeconds int, policy FillTimeoutPolicy) (*OrderFillResult, error) {
+	backpackSymbol := t.mapSymbol(symbol)
+	log.Printf("⏳ [Backpack] 等待订单成交: %s (订单ID: %s, 超时策略: %s)", backpackSymbol, orderID, policy)
+
+	if maxWaitSeconds <= 0 {
+		maxWaitSeconds = 30
+	}
+	timeout := time.NewTimer(time.Duration(maxWaitSeconds) * time.Second)
+	defer timeout.Stop()
+
+	updates, unsubscribe := t.orderUpdatesFor(orderID)
+	defer unsubscribe()
+	pollInterval, firstPoll := orderPollInterval, orderPollInterval
+	if updates != nil {
+		// 订阅后立即查询一次，订阅前已成交的订单不会再有推送
+		pollInterval, firstPoll = orderStreamPollInterval, 0
+	}
+	poll := time.NewTimer(firstPoll)
+	defer poll.Stop()
+
+	var last *Order
+	attempt := 0
+wait:
+	for {
+		var order *Order
+		select {
+		case <-ctx.Done():
+			if last == nil {
+				return nil, fmt.Errorf("等待订单成交已取消: %w", ctx.Err())
+			}
+			return newOrderFillResult(last), fmt.Errorf("等待订单成交已取消: %w", ctx.Err())
+		case <-timeout.C:
+			break wait
+		case update := <-updates:
+			order = update.order()
+			log.Printf("  → 订单推送: %s %s 已成交 %s/%s", update.Event, order.Status,
+				formatFloat(order.ExecutedQuantity.Float64(), 8), formatFloat(order.Quantity.Float64(), 8))
+		case <-poll.C:
+			poll.Reset(pollInterval)
+			attempt++
+			var err error
+			order, err = t.GetOrderWithContext(ctx, symbol, orderID)
+			if err != nil {
+				log.Printf("  ⚠️ 查询订单状态失败: %v", err)
+				continue
+			}
+			log.Printf("  → 订单状态: %s 已成交 %s/%s (第%d次检查)", order.Status,
+				formatFloat(order.ExecutedQuantity.Float64(), 8), formatFloat(order.Quantity.Float64(), 8), attempt)
+		}
+		last = order
+
+		switch order.Status {
+		case "Filled":
+			log.Printf("  ✓ 订单已完全成交")
+			return newOrderFillResult(order), nil
+		case "Cancelled", "Expired", "Rejected":
+			// 撤单前可能已部分成交，一并返回
+			return newOrderFillResult(order), fmt.Errorf("订单未完全成交，状态: %s", order.Status)
+		}
+	}
+
+	if last == nil {
+		return nil, fmt.Errorf("等待订单成交超时（%d秒），且未能查询到订单状态", maxWaitSeconds)
+	}
+
+	result := newOrderFillResult(last)
+	result.TimedOut = true
+	log.Printf("  ⏰ 等待订单成交超时（%d秒），已成交 %s，剩余 %s，按 %s 处理",
+		maxWaitSeconds, formatFloat(result.FilledQuantity, 8), formatFloat(result.RemainingQuantity, 8), policy)
+
+	if policy == FillTimeoutWait {
+		return result, nil
+	}
+
+	// 撤销剩余部分，以撤单响应中的成交量为准（避免最后一次查询后又有成交）
+	cancelled, err := t.CancelOrderWithContext(ctx, symbol, orderID)
+	if err != nil {
+		return result, err
+	}
+	result = newOrderFillResult(cancelled)
+	result.TimedOut = true
+
+	if policy == FillTimeoutCancelRemainder || result.RemainingQuantity <= 0 {
+		return result, nil
+	}
+
+	// 市价补足剩余数量（沿用原订单的只减仓标志）
+	marketOrder, err := t.createOrder(ctx, backpackSymbol, cancelled.Side, "Market", result.RemainingQuantity, nil, 0, 0, cancelled.ReduceOnly)
+	if err != nil {
+		return result, fmt.Errorf("剩余数量转市价单失败: %w", err)
+	}
+	result.MarketOrderID = marketOrder.ID
+	executed := marketOrder.ExecutedQuantity.Float64()
+	if executed > 0 {
+		result.addFill(executed, marketOrder.ExecutedQuoteQuantity.Float64()/executed)
+		result.RemainingQuantity -= executed
+		if result.RemainingQuantity < 0 {
+			result.RemainingQuantity = 0
+		}
+	}
+	result.Status = "Filled"
+	if result.RemainingQuantity > 0 {
+		result.Status = "PartiallyFilled"
+	}
+	return result, nil
+}
@@ -0,0 +1,202 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultRateLimitBackoff = 1 * time.Second // 429 未携带 Retry-After 时的默认退避时长
+	defaultBanBackoff       = 2 * time.Minute // 418 封禁未携带 Retry-After 时的默认退避时长
+)
+
+// ErrRateLimited 请求被交易所限频（HTTP 429）或封禁（HTTP 418）
+// 使用 errors.Is(err, ErrRateLimited) 判断，使用 errors.As 获取 *RateLimitError 中的等待时长
+var ErrRateLimited = errors.New("请求被限频")
+
+// RateLimitError 限频错误详情
+type RateLimitError struct {
+	StatusCode int           // 429 或 418；0 表示处于本地退避期，请求未发出
+	RetryAfter time.Duration // 建议的等待时长
+	Body       string        // 交易所返回的原始响应
+}
+
+func (e *RateLimitError) Error() string {
+	if e.StatusCode == 0 {
+		return fmt.Sprintf("请求被限频: 退避中，%.1f秒后重试", e.RetryAfter.Seconds())
+	}
+	return fmt.Sprintf("请求被限频: HTTP %d，%.1f秒后重试 - %s", e.StatusCode, e.RetryAfter.Seconds(), e.Body)
+}
+
+// Unwrap 使 errors.Is(err, ErrRateLimited) 成立
+func (e *RateLimitError) Unwrap() error {
+	return ErrRateLimited
+}
+
+// APIError Backpack 返回的非200响应
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API请求失败: HTTP %d - %s", e.StatusCode, e.Body)
+}
+
+var (
+	backpackTransport     *http.Transport
+	backpackTransportOnce sync.Once
+)
+
+// sharedBackpackTransport 返回所有Backpack交易器共享的调优Transport
+// 默认Transport每个host只保留2个空闲连接，高频请求时会反复建立TLS连接，
+// 这里放大空闲连接池并开启keep-alive与HTTP/2，让多个交易器实例复用连接
+func sharedBackpackTransport() *http.Transport {
+	backpackTransportOnce.Do(func() {
+		backpackTransport = &http.Transport{
+			Proxy: http.ProxyFromEnvironment,
+			DialContext: (&net.Dialer{
+				Timeout:   10 * time.Second,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+			ForceAttemptHTTP2:     true,
+			MaxIdleConns:          100,
+			MaxIdleConnsPerHost:   32,
+			IdleConnTimeout:       90 * time.Second,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ExpectContinueTimeout: 1 * time.Second,
+		}
+	})
+	return backpackTransport
+}
+
+// SetTransport 替换HTTP transport（如测试中使用 VCRTransport 回放录制的响应）
+func (t *BackpackTrader) SetTransport(rt http.RoundTripper) {
+	t.client.Transport = rt
+}
+
+// WarmUp 预热连接池：并发请求 /api/v1/ping 提前完成TCP/TLS握手
+// connections: 需要预热的连接数（<=0 时为1）
+func (t *BackpackTrader) WarmUp(connections int) error {
+	if connections <= 0 {
+		connections = 1
+	}
+
+	url := strings.TrimSuffix(t.baseURL, "/") + "/api/v1/ping"
+	errs := make(chan error, connections)
+	var wg sync.WaitGroup
+	for i := 0; i < connections; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := t.client.Get(url)
+			if err != nil {
+				errs <- err
+				return
+			}
+			// 读完响应体才能把连接放回空闲池
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	failed := 0
+	var lastErr error
+	for err := range errs {
+		failed++
+		lastErr = err
+	}
+	if failed == connections {
+		return fmt.Errorf("连接预热失败: %w", lastErr)
+	}
+
+	log.Printf("🔥 [Backpack] 连接预热完成: %d/%d", connections-failed, connections)
+	return nil
+}
+
+// doRequest 发送请求并统一处理限频与错误状态码
+// 返回状态码为200的响应（调用方负责关闭Body）
+func (t *BackpackTrader) doRequest(req *http.Request) (*http.Response, error) {
+	if wait := t.backoffRemaining(); wait > 0 {
+		return nil, &RateLimitError{RetryAfter: wait}
+	}
+	if t.limiter != nil {
+		if err := t.limiter.Wait(req.Context(), req.URL.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("发送请求失败: %w", err)
+	}
+	if resp.StatusCode == http.StatusOK {
+		return resp, nil
+	}
+
+	defer resp.Body.Close()
+	bodyBytes, _ := io.ReadAll(resp.Body)
+	log.Printf("❌ [Backpack] API错误: %s %s -> HTTP %d", req.Method, req.URL.Path, resp.StatusCode)
+	log.Printf("❌ [Backpack] 错误响应: %s", string(bodyBytes))
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusTeapot {
+		fallback := defaultRateLimitBackoff
+		if resp.StatusCode == http.StatusTeapot {
+			fallback = defaultBanBackoff
+		}
+		wait := parseRetryAfter(resp.Header.Get("Retry-After"), time.Now(), fallback)
+		t.extendBackoff(wait)
+		log.Printf("🚦 [Backpack] 触发限频 (HTTP %d)，暂停所有请求 %.1f 秒", resp.StatusCode, wait.Seconds())
+		return nil, &RateLimitError{StatusCode: resp.StatusCode, RetryAfter: wait, Body: string(bodyBytes)}
+	}
+
+	return nil, &APIError{StatusCode: resp.StatusCode, Body: string(bodyBytes)}
+}
+
+// parseRetryAfter 解析 Retry-After 头（秒数或HTTP日期），无效时返回 fallback
+func parseRetryAfter(value string, now time.Time, fallback time.Duration) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback
+	}
+	if secs, err := strconv.ParseFloat(value, 64); err == nil && secs >= 0 {
+		return time.Duration(secs * float64(time.Second))
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		if wait := at.Sub(now); wait > 0 {
+			return wait
+		}
+		return 0
+	}
+	return fallback
+}
+
+// backoffRemaining 返回本实例剩余的限频退避时长
+func (t *BackpackTrader) backoffRemaining() time.Duration {
+	t.backoffMu.Lock()
+	defer t.backoffMu.Unlock()
+	if wait := time.Until(t.backoffUntil); wait > 0 {
+		return wait
+	}
+	return 0
+}
+
+// extendBackoff 延长本实例的限频退避期（只会延长，不会缩短）
+func (t *BackpackTrader) extendBackoff(wait time.Duration) {
+	until := time.Now().Add(wait)
+	t.backoffMu.Lock()
+	defer t.backoffMu.Unlock()
+	if until.After(t.backoffUntil) {
+		t.backoffUntil = until
+	}
+}
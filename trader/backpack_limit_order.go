@@ -0,0 +1,120 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// TimeInForce 限价单有效方式
+type TimeInForce string
+
+const (
+	TimeInForceGTC TimeInForce = "GTC" // 一直有效直到撤销（默认）
+	TimeInForceIOC TimeInForce = "IOC" // 立即成交，未成交部分撤销
+	TimeInForceFOK TimeInForce = "FOK" // 全部立即成交，否则整单撤销
+)
+
+// LimitOrderOptions 限价单选项
+type LimitOrderOptions struct {
+	TimeInForce TimeInForce // 空表示 GTC
+	PostOnly    bool        // 只做Maker：会立即成交时交易所拒单（不能与 IOC/FOK 同时使用）
+	ReduceOnly  bool        // 只减仓：成交不会增加或反向持仓
+}
+
+// boolRequestFields 请求体中需要按 JSON 布尔值发送的字段
+var boolRequestFields = map[string]bool{
+	"postOnly":   true,
+	"reduceOnly": true,
+}
+
+// validate 校验选项组合，返回规范化后的 TimeInForce
+func (o LimitOrderOptions) validate() (TimeInForce, error) {
+	tif := o.TimeInForce
+	switch tif {
+	case "":
+		tif = TimeInForceGTC
+	case TimeInForceGTC, TimeInForceIOC, TimeInForceFOK:
+	default:
+		return "", fmt.Errorf("不支持的 timeInForce: %q", o.TimeInForce)
+	}
+	if o.PostOnly && tif != TimeInForceGTC {
+		return "", fmt.Errorf("postOnly 不能与 %s 同时使用", tif)
+	}
+	return tif, nil
+}
+
+// orderSide 将 buy/sell（或 Bid/Ask）转换为 Backpack 的订单方向
+func orderSide(side string) (string, error) {
+	switch strings.ToLower(side) {
+	case "buy", "bid":
+		return "Bid", nil
+	case "sell", "ask":
+		return "Ask", nil
+	}
+	return "", fmt.Errorf("无效的订单方向: %q", side)
+}
+
+// PlaceLimitOrder 挂限价单
+// side: "buy"/"sell"（也接受 "Bid"/"Ask"）；价格按交易对价格精度格式化
+func (t *BackpackTrader) PlaceLimitOrder(symbol, side string, quantity, price float64, opts LimitOrderOptions) (*Order, error) {
+	return t.PlaceLimitOrderWithContext(context.Background(), symbol, side, quantity, price, opts)
+}
+
+// PlaceLimitOrderWithContext 同 PlaceLimitOrder，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) PlaceLimitOrderWithContext(ctx context.Context, symbol, side string, quantity, price float64, opts LimitOrderOptions) (*Order, error) {
+	bpSide, err := orderSide(side)
+	if err != nil {
+		return nil, err
+	}
+	tif, err := opts.validate()
+	if err != nil {
+		return nil, err
+	}
+	if quantity <= 0 || price <= 0 {
+		return nil, fmt.Errorf("无效的限价单: 数量=%.8f 价格=%.8f", quantity, price)
+	}
+
+	backpackSymbol := t.mapSymbol(symbol)
+	qtyStr, err := t.FormatQuantityWithContext(ctx, backpackSymbol, quantity)
+	if err != nil {
+		log.Printf("⚠️ [Backpack] 格式化数量失败，使用默认精度: %v", err)
+		qtyStr = formatFloat(quantity, 8)
+	}
+	pricePrecision := 2
+	if precision, err := t.getSymbolPrecision(ctx, backpackSymbol); err == nil {
+		pricePrecision = precision.PricePrecision
+	}
+
+	data := map[string]string{
+		"symbol":      backpackSymbol,
+		"side":        bpSide,
+		"orderType":   "Limit",
+		"quantity":    qtyStr,
+		"price":       formatFloat(price, pricePrecision),
+		"timeInForce": string(tif),
+	}
+	if opts.PostOnly {
+		data["postOnly"] = "true"
+	}
+	if opts.ReduceOnly {
+		data["reduceOnly"] = "true"
+	}
+	tagOrder(ctx, data)
+
+	log.Printf("📤 [Backpack] 挂限价单: %s %s@%s %s %s postOnly=%v reduceOnly=%v",
+		bpSide, qtyStr, data["price"], backpackSymbol, tif, opts.PostOnly, opts.ReduceOnly)
+	order, err := t.requestOrder(ctx, "POST", "/api/v1/order", nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("挂限价单失败: %w", err)
+	}
+	t.invalidatePositionCache()
+	if order.ID == "" {
+		return nil, fmt.Errorf("下单响应缺少订单ID: %s Limit %s", bpSide, backpackSymbol)
+	}
+
+	log.Printf("✓ [Backpack] 限价单已创建: ID=%s 状态=%s 已成交=%s", order.ID, order.Status,
+		formatFloat(order.ExecutedQuantity.Float64(), 8))
+	return order, nil
+}
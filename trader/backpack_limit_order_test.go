@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpackTrader_PlaceLimitOrder(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/markets":
+			w.Write([]byte(`[{"symbol":"SOL_USDC_PERP","filters":{"price":{"tickSize":"0.001"},"quantity":{"stepSize":"0.01"}}}]`))
+		case "/api/v1/order":
+			body = nil
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.Write([]byte(`{"id":"42","status":"New","orderType":"Limit","timeInForce":"GTC","postOnly":true}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	order, err := trader.PlaceLimitOrder("SOLUSDT", "buy", 1.234, 150.1234, LimitOrderOptions{PostOnly: true, ReduceOnly: true})
+	require.NoError(t, err)
+	assert.Equal(t, "42", order.ID)
+	assert.True(t, order.PostOnly)
+
+	assert.Equal(t, "SOL_USDC_PERP", body["symbol"])
+	assert.Equal(t, "Bid", body["side"])
+	assert.Equal(t, "Limit", body["orderType"])
+	assert.Equal(t, "1.23", body["quantity"])
+	assert.Equal(t, "150.123", body["price"], "按 tickSize 精度格式化")
+	assert.Equal(t, "GTC", body["timeInForce"])
+	assert.Equal(t, true, body["postOnly"], "以布尔值发送")
+	assert.Equal(t, true, body["reduceOnly"])
+	assert.IsType(t, float64(0), body["clientId"])
+
+	_, err = trader.PlaceLimitOrder("SOLUSDT", "Ask", 1, 151, LimitOrderOptions{TimeInForce: TimeInForceIOC})
+	require.NoError(t, err)
+	assert.Equal(t, "Ask", body["side"])
+	assert.Equal(t, "IOC", body["timeInForce"])
+	assert.NotContains(t, body, "postOnly")
+	assert.NotContains(t, body, "reduceOnly")
+}
+
+func TestBackpackTrader_PlaceLimitOrderValidation(t *testing.T) {
+	trader := newTestBackpackTrader(t, "http://127.0.0.1:0")
+
+	_, err := trader.PlaceLimitOrder("SOLUSDT", "long", 1, 150, LimitOrderOptions{})
+	assert.Error(t, err, "方向只接受 buy/sell")
+	_, err = trader.PlaceLimitOrder("SOLUSDT", "buy", 1, 150, LimitOrderOptions{TimeInForce: "GTD"})
+	assert.Error(t, err)
+	_, err = trader.PlaceLimitOrder("SOLUSDT", "buy", 1, 150, LimitOrderOptions{TimeInForce: TimeInForceFOK, PostOnly: true})
+	assert.Error(t, err, "postOnly 只能配合 GTC")
+	_, err = trader.PlaceLimitOrder("SOLUSDT", "sell", 1, 0, LimitOrderOptions{})
+	assert.Error(t, err)
+	_, err = trader.PlaceLimitOrder("SOLUSDT", "sell", 0, 150, LimitOrderOptions{})
+	assert.Error(t, err)
+}
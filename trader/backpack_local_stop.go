@@ -0,0 +1,227 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultLocalStopInterval 本地止损监控的价格轮询间隔
+	defaultLocalStopInterval = 2 * time.Second
+	// localStopIDPrefix 本地止损ID前缀，与交易所订单ID区分
+	localStopIDPrefix = "local-"
+)
+
+// localStop 交易所不支持触发单时在本地监控的止损
+type localStop struct {
+	ID           string  // 本地ID（local-N），add 时分配
+	Symbol       string  // 标准格式交易对
+	Side         string  // 平仓方向：Ask（多仓止损）/ Bid（空仓止损）
+	Quantity     string  // 已按精度格式化的平仓数量
+	TriggerPrice float64 // 触发价
+	TakeProfit   bool    // 止盈（价格向有利方向触及时平仓）
+	Group        string  // 联动组：组内一个触发后移除其余（SetStopLossAndTakeProfit）
+}
+
+// triggered 最新价是否已触及止损（止盈方向相反）
+func (s localStop) triggered(price float64) bool {
+	if (s.Side == "Ask") != s.TakeProfit {
+		return price <= s.TriggerPrice
+	}
+	return price >= s.TriggerPrice
+}
+
+// localStopMonitor 轮询最新价，触及止损价时以只减仓市价单平仓（仅在交易所拒绝触发单时使用）
+// 进程退出后止损失效，因此只作为兜底
+type localStopMonitor struct {
+	t        *BackpackTrader
+	ctx      context.Context // 交易器生命周期，Stop 后取消进行中的请求并停止监控
+	interval time.Duration
+
+	mu      sync.Mutex
+	stops   map[string][]localStop // 交易所格式交易对 -> 止损
+	running bool
+	nextID  int
+}
+
+func newLocalStopMonitor(t *BackpackTrader) *localStopMonitor {
+	return &localStopMonitor{t: t, ctx: t.ctx, interval: defaultLocalStopInterval, stops: make(map[string][]localStop)}
+}
+
+// add 添加止损并返回其本地ID，监控未运行时启动
+func (m *localStopMonitor) add(backpackSymbol string, stop localStop) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.nextID++
+	stop.ID = fmt.Sprintf("%s%d", localStopIDPrefix, m.nextID)
+	m.stops[backpackSymbol] = append(m.stops[backpackSymbol], stop)
+	if !m.running {
+		m.running = true
+		go m.run()
+	}
+	return stop.ID
+}
+
+// isLocalStopID 是否为本地止损ID
+func isLocalStopID(id string) bool {
+	return strings.HasPrefix(id, localStopIDPrefix)
+}
+
+// cancel 移除交易对的全部本地止损，返回移除数量
+func (m *localStopMonitor) cancel(backpackSymbol string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.stops[backpackSymbol])
+	delete(m.stops, backpackSymbol)
+	return n
+}
+
+// count 当前监控中的止损数量
+func (m *localStopMonitor) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, stops := range m.stops {
+		n += len(stops)
+	}
+	return n
+}
+
+// run 轮询直到没有待监控的止损或交易器停止
+func (m *localStopMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			m.mu.Lock()
+			m.running = false
+			m.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+		if !m.check() {
+			return
+		}
+	}
+}
+
+// check 检查一轮，返回是否还有待监控的止损（没有时标记监控已停止）
+func (m *localStopMonitor) check() bool {
+	m.mu.Lock()
+	snapshot := make(map[string][]localStop, len(m.stops))
+	for symbol, stops := range m.stops {
+		snapshot[symbol] = append([]localStop(nil), stops...)
+	}
+	m.mu.Unlock()
+
+	for backpackSymbol, stops := range snapshot {
+		price, err := m.t.GetMarketPriceWithContext(m.ctx, stops[0].Symbol)
+		if err != nil {
+			log.Printf("⚠️ [Backpack] 本地止损获取 %s 价格失败: %v", backpackSymbol, err)
+			continue
+		}
+		fired := make(map[string]bool)
+		for _, stop := range stops {
+			if !stop.triggered(price) || fired[stop.Group] {
+				continue
+			}
+			log.Printf("🛑 [Backpack] %s 价格 %.4f 触及本地止损/止盈 %.4f，市价平仓", backpackSymbol, price, stop.TriggerPrice)
+			data := map[string]string{
+				"symbol":     backpackSymbol,
+				"side":       stop.Side,
+				"orderType":  "Market",
+				"quantity":   stop.Quantity,
+				"reduceOnly": "true",
+			}
+			tagOrder(m.ctx, data)
+			if _, err := m.t.requestOrder(m.ctx, "POST", "/api/v1/order", nil, data); err != nil {
+				// 保留止损，下一轮重试
+				log.Printf("❌ [Backpack] 本地止损平仓失败: %v", err)
+				continue
+			}
+			m.remove(backpackSymbol, stop)
+			if stop.Group != "" {
+				fired[stop.Group] = true
+				m.removeGroup(backpackSymbol, stop.Group)
+			}
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.stops) == 0 {
+		m.running = false
+		return false
+	}
+	return true
+}
+
+// remove 移除已触发的止损（期间被撤销时忽略）
+func (m *localStopMonitor) remove(backpackSymbol string, stop localStop) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stops := m.stops[backpackSymbol]
+	for i, s := range stops {
+		if s == stop {
+			stops = append(stops[:i], stops[i+1:]...)
+			break
+		}
+	}
+	if len(stops) == 0 {
+		delete(m.stops, backpackSymbol)
+		return
+	}
+	m.stops[backpackSymbol] = stops
+}
+
+// removeID 按本地ID移除单个止损
+func (m *localStopMonitor) removeID(backpackSymbol, id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []localStop
+	for _, s := range m.stops[backpackSymbol] {
+		if s.ID != id {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		delete(m.stops, backpackSymbol)
+		return
+	}
+	m.stops[backpackSymbol] = kept
+}
+
+// removeGroup 移除联动组内的其余止损/止盈
+func (m *localStopMonitor) removeGroup(backpackSymbol, group string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var kept []localStop
+	for _, s := range m.stops[backpackSymbol] {
+		if s.Group != group {
+			kept = append(kept, s)
+		}
+	}
+	if len(kept) == 0 {
+		delete(m.stops, backpackSymbol)
+		return
+	}
+	m.stops[backpackSymbol] = kept
+}
+
+// isTriggerUnsupported 交易所是否因不支持触发单参数而拒单（触发价无效等其他拒单原因不算）
+func isTriggerUnsupported(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	body := strings.ToLower(apiErr.Body)
+	return apiErr.StatusCode == http.StatusBadRequest && strings.Contains(body, "trigger") &&
+		(strings.Contains(body, "not supported") || strings.Contains(body, "unsupported") || strings.Contains(body, "unknown field"))
+}
@@ -0,0 +1,234 @@
+package trader
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpackTrader_SetStopLossTriggerOrder(t *testing.T) {
+	var orders []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method+" "+r.URL.Path != "POST /api/v1/order" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		orders = append(orders, body)
+		w.Write([]byte(`{"id":"1","status":"TriggerPending"}`))
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+
+	require.NoError(t, tr.SetStopLoss("SOLUSDT", "LONG", 1, 95))
+	require.Len(t, orders, 1)
+	assert.Equal(t, "Ask", orders[0]["side"])
+	assert.Equal(t, "Market", orders[0]["orderType"])
+	assert.Equal(t, "95", orders[0]["triggerPrice"])
+	assert.Equal(t, true, orders[0]["reduceOnly"])
+	assert.NotContains(t, orders[0], "price", "止损不能是立即成交的限价单")
+
+	// 触发后限价：空仓止损买入价高于触发价
+	tr.SetStopLimitOffset(1)
+	require.NoError(t, tr.SetStopLoss("SOLUSDT", "SHORT", 1, 100))
+	require.Len(t, orders, 2)
+	assert.Equal(t, "Bid", orders[1]["side"])
+	assert.Equal(t, "Limit", orders[1]["orderType"])
+	assert.Equal(t, "101", orders[1]["price"])
+}
+
+func TestBackpackTrader_SetStopLossRequiresOrderID(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/order" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"status":"TriggerPending"}`))
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+
+	// 没有订单ID的止损无法跟踪和撤销，不能报告为已设置
+	require.Error(t, tr.SetStopLoss("SOLUSDT", "LONG", 1, 95))
+	assert.Zero(t, tr.localStops.count())
+}
+
+func TestBackpackTrader_SetStopLossUsesPricePrecision(t *testing.T) {
+	var bodies []map[string]interface{}
+	tr := newTestBackpackTrader(t, newOrderCaptureServer(t, &bodies).URL)
+
+	require.NoError(t, tr.SetStopLoss("DOGEUSDT", "LONG", 100, 0.1234))
+	tr.SetStopLimitOffset(1)
+	require.NoError(t, tr.SetStopLoss("DOGEUSDT", "LONG", 100, 0.1234))
+	require.Len(t, bodies, 2)
+	assert.Equal(t, "0.1234", bodies[0]["triggerPrice"], "低价币止损不能被舍入到 0.12")
+	assert.Equal(t, "0.1234", bodies[1]["triggerPrice"])
+	assert.Equal(t, "0.12217", bodies[1]["price"])
+}
+
+func TestBackpackTrader_SetStopLossLocalFallback(t *testing.T) {
+	var mu sync.Mutex
+	price := "100"
+	var triggerOrders, closeOrders []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/ticker":
+			w.Write([]byte(`{"symbol":"SOL_USDC_PERP","lastPrice":"` + price + `"}`))
+		case "POST /api/v1/order":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			if _, ok := body["triggerPrice"]; ok {
+				triggerOrders = append(triggerOrders, body)
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"code":"INVALID_ORDER","message":"Trigger orders are not supported"}`))
+				return
+			}
+			closeOrders = append(closeOrders, body)
+			w.Write([]byte(`{"id":"9","status":"Filled"}`))
+		case "DELETE /api/v1/orders":
+			w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+	tr.SetResponseCache(ResponseCacheConfig{})
+	tr.localStops.interval = 10 * time.Millisecond
+
+	// 交易所拒绝触发单：改为本地监控，之后不再尝试触发单
+	require.NoError(t, tr.SetStopLoss("SOLUSDT", "LONG", 1, 95))
+	require.NoError(t, tr.SetStopLoss("ETHUSDT", "LONG", 1, 90))
+	mu.Lock()
+	assert.Len(t, triggerOrders, 1)
+	mu.Unlock()
+	assert.Equal(t, 2, tr.localStops.count())
+
+	// 撤单时一并移除本地止损
+	require.NoError(t, tr.CancelStopLossOrders("ETHUSDT"))
+	assert.Equal(t, 1, tr.localStops.count())
+
+	// 价格未触及止损时不平仓
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	assert.Empty(t, closeOrders)
+	price = "94.5"
+	mu.Unlock()
+
+	require.Eventually(t, func() bool { return tr.localStops.count() == 0 }, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, closeOrders, 1)
+	assert.Equal(t, "Ask", closeOrders[0]["side"])
+	assert.Equal(t, "Market", closeOrders[0]["orderType"])
+	assert.Equal(t, true, closeOrders[0]["reduceOnly"])
+}
+
+func TestIsTriggerUnsupported(t *testing.T) {
+	assert.True(t, isTriggerUnsupported(&APIError{StatusCode: 400, Body: `{"message":"Trigger orders are not supported"}`}))
+	assert.False(t, isTriggerUnsupported(&APIError{StatusCode: 400, Body: `{"message":"Trigger price would trigger immediately"}`}))
+	assert.False(t, isTriggerUnsupported(&APIError{StatusCode: 503, Body: "unsupported"}))
+	assert.False(t, isTriggerUnsupported(nil))
+}
+
+func TestBackpackTrader_CancelProtectionOrderByID(t *testing.T) {
+	var cancelled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/order":
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"INVALID_ORDER","message":"Trigger orders are not supported"}`))
+		case "DELETE /api/v1/order":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			cancelled = append(cancelled, body["orderId"].(string))
+			w.Write([]byte(`{"id":"42","status":"Cancelled"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+	tr.localStops.interval = time.Hour
+
+	// 同一交易对上两笔本地止损，只撤销指定的一笔
+	first, err := tr.PlaceStopLoss("SOLUSDT", "LONG", 1, 95)
+	require.NoError(t, err)
+	second, err := tr.PlaceStopLoss("SOLUSDT", "LONG", 2, 90)
+	require.NoError(t, err)
+	require.NotEqual(t, first, second)
+	require.NoError(t, tr.CancelProtectionOrder("SOLUSDT", first))
+	assert.Equal(t, 1, tr.localStops.count())
+	assert.Empty(t, cancelled, "本地止损不请求交易所")
+
+	// 交易所订单按ID撤销
+	require.NoError(t, tr.CancelProtectionOrder("SOLUSDT", "42"))
+	assert.Equal(t, []string{"42"}, cancelled)
+	assert.Equal(t, 1, tr.localStops.count())
+}
+
+func TestBackpackTrader_StopCancelsLocalStopRequests(t *testing.T) {
+	arrived := make(chan struct{}, 1)
+	aborted := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/ticker":
+			w.Write([]byte(`{"symbol":"SOL_USDC_PERP","lastPrice":"90"}`))
+		case "POST /api/v1/order":
+			// 平仓请求挂起，只有交易器停止时才会被取消（读完请求体后才能感知客户端断开）
+			io.Copy(io.Discard, r.Body)
+			select {
+			case arrived <- struct{}{}:
+			default:
+			}
+			<-r.Context().Done()
+			select {
+			case <-aborted:
+			default:
+				close(aborted)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+	tr.SetResponseCache(ResponseCacheConfig{})
+	tr.localStops.interval = 10 * time.Millisecond
+	tr.triggerUnsupported.Store(true)
+
+	require.NoError(t, tr.SetStopLoss("SOLUSDT", "LONG", 1, 95))
+	select {
+	case <-arrived:
+	case <-time.After(time.Second):
+		t.Fatal("本地止损未触发平仓")
+	}
+
+	tr.Stop()
+	select {
+	case <-aborted:
+	case <-time.After(time.Second):
+		t.Fatal("Stop 未取消进行中的平仓请求")
+	}
+	require.Eventually(t, func() bool {
+		tr.localStops.mu.Lock()
+		defer tr.localStops.mu.Unlock()
+		return !tr.localStops.running
+	}, time.Second, 10*time.Millisecond, "监控应随交易器停止")
+	assert.Equal(t, 1, tr.localStops.count(), "未成交的止损保留")
+}
@@ -0,0 +1,255 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultOCOInterval 止盈止损联动的订单状态轮询间隔
+const defaultOCOInterval = 2 * time.Second
+
+// ProtectionPairSetter 支持为已有持仓一次性挂出联动止盈止损（触发一个自动撤销另一个）的交易器
+type ProtectionPairSetter interface {
+	SetStopLossAndTakeProfit(symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error
+}
+
+// ocoPair 一对联动的止损/止盈触发单
+type ocoPair struct {
+	Symbol       string // 标准格式交易对
+	StopLossID   string
+	TakeProfitID string
+}
+
+// ocoMonitor 轮询联动单状态：一侧成交、撤销或不再挂单时撤销另一侧
+// Backpack 只支持在开仓单上附带止盈止损（原生OCO），已有持仓的止盈止损需要在本地联动
+type ocoMonitor struct {
+	t        *BackpackTrader
+	ctx      context.Context // 交易器生命周期，Stop 后取消进行中的请求并停止监控
+	interval time.Duration
+
+	mu      sync.Mutex
+	pairs   map[string][]ocoPair // 交易所格式交易对 -> 联动单
+	running bool
+}
+
+func newOCOMonitor(t *BackpackTrader) *ocoMonitor {
+	return &ocoMonitor{t: t, ctx: t.ctx, interval: defaultOCOInterval, pairs: make(map[string][]ocoPair)}
+}
+
+// add 添加联动单，监控未运行时启动
+func (m *ocoMonitor) add(backpackSymbol string, pair ocoPair) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pairs[backpackSymbol] = append(m.pairs[backpackSymbol], pair)
+	if !m.running {
+		m.running = true
+		go m.run()
+	}
+}
+
+// cancel 停止联动交易对的全部订单（订单本身由调用方撤销），返回移除数量
+func (m *ocoMonitor) cancel(backpackSymbol string) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := len(m.pairs[backpackSymbol])
+	delete(m.pairs, backpackSymbol)
+	return n
+}
+
+// count 当前联动中的订单对数量
+func (m *ocoMonitor) count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	n := 0
+	for _, pairs := range m.pairs {
+		n += len(pairs)
+	}
+	return n
+}
+
+// run 轮询直到没有待联动的订单或交易器停止
+func (m *ocoMonitor) run() {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-m.ctx.Done():
+			m.mu.Lock()
+			m.running = false
+			m.mu.Unlock()
+			return
+		case <-ticker.C:
+		}
+		if !m.check() {
+			return
+		}
+	}
+}
+
+// check 检查一轮，返回是否还有待联动的订单（没有时标记监控已停止）
+func (m *ocoMonitor) check() bool {
+	m.mu.Lock()
+	snapshot := make(map[string][]ocoPair, len(m.pairs))
+	for symbol, pairs := range m.pairs {
+		snapshot[symbol] = append([]ocoPair(nil), pairs...)
+	}
+	m.mu.Unlock()
+
+	ctx := m.ctx
+	for backpackSymbol, pairs := range snapshot {
+		for _, pair := range pairs {
+			slDone, err := m.t.orderDone(ctx, pair.Symbol, pair.StopLossID)
+			if err != nil {
+				log.Printf("⚠️ [Backpack] 查询止损单 %s 失败: %v", pair.StopLossID, err)
+				continue
+			}
+			tpDone, err := m.t.orderDone(ctx, pair.Symbol, pair.TakeProfitID)
+			if err != nil {
+				log.Printf("⚠️ [Backpack] 查询止盈单 %s 失败: %v", pair.TakeProfitID, err)
+				continue
+			}
+
+			var other, kind string
+			switch {
+			case slDone && tpDone:
+			case slDone:
+				other, kind = pair.TakeProfitID, "止损"
+			case tpDone:
+				other, kind = pair.StopLossID, "止盈"
+			default:
+				continue
+			}
+			if other != "" {
+				log.Printf("🔗 [Backpack] %s %s单已结束，撤销联动订单 %s", backpackSymbol, kind, other)
+				if _, err := m.t.CancelOrderWithContext(ctx, pair.Symbol, other); err != nil && !isOrderNotFound(err) {
+					// 保留联动，下一轮重试
+					log.Printf("❌ [Backpack] 撤销联动订单 %s 失败: %v", other, err)
+					continue
+				}
+			}
+			m.remove(backpackSymbol, pair)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pairs) == 0 {
+		m.running = false
+		return false
+	}
+	return true
+}
+
+// remove 移除已结束的联动单（期间被撤销时忽略）
+func (m *ocoMonitor) remove(backpackSymbol string, pair ocoPair) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	pairs := m.pairs[backpackSymbol]
+	for i, p := range pairs {
+		if p == pair {
+			pairs = append(pairs[:i], pairs[i+1:]...)
+			break
+		}
+	}
+	if len(pairs) == 0 {
+		delete(m.pairs, backpackSymbol)
+		return
+	}
+	m.pairs[backpackSymbol] = pairs
+}
+
+// orderDone 订单是否已结束（成交、撤销、过期或不在挂单中）
+func (t *BackpackTrader) orderDone(ctx context.Context, symbol, orderID string) (bool, error) {
+	order, err := t.GetOrderWithContext(ctx, symbol, orderID)
+	if isOrderNotFound(err) {
+		// 挂单接口只返回未结束的订单
+		return true, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	switch order.Status {
+	case "Filled", "Cancelled", "Expired", "Rejected", "TriggerFailed":
+		return true, nil
+	}
+	return false, nil
+}
+
+// isOrderNotFound 订单是否已不在挂单中（404）
+func isOrderNotFound(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+// SetStopLossAndTakeProfit 为已有持仓一次性挂出联动的止损和止盈（只减仓触发单），触发一个后自动撤销另一个
+// 两单在同一请求中提交，任一失败时撤销另一单并返回错误，不会只留下一侧；
+// 交易所不支持触发单时两侧都改为本地监控，同样只会触发一侧
+func (t *BackpackTrader) SetStopLossAndTakeProfit(symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error {
+	return t.SetStopLossAndTakeProfitWithContext(context.Background(), symbol, positionSide, quantity, stopPrice, takeProfitPrice)
+}
+
+// SetStopLossAndTakeProfitWithContext 同 SetStopLossAndTakeProfit，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) SetStopLossAndTakeProfitWithContext(ctx context.Context, symbol string, positionSide string, quantity, stopPrice, takeProfitPrice float64) error {
+	positionSide = strings.ToLower(positionSide)
+	if err := validateBracketLegs(positionSide, quantity, stopPrice, []BracketTarget{{Price: takeProfitPrice, Quantity: quantity}}); err != nil {
+		return fmt.Errorf("止盈止损参数无效: %w", err)
+	}
+	backpackSymbol := t.mapSymbol(symbol)
+	log.Printf("🔗 [Backpack] 设置联动止盈止损: %s %s 数量=%.4f SL=%.2f TP=%.2f", backpackSymbol, positionSide, quantity, stopPrice, takeProfitPrice)
+
+	side := "Bid" // 空仓平仓 = 买入
+	if positionSide == "long" {
+		side = "Ask"
+	}
+
+	if t.triggerUnsupported.Load() {
+		return t.addLocalProtectionPair(ctx, backpackSymbol, symbol, side, quantity, stopPrice, takeProfitPrice)
+	}
+
+	reduceOnly := LimitOrderOptions{ReduceOnly: true}
+	results := t.PlaceOrdersWithContext(ctx, []OrderRequest{
+		{Symbol: symbol, Side: side, Quantity: quantity, TriggerPrice: stopPrice, Options: reduceOnly},
+		{Symbol: symbol, Side: side, Quantity: quantity, TriggerPrice: takeProfitPrice, Options: reduceOnly},
+	})
+	sl, tp := results[0], results[1]
+	if isTriggerUnsupported(sl.Err) && isTriggerUnsupported(tp.Err) {
+		log.Printf("⚠️ [Backpack] 交易所不支持触发单，止盈止损改为本地监控: %v", sl.Err)
+		t.triggerUnsupported.Store(true)
+		return t.addLocalProtectionPair(ctx, backpackSymbol, symbol, side, quantity, stopPrice, takeProfitPrice)
+	}
+	if sl.Err != nil || tp.Err != nil {
+		// 撤销已挂出的一侧，保证要么两侧都在、要么都不在
+		for _, r := range results {
+			if r.Err != nil || r.Order == nil {
+				continue
+			}
+			if _, err := t.CancelOrderWithContext(ctx, symbol, r.Order.ID); err != nil && !isOrderNotFound(err) {
+				log.Printf("❌ [Backpack] 撤销未配对的订单 %s 失败，请手动处理: %v", r.Order.ID, err)
+			}
+		}
+		return fmt.Errorf("设置联动止盈止损失败: %w", errors.Join(sl.Err, tp.Err))
+	}
+
+	t.ocoOrders.add(backpackSymbol, ocoPair{Symbol: symbol, StopLossID: sl.Order.ID, TakeProfitID: tp.Order.ID})
+	log.Printf("✓ [Backpack] 联动止盈止损已设置: 止损 %s / 止盈 %s", sl.Order.ID, tp.Order.ID)
+	return nil
+}
+
+// addLocalProtectionPair 以本地监控设置联动止盈止损（同组只会触发一个）
+func (t *BackpackTrader) addLocalProtectionPair(ctx context.Context, backpackSymbol, symbol, side string, quantity, stopPrice, takeProfitPrice float64) error {
+	qtyStr, err := t.FormatQuantityWithContext(ctx, backpackSymbol, quantity)
+	if err != nil {
+		return fmt.Errorf("格式化止盈止损数量失败: %w", err)
+	}
+	group := fmt.Sprintf("%s@%s/%s", backpackSymbol, formatFloat(stopPrice, 8), formatFloat(takeProfitPrice, 8))
+	t.localStops.add(backpackSymbol, localStop{Symbol: symbol, Side: side, Quantity: qtyStr, TriggerPrice: stopPrice, Group: group})
+	t.localStops.add(backpackSymbol, localStop{Symbol: symbol, Side: side, Quantity: qtyStr, TriggerPrice: takeProfitPrice, TakeProfit: true, Group: group})
+	log.Printf("✓ [Backpack] 联动止盈止损已设置（本地监控）")
+	return nil
+}
@@ -0,0 +1,179 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpackTrader_SetStopLossAndTakeProfit(t *testing.T) {
+	var mu sync.Mutex
+	var placed []map[string]interface{}
+	var cancelled []string
+	open := map[string]bool{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/orders":
+			var body []map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			resp := make([]map[string]interface{}, len(body))
+			for i, order := range body {
+				id := fmt.Sprint(len(placed) + 1)
+				placed = append(placed, order)
+				open[id] = true
+				resp[i] = map[string]interface{}{"id": id, "status": "TriggerPending"}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "GET /api/v1/order":
+			id := r.URL.Query().Get("orderId")
+			if !open[id] {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"RESOURCE_NOT_FOUND","message":"Order not found"}`))
+				return
+			}
+			w.Write([]byte(`{"id":"` + id + `","status":"TriggerPending"}`))
+		case "DELETE /api/v1/order":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			id := fmt.Sprint(body["orderId"])
+			cancelled = append(cancelled, id)
+			delete(open, id)
+			w.Write([]byte(`{"id":"` + id + `","status":"Cancelled"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+	tr.ocoOrders.interval = 10 * time.Millisecond
+
+	require.Error(t, tr.SetStopLossAndTakeProfit("SOLUSDT", "LONG", 1, 110, 100), "做多止损须低于止盈")
+
+	require.NoError(t, tr.SetStopLossAndTakeProfit("SOLUSDT", "LONG", 1, 95, 110))
+	mu.Lock()
+	require.Len(t, placed, 2)
+	for _, order := range placed {
+		assert.Equal(t, "Ask", order["side"])
+		assert.Equal(t, "Market", order["orderType"])
+		assert.Equal(t, true, order["reduceOnly"])
+		assert.NotContains(t, order, "price")
+	}
+	assert.Equal(t, "95", placed[0]["triggerPrice"])
+	assert.Equal(t, "110", placed[1]["triggerPrice"])
+	mu.Unlock()
+	assert.Equal(t, 1, tr.ocoOrders.count())
+
+	// 两侧都挂着时不撤单
+	time.Sleep(50 * time.Millisecond)
+	mu.Lock()
+	assert.Empty(t, cancelled)
+	// 止损触发成交后不再在挂单中，应撤销止盈
+	delete(open, "1")
+	mu.Unlock()
+
+	require.Eventually(t, func() bool { return tr.ocoOrders.count() == 0 }, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"2"}, cancelled)
+}
+
+func TestBackpackTrader_SetStopLossAndTakeProfitRollsBack(t *testing.T) {
+	var cancelled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/orders":
+			w.Write([]byte(`[{"id":"7","status":"TriggerPending"},{"code":"INVALID_ORDER","message":"Trigger price would trigger immediately"}]`))
+		case "DELETE /api/v1/order":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			cancelled = append(cancelled, fmt.Sprint(body["orderId"]))
+			w.Write([]byte(`{"id":"7","status":"Cancelled"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+
+	err := tr.SetStopLossAndTakeProfit("SOLUSDT", "SHORT", 1, 110, 95)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "INVALID_ORDER")
+	assert.Equal(t, []string{"7"}, cancelled, "已挂出的一侧被撤销")
+	assert.Zero(t, tr.ocoOrders.count())
+}
+
+func TestLocalStopMonitor_LinkedPair(t *testing.T) {
+	var mu sync.Mutex
+	price := "100"
+	var closeOrders []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /api/v1/ticker":
+			w.Write([]byte(`{"symbol":"SOL_USDC_PERP","lastPrice":"` + price + `"}`))
+		case "POST /api/v1/order":
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			closeOrders = append(closeOrders, body)
+			w.Write([]byte(`{"id":"9","status":"Filled"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+	tr.SetResponseCache(ResponseCacheConfig{})
+	tr.localStops.interval = 10 * time.Millisecond
+	tr.triggerUnsupported.Store(true)
+
+	require.NoError(t, tr.SetStopLossAndTakeProfit("SOLUSDT", "SHORT", 1, 105, 90))
+	assert.Equal(t, 2, tr.localStops.count())
+
+	// 空仓止盈：价格跌破止盈价后平仓，并移除联动的止损
+	mu.Lock()
+	price = "89.5"
+	mu.Unlock()
+	require.Eventually(t, func() bool { return tr.localStops.count() == 0 }, time.Second, 10*time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, closeOrders, 1)
+	assert.Equal(t, "Bid", closeOrders[0]["side"])
+	assert.Equal(t, true, closeOrders[0]["reduceOnly"])
+}
+
+func TestBackpackTrader_SetTakeProfitIsReduceOnly(t *testing.T) {
+	var bodies []map[string]interface{}
+	tr := newTestBackpackTrader(t, newOrderCaptureServer(t, &bodies).URL)
+
+	require.NoError(t, tr.SetTakeProfit("DOGEUSDT", "LONG", 100, 0.13456))
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "Ask", bodies[0]["side"])
+	assert.Equal(t, "Limit", bodies[0]["orderType"])
+	assert.Equal(t, "0.13456", bodies[0]["price"])
+	assert.Equal(t, true, bodies[0]["reduceOnly"], "止盈单只能减仓")
+
+	// 响应缺少订单ID时视为失败
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/order" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"status":"New"}`))
+	}))
+	defer server.Close()
+	require.Error(t, newTestBackpackTrader(t, server.URL).SetTakeProfit("SOLUSDT", "LONG", 1, 110))
+}
@@ -0,0 +1,83 @@
+package trader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// backpackRequestTimeout 默认的单次请求超时
+const backpackRequestTimeout = 30 * time.Second
+
+// BackpackOption NewBackpackTrader 的可选配置
+type BackpackOption func(*BackpackTrader) error
+
+// WithHTTPClient 使用自定义 http.Client（超时、Transport、Cookie 等均由调用方决定）
+// Transport 为 *http.Transport 时，账户 WebSocket 推送沿用其代理、拨号和 TLS 配置
+func WithHTTPClient(client *http.Client) BackpackOption {
+	return func(t *BackpackTrader) error {
+		if client == nil {
+			return fmt.Errorf("http.Client 不能为空")
+		}
+		t.client = client
+		return nil
+	}
+}
+
+// WithTransport 替换默认的共享 Transport（自定义 TLS、DNS 固定解析等），保留默认的请求超时
+// rt 为 *http.Transport 时，账户 WebSocket 推送沿用其代理、拨号和 TLS 配置
+func WithTransport(rt http.RoundTripper) BackpackOption {
+	return func(t *BackpackTrader) error {
+		if rt == nil {
+			return fmt.Errorf("transport 不能为空")
+		}
+		t.client = &http.Client{Timeout: t.client.Timeout, Transport: rt}
+		return nil
+	}
+}
+
+// WithProxy 通过指定代理访问交易所（http/https/socks5），REST 请求和账户 WebSocket 推送都经过代理
+// 未设置时使用环境变量 HTTP_PROXY / HTTPS_PROXY / NO_PROXY
+func WithProxy(proxyURL string) BackpackOption {
+	return func(t *BackpackTrader) error {
+		u, err := url.Parse(proxyURL)
+		if err != nil {
+			return fmt.Errorf("无效的代理地址: %w", err)
+		}
+		switch u.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("不支持的代理协议 %q（应为 http/https/socks5）", u.Scheme)
+		}
+		if u.Host == "" {
+			return fmt.Errorf("代理地址缺少主机: %s", proxyURL)
+		}
+		// 共享 Transport 被所有交易器复用，代理只作用于当前交易器
+		transport := sharedBackpackTransport().Clone()
+		transport.Proxy = http.ProxyURL(u)
+		t.client = &http.Client{Timeout: t.client.Timeout, Transport: transport}
+		return nil
+	}
+}
+
+// wsDialer 账户 WebSocket 推送使用的拨号器，与 REST 请求走相同的代理、拨号和 TLS 配置
+func (t *BackpackTrader) wsDialer() *websocket.Dialer {
+	dialer := *websocket.DefaultDialer
+	transport, ok := t.client.Transport.(*http.Transport)
+	if !ok {
+		return &dialer
+	}
+	if transport.Proxy != nil {
+		dialer.Proxy = transport.Proxy
+	}
+	dialer.NetDialContext = transport.DialContext
+	if transport.TLSClientConfig != nil {
+		// HTTP/2 协商会在 NextProtos 中加入 h2，WebSocket 握手只能使用 HTTP/1.1
+		dialer.TLSClientConfig = transport.TLSClientConfig.Clone()
+		dialer.TLSClientConfig.NextProtos = []string{"http/1.1"}
+	}
+	return &dialer
+}
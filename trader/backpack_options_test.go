@@ -0,0 +1,75 @@
+package trader
+
+import (
+	"crypto/ed25519"
+	"crypto/tls"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newOptionTestTrader(t *testing.T, opts ...BackpackOption) (*BackpackTrader, error) {
+	seed := make([]byte, ed25519.SeedSize)
+	return NewBackpackTrader("test-api-key", base64.StdEncoding.EncodeToString(seed), "test-user", opts...)
+}
+
+func TestBackpackTrader_WithProxy(t *testing.T) {
+	var proxied int32
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 经过 HTTP 代理的请求行是完整的目标地址
+		if r.URL.Host == "backpack.internal" && r.URL.Path == "/api/v1/ping" {
+			atomic.AddInt32(&proxied, 1)
+			w.Write([]byte("pong"))
+			return
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer proxy.Close()
+
+	tr, err := newOptionTestTrader(t, WithProxy(proxy.URL))
+	require.NoError(t, err)
+	tr.baseURL = "http://backpack.internal/"
+	require.NoError(t, tr.WarmUp(2))
+	assert.Equal(t, int32(2), atomic.LoadInt32(&proxied))
+	assert.NotSame(t, sharedBackpackTransport(), tr.client.Transport, "代理不影响共享 Transport")
+	assert.Equal(t, backpackRequestTimeout, tr.client.Timeout)
+
+	// 账户推送走同一个代理
+	req, _ := http.NewRequest("GET", "https://ws.backpack.exchange", nil)
+	proxyURL, err := tr.wsDialer().Proxy(req)
+	require.NoError(t, err)
+	assert.Equal(t, proxy.URL, proxyURL.String())
+
+	for _, bad := range []string{"ftp://proxy:21", "http://", "://bad"} {
+		_, err := newOptionTestTrader(t, WithProxy(bad))
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestBackpackTrader_WithHTTPClientAndTransport(t *testing.T) {
+	client := &http.Client{}
+	tr, err := newOptionTestTrader(t, WithHTTPClient(client))
+	require.NoError(t, err)
+	assert.Same(t, client, tr.client)
+	assert.NotNil(t, tr.wsDialer(), "非 *http.Transport 时使用默认拨号器")
+
+	transport := &http.Transport{TLSClientConfig: &tls.Config{ServerName: "api.backpack.exchange", NextProtos: []string{"h2", "http/1.1"}}}
+	tr, err = newOptionTestTrader(t, WithTransport(transport))
+	require.NoError(t, err)
+	assert.Same(t, transport, tr.client.Transport)
+	assert.Equal(t, backpackRequestTimeout, tr.client.Timeout)
+	dialer := tr.wsDialer()
+	assert.Equal(t, "api.backpack.exchange", dialer.TLSClientConfig.ServerName)
+	assert.Equal(t, []string{"http/1.1"}, dialer.TLSClientConfig.NextProtos)
+	assert.Equal(t, []string{"h2", "http/1.1"}, transport.TLSClientConfig.NextProtos, "不修改调用方的 TLS 配置")
+
+	_, err = newOptionTestTrader(t, WithHTTPClient(nil))
+	assert.Error(t, err)
+	_, err = newOptionTestTrader(t, WithTransport(nil))
+	assert.Error(t, err)
+}
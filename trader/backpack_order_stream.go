@@ -0,0 +1,158 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// orderStreamPollInterval 账户推送已连接时查询订单状态的兜底间隔（推送可能在重连期间丢失）
+const orderStreamPollInterval = 5 * time.Second
+
+// OrderUpdate account.orderUpdate 推送的订单事件（成交事件带本次成交的价格、数量和手续费）
+type OrderUpdate struct {
+	Event                 string      `json:"e"` // orderAccepted / orderFill / orderCancelled / orderExpired / triggerPlaced ...
+	EventTime             int64       `json:"E"` // 微秒
+	Symbol                string      `json:"s"`
+	ClientID              json.Number `json:"c"`
+	Side                  string      `json:"S"`
+	OrderType             string      `json:"o"`
+	TimeInForce           string      `json:"f"`
+	Quantity              FlexFloat   `json:"q"`
+	Price                 FlexFloat   `json:"p"`
+	TriggerPrice          FlexFloat   `json:"P"`
+	Status                string      `json:"X"`
+	OrderID               string      `json:"i"`
+	ReduceOnly            bool        `json:"r"`
+	ExecutedQuantity      FlexFloat   `json:"z"`
+	ExecutedQuoteQuantity FlexFloat   `json:"Z"`
+
+	// 以下仅成交事件（orderFill）有值
+	TradeID      json.Number `json:"t"`
+	FillQuantity FlexFloat   `json:"l"`
+	FillPrice    FlexFloat   `json:"L"`
+	Maker        bool        `json:"m"`
+	Fee          FlexFloat   `json:"n"`
+	FeeSymbol    string      `json:"N"`
+}
+
+// IsFill 是否为成交事件
+func (u OrderUpdate) IsFill() bool {
+	return u.Event == "orderFill"
+}
+
+// Time 事件时间
+func (u OrderUpdate) Time() time.Time {
+	return time.UnixMicro(u.EventTime)
+}
+
+// order 转换为订单状态（用于与 REST 查询结果统一处理）
+func (u OrderUpdate) order() *Order {
+	return &Order{
+		ID:                    u.OrderID,
+		ClientID:              u.ClientID,
+		Symbol:                u.Symbol,
+		Side:                  u.Side,
+		OrderType:             u.OrderType,
+		Status:                u.Status,
+		Price:                 u.Price,
+		Quantity:              u.Quantity,
+		ExecutedQuantity:      u.ExecutedQuantity,
+		ExecutedQuoteQuantity: u.ExecutedQuoteQuantity,
+		TimeInForce:           u.TimeInForce,
+		ReduceOnly:            u.ReduceOnly,
+		TriggerPrice:          u.TriggerPrice,
+	}
+}
+
+// orderUpdateHub 订单事件的订阅者
+type orderUpdateHub struct {
+	mu   sync.Mutex
+	next int
+	subs map[int]func(OrderUpdate)
+}
+
+// subscribe 注册回调，返回取消订阅函数
+func (h *orderUpdateHub) subscribe(fn func(OrderUpdate)) func() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subs == nil {
+		h.subs = make(map[int]func(OrderUpdate))
+	}
+	h.next++
+	id := h.next
+	h.subs[id] = fn
+	return func() {
+		h.mu.Lock()
+		delete(h.subs, id)
+		h.mu.Unlock()
+	}
+}
+
+// publish 依次调用回调（在推送读取协程中执行，回调不应阻塞）
+func (h *orderUpdateHub) publish(update OrderUpdate) {
+	h.mu.Lock()
+	subs := make([]func(OrderUpdate), 0, len(h.subs))
+	for _, fn := range h.subs {
+		subs = append(subs, fn)
+	}
+	h.mu.Unlock()
+	for _, fn := range subs {
+		fn(update)
+	}
+}
+
+// Connected 推送是否已连接
+func (s *BackpackAccountStream) Connected() bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.connected
+}
+
+// OnOrderUpdate 订阅订单和成交推送（需先 EnableAccountStream），返回取消订阅函数
+// 回调在推送读取协程中执行，不应阻塞；断线重连期间的事件会丢失，需要可靠状态时仍应以 REST 查询为准
+func (t *BackpackTrader) OnOrderUpdate(fn func(OrderUpdate)) (func(), error) {
+	if t.accountStream == nil {
+		return nil, fmt.Errorf("未启用账户推送")
+	}
+	return t.accountStream.orders.subscribe(fn), nil
+}
+
+// OrderUpdates 以通道形式订阅订单和成交推送，buffer 为通道容量（<=0 时为64）
+// 通道满时丢弃新事件；取消订阅后不再写入，通道不会被关闭
+func (t *BackpackTrader) OrderUpdates(buffer int) (<-chan OrderUpdate, func(), error) {
+	if buffer <= 0 {
+		buffer = 64
+	}
+	ch := make(chan OrderUpdate, buffer)
+	unsubscribe, err := t.OnOrderUpdate(func(update OrderUpdate) {
+		select {
+		case ch <- update:
+		default:
+		}
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return ch, unsubscribe, nil
+}
+
+// orderUpdatesFor 订阅单个订单的推送；账户推送未连接时返回 nil 通道（从 nil 通道接收会一直阻塞，select 中相当于没有这个分支）
+func (t *BackpackTrader) orderUpdatesFor(orderID string) (<-chan OrderUpdate, func()) {
+	stream := t.accountStream
+	if stream == nil || !stream.Connected() {
+		return nil, func() {}
+	}
+	ch := make(chan OrderUpdate, 16)
+	unsubscribe := stream.orders.subscribe(func(update OrderUpdate) {
+		if update.OrderID != orderID {
+			return
+		}
+		select {
+		case ch <- update:
+		default:
+		}
+	})
+	return ch, unsubscribe
+}
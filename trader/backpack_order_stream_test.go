@@ -0,0 +1,94 @@
+package trader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpackTrader_OrderUpdates(t *testing.T) {
+	tr := &BackpackTrader{}
+	_, err := tr.OnOrderUpdate(func(OrderUpdate) {})
+	assert.Error(t, err, "未启用账户推送")
+
+	stream := newBackpackAccountStream(tr, "")
+	tr.accountStream = stream
+	var callbacks int32
+	unsubscribe, err := tr.OnOrderUpdate(func(OrderUpdate) { atomic.AddInt32(&callbacks, 1) })
+	require.NoError(t, err)
+	updates, stop, err := tr.OrderUpdates(1)
+	require.NoError(t, err)
+	defer stop()
+
+	stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderFill","E":1714000000000000,"s":"SOL_USDC_PERP","c":42,"S":"Bid","o":"Limit","q":"2","p":"100","X":"PartiallyFilled","i":"111","t":567,"l":"0.5","L":"99.9","m":true,"n":"0.01","N":"USDC","z":"0.5","Z":"49.95"}}`))
+	select {
+	case update := <-updates:
+		assert.True(t, update.IsFill())
+		assert.Equal(t, "111", update.OrderID)
+		assert.Equal(t, "PartiallyFilled", update.Status)
+		assert.Equal(t, 0.5, update.FillQuantity.Float64())
+		assert.Equal(t, 99.9, update.FillPrice.Float64())
+		assert.True(t, update.Maker)
+		assert.Equal(t, "USDC", update.FeeSymbol)
+		assert.Equal(t, "567", update.TradeID.String())
+		assert.Equal(t, int64(1714000000), update.Time().Unix())
+		order := update.order()
+		assert.Equal(t, 2.0, order.Quantity.Float64())
+		assert.Equal(t, 49.95, order.ExecutedQuoteQuantity.Float64())
+	default:
+		t.Fatal("应通过通道收到成交推送")
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&callbacks))
+	assert.Len(t, stream.refreshCh, 1, "成交后刷新权益")
+
+	// 通道满时丢弃，不阻塞推送读取
+	stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderAccepted","i":"1"}}`))
+	stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderAccepted","i":"2"}}`))
+	assert.Len(t, updates, 1)
+
+	unsubscribe()
+	stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderAccepted","i":"3"}}`))
+	assert.Equal(t, int32(3), atomic.LoadInt32(&callbacks))
+}
+
+func TestBackpackTrader_WaitForOrderFillUsesStream(t *testing.T) {
+	var polls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.Method == "GET" && r.URL.Path == "/api/v1/order" {
+			atomic.AddInt32(&polls, 1)
+			w.Write([]byte(`{"id":"111","side":"Bid","status":"New","quantity":"1","executedQuantity":"0"}`))
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+
+	tr := newTestBackpackTrader(t, server.URL)
+	stream := newBackpackAccountStream(tr, "")
+	stream.setConnected(true)
+	tr.accountStream = stream
+
+	go func() {
+		// 等到订阅和首次查询完成后推送成交
+		for atomic.LoadInt32(&polls) == 0 {
+			time.Sleep(5 * time.Millisecond)
+		}
+		stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderFill","s":"SOL_USDC_PERP","i":"999","X":"Filled","q":"3","z":"3","Z":"300"}}`))
+		stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderFill","s":"SOL_USDC_PERP","i":"111","X":"PartiallyFilled","q":"1","z":"0.4","Z":"40"}}`))
+		stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderFill","s":"SOL_USDC_PERP","i":"111","X":"Filled","q":"1","z":"1","Z":"101"}}`))
+	}()
+
+	start := time.Now()
+	result, err := tr.WaitForOrderFill("SOLUSDT", "111", 10, FillTimeoutWait)
+	require.NoError(t, err)
+	assert.True(t, result.Filled())
+	assert.InDelta(t, 101, result.AvgPrice, 1e-9)
+	assert.Less(t, time.Since(start), orderStreamPollInterval, "推送成交后立即返回")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&polls), "推送有效时只查询一次")
+}
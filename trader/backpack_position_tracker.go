@@ -0,0 +1,142 @@
+package trader
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"nofx/market"
+)
+
+// backpackPositionUpdate account.positionUpdate 推送数据（positionAdjusted 等事件带完整的持仓字段）
+type backpackPositionUpdate struct {
+	Event               string      `json:"e"` // positionOpened / positionAdjusted / positionClosed
+	EventTime           int64       `json:"E"` // 微秒
+	Symbol              string      `json:"s"`
+	BreakEvenPrice      FlexFloat   `json:"b"`
+	EntryPrice          FlexFloat   `json:"B"`
+	EstLiquidationPrice FlexFloat   `json:"l"`
+	IMF                 FlexFloat   `json:"f"`
+	MarkPrice           FlexFloat   `json:"M"`
+	MMF                 FlexFloat   `json:"m"`
+	NetQuantity         FlexFloat   `json:"q"`
+	NetExposureQuantity FlexFloat   `json:"Q"`
+	NetExposureNotional FlexFloat   `json:"n"`
+	PositionID          json.Number `json:"i"`
+	PnlRealized         FlexFloat   `json:"p"`
+	PnlUnrealized       FlexFloat   `json:"P"`
+}
+
+// closed 推送是否表示持仓已平
+func (u backpackPositionUpdate) closed() bool {
+	return u.Event == "positionClosed" || u.NetQuantity.Float64() == 0
+}
+
+// position 转换为与 REST 查询一致的持仓
+func (u backpackPositionUpdate) position() Position {
+	return Position{
+		Symbol:              u.Symbol,
+		PositionID:          u.PositionID.String(),
+		NetQuantity:         u.NetQuantity,
+		NetExposureQuantity: u.NetExposureQuantity,
+		NetExposureNotional: u.NetExposureNotional,
+		EntryPrice:          u.EntryPrice,
+		BreakEvenPrice:      u.BreakEvenPrice,
+		MarkPrice:           u.MarkPrice,
+		EstLiquidationPrice: u.EstLiquidationPrice,
+		IMF:                 u.IMF,
+		MMF:                 u.MMF,
+		PnlUnrealized:       u.PnlUnrealized,
+		PnlRealized:         u.PnlRealized,
+	}
+}
+
+// positionTracker 由 REST 快照初始化、持仓推送实时更新的本地持仓
+type positionTracker struct {
+	mu        sync.RWMutex
+	positions map[string]Position  // 标准格式交易对 -> 持仓
+	pushedAt  map[string]time.Time // 最近一次推送的接收时间（含已平仓的交易对）
+}
+
+// reset 用 REST 快照替换持仓；快照请求发出（since）之后收到推送的交易对以推送为准，避免被较旧的快照覆盖
+func (p *positionTracker) reset(positions []Position, since time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	next := make(map[string]Position, len(positions))
+	for _, pos := range positions {
+		next[market.Normalize(pos.Symbol)] = pos
+	}
+	for symbol, at := range p.pushedAt {
+		if !at.After(since) {
+			delete(p.pushedAt, symbol)
+			continue
+		}
+		if pos, ok := p.positions[symbol]; ok {
+			next[symbol] = pos
+		} else {
+			delete(next, symbol)
+		}
+	}
+	p.positions = next
+}
+
+// apply 应用一条持仓推送
+func (p *positionTracker) apply(update backpackPositionUpdate, at time.Time) {
+	symbol := market.Normalize(update.Symbol)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.positions == nil {
+		p.positions = make(map[string]Position)
+	}
+	if p.pushedAt == nil {
+		p.pushedAt = make(map[string]time.Time)
+	}
+	if update.closed() {
+		delete(p.positions, symbol)
+	} else {
+		p.positions[symbol] = update.position()
+	}
+	p.pushedAt[symbol] = at
+}
+
+// snapshot 当前持仓（按交易对排序）
+func (p *positionTracker) snapshot() []Position {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	positions := make([]Position, 0, len(p.positions))
+	for _, pos := range p.positions {
+		positions = append(positions, pos)
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions
+}
+
+// Positions 返回推送维护的持仓；未连接或快照过期时返回 false
+func (s *BackpackAccountStream) Positions() ([]Position, bool) {
+	s.mu.RLock()
+	fresh := s.connected && time.Since(s.refreshedAt) <= accountStreamMaxAge
+	s.mu.RUnlock()
+	if !fresh {
+		return nil, false
+	}
+	return s.positions.snapshot(), true
+}
+
+// streamPositions 账户推送可用时返回本地维护的持仓
+func (t *BackpackTrader) streamPositions() ([]Position, bool) {
+	if t.accountStream == nil {
+		return nil, false
+	}
+	return t.accountStream.Positions()
+}
+
+// GetPositionsCached 获取当前持仓：启用账户推送（EnableAccountStream）且已连接时直接返回本地维护的持仓，不请求 REST；
+// 未启用、断线或快照过期时回退到 GetPositions。适合策略循环每个 tick 调用
+func (t *BackpackTrader) GetPositionsCached() ([]map[string]interface{}, error) {
+	if positions, ok := t.streamPositions(); ok {
+		return positionMaps(positions), nil
+	}
+	return t.GetPositions()
+}
@@ -0,0 +1,98 @@
+package trader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPositionTracker_ResetKeepsNewerPushes(t *testing.T) {
+	var tracker positionTracker
+	since := time.Now()
+	tracker.apply(backpackPositionUpdate{Event: "positionOpened", Symbol: "ETH_USDC_PERP", NetQuantity: 1, EntryPrice: 3000}, since.Add(-time.Second))
+	tracker.apply(backpackPositionUpdate{Event: "positionAdjusted", Symbol: "SOL_USDC_PERP", NetQuantity: -3, EntryPrice: 150}, since.Add(time.Millisecond))
+	tracker.apply(backpackPositionUpdate{Event: "positionClosed", Symbol: "BTC_USDC_PERP"}, since.Add(time.Millisecond))
+
+	// 快照在推送之前发出：SOL 以推送为准，BTC 已平仓，ETH 的推送早于快照，以快照为准（已平）
+	tracker.reset([]Position{
+		{Symbol: "SOL_USDC_PERP", NetQuantity: -2, EntryPrice: 150},
+		{Symbol: "BTC_USDC_PERP", NetQuantity: 0.1, EntryPrice: 60000},
+		{Symbol: "DOGE_USDC_PERP", NetQuantity: 100, EntryPrice: 0.1},
+	}, since)
+
+	positions := tracker.snapshot()
+	require.Len(t, positions, 2)
+	assert.Equal(t, "DOGE_USDC_PERP", positions[0].Symbol)
+	assert.Equal(t, "SOL_USDC_PERP", positions[1].Symbol)
+	assert.Equal(t, -3.0, positions[1].NetQuantity.Float64())
+
+	// 下一次快照晚于所有推送，完全以快照为准
+	tracker.reset([]Position{{Symbol: "BTC_USDC_PERP", NetQuantity: 0.2}}, time.Now().Add(time.Second))
+	positions = tracker.snapshot()
+	require.Len(t, positions, 1)
+	assert.Equal(t, "BTC_USDC_PERP", positions[0].Symbol)
+	assert.Empty(t, tracker.pushedAt)
+}
+
+func TestBackpackTrader_GetPositionsCached(t *testing.T) {
+	var positionRequests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/position":
+			atomic.AddInt32(&positionRequests, 1)
+			w.Write([]byte(`[{"symbol":"SOL_USDC_PERP","netQuantity":"2","entryPrice":"140","markPrice":"141","pnlUnrealized":"2","imf":"0.1"}]`))
+		case "/api/v1/capital/collateral":
+			w.Write([]byte(`{"netEquity":"1000","netEquityAvailable":"800","pnlUnrealized":"2"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	tr := newTestBackpackTrader(t, server.URL)
+
+	// 未启用推送：每次请求 REST
+	positions, err := tr.GetPositionsCached()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&positionRequests))
+
+	stream := newBackpackAccountStream(tr, "")
+	require.NoError(t, stream.refresh())
+	tr.accountStream = stream
+	_, err = tr.GetPositionsCached()
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&positionRequests), "未连接时回退到 REST")
+
+	stream.setConnected(true)
+	before := atomic.LoadInt32(&positionRequests)
+	stream.handleMessage([]byte(`{"stream":"account.positionUpdate","data":{"e":"positionAdjusted","s":"SOL_USDC_PERP","B":"139","M":"145","P":"12","q":"2.5","f":"0.2","i":77}}`))
+	stream.handleMessage([]byte(`{"stream":"account.positionUpdate","data":{"e":"positionOpened","s":"ETH_USDC_PERP","B":"3000","M":"3000","P":"0","q":"-0.5","f":"0.1"}}`))
+
+	positions, err = tr.GetPositionsCached()
+	require.NoError(t, err)
+	require.Len(t, positions, 2)
+	assert.Equal(t, "ETHUSDT", positions[0]["symbol"])
+	assert.Equal(t, "short", positions[0]["side"])
+	assert.Equal(t, "SOLUSDT", positions[1]["symbol"])
+	assert.Equal(t, 2.5, positions[1]["positionAmt"])
+	assert.Equal(t, 139.0, positions[1]["entryPrice"])
+	assert.Equal(t, 12.0, positions[1]["unRealizedProfit"])
+	assert.Equal(t, 5.0, positions[1]["leverage"])
+
+	stream.handleMessage([]byte(`{"stream":"account.positionUpdate","data":{"e":"positionClosed","s":"ETH_USDC_PERP","q":"0"}}`))
+	positions, err = tr.GetPositionsCached()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, before, atomic.LoadInt32(&positionRequests), "推送有效时不请求 REST")
+
+	stream.setConnected(false)
+	_, ok := stream.Positions()
+	assert.False(t, ok)
+}
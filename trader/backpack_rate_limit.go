@@ -0,0 +1,113 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimitConfig Backpack 请求令牌桶限流配置
+// 所有公开和认证请求共享同一个令牌桶，按 EndpointWeights 扣减令牌（未配置的接口权重为1）
+type RateLimitConfig struct {
+	RequestsPerSecond float64        // 令牌补充速率（<=0 表示不限流）
+	Burst             int            // 桶容量，即允许的瞬时突发请求数（<=0 时取 ceil(RequestsPerSecond)）
+	EndpointWeights   map[string]int // 接口路径 -> 权重，如 "/wapi/v1/history/fills": 5
+}
+
+// Enabled 是否启用限流
+func (c RateLimitConfig) Enabled() bool {
+	return c.RequestsPerSecond > 0
+}
+
+// requestLimiter 令牌桶：令牌不足时等待补充，而不是把请求发出去换一个 429
+type requestLimiter struct {
+	rate    float64
+	burst   float64
+	weights map[string]int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+	now    func() time.Time
+}
+
+func newRequestLimiter(cfg RateLimitConfig) *requestLimiter {
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(cfg.RequestsPerSecond))
+	}
+	weights := make(map[string]int, len(cfg.EndpointWeights))
+	for path, w := range cfg.EndpointWeights {
+		weights[path] = w
+	}
+	return &requestLimiter{
+		rate:    cfg.RequestsPerSecond,
+		burst:   float64(burst),
+		weights: weights,
+		tokens:  float64(burst),
+		now:     time.Now,
+	}
+}
+
+// weight 返回接口权重（超过桶容量时按桶容量计，否则永远等不到足够的令牌）
+func (l *requestLimiter) weight(path string) float64 {
+	w, ok := l.weights[path]
+	if !ok || w <= 0 {
+		w = 1
+	}
+	return math.Min(float64(w), l.burst)
+}
+
+// reserve 扣减令牌，返回需要等待的时长（0 表示可立即发送）
+// 令牌允许透支，等待期间后续请求按顺序排在后面
+func (l *requestLimiter) reserve(n float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if !l.last.IsZero() {
+		l.tokens = math.Min(l.burst, l.tokens+now.Sub(l.last).Seconds()*l.rate)
+	}
+	l.last = now
+	l.tokens -= n
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// cancel 归还未使用的令牌（等待被 ctx 取消时）
+func (l *requestLimiter) cancel(n float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens = math.Min(l.burst, l.tokens+n)
+}
+
+// Wait 按接口权重等待令牌，ctx 取消时返回 ctx.Err()
+func (l *requestLimiter) Wait(ctx context.Context, path string) error {
+	n := l.weight(path)
+	wait := l.reserve(n)
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		l.cancel(n)
+		return fmt.Errorf("等待限流令牌时取消: %w", ctx.Err())
+	}
+}
+
+// SetRateLimit 启用请求令牌桶限流（cfg.RequestsPerSecond<=0 时关闭）
+func (t *BackpackTrader) SetRateLimit(cfg RateLimitConfig) {
+	if !cfg.Enabled() {
+		t.limiter = nil
+		return
+	}
+	t.limiter = newRequestLimiter(cfg)
+}
@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestLimiter_ReserveRefillsAndWeights(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newRequestLimiter(RateLimitConfig{
+		RequestsPerSecond: 10,
+		Burst:             5,
+		EndpointWeights:   map[string]int{"/wapi/v1/history/fills": 3, "/huge": 100},
+	})
+	l.now = func() time.Time { return now }
+
+	// 桶满时突发5个权重1请求无需等待
+	for i := 0; i < 5; i++ {
+		assert.Zero(t, l.reserve(l.weight("/api/v1/ticker")))
+	}
+	// 第6个需要等待 0.1 秒
+	assert.Equal(t, 100*time.Millisecond, l.reserve(1))
+
+	// 1秒后补满（上限为桶容量），权重3的接口扣3个
+	now = now.Add(time.Second)
+	assert.Zero(t, l.reserve(l.weight("/wapi/v1/history/fills")))
+	assert.InDelta(t, 2, l.tokens, 1e-9)
+
+	// 权重超过桶容量时按桶容量计
+	assert.Equal(t, 5.0, l.weight("/huge"))
+}
+
+func TestBackpackTrader_RateLimitSpacesRequests(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"lastPrice":"100"}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	trader.SetRateLimit(RateLimitConfig{RequestsPerSecond: 20, Burst: 2})
+
+	start := time.Now()
+	for i := 0; i < 4; i++ {
+		_, err := trader.GetMarketPrice("SOLUSDT")
+		require.NoError(t, err)
+	}
+	// 突发2个之后，剩余2个按 50ms 间隔发出
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&hits))
+
+	// 等待令牌时 ctx 取消，请求不发出
+	trader.SetRateLimit(RateLimitConfig{RequestsPerSecond: 0.1, Burst: 1})
+	_, err := trader.GetMarketPrice("SOLUSDT")
+	require.NoError(t, err)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = trader.GetMarketPriceWithContext(ctx, "SOLUSDT")
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Equal(t, int32(5), atomic.LoadInt32(&hits))
+
+	trader.SetRateLimit(RateLimitConfig{})
+	assert.Nil(t, trader.limiter)
+}
@@ -0,0 +1,134 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ResponseCacheConfig 幂等 GET 接口的响应缓存有效期（端点路径 -> TTL，未配置或 <=0 的端点不缓存）
+type ResponseCacheConfig map[string]time.Duration
+
+// DefaultResponseCacheConfig 默认只缓存市场信息：精度等静态数据几乎不变，行情和账户数据实时读取
+var DefaultResponseCacheConfig = ResponseCacheConfig{
+	"/api/v1/markets": 5 * time.Minute,
+}
+
+// Enabled 是否有端点启用缓存
+func (c ResponseCacheConfig) Enabled() bool {
+	for _, ttl := range c {
+		if ttl > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// responseCache 按 端点+查询参数 缓存原始响应体
+// 同一个 key 的并发请求合并为一次网络请求，其余调用方等待结果
+type responseCache struct {
+	mu       sync.Mutex
+	ttls     ResponseCacheConfig
+	entries  map[string]cachedResponse
+	inflight map[string]*inflightResponse
+}
+
+type cachedResponse struct {
+	body    []byte
+	expires time.Time
+}
+
+type inflightResponse struct {
+	done chan struct{}
+	body []byte
+	err  error
+}
+
+func newResponseCache(cfg ResponseCacheConfig) *responseCache {
+	ttls := make(ResponseCacheConfig, len(cfg))
+	for endpoint, ttl := range cfg {
+		ttls[endpoint] = ttl
+	}
+	return &responseCache{
+		ttls:     ttls,
+		entries:  make(map[string]cachedResponse),
+		inflight: make(map[string]*inflightResponse),
+	}
+}
+
+// get 返回缓存的响应体，过期或未缓存时调用 fetch；出错的响应不缓存
+func (c *responseCache) get(ctx context.Context, endpoint, query string, fetch func() ([]byte, error)) ([]byte, error) {
+	if c == nil {
+		return fetch()
+	}
+	key := endpoint + "?" + query
+
+	c.mu.Lock()
+	ttl := c.ttls[endpoint]
+	if ttl <= 0 {
+		c.mu.Unlock()
+		return fetch()
+	}
+	if entry, ok := c.entries[key]; ok && time.Now().Before(entry.expires) {
+		c.mu.Unlock()
+		return entry.body, nil
+	}
+	if call, ok := c.inflight[key]; ok {
+		c.mu.Unlock()
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		// 发起请求的调用方被取消时，不把它的取消错误传给其他调用方
+		if isContextError(call.err) && ctx.Err() == nil {
+			return fetch()
+		}
+		return call.body, call.err
+	}
+	call := &inflightResponse{done: make(chan struct{})}
+	c.inflight[key] = call
+	c.mu.Unlock()
+
+	call.body, call.err = fetch()
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if call.err == nil {
+		c.entries[key] = cachedResponse{body: call.body, expires: time.Now().Add(ttl)}
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.body, call.err
+}
+
+// invalidate 清除端点的全部缓存（如下单后清除余额缓存）
+func (c *responseCache) invalidate(endpoint string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	prefix := endpoint + "?"
+	for key := range c.entries {
+		if strings.HasPrefix(key, prefix) {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// SetResponseCache 设置幂等 GET 接口的响应缓存有效期（替换默认配置；空配置表示不缓存）
+// 可缓存的端点：/api/v1/markets、/api/v1/ticker、/api/v1/capital/collateral
+func (t *BackpackTrader) SetResponseCache(cfg ResponseCacheConfig) {
+	if !cfg.Enabled() {
+		t.responses = nil
+		return
+	}
+	t.responses = newResponseCache(cfg)
+}
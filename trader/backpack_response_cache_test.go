@@ -0,0 +1,139 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResponseCache_CoalescesAndExpires(t *testing.T) {
+	cache := newResponseCache(ResponseCacheConfig{"/api/v1/ticker": 50 * time.Millisecond})
+	var fetches int32
+	release := make(chan struct{})
+	fetch := func() ([]byte, error) {
+		atomic.AddInt32(&fetches, 1)
+		<-release
+		return []byte("ok"), nil
+	}
+
+	// 并发请求合并为一次
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			body, err := cache.get(context.Background(), "/api/v1/ticker", "symbol=SOL_USDC_PERP", fetch)
+			assert.NoError(t, err)
+			assert.Equal(t, "ok", string(body))
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+
+	// 有效期内读缓存，不同参数分别缓存
+	cache.get(context.Background(), "/api/v1/ticker", "symbol=SOL_USDC_PERP", fetch)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&fetches))
+	cache.get(context.Background(), "/api/v1/ticker", "symbol=BTC_USDC_PERP", fetch)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&fetches))
+
+	time.Sleep(60 * time.Millisecond)
+	cache.get(context.Background(), "/api/v1/ticker", "symbol=SOL_USDC_PERP", fetch)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&fetches), "过期后重新请求")
+
+	cache.invalidate("/api/v1/ticker")
+	cache.get(context.Background(), "/api/v1/ticker", "symbol=SOL_USDC_PERP", fetch)
+	assert.Equal(t, int32(4), atomic.LoadInt32(&fetches))
+
+	// 未配置的端点不缓存
+	cache.get(context.Background(), "/api/v1/depth", "", fetch)
+	cache.get(context.Background(), "/api/v1/depth", "", fetch)
+	assert.Equal(t, int32(6), atomic.LoadInt32(&fetches))
+}
+
+func TestResponseCache_ErrorsNotCached(t *testing.T) {
+	cache := newResponseCache(ResponseCacheConfig{"/api/v1/markets": time.Minute})
+	calls := 0
+	fetch := func() ([]byte, error) {
+		calls++
+		if calls == 1 {
+			return nil, fmt.Errorf("boom")
+		}
+		return []byte("[]"), nil
+	}
+
+	_, err := cache.get(context.Background(), "/api/v1/markets", "", fetch)
+	require.Error(t, err)
+	body, err := cache.get(context.Background(), "/api/v1/markets", "", fetch)
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(body))
+	assert.Equal(t, 2, calls)
+}
+
+func TestBackpackTrader_ResponseCache(t *testing.T) {
+	var markets, tickers, collateral int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/markets":
+			atomic.AddInt32(&markets, 1)
+			var items []string
+			for i := 0; i < 20; i++ {
+				items = append(items, fmt.Sprintf(`{"symbol":"C%d_USDC_PERP","filters":{"quantity":{"stepSize":"0.01"}}}`, i))
+			}
+			w.Write([]byte("[" + strings.Join(items, ",") + "]"))
+		case "/api/v1/ticker":
+			atomic.AddInt32(&tickers, 1)
+			w.Write([]byte(`{"lastPrice":"150"}`))
+		case "/api/v1/capital/collateral":
+			atomic.AddInt32(&collateral, 1)
+			w.Write([]byte(`{"netEquity":"1000","netEquityAvailable":"900"}`))
+		case "/api/v1/order":
+			w.Write([]byte(`{"id":"1","status":"Filled"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	// 默认只缓存市场信息：20 个交易对的精度只请求一次
+	trader := newTestBackpackTrader(t, server.URL)
+	for i := 0; i < 20; i++ {
+		_, err := trader.FormatQuantity(fmt.Sprintf("C%d_USDC_PERP", i), 1.234)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, int32(1), atomic.LoadInt32(&markets))
+	trader.GetMarketPrice("SOLUSDT")
+	trader.GetMarketPrice("SOLUSDT")
+	assert.Equal(t, int32(2), atomic.LoadInt32(&tickers), "默认不缓存行情")
+
+	trader.SetResponseCache(ResponseCacheConfig{"/api/v1/ticker": time.Minute, "/api/v1/capital/collateral": time.Minute})
+	trader.GetMarketPrice("SOLUSDT")
+	trader.GetMarketPrice("SOLUSDT")
+	assert.Equal(t, int32(3), atomic.LoadInt32(&tickers))
+
+	_, err := trader.AccountBalance(context.Background())
+	require.NoError(t, err)
+	trader.AccountBalance(context.Background())
+	assert.Equal(t, int32(1), atomic.LoadInt32(&collateral))
+
+	// 下单后余额缓存失效
+	_, err = trader.OpenLong("SOLUSDT", 1, 5)
+	require.NoError(t, err)
+	trader.AccountBalance(context.Background())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&collateral))
+
+	trader.SetResponseCache(ResponseCacheConfig{})
+	trader.GetMarketPrice("SOLUSDT")
+	assert.Equal(t, int32(4), atomic.LoadInt32(&tickers), "空配置关闭缓存")
+}
@@ -0,0 +1,119 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand"
+	"net"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultRetryBaseDelay = 200 * time.Millisecond
+	defaultRetryMaxDelay  = 5 * time.Second
+)
+
+// RetryPolicy Backpack 请求失败重试策略（指数退避 + 随机抖动）
+// 重试 429、5xx 和网络超时；下单（orderExecute）、提现（withdraw）和子账户划转（subaccountTransfer）请求结果不确定时不重试，只重试明确未被处理的 429
+type RetryPolicy struct {
+	MaxAttempts int           // 最多请求次数（含首次，<=1 表示不重试）
+	BaseDelay   time.Duration // 首次重试前的等待（<=0 时为200ms），之后每次翻倍
+	MaxDelay    time.Duration // 单次等待上限（<=0 时为5s）；429 要求的等待超过该值时放弃重试
+}
+
+// Enabled 是否启用重试
+func (p RetryPolicy) Enabled() bool {
+	return p.MaxAttempts > 1
+}
+
+// delay 第 attempt 次失败后的等待时长，在 [d/2, d] 之间随机抖动，避免多个实例同时重试
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	base, max := p.BaseDelay, p.MaxDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+	if max <= 0 {
+		max = defaultRetryMaxDelay
+	}
+	d := base
+	for i := 1; i < attempt && d < max; i++ {
+		d *= 2
+	}
+	if d > max {
+		d = max
+	}
+	half := d / 2
+	return half + time.Duration(rand.Int63n(int64(d-half)+1))
+}
+
+// maxDelay 单次等待上限
+func (p RetryPolicy) maxDelay() time.Duration {
+	if p.MaxDelay <= 0 {
+		return defaultRetryMaxDelay
+	}
+	return p.MaxDelay
+}
+
+// retryDelay 判断错误是否可以重试，返回重试前需要等待的时长
+func (p RetryPolicy) retryDelay(instruction string, attempt int, err error) (time.Duration, bool) {
+	wait := p.delay(attempt)
+
+	var rateErr *RateLimitError
+	if errors.As(err, &rateErr) {
+		// 418 封禁时长通常以分钟计，重试没有意义
+		if rateErr.StatusCode == http.StatusTeapot || rateErr.RetryAfter > p.maxDelay() {
+			return 0, false
+		}
+		if rateErr.RetryAfter > wait {
+			wait = rateErr.RetryAfter
+		}
+		return wait, true
+	}
+
+	// 下单、提现和划转请求在 5xx/超时时可能已被交易所接受，重试会重复下单/提现/划转
+	if instruction == "orderExecute" || instruction == "withdraw" || instruction == "subaccountTransfer" {
+		return 0, false
+	}
+
+	var apiErr *APIError
+	if errors.As(err, &apiErr) {
+		return wait, apiErr.StatusCode >= 500
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return wait, true
+	}
+	return 0, false
+}
+
+// SetRetryPolicy 设置请求失败重试策略（policy.MaxAttempts<=1 时关闭）
+func (t *BackpackTrader) SetRetryPolicy(policy RetryPolicy) {
+	t.retry = policy
+}
+
+// withRetry 按重试策略执行 send；每次尝试都重新构建请求，保证签名和时间戳是新的
+// instruction 为认证请求的指令类型（公开请求为端点路径）
+func (t *BackpackTrader) withRetry(ctx context.Context, instruction string, send func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+		if err == nil || attempt >= t.retry.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+		wait, ok := t.retry.retryDelay(instruction, attempt, err)
+		if !ok {
+			return nil, err
+		}
+
+		log.Printf("🔁 [Backpack] %s 第%d次请求失败: %v，%.1f秒后重试", instruction, attempt, err, wait.Seconds())
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, fmt.Errorf("等待重试时取消: %w (上次错误: %v)", ctx.Err(), err)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryPolicy_DelayGrowsWithJitter(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 5, BaseDelay: 100 * time.Millisecond, MaxDelay: 300 * time.Millisecond}
+	for i := 0; i < 20; i++ {
+		d := p.delay(1)
+		assert.True(t, d >= 50*time.Millisecond && d <= 100*time.Millisecond, "第1次: %v", d)
+		d = p.delay(2)
+		assert.True(t, d >= 100*time.Millisecond && d <= 200*time.Millisecond, "第2次: %v", d)
+		d = p.delay(5)
+		assert.True(t, d >= 150*time.Millisecond && d <= 300*time.Millisecond, "封顶: %v", d)
+	}
+	assert.False(t, RetryPolicy{MaxAttempts: 1}.Enabled())
+}
+
+func TestRetryPolicy_RetryDelay(t *testing.T) {
+	p := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Second}
+
+	_, ok := p.retryDelay("orderQuery", 1, &APIError{StatusCode: 503})
+	assert.True(t, ok, "5xx")
+	_, ok = p.retryDelay("orderQuery", 1, &APIError{StatusCode: 400})
+	assert.False(t, ok, "4xx 不重试")
+
+	wait, ok := p.retryDelay("orderExecute", 1, &RateLimitError{StatusCode: 429, RetryAfter: 500 * time.Millisecond})
+	assert.True(t, ok, "429 未被处理，下单也可重试")
+	assert.Equal(t, 500*time.Millisecond, wait, "至少等待 Retry-After")
+	_, ok = p.retryDelay("orderQuery", 1, &RateLimitError{StatusCode: 429, RetryAfter: time.Minute})
+	assert.False(t, ok, "Retry-After 超过单次等待上限")
+	_, ok = p.retryDelay("orderQuery", 1, &RateLimitError{StatusCode: 418, RetryAfter: 0})
+	assert.False(t, ok, "封禁不重试")
+
+	_, ok = p.retryDelay("orderExecute", 1, &APIError{StatusCode: 502})
+	assert.False(t, ok, "下单 5xx 可能已成交")
+	_, ok = p.retryDelay("orderQuery", 1, errors.New("boom"))
+	assert.False(t, ok)
+}
+
+func TestBackpackTrader_RetriesTransientErrorsWithFreshSignature(t *testing.T) {
+	var calls int32
+	var mu sync.Mutex
+	var signatures []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		signatures = append(signatures, r.Header.Get("X-TIMESTAMP")+"|"+r.Header.Get("X-SIGNATURE"))
+		mu.Unlock()
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","status":"New"}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	trader.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: 2 * time.Millisecond})
+
+	order, err := trader.GetOrder("SOLUSDT", "1")
+	require.NoError(t, err)
+	assert.Equal(t, "New", order.Status)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+	mu.Lock()
+	defer mu.Unlock()
+	assert.NotEqual(t, signatures[0], signatures[1], "每次重试重新签名")
+	assert.NotEqual(t, signatures[1], signatures[2])
+}
+
+func TestBackpackTrader_RetryGivesUp(t *testing.T) {
+	var calls, orders int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if r.URL.Path == "/api/v1/order" && r.Method == "POST" {
+			atomic.AddInt32(&orders, 1)
+		}
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	trader.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	// 次数用尽后返回最后一次错误
+	_, err := trader.GetOrder("SOLUSDT", "1")
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusBadGateway, apiErr.StatusCode)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// 下单不重试
+	_, err = trader.OpenLong("SOLUSDT", 1, 5)
+	require.Error(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&orders))
+
+	// 等待重试期间 ctx 取消
+	atomic.StoreInt32(&calls, 0)
+	trader.SetRetryPolicy(RetryPolicy{MaxAttempts: 5, BaseDelay: time.Second})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err = trader.GetMarketPriceWithContext(ctx, "SOLUSDT")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
@@ -0,0 +1,218 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// backpackMaxScaledLevels 分批挂单的最大档数
+const backpackMaxScaledLevels = 50
+
+// ScaledOrderGroup 一组分批挂出的限价单（把一次入场拆成价格区间内的多档）
+type ScaledOrderGroup struct {
+	ID            string        `json:"id"`
+	Symbol        string        `json:"symbol"`
+	Side          string        `json:"side"` // Bid / Ask
+	TotalQuantity float64       `json:"total_quantity"`
+	PriceLow      float64       `json:"price_low"`
+	PriceHigh     float64       `json:"price_high"`
+	CreatedAt     time.Time     `json:"created_at"`
+	Orders        []OrderResult `json:"-"` // 每档一个，按价格从低到高
+}
+
+// OrderIDs 挂单成功的订单ID
+func (g *ScaledOrderGroup) OrderIDs() []string {
+	var ids []string
+	for _, r := range g.Orders {
+		if r.Err == nil && r.Order != nil {
+			ids = append(ids, r.Order.ID)
+		}
+	}
+	return ids
+}
+
+// FilledQuantity 已成交数量（按最近一次下单/刷新/撤单时的订单状态）
+func (g *ScaledOrderGroup) FilledQuantity() float64 {
+	var filled float64
+	for _, r := range g.Orders {
+		if r.Err == nil && r.Order != nil {
+			filled += r.Order.ExecutedQuantity.Float64()
+		}
+	}
+	return filled
+}
+
+// scaledOrderBook 分批挂单分组登记
+type scaledOrderBook struct {
+	mu     sync.Mutex
+	seq    int
+	groups map[string]*ScaledOrderGroup
+}
+
+func (b *scaledOrderBook) add(g *ScaledOrderGroup) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.groups == nil {
+		b.groups = make(map[string]*ScaledOrderGroup)
+	}
+	b.seq++
+	g.ID = fmt.Sprintf("%s#%d", g.Symbol, b.seq)
+	b.groups[g.ID] = g
+}
+
+func (b *scaledOrderBook) get(id string) (*ScaledOrderGroup, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	g, ok := b.groups[id]
+	return g, ok
+}
+
+func (b *scaledOrderBook) remove(id string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.groups, id)
+}
+
+// scaledLevels 在 [low, high] 内等距取 levels 档价格，数量平均分配
+func scaledLevels(totalQty, priceLow, priceHigh float64, levels int) ([]float64, float64, error) {
+	if totalQty <= 0 {
+		return nil, 0, fmt.Errorf("无效的总数量: %.8f", totalQty)
+	}
+	if priceLow <= 0 || priceHigh < priceLow {
+		return nil, 0, fmt.Errorf("无效的价格区间: %.8f ~ %.8f", priceLow, priceHigh)
+	}
+	if levels < 2 || levels > backpackMaxScaledLevels {
+		return nil, 0, fmt.Errorf("档数应在 2 ~ %d 之间，实际 %d", backpackMaxScaledLevels, levels)
+	}
+	if priceHigh == priceLow {
+		return nil, 0, fmt.Errorf("分批挂单需要价格区间，最低价与最高价相同: %.8f", priceLow)
+	}
+	step := (priceHigh - priceLow) / float64(levels-1)
+	prices := make([]float64, levels)
+	for i := range prices {
+		prices[i] = priceLow + step*float64(i)
+	}
+	return prices, totalQty / float64(levels), nil
+}
+
+// PlaceScaledOrders 把一次入场拆成 [priceLow, priceHigh] 内等距的 levels 档限价单（GTC，每档数量相同，按数量精度取整）
+// 返回的分组可用 CancelScaledOrders 整体撤销剩余挂单；部分档位失败时仍返回分组，失败原因见 Orders[i].Err
+func (t *BackpackTrader) PlaceScaledOrders(symbol, side string, totalQty, priceLow, priceHigh float64, levels int) (*ScaledOrderGroup, error) {
+	return t.PlaceScaledOrdersWithContext(context.Background(), symbol, side, totalQty, priceLow, priceHigh, levels)
+}
+
+// PlaceScaledOrdersWithContext 同 PlaceScaledOrders，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) PlaceScaledOrdersWithContext(ctx context.Context, symbol, side string, totalQty, priceLow, priceHigh float64, levels int) (*ScaledOrderGroup, error) {
+	bpSide, err := orderSide(side)
+	if err != nil {
+		return nil, err
+	}
+	prices, qty, err := scaledLevels(totalQty, priceLow, priceHigh, levels)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs := make([]OrderRequest, len(prices))
+	for i, price := range prices {
+		reqs[i] = OrderRequest{Symbol: symbol, Side: bpSide, Quantity: qty, Price: price}
+	}
+	log.Printf("🪜 [Backpack] 分批挂单: %s %s 总数量=%.4f %d档 %.4f ~ %.4f", t.mapSymbol(symbol), bpSide, totalQty, levels, priceLow, priceHigh)
+
+	group := &ScaledOrderGroup{
+		Symbol:        t.mapSymbol(symbol),
+		Side:          bpSide,
+		TotalQuantity: totalQty,
+		PriceLow:      priceLow,
+		PriceHigh:     priceHigh,
+		CreatedAt:     time.Now(),
+		Orders:        t.PlaceOrdersWithContext(ctx, reqs),
+	}
+	placed := len(group.OrderIDs())
+	if placed == 0 {
+		return group, fmt.Errorf("分批挂单全部失败: %w", group.Orders[0].Err)
+	}
+	t.scaledOrders.add(group)
+	log.Printf("✓ [Backpack] 分批挂单 %s: %d/%d 档挂单成功", group.ID, placed, levels)
+	return group, nil
+}
+
+// ScaledOrderGroup 按ID查询分批挂单分组（已整体撤销的分组不再登记）
+func (t *BackpackTrader) ScaledOrderGroup(id string) (*ScaledOrderGroup, bool) {
+	return t.scaledOrders.get(id)
+}
+
+// ScaledOrderGroups 当前登记的全部分批挂单分组（按创建时间排序）
+func (t *BackpackTrader) ScaledOrderGroups() []*ScaledOrderGroup {
+	t.scaledOrders.mu.Lock()
+	groups := make([]*ScaledOrderGroup, 0, len(t.scaledOrders.groups))
+	for _, g := range t.scaledOrders.groups {
+		groups = append(groups, g)
+	}
+	t.scaledOrders.mu.Unlock()
+	sort.Slice(groups, func(i, j int) bool { return groups[i].CreatedAt.Before(groups[j].CreatedAt) })
+	return groups
+}
+
+// RefreshScaledOrders 查询分组内每档订单的最新状态（成交数量等）
+func (t *BackpackTrader) RefreshScaledOrders(ctx context.Context, id string) (*ScaledOrderGroup, error) {
+	group, ok := t.scaledOrders.get(id)
+	if !ok {
+		return nil, fmt.Errorf("分批挂单分组不存在: %s", id)
+	}
+	var errs []error
+	for i, r := range group.Orders {
+		if r.Err != nil || r.Order == nil {
+			continue
+		}
+		order, err := t.GetOrderWithContext(ctx, group.Symbol, r.Order.ID)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		group.Orders[i].Order = order
+	}
+	return group, errors.Join(errs...)
+}
+
+// CancelScaledOrders 撤销分组内尚未成交的挂单（已成交或已撤销的档位忽略），返回撤销的订单数
+// 全部撤销成功后分组不再登记；部分失败时保留分组，可重试
+func (t *BackpackTrader) CancelScaledOrders(id string) (int, error) {
+	return t.CancelScaledOrdersWithContext(context.Background(), id)
+}
+
+// CancelScaledOrdersWithContext 同 CancelScaledOrders，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) CancelScaledOrdersWithContext(ctx context.Context, id string) (int, error) {
+	group, ok := t.scaledOrders.get(id)
+	if !ok {
+		return 0, fmt.Errorf("分批挂单分组不存在: %s", id)
+	}
+
+	cancelled := 0
+	var errs []error
+	for i, r := range group.Orders {
+		if r.Err != nil || r.Order == nil {
+			continue
+		}
+		order, err := t.CancelOrderWithContext(ctx, group.Symbol, r.Order.ID)
+		switch {
+		case err == nil:
+			group.Orders[i].Order = order
+			cancelled++
+		case isOrderNotFound(err):
+			// 已全部成交或已被撤销
+		default:
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return cancelled, fmt.Errorf("撤销分批挂单 %s 失败: %w", id, err)
+	}
+	t.scaledOrders.remove(id)
+	log.Printf("✓ [Backpack] 分批挂单 %s 已撤销 %d 档，已成交 %.4f/%.4f", id, cancelled, group.FilledQuantity(), group.TotalQuantity)
+	return cancelled, nil
+}
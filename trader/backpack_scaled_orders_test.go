@@ -0,0 +1,108 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestScaledLevels(t *testing.T) {
+	prices, qty, err := scaledLevels(3, 90, 100, 5)
+	require.NoError(t, err)
+	assert.Equal(t, []float64{90, 92.5, 95, 97.5, 100}, prices)
+	assert.InDelta(t, 0.6, qty, 1e-12)
+
+	for _, tc := range []struct {
+		qty, low, high float64
+		levels         int
+	}{
+		{0, 90, 100, 5},
+		{1, 0, 100, 5},
+		{1, 100, 90, 5},
+		{1, 100, 100, 5},
+		{1, 90, 100, 1},
+		{1, 90, 100, backpackMaxScaledLevels + 1},
+	} {
+		_, _, err := scaledLevels(tc.qty, tc.low, tc.high, tc.levels)
+		assert.Error(t, err, "%+v", tc)
+	}
+}
+
+func TestBackpackTrader_ScaledOrders(t *testing.T) {
+	var mu sync.Mutex
+	var placed []map[string]interface{}
+	var cancelled []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "POST /api/v1/orders":
+			var body []map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			resp := make([]map[string]interface{}, len(body))
+			for i, order := range body {
+				placed = append(placed, order)
+				resp[i] = map[string]interface{}{"id": fmt.Sprint(i + 1), "symbol": order["symbol"], "side": order["side"],
+					"price": order["price"], "quantity": order["quantity"], "status": "New"}
+			}
+			json.NewEncoder(w).Encode(resp)
+		case "DELETE /api/v1/order":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			id := fmt.Sprint(body["orderId"])
+			if id == "1" {
+				// 第一档已全部成交
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(`{"code":"RESOURCE_NOT_FOUND","message":"Order not found"}`))
+				return
+			}
+			cancelled = append(cancelled, id)
+			w.Write([]byte(`{"id":"` + id + `","status":"Cancelled","executedQuantity":"0"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+
+	group, err := tr.PlaceScaledOrders("SOLUSDT", "buy", 3, 90, 100, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "SOL_USDC_PERP#1", group.ID)
+	assert.Equal(t, "Bid", group.Side)
+	assert.Equal(t, []string{"1", "2", "3"}, group.OrderIDs())
+	mu.Lock()
+	require.Len(t, placed, 3)
+	assert.Equal(t, "90", placed[0]["price"])
+	assert.Equal(t, "95", placed[1]["price"])
+	assert.Equal(t, "100", placed[2]["price"])
+	for _, order := range placed {
+		assert.Equal(t, "Limit", order["orderType"])
+		assert.Equal(t, "Bid", order["side"])
+	}
+	mu.Unlock()
+
+	got, ok := tr.ScaledOrderGroup(group.ID)
+	require.True(t, ok)
+	assert.Same(t, group, got)
+	assert.Len(t, tr.ScaledOrderGroups(), 1)
+
+	// 整体撤销剩余挂单，已成交的档位忽略
+	n, err := tr.CancelScaledOrders(group.ID)
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	mu.Lock()
+	assert.ElementsMatch(t, []string{"2", "3"}, cancelled)
+	mu.Unlock()
+
+	_, ok = tr.ScaledOrderGroup(group.ID)
+	assert.False(t, ok, "撤销后分组不再登记")
+	_, err = tr.CancelScaledOrders(group.ID)
+	assert.Error(t, err)
+}
@@ -0,0 +1,233 @@
+package trader
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// backpackSignatureWindow 签名有效窗口（毫秒）
+const backpackSignatureWindow int64 = 60000
+
+// backpackInstructions 预先计算的 "METHOD /endpoint" -> 指令类型 映射
+var backpackInstructions = map[string]string{
+	"GET /api/v1/account":                  "accountQuery",
+	"GET /api/v1/capital":                  "balanceQuery",
+	"GET /api/v1/capital/collateral":       "collateralQuery",
+	"GET /api/v1/position":                 "positionQuery",
+	"GET /api/v1/orders":                   "orderQueryAll",
+	"DELETE /api/v1/orders":                "orderCancelAll",
+	"POST /api/v1/order":                   "orderExecute",
+	"POST /api/v1/orders":                  "orderExecute", // 批量下单
+	"DELETE /api/v1/order":                 "orderCancel",
+	"GET /api/v1/order":                    "orderQuery",
+	"GET /api/v1/ticker":                   "marketdataQuery",
+	"GET /wapi/v1/history/fills":           "fillHistoryQueryAll",
+	"GET /wapi/v1/history/orders":          "orderHistoryQueryAll",
+	"GET /wapi/v1/history/funding":         "fundingHistoryQueryAll",
+	"GET /wapi/v1/capital/deposit/address": "depositAddressQuery",
+	"GET /wapi/v1/capital/deposits":        "depositQueryAll",
+	"GET /wapi/v1/capital/withdrawals":     "withdrawalQueryAll",
+	"POST /wapi/v1/capital/withdrawals":    "withdraw",
+	"GET /wapi/v1/subaccounts":             "subaccountQueryAll",
+	"POST /wapi/v1/subaccounts/transfer":   "subaccountTransfer",
+}
+
+// signingBufferPool 复用签名字符串缓冲区，减少下单路径上的内存分配
+// 注意 strings.Builder.Reset 会丢弃底层数组，无法真正复用，因此这里池化 []byte
+var signingBufferPool = sync.Pool{
+	New: func() interface{} {
+		buf := make([]byte, 0, 256)
+		return &buf
+	},
+}
+
+// determineInstructionType 根据请求方法和端点确定指令类型
+func (t *BackpackTrader) determineInstructionType(method, endpoint string) string {
+	return backpackInstructionType(method, endpoint)
+}
+
+// backpackInstructionType 根据请求方法和端点确定指令类型
+func backpackInstructionType(method, endpoint string) string {
+	method = strings.ToUpper(method)
+
+	// 规范化端点
+	if !strings.HasPrefix(endpoint, "/") {
+		endpoint = "/" + endpoint
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+
+	if instruction, ok := backpackInstructions[method+" "+endpoint]; ok {
+		return instruction
+	}
+
+	// 未知端点，生成默认指令类型
+	log.Printf("⚠️ 未知的API端点: %s %s", method, endpoint)
+	return strings.ToLower(method) + strings.ReplaceAll(endpoint, "/", "_")
+}
+
+// parseBackpackPrivateKey 解码base64编码的ED25519私钥（32字节seed或64字节完整私钥）
+func parseBackpackPrivateKey(privateKeyB64 string) (ed25519.PrivateKey, error) {
+	privateKeyBytes, err := base64.StdEncoding.DecodeString(privateKeyB64)
+	if err != nil {
+		return nil, fmt.Errorf("解码私钥失败: %w", err)
+	}
+
+	switch len(privateKeyBytes) {
+	case ed25519.SeedSize:
+		// 32字节seed，需要生成完整的64字节私钥
+		return ed25519.NewKeyFromSeed(privateKeyBytes), nil
+	case ed25519.PrivateKeySize:
+		return ed25519.PrivateKey(privateKeyBytes), nil
+	default:
+		return nil, fmt.Errorf("私钥长度错误: 期望32或64字节，实际%d字节", len(privateKeyBytes))
+	}
+}
+
+// BackpackSignature 一次请求签名的全部中间结果，用于排查 "invalid signature"
+type BackpackSignature struct {
+	Instruction   string // 指令类型
+	SigningString string // 待签名字符串
+	Signature     string // base64编码的签名（X-SIGNATURE）
+	PublicKey     string // base64编码的公钥（应与交易所后台的API Key一致）
+	Timestamp     int64  // X-TIMESTAMP
+	Window        int64  // X-WINDOW
+}
+
+// SignBackpackRequest 按给定私钥、请求参数和时间戳生成签名
+// 与实际下单使用同一套规则，结果是确定性的，可与其他实现或交易所文档逐字节比对
+// window<=0 时使用默认窗口
+func SignBackpackRequest(privateKeyB64, method, endpoint string, params, data map[string]string, timestamp, window int64) (*BackpackSignature, error) {
+	privateKey, err := parseBackpackPrivateKey(privateKeyB64)
+	if err != nil {
+		return nil, err
+	}
+	if window <= 0 {
+		window = backpackSignatureWindow
+	}
+
+	instruction := backpackInstructionType(method, endpoint)
+	signingString := buildSigningString(instruction, params, data, timestamp, window)
+	signature := ed25519.Sign(privateKey, []byte(signingString))
+	return &BackpackSignature{
+		Instruction:   instruction,
+		SigningString: signingString,
+		Signature:     base64.StdEncoding.EncodeToString(signature),
+		PublicKey:     base64.StdEncoding.EncodeToString(privateKey.Public().(ed25519.PublicKey)),
+		Timestamp:     timestamp,
+		Window:        window,
+	}, nil
+}
+
+// VerifyBackpackSignature 用base64编码的公钥（即API Key）验证签名
+func VerifyBackpackSignature(publicKeyB64, signingString, signatureB64 string) error {
+	publicKey, err := base64.StdEncoding.DecodeString(publicKeyB64)
+	if err != nil {
+		return fmt.Errorf("解码公钥失败: %w", err)
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return fmt.Errorf("公钥长度错误: 期望%d字节，实际%d字节", ed25519.PublicKeySize, len(publicKey))
+	}
+	signature, err := base64.StdEncoding.DecodeString(signatureB64)
+	if err != nil {
+		return fmt.Errorf("解码签名失败: %w", err)
+	}
+	if !ed25519.Verify(ed25519.PublicKey(publicKey), []byte(signingString), signature) {
+		return fmt.Errorf("签名不匹配: %s", signingString)
+	}
+	return nil
+}
+
+// buildSigningString 构建待签名字符串
+// 格式: instruction=X&<查询参数按key排序>&<请求体参数按key排序>&timestamp=T&window=W
+// 值为空的参数不参与签名
+func buildSigningString(instruction string, params, data map[string]string, timestamp, window int64) string {
+	bufPtr := signingBufferPool.Get().(*[]byte)
+	buf := (*bufPtr)[:0]
+
+	buf = append(buf, "instruction="...)
+	buf = append(buf, instruction...)
+	buf = appendSortedParams(buf, params)
+	buf = appendSortedParams(buf, data)
+	buf = append(buf, "&timestamp="...)
+	buf = strconv.AppendInt(buf, timestamp, 10)
+	buf = append(buf, "&window="...)
+	buf = strconv.AppendInt(buf, window, 10)
+
+	s := string(buf)
+	*bufPtr = buf
+	signingBufferPool.Put(bufPtr)
+	return s
+}
+
+// buildBatchSigningString 构建批量请求的待签名字符串
+// 格式: instruction=X&<订单1参数按key排序>&instruction=X&<订单2参数>...&timestamp=T&window=W
+func buildBatchSigningString(instruction string, items []map[string]string, timestamp, window int64) string {
+	bufPtr := signingBufferPool.Get().(*[]byte)
+	buf := (*bufPtr)[:0]
+
+	for i, item := range items {
+		if i > 0 {
+			buf = append(buf, '&')
+		}
+		buf = append(buf, "instruction="...)
+		buf = append(buf, instruction...)
+		buf = appendSortedParams(buf, item)
+	}
+	buf = append(buf, "&timestamp="...)
+	buf = strconv.AppendInt(buf, timestamp, 10)
+	buf = append(buf, "&window="...)
+	buf = strconv.AppendInt(buf, window, 10)
+
+	s := string(buf)
+	*bufPtr = buf
+	signingBufferPool.Put(bufPtr)
+	return s
+}
+
+// appendSortedParams 按key字母顺序追加非空参数
+func appendSortedParams(buf []byte, params map[string]string) []byte {
+	if len(params) == 0 {
+		return buf
+	}
+
+	var stack [16]string
+	keys := stack[:0]
+	for k, v := range params {
+		if v != "" {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		buf = append(buf, '&')
+		buf = append(buf, k...)
+		buf = append(buf, '=')
+		buf = append(buf, params[k]...)
+	}
+	return buf
+}
+
+// encodeQuery 编码URL查询参数
+// 与签名字符串使用相同的规则：跳过空值、按key字母排序；区别只在于值经过URL转义，
+// 服务端解码后重建的签名字符串与 buildSigningString 的结果完全一致
+func encodeQuery(params map[string]string) string {
+	if len(params) == 0 {
+		return ""
+	}
+	values := make(url.Values, len(params))
+	for k, v := range params {
+		if v != "" {
+			values.Set(k, v)
+		}
+	}
+	// url.Values.Encode 按key排序
+	return values.Encode()
+}
@@ -0,0 +1,126 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// AccountSnapshot 账户快照：余额、持仓、挂单在同一轮并发请求中获取
+type AccountSnapshot struct {
+	Balance    *Balance
+	Positions  []Position // 已过滤数量为0的持仓
+	OpenOrders []Order    // 全部交易对的挂单
+	Time       time.Time  // 三个请求全部返回的时间
+}
+
+// accountSnapshotter 支持一次获取账户快照的交易器（AutoTrader 在热路径上优先使用）
+type accountSnapshotter interface {
+	AccountSnapshotMaps(ctx context.Context) (balance map[string]interface{}, positions []map[string]interface{}, err error)
+}
+
+// AccountSnapshot 并发获取余额、持仓和挂单，任一请求失败时取消其余请求并返回错误
+// 替代依次调用 AccountBalance、OpenPositions 和查询挂单：耗时取决于最慢的一个请求，且三者时间上更接近
+func (t *BackpackTrader) AccountSnapshot(ctx context.Context) (*AccountSnapshot, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		snapshot AccountSnapshot
+		wg       sync.WaitGroup
+		errMu    sync.Mutex
+		firstErr error
+	)
+	run := func(name string, fn func() error) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := fn(); err != nil {
+				errMu.Lock()
+				if firstErr == nil {
+					firstErr = fmt.Errorf("获取%s失败: %w", name, err)
+					cancel()
+				}
+				errMu.Unlock()
+			}
+		}()
+	}
+
+	run("余额", func() (err error) {
+		snapshot.Balance, err = t.AccountBalance(ctx)
+		return err
+	})
+	run("持仓", func() (err error) {
+		snapshot.Positions, err = t.OpenPositions(ctx)
+		return err
+	})
+	run("挂单", func() (err error) {
+		snapshot.OpenOrders, err = t.openOrders(ctx, "")
+		return err
+	})
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, fmt.Errorf("获取账户快照失败: %w", firstErr)
+	}
+	snapshot.Time = time.Now()
+	t.cachePositions(snapshot.Positions, snapshot.Time)
+	return &snapshot, nil
+}
+
+// AccountSnapshotMaps 同 AccountSnapshot，余额和持仓按 Trader 接口的 map 格式返回
+func (t *BackpackTrader) AccountSnapshotMaps(ctx context.Context) (map[string]interface{}, []map[string]interface{}, error) {
+	snapshot, err := t.AccountSnapshot(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return snapshot.Balance.toMap(), positionMaps(snapshot.Positions), nil
+}
+
+// GetOpenOrders 查询交易对当前的挂单（symbol 为空时查询全部交易对）
+// 结果包含尚未触发的条件单（Status 为 TriggerPending），可用于确认自己的止损止盈单是否仍在
+func (t *BackpackTrader) GetOpenOrders(symbol string) ([]Order, error) {
+	return t.GetOpenOrdersWithContext(context.Background(), symbol)
+}
+
+// GetOpenOrdersWithContext 同 GetOpenOrders，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetOpenOrdersWithContext(ctx context.Context, symbol string) ([]Order, error) {
+	orders, err := t.openOrders(ctx, symbol)
+	if err != nil {
+		return nil, fmt.Errorf("查询挂单失败: %w", err)
+	}
+	return orders, nil
+}
+
+// openOrders 查询挂单（symbol 为空时查询全部交易对）
+func (t *BackpackTrader) openOrders(ctx context.Context, symbol string) ([]Order, error) {
+	params := map[string]string{"marketType": "PERP"}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	var orders []Order
+	if _, err := streamAuthenticatedArray(ctx, t, "/api/v1/orders", params, func(o Order) error {
+		orders = append(orders, o)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// accountState 获取余额和持仓：交易器支持账户快照时并发获取，否则依次调用 GetBalance、GetPositions
+func (at *AutoTrader) accountState() (map[string]interface{}, []map[string]interface{}, error) {
+	if s, ok := at.trader.(accountSnapshotter); ok {
+		return s.AccountSnapshotMaps(context.Background())
+	}
+	balance, err := at.trader.GetBalance()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取账户余额失败: %w", err)
+	}
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	return balance, positions, nil
+}
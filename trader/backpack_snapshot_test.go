@@ -0,0 +1,132 @@
+package trader
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpackTrader_AccountSnapshot(t *testing.T) {
+	const delay = 100 * time.Millisecond
+	var positionCalls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/capital/collateral":
+			w.Write([]byte(`{"netEquity":"1000","netEquityAvailable":"900","pnlUnrealized":"10"}`))
+		case "/api/v1/position":
+			atomic.AddInt32(&positionCalls, 1)
+			w.Write([]byte(`[{"symbol":"SOL_USDC_PERP","netQuantity":"2","entryPrice":"150","markPrice":"151"},
+				{"symbol":"ETH_USDC_PERP","netQuantity":"0","entryPrice":"3000","markPrice":"3000"}]`))
+		case "/api/v1/orders":
+			assert.Equal(t, "PERP", r.URL.Query().Get("marketType"))
+			w.Write([]byte(`[{"id":"7","symbol":"SOL_USDC_PERP","side":"Ask","orderType":"Limit","status":"New","price":"160","quantity":"2"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	start := time.Now()
+	snapshot, err := trader.AccountSnapshot(context.Background())
+	require.NoError(t, err)
+	assert.Less(t, time.Since(start), 2*delay, "三个请求并发执行")
+
+	assert.InDelta(t, 1000, snapshot.Balance.NetEquity.Float64(), 1e-9)
+	require.Len(t, snapshot.Positions, 1, "过滤数量为0的持仓")
+	assert.Equal(t, "long", snapshot.Positions[0].Side())
+	require.Len(t, snapshot.OpenOrders, 1)
+	assert.Equal(t, "7", snapshot.OpenOrders[0].ID)
+	assert.False(t, snapshot.Time.Before(start))
+
+	// 快照刷新了持仓缓存，GetPosition 不再请求交易所
+	pos, err := trader.GetPosition("SOLUSDT")
+	require.NoError(t, err)
+	require.NotNil(t, pos)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&positionCalls))
+
+	balance, positions, err := trader.AccountSnapshotMaps(context.Background())
+	require.NoError(t, err)
+	assert.Contains(t, balance, "totalWalletBalance")
+	require.Len(t, positions, 1)
+	assert.Equal(t, "SOLUSDT", positions[0]["symbol"])
+}
+
+func TestBackpackTrader_AccountSnapshotFailsFast(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/position" {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"code":"INVALID_CLIENT_REQUEST"}`))
+			return
+		}
+		// 其余请求在失败后被取消，不会等满
+		select {
+		case <-r.Context().Done():
+		case <-time.After(5 * time.Second):
+		}
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	start := time.Now()
+	_, err := trader.AccountSnapshot(context.Background())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "持仓")
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestBackpackTrader_GetOpenOrders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/orders" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		assert.Equal(t, "SOL_USDC_PERP", r.URL.Query().Get("symbol"))
+		assert.Equal(t, "PERP", r.URL.Query().Get("marketType"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"id":"1","symbol":"SOL_USDC_PERP","side":"Bid","orderType":"Limit","status":"New","price":"140","quantity":"1","executedQuantity":"0.4","clientId":7},
+			{"id":"2","symbol":"SOL_USDC_PERP","side":"Ask","orderType":"Market","status":"TriggerPending","quantity":"1","triggerPrice":"130","reduceOnly":true}
+		]`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	orders, err := trader.GetOpenOrders("SOLUSDT")
+	require.NoError(t, err)
+	require.Len(t, orders, 2)
+
+	assert.Equal(t, "1", orders[0].ID)
+	assert.InDelta(t, 140, orders[0].Price.Float64(), 1e-9)
+	assert.InDelta(t, 0.4, orders[0].ExecutedQuantity.Float64(), 1e-9)
+	assert.Equal(t, "7", orders[0].ClientID.String())
+	assert.False(t, orders[0].IsTrigger())
+
+	assert.True(t, orders[1].IsTrigger(), "止损触发单也在挂单列表中")
+	assert.InDelta(t, 130, orders[1].TriggerPrice.Float64(), 1e-9)
+	assert.True(t, orders[1].ReduceOnly)
+}
+
+func TestBackpackTrader_GetOpenOrdersError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"INVALID_SYMBOL"}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	_, err := trader.GetOpenOrders("FOOUSDT")
+	require.Error(t, err)
+	var apiErr *APIError
+	assert.ErrorAs(t, err, &apiErr)
+}
@@ -0,0 +1,114 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+// StopEntryOptions 条件开仓（突破开仓）选项
+type StopEntryOptions struct {
+	LimitPrice float64   // 触发后以该价格挂限价单（0 表示触发后市价成交）
+	StopLoss   float64   // 止损触发价（0 表示不设置）
+	TakeProfit float64   // 止盈触发价（0 表示不设置）
+	ExpireAt   time.Time // 到期时间（零值表示不设置），到期仍未触发则撤单，用于与信号有效期对齐
+}
+
+// OpenLongStop 挂出条件开多单：价格向上突破 triggerPrice 时由交易所触发开仓
+// 例如在内包线高点上方挂单，避免轮询价格后再市价追单
+func (t *BackpackTrader) OpenLongStop(symbol string, quantity, triggerPrice float64, opts StopEntryOptions) (map[string]interface{}, error) {
+	log.Printf("🟢 [Backpack] 条件开多仓: %s 数量=%.4f 触发价=%.4f", symbol, quantity, triggerPrice)
+	return orderResult(t.placeStopEntry(symbol, "Bid", quantity, triggerPrice, opts))
+}
+
+// OpenShortStop 挂出条件开空单：价格向下跌破 triggerPrice 时由交易所触发开仓
+func (t *BackpackTrader) OpenShortStop(symbol string, quantity, triggerPrice float64, opts StopEntryOptions) (map[string]interface{}, error) {
+	log.Printf("🔴 [Backpack] 条件开空仓: %s 数量=%.4f 触发价=%.4f", symbol, quantity, triggerPrice)
+	return orderResult(t.placeStopEntry(symbol, "Ask", quantity, triggerPrice, opts))
+}
+
+// validateStopEntry 校验触发价与止盈止损的相对位置
+// 多单：止损 < 触发价 < 止盈；空单相反
+func validateStopEntry(side string, triggerPrice float64, opts StopEntryOptions) error {
+	if triggerPrice <= 0 {
+		return fmt.Errorf("无效的触发价: %.8f", triggerPrice)
+	}
+	isBuy := side == "Bid"
+	if opts.StopLoss > 0 && (opts.StopLoss >= triggerPrice) == isBuy {
+		return fmt.Errorf("止损价 %.8f 与触发价 %.8f 方向不符", opts.StopLoss, triggerPrice)
+	}
+	if opts.TakeProfit > 0 && (opts.TakeProfit <= triggerPrice) == isBuy {
+		return fmt.Errorf("止盈价 %.8f 与触发价 %.8f 方向不符", opts.TakeProfit, triggerPrice)
+	}
+	return nil
+}
+
+// placeStopEntry 校验当前价后挂出触发单
+func (t *BackpackTrader) placeStopEntry(symbol, side string, quantity, triggerPrice float64, opts StopEntryOptions) (*Order, error) {
+	if err := validateStopEntry(side, triggerPrice, opts); err != nil {
+		return nil, err
+	}
+	if !opts.ExpireAt.IsZero() && !time.Now().Before(opts.ExpireAt) {
+		return nil, fmt.Errorf("条件单已过期: %s", opts.ExpireAt.Format(time.RFC3339))
+	}
+
+	// 触发价已被穿越时交易所会立即触发，等同于追单，直接拒绝由调用方决定是否市价开仓
+	price, err := t.GetMarketPrice(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新价失败: %w", err)
+	}
+	if (side == "Bid" && price >= triggerPrice) || (side == "Ask" && price <= triggerPrice) {
+		return nil, fmt.Errorf("当前价 %.8f 已越过触发价 %.8f", price, triggerPrice)
+	}
+
+	backpackSymbol := t.mapSymbol(symbol)
+	order, err := t.createTriggerOrder(context.Background(), backpackSymbol, side, quantity, triggerPrice, opts)
+	if err != nil {
+		return nil, err
+	}
+	if !opts.ExpireAt.IsZero() {
+		t.expiry.Schedule(backpackSymbol, order.ID, opts.ExpireAt)
+	}
+	return order, nil
+}
+
+// createTriggerOrder 创建触发单（触发后按 LimitPrice 挂限价或市价成交，并附带止盈止损）
+func (t *BackpackTrader) createTriggerOrder(ctx context.Context, symbol, side string, quantity, triggerPrice float64, opts StopEntryOptions) (*Order, error) {
+	qtyStr, err := t.FormatQuantityWithContext(ctx, symbol, quantity)
+	if err != nil {
+		log.Printf("⚠️ [Backpack] 格式化数量失败，使用默认精度: %v", err)
+		qtyStr = formatFloat(quantity, 8)
+	}
+
+	data := map[string]string{
+		"symbol":          symbol,
+		"side":            side,
+		"orderType":       "Market",
+		"triggerPrice":    t.formatPrice(ctx, symbol, triggerPrice),
+		"triggerQuantity": qtyStr,
+	}
+	tagOrder(ctx, data)
+	if opts.LimitPrice > 0 {
+		data["orderType"] = "Limit"
+		data["price"] = t.formatPrice(ctx, symbol, opts.LimitPrice)
+	}
+	if opts.StopLoss > 0 {
+		data["stopLossTriggerPrice"] = t.formatPrice(ctx, symbol, opts.StopLoss)
+	}
+	if opts.TakeProfit > 0 {
+		data["takeProfitTriggerPrice"] = t.formatPrice(ctx, symbol, opts.TakeProfit)
+	}
+
+	log.Printf("📤 [Backpack] 挂触发单: %s %s %s 触发价=%s", side, data["orderType"], qtyStr, data["triggerPrice"])
+	order, err := t.requestOrder(ctx, "POST", "/api/v1/order", nil, data)
+	if err != nil {
+		return nil, fmt.Errorf("挂触发单失败: %w", err)
+	}
+	if order.ID == "" {
+		return nil, fmt.Errorf("触发单响应缺少订单ID: %s %s", side, symbol)
+	}
+
+	log.Printf("✓ [Backpack] 触发单已创建: ID=%s 状态=%s", order.ID, order.Status)
+	return order, nil
+}
@@ -0,0 +1,296 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	backpackHistoryPageSize     = 1000                   // 历史接口单页最大条数
+	backpackHistoryPageInterval = 200 * time.Millisecond // 翻页间隔，避免连续翻页触发限频
+)
+
+// errStopPaging 回调返回该错误表示已取够数据，停止翻页（不视为失败）
+var errStopPaging = errors.New("stop paging")
+
+// decodeJSONArray 流式解码JSON数组，逐个元素回调
+// 不把整个响应读入内存，适合数MB的历史记录响应；返回已解码的元素数量
+func decodeJSONArray[T any](r io.Reader, fn func(T) error) (int, error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return 0, fmt.Errorf("读取响应失败: %w", err)
+	}
+	if delim, ok := tok.(json.Delim); !ok || delim != '[' {
+		return 0, fmt.Errorf("响应不是JSON数组: %v", tok)
+	}
+
+	count := 0
+	for dec.More() {
+		var item T
+		if err := dec.Decode(&item); err != nil {
+			return count, fmt.Errorf("解析第%d条记录失败: %w", count+1, err)
+		}
+		if err := fn(item); err != nil {
+			return count, err
+		}
+		count++
+	}
+
+	if _, err := dec.Token(); err != nil {
+		return count, fmt.Errorf("读取数组结束符失败: %w", err)
+	}
+	return count, nil
+}
+
+// streamAuthenticatedArray 发起认证GET请求，并流式解码返回的数组
+func streamAuthenticatedArray[T any](ctx context.Context, t *BackpackTrader, endpoint string, params map[string]string, fn func(T) error) (int, error) {
+	resp, err := t.sendAuthenticatedRequest(ctx, "GET", endpoint, params, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	return decodeJSONArray(resp.Body, fn)
+}
+
+// streamHistoryPages 按 offset 分页拉取历史接口，逐页流式处理
+// 某页返回条数少于 pageSize 时视为最后一页；maxResults>0 时处理满该数量即停止
+func streamHistoryPages[T any](ctx context.Context, t *BackpackTrader, endpoint string, params map[string]string, pageSize, maxResults int, fn func(T) error) (int, error) {
+	if pageSize <= 0 || pageSize > backpackHistoryPageSize {
+		pageSize = backpackHistoryPageSize
+	}
+	if maxResults > 0 && maxResults < pageSize {
+		pageSize = maxResults
+	}
+
+	total := 0
+	for offset := 0; ; offset += pageSize {
+		if offset > 0 {
+			select {
+			case <-time.After(backpackHistoryPageInterval):
+			case <-ctx.Done():
+				return total, ctx.Err()
+			}
+		}
+
+		pageParams := make(map[string]string, len(params)+2)
+		for k, v := range params {
+			pageParams[k] = v
+		}
+		pageParams["limit"] = strconv.Itoa(pageSize)
+		pageParams["offset"] = strconv.Itoa(offset)
+
+		n, err := streamAuthenticatedArray(ctx, t, endpoint, pageParams, func(item T) error {
+			if maxResults > 0 && total >= maxResults {
+				return errStopPaging
+			}
+			if err := fn(item); err != nil {
+				return err
+			}
+			total++
+			return nil
+		})
+		if errors.Is(err, errStopPaging) {
+			return total, nil
+		}
+		if err != nil {
+			return total, err
+		}
+		if n < pageSize || (maxResults > 0 && total >= maxResults) {
+			return total, nil
+		}
+		log.Printf("📄 [Backpack] %s 已处理 %d 条，继续拉取下一页", endpoint, total)
+	}
+}
+
+// collectHistory 拉取历史接口的完整结果集（maxResults>0 时为硬上限）
+func collectHistory[T any](ctx context.Context, t *BackpackTrader, endpoint string, params map[string]string, maxResults int) ([]T, error) {
+	var items []T
+	_, err := streamHistoryPages(ctx, t, endpoint, params, backpackHistoryPageSize, maxResults, func(item T) error {
+		items = append(items, item)
+		return nil
+	})
+	return items, err
+}
+
+// StreamFillHistory 逐条流式处理成交历史（symbol为空表示全部交易对）
+func (t *BackpackTrader) StreamFillHistory(symbol string, fn func(Fill) error) (int, error) {
+	return t.StreamFillHistoryWithContext(context.Background(), symbol, fn)
+}
+
+// StreamFillHistoryWithContext 同 StreamFillHistory，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) StreamFillHistoryWithContext(ctx context.Context, symbol string, fn func(Fill) error) (int, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	return streamHistoryPages(ctx, t, "/wapi/v1/history/fills", params, backpackHistoryPageSize, 0, fn)
+}
+
+// StreamOrderHistory 逐条流式处理历史订单（symbol为空表示全部交易对）
+func (t *BackpackTrader) StreamOrderHistory(symbol string, fn func(Order) error) (int, error) {
+	return t.StreamOrderHistoryWithContext(context.Background(), symbol, fn)
+}
+
+// StreamOrderHistoryWithContext 同 StreamOrderHistory，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) StreamOrderHistoryWithContext(ctx context.Context, symbol string, fn func(Order) error) (int, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	return streamHistoryPages(ctx, t, "/wapi/v1/history/orders", params, backpackHistoryPageSize, 0, fn)
+}
+
+// FetchFillHistory 自动翻页获取全部成交历史
+// symbol为空表示全部交易对；maxResults为返回条数硬上限（<=0 表示不限制）
+func (t *BackpackTrader) FetchFillHistory(symbol string, maxResults int) ([]Fill, error) {
+	return t.FetchFillHistoryWithContext(context.Background(), symbol, maxResults)
+}
+
+// FetchFillHistoryWithContext 同 FetchFillHistory，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) FetchFillHistoryWithContext(ctx context.Context, symbol string, maxResults int) ([]Fill, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	fills, err := collectHistory[Fill](ctx, t, "/wapi/v1/history/fills", params, maxResults)
+	if err != nil {
+		return fills, fmt.Errorf("获取成交历史失败: %w", err)
+	}
+	return fills, nil
+}
+
+// GetFills 自动翻页获取 since 之后的成交记录，用于本地计算盈亏和手续费
+// symbol为空表示全部交易对；since 为零值表示不限起始时间；limit为返回条数硬上限（<=0 表示不限制）
+func (t *BackpackTrader) GetFills(symbol string, since time.Time, limit int) ([]Fill, error) {
+	return t.GetFillsWithContext(context.Background(), symbol, since, limit)
+}
+
+// GetFillsWithContext 同 GetFills，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetFillsWithContext(ctx context.Context, symbol string, since time.Time, limit int) ([]Fill, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	if !since.IsZero() {
+		params["from"] = strconv.FormatInt(since.UnixMilli(), 10)
+	}
+
+	var fills []Fill
+	_, err := streamHistoryPages(ctx, t, "/wapi/v1/history/fills", params, backpackHistoryPageSize, 0, func(f Fill) error {
+		// 交易所已按 from 过滤，这里再按成交时间过滤一次，兼容忽略 from 参数的情况
+		if !since.IsZero() && f.Time().Before(since) {
+			return nil
+		}
+		fills = append(fills, f)
+		if limit > 0 && len(fills) >= limit {
+			return errStopPaging
+		}
+		return nil
+	})
+	if err != nil {
+		return fills, fmt.Errorf("获取成交记录失败: %w", err)
+	}
+	return fills, nil
+}
+
+// GetFundingPayments 自动翻页获取 since 之后的资金费收付记录，用于把资金费计入盈亏
+// symbol为空表示全部交易对；since 为零值表示不限起始时间；limit为返回条数硬上限（<=0 表示不限制）
+func (t *BackpackTrader) GetFundingPayments(symbol string, since time.Time, limit int) ([]FundingPayment, error) {
+	return t.GetFundingPaymentsWithContext(context.Background(), symbol, since, limit)
+}
+
+// GetFundingPaymentsWithContext 同 GetFundingPayments，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetFundingPaymentsWithContext(ctx context.Context, symbol string, since time.Time, limit int) ([]FundingPayment, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	if !since.IsZero() {
+		params["from"] = strconv.FormatInt(since.UnixMilli(), 10)
+	}
+
+	var payments []FundingPayment
+	_, err := streamHistoryPages(ctx, t, "/wapi/v1/history/funding", params, backpackHistoryPageSize, 0, func(p FundingPayment) error {
+		// 交易所已按 from 过滤，这里再按结算时间过滤一次，兼容忽略 from 参数的情况
+		if !since.IsZero() && p.Time().Before(since) {
+			return nil
+		}
+		payments = append(payments, p)
+		if limit > 0 && len(payments) >= limit {
+			return errStopPaging
+		}
+		return nil
+	})
+	if err != nil {
+		return payments, fmt.Errorf("获取资金费记录失败: %w", err)
+	}
+	return payments, nil
+}
+
+// FetchOrderHistory 自动翻页获取全部历史订单
+// symbol为空表示全部交易对；maxResults为返回条数硬上限（<=0 表示不限制）
+func (t *BackpackTrader) FetchOrderHistory(symbol string, maxResults int) ([]Order, error) {
+	return t.FetchOrderHistoryWithContext(context.Background(), symbol, maxResults)
+}
+
+// FetchOrderHistoryWithContext 同 FetchOrderHistory，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) FetchOrderHistoryWithContext(ctx context.Context, symbol string, maxResults int) ([]Order, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	orders, err := collectHistory[Order](ctx, t, "/wapi/v1/history/orders", params, maxResults)
+	if err != nil {
+		return orders, fmt.Errorf("获取历史订单失败: %w", err)
+	}
+	return orders, nil
+}
+
+// GetOrderHistory 自动翻页获取 [from, to] 内的历史订单，用于审计机器人在某段时间的实际操作
+// symbol为空表示全部交易对；from/to 为零值表示不限；status 为空表示全部状态（如 Filled、Cancelled，不区分大小写）
+func (t *BackpackTrader) GetOrderHistory(symbol string, from, to time.Time, status string) ([]Order, error) {
+	return t.GetOrderHistoryWithContext(context.Background(), symbol, from, to, status)
+}
+
+// GetOrderHistoryWithContext 同 GetOrderHistory，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetOrderHistoryWithContext(ctx context.Context, symbol string, from, to time.Time, status string) ([]Order, error) {
+	params := map[string]string{}
+	if symbol != "" {
+		params["symbol"] = t.mapSymbol(symbol)
+	}
+	if !from.IsZero() {
+		params["from"] = strconv.FormatInt(from.UnixMilli(), 10)
+	}
+	if !to.IsZero() {
+		params["to"] = strconv.FormatInt(to.UnixMilli(), 10)
+	}
+
+	var orders []Order
+	_, err := streamHistoryPages(ctx, t, "/wapi/v1/history/orders", params, backpackHistoryPageSize, 0, func(o Order) error {
+		// 交易所不一定支持按时间和状态过滤，这里按下单时间和状态再过滤一次（下单时间无法解析的保留）
+		if created := o.CreatedTime(); !created.IsZero() {
+			if (!from.IsZero() && created.Before(from)) || (!to.IsZero() && created.After(to)) {
+				return nil
+			}
+		}
+		if status != "" && !strings.EqualFold(o.Status, status) {
+			return nil
+		}
+		orders = append(orders, o)
+		return nil
+	})
+	if err != nil {
+		return orders, fmt.Errorf("获取历史订单失败: %w", err)
+	}
+	return orders, nil
+}
@@ -0,0 +1,122 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+)
+
+// Subaccount 子账户（/wapi/v1/subaccounts）
+type Subaccount struct {
+	ID   json.Number `json:"id"`
+	Name string      `json:"name"`
+}
+
+// SetSubaccount 设置交易器使用的子账户，之后所有认证请求都携带 subaccountId 并参与签名（0 表示主账户）
+// 应在开始交易前设置
+func (t *BackpackTrader) SetSubaccount(id int) {
+	t.subaccountID = id
+	if id > 0 {
+		log.Printf("🏦 [Backpack] 使用子账户 %d", id)
+	}
+}
+
+// Subaccount 返回交易器使用的子账户（0 表示主账户）
+func (t *BackpackTrader) Subaccount() int {
+	return t.subaccountID
+}
+
+type subaccountKey struct{}
+
+// WithSubaccount 返回指定子账户的 ctx，经该 ctx 发出的认证请求使用该子账户（优先于 SetSubaccount；0 表示主账户）
+func WithSubaccount(ctx context.Context, id int) context.Context {
+	return context.WithValue(ctx, subaccountKey{}, id)
+}
+
+// subaccountFor 读取请求使用的子账户：ctx 中指定的优先，否则使用交易器配置
+func (t *BackpackTrader) subaccountFor(ctx context.Context) int {
+	if id, ok := ctx.Value(subaccountKey{}).(int); ok {
+		return id
+	}
+	return t.subaccountID
+}
+
+// withSubaccountField 返回附加 subaccountId 的参数副本（id<=0 时原样返回，不修改调用方的 map）
+func withSubaccountField(fields map[string]string, id int) map[string]string {
+	if id <= 0 {
+		return fields
+	}
+	out := make(map[string]string, len(fields)+1)
+	for k, v := range fields {
+		out[k] = v
+	}
+	out["subaccountId"] = strconv.Itoa(id)
+	return out
+}
+
+// ListSubaccounts 获取主账户下的全部子账户
+func (t *BackpackTrader) ListSubaccounts() ([]Subaccount, error) {
+	return t.ListSubaccountsWithContext(context.Background())
+}
+
+// ListSubaccountsWithContext 同 ListSubaccounts，ctx 用于设置超时和取消进行中的请求
+// 子账户列表只能由主账户查询，因此忽略交易器配置的子账户
+func (t *BackpackTrader) ListSubaccountsWithContext(ctx context.Context) ([]Subaccount, error) {
+	var subaccounts []Subaccount
+	if err := t.requestJSON(WithSubaccount(ctx, 0), "GET", "/wapi/v1/subaccounts", nil, nil, &subaccounts); err != nil {
+		return nil, fmt.Errorf("获取子账户列表失败: %w", err)
+	}
+	return subaccounts, nil
+}
+
+// GetSubaccountBalance 获取指定子账户的余额（0 表示主账户），不经过响应缓存和账户推送
+func (t *BackpackTrader) GetSubaccountBalance(id int) (*Balance, error) {
+	return t.GetSubaccountBalanceWithContext(context.Background(), id)
+}
+
+// GetSubaccountBalanceWithContext 同 GetSubaccountBalance，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetSubaccountBalanceWithContext(ctx context.Context, id int) (*Balance, error) {
+	var balance Balance
+	if err := t.requestJSON(WithSubaccount(ctx, id), "GET", "/api/v1/capital/collateral", nil, nil, &balance); err != nil {
+		return nil, fmt.Errorf("获取子账户 %d 余额失败: %w", id, err)
+	}
+	return &balance, nil
+}
+
+// TransferBetweenSubaccounts 在主账户与子账户之间划转资产（from/to 为子账户ID，0 表示主账户），用于从备用账户补充保证金等内部调拨
+// 划转由主账户签名发起，不使用交易器配置的子账户；结果不确定时不会自动重试
+func (t *BackpackTrader) TransferBetweenSubaccounts(from, to int, symbol string, quantity float64) error {
+	return t.TransferBetweenSubaccountsWithContext(context.Background(), from, to, symbol, quantity)
+}
+
+// TransferBetweenSubaccountsWithContext 同 TransferBetweenSubaccounts，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) TransferBetweenSubaccountsWithContext(ctx context.Context, from, to int, symbol string, quantity float64) error {
+	if from == to {
+		return fmt.Errorf("划转的来源和目标账户相同: %d", from)
+	}
+	if from < 0 || to < 0 {
+		return fmt.Errorf("无效的子账户ID: %d → %d", from, to)
+	}
+	if symbol == "" {
+		return fmt.Errorf("划转需要指定币种")
+	}
+	if quantity <= 0 {
+		return fmt.Errorf("无效的划转数量: %v", quantity)
+	}
+
+	data := map[string]string{
+		"symbol":           symbol,
+		"quantity":         strconv.FormatFloat(quantity, 'f', -1, 64),
+		"fromSubaccountId": strconv.Itoa(from),
+		"toSubaccountId":   strconv.Itoa(to),
+	}
+	log.Printf("💸 [Backpack] 子账户划转: %s %s，%d → %d", data["quantity"], symbol, from, to)
+	resp, err := t.sendAuthenticatedRequest(WithSubaccount(ctx, 0), "POST", "/wapi/v1/subaccounts/transfer", nil, data)
+	if err != nil {
+		return fmt.Errorf("子账户划转失败: %w", err)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -0,0 +1,158 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackpackTrader_Subaccount(t *testing.T) {
+	var tr *BackpackTrader
+	var subaccounts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wapi/v1/subaccounts":
+			assert.Empty(t, r.URL.Query().Get("subaccountId"), "子账户列表只能由主账户查询")
+			verifyCapitalSignature(t, tr, r, "subaccountQueryAll", nil)
+			w.Write([]byte(`[{"id":3,"name":"trend"},{"id":5,"name":"grid"}]`))
+		case "GET /api/v1/capital/collateral":
+			id := r.URL.Query().Get("subaccountId")
+			subaccounts = append(subaccounts, id)
+			verifyCapitalSignature(t, tr, r, "collateralQuery", map[string]string{"subaccountId": id})
+			w.Write([]byte(`{"netEquity":"1000","netEquityAvailable":"800","pnlUnrealized":"0"}`))
+		case "POST /api/v1/order":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			assert.Equal(t, float64(3), body["subaccountId"], "subaccountId 以整数发送")
+			verifyCapitalSignature(t, tr, r, "orderExecute", map[string]string{"symbol": "SOL_USDC_PERP", "subaccountId": "3"})
+			w.Write([]byte(`{"id":"1"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr = newTestBackpackTrader(t, server.URL)
+	tr.SetResponseCache(ResponseCacheConfig{})
+	tr.SetSubaccount(3)
+	assert.Equal(t, 3, tr.Subaccount())
+
+	list, err := tr.ListSubaccounts()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "grid", list[1].Name)
+	assert.Equal(t, "5", list[1].ID.String())
+
+	// 交易器配置的子账户参与签名，调用方的参数不被修改
+	data := map[string]string{"symbol": "SOL_USDC_PERP"}
+	resp, err := tr.sendAuthenticatedRequest(context.Background(), "POST", "/api/v1/order", nil, data)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.NotContains(t, data, "subaccountId")
+
+	_, err = tr.AccountBalance(context.Background())
+	require.NoError(t, err)
+	balance, err := tr.GetSubaccountBalance(5)
+	require.NoError(t, err)
+	assert.InDelta(t, 1000, balance.NetEquity.Float64(), 1e-9)
+	_, err = tr.GetSubaccountBalance(0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"3", "5", ""}, subaccounts)
+
+	assert.Error(t, tr.EnableAccountStream(), "账户推送不支持子账户")
+}
+
+func TestBackpackTrader_BalanceCacheSeparatesSubaccounts(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/capital/collateral" {
+			http.NotFound(w, r)
+			return
+		}
+		requests++
+		equity := "1000"
+		if r.URL.Query().Get("subaccountId") == "7" {
+			equity = "250"
+		}
+		w.Write([]byte(`{"netEquity":"` + equity + `","netEquityAvailable":"` + equity + `","pnlUnrealized":"0"}`))
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+	tr.SetResponseCache(ResponseCacheConfig{"/api/v1/capital/collateral": time.Minute})
+
+	primary, err := tr.AccountBalance(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, 1000, primary.TotalWalletBalance(), 1e-9)
+	sub, err := tr.AccountBalance(WithSubaccount(context.Background(), 7))
+	require.NoError(t, err)
+	assert.InDelta(t, 250, sub.TotalWalletBalance(), 1e-9, "子账户不能读到主账户的缓存余额")
+	_, err = tr.AccountBalance(WithSubaccount(context.Background(), 7))
+	require.NoError(t, err)
+	assert.Equal(t, 2, requests, "每个子账户各自缓存")
+
+	// 下单后清除全部子账户的余额缓存
+	tr.invalidatePositionCache()
+	_, err = tr.AccountBalance(WithSubaccount(context.Background(), 7))
+	require.NoError(t, err)
+	_, err = tr.AccountBalance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 4, requests)
+}
+
+func TestWithSubaccountField(t *testing.T) {
+	fields := map[string]string{"symbol": "BTC_USDC_PERP"}
+	assert.Equal(t, fields, withSubaccountField(fields, 0))
+
+	tagged := withSubaccountField(fields, 12)
+	assert.Equal(t, strconv.Itoa(12), tagged["subaccountId"])
+	assert.Len(t, fields, 1)
+	assert.Equal(t, "12", withSubaccountField(nil, 12)["subaccountId"])
+}
+
+func TestBackpackTrader_TransferBetweenSubaccounts(t *testing.T) {
+	var tr *BackpackTrader
+	var requests int
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Method+" "+r.URL.Path != "POST /wapi/v1/subaccounts/transfer" {
+			http.NotFound(w, r)
+			return
+		}
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		assert.Equal(t, float64(0), body["fromSubaccountId"], "子账户ID以整数发送")
+		assert.Equal(t, float64(5), body["toSubaccountId"])
+		assert.Equal(t, "150.5", body["quantity"])
+		assert.NotContains(t, body, "subaccountId", "划转由主账户发起")
+		verifyCapitalSignature(t, tr, r, "subaccountTransfer", map[string]string{
+			"symbol": "USDC", "quantity": "150.5", "fromSubaccountId": "0", "toSubaccountId": "5",
+		})
+		w.WriteHeader(status)
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+	tr = newTestBackpackTrader(t, server.URL)
+	tr.SetSubaccount(3)
+	tr.SetRetryPolicy(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+
+	require.NoError(t, tr.TransferBetweenSubaccounts(0, 5, "USDC", 150.5))
+	assert.Equal(t, 1, requests)
+
+	// 结果不确定时不重试，避免重复划转
+	status = http.StatusInternalServerError
+	require.Error(t, tr.TransferBetweenSubaccounts(0, 5, "USDC", 150.5))
+	assert.Equal(t, 2, requests)
+
+	assert.Error(t, tr.TransferBetweenSubaccounts(5, 5, "USDC", 1))
+	assert.Error(t, tr.TransferBetweenSubaccounts(0, 5, "USDC", 0))
+	assert.Equal(t, 2, requests)
+}
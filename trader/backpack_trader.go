@@ -1,6 +1,7 @@
 package trader
 
 import (
+	"context"
 	"crypto/ed25519"
 	"encoding/base64"
 	"encoding/json"
@@ -9,9 +10,10 @@ import (
 	"log"
 	"net/http"
 	"nofx/market"
-	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -25,178 +27,160 @@ type BackpackTrader struct {
 	// 缓存
 	symbolPrecision map[string]*SymbolPrecision
 	marketInfo      map[string]interface{}
+
+	// 限频退避：收到429/418后，在 backoffUntil 之前的所有请求直接返回 ErrRateLimited
+	backoffMu    sync.Mutex
+	backoffUntil time.Time
+
+	// 持仓缓存：GetPositions 每次都请求交易所并刷新缓存，GetPosition 在有效期内直接读缓存
+	cachedPositions     []map[string]interface{}
+	positionsCacheTime  time.Time
+	positionsCacheMutex sync.RWMutex
+	positionsCacheTTL   time.Duration
+
+	// 本地订单到期调度（Backpack 不支持 GTT，到期由本地撤单）
+	expiry *ExpiryScheduler
+
+	// 账户级下单频率限制（nil 表示不限制）
+	throttle *OrderThrottle
+
+	// 请求令牌桶限流（nil 表示不限流）
+	limiter *requestLimiter
+
+	// 请求失败重试策略（零值表示不重试）
+	retry RetryPolicy
+
+	// 账户 WebSocket 推送（nil 表示未启用，余额每次走 REST）
+	accountStream *BackpackAccountStream
+
+	// 幂等 GET 接口的响应缓存（nil 表示不缓存）
+	responses *responseCache
+
+	// 批量下单接口返回404/405后置位，之后直接并发逐个下单
+	batchUnsupported atomic.Bool
+
+	// 子账户ID（0 表示主账户），非0时所有认证请求携带 subaccountId
+	subaccountID int
+
+	// 止损触发后挂限价单的价格偏移比例（0 表示触发后市价成交）
+	stopLimitOffsetPct float64
+	// 交易所拒绝触发单参数后置位，之后止损直接由本地监控
+	triggerUnsupported atomic.Bool
+	localStops         *localStopMonitor
+	// 已有持仓的联动止盈止损（SetStopLossAndTakeProfit）
+	ocoOrders *ocoMonitor
+
+	// 分批挂单分组（PlaceScaledOrders）
+	scaledOrders scaledOrderBook
+
+	// 提现保护（nil 表示不检查）
+	withdrawalGuard *withdrawalGuard
+
+	// 交易器生命周期：后台监控（本地止损、联动止盈止损）使用，Stop 时取消
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
 // NewBackpackTrader 创建Backpack交易器
 // apiKey: Backpack API密钥
 // privateKeyB64: base64编码的ED25519私钥
 // userID: 用户ID (用于日志)
-func NewBackpackTrader(apiKey, privateKeyB64, userID string) (*BackpackTrader, error) {
-	// 解码base64私钥
-	privateKeyBytes, err := base64.StdEncoding.DecodeString(privateKeyB64)
+// opts: 可选配置（WithHTTPClient / WithTransport / WithProxy）
+func NewBackpackTrader(apiKey, privateKeyB64, userID string, opts ...BackpackOption) (*BackpackTrader, error) {
+	privateKey, err := parseBackpackPrivateKey(privateKeyB64)
 	if err != nil {
-		return nil, fmt.Errorf("解码私钥失败: %w", err)
-	}
-
-	// 确保私钥长度正确 (ED25519私钥应该是32字节，但库使用的是64字节seed+public key)
-	var privateKey ed25519.PrivateKey
-	if len(privateKeyBytes) == 32 {
-		// 如果是32字节，需要生成完整的64字节私钥
-		privateKey = ed25519.NewKeyFromSeed(privateKeyBytes)
-	} else if len(privateKeyBytes) == 64 {
-		// 如果已经是64字节，直接使用
-		privateKey = ed25519.PrivateKey(privateKeyBytes)
-	} else {
-		return nil, fmt.Errorf("私钥长度错误: 期望32或64字节，实际%d字节", len(privateKeyBytes))
+		return nil, err
 	}
 
 	trader := &BackpackTrader{
-		apiKey:          apiKey,
-		privateKey:      privateKey,
-		baseURL:         "https://api.backpack.exchange/",
-		client:          &http.Client{Timeout: 30 * time.Second},
-		symbolPrecision: make(map[string]*SymbolPrecision),
-		marketInfo:      make(map[string]interface{}),
+		apiKey:            apiKey,
+		privateKey:        privateKey,
+		baseURL:           "https://api.backpack.exchange/",
+		client:            &http.Client{Timeout: backpackRequestTimeout, Transport: sharedBackpackTransport()},
+		symbolPrecision:   make(map[string]*SymbolPrecision),
+		marketInfo:        make(map[string]interface{}),
+		positionsCacheTTL: 5 * time.Second, // 5秒缓存
+		responses:         newResponseCache(DefaultResponseCacheConfig),
+	}
+	for _, opt := range opts {
+		if err := opt(trader); err != nil {
+			return nil, fmt.Errorf("Backpack配置无效: %w", err)
+		}
 	}
+	trader.ctx, trader.cancel = context.WithCancel(context.Background())
+	trader.localStops = newLocalStopMonitor(trader)
+	trader.ocoOrders = newOCOMonitor(trader)
+	trader.expiry = NewExpiryScheduler(func(symbol, orderID string) error {
+		_, err := trader.CancelOrder(symbol, orderID)
+		return err
+	})
 
 	log.Printf("🏦 Backpack交易器初始化成功 (用户: %s)", userID)
 	return trader, nil
 }
 
-// determineInstructionType 根据请求方法和端点确定指令类型
-func (t *BackpackTrader) determineInstructionType(method, endpoint string) string {
-	method = strings.ToUpper(method)
-
-	// 规范化端点
-	if !strings.HasPrefix(endpoint, "/") {
-		endpoint = "/" + endpoint
-	}
-	endpoint = strings.TrimSuffix(endpoint, "/")
-
-	// 根据端点返回指令类型
-	switch endpoint {
-	case "/api/v1/account":
-		if method == "GET" {
-			return "accountQuery"
-		}
-	case "/api/v1/capital":
-		if method == "GET" {
-			return "balanceQuery"
-		}
-	case "/api/v1/capital/collateral":
-		if method == "GET" {
-			return "collateralQuery"
-		}
-	case "/api/v1/position":
-		if method == "GET" {
-			return "positionQuery"
-		}
-	case "/api/v1/orders":
-		if method == "GET" {
-			return "orderQueryAll"
-		} else if method == "DELETE" {
-			return "orderCancelAll"
-		}
-	case "/api/v1/order":
-		if method == "POST" {
-			return "orderExecute"
-		} else if method == "DELETE" {
-			return "orderCancel"
-		} else if method == "GET" {
-			return "orderQuery"
-		}
-	case "/api/v1/ticker":
-		return "marketdataQuery"
-	case "/wapi/v1/history/fills":
-		if method == "GET" {
-			return "fillHistoryQueryAll"
-		}
-	case "/wapi/v1/history/orders":
-		if method == "GET" {
-			return "orderHistoryQueryAll"
-		}
-	}
-
-	// 未知端点，生成默认指令类型
-	log.Printf("⚠️ 未知的API端点: %s %s", method, endpoint)
-	return fmt.Sprintf("%s%s", strings.ToLower(method), strings.ReplaceAll(endpoint, "/", "_"))
+// Stop 停止交易器的后台任务：取消本地止损和联动止盈止损监控中进行中的请求，关闭账户推送并停止到期撤单计时
+// 停止后本地监控的止损不再生效，交易器不应再用于下单
+func (t *BackpackTrader) Stop() {
+	t.cancel()
+	t.DisableAccountStream()
+	t.expiry.Stop()
 }
 
-// generateSignature 生成API请求签名
+// generateSignature 生成API请求签名（热路径：不打印日志）
 func (t *BackpackTrader) generateSignature(method, endpoint string, params, data map[string]string) (map[string]string, error) {
 	// 获取指令类型
-	instructionType := t.determineInstructionType(method, endpoint)
+	instructionType := backpackInstructionType(method, endpoint)
 
 	// 当前时间戳（毫秒）
 	timestamp := time.Now().UnixMilli()
-	window := int64(60000) // 增加到60秒窗口，避免网络延迟导致过期
-
-	// 🐛 调试：打印系统时间
-	log.Printf("🐛 [Backpack] 当前系统时间: %s", time.Now().Format("2006-01-02 15:04:05.000"))
+	window := backpackSignatureWindow // 60秒窗口，避免网络延迟导致过期
 
-	// 构建签名字符串
-	signatureStr := fmt.Sprintf("instruction=%s", instructionType)
+	// 构建签名字符串并使用ED25519签名
+	signatureStr := buildSigningString(instructionType, params, data, timestamp, window)
+	return t.signHeaders(signatureStr, timestamp, window), nil
+}
 
-	// 添加查询参数（按字母顺序排序）
-	if len(params) > 0 {
-		keys := make([]string, 0, len(params))
-		for k := range params {
-			if params[k] != "" {
-				keys = append(keys, k)
-			}
-		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			signatureStr += fmt.Sprintf("&%s=%s", k, params[k])
-		}
+// signHeaders 对签名字符串签名并构建认证请求头
+func (t *BackpackTrader) signHeaders(signatureStr string, timestamp, window int64) map[string]string {
+	signature := ed25519.Sign(t.privateKey, []byte(signatureStr))
+	return map[string]string{
+		"X-API-KEY":    t.apiKey,
+		"X-SIGNATURE":  base64.StdEncoding.EncodeToString(signature),
+		"X-TIMESTAMP":  strconv.FormatInt(timestamp, 10),
+		"X-WINDOW":     strconv.FormatInt(window, 10),
+		"Content-Type": "application/json",
 	}
+}
 
-	// 添加请求体参数（按字母顺序排序）
-	if len(data) > 0 {
-		keys := make([]string, 0, len(data))
-		for k := range data {
-			if data[k] != "" {
-				keys = append(keys, k)
+// sendAuthenticatedRequest 签名并发送认证请求，返回状态码为200的响应（调用方负责关闭Body）
+// ctx 取消或超时时中断排队和进行中的请求；按重试策略重试时每次重新签名
+func (t *BackpackTrader) sendAuthenticatedRequest(ctx context.Context, method, endpoint string, params, data map[string]string) (*http.Response, error) {
+	return t.withRetry(ctx, backpackInstructionType(method, endpoint), func() (*http.Response, error) {
+		return t.sendAuthenticatedRequestOnce(ctx, method, endpoint, params, data)
+	})
+}
+
+// sendAuthenticatedRequestOnce 签名并发送一次认证请求
+func (t *BackpackTrader) sendAuthenticatedRequestOnce(ctx context.Context, method, endpoint string, params, data map[string]string) (*http.Response, error) {
+	// 下单/撤单先申请账户额度，超限时不发出请求
+	if t.throttle != nil {
+		if kind := throttleKindForInstruction(backpackInstructionType(method, endpoint)); kind != "" {
+			if err := t.throttle.AcquireContext(ctx, kind); err != nil {
+				return nil, err
 			}
 		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			signatureStr += fmt.Sprintf("&%s=%s", k, data[k])
-		}
 	}
 
-	// 添加时间戳和窗口
-	signatureStr += fmt.Sprintf("&timestamp=%d&window=%d", timestamp, window)
-
-	// 🐛 调试：打印签名字符串
-	log.Printf("🐛 [Backpack] 签名字符串: %s", signatureStr)
-	log.Printf("🐛 [Backpack] 时间戳: %d, 窗口: %d", timestamp, window)
-
-	// 使用ED25519签名
-	messageBytes := []byte(signatureStr)
-	signature := ed25519.Sign(t.privateKey, messageBytes)
-
-	// Base64编码签名
-	signatureB64 := base64.StdEncoding.EncodeToString(signature)
-
-	// 构建请求头
-	headers := map[string]string{
-		"X-API-KEY":    t.apiKey,
-		"X-SIGNATURE":  signatureB64,
-		"X-TIMESTAMP":  fmt.Sprintf("%d", timestamp),
-		"X-WINDOW":     fmt.Sprintf("%d", window),
-		"Content-Type": "application/json",
+	// 子账户：GET 放在查询参数中，其他方法放在请求体中，均参与签名
+	if id := t.subaccountFor(ctx); id > 0 {
+		if strings.EqualFold(method, "GET") {
+			params = withSubaccountField(params, id)
+		} else {
+			data = withSubaccountField(data, id)
+		}
 	}
 
-	// 🐛 调试：打印请求头（隐藏敏感信息）
-	log.Printf("🐛 [Backpack] 请求头: X-TIMESTAMP=%d, X-WINDOW=%d", timestamp, window)
-	log.Printf("🐛 [Backpack] 签名（前20字符）: %s...", signatureB64[:min(20, len(signatureB64))])
-
-	return headers, nil
-}
-
-// makeAuthenticatedRequest 发起需要认证的API请求
-func (t *BackpackTrader) makeAuthenticatedRequest(method, endpoint string, params, data map[string]string) (map[string]interface{}, error) {
 	// 生成签名头部
 	headers, err := t.generateSignature(method, endpoint, params, data)
 	if err != nil {
@@ -212,29 +196,21 @@ func (t *BackpackTrader) makeAuthenticatedRequest(method, endpoint string, param
 
 	if method == "GET" {
 		// GET请求，参数放在URL中
-		if len(params) > 0 {
-			queryParams := make([]string, 0, len(params))
-			for k, v := range params {
-				if v != "" {
-					queryParams = append(queryParams, fmt.Sprintf("%s=%s", k, v))
-				}
-			}
-			if len(queryParams) > 0 {
-				url += "?" + strings.Join(queryParams, "&")
-			}
+		if query := encodeQuery(params); query != "" {
+			url += "?" + query
 		}
-		req, err = http.NewRequest(method, url, nil)
+		req, err = http.NewRequestWithContext(ctx, method, url, nil)
 	} else if method == "POST" || method == "PUT" || method == "DELETE" {
 		// POST/PUT/DELETE请求，参数放在请求体中
 		var body io.Reader
 		if len(data) > 0 {
-			jsonData, err := json.Marshal(data)
+			jsonData, err := marshalRequestBody(data)
 			if err != nil {
 				return nil, fmt.Errorf("序列化请求体失败: %w", err)
 			}
 			body = strings.NewReader(string(jsonData))
 		}
-		req, err = http.NewRequest(method, url, body)
+		req, err = http.NewRequestWithContext(ctx, method, url, body)
 	} else {
 		return nil, fmt.Errorf("不支持的HTTP方法: %s", method)
 	}
@@ -248,10 +224,50 @@ func (t *BackpackTrader) makeAuthenticatedRequest(method, endpoint string, param
 		req.Header.Set(k, v)
 	}
 
-	// 发送请求
-	resp, err := t.client.Do(req)
+	// 发送请求（统一处理限频与错误状态码）
+	return t.doRequest(req)
+}
+
+// marshalRequestBody 序列化请求体；clientId、subaccountId 等ID字段按交易所要求以整数发送，postOnly 等标志以布尔值发送，其余字段保持字符串
+// 签名仍使用字符串形式（"true"/"false"），与交易所的验签方式一致
+func marshalRequestBody(data map[string]string) ([]byte, error) {
+	return json.Marshal(requestBodyFields(data))
+}
+
+// integerRequestFields 请求体中需要按 JSON 整数发送的字段
+var integerRequestFields = map[string]bool{
+	"clientId":         true,
+	"subaccountId":     true,
+	"fromSubaccountId": true,
+	"toSubaccountId":   true,
+}
+
+// requestBodyFields 按 marshalRequestBody 的规则转换请求体字段（批量下单时逐个订单使用）
+func requestBodyFields(data map[string]string) map[string]interface{} {
+	body := make(map[string]interface{}, len(data))
+	for k, v := range data {
+		switch {
+		case integerRequestFields[k]:
+			body[k] = json.Number(v)
+		case boolRequestFields[k]:
+			body[k] = v == "true"
+		default:
+			body[k] = v
+		}
+	}
+	return body
+}
+
+// tagOrder 设置订单的 clientId：使用 ctx 中指定的 clientId，未指定时按归因信息生成
+func tagOrder(ctx context.Context, data map[string]string) {
+	data["clientId"] = strconv.FormatUint(uint64(clientIDFromContext(ctx)), 10)
+}
+
+// makeAuthenticatedRequest 发起需要认证的API请求
+func (t *BackpackTrader) makeAuthenticatedRequest(ctx context.Context, method, endpoint string, params, data map[string]string) (map[string]interface{}, error) {
+	resp, err := t.sendAuthenticatedRequest(ctx, method, endpoint, params, data)
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -261,13 +277,6 @@ func (t *BackpackTrader) makeAuthenticatedRequest(method, endpoint string, param
 		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
 
-	// 检查HTTP状态码
-	if resp.StatusCode != 200 {
-		log.Printf("❌ [Backpack] API错误: %s %s -> HTTP %d", method, endpoint, resp.StatusCode)
-		log.Printf("❌ [Backpack] 错误响应: %s", string(bodyBytes))
-		return nil, fmt.Errorf("API请求失败: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
-	}
-
 	// 尝试解析JSON
 	var result map[string]interface{}
 	contentType := resp.Header.Get("Content-Type")
@@ -290,51 +299,14 @@ func (t *BackpackTrader) makeAuthenticatedRequest(method, endpoint string, param
 }
 
 // makeAuthenticatedRequestArray 发起认证请求并返回数组
-func (t *BackpackTrader) makeAuthenticatedRequestArray(method, endpoint string, params, data map[string]string) ([]interface{}, error) {
-	// 生成签名头部
-	headers, err := t.generateSignature(method, endpoint, params, data)
-	if err != nil {
-		return nil, fmt.Errorf("生成签名失败: %w", err)
-	}
-
-	// 构建完整URL
-	url := strings.TrimSuffix(t.baseURL, "/") + endpoint
-
-	// 创建请求
-	var req *http.Request
-	method = strings.ToUpper(method)
-
-	if method == "GET" {
-		// GET请求，参数放在URL中
-		if len(params) > 0 {
-			queryParams := make([]string, 0, len(params))
-			for k, v := range params {
-				if v != "" {
-					queryParams = append(queryParams, fmt.Sprintf("%s=%s", k, v))
-				}
-			}
-			if len(queryParams) > 0 {
-				url += "?" + strings.Join(queryParams, "&")
-			}
-		}
-		req, err = http.NewRequest(method, url, nil)
-	} else {
+func (t *BackpackTrader) makeAuthenticatedRequestArray(ctx context.Context, method, endpoint string, params, data map[string]string) ([]interface{}, error) {
+	if strings.ToUpper(method) != "GET" {
 		return nil, fmt.Errorf("不支持的HTTP方法: %s", method)
 	}
 
+	resp, err := t.sendAuthenticatedRequest(ctx, method, endpoint, params, data)
 	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
-	}
-
-	// 设置请求头
-	for k, v := range headers {
-		req.Header.Set(k, v)
-	}
-
-	// 发送请求
-	resp, err := t.client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
+		return nil, err
 	}
 	defer resp.Body.Close()
 
@@ -344,13 +316,6 @@ func (t *BackpackTrader) makeAuthenticatedRequestArray(method, endpoint string,
 		return nil, fmt.Errorf("读取响应失败: %w", err)
 	}
 
-	// 检查HTTP状态码
-	if resp.StatusCode != 200 {
-		log.Printf("❌ [Backpack] API错误: %s %s -> HTTP %d", method, endpoint, resp.StatusCode)
-		log.Printf("❌ [Backpack] 错误响应: %s", string(bodyBytes))
-		return nil, fmt.Errorf("API请求失败: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
-	}
-
 	// 解析JSON数组
 	var result []interface{}
 	if err := json.Unmarshal(bodyBytes, &result); err != nil {
@@ -361,41 +326,45 @@ func (t *BackpackTrader) makeAuthenticatedRequestArray(method, endpoint string,
 }
 
 // makePublicRequest 发起公开API请求（不需要签名）
-func (t *BackpackTrader) makePublicRequest(method, endpoint string, params map[string]string) (interface{}, error) {
+func (t *BackpackTrader) makePublicRequest(ctx context.Context, method, endpoint string, params map[string]string) (interface{}, error) {
 	// 构建完整URL
 	url := strings.TrimSuffix(t.baseURL, "/") + endpoint
 
 	// GET请求，参数放在URL中
-	if len(params) > 0 {
-		queryParams := make([]string, 0, len(params))
-		for k, v := range params {
-			if v != "" {
-				queryParams = append(queryParams, fmt.Sprintf("%s=%s", k, v))
+	if query := encodeQuery(params); query != "" {
+		url += "?" + query
+	}
+
+	fetch := func() ([]byte, error) {
+		// 公开接口没有指令类型，重试日志中使用端点路径
+		resp, err := t.withRetry(ctx, endpoint, func() (*http.Response, error) {
+			req, err := http.NewRequestWithContext(ctx, method, url, nil)
+			if err != nil {
+				return nil, fmt.Errorf("创建请求失败: %w", err)
 			}
+			return t.doRequest(req)
+		})
+		if err != nil {
+			return nil, err
 		}
-		if len(queryParams) > 0 {
-			url += "?" + strings.Join(queryParams, "&")
+		defer resp.Body.Close()
+
+		bodyBytes, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, fmt.Errorf("读取响应失败: %w", err)
 		}
+		return bodyBytes, nil
 	}
 
-	req, err := http.NewRequest(method, url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("创建请求失败: %w", err)
+	var bodyBytes []byte
+	var err error
+	if strings.ToUpper(method) == "GET" {
+		bodyBytes, err = t.responses.get(ctx, endpoint, encodeQuery(params), fetch)
+	} else {
+		bodyBytes, err = fetch()
 	}
-
-	resp, err := t.client.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("发送请求失败: %w", err)
-	}
-	defer resp.Body.Close()
-
-	bodyBytes, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return nil, fmt.Errorf("读取响应失败: %w", err)
-	}
-
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("API请求失败: HTTP %d - %s", resp.StatusCode, string(bodyBytes))
+		return nil, err
 	}
 
 	// 尝试解析JSON
@@ -423,195 +392,202 @@ func formatFloat(val float64, precision int) string {
 	return formatted
 }
 
-// min 返回两个整数中的较小值
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
-	return b
-}
-
 // ==================== Trader接口实现 ====================
 
-// GetBalance 获取账户余额
+// GetBalance 获取账户余额（启用账户推送且快照有效时直接返回推送维护的权益）
+//
+// Deprecated: 仅为满足 Trader 接口保留，新代码请使用 AccountBalance
 func (t *BackpackTrader) GetBalance() (map[string]interface{}, error) {
-	log.Printf("📊 [Backpack] 获取账户余额...")
+	return t.GetBalanceWithContext(context.Background())
+}
 
-	// 调用 /api/v1/capital/collateral 获取抵押品信息
-	resp, err := t.makeAuthenticatedRequest("GET", "/api/v1/capital/collateral", nil, nil)
+// GetBalanceWithContext 同 GetBalance，ctx 用于设置超时和取消进行中的请求
+//
+// Deprecated: 使用 AccountBalance
+func (t *BackpackTrader) GetBalanceWithContext(ctx context.Context) (map[string]interface{}, error) {
+	balance, err := t.AccountBalance(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取余额失败: %w", err)
+		return nil, err
 	}
+	return balance.toMap(), nil
+}
 
-	// 🐛 调试：打印原始响应
-	log.Printf("🐛 [Backpack] 原始余额响应: %+v", resp)
-
-	// 解析响应
-	// Backpack 响应格式:
-	// {
-	//   "netEquity": 499.9,
-	//   "netEquityAvailable": 499.9,
-	//   "pnlUnrealized": 0,
-	//   "collateral": [{"symbol": "USDC", "totalQuantity": "499.9", "availableQuantity": "499.9", ...}]
-	// }
-
-	// 优先使用顶层字段
-	var totalWalletBalance float64 = 0
-	var availableBalance float64 = 0
-	var totalUnrealizedProfit float64 = 0
-
-	// 获取总净值
-	if netEquity, ok := resp["netEquity"].(float64); ok {
-		totalWalletBalance = netEquity
-	}
-
-	// 获取可用净值
-	if netEquityAvailable, ok := resp["netEquityAvailable"].(float64); ok {
-		availableBalance = netEquityAvailable
-	}
-
-	// 获取未实现盈亏
-	if pnlUnrealized, ok := resp["pnlUnrealized"].(float64); ok {
-		totalUnrealizedProfit = pnlUnrealized
-	}
-
-	// 如果顶层字段为空，尝试从 collateral 数组中计算
-	if totalWalletBalance == 0 {
-		if collateralData, ok := resp["collateral"]; ok {
-			if collateralList, ok := collateralData.([]interface{}); ok {
-				for _, item := range collateralList {
-					collateral, ok := item.(map[string]interface{})
-					if !ok {
-						continue
-					}
+// AccountBalance 获取账户余额（启用账户推送且快照有效时直接返回推送维护的权益）
+func (t *BackpackTrader) AccountBalance(ctx context.Context) (*Balance, error) {
+	if t.accountStream != nil {
+		if balance, ok := t.accountStream.Balance(); ok {
+			return balance, nil
+		}
+	}
+	return t.fetchBalance(ctx)
+}
 
-					// 获取总额 (totalQuantity)
-					if totalQtyStr, ok := collateral["totalQuantity"].(string); ok {
-						if total, err := strconv.ParseFloat(totalQtyStr, 64); err == nil {
-							totalWalletBalance += total
-						}
-					} else if totalQty, ok := collateral["totalQuantity"].(float64); ok {
-						totalWalletBalance += totalQty
-					}
+// fetchBalance 通过 REST 获取账户余额（/api/v1/capital/collateral）
+func (t *BackpackTrader) fetchBalance(ctx context.Context) (*Balance, error) {
+	log.Printf("📊 [Backpack] 获取账户余额...")
 
-					// 获取可用余额 (availableQuantity)
-					if availableQtyStr, ok := collateral["availableQuantity"].(string); ok {
-						if available, err := strconv.ParseFloat(availableQtyStr, 64); err == nil {
-							availableBalance += available
-						}
-					} else if availableQty, ok := collateral["availableQuantity"].(float64); ok {
-						availableBalance += availableQty
-					}
-				}
-			}
+	// 缓存按子账户区分，避免 WithSubaccount 的查询读到主账户的缓存余额
+	query := ""
+	if id := t.subaccountFor(ctx); id > 0 {
+		query = "subaccountId=" + strconv.Itoa(id)
+	}
+	body, err := t.responses.get(ctx, "/api/v1/capital/collateral", query, func() ([]byte, error) {
+		resp, err := t.sendAuthenticatedRequest(ctx, "GET", "/api/v1/capital/collateral", nil, nil)
+		if err != nil {
+			return nil, err
 		}
+		defer resp.Body.Close()
+		return io.ReadAll(resp.Body)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("获取余额失败: %w", err)
 	}
 
-	result := map[string]interface{}{
-		"totalWalletBalance":    totalWalletBalance,
-		"availableBalance":      availableBalance,
-		"totalUnrealizedProfit": totalUnrealizedProfit,
+	var balance Balance
+	if err := json.Unmarshal(body, &balance); err != nil {
+		return nil, fmt.Errorf("解析余额失败: %w", err)
 	}
 
 	log.Printf("✓ [Backpack] 余额: %.2f USDC (可用: %.2f, 未实现盈亏: %.2f)",
-		totalWalletBalance, availableBalance, totalUnrealizedProfit)
-
-	return result, nil
+		balance.TotalWalletBalance(), balance.AvailableBalance(), balance.PnlUnrealized.Float64())
+	return &balance, nil
 }
 
 // GetPositions 获取当前持仓
+//
+// Deprecated: 仅为满足 Trader 接口保留，新代码请使用 OpenPositions
 func (t *BackpackTrader) GetPositions() ([]map[string]interface{}, error) {
-	log.Printf("📊 [Backpack] 获取持仓信息...")
+	return t.GetPositionsWithContext(context.Background())
+}
 
-	// 调用 /api/v1/position 获取持仓（返回数组）
-	positionList, err := t.makeAuthenticatedRequestArray("GET", "/api/v1/position", nil, nil)
+// GetPositionsWithContext 同 GetPositions，ctx 用于设置超时和取消进行中的请求
+//
+// Deprecated: 使用 OpenPositions
+func (t *BackpackTrader) GetPositionsWithContext(ctx context.Context) ([]map[string]interface{}, error) {
+	rawPositions, err := t.OpenPositions(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("获取持仓失败: %w", err)
+		return nil, err
 	}
 
-	positions := make([]map[string]interface{}, 0)
+	return t.cachePositions(rawPositions, time.Now()), nil
+}
 
-	for _, item := range positionList {
-		pos, ok := item.(map[string]interface{})
-		if !ok {
-			continue
-		}
+// positionMaps 将持仓转换为 Trader 接口的 map 格式
+func positionMaps(rawPositions []Position) []map[string]interface{} {
+	positions := make([]map[string]interface{}, 0, len(rawPositions))
+	for _, pos := range rawPositions {
+		positions = append(positions, pos.toMap())
+	}
+	return positions
+}
 
-		// 解析持仓数量 (Backpack使用netQuantity，正数=多仓，负数=空仓)
-		netQtyStr, ok := pos["netQuantity"].(string)
-		if !ok {
-			continue
-		}
+// cachePositions 用最新查询到的持仓刷新 GetPosition 缓存，返回 map 格式的持仓
+func (t *BackpackTrader) cachePositions(rawPositions []Position, at time.Time) []map[string]interface{} {
+	positions := positionMaps(rawPositions)
+	t.positionsCacheMutex.Lock()
+	t.cachedPositions = positions
+	t.positionsCacheTime = at
+	t.positionsCacheMutex.Unlock()
+	return positions
+}
 
-		netQty, err := strconv.ParseFloat(netQtyStr, 64)
-		if err != nil || netQty == 0 {
-			continue // 跳过0持仓
-		}
+// OpenPositions 获取当前持仓（已过滤数量为0的持仓）
+func (t *BackpackTrader) OpenPositions(ctx context.Context) ([]Position, error) {
+	log.Printf("📊 [Backpack] 获取持仓信息...")
 
-		// 确定方向
-		var side string
-		var size float64
-		if netQty > 0 {
-			side = "long"
-			size = netQty
-		} else {
-			side = "short"
-			size = -netQty
+	// 调用 /api/v1/position 获取持仓（返回数组）
+	var positions []Position
+	if _, err := streamAuthenticatedArray(ctx, t, "/api/v1/position", nil, func(p Position) error {
+		// Backpack使用netQuantity，正数=多仓，负数=空仓
+		if p.Side() == "" {
+			return nil // 跳过0持仓
 		}
+		positions = append(positions, p)
+		log.Printf("  - %s: %s %.4f @ %.2f (PnL: %.2f, 杠杆: %.1fx, 保证金: %.2f)",
+			p.Symbol, p.Side(), p.Size(), p.EntryPrice.Float64(), p.PnlUnrealized.Float64(),
+			p.Leverage(), p.InitialMargin())
+		return nil
+	}); err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
 
-		// 获取符号（Backpack格式）
-		backpackSymbol, _ := pos["symbol"].(string)
-		// 转换为币安格式，以便与系统其他部分兼容
-		symbol := market.Normalize(backpackSymbol) // ETH_USDC_PERP -> ETHUSDT
+	log.Printf("✓ [Backpack] 共 %d 个持仓", len(positions))
+	return positions, nil
+}
 
-		// 获取入场价格
-		entryPriceStr, _ := pos["entryPrice"].(string)
-		entryPrice, _ := strconv.ParseFloat(entryPriceStr, 64)
+// SetPositionCacheTTL 设置 GetPosition 使用的持仓缓存有效期（<=0 表示不使用缓存）
+func (t *BackpackTrader) SetPositionCacheTTL(ttl time.Duration) {
+	t.positionsCacheMutex.Lock()
+	defer t.positionsCacheMutex.Unlock()
+	t.positionsCacheTTL = ttl
+}
 
-		// 获取标记价格
-		markPriceStr, _ := pos["markPrice"].(string)
-		markPrice, _ := strconv.ParseFloat(markPriceStr, 64)
+// invalidatePositionCache 下单后清空持仓缓存和余额响应缓存，避免读到过期数量
+func (t *BackpackTrader) invalidatePositionCache() {
+	t.positionsCacheMutex.Lock()
+	defer t.positionsCacheMutex.Unlock()
+	t.cachedPositions = nil
+	t.responses.invalidate("/api/v1/capital/collateral") // 清除全部子账户的余额缓存
+}
 
-		// 获取未实现盈亏
-		unrealizedPnLStr, _ := pos["pnlUnrealized"].(string)
-		unrealizedPnL, _ := strconv.ParseFloat(unrealizedPnLStr, 64)
+// GetPosition 获取单个交易对的持仓（带短时缓存）
+// 没有持仓时返回 nil, nil
+func (t *BackpackTrader) GetPosition(symbol string) (map[string]interface{}, error) {
+	return t.GetPositionWithContext(context.Background(), symbol)
+}
 
-		// 获取清算价格
-		liquidationPriceStr, _ := pos["liquidationPrice"].(string)
-		liquidationPrice, _ := strconv.ParseFloat(liquidationPriceStr, 64)
+// GetPositionWithContext 同 GetPosition，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetPositionWithContext(ctx context.Context, symbol string) (map[string]interface{}, error) {
+	t.positionsCacheMutex.RLock()
+	positions := t.cachedPositions
+	fresh := positions != nil && time.Since(t.positionsCacheTime) < t.positionsCacheTTL
+	t.positionsCacheMutex.RUnlock()
 
-		// 获取杠杆（Backpack可能不直接提供，使用默认值）
-		leverage := 1.0
-		if lev, ok := pos["leverage"].(float64); ok {
-			leverage = lev
+	if !fresh {
+		var err error
+		positions, err = t.GetPositionsWithContext(ctx)
+		if err != nil {
+			return nil, err
 		}
+	}
 
-		position := map[string]interface{}{
-			"symbol":            symbol,
-			"side":              side,
-			"positionAmt":       size,
-			"entryPrice":        entryPrice,
-			"markPrice":         markPrice,
-			"unRealizedProfit":  unrealizedPnL,
-			"liquidationPrice":  liquidationPrice,
-			"leverage":          leverage,
+	normalized := market.Normalize(symbol)
+	for _, pos := range positions {
+		if posSymbol, _ := pos["symbol"].(string); posSymbol == normalized {
+			return pos, nil
 		}
-
-		positions = append(positions, position)
-		log.Printf("  - %s (%s): %s %.4f @ %.2f (PnL: %.2f)", symbol, backpackSymbol, side, size, entryPrice, unrealizedPnL)
 	}
+	return nil, nil
+}
 
-	log.Printf("✓ [Backpack] 共 %d 个持仓", len(positions))
-	return positions, nil
+// positionQuantity 获取指定方向的持仓数量，用于 quantity==0 的全部平仓
+func (t *BackpackTrader) positionQuantity(ctx context.Context, symbol, side string) (float64, error) {
+	pos, err := t.GetPositionWithContext(ctx, symbol)
+	if err != nil {
+		return 0, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	if pos != nil {
+		posSide, _ := pos["side"].(string)
+		posAmt, _ := pos["positionAmt"].(float64)
+		if posSide == side && posAmt > 0 {
+			return posAmt, nil
+		}
+	}
+	return 0, nil
 }
 
 // GetMarketPrice 获取市场价格
 func (t *BackpackTrader) GetMarketPrice(symbol string) (float64, error) {
+	return t.GetMarketPriceWithContext(context.Background(), symbol)
+}
+
+// GetMarketPriceWithContext 同 GetMarketPrice，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetMarketPriceWithContext(ctx context.Context, symbol string) (float64, error) {
 	// 映射符号
 	backpackSymbol := t.mapSymbol(symbol)
 
 	// 调用公开API获取ticker
-	resp, err := t.makePublicRequest("GET", "/api/v1/ticker", map[string]string{
+	resp, err := t.makePublicRequest(ctx, "GET", "/api/v1/ticker", map[string]string{
 		"symbol": backpackSymbol,
 	})
 	if err != nil {
@@ -644,11 +620,12 @@ func (t *BackpackTrader) GetMarketPrice(symbol string) (float64, error) {
 // orderType: "Market" 或 "Limit"
 // stopLoss: 止损价格（0表示不设置）
 // takeProfit: 止盈价格（0表示不设置）
-func (t *BackpackTrader) createOrder(symbol, side, orderType string, quantity float64, price *float64, stopLoss, takeProfit float64) (map[string]interface{}, error) {
+// reduceOnly: 只减仓（平仓单使用，交易所保证不会增加或反向持仓）
+func (t *BackpackTrader) createOrder(ctx context.Context, symbol, side, orderType string, quantity float64, price *float64, stopLoss, takeProfit float64, reduceOnly bool) (*Order, error) {
 	backpackSymbol := t.mapSymbol(symbol)
 
 	// 格式化数量
-	qtyStr, err := t.FormatQuantity(backpackSymbol, quantity)
+	qtyStr, err := t.FormatQuantityWithContext(ctx, backpackSymbol, quantity)
 	if err != nil {
 		log.Printf("⚠️ [Backpack] 格式化数量失败，使用默认精度: %v", err)
 		qtyStr = formatFloat(quantity, 8)
@@ -661,131 +638,177 @@ func (t *BackpackTrader) createOrder(symbol, side, orderType string, quantity fl
 		"orderType": orderType,
 		"quantity":  qtyStr,
 	}
+	tagOrder(ctx, data)
 
 	// 限价单需要价格
 	if orderType == "Limit" && price != nil {
-		priceStr := formatFloat(*price, 2)
-		data["price"] = priceStr
+		data["price"] = t.formatPrice(ctx, backpackSymbol, *price)
+	}
+
+	if reduceOnly {
+		data["reduceOnly"] = "true"
 	}
 
 	// ✅ Backpack 止盈止损：在开仓订单中设置（OCO订单，互相取消）
 	if stopLoss > 0 {
-		data["stopLossTriggerPrice"] = formatFloat(stopLoss, 2)
-		log.Printf("  → 止损触发价: %.2f", stopLoss)
+		data["stopLossTriggerPrice"] = t.formatPrice(ctx, backpackSymbol, stopLoss)
+		log.Printf("  → 止损触发价: %s", data["stopLossTriggerPrice"])
 	}
 	if takeProfit > 0 {
-		data["takeProfitTriggerPrice"] = formatFloat(takeProfit, 2)
-		log.Printf("  → 止盈触发价: %.2f", takeProfit)
+		data["takeProfitTriggerPrice"] = t.formatPrice(ctx, backpackSymbol, takeProfit)
+		log.Printf("  → 止盈触发价: %s", data["takeProfitTriggerPrice"])
 	}
 
-	log.Printf("📤 [Backpack] 下单: %s %s %s %s", side, orderType, qtyStr, backpackSymbol)
+	log.Printf("📤 [Backpack] 下单: %s %s %s %s reduceOnly=%v", side, orderType, qtyStr, backpackSymbol, reduceOnly)
 
 	// 发送订单
-	resp, err := t.makeAuthenticatedRequest("POST", "/api/v1/order", nil, data)
+	order, err := t.requestOrder(ctx, "POST", "/api/v1/order", nil, data)
 	if err != nil {
 		return nil, fmt.Errorf("下单失败: %w", err)
 	}
+	t.invalidatePositionCache()
+
+	if order.ID == "" {
+		return nil, fmt.Errorf("下单响应缺少订单ID: %s %s %s", side, orderType, backpackSymbol)
+	}
+
+	log.Printf("✓ [Backpack] 订单已创建: ID=%s 状态=%s 已成交=%s", order.ID, order.Status,
+		formatFloat(order.ExecutedQuantity.Float64(), 8))
+	return order, nil
+}
 
-	log.Printf("✓ [Backpack] 订单已创建: %+v", resp)
-	return resp, nil
+// orderResult 将 createOrder 的结果转换为 Trader 接口的 map 返回值
+func orderResult(order *Order, err error) (map[string]interface{}, error) {
+	if err != nil {
+		return nil, err
+	}
+	return order.toMap(), nil
 }
 
 // OpenLong 开多仓
+//
+// Deprecated: 仅为满足 Trader 接口保留，新代码请使用 OpenLongOrder
 func (t *BackpackTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.OpenLongWithContext(context.Background(), symbol, quantity, leverage)
+}
+
+// OpenLongWithContext 同 OpenLong，ctx 用于设置超时和取消进行中的请求
+//
+// Deprecated: 使用 OpenLongOrder
+func (t *BackpackTrader) OpenLongWithContext(ctx context.Context, symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return orderResult(t.OpenLongOrder(ctx, symbol, quantity, leverage))
+}
+
+// OpenLongOrder 开多仓，返回交易所订单
+func (t *BackpackTrader) OpenLongOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*Order, error) {
 	// 将币安格式转换为Backpack格式: ETHUSDT -> ETH_USDC_PERP
 	backpackSymbol := market.ConvertToBackpackSymbol(symbol)
 	log.Printf("🟢 [Backpack] 开多仓: %s (原始:%s) 数量=%.4f 杠杆=%dx", backpackSymbol, symbol, quantity, leverage)
 
 	// Backpack使用Bid表示做多（买入）
 	// 注意：这个方法不带止盈止损，如需止盈止损请使用 OpenLongWithProtection
-	return t.createOrder(backpackSymbol, "Bid", "Market", quantity, nil, 0, 0)
+	return t.createOrder(ctx, backpackSymbol, "Bid", "Market", quantity, nil, 0, 0, false)
 }
 
 // OpenShort 开空仓
+//
+// Deprecated: 仅为满足 Trader 接口保留，新代码请使用 OpenShortOrder
 func (t *BackpackTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return t.OpenShortWithContext(context.Background(), symbol, quantity, leverage)
+}
+
+// OpenShortWithContext 同 OpenShort，ctx 用于设置超时和取消进行中的请求
+//
+// Deprecated: 使用 OpenShortOrder
+func (t *BackpackTrader) OpenShortWithContext(ctx context.Context, symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return orderResult(t.OpenShortOrder(ctx, symbol, quantity, leverage))
+}
+
+// OpenShortOrder 开空仓，返回交易所订单
+func (t *BackpackTrader) OpenShortOrder(ctx context.Context, symbol string, quantity float64, leverage int) (*Order, error) {
 	// 将币安格式转换为Backpack格式
 	backpackSymbol := market.ConvertToBackpackSymbol(symbol)
 	log.Printf("🔴 [Backpack] 开空仓: %s (原始:%s) 数量=%.4f 杠杆=%dx", backpackSymbol, symbol, quantity, leverage)
 
 	// Backpack使用Ask表示做空（卖出）
 	// 注意：这个方法不带止盈止损，如需止盈止损请使用 OpenShortWithProtection
-	return t.createOrder(backpackSymbol, "Ask", "Market", quantity, nil, 0, 0)
+	return t.createOrder(ctx, backpackSymbol, "Ask", "Market", quantity, nil, 0, 0, false)
 }
 
 // CloseLong 平多仓
+//
+// Deprecated: 仅为满足 Trader 接口保留，新代码请使用 CloseLongOrder
 func (t *BackpackTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.CloseLongWithContext(context.Background(), symbol, quantity)
+}
+
+// CloseLongWithContext 同 CloseLong，ctx 用于设置超时和取消进行中的请求
+//
+// Deprecated: 使用 CloseLongOrder
+func (t *BackpackTrader) CloseLongWithContext(ctx context.Context, symbol string, quantity float64) (map[string]interface{}, error) {
+	return orderResult(t.CloseLongOrder(ctx, symbol, quantity))
+}
+
+// CloseLongOrder 平多仓，返回交易所订单
+func (t *BackpackTrader) CloseLongOrder(ctx context.Context, symbol string, quantity float64) (*Order, error) {
 	// 将币安格式转换为Backpack格式
 	backpackSymbol := market.ConvertToBackpackSymbol(symbol)
 
 	// 如果 quantity = 0，表示全部平仓，需要先获取实际持仓数量
 	if quantity == 0 {
-		positions, err := t.GetPositions()
+		var err error
+		quantity, err = t.positionQuantity(ctx, symbol, "long")
 		if err != nil {
-			return nil, fmt.Errorf("获取持仓失败: %w", err)
+			return nil, err
 		}
-
-		// 查找该币种的多仓持仓
-		found := false
-		for _, pos := range positions {
-			posSymbol, _ := pos["symbol"].(string)
-			posSide, _ := pos["side"].(string)
-			posAmt, _ := pos["positionAmt"].(float64)
-
-			if posSymbol == symbol && posSide == "long" && posAmt > 0 {
-				quantity = posAmt
-				found = true
-				log.Printf("  → 全部平仓，实际数量: %.4f", quantity)
-				break
-			}
-		}
-
-		if !found || quantity == 0 {
+		if quantity == 0 {
 			return nil, fmt.Errorf("没有找到 %s 的多仓持仓", symbol)
 		}
+		log.Printf("  → 全部平仓，实际数量: %.4f", quantity)
 	}
 
 	log.Printf("🟡 [Backpack] 平多仓: %s (原始:%s) 数量=%.4f", backpackSymbol, symbol, quantity)
 
-	// 平多仓 = 卖出 = Ask
-	return t.createOrder(backpackSymbol, "Ask", "Market", quantity, nil, 0, 0)
+	// 平多仓 = 卖出 = Ask；reduceOnly 防止数量过期时反向开空
+	return t.createOrder(ctx, backpackSymbol, "Ask", "Market", quantity, nil, 0, 0, true)
 }
 
 // CloseShort 平空仓
+//
+// Deprecated: 仅为满足 Trader 接口保留，新代码请使用 CloseShortOrder
 func (t *BackpackTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return t.CloseShortWithContext(context.Background(), symbol, quantity)
+}
+
+// CloseShortWithContext 同 CloseShort，ctx 用于设置超时和取消进行中的请求
+//
+// Deprecated: 使用 CloseShortOrder
+func (t *BackpackTrader) CloseShortWithContext(ctx context.Context, symbol string, quantity float64) (map[string]interface{}, error) {
+	return orderResult(t.CloseShortOrder(ctx, symbol, quantity))
+}
+
+// CloseShortOrder 平空仓，返回交易所订单
+func (t *BackpackTrader) CloseShortOrder(ctx context.Context, symbol string, quantity float64) (*Order, error) {
 	// 将币安格式转换为Backpack格式
 	backpackSymbol := market.ConvertToBackpackSymbol(symbol)
 
 	// 如果 quantity = 0，表示全部平仓，需要先获取实际持仓数量
 	if quantity == 0 {
-		positions, err := t.GetPositions()
+		var err error
+		quantity, err = t.positionQuantity(ctx, symbol, "short")
 		if err != nil {
-			return nil, fmt.Errorf("获取持仓失败: %w", err)
-		}
-
-		// 查找该币种的空仓持仓
-		found := false
-		for _, pos := range positions {
-			posSymbol, _ := pos["symbol"].(string)
-			posSide, _ := pos["side"].(string)
-			posAmt, _ := pos["positionAmt"].(float64)
-
-			if posSymbol == symbol && posSide == "short" && posAmt > 0 {
-				quantity = posAmt
-				found = true
-				log.Printf("  → 全部平仓，实际数量: %.4f", quantity)
-				break
-			}
+			return nil, err
 		}
-
-		if !found || quantity == 0 {
+		if quantity == 0 {
 			return nil, fmt.Errorf("没有找到 %s 的空仓持仓", symbol)
 		}
+		log.Printf("  → 全部平仓，实际数量: %.4f", quantity)
 	}
 
 	log.Printf("🟡 [Backpack] 平空仓: %s (原始:%s) 数量=%.4f", backpackSymbol, symbol, quantity)
 
-	// 平空仓 = 买入 = Bid
-	return t.createOrder(backpackSymbol, "Bid", "Market", quantity, nil, 0, 0)
+	// 平空仓 = 买入 = Bid；reduceOnly 防止数量过期时反向开多
+	return t.createOrder(ctx, backpackSymbol, "Bid", "Market", quantity, nil, 0, 0, true)
 }
 
 // SetLeverage 设置杠杆（Backpack可能不支持动态调整杠杆）
@@ -809,17 +832,29 @@ func (t *BackpackTrader) SetMarginMode(symbol string, isCrossMargin bool) error
 
 // CancelAllOrders 取消所有订单
 func (t *BackpackTrader) CancelAllOrders(symbol string) error {
+	return t.CancelAllOrdersWithContext(context.Background(), symbol)
+}
+
+// CancelAllOrdersWithContext 同 CancelAllOrders，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) CancelAllOrdersWithContext(ctx context.Context, symbol string) error {
 	backpackSymbol := t.mapSymbol(symbol)
 	log.Printf("🗑️ [Backpack] 取消所有订单: %s", backpackSymbol)
 
-	params := map[string]string{
+	// DELETE 参数放在请求体中；响应为被撤销订单的数组，这里不需要解析
+	data := map[string]string{
 		"symbol": backpackSymbol,
 	}
 
-	_, err := t.makeAuthenticatedRequest("DELETE", "/api/v1/orders", params, nil)
+	resp, err := t.sendAuthenticatedRequest(ctx, "DELETE", "/api/v1/orders", nil, data)
 	if err != nil {
 		return fmt.Errorf("取消所有订单失败: %w", err)
 	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	if n := t.localStops.cancel(backpackSymbol); n > 0 {
+		log.Printf("✓ [Backpack] 已移除 %s 的 %d 个本地止损", backpackSymbol, n)
+	}
+	t.ocoOrders.cancel(backpackSymbol)
 
 	log.Printf("✓ [Backpack] 已取消 %s 的所有订单", backpackSymbol)
 	return nil
@@ -849,6 +884,24 @@ func (t *BackpackTrader) CancelStopOrders(symbol string) error {
 
 // SetStopLoss 设置止损
 func (t *BackpackTrader) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return t.SetStopLossWithContext(context.Background(), symbol, positionSide, quantity, stopPrice)
+}
+
+// SetStopLossWithContext 同 SetStopLoss，ctx 用于设置超时和取消进行中的请求
+// 挂只减仓的触发单：价格触及 stopPrice 时市价平仓（设置了 SetStopLimitOffset 时改为限价）；
+// 交易所不支持触发单时改为本地轮询价格，触及后市价平仓
+func (t *BackpackTrader) SetStopLossWithContext(ctx context.Context, symbol string, positionSide string, quantity, stopPrice float64) error {
+	_, err := t.placeStopLoss(ctx, symbol, positionSide, quantity, stopPrice)
+	return err
+}
+
+// PlaceStopLoss 同 SetStopLoss，返回止损单ID（本地监控的止损返回本地ID），供 CancelProtectionOrder 按ID撤销
+func (t *BackpackTrader) PlaceStopLoss(symbol, positionSide string, quantity, stopPrice float64) (string, error) {
+	return t.placeStopLoss(context.Background(), symbol, positionSide, quantity, stopPrice)
+}
+
+// placeStopLoss 挂止损并返回订单ID
+func (t *BackpackTrader) placeStopLoss(ctx context.Context, symbol string, positionSide string, quantity, stopPrice float64) (string, error) {
 	backpackSymbol := t.mapSymbol(symbol)
 	log.Printf("🛡️ [Backpack] 设置止损: %s %s 数量=%.4f 价格=%.2f", backpackSymbol, positionSide, quantity, stopPrice)
 
@@ -860,31 +913,76 @@ func (t *BackpackTrader) SetStopLoss(symbol string, positionSide string, quantit
 		side = "Bid" // 空仓止损 = 买入
 	}
 
-	// ⚠️ Backpack 注意事项：
-	// Backpack 的真正止损应该在开仓时通过 stopLossTriggerPrice 参数设置
-	// 这里作为事后设置，我们使用 Limit 订单挂在止损价格
-	// 虽然不是触发式止损，但可以在价格到达时自动成交
-	qtyStr, _ := t.FormatQuantity(backpackSymbol, quantity)
+	qtyStr, err := t.FormatQuantityWithContext(ctx, backpackSymbol, quantity)
+	if err != nil {
+		return "", fmt.Errorf("格式化止损数量失败: %w", err)
+	}
+	stop := localStop{Symbol: symbol, Side: side, Quantity: qtyStr, TriggerPrice: stopPrice}
+	if t.triggerUnsupported.Load() {
+		id := t.localStops.add(backpackSymbol, stop)
+		log.Printf("✓ [Backpack] 止损已设置（本地监控）")
+		return id, nil
+	}
+
 	data := map[string]string{
-		"symbol":    backpackSymbol,
-		"side":      side,
-		"orderType": "Limit",  // 使用 Limit 而不是 StopMarket
-		"quantity":  qtyStr,
-		"price":     formatFloat(stopPrice, 2),
-		"timeInForce": "GTC",  // Good Till Cancel
+		"symbol":          backpackSymbol,
+		"side":            side,
+		"orderType":       "Market",
+		"triggerPrice":    t.formatPrice(ctx, backpackSymbol, stopPrice),
+		"triggerQuantity": qtyStr,
+		"reduceOnly":      "true",
+	}
+	if pct := t.stopLimitOffsetPct; pct > 0 {
+		// 限价让出偏移，保证触发后在跳空行情中仍能成交
+		limit := stopPrice * (1 - pct/100)
+		if side == "Bid" {
+			limit = stopPrice * (1 + pct/100)
+		}
+		data["orderType"] = "Limit"
+		data["price"] = t.formatPrice(ctx, backpackSymbol, limit)
 	}
+	tagOrder(ctx, data)
 
-	_, err := t.makeAuthenticatedRequest("POST", "/api/v1/order", nil, data)
+	order, err := t.requestOrder(ctx, "POST", "/api/v1/order", nil, data)
+	if isTriggerUnsupported(err) {
+		log.Printf("⚠️ [Backpack] 交易所不支持触发单，止损改为本地监控: %v", err)
+		t.triggerUnsupported.Store(true)
+		return t.localStops.add(backpackSymbol, stop), nil
+	}
 	if err != nil {
-		return fmt.Errorf("设置止损失败: %w", err)
+		return "", fmt.Errorf("设置止损失败: %w", err)
+	}
+	if order.ID == "" {
+		return "", fmt.Errorf("止损单响应缺少订单ID: %s %s", side, backpackSymbol)
 	}
 
-	log.Printf("✓ [Backpack] 止损已设置（使用Limit订单）")
-	return nil
+	log.Printf("✓ [Backpack] 止损已设置（%s 触发单）: ID=%s", data["orderType"], order.ID)
+	return order.ID, nil
+}
+
+// SetStopLimitOffset 设置止损触发后限价单相对触发价的让价百分比（0.5 = 0.5%，0 表示触发后市价成交）
+func (t *BackpackTrader) SetStopLimitOffset(pct float64) {
+	t.stopLimitOffsetPct = pct
 }
 
 // SetTakeProfit 设置止盈
 func (t *BackpackTrader) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return t.SetTakeProfitWithContext(context.Background(), symbol, positionSide, quantity, takeProfitPrice)
+}
+
+// SetTakeProfitWithContext 同 SetTakeProfit，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) SetTakeProfitWithContext(ctx context.Context, symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	_, err := t.placeTakeProfit(ctx, symbol, positionSide, quantity, takeProfitPrice)
+	return err
+}
+
+// PlaceTakeProfit 同 SetTakeProfit，返回止盈单ID，供 CancelProtectionOrder 按ID撤销
+func (t *BackpackTrader) PlaceTakeProfit(symbol, positionSide string, quantity, takeProfitPrice float64) (string, error) {
+	return t.placeTakeProfit(context.Background(), symbol, positionSide, quantity, takeProfitPrice)
+}
+
+// placeTakeProfit 挂止盈并返回订单ID
+func (t *BackpackTrader) placeTakeProfit(ctx context.Context, symbol string, positionSide string, quantity, takeProfitPrice float64) (string, error) {
 	backpackSymbol := t.mapSymbol(symbol)
 	log.Printf("🎯 [Backpack] 设置止盈: %s %s 数量=%.4f 价格=%.2f", backpackSymbol, positionSide, quantity, takeProfitPrice)
 
@@ -896,136 +994,171 @@ func (t *BackpackTrader) SetTakeProfit(symbol string, positionSide string, quant
 		side = "Bid" // 空仓止盈 = 买入
 	}
 
-	// 创建限价止盈订单
-	qtyStr, _ := t.FormatQuantity(backpackSymbol, quantity)
+	// 创建限价止盈订单（只减仓，持仓已被止损平掉时不会反向开仓）
+	qtyStr, err := t.FormatQuantityWithContext(ctx, backpackSymbol, quantity)
+	if err != nil {
+		return "", fmt.Errorf("格式化止盈数量失败: %w", err)
+	}
 	data := map[string]string{
 		"symbol":      backpackSymbol,
 		"side":        side,
 		"orderType":   "Limit",
 		"quantity":    qtyStr,
-		"price":       formatFloat(takeProfitPrice, 2),
-		"timeInForce": "GTC",  // Good Till Cancel
-	}
-
-	_, err := t.makeAuthenticatedRequest("POST", "/api/v1/order", nil, data)
-	if err != nil {
-		return fmt.Errorf("设置止盈失败: %w", err)
-	}
-
-	log.Printf("✓ [Backpack] 止盈已设置（使用Limit订单）")
-	return nil
-}
-
-// getOrderStatus 查询订单状态
-func (t *BackpackTrader) getOrderStatus(symbol, orderID string) (string, error) {
-	backpackSymbol := t.mapSymbol(symbol)
-
-	params := map[string]string{
-		"symbol":  backpackSymbol,
-		"orderId": orderID,
+		"price":       t.formatPrice(ctx, backpackSymbol, takeProfitPrice),
+		"timeInForce": "GTC", // Good Till Cancel
+		"reduceOnly":  "true",
 	}
+	tagOrder(ctx, data)
 
-	resp, err := t.makeAuthenticatedRequest("GET", "/api/v1/order", params, nil)
+	order, err := t.requestOrder(ctx, "POST", "/api/v1/order", nil, data)
 	if err != nil {
-		return "", fmt.Errorf("查询订单状态失败: %w", err)
+		return "", fmt.Errorf("设置止盈失败: %w", err)
 	}
-
-	// 获取订单状态
-	status, ok := resp["status"].(string)
-	if !ok {
-		return "", fmt.Errorf("无法解析订单状态")
+	if order.ID == "" {
+		return "", fmt.Errorf("止盈单响应缺少订单ID: %s %s", side, backpackSymbol)
 	}
 
-	return status, nil
+	log.Printf("✓ [Backpack] 止盈已设置（使用Limit订单）: ID=%s", order.ID)
+	return order.ID, nil
 }
 
-// waitForOrderFilled 等待订单成交（最多等待30秒）
-func (t *BackpackTrader) waitForOrderFilled(symbol, orderID string, maxWaitSeconds int) error {
+// CancelProtectionOrder 按ID撤销 PlaceStopLoss/PlaceTakeProfit 挂出的单个保护单，不影响该交易对的其他挂单
+func (t *BackpackTrader) CancelProtectionOrder(symbol, orderID string) error {
 	backpackSymbol := t.mapSymbol(symbol)
-	log.Printf("⏳ [Backpack] 等待订单成交: %s (订单ID: %s)", backpackSymbol, orderID)
-
-	if maxWaitSeconds <= 0 {
-		maxWaitSeconds = 30
+	if isLocalStopID(orderID) {
+		t.localStops.removeID(backpackSymbol, orderID)
+		return nil
 	}
-
-	// 每隔0.5秒检查一次
-	checkInterval := 500 * time.Millisecond
-	maxAttempts := maxWaitSeconds * 2 // 每秒2次
-
-	for attempt := 0; attempt < maxAttempts; attempt++ {
-		time.Sleep(checkInterval)
-
-		status, err := t.getOrderStatus(symbol, orderID)
-		if err != nil {
-			log.Printf("  ⚠️ 查询订单状态失败: %v", err)
-			continue
-		}
-
-		log.Printf("  → 订单状态: %s (第%d次检查)", status, attempt+1)
-
-		switch status {
-		case "Filled":
-			log.Printf("  ✓ 订单已完全成交")
-			return nil
-		case "PartiallyFilled":
-			log.Printf("  ⏳ 订单部分成交，继续等待...")
-			continue
-		case "New":
-			// 订单还在队列中，继续等待
-			continue
-		case "Cancelled", "Expired", "Rejected":
-			return fmt.Errorf("订单未成交，状态: %s", status)
-		default:
-			log.Printf("  ⚠️ 未知订单状态: %s，继续等待...", status)
-			continue
-		}
+	if _, err := t.CancelOrder(symbol, orderID); err != nil && !isOrderNotFound(err) {
+		return fmt.Errorf("撤销保护单 %s 失败: %w", orderID, err)
 	}
-
-	return fmt.Errorf("等待订单成交超时（%d秒）", maxWaitSeconds)
+	return nil
 }
 
 // OpenLongWithProtection 开多仓并设置止盈止损（Backpack专用方法）
 // ✅ 使用 Backpack 的 OCO 订单功能，在开仓时同时设置止盈止损
 func (t *BackpackTrader) OpenLongWithProtection(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error {
+	return t.OpenLongWithProtectionWithContext(context.Background(), symbol, quantity, leverage, stopLoss, takeProfit)
+}
+
+// OpenLongWithProtectionWithContext 同 OpenLongWithProtection，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) OpenLongWithProtectionWithContext(ctx context.Context, symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error {
 	backpackSymbol := market.ConvertToBackpackSymbol(symbol)
 	log.Printf("🟢 [Backpack] 开多仓（带保护）: %s 数量=%.4f 杠杆=%dx SL=%.2f TP=%.2f",
 		symbol, quantity, leverage, stopLoss, takeProfit)
 
 	// ✅ Backpack 一次性开仓+止盈止损（OCO订单）
 	// 止盈和止损是互相关联的，触发一个会自动取消另一个
-	order, err := t.createOrder(backpackSymbol, "Bid", "Market", quantity, nil, stopLoss, takeProfit)
+	order, err := t.createOrder(ctx, backpackSymbol, "Bid", "Market", quantity, nil, stopLoss, takeProfit, false)
 	if err != nil {
 		return fmt.Errorf("开仓失败: %w", err)
 	}
 
-	log.Printf("✓ [Backpack] 开多仓完成（带OCO保护），订单ID: %v", order["id"])
+	log.Printf("✓ [Backpack] 开多仓完成（带OCO保护），订单ID: %s", order.ID)
 	return nil
 }
 
 // OpenShortWithProtection 开空仓并设置止盈止损（Backpack专用方法）
 // ✅ 使用 Backpack 的 OCO 订单功能，在开仓时同时设置止盈止损
 func (t *BackpackTrader) OpenShortWithProtection(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error {
+	return t.OpenShortWithProtectionWithContext(context.Background(), symbol, quantity, leverage, stopLoss, takeProfit)
+}
+
+// OpenShortWithProtectionWithContext 同 OpenShortWithProtection，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) OpenShortWithProtectionWithContext(ctx context.Context, symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error {
 	backpackSymbol := market.ConvertToBackpackSymbol(symbol)
 	log.Printf("🔴 [Backpack] 开空仓（带保护）: %s 数量=%.4f 杠杆=%dx SL=%.2f TP=%.2f",
 		symbol, quantity, leverage, stopLoss, takeProfit)
 
 	// ✅ Backpack 一次性开仓+止盈止损（OCO订单）
 	// 止盈和止损是互相关联的，触发一个会自动取消另一个
-	order, err := t.createOrder(backpackSymbol, "Ask", "Market", quantity, nil, stopLoss, takeProfit)
+	order, err := t.createOrder(ctx, backpackSymbol, "Ask", "Market", quantity, nil, stopLoss, takeProfit, false)
 	if err != nil {
 		return fmt.Errorf("开仓失败: %w", err)
 	}
 
-	log.Printf("✓ [Backpack] 开空仓完成（带OCO保护），订单ID: %v", order["id"])
+	log.Printf("✓ [Backpack] 开空仓完成（带OCO保护），订单ID: %s", order.ID)
 	return nil
 }
 
+// GetFundingRate 获取当前资金费率（Backpack 每小时结算一次）
+func (t *BackpackTrader) GetFundingRate(symbol string) (float64, error) {
+	return t.GetFundingRateWithContext(context.Background(), symbol)
+}
+
+// GetFundingRateWithContext 同 GetFundingRate，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetFundingRateWithContext(ctx context.Context, symbol string) (float64, error) {
+	backpackSymbol := t.mapSymbol(symbol)
+
+	resp, err := t.makePublicRequest(ctx, "GET", "/api/v1/markPrices", map[string]string{
+		"symbol": backpackSymbol,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("获取资金费率失败: %w", err)
+	}
+
+	items, ok := resp.([]interface{})
+	if !ok {
+		return 0, fmt.Errorf("markPrices响应格式错误")
+	}
+	for _, item := range items {
+		entry, ok := item.(map[string]interface{})
+		if !ok || entry["symbol"] != backpackSymbol {
+			continue
+		}
+		rateStr, _ := entry["fundingRate"].(string)
+		rate, err := strconv.ParseFloat(rateStr, 64)
+		if err != nil {
+			return 0, fmt.Errorf("解析资金费率失败: %w", err)
+		}
+		return rate, nil
+	}
+	return 0, fmt.Errorf("未找到 %s 的资金费率", backpackSymbol)
+}
+
+// GetExchangeStatus 查询交易所运行状态（公开接口 /api/v1/status）
+func (t *BackpackTrader) GetExchangeStatus() (*ExchangeStatus, error) {
+	return t.GetExchangeStatusWithContext(context.Background())
+}
+
+// GetExchangeStatusWithContext 同 GetExchangeStatus，ctx 用于设置超时和取消进行中的请求
+func (t *BackpackTrader) GetExchangeStatusWithContext(ctx context.Context) (*ExchangeStatus, error) {
+	resp, err := t.makePublicRequest(ctx, "GET", "/api/v1/status", nil)
+	if err != nil {
+		return nil, fmt.Errorf("获取交易所状态失败: %w", err)
+	}
+	data, ok := resp.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("status响应格式错误")
+	}
+
+	status := &ExchangeStatus{CheckedAt: time.Now()}
+	status.Message, _ = data["message"].(string)
+	switch state, _ := data["status"].(string); state {
+	case "Ok":
+		status.State = ExchangeStateOK
+	case "Maintenance":
+		status.State = ExchangeStateMaintenance
+	default:
+		status.State = ExchangeStateDegraded
+		if status.Message == "" {
+			status.Message = "未知状态: " + state
+		}
+	}
+	return status, nil
+}
+
 // FormatQuantity 格式化数量（根据交易对精度）
 func (t *BackpackTrader) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return t.FormatQuantityWithContext(context.Background(), symbol, quantity)
+}
+
+// FormatQuantityWithContext 同 FormatQuantity，ctx 用于取消首次查询精度的请求
+func (t *BackpackTrader) FormatQuantityWithContext(ctx context.Context, symbol string, quantity float64) (string, error) {
 	backpackSymbol := t.mapSymbol(symbol)
 
 	// 获取精度信息
-	precision, err := t.getSymbolPrecision(backpackSymbol)
+	precision, err := t.getSymbolPrecision(ctx, backpackSymbol)
 	if err != nil {
 		log.Printf("⚠️ [Backpack] 获取 %s 精度失败: %v，使用默认精度", backpackSymbol, err)
 		// 使用默认精度
@@ -1037,8 +1170,27 @@ func (t *BackpackTrader) FormatQuantity(symbol string, quantity float64) (string
 	return formatted, nil
 }
 
+// formatPrice 按交易对价格精度格式化价格（获取精度失败时使用默认2位）
+func (t *BackpackTrader) formatPrice(ctx context.Context, symbol string, price float64) string {
+	precision, err := t.getSymbolPrecision(ctx, t.mapSymbol(symbol))
+	if err != nil {
+		log.Printf("⚠️ [Backpack] 获取 %s 精度失败: %v，价格使用默认精度", symbol, err)
+		return formatFloat(price, 2)
+	}
+	return formatFloat(price, precision.PricePrecision)
+}
+
+// QuantityStep 返回交易对的数量步进值（用于按金额下单时向下取整）
+func (t *BackpackTrader) QuantityStep(symbol string) (float64, error) {
+	precision, err := t.getSymbolPrecision(context.Background(), t.mapSymbol(symbol))
+	if err != nil {
+		return 0, err
+	}
+	return precision.StepSize, nil
+}
+
 // getSymbolPrecision 获取交易对精度信息
-func (t *BackpackTrader) getSymbolPrecision(symbol string) (*SymbolPrecision, error) {
+func (t *BackpackTrader) getSymbolPrecision(ctx context.Context, symbol string) (*SymbolPrecision, error) {
 	// 检查缓存
 	if precision, ok := t.symbolPrecision[symbol]; ok {
 		return precision, nil
@@ -1046,7 +1198,7 @@ func (t *BackpackTrader) getSymbolPrecision(symbol string) (*SymbolPrecision, er
 
 	// 从市场信息获取精度
 	// 调用 /api/v1/markets 获取所有市场信息
-	resp, err := t.makePublicRequest("GET", "/api/v1/markets", nil)
+	resp, err := t.makePublicRequest(ctx, "GET", "/api/v1/markets", nil)
 	if err != nil {
 		return nil, fmt.Errorf("获取市场信息失败: %w", err)
 	}
@@ -1070,9 +1222,9 @@ func (t *BackpackTrader) getSymbolPrecision(symbol string) (*SymbolPrecision, er
 
 		// 解析精度信息
 		precision := &SymbolPrecision{
-			PricePrecision:    2,     // 默认价格精度
-			QuantityPrecision: 8,     // 默认数量精度
-			TickSize:          0.01,  // 默认价格步进
+			PricePrecision:    2,          // 默认价格精度
+			QuantityPrecision: 8,          // 默认数量精度
+			TickSize:          0.01,       // 默认价格步进
 			StepSize:          0.00000001, // 默认数量步进
 		}
 
@@ -1082,6 +1234,9 @@ func (t *BackpackTrader) getSymbolPrecision(symbol string) (*SymbolPrecision, er
 			if priceFilter, ok := filters["price"].(map[string]interface{}); ok {
 				if tickSize, ok := priceFilter["tickSize"].(string); ok {
 					precision.PricePrecision = calculatePrecision(tickSize)
+					if tick, err := strconv.ParseFloat(tickSize, 64); err == nil {
+						precision.TickSize = tick
+					}
 				}
 			}
 
@@ -0,0 +1,1170 @@
+package trader
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestBackpackTrader 创建指向 mock 服务器的 Backpack 交易器
+func newTestBackpackTrader(t *testing.T, serverURL string) *BackpackTrader {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	trader, err := NewBackpackTrader("test-api-key", base64.StdEncoding.EncodeToString(seed), "test-user")
+	require.NoError(t, err)
+	trader.baseURL = serverURL
+	return trader
+}
+
+func TestBackpackTrader_WarmUp(t *testing.T) {
+	var pings int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/ping" {
+			atomic.AddInt32(&pings, 1)
+			w.Write([]byte("pong"))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	assert.NoError(t, trader.WarmUp(4))
+	assert.Equal(t, int32(4), atomic.LoadInt32(&pings))
+}
+
+func TestBackpackTrader_WarmUpAllFailed(t *testing.T) {
+	trader := newTestBackpackTrader(t, "http://127.0.0.1:1")
+	assert.Error(t, trader.WarmUp(2))
+}
+
+func TestSharedBackpackTransportIsReused(t *testing.T) {
+	a := newTestBackpackTrader(t, "http://a")
+	b := newTestBackpackTrader(t, "http://b")
+	assert.Same(t, a.client.Transport, b.client.Transport)
+	assert.Equal(t, 32, sharedBackpackTransport().MaxIdleConnsPerHost)
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	body := `[{"tradeId":1,"orderId":"11","symbol":"SOL_USDC_PERP","side":"Bid","price":"101.5","quantity":"2","fee":0.01,"feeSymbol":"USDC","isMaker":true,"timestamp":"2024-05-01T12:00:00"},
+	          {"tradeId":2,"orderId":"12","symbol":"SOL_USDC_PERP","side":"Ask","price":"102","quantity":"1.5","fee":"0.02","feeSymbol":"USDC","isMaker":false,"timestamp":"2024-05-01T12:05:00.123"}]`
+
+	var fills []Fill
+	n, err := decodeJSONArray(strings.NewReader(body), func(f Fill) error {
+		fills = append(fills, f)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.Equal(t, 101.5, fills[0].Price.Float64())
+	assert.Equal(t, 0.01, fills[0].Fee.Float64())
+	assert.Equal(t, 0.02, fills[1].Fee.Float64())
+	assert.True(t, fills[0].IsMaker)
+	assert.Equal(t, time.Date(2024, 5, 1, 12, 5, 0, 123000000, time.UTC), fills[1].Time())
+}
+
+func TestDecodeJSONArray_NotArray(t *testing.T) {
+	_, err := decodeJSONArray(strings.NewReader(`{"code":"ERR"}`), func(Fill) error { return nil })
+	assert.Error(t, err)
+}
+
+// newHistoryPagingServer 模拟按 offset/limit 分页的历史接口，记录每次请求的 offset
+func newHistoryPagingServer(t *testing.T, path string, total int, offsets *[]string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, path, r.URL.Path)
+		*offsets = append(*offsets, r.URL.Query().Get("offset"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		items := make([]map[string]interface{}, 0, limit)
+		for i := offset; i < total && i < offset+limit; i++ {
+			items = append(items, map[string]interface{}{"id": strconv.Itoa(i), "tradeId": i, "price": "1", "quantity": "1"})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+	}))
+}
+
+func TestBackpackTrader_StreamFillHistoryPaginates(t *testing.T) {
+	const total = backpackHistoryPageSize + 3
+	var offsets []string
+	server := newHistoryPagingServer(t, "/wapi/v1/history/fills", total, &offsets)
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	seen := 0
+	n, err := trader.StreamFillHistory("SOLUSDT", func(Fill) error {
+		seen++
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, total, n)
+	assert.Equal(t, total, seen)
+	assert.Equal(t, []string{"0", strconv.Itoa(backpackHistoryPageSize)}, offsets)
+}
+
+func TestBackpackTrader_FetchOrderHistoryHardCap(t *testing.T) {
+	var offsets []string
+	server := newHistoryPagingServer(t, "/wapi/v1/history/orders", 2500, &offsets)
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	orders, err := trader.FetchOrderHistory("", 1500)
+	require.NoError(t, err)
+	assert.Len(t, orders, 1500)
+	assert.Equal(t, "1499", orders[1499].ID)
+	assert.Equal(t, []string{"0", "1000"}, offsets)
+}
+
+func TestBackpackTrader_FetchFillHistorySmallCap(t *testing.T) {
+	var offsets []string
+	server := newHistoryPagingServer(t, "/wapi/v1/history/fills", 50, &offsets)
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	fills, err := trader.FetchFillHistory("BTCUSDT", 10)
+	require.NoError(t, err)
+	assert.Len(t, fills, 10)
+	assert.Equal(t, []string{"0"}, offsets)
+}
+
+func TestBuildSigningString(t *testing.T) {
+	params := map[string]string{"symbol": "SOL_USDC_PERP", "empty": ""}
+	data := map[string]string{"side": "Bid", "quantity": "1.5", "orderType": "Market"}
+
+	got := buildSigningString("orderExecute", params, data, 1700000000000, 60000)
+	want := "instruction=orderExecute&symbol=SOL_USDC_PERP&orderType=Market&quantity=1.5&side=Bid&timestamp=1700000000000&window=60000"
+	assert.Equal(t, want, got)
+
+	// 缓冲区复用后之前返回的字符串不受影响
+	_ = buildSigningString("balanceQuery", nil, nil, 1, 2)
+	assert.Equal(t, want, got)
+}
+
+// backpackSignatureVectors 签名黄金向量：私钥为 0x00..0x1f 的32字节seed，时间戳 1700000000000，窗口 5000
+// ED25519 签名是确定性的，任何签名规则的改动都会导致这些向量失效
+var backpackSignatureVectors = []struct {
+	name          string
+	method        string
+	endpoint      string
+	params        map[string]string
+	data          map[string]string
+	signingString string
+	signature     string
+}{
+	{
+		name:          "无参数查询",
+		method:        "GET",
+		endpoint:      "/api/v1/capital",
+		signingString: "instruction=balanceQuery&timestamp=1700000000000&window=5000",
+		signature:     "ogsyh1c6LnY10GiJaCoAEdHpkK2BT4KgS7NAd1PW2DQndw1OnR0WbY0M753KwW9Mq6Dmw3k+Kdw+wPxzKMCiAg==",
+	},
+	{
+		name:          "下单请求体",
+		method:        "POST",
+		endpoint:      "/api/v1/order",
+		data:          map[string]string{"symbol": "SOL_USDC_PERP", "side": "Bid", "orderType": "Market", "quantity": "1.5"},
+		signingString: "instruction=orderExecute&orderType=Market&quantity=1.5&side=Bid&symbol=SOL_USDC_PERP&timestamp=1700000000000&window=5000",
+		signature:     "e8/tgjpIFKUyWvCSOCk8Ru0B+uur+8dkTvVTsueWDhUJNNsSMbY21UxJdVTUsbUNqsosavVxU9HN0jUMHFPWBg==",
+	},
+	{
+		name:          "查询参数含特殊字符（签名使用未转义的值）",
+		method:        "GET",
+		endpoint:      "/api/v1/orders",
+		params:        map[string]string{"symbol": "SOL_USDC_PERP", "weird": "a&b=c"},
+		signingString: "instruction=orderQueryAll&symbol=SOL_USDC_PERP&weird=a&b=c&timestamp=1700000000000&window=5000",
+		signature:     "WwkDwkNy+kkdfVXGjWGWO4nANU0Y4cw3wdzLTfYLdTEFtKvNv8Nz1xw4JUR0PWvFEMSlvHlJ0MabQNyPrzXbBA==",
+	},
+}
+
+func TestSignBackpackRequest_GoldenVectors(t *testing.T) {
+	seed := make([]byte, ed25519.SeedSize)
+	for i := range seed {
+		seed[i] = byte(i)
+	}
+	key := base64.StdEncoding.EncodeToString(seed)
+
+	for _, v := range backpackSignatureVectors {
+		t.Run(v.name, func(t *testing.T) {
+			sig, err := SignBackpackRequest(key, v.method, v.endpoint, v.params, v.data, 1700000000000, 5000)
+			require.NoError(t, err)
+			assert.Equal(t, v.signingString, sig.SigningString)
+			assert.Equal(t, v.signature, sig.Signature)
+			assert.Equal(t, "A6EHv/POEL4dcN0Y50vAmWfk1jCbpQ1fHdyGZBJVMbg=", sig.PublicKey)
+			assert.NoError(t, VerifyBackpackSignature(sig.PublicKey, v.signingString, v.signature))
+		})
+	}
+
+	// 64字节私钥与32字节seed等价
+	full := ed25519.NewKeyFromSeed(seed)
+	sig, err := SignBackpackRequest(base64.StdEncoding.EncodeToString(full), "GET", "/api/v1/capital", nil, nil, 1700000000000, 5000)
+	require.NoError(t, err)
+	assert.Equal(t, backpackSignatureVectors[0].signature, sig.Signature)
+}
+
+func TestVerifyBackpackSignature(t *testing.T) {
+	// RFC 8032 TEST 1（空消息）
+	pub := "11qYAYKxCrfVS/7TyWQHOg7hcvPapiMlrwIaaPcHURo="
+	sig := "5VZDAMNgrHKQhuLMgG6CioSHfx645dl02HPgZSJJAVVfuIIVkKM7rMYeOXAc+bRr0lv18FlbviRlUUFDjnoQCw=="
+	assert.NoError(t, VerifyBackpackSignature(pub, "", sig))
+	assert.ErrorContains(t, VerifyBackpackSignature(pub, "tampered", sig), "签名不匹配")
+	assert.ErrorContains(t, VerifyBackpackSignature("AAAA", "", sig), "公钥长度错误")
+
+	_, err := SignBackpackRequest("bm90LWEta2V5", "GET", "/api/v1/capital", nil, nil, 1, 1)
+	assert.ErrorContains(t, err, "私钥长度错误")
+}
+
+func TestDetermineInstructionType(t *testing.T) {
+	trader := newTestBackpackTrader(t, "http://localhost")
+	assert.Equal(t, "orderExecute", trader.determineInstructionType("post", "api/v1/order/"))
+	assert.Equal(t, "orderCancelAll", trader.determineInstructionType("DELETE", "/api/v1/orders"))
+	assert.Equal(t, "fillHistoryQueryAll", trader.determineInstructionType("GET", "/wapi/v1/history/fills"))
+	assert.Equal(t, "get_api_v1_unknown", trader.determineInstructionType("GET", "/api/v1/unknown"))
+}
+
+func BenchmarkGenerateSignature(b *testing.B) {
+	seed := make([]byte, ed25519.SeedSize)
+	trader, err := NewBackpackTrader("bench-key", base64.StdEncoding.EncodeToString(seed), "bench")
+	if err != nil {
+		b.Fatal(err)
+	}
+	data := map[string]string{
+		"symbol":                 "SOL_USDC_PERP",
+		"side":                   "Bid",
+		"orderType":              "Market",
+		"quantity":               "1.25",
+		"stopLossTriggerPrice":   "95.5",
+		"takeProfitTriggerPrice": "120",
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := trader.generateSignature("POST", "/api/v1/order", nil, data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEncodeQuery(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string]string
+		want   string
+	}{
+		{"空参数", nil, ""},
+		{"全部为空值", map[string]string{"a": ""}, ""},
+		{"按key排序", map[string]string{"symbol": "SOL_USDC_PERP", "limit": "100"}, "limit=100&symbol=SOL_USDC_PERP"},
+		{"特殊字符转义", map[string]string{"clientId": "a&b=c"}, "clientId=a%26b%3Dc"},
+		{"空格和加号", map[string]string{"note": "a b+c"}, "note=a+b%2Bc"},
+		{"Unicode", map[string]string{"tag": "止损"}, "tag=%E6%AD%A2%E6%8D%9F"},
+		{"跳过空值", map[string]string{"from": "", "to": "1700000000000"}, "to=1700000000000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, encodeQuery(tt.params))
+		})
+	}
+}
+
+func TestBackpackTrader_QuerySignatureMatchesServerDecoding(t *testing.T) {
+	params := map[string]string{
+		"symbol": "SOL_USDC_PERP",
+		"weird":  "a&b=c d+e/止",
+		"empty":  "",
+	}
+
+	var trader *BackpackTrader
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// 模拟服务端：解码查询参数后重建签名字符串并验签
+		decoded := map[string]string{}
+		for k, v := range r.URL.Query() {
+			decoded[k] = v[0]
+		}
+		assert.Equal(t, params["weird"], decoded["weird"])
+		assert.NotContains(t, decoded, "empty")
+
+		ts, _ := strconv.ParseInt(r.Header.Get("X-TIMESTAMP"), 10, 64)
+		window, _ := strconv.ParseInt(r.Header.Get("X-WINDOW"), 10, 64)
+		msg := buildSigningString("orderQueryAll", decoded, nil, ts, window)
+		sig, err := base64.StdEncoding.DecodeString(r.Header.Get("X-SIGNATURE"))
+		require.NoError(t, err)
+		pub := trader.privateKey.Public().(ed25519.PublicKey)
+		assert.True(t, ed25519.Verify(pub, []byte(msg), sig), "signature mismatch for %s", msg)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("[]"))
+	}))
+	defer server.Close()
+
+	trader = newTestBackpackTrader(t, server.URL)
+	_, err := trader.makeAuthenticatedRequestArray(context.Background(), "GET", "/api/v1/orders", params, nil)
+	assert.NoError(t, err)
+}
+
+func TestBackpackTrader_RateLimitedBacksOffGlobally(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Retry-After", "30")
+		w.WriteHeader(http.StatusTooManyRequests)
+		w.Write([]byte(`{"code":"TOO_MANY_REQUESTS"}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	_, err := trader.makeAuthenticatedRequest(context.Background(), "GET", "/api/v1/capital", nil, nil)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+
+	var rlErr *RateLimitError
+	require.True(t, errors.As(err, &rlErr))
+	assert.Equal(t, http.StatusTooManyRequests, rlErr.StatusCode)
+	assert.Equal(t, 30*time.Second, rlErr.RetryAfter)
+
+	// 退避期内的请求（包括公共接口）不应再发往交易所
+	_, err = trader.makePublicRequest(context.Background(), "GET", "/api/v1/markets", nil)
+	assert.True(t, errors.Is(err, ErrRateLimited))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&hits))
+}
+
+func TestBackpackTrader_NonRateLimitErrorIsAPIError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"code":"INVALID_ORDER"}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	_, err := trader.makeAuthenticatedRequest(context.Background(), "GET", "/api/v1/capital", nil, nil)
+	require.Error(t, err)
+	assert.False(t, errors.Is(err, ErrRateLimited))
+
+	var apiErr *APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusBadRequest, apiErr.StatusCode)
+	assert.Zero(t, trader.backoffRemaining())
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, 5*time.Second, parseRetryAfter("5", now, time.Second))
+	assert.Equal(t, 1500*time.Millisecond, parseRetryAfter("1.5", now, time.Second))
+	assert.Equal(t, 10*time.Second, parseRetryAfter(now.Add(10*time.Second).Format(http.TimeFormat), now, time.Second))
+	assert.Equal(t, time.Duration(0), parseRetryAfter(now.Add(-time.Minute).Format(http.TimeFormat), now, time.Second))
+	assert.Equal(t, 2*time.Minute, parseRetryAfter("", now, 2*time.Minute))
+	assert.Equal(t, time.Second, parseRetryAfter("garbage", now, time.Second))
+}
+
+// newPartialFillServer 模拟一直部分成交的限价单（1.0 中成交 0.4，均价 100）
+func newPartialFillServer(t *testing.T, cancels, marketOrders *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/order" && r.Method == "GET":
+			w.Write([]byte(`{"id":"111","side":"Bid","status":"PartiallyFilled","quantity":"1","executedQuantity":"0.4","executedQuoteQuantity":"40"}`))
+		case r.URL.Path == "/api/v1/order" && r.Method == "DELETE":
+			atomic.AddInt32(cancels, 1)
+			w.Write([]byte(`{"id":"111","side":"Bid","status":"Cancelled","quantity":"1","executedQuantity":"0.5","executedQuoteQuantity":"50"}`))
+		case r.URL.Path == "/api/v1/order" && r.Method == "POST":
+			atomic.AddInt32(marketOrders, 1)
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, "Market", body["orderType"])
+			assert.Equal(t, "0.5", body["quantity"])
+			w.Write([]byte(`{"id":"222","side":"Bid","status":"Filled","quantity":"0.5","executedQuantity":"0.5","executedQuoteQuantity":"55"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestBackpackTrader_WaitForOrderFillTimeoutPolicies(t *testing.T) {
+	t.Run("wait", func(t *testing.T) {
+		var cancels, marketOrders int32
+		server := newPartialFillServer(t, &cancels, &marketOrders)
+		defer server.Close()
+
+		result, err := newTestBackpackTrader(t, server.URL).WaitForOrderFill("SOLUSDT", "111", 1, FillTimeoutWait)
+		require.NoError(t, err)
+		assert.True(t, result.TimedOut)
+		assert.InDelta(t, 0.4, result.FilledQuantity, 1e-9)
+		assert.InDelta(t, 100, result.AvgPrice, 1e-9)
+		assert.InDelta(t, 0.6, result.RemainingQuantity, 1e-9)
+		assert.Zero(t, atomic.LoadInt32(&cancels))
+	})
+
+	t.Run("cancel-remainder", func(t *testing.T) {
+		var cancels, marketOrders int32
+		server := newPartialFillServer(t, &cancels, &marketOrders)
+		defer server.Close()
+
+		result, err := newTestBackpackTrader(t, server.URL).WaitForOrderFill("SOLUSDT", "111", 1, FillTimeoutCancelRemainder)
+		require.NoError(t, err)
+		// 以撤单响应中的成交量为准
+		assert.Equal(t, "Cancelled", result.Status)
+		assert.InDelta(t, 0.5, result.FilledQuantity, 1e-9)
+		assert.InDelta(t, 0.5, result.RemainingQuantity, 1e-9)
+		assert.Equal(t, int32(1), atomic.LoadInt32(&cancels))
+		assert.Zero(t, atomic.LoadInt32(&marketOrders))
+	})
+
+	t.Run("convert-to-market", func(t *testing.T) {
+		var cancels, marketOrders int32
+		server := newPartialFillServer(t, &cancels, &marketOrders)
+		defer server.Close()
+
+		result, err := newTestBackpackTrader(t, server.URL).WaitForOrderFill("SOLUSDT", "111", 1, FillTimeoutConvertToMarket)
+		require.NoError(t, err)
+		assert.True(t, result.Filled())
+		assert.Equal(t, "222", result.MarketOrderID)
+		assert.InDelta(t, 1.0, result.FilledQuantity, 1e-9)
+		assert.InDelta(t, 105, result.AvgPrice, 1e-9) // (50 + 55) / 1.0
+		assert.Equal(t, int32(1), atomic.LoadInt32(&marketOrders))
+	})
+
+	t.Run("context-cancelled", func(t *testing.T) {
+		var cancels, marketOrders int32
+		server := newPartialFillServer(t, &cancels, &marketOrders)
+		defer server.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond) // 首次查询在 500ms 后
+		defer cancel()
+		start := time.Now()
+		result, err := newTestBackpackTrader(t, server.URL).WaitForOrderFillWithContext(ctx, "SOLUSDT", "111", 10, FillTimeoutConvertToMarket)
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second, "ctx 取消后应立即返回，不等到超时")
+		require.NotNil(t, result)
+		assert.InDelta(t, 0.4, result.FilledQuantity, 1e-9)
+		// 调用方已放弃等待，不再撤单或转市价
+		assert.Zero(t, atomic.LoadInt32(&cancels))
+		assert.Zero(t, atomic.LoadInt32(&marketOrders))
+	})
+}
+
+func TestLimitEntryOptions_Timeout(t *testing.T) {
+	assert.Zero(t, LimitEntryOptions{}.timeout())
+	assert.Equal(t, 30*time.Second, LimitEntryOptions{Timeout: 30 * time.Second}.timeout())
+	assert.Equal(t, 15*time.Minute, LimitEntryOptions{Timeout: time.Minute, Candles: 3, CandleInterval: 5 * time.Minute}.timeout())
+}
+
+func TestBackpackTrader_LimitEntryCancelledOnTimeout(t *testing.T) {
+	var cancels, orders int32
+	var prices []string
+	var mu sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/order" && r.Method == "POST":
+			n := atomic.AddInt32(&orders, 1)
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			assert.Equal(t, "Limit", body["orderType"])
+			mu.Lock()
+			prices = append(prices, body["price"])
+			mu.Unlock()
+			w.Write([]byte(`{"id":"` + strconv.Itoa(int(n)) + `","status":"New"}`))
+		case r.URL.Path == "/api/v1/order" && r.Method == "GET":
+			w.Write([]byte(`{"id":"1","side":"Bid","status":"New","quantity":"1","executedQuantity":"0"}`))
+		case r.URL.Path == "/api/v1/order" && r.Method == "DELETE":
+			atomic.AddInt32(&cancels, 1)
+			w.Write([]byte(`{"id":"1","side":"Bid","status":"Cancelled","quantity":"1","executedQuantity":"0.25","executedQuoteQuantity":"25"}`))
+		case r.URL.Path == "/api/v1/ticker":
+			w.Write([]byte(`{"lastPrice":"101.5"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	_, err := trader.OpenLongLimit("SOLUSDT", 1, 100, LimitEntryOptions{
+		Timeout:     50 * time.Millisecond,
+		Action:      EntryTimeoutReprice,
+		MaxReprices: 1,
+	})
+	require.NoError(t, err)
+
+	// 首次超时撤单后按最新价重挂一次，重挂单再次超时后只撤不挂
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&cancels) == 2 }, 2*time.Second, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&orders))
+	mu.Lock()
+	assert.Equal(t, []string{"100", "101.5"}, prices)
+	mu.Unlock()
+}
+
+func TestBackpackTrader_GetPositionUsesCache(t *testing.T) {
+	var positionCalls int32
+	var closeQty string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/api/v1/position":
+			atomic.AddInt32(&positionCalls, 1)
+			w.Write([]byte(`[{"symbol":"SOL_USDC_PERP","netQuantity":"2.5","entryPrice":"100"},{"symbol":"ETH_USDC_PERP","netQuantity":"-1","entryPrice":"3000"}]`))
+		case r.URL.Path == "/api/v1/order" && r.Method == "POST":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			closeQty = body["quantity"]
+			w.Write([]byte(`{"id":"1","status":"Filled"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+
+	pos, err := trader.GetPosition("SOLUSDT")
+	require.NoError(t, err)
+	require.NotNil(t, pos)
+	assert.Equal(t, "long", pos["side"])
+	assert.Equal(t, 2.5, pos["positionAmt"])
+
+	pos, err = trader.GetPosition("BTCUSDT")
+	require.NoError(t, err)
+	assert.Nil(t, pos)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&positionCalls))
+
+	// 全部平仓读取缓存中的数量，下单后缓存失效
+	_, err = trader.CloseShort("ETHUSDT", 0)
+	require.NoError(t, err)
+	assert.Equal(t, "1", closeQty)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&positionCalls))
+
+	_, err = trader.CloseLong("ETHUSDT", 0)
+	assert.Error(t, err)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&positionCalls))
+
+	trader.SetPositionCacheTTL(0)
+	_, err = trader.GetPosition("SOLUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), atomic.LoadInt32(&positionCalls))
+}
+
+func TestBackpackTrader_CloseOrdersAreReduceOnly(t *testing.T) {
+	var bodies []map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/order" && r.Method == "POST" {
+			var body map[string]interface{}
+			json.NewDecoder(r.Body).Decode(&body)
+			bodies = append(bodies, body)
+			w.Write([]byte(`{"id":"1","status":"Filled"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	_, err := trader.OpenLong("SOLUSDT", 1, 5)
+	require.NoError(t, err)
+	_, err = trader.CloseLong("SOLUSDT", 1)
+	require.NoError(t, err)
+	_, err = trader.CloseShort("SOLUSDT", 1)
+	require.NoError(t, err)
+
+	require.Len(t, bodies, 3)
+	assert.NotContains(t, bodies[0], "reduceOnly", "开仓单不设置只减仓")
+	assert.Equal(t, true, bodies[1]["reduceOnly"])
+	assert.Equal(t, "Ask", bodies[1]["side"])
+	assert.Equal(t, true, bodies[2]["reduceOnly"])
+	assert.Equal(t, "Bid", bodies[2]["side"])
+}
+
+func TestBackpackTrader_GetPositionsParsesMargin(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[{"symbol":"BTC_USDC_PERP","netQuantity":"-0.5","netExposureNotional":"-30000","entryPrice":"61000","markPrice":"60000",
+			"estLiquidationPrice":"70000","imf":"0.1","mmf":"0.05","pnlUnrealized":"500"},
+			{"symbol":"SOL_USDC_PERP","netQuantity":"0","imf":"0.02"}]`))
+	}))
+	defer server.Close()
+
+	positions, err := newTestBackpackTrader(t, server.URL).GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+
+	pos := positions[0]
+	assert.Equal(t, "BTCUSDT", pos["symbol"])
+	assert.Equal(t, "short", pos["side"])
+	assert.Equal(t, 0.5, pos["positionAmt"])
+	assert.Equal(t, 70000.0, pos["liquidationPrice"])
+	assert.InDelta(t, 10.0, pos["leverage"], 1e-9)
+	assert.InDelta(t, 30000.0, pos["notional"], 1e-9)
+	assert.InDelta(t, 3000.0, pos["initialMargin"], 1e-9)
+	assert.InDelta(t, 1500.0, pos["maintenanceMargin"], 1e-9)
+}
+
+func TestPosition_DerivedValues(t *testing.T) {
+	var p Position
+	require.NoError(t, json.Unmarshal([]byte(`{"netQuantity":"2","markPrice":"50","imf":""}`), &p))
+	assert.Equal(t, "long", p.Side())
+	assert.Equal(t, 100.0, p.Notional()) // 未返回 netExposureNotional 时用 数量×标记价格
+	assert.Equal(t, 1.0, p.Leverage())
+	assert.Zero(t, p.InitialMargin())
+}
+
+func TestBalance_ParsesStringNumbersAndCollateral(t *testing.T) {
+	var b Balance
+	require.NoError(t, json.Unmarshal([]byte(`{"netEquity":"1000.5","netEquityAvailable":800,"pnlUnrealized":"-12.5"}`), &b))
+	assert.Equal(t, 1000.5, b.TotalWalletBalance())
+	assert.Equal(t, 800.0, b.AvailableBalance())
+	assert.Equal(t, -12.5, b.PnlUnrealized.Float64())
+
+	// 抵押资产以各自币种计价，不能把数量相加：有净值时以净值为准
+	b = Balance{}
+	require.NoError(t, json.Unmarshal([]byte(`{"netEquity":"9999","netEquityAvailable":"8888","collateral":[
+		{"symbol":"USDC","assetMarkPrice":"1","totalQuantity":"400","availableQuantity":"300"},
+		{"symbol":"SOL","assetMarkPrice":"150","totalQuantity":100,"availableQuantity":"50"}]}`), &b))
+	assert.Equal(t, 9999.0, b.TotalWalletBalance())
+	assert.Equal(t, 8888.0, b.AvailableBalance())
+	assert.Equal(t, map[string]interface{}{
+		"totalWalletBalance":    9999.0,
+		"availableBalance":      8888.0,
+		"totalUnrealizedProfit": 0.0,
+	}, b.toMap())
+
+	// 未返回净值时按标记价格折算美元价值（优先使用 balanceNotional）
+	b = Balance{}
+	require.NoError(t, json.Unmarshal([]byte(`{"collateral":[
+		{"symbol":"USDC","assetMarkPrice":"1","totalQuantity":"400","availableQuantity":"300"},
+		{"symbol":"SOL","assetMarkPrice":"150","totalQuantity":100,"availableQuantity":"50","balanceNotional":"14900"}]}`), &b))
+	assert.Equal(t, 400.0+14900.0, b.TotalWalletBalance())
+	assert.Equal(t, 300.0+50*150.0, b.AvailableBalance())
+}
+
+func TestBackpackTrader_TypedBalanceAndPositions(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/capital/collateral":
+			w.Write([]byte(`{"netEquity":"1000","netEquityAvailable":"750","pnlUnrealized":"25"}`))
+		case "/api/v1/position":
+			w.Write([]byte(`[{"symbol":"ETH_USDC_PERP","netQuantity":"1.5","entryPrice":"3000","markPrice":"3010"},
+				{"symbol":"SOL_USDC_PERP","netQuantity":"0"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	trader := newTestBackpackTrader(t, server.URL)
+
+	balance, err := trader.AccountBalance(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, 1000.0, balance.TotalWalletBalance())
+	assert.Equal(t, 750.0, balance.AvailableBalance())
+
+	legacy, err := trader.GetBalance()
+	require.NoError(t, err)
+	assert.Equal(t, 750.0, legacy["availableBalance"])
+	assert.Equal(t, 25.0, legacy["totalUnrealizedProfit"])
+
+	positions, err := trader.OpenPositions(context.Background())
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "ETH_USDC_PERP", positions[0].Symbol)
+	assert.Equal(t, "long", positions[0].Side())
+	assert.Equal(t, 1.5, positions[0].Size())
+}
+
+func TestBackpackTrader_CreateOrderTypedResult(t *testing.T) {
+	var response string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/order" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(response))
+	}))
+	defer server.Close()
+	trader := newTestBackpackTrader(t, server.URL)
+
+	response = `{"id":"114250432327","clientId":42,"symbol":"SOL_USDC_PERP","side":"Bid","status":"Filled",
+		"quantity":"1","executedQuantity":"1","executedQuoteQuantity":"150.5","stopLossTriggerPrice":"140"}`
+	order, err := trader.createOrder(context.Background(), "SOL_USDC_PERP", "Bid", "Market", 1, nil, 140, 0, false)
+	require.NoError(t, err)
+	assert.Equal(t, "114250432327", order.ID)
+	assert.Equal(t, "42", order.ClientID.String())
+	assert.Equal(t, 1.0, order.ExecutedQuantity.Float64())
+	assert.Equal(t, 140.0, order.StopLossTriggerPrice.Float64())
+
+	result, err := trader.OpenLong("SOLUSDT", 1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, int64(114250432327), result["orderId"])
+	assert.Equal(t, "Filled", result["status"])
+
+	// 响应缺少订单ID时必须报错，不能当作下单成功
+	response = `{"status":"New"}`
+	_, err = trader.createOrder(context.Background(), "SOL_USDC_PERP", "Bid", "Market", 1, nil, 0, 0, false)
+	assert.ErrorContains(t, err, "缺少订单ID")
+}
+
+func TestExchangeStatusMonitor_BackpackMaintenance(t *testing.T) {
+	var response atomic.Value
+	response.Store(`{"status":"Ok","message":null}`)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := response.Load().(string)
+		if body == "" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	defer server.Close()
+
+	monitor := NewExchangeStatusMonitor(newTestBackpackTrader(t, server.URL))
+	assert.Equal(t, ExchangeStateOK, monitor.Check().State)
+	assert.False(t, monitor.EntriesPaused())
+	assert.Equal(t, 1.0, monitor.ToleranceMultiplier())
+
+	response.Store(`{"status":"Maintenance","message":"Scheduled upgrade"}`)
+	status := monitor.Check()
+	assert.Equal(t, ExchangeStateMaintenance, status.State)
+	assert.Equal(t, "Scheduled upgrade", status.Message)
+	assert.True(t, monitor.EntriesPaused())
+	assert.Greater(t, monitor.ToleranceMultiplier(), 1.0)
+
+	response.Store(`{"status":"Ok","message":null}`)
+	assert.Equal(t, ExchangeStateOK, monitor.Check().State)
+
+	// 状态接口连续失败达到阈值才视为降级
+	response.Store("")
+	for i := 1; i < defaultStatusFailureLimit; i++ {
+		assert.Equal(t, ExchangeStateOK, monitor.Check().State)
+	}
+	assert.Equal(t, ExchangeStateDegraded, monitor.Check().State)
+}
+
+func TestValidateStopEntry(t *testing.T) {
+	assert.NoError(t, validateStopEntry("Bid", 100, StopEntryOptions{StopLoss: 95, TakeProfit: 110}))
+	assert.NoError(t, validateStopEntry("Ask", 100, StopEntryOptions{StopLoss: 105, TakeProfit: 90}))
+	assert.Error(t, validateStopEntry("Bid", 100, StopEntryOptions{StopLoss: 101}))
+	assert.Error(t, validateStopEntry("Ask", 100, StopEntryOptions{TakeProfit: 101}))
+	assert.Error(t, validateStopEntry("Bid", 0, StopEntryOptions{}))
+}
+
+func TestBackpackTrader_OpenLongStop(t *testing.T) {
+	var body map[string]interface{}
+	lastPrice := "99.5"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/ticker":
+			w.Write([]byte(`{"symbol":"SOL_USDC_PERP","lastPrice":"` + lastPrice + `"}`))
+		case "/api/v1/order":
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			w.Write([]byte(`{"id":"7","symbol":"SOL_USDC_PERP","side":"Bid","status":"TriggerPending","quantity":"2","triggerPrice":"100"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	trader := newTestBackpackTrader(t, server.URL)
+
+	result, err := trader.OpenLongStop("SOLUSDT", 2, 100, StopEntryOptions{StopLoss: 97, TakeProfit: 106})
+	require.NoError(t, err)
+	assert.Equal(t, "TriggerPending", result["status"])
+	assert.Equal(t, "Market", body["orderType"])
+	assert.Equal(t, "100", body["triggerPrice"])
+	assert.Equal(t, "2", body["triggerQuantity"])
+	assert.Equal(t, "97", body["stopLossTriggerPrice"])
+	assert.Equal(t, "106", body["takeProfitTriggerPrice"])
+	assert.IsType(t, float64(0), body["clientId"], "clientId 以整数发送")
+
+	// 价格已越过触发价时拒绝挂单
+	body = nil
+	lastPrice = "100.2"
+	_, err = trader.OpenLongStop("SOLUSDT", 2, 100, StopEntryOptions{})
+	assert.ErrorContains(t, err, "已越过触发价")
+	assert.Nil(t, body)
+}
+
+func TestBackpackAccountStream_BalanceFollowsPositionUpdates(t *testing.T) {
+	var collateralRequests, moved int32
+	subscribed := make(chan []string, 1)
+	upgrader := websocket.Upgrader{}
+	var tr *BackpackTrader
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/capital/collateral":
+			atomic.AddInt32(&collateralRequests, 1)
+			w.Header().Set("Content-Type", "application/json")
+			if atomic.LoadInt32(&moved) == 1 {
+				w.Write([]byte(`{"netEquity":1030,"netEquityAvailable":830,"pnlUnrealized":80}`))
+				return
+			}
+			w.Write([]byte(`{"netEquity":1000,"netEquityAvailable":800,"pnlUnrealized":50}`))
+		case "/api/v1/position":
+			w.Header().Set("Content-Type", "application/json")
+			if atomic.LoadInt32(&moved) == 1 {
+				w.Write([]byte(`[{"symbol":"SOL_USDC_PERP","netQuantity":"2","entryPrice":"100","markPrice":"140","pnlUnrealized":"80"}]`))
+				return
+			}
+			w.Write([]byte(`[{"symbol":"SOL_USDC_PERP","netQuantity":"2","entryPrice":"100","markPrice":"125","pnlUnrealized":"50"}]`))
+		case "/ws":
+			conn, err := upgrader.Upgrade(w, r, nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			var sub struct {
+				Method    string   `json:"method"`
+				Params    []string `json:"params"`
+				Signature []string `json:"signature"`
+			}
+			require.NoError(t, conn.ReadJSON(&sub))
+			assert.Equal(t, "SUBSCRIBE", sub.Method)
+			require.Len(t, sub.Signature, 4)
+			signingString := "instruction=subscribe&timestamp=" + sub.Signature[2] + "&window=" + sub.Signature[3]
+			publicKey := base64.StdEncoding.EncodeToString(tr.privateKey.Public().(ed25519.PublicKey))
+			assert.NoError(t, VerifyBackpackSignature(publicKey, signingString, sub.Signature[1]))
+			subscribed <- sub.Params
+
+			conn.WriteMessage(websocket.TextMessage, []byte(
+				`{"stream":"account.positionUpdate","data":{"e":"positionAdjusted","s":"SOL_USDC_PERP","M":"140","P":"80","q":"2"}}`))
+			// 交易所侧同步变化（之后的REST刷新与推送结果一致）
+			atomic.StoreInt32(&moved, 1)
+			// 保持连接直到测试结束
+			for {
+				if _, _, err := conn.ReadMessage(); err != nil {
+					return
+				}
+			}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	tr = newTestBackpackTrader(t, server.URL)
+	stream := newBackpackAccountStream(tr, "ws"+strings.TrimPrefix(server.URL, "http")+"/ws")
+	require.NoError(t, stream.refresh())
+	_, ok := stream.Balance()
+	assert.False(t, ok, "未连接时不使用推送余额")
+
+	tr.accountStream = stream
+	go stream.run()
+	defer tr.DisableAccountStream()
+
+	select {
+	case params := <-subscribed:
+		assert.ElementsMatch(t, []string{"account.positionUpdate", "account.orderUpdate"}, params)
+	case <-time.After(5 * time.Second):
+		t.Fatal("未收到订阅请求")
+	}
+
+	// 未实现盈亏 50 -> 80：权益按差值修正（REST刷新后结果一致）
+	require.Eventually(t, func() bool {
+		balance, ok := stream.Balance()
+		return ok && balance.TotalWalletBalance() == 1030
+	}, 5*time.Second, 10*time.Millisecond)
+
+	before := atomic.LoadInt32(&collateralRequests)
+	balance, err := tr.GetBalance()
+	require.NoError(t, err)
+	assert.Equal(t, 1030.0, balance["totalWalletBalance"])
+	assert.Equal(t, 830.0, balance["availableBalance"])
+	assert.Equal(t, 80.0, balance["totalUnrealizedProfit"])
+	assert.Equal(t, before, atomic.LoadInt32(&collateralRequests), "推送有效时不请求REST")
+}
+
+func TestBackpackAccountStream_FillTriggersRefresh(t *testing.T) {
+	stream := newBackpackAccountStream(&BackpackTrader{}, "")
+	stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderAccepted","s":"SOL_USDC_PERP"}}`))
+	assert.Len(t, stream.refreshCh, 0)
+	stream.handleMessage([]byte(`{"stream":"account.orderUpdate","data":{"e":"orderFill","s":"SOL_USDC_PERP"}}`))
+	assert.Len(t, stream.refreshCh, 1)
+
+	// 平仓：未实现盈亏清零并请求刷新（请求合并，不阻塞）
+	stream.handleMessage([]byte(`{"stream":"account.positionUpdate","data":{"e":"positionClosed","s":"SOL_USDC_PERP","P":"12","q":"0"}}`))
+	assert.Equal(t, 0.0, stream.unrealized["SOLUSDT"])
+	assert.Len(t, stream.refreshCh, 1)
+}
+
+func TestBackpackTrader_ContextDeadlineAbortsRequest(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	trader := newTestBackpackTrader(t, server.URL)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := trader.GetBalanceWithContext(ctx)
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded))
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestBackpackTrader_CancelledContextSkipsRequest(t *testing.T) {
+	var hits int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"lastPrice":"150.5"}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := trader.GetMarketPriceWithContext(ctx, "SOLUSDT")
+	assert.True(t, errors.Is(err, context.Canceled))
+	assert.Equal(t, int32(0), atomic.LoadInt32(&hits))
+
+	// 不带 ctx 的旧接口行为不变
+	price, err := trader.GetMarketPrice("SOLUSDT")
+	require.NoError(t, err)
+	assert.Equal(t, 150.5, price)
+}
+
+func TestBackpackTrader_GetFills(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	const total = backpackHistoryPageSize + 5
+	var froms, offsets []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/wapi/v1/history/fills", r.URL.Path)
+		froms = append(froms, r.URL.Query().Get("from"))
+		offsets = append(offsets, r.URL.Query().Get("offset"))
+		offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+		limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+		var items []map[string]interface{}
+		for i := offset; i < total && i < offset+limit; i++ {
+			ts := since.Add(time.Duration(i) * time.Second)
+			if i == 0 {
+				ts = since.Add(-time.Hour) // 交易所未按 from 过滤的旧成交
+			}
+			items = append(items, map[string]interface{}{
+				"tradeId": i, "orderId": strconv.Itoa(i), "symbol": "SOL_USDC_PERP", "side": "Bid",
+				"price": "150.5", "quantity": "2", "fee": "0.012", "feeSymbol": "USDC", "isMaker": i%2 == 0,
+				"timestamp": ts.Format("2006-01-02T15:04:05.000"),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	fills, err := trader.GetFills("SOLUSDT", since, 0)
+	require.NoError(t, err)
+	assert.Len(t, fills, total-1, "过滤 since 之前的成交")
+	assert.Equal(t, []string{"0", strconv.Itoa(backpackHistoryPageSize)}, offsets)
+	assert.Equal(t, strconv.FormatInt(since.UnixMilli(), 10), froms[0])
+
+	f := fills[0]
+	assert.Equal(t, "1", f.OrderID)
+	assert.InDelta(t, 150.5, f.Price.Float64(), 1e-9)
+	assert.InDelta(t, 2, f.Quantity.Float64(), 1e-9)
+	assert.InDelta(t, 0.012, f.Fee.Float64(), 1e-9)
+	assert.Equal(t, "USDC", f.FeeSymbol)
+	assert.False(t, f.IsMaker)
+	assert.True(t, fills[1].IsMaker)
+	assert.Equal(t, since.Add(time.Second), f.Time())
+
+	offsets = nil
+	fills, err = trader.GetFills("SOLUSDT", time.Time{}, 3)
+	require.NoError(t, err)
+	assert.Len(t, fills, 3)
+	assert.Equal(t, []string{"0"}, offsets, "取够 limit 后停止翻页")
+	assert.Empty(t, froms[len(froms)-1], "since 为零值时不带 from")
+}
+
+func TestBackpackTrader_GetOrderHistory(t *testing.T) {
+	from := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	to := from.Add(time.Hour)
+	const total = backpackHistoryPageSize + 10
+	var offsets []string
+	var query url.Values
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/wapi/v1/history/orders", r.URL.Path)
+		query = r.URL.Query()
+		offsets = append(offsets, query.Get("offset"))
+		offset, _ := strconv.Atoi(query.Get("offset"))
+		limit, _ := strconv.Atoi(query.Get("limit"))
+
+		var items []map[string]interface{}
+		for i := offset; i < total && i < offset+limit; i++ {
+			created := from.Add(time.Duration(i) * time.Second)
+			if i == total-2 {
+				created = to.Add(time.Minute) // 交易所未按 to 过滤
+			}
+			status := "Filled"
+			if i%2 == 1 {
+				status = "Cancelled"
+			}
+			items = append(items, map[string]interface{}{
+				"id": strconv.Itoa(i), "symbol": "SOL_USDC_PERP", "side": "Ask", "orderType": "Market",
+				"status": status, "quantity": "1", "executedQuantity": "1", "createdAt": created.UnixMilli(),
+			})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(items)
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	orders, err := trader.GetOrderHistory("SOLUSDT", from, to, "filled")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0", strconv.Itoa(backpackHistoryPageSize)}, offsets, "自动翻页")
+	assert.Equal(t, "SOL_USDC_PERP", query.Get("symbol"))
+	assert.Equal(t, strconv.FormatInt(from.UnixMilli(), 10), query.Get("from"))
+	assert.Equal(t, strconv.FormatInt(to.UnixMilli(), 10), query.Get("to"))
+	assert.Len(t, orders, total/2-1, "按状态和时间过滤")
+	for _, o := range orders {
+		assert.Equal(t, "Filled", o.Status)
+		assert.False(t, o.CreatedTime().After(to))
+	}
+	assert.Equal(t, from, orders[0].CreatedTime())
+
+	orders, err = trader.GetOrderHistory("", time.Time{}, time.Time{}, "")
+	require.NoError(t, err)
+	assert.Len(t, orders, total)
+	assert.Empty(t, query.Get("from"))
+	assert.Empty(t, query.Get("symbol"))
+}
+
+func TestBackpackTrader_GetFundingPayments(t *testing.T) {
+	since := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var query url.Values
+	var instruction string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/wapi/v1/history/funding", r.URL.Path)
+		query = r.URL.Query()
+		instruction = backpackInstructionType(r.Method, r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`[
+			{"symbol":"SOL_USDC_PERP","quantity":"-0.0123","fundingRate":"0.0001","intervalEndTimestamp":"2024-05-01T02:00:00"},
+			{"symbol":"SOL_USDC_PERP","quantity":"0.004","fundingRate":"-0.00005","intervalEndTimestamp":"2024-05-01T01:00:00"},
+			{"symbol":"SOL_USDC_PERP","quantity":"-0.01","fundingRate":"0.0001","intervalEndTimestamp":"2024-04-30T23:00:00"}
+		]`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	payments, err := trader.GetFundingPayments("SOLUSDT", since, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "SOL_USDC_PERP", query.Get("symbol"))
+	assert.Equal(t, strconv.FormatInt(since.UnixMilli(), 10), query.Get("from"), "since 应由交易所过滤，不能下载全部历史")
+	assert.Equal(t, "fundingHistoryQueryAll", instruction)
+	require.Len(t, payments, 2, "过滤 since 之前的结算")
+	assert.InDelta(t, -0.0123, payments[0].Payment(), 1e-12)
+	assert.InDelta(t, 0.0001, payments[0].FundingRate.Float64(), 1e-12)
+	assert.Equal(t, since.Add(2*time.Hour), payments[0].Time())
+	assert.InDelta(t, 0.004, payments[1].Payment(), 1e-12)
+
+	payments, err = trader.GetFundingPayments("", time.Time{}, 1)
+	require.NoError(t, err)
+	assert.Len(t, payments, 1)
+	assert.Empty(t, query.Get("symbol"))
+	assert.Empty(t, query.Get("from"))
+}
+
+// newOrderCaptureServer 返回低价币精度（DOGE_USDC_PERP tickSize 0.00001）并记录下单请求体的测试服务器
+func newOrderCaptureServer(t *testing.T, bodies *[]map[string]interface{}) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/markets":
+			w.Write([]byte(`[{"symbol":"DOGE_USDC_PERP","filters":{"price":{"tickSize":"0.00001"},"quantity":{"stepSize":"1"}}}]`))
+		case "/api/v1/order":
+			var body map[string]interface{}
+			require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+			*bodies = append(*bodies, body)
+			w.Write([]byte(`{"id":"` + strconv.Itoa(len(*bodies)) + `","status":"New"}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestBackpackTrader_PrecisionLookupHonoursContext(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/v1/markets" {
+			// 市场信息接口挂起，首次查询精度只能靠 ctx 取消
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		http.NotFound(w, r)
+	}))
+	defer server.Close()
+	defer close(release)
+	tr := newTestBackpackTrader(t, server.URL)
+	tr.SetResponseCache(ResponseCacheConfig{})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := tr.PlaceLimitOrderWithContext(ctx, "SOLUSDT", "buy", 1, 100, LimitOrderOptions{})
+	require.Error(t, err)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "err = %v", err)
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+func TestBackpackTrader_PlaceQuoteUsesPricePrecision(t *testing.T) {
+	var bodies []map[string]interface{}
+	trader := newTestBackpackTrader(t, newOrderCaptureServer(t, &bodies).URL)
+
+	_, err := trader.PlaceQuote("DOGEUSDT", true, 100, 0.12341)
+	require.NoError(t, err)
+	_, err = trader.PlaceQuote("DOGEUSDT", false, 100, 0.12349)
+	require.NoError(t, err)
+	require.Len(t, bodies, 2)
+	assert.Equal(t, "0.12341", bodies[0]["price"], "买卖报价不能被舍入到同一价格")
+	assert.Equal(t, "0.12349", bodies[1]["price"])
+
+	_, err = trader.createOrder(context.Background(), "DOGE_USDC_PERP", "Bid", "Market", 100, nil, 0.11877, 0.13456, false)
+	require.NoError(t, err)
+	require.Len(t, bodies, 3)
+	assert.Equal(t, "0.11877", bodies[2]["stopLossTriggerPrice"])
+	assert.Equal(t, "0.13456", bodies[2]["takeProfitTriggerPrice"])
+}
+
+func TestBackpackTrader_TriggerOrderUsesPricePrecision(t *testing.T) {
+	var bodies []map[string]interface{}
+	trader := newTestBackpackTrader(t, newOrderCaptureServer(t, &bodies).URL)
+
+	_, err := trader.createTriggerOrder(context.Background(), "DOGE_USDC_PERP", "Bid", 100, 0.1234,
+		StopEntryOptions{LimitPrice: 0.12345, StopLoss: 0.11877, TakeProfit: 0.13456})
+	require.NoError(t, err)
+	require.Len(t, bodies, 1)
+	assert.Equal(t, "0.1234", bodies[0]["triggerPrice"], "低价币触发价不能被舍入到 0.12")
+	assert.Equal(t, "0.12345", bodies[0]["price"])
+	assert.Equal(t, "0.11877", bodies[0]["stopLossTriggerPrice"])
+	assert.Equal(t, "0.13456", bodies[0]["takeProfitTriggerPrice"])
+}
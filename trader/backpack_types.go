@@ -0,0 +1,317 @@
+package trader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"nofx/market"
+	"strconv"
+	"time"
+)
+
+// FlexFloat 兼容字符串和数字两种JSON表示的浮点数
+// Backpack 大部分数值字段以字符串返回（如 "499.9"），少数字段为数字
+type FlexFloat float64
+
+// UnmarshalJSON 解析 "1.23" / 1.23 / null / ""
+func (f *FlexFloat) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "null" {
+		*f = 0
+		return nil
+	}
+	if data[0] == '"' {
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		if s == "" {
+			*f = 0
+			return nil
+		}
+		v, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return fmt.Errorf("无效的数值 %q: %w", s, err)
+		}
+		*f = FlexFloat(v)
+		return nil
+	}
+	var v float64
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+	*f = FlexFloat(v)
+	return nil
+}
+
+// Float64 转换为 float64
+func (f FlexFloat) Float64() float64 {
+	return float64(f)
+}
+
+// backpackTimeLayouts Backpack 历史接口返回的时间格式（UTC，无时区后缀）
+var backpackTimeLayouts = []string{
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	time.RFC3339Nano,
+}
+
+// parseBackpackTime 解析 Backpack 时间字符串（数字字符串视为毫秒时间戳）
+func parseBackpackTime(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("时间为空")
+	}
+	if ms, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.UnixMilli(ms).UTC(), nil
+	}
+	for _, layout := range backpackTimeLayouts {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("无法解析时间: %s", s)
+}
+
+// Fill 成交记录（/wapi/v1/history/fills）
+type Fill struct {
+	TradeID   json.Number `json:"tradeId"`
+	OrderID   string      `json:"orderId"`
+	ClientID  json.Number `json:"clientId"`
+	Symbol    string      `json:"symbol"`
+	Side      string      `json:"side"` // "Bid" / "Ask"
+	Price     FlexFloat   `json:"price"`
+	Quantity  FlexFloat   `json:"quantity"`
+	Fee       FlexFloat   `json:"fee"`
+	FeeSymbol string      `json:"feeSymbol"`
+	IsMaker   bool        `json:"isMaker"`
+	Timestamp string      `json:"timestamp"`
+}
+
+// Time 成交时间
+func (f Fill) Time() time.Time {
+	t, _ := parseBackpackTime(f.Timestamp)
+	return t
+}
+
+// Tag 解析 clientId 中的策略归因信息
+func (f Fill) Tag() (OrderTag, bool) {
+	return decodeClientIDNumber(f.ClientID)
+}
+
+// FundingPayment 资金费收付记录（/wapi/v1/history/funding）
+type FundingPayment struct {
+	Symbol               string    `json:"symbol"`
+	Quantity             FlexFloat `json:"quantity"`    // 资金费金额（正数为收取，负数为支付）
+	FundingRate          FlexFloat `json:"fundingRate"` // 该结算周期的资金费率
+	IntervalEndTimestamp string    `json:"intervalEndTimestamp"`
+}
+
+// Time 资金费结算时间
+func (p FundingPayment) Time() time.Time {
+	t, _ := parseBackpackTime(p.IntervalEndTimestamp)
+	return t
+}
+
+// Payment 资金费金额（正数为收取，负数为支付）
+func (p FundingPayment) Payment() float64 {
+	return p.Quantity.Float64()
+}
+
+// Order 订单（/api/v1/order、/wapi/v1/history/orders）
+type Order struct {
+	ID                    string      `json:"id"`
+	ClientID              json.Number `json:"clientId"`
+	Symbol                string      `json:"symbol"`
+	Side                  string      `json:"side"`
+	OrderType             string      `json:"orderType"`
+	Status                string      `json:"status"`
+	Price                 FlexFloat   `json:"price"`
+	Quantity              FlexFloat   `json:"quantity"`
+	ExecutedQuantity      FlexFloat   `json:"executedQuantity"`
+	ExecutedQuoteQuantity FlexFloat   `json:"executedQuoteQuantity"`
+	TimeInForce           string      `json:"timeInForce"`
+	PostOnly              bool        `json:"postOnly"`
+	ReduceOnly            bool        `json:"reduceOnly"`
+	TriggerPrice          FlexFloat   `json:"triggerPrice"`
+	CreatedAt             json.Number `json:"createdAt"`
+
+	// 开仓单附带的止盈止损（OCO）
+	StopLossTriggerPrice   FlexFloat `json:"stopLossTriggerPrice"`
+	TakeProfitTriggerPrice FlexFloat `json:"takeProfitTriggerPrice"`
+	StopLossOrderID        string    `json:"stopLossOrderId"`   // 交易所返回时填充
+	TakeProfitOrderID      string    `json:"takeProfitOrderId"` // 交易所返回时填充
+}
+
+// Tag 解析 clientId 中的策略归因信息
+func (o *Order) Tag() (OrderTag, bool) {
+	return decodeClientIDNumber(o.ClientID)
+}
+
+// CreatedTime 下单时间（无法解析时为零值）
+func (o *Order) CreatedTime() time.Time {
+	t, _ := parseBackpackTime(o.CreatedAt.String())
+	return t
+}
+
+// IsTrigger 是否为条件单（止损/止盈等触发单）
+func (o *Order) IsTrigger() bool {
+	return o.TriggerPrice.Float64() > 0 || o.Status == "TriggerPending"
+}
+
+// toMap 转换为 Trader 接口使用的 map 结果
+// orderId 按其他交易所的约定为 int64（无法解析时为0），原始ID保存在 id 字段；symbol 转换为标准格式
+func (o *Order) toMap() map[string]interface{} {
+	orderID, _ := strconv.ParseInt(o.ID, 10, 64)
+	return map[string]interface{}{
+		"orderId":               orderID,
+		"id":                    o.ID,
+		"clientId":              o.ClientID.String(),
+		"symbol":                market.Normalize(o.Symbol),
+		"side":                  o.Side,
+		"orderType":             o.OrderType,
+		"status":                o.Status,
+		"price":                 o.Price.Float64(),
+		"quantity":              o.Quantity.Float64(),
+		"executedQuantity":      o.ExecutedQuantity.Float64(),
+		"executedQuoteQuantity": o.ExecutedQuoteQuantity.Float64(),
+		"stopLossOrderId":       o.StopLossOrderID,
+		"takeProfitOrderId":     o.TakeProfitOrderID,
+	}
+}
+
+// CollateralBalance 单个抵押资产（数量以各自资产计价，不能直接相加）
+type CollateralBalance struct {
+	Symbol            string    `json:"symbol"`
+	AssetMarkPrice    FlexFloat `json:"assetMarkPrice"`
+	TotalQuantity     FlexFloat `json:"totalQuantity"`
+	AvailableQuantity FlexFloat `json:"availableQuantity"`
+	BalanceNotional   FlexFloat `json:"balanceNotional"` // 按标记价格折算的美元价值
+}
+
+// Balance 账户余额（/api/v1/capital/collateral）
+type Balance struct {
+	NetEquity          FlexFloat           `json:"netEquity"`
+	NetEquityAvailable FlexFloat           `json:"netEquityAvailable"`
+	PnlUnrealized      FlexFloat           `json:"pnlUnrealized"`
+	Collateral         []CollateralBalance `json:"collateral"`
+}
+
+// TotalWalletBalance 账户净值（netEquity）；未返回净值时按标记价格汇总各抵押资产的美元价值
+func (b *Balance) TotalWalletBalance() float64 {
+	if equity := b.NetEquity.Float64(); equity != 0 || len(b.Collateral) == 0 {
+		return equity
+	}
+	var total float64
+	for _, c := range b.Collateral {
+		if notional := c.BalanceNotional.Float64(); notional != 0 {
+			total += notional
+		} else {
+			total += c.TotalQuantity.Float64() * c.AssetMarkPrice.Float64()
+		}
+	}
+	return total
+}
+
+// AvailableBalance 可用净值（netEquityAvailable）；未返回时按标记价格汇总各抵押资产可用数量的美元价值
+func (b *Balance) AvailableBalance() float64 {
+	if available := b.NetEquityAvailable.Float64(); available != 0 || len(b.Collateral) == 0 {
+		return available
+	}
+	var available float64
+	for _, c := range b.Collateral {
+		available += c.AvailableQuantity.Float64() * c.AssetMarkPrice.Float64()
+	}
+	return available
+}
+
+// toMap 转换为 Trader 接口使用的 map 结果
+func (b *Balance) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"totalWalletBalance":    b.TotalWalletBalance(),
+		"availableBalance":      b.AvailableBalance(),
+		"totalUnrealizedProfit": b.PnlUnrealized.Float64(),
+	}
+}
+
+// Position 持仓（/api/v1/position）
+// Backpack 使用净持仓：NetQuantity 为正表示多仓，为负表示空仓
+type Position struct {
+	Symbol                   string    `json:"symbol"`
+	PositionID               string    `json:"positionId"`
+	NetQuantity              FlexFloat `json:"netQuantity"`
+	NetExposureQuantity      FlexFloat `json:"netExposureQuantity"`
+	NetExposureNotional      FlexFloat `json:"netExposureNotional"`
+	NetCost                  FlexFloat `json:"netCost"`
+	EntryPrice               FlexFloat `json:"entryPrice"`
+	BreakEvenPrice           FlexFloat `json:"breakEvenPrice"`
+	MarkPrice                FlexFloat `json:"markPrice"`
+	EstLiquidationPrice      FlexFloat `json:"estLiquidationPrice"`
+	IMF                      FlexFloat `json:"imf"` // 初始保证金率
+	MMF                      FlexFloat `json:"mmf"` // 维持保证金率
+	PnlUnrealized            FlexFloat `json:"pnlUnrealized"`
+	PnlRealized              FlexFloat `json:"pnlRealized"`
+	CumulativeFundingPayment FlexFloat `json:"cumulativeFundingPayment"`
+}
+
+// toMap 转换为 Trader 接口使用的币安格式 map（symbol 统一为 ETHUSDT 形式）
+func (p Position) toMap() map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":            market.Normalize(p.Symbol),
+		"side":              p.Side(),
+		"positionAmt":       p.Size(),
+		"entryPrice":        p.EntryPrice.Float64(),
+		"markPrice":         p.MarkPrice.Float64(),
+		"unRealizedProfit":  p.PnlUnrealized.Float64(),
+		"liquidationPrice":  p.EstLiquidationPrice.Float64(),
+		"leverage":          p.Leverage(),
+		"notional":          p.Notional(),
+		"initialMargin":     p.InitialMargin(),
+		"maintenanceMargin": p.MaintenanceMargin(),
+		"imf":               p.IMF.Float64(),
+		"mmf":               p.MMF.Float64(),
+	}
+}
+
+// Side 持仓方向："long" / "short"（无持仓时为空）
+func (p Position) Side() string {
+	switch {
+	case p.NetQuantity > 0:
+		return "long"
+	case p.NetQuantity < 0:
+		return "short"
+	default:
+		return ""
+	}
+}
+
+// Size 持仓数量（绝对值）
+func (p Position) Size() float64 {
+	return math.Abs(p.NetQuantity.Float64())
+}
+
+// Notional 持仓名义价值（绝对值），交易所未返回时用 数量×标记价格 估算
+func (p Position) Notional() float64 {
+	if n := math.Abs(p.NetExposureNotional.Float64()); n > 0 {
+		return n
+	}
+	return p.Size() * p.MarkPrice.Float64()
+}
+
+// Leverage 有效杠杆 = 1 / 初始保证金率；交易所未返回 imf 时为 1
+func (p Position) Leverage() float64 {
+	if imf := p.IMF.Float64(); imf > 0 {
+		return 1 / imf
+	}
+	return 1
+}
+
+// InitialMargin 占用的初始保证金 = 名义价值 × imf
+func (p Position) InitialMargin() float64 {
+	return p.Notional() * p.IMF.Float64()
+}
+
+// MaintenanceMargin 维持保证金 = 名义价值 × mmf
+func (p Position) MaintenanceMargin() float64 {
+	return p.Notional() * p.MMF.Float64()
+}
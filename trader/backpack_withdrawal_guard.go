@@ -0,0 +1,129 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultWithdrawalConfirmTimeout 默认等待人工确认的时长
+const defaultWithdrawalConfirmTimeout = 5 * time.Minute
+
+// ErrWithdrawalBlocked 提现被本地保护拦截（地址不在白名单、超过当日上限或未获确认），请求未签名发出
+var ErrWithdrawalBlocked = errors.New("提现被拦截")
+
+// FundsConfirmer 资金转出前的人工确认（如 logger.TelegramConfirmer 的确认按钮）
+// 确认返回 true，拒绝返回 false；超时或发送失败返回错误
+type FundsConfirmer interface {
+	Confirm(ctx context.Context, summary string) (bool, error)
+}
+
+// WithdrawalGuardConfig 提现本地保护，在提现请求签名之前检查
+type WithdrawalGuardConfig struct {
+	AllowedAddresses map[string][]string // 区块链 -> 允许的提现地址（不在白名单内的一律拒绝）
+	DailyLimits      map[string]float64  // 币种 -> 每个UTC日累计提现上限（未配置的币种一律拒绝）
+	Confirmer        FundsConfirmer      // 每笔提现前的人工确认（必填）
+	ConfirmTimeout   time.Duration       // 等待确认的时长（默认5分钟），超时视为拒绝
+}
+
+// withdrawalGuard 已启用的提现保护；mu 让提现逐笔检查、确认和提交，避免并发请求同时通过当日上限
+type withdrawalGuard struct {
+	cfg WithdrawalGuardConfig
+	mu  sync.Mutex
+}
+
+// SetWithdrawalGuard 启用提现保护：地址白名单、每日上限和人工确认，任一不满足时 RequestWithdrawal 返回 ErrWithdrawalBlocked
+func (t *BackpackTrader) SetWithdrawalGuard(cfg WithdrawalGuardConfig) error {
+	if cfg.Confirmer == nil {
+		return fmt.Errorf("提现保护必须配置人工确认")
+	}
+	if len(cfg.AllowedAddresses) == 0 {
+		return fmt.Errorf("提现保护至少需要一个白名单地址")
+	}
+	limits := make(map[string]float64, len(cfg.DailyLimits))
+	for symbol, limit := range cfg.DailyLimits {
+		if limit <= 0 {
+			return fmt.Errorf("%s 的每日提现上限必须大于0", symbol)
+		}
+		limits[strings.ToUpper(symbol)] = limit
+	}
+	cfg.DailyLimits = limits
+	if cfg.ConfirmTimeout <= 0 {
+		cfg.ConfirmTimeout = defaultWithdrawalConfirmTimeout
+	}
+	t.withdrawalGuard = &withdrawalGuard{cfg: cfg}
+	log.Printf("🔐 [Backpack] 已启用提现保护: %d 条链白名单, %d 个币种每日上限", len(cfg.AllowedAddresses), len(limits))
+	return nil
+}
+
+// allowed 地址是否在该链的白名单内（0x 开头的 EVM 地址不区分大小写）
+func (c WithdrawalGuardConfig) allowed(blockchain, address string) bool {
+	for chain, addresses := range c.AllowedAddresses {
+		if !strings.EqualFold(chain, blockchain) {
+			continue
+		}
+		for _, a := range addresses {
+			if a == address || (strings.HasPrefix(a, "0x") && strings.EqualFold(a, address)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkWithdrawal 依次检查白名单、当日上限和人工确认（调用方持有 guard.mu）
+func (t *BackpackTrader) checkWithdrawal(ctx context.Context, guard *withdrawalGuard, req WithdrawalRequest) error {
+	cfg := guard.cfg
+	if !cfg.allowed(req.Blockchain, req.Address) {
+		return fmt.Errorf("%w: 地址 %s (%s) 不在白名单内", ErrWithdrawalBlocked, req.Address, req.Blockchain)
+	}
+
+	symbol := strings.ToUpper(req.Symbol)
+	limit, ok := cfg.DailyLimits[symbol]
+	if !ok {
+		return fmt.Errorf("%w: %s 未配置每日提现上限", ErrWithdrawalBlocked, symbol)
+	}
+	used, err := t.withdrawnToday(ctx, symbol)
+	if err != nil {
+		// 无法确认当日已提现数量时不放行
+		return fmt.Errorf("%w: %v", ErrWithdrawalBlocked, err)
+	}
+	if used+req.Quantity > limit {
+		return fmt.Errorf("%w: %s 今日已提现 %.8g，本次 %.8g 将超过每日上限 %.8g", ErrWithdrawalBlocked, symbol, used, req.Quantity, limit)
+	}
+
+	summary := fmt.Sprintf("💸 Backpack 提现确认\n币种: %s\n数量: %s\n区块链: %s\n地址: %s\n今日已提现: %.8g / %.8g\n%s 内未确认将自动取消",
+		symbol, strconv.FormatFloat(req.Quantity, 'f', -1, 64), req.Blockchain, req.Address, used, limit, cfg.ConfirmTimeout)
+	confirmCtx, cancel := context.WithTimeout(ctx, cfg.ConfirmTimeout)
+	defer cancel()
+	log.Printf("⏳ [Backpack] 等待提现确认: %.8g %s → %s (%s)", req.Quantity, symbol, req.Address, req.Blockchain)
+	confirmed, err := cfg.Confirmer.Confirm(confirmCtx, summary)
+	if err != nil {
+		return fmt.Errorf("%w: 未获确认: %v", ErrWithdrawalBlocked, err)
+	}
+	if !confirmed {
+		return fmt.Errorf("%w: 确认被拒绝", ErrWithdrawalBlocked)
+	}
+	return nil
+}
+
+// withdrawnToday 以交易所提现记录为准统计当前UTC日已提现数量（重启后不会重置），已作废的提现不计入
+func (t *BackpackTrader) withdrawnToday(ctx context.Context, symbol string) (float64, error) {
+	dayStart := time.Now().UTC().Truncate(24 * time.Hour)
+	withdrawals, err := t.GetWithdrawalsWithContext(ctx, dayStart, time.Time{})
+	if err != nil {
+		return 0, fmt.Errorf("查询今日提现记录失败: %w", err)
+	}
+	var used float64
+	for _, w := range withdrawals {
+		if strings.EqualFold(w.Symbol, symbol) && w.Status != "void" {
+			used += w.Quantity.Float64()
+		}
+	}
+	return used, nil
+}
@@ -0,0 +1,104 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubConfirmer 记录确认消息并返回预设结果
+type stubConfirmer struct {
+	confirm   bool
+	err       error
+	summaries []string
+}
+
+func (s *stubConfirmer) Confirm(ctx context.Context, summary string) (bool, error) {
+	s.summaries = append(s.summaries, summary)
+	if _, ok := ctx.Deadline(); !ok {
+		return false, errors.New("确认应带超时")
+	}
+	return s.confirm, s.err
+}
+
+func TestBackpackTrader_WithdrawalGuard(t *testing.T) {
+	var submitted, historyQueries int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method + " " + r.URL.Path {
+		case "GET /wapi/v1/capital/withdrawals":
+			historyQueries++
+			// 今日已提现 300 USDC（作废的 500 不计入）和 1 SOL
+			w.Write([]byte(`[{"id":1,"symbol":"USDC","quantity":"300","status":"confirmed"},
+				{"id":2,"symbol":"USDC","quantity":"500","status":"void"},
+				{"id":3,"symbol":"SOL","quantity":"1","status":"pending"}]`))
+		case "POST /wapi/v1/capital/withdrawals":
+			submitted++
+			w.Write([]byte(`{"id":99,"symbol":"USDC","quantity":"100","status":"pending"}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+
+	confirmer := &stubConfirmer{confirm: true}
+	assert.Error(t, tr.SetWithdrawalGuard(WithdrawalGuardConfig{AllowedAddresses: map[string][]string{"Solana": {"Vault"}}}), "必须配置确认")
+	assert.Error(t, tr.SetWithdrawalGuard(WithdrawalGuardConfig{Confirmer: confirmer}), "必须配置白名单")
+	require.NoError(t, tr.SetWithdrawalGuard(WithdrawalGuardConfig{
+		AllowedAddresses: map[string][]string{"Solana": {"Vault"}, "Ethereum": {"0xAbC"}},
+		DailyLimits:      map[string]float64{"usdc": 500},
+		Confirmer:        confirmer,
+	}))
+
+	blocked := func(req WithdrawalRequest, msg string) {
+		t.Helper()
+		_, err := tr.RequestWithdrawal(req)
+		assert.ErrorIs(t, err, ErrWithdrawalBlocked, msg)
+	}
+	blocked(WithdrawalRequest{Address: "Attacker", Blockchain: "Solana", Symbol: "USDC", Quantity: 1}, "地址不在白名单")
+	blocked(WithdrawalRequest{Address: "Vault", Blockchain: "Ethereum", Symbol: "USDC", Quantity: 1}, "白名单按链区分")
+	blocked(WithdrawalRequest{Address: "Vault", Blockchain: "Solana", Symbol: "SOL", Quantity: 1}, "未配置上限的币种")
+	assert.Equal(t, 0, historyQueries, "白名单和币种检查不请求交易所")
+	blocked(WithdrawalRequest{Address: "Vault", Blockchain: "Solana", Symbol: "USDC", Quantity: 200.01}, "超过每日上限")
+	assert.Empty(t, confirmer.summaries, "超限时不发起确认")
+
+	withdrawal, err := tr.RequestWithdrawal(WithdrawalRequest{Address: "0xabc", Blockchain: "ethereum", Symbol: "USDC", Quantity: 200})
+	require.NoError(t, err)
+	assert.Equal(t, "pending", withdrawal.Status)
+	require.Len(t, confirmer.summaries, 1)
+	assert.Contains(t, confirmer.summaries[0], "今日已提现: 300 / 500")
+	assert.Contains(t, confirmer.summaries[0], "0xabc")
+
+	confirmer.confirm = false
+	blocked(WithdrawalRequest{Address: "Vault", Blockchain: "Solana", Symbol: "USDC", Quantity: 10}, "确认被拒绝")
+	confirmer.err = context.DeadlineExceeded
+	blocked(WithdrawalRequest{Address: "Vault", Blockchain: "Solana", Symbol: "USDC", Quantity: 10}, "确认超时")
+	assert.Equal(t, 1, submitted, "未通过保护的提现不签名发出")
+	assert.Equal(t, defaultWithdrawalConfirmTimeout, tr.withdrawalGuard.cfg.ConfirmTimeout)
+}
+
+func TestBackpackTrader_WithdrawalGuardHistoryUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+	tr := newTestBackpackTrader(t, server.URL)
+	confirmer := &stubConfirmer{confirm: true}
+	require.NoError(t, tr.SetWithdrawalGuard(WithdrawalGuardConfig{
+		AllowedAddresses: map[string][]string{"Solana": {"Vault"}},
+		DailyLimits:      map[string]float64{"USDC": 500},
+		Confirmer:        confirmer,
+		ConfirmTimeout:   time.Minute,
+	}))
+
+	_, err := tr.RequestWithdrawal(WithdrawalRequest{Address: "Vault", Blockchain: "Solana", Symbol: "USDC", Quantity: 1})
+	assert.ErrorIs(t, err, ErrWithdrawalBlocked, "无法确认当日用量时不放行")
+	assert.Empty(t, confirmer.summaries)
+}
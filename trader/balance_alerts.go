@@ -0,0 +1,120 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// defaultBalanceAlertCooldown 同类告警的默认最小间隔
+const defaultBalanceAlertCooldown = 30 * time.Minute
+
+// BalanceAlertConfig 余额与保证金告警配置（各项为0表示不检查）
+type BalanceAlertConfig struct {
+	MinAvailableBalance float64       // 可用余额低于该值时告警（USDT）
+	MaxMarginUsagePct   float64       // 保证金使用率超过该值时告警（百分比）
+	MaxPnLSwingPct      float64       // 当日未实现盈亏最高与最低之差占权益的比例超过该值时告警（百分比）
+	Cooldown            time.Duration // 同类告警的最小间隔（默认30分钟）
+}
+
+// Enabled 是否配置了任一告警
+func (c BalanceAlertConfig) Enabled() bool {
+	return c.MinAvailableBalance > 0 || c.MaxMarginUsagePct > 0 || c.MaxPnLSwingPct > 0
+}
+
+// BalanceSnapshot 用于告警检查的账户快照
+type BalanceSnapshot struct {
+	Time             time.Time
+	Equity           float64 // 总权益（钱包余额 + 未实现盈亏）
+	AvailableBalance float64
+	UnrealizedPnL    float64
+	MarginUsedPct    float64
+}
+
+// BalanceAlertKind 告警类型
+type BalanceAlertKind string
+
+const (
+	BalanceAlertLowBalance  BalanceAlertKind = "low_balance"
+	BalanceAlertMarginUsage BalanceAlertKind = "margin_usage"
+	BalanceAlertPnLSwing    BalanceAlertKind = "pnl_swing"
+)
+
+// BalanceAlert 一条触发的告警
+type BalanceAlert struct {
+	Kind    BalanceAlertKind
+	Message string
+}
+
+// BalanceAlerter 余额阈值、保证金使用率与日内盈亏波动告警
+// 告警通过 logger.Alertf 发出（配置Telegram时会推送）
+type BalanceAlerter struct {
+	cfg   BalanceAlertConfig
+	alert func(format string, args ...interface{})
+
+	mu        sync.Mutex
+	lastAlert map[BalanceAlertKind]time.Time
+	day       string  // 当前统计的UTC日期
+	pnlHigh   float64 // 当日未实现盈亏最高值
+	pnlLow    float64 // 当日未实现盈亏最低值
+}
+
+// NewBalanceAlerter 创建告警器
+func NewBalanceAlerter(cfg BalanceAlertConfig) *BalanceAlerter {
+	if cfg.Cooldown <= 0 {
+		cfg.Cooldown = defaultBalanceAlertCooldown
+	}
+	return &BalanceAlerter{
+		cfg:       cfg,
+		alert:     logger.Alertf,
+		lastAlert: make(map[BalanceAlertKind]time.Time),
+	}
+}
+
+// Observe 检查一次账户快照，返回本次发出的告警（冷却期内的重复告警不返回）
+func (a *BalanceAlerter) Observe(s BalanceSnapshot) []BalanceAlert {
+	if s.Time.IsZero() {
+		s.Time = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// 跨日重置盈亏波动统计
+	if day := s.Time.UTC().Format("2006-01-02"); day != a.day {
+		a.day = day
+		a.pnlHigh, a.pnlLow = s.UnrealizedPnL, s.UnrealizedPnL
+	}
+	a.pnlHigh = math.Max(a.pnlHigh, s.UnrealizedPnL)
+	a.pnlLow = math.Min(a.pnlLow, s.UnrealizedPnL)
+
+	var alerts []BalanceAlert
+	if a.cfg.MinAvailableBalance > 0 && s.AvailableBalance < a.cfg.MinAvailableBalance {
+		alerts = a.fire(alerts, s.Time, BalanceAlertLowBalance,
+			fmt.Sprintf("可用余额 %.2f USDT 低于下限 %.2f USDT", s.AvailableBalance, a.cfg.MinAvailableBalance))
+	}
+	if a.cfg.MaxMarginUsagePct > 0 && s.MarginUsedPct > a.cfg.MaxMarginUsagePct {
+		alerts = a.fire(alerts, s.Time, BalanceAlertMarginUsage,
+			fmt.Sprintf("保证金使用率 %.1f%% 超过上限 %.1f%%", s.MarginUsedPct, a.cfg.MaxMarginUsagePct))
+	}
+	if a.cfg.MaxPnLSwingPct > 0 && s.Equity > 0 {
+		if swing := (a.pnlHigh - a.pnlLow) / s.Equity * 100; swing > a.cfg.MaxPnLSwingPct {
+			alerts = a.fire(alerts, s.Time, BalanceAlertPnLSwing,
+				fmt.Sprintf("日内未实现盈亏波动 %.1f%%（%.2f ~ %.2f USDT）超过 %.1f%%", swing, a.pnlLow, a.pnlHigh, a.cfg.MaxPnLSwingPct))
+		}
+	}
+	return alerts
+}
+
+// fire 冷却期外发出告警并记录
+func (a *BalanceAlerter) fire(alerts []BalanceAlert, now time.Time, kind BalanceAlertKind, message string) []BalanceAlert {
+	if last, ok := a.lastAlert[kind]; ok && now.Sub(last) < a.cfg.Cooldown {
+		return alerts
+	}
+	a.lastAlert[kind] = now
+	a.alert("账户告警 [%s]: %s", kind, message)
+	return append(alerts, BalanceAlert{Kind: kind, Message: message})
+}
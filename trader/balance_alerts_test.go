@@ -0,0 +1,42 @@
+package trader
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBalanceAlerter_ThresholdsAndCooldown(t *testing.T) {
+	a := NewBalanceAlerter(BalanceAlertConfig{
+		MinAvailableBalance: 100,
+		MaxMarginUsagePct:   80,
+		MaxPnLSwingPct:      5,
+		Cooldown:            time.Hour,
+	})
+	var sent []string
+	a.alert = func(format string, args ...interface{}) { sent = append(sent, fmt.Sprintf(format, args...)) }
+
+	start := time.Date(2025, 3, 1, 1, 0, 0, 0, time.UTC)
+	alerts := a.Observe(BalanceSnapshot{Time: start, Equity: 1000, AvailableBalance: 500, UnrealizedPnL: 20, MarginUsedPct: 50})
+	assert.Empty(t, alerts)
+
+	// 可用余额不足 + 保证金使用率过高 + 盈亏从 +20 摆到 -40（6%）
+	alerts = a.Observe(BalanceSnapshot{Time: start.Add(time.Minute), Equity: 1000, AvailableBalance: 50, UnrealizedPnL: -40, MarginUsedPct: 90})
+	require.Len(t, alerts, 3)
+	assert.Equal(t, BalanceAlertLowBalance, alerts[0].Kind)
+	assert.Equal(t, BalanceAlertMarginUsage, alerts[1].Kind)
+	assert.Equal(t, BalanceAlertPnLSwing, alerts[2].Kind)
+	assert.Len(t, sent, 3)
+
+	// 冷却期内不重复告警
+	alerts = a.Observe(BalanceSnapshot{Time: start.Add(30 * time.Minute), Equity: 1000, AvailableBalance: 40, UnrealizedPnL: -40, MarginUsedPct: 95})
+	assert.Empty(t, alerts)
+
+	// 冷却期后再次告警；跨日后盈亏波动重新统计
+	alerts = a.Observe(BalanceSnapshot{Time: start.Add(24 * time.Hour), Equity: 1000, AvailableBalance: 40, UnrealizedPnL: -40, MarginUsedPct: 50})
+	require.Len(t, alerts, 1)
+	assert.Equal(t, BalanceAlertLowBalance, alerts[0].Kind)
+}
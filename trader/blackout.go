@@ -0,0 +1,146 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"nofx/decision"
+)
+
+// defaultFundingIntervalHours 默认资金费结算间隔
+const defaultFundingIntervalHours = 8
+
+// BlackoutWindow 固定的禁止开仓时间窗口（如 CPI/FOMC 等重要数据发布前后）
+type BlackoutWindow struct {
+	Name  string    `json:"name"`
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// BlackoutConfig 交易日历：屏蔽期内不开新仓，可选放宽已有持仓的止损
+type BlackoutConfig struct {
+	Windows              []BlackoutWindow // 固定时间窗口
+	FundingBuffer        time.Duration    // 资金费结算时刻前后各屏蔽多久（0 表示不屏蔽结算时刻）
+	FundingIntervalHours int              // 资金费结算间隔（小时，默认8，即 UTC 0/8/16 点）
+	WidenStopPct         float64          // 屏蔽期内止损距离放大比例（0.5 = 距离放大50%，0 表示不调整），结束后恢复
+}
+
+// Active 返回 now 所处的屏蔽窗口名称
+func (c BlackoutConfig) Active(now time.Time) (string, bool) {
+	for _, w := range c.Windows {
+		if !now.Before(w.Start) && now.Before(w.End) {
+			return w.Name, true
+		}
+	}
+
+	if c.FundingBuffer > 0 {
+		interval := time.Duration(c.FundingIntervalHours) * time.Hour
+		if interval <= 0 {
+			interval = defaultFundingIntervalHours * time.Hour
+		}
+		// 距离最近一个结算时刻（UTC 整点对齐）的时间
+		sinceLast := now.UTC().Sub(now.UTC().Truncate(interval))
+		if sinceLast < c.FundingBuffer || interval-sinceLast <= c.FundingBuffer {
+			return "资金费结算", true
+		}
+	}
+	return "", false
+}
+
+// checkBlackout 屏蔽期内禁止开仓
+func (at *AutoTrader) checkBlackout(now time.Time) error {
+	if name, active := at.config.Blackout.Active(now); active {
+		return fmt.Errorf("❌ 处于禁止开仓时段（%s），暂停开仓", name)
+	}
+	return nil
+}
+
+// widenedStop 以当前价格为基准把止损距离放大 pct
+func widenedStop(side string, markPrice, stopLoss, pct float64) float64 {
+	distance := math.Abs(markPrice-stopLoss) * (1 + pct)
+	if side == "long" {
+		return math.Max(markPrice-distance, 0)
+	}
+	return markPrice + distance
+}
+
+// applyBlackoutStops 进入屏蔽期时放宽持仓止损，离开后恢复原止损
+func (at *AutoTrader) applyBlackoutStops(positions []decision.PositionInfo, now time.Time) {
+	cfg := at.config.Blackout
+	if cfg.WidenStopPct <= 0 {
+		return
+	}
+	if at.blackoutStops == nil {
+		at.blackoutStops = make(map[string]float64)
+	}
+	name, active := cfg.Active(now)
+
+	for _, pos := range positions {
+		posKey := pos.Symbol + "_" + pos.Side
+		original, widened := at.blackoutStops[posKey]
+
+		switch {
+		case active && !widened:
+			stop := at.positionStopLoss[posKey]
+			if stop <= 0 || pos.MarkPrice <= 0 {
+				continue
+			}
+			newStop := widenedStop(pos.Side, pos.MarkPrice, stop, cfg.WidenStopPct)
+			if err := at.replaceStopLoss(pos, newStop); err != nil {
+				log.Printf("  ⚠ %s %s 屏蔽期放宽止损失败: %v", pos.Symbol, pos.Side, err)
+				continue
+			}
+			at.blackoutStops[posKey] = stop
+			log.Printf("  🛡️ %s %s 进入屏蔽期（%s），止损 %.4f → %.4f", pos.Symbol, pos.Side, name, stop, newStop)
+
+		case !active && widened:
+			// 价格已越过原止损时保留放宽后的止损，交由AI处理
+			if (pos.Side == "long" && pos.MarkPrice <= original) || (pos.Side == "short" && pos.MarkPrice >= original) {
+				log.Printf("  ⚠ %s %s 价格已越过原止损 %.4f，保留放宽后的止损", pos.Symbol, pos.Side, original)
+			} else if err := at.replaceStopLoss(pos, original); err != nil {
+				log.Printf("  ⚠ %s %s 恢复止损失败: %v", pos.Symbol, pos.Side, err)
+				continue
+			} else {
+				log.Printf("  ✓ %s %s 屏蔽期结束，止损恢复为 %.4f", pos.Symbol, pos.Side, original)
+			}
+			delete(at.blackoutStops, posKey)
+		}
+	}
+
+	// 已平仓的持仓不再需要恢复
+	open := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		open[pos.Symbol+"_"+pos.Side] = true
+	}
+	for posKey := range at.blackoutStops {
+		if !open[posKey] {
+			delete(at.blackoutStops, posKey)
+		}
+	}
+}
+
+// replaceStopLoss 撤销并重挂止损（部分交易所撤止损会一并撤掉止盈，已知止盈时一起重挂）
+func (at *AutoTrader) replaceStopLoss(pos decision.PositionInfo, stopLoss float64) error {
+	posKey := pos.Symbol + "_" + pos.Side
+	positionSide := strings.ToUpper(pos.Side)
+
+	if err := at.trader.CancelStopLossOrders(pos.Symbol); err != nil {
+		log.Printf("  ⚠ 取消旧止损单失败: %v", err)
+	}
+	if err := at.trader.SetStopLoss(pos.Symbol, positionSide, pos.Quantity, stopLoss); err != nil {
+		return fmt.Errorf("设置止损失败: %w", err)
+	}
+	at.positionStopLoss[posKey] = stopLoss
+
+	if _, ok := at.trader.(*BackpackTrader); ok {
+		if tp := at.positionTakeProfit[posKey]; tp > 0 {
+			if err := at.trader.SetTakeProfit(pos.Symbol, positionSide, pos.Quantity, tp); err != nil {
+				log.Printf("  ⚠ 重挂止盈失败: %v", err)
+			}
+		}
+	}
+	return nil
+}
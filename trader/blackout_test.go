@@ -0,0 +1,93 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"nofx/decision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stopRecordingTrader 记录最近一次止损价格的 MockTrader
+type stopRecordingTrader struct {
+	MockTrader
+	stops []float64
+}
+
+func (s *stopRecordingTrader) SetStopLoss(symbol, positionSide string, quantity, stopPrice float64) error {
+	s.stops = append(s.stops, stopPrice)
+	return nil
+}
+
+func TestBlackoutConfig_Active(t *testing.T) {
+	cpi := time.Date(2026, 10, 15, 12, 30, 0, 0, time.UTC)
+	cfg := BlackoutConfig{
+		Windows:       []BlackoutWindow{{Name: "CPI", Start: cpi.Add(-15 * time.Minute), End: cpi.Add(30 * time.Minute)}},
+		FundingBuffer: 10 * time.Minute,
+	}
+
+	name, active := cfg.Active(cpi)
+	assert.True(t, active)
+	assert.Equal(t, "CPI", name)
+	_, active = cfg.Active(cpi.Add(30 * time.Minute))
+	assert.False(t, active, "窗口结束时刻不再屏蔽")
+
+	// 资金费结算（UTC 0/8/16 点）前后10分钟
+	_, active = cfg.Active(time.Date(2026, 10, 15, 7, 55, 0, 0, time.UTC))
+	assert.True(t, active)
+	_, active = cfg.Active(time.Date(2026, 10, 15, 16, 5, 0, 0, time.UTC))
+	assert.True(t, active)
+	_, active = cfg.Active(time.Date(2026, 10, 15, 9, 0, 0, 0, time.UTC))
+	assert.False(t, active)
+
+	_, active = BlackoutConfig{}.Active(cpi)
+	assert.False(t, active, "零值不屏蔽")
+}
+
+func TestAutoTrader_BlackoutBlocksEntries(t *testing.T) {
+	now := time.Now()
+	at := &AutoTrader{config: AutoTraderConfig{Blackout: BlackoutConfig{
+		Windows: []BlackoutWindow{{Name: "FOMC", Start: now.Add(-time.Minute), End: now.Add(time.Hour)}},
+	}}}
+	err := at.checkEntriesAllowed()
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "FOMC")
+
+	at.config.Blackout.Windows[0].End = now.Add(-time.Second)
+	assert.NoError(t, at.checkEntriesAllowed())
+}
+
+func TestAutoTrader_BlackoutWidensAndRestoresStops(t *testing.T) {
+	start := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	tr := &stopRecordingTrader{}
+	at := &AutoTrader{
+		trader: tr,
+		config: AutoTraderConfig{Blackout: BlackoutConfig{
+			Windows:      []BlackoutWindow{{Name: "CPI", Start: start, End: start.Add(time.Hour)}},
+			WidenStopPct: 0.5,
+		}},
+		positionStopLoss:   map[string]float64{"BTCUSDT_long": 95, "ETHUSDT_short": 110},
+		positionTakeProfit: map[string]float64{},
+	}
+	positions := []decision.PositionInfo{
+		{Symbol: "BTCUSDT", Side: "long", MarkPrice: 100, Quantity: 1},
+		{Symbol: "ETHUSDT", Side: "short", MarkPrice: 100, Quantity: 2},
+	}
+
+	// 进入屏蔽期：止损距离放大50%
+	at.applyBlackoutStops(positions, start.Add(time.Minute))
+	assert.Equal(t, []float64{92.5, 115}, tr.stops)
+	assert.Equal(t, 92.5, at.positionStopLoss["BTCUSDT_long"])
+
+	// 屏蔽期内不重复放宽
+	at.applyBlackoutStops(positions, start.Add(2*time.Minute))
+	assert.Len(t, tr.stops, 2)
+
+	// 屏蔽期结束：恢复原止损；ETH 已平仓不再处理
+	at.applyBlackoutStops(positions[:1], start.Add(2*time.Hour))
+	assert.Equal(t, []float64{92.5, 115, 95}, tr.stops)
+	assert.Equal(t, 95.0, at.positionStopLoss["BTCUSDT_long"])
+	assert.Empty(t, at.blackoutStops)
+}
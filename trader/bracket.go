@@ -0,0 +1,415 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ProtectedOpener 支持开仓时原生附带止盈止损（OCO）的交易器
+type ProtectedOpener interface {
+	OpenLongWithProtection(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error
+	OpenShortWithProtection(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error
+}
+
+// BracketState 组合单状态
+type BracketState string
+
+const (
+	BracketActive    BracketState = "active"    // 持仓中，止盈止损有效
+	BracketClosed    BracketState = "closed"    // 持仓已平（止损/止盈触发或主动平仓）
+	BracketCancelled BracketState = "cancelled" // 止盈止损已撤销，持仓交由调用方管理
+)
+
+// BracketTarget 单个止盈目标
+type BracketTarget struct {
+	Price    float64 `json:"price"`
+	Quantity float64 `json:"quantity"`
+}
+
+// BracketRequest 组合单请求：入场 + 止损 + 一个或多个止盈
+type BracketRequest struct {
+	Symbol      string
+	Side        string // long/short
+	Quantity    float64
+	Leverage    int
+	StopLoss    float64
+	TakeProfits []BracketTarget // 数量为0的单个目标表示全部数量
+}
+
+// BracketOrder 作为整体管理的组合单
+type BracketOrder struct {
+	ID          string          `json:"id"`
+	Symbol      string          `json:"symbol"`
+	Side        string          `json:"side"`
+	Quantity    float64         `json:"quantity"`  // 入场数量
+	Remaining   float64         `json:"remaining"` // 剩余持仓数量
+	Leverage    int             `json:"leverage"`
+	StopLoss    float64         `json:"stop_loss"`
+	TakeProfits []BracketTarget `json:"take_profits"` // 尚未成交的止盈目标（按距离由近到远）
+	Native      bool            `json:"native"`       // 是否使用交易所原生OCO
+	State       BracketState    `json:"state"`
+	CreatedAt   time.Time       `json:"created_at"`
+}
+
+// BracketManager 组合单管理器
+// 交易所支持原生OCO时使用原生单（单个止盈目标），否则通过 Trader 接口分别挂止损/止盈并由 Sync 模拟OCO：
+// 持仓消失时撤销剩余挂单，部分止盈成交后按剩余数量重挂止损。
+// 止盈止损挂单按交易对撤销，因此同一交易对同时只允许一个活动组合单。
+type BracketManager struct {
+	trader   Trader
+	brackets map[string]*BracketOrder
+	nextID   int
+	mu       sync.Mutex
+}
+
+// NewBracketManager 创建组合单管理器
+func NewBracketManager(t Trader) *BracketManager {
+	return &BracketManager{trader: t, brackets: make(map[string]*BracketOrder)}
+}
+
+// validate 校验请求并补全止盈数量
+func (r *BracketRequest) validate() error {
+	if r.Symbol == "" {
+		return fmt.Errorf("交易对不能为空")
+	}
+	if r.Side != "long" && r.Side != "short" {
+		return fmt.Errorf("无效的方向: %q（应为 long 或 short）", r.Side)
+	}
+	if r.Quantity <= 0 {
+		return fmt.Errorf("数量必须大于0: %.8f", r.Quantity)
+	}
+	if len(r.TakeProfits) == 1 && r.TakeProfits[0].Quantity == 0 {
+		r.TakeProfits[0].Quantity = r.Quantity
+	}
+	return validateBracketLegs(r.Side, r.Quantity, r.StopLoss, r.TakeProfits)
+}
+
+// validateBracketLegs 校验止损和止盈目标：做多止损须低于所有止盈，做空相反
+func validateBracketLegs(side string, quantity, stopLoss float64, targets []BracketTarget) error {
+	if stopLoss <= 0 {
+		return fmt.Errorf("必须设置止损价格")
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("至少需要一个止盈目标")
+	}
+	var total float64
+	for _, tp := range targets {
+		if tp.Price <= 0 || tp.Quantity <= 0 {
+			return fmt.Errorf("止盈目标价格和数量必须大于0")
+		}
+		if side == "long" && tp.Price <= stopLoss {
+			return fmt.Errorf("做多时止盈价(%.4f)必须高于止损价(%.4f)", tp.Price, stopLoss)
+		}
+		if side == "short" && tp.Price >= stopLoss {
+			return fmt.Errorf("做空时止盈价(%.4f)必须低于止损价(%.4f)", tp.Price, stopLoss)
+		}
+		total += tp.Quantity
+	}
+	if total > quantity*(1+1e-9) {
+		return fmt.Errorf("止盈目标总数量(%.8f)超过持仓数量(%.8f)", total, quantity)
+	}
+	return nil
+}
+
+// sortTargets 按距离由近到远排序止盈目标
+func sortTargets(side string, targets []BracketTarget) []BracketTarget {
+	sorted := append([]BracketTarget(nil), targets...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if side == "long" {
+			return sorted[i].Price < sorted[j].Price
+		}
+		return sorted[i].Price > sorted[j].Price
+	})
+	return sorted
+}
+
+// Open 开仓并挂止损/止盈
+// 非原生模式下挂保护单失败会立即平仓，避免留下无保护的持仓
+func (m *BracketManager) Open(req BracketRequest) (*BracketOrder, error) {
+	if err := req.validate(); err != nil {
+		return nil, fmt.Errorf("组合单参数无效: %w", err)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, b := range m.brackets {
+		if b.Symbol == req.Symbol && b.State == BracketActive {
+			return nil, fmt.Errorf("%s 已有活动组合单 %s", req.Symbol, b.ID)
+		}
+	}
+
+	m.nextID++
+	b := &BracketOrder{
+		ID:          fmt.Sprintf("bracket-%d", m.nextID),
+		Symbol:      req.Symbol,
+		Side:        req.Side,
+		Quantity:    req.Quantity,
+		Remaining:   req.Quantity,
+		Leverage:    req.Leverage,
+		StopLoss:    req.StopLoss,
+		TakeProfits: sortTargets(req.Side, req.TakeProfits),
+		State:       BracketActive,
+		CreatedAt:   time.Now(),
+	}
+
+	native, ok := m.trader.(ProtectedOpener)
+	if ok && len(b.TakeProfits) == 1 && b.TakeProfits[0].Quantity == b.Quantity {
+		var err error
+		if b.Side == "long" {
+			err = native.OpenLongWithProtection(b.Symbol, b.Quantity, b.Leverage, b.StopLoss, b.TakeProfits[0].Price)
+		} else {
+			err = native.OpenShortWithProtection(b.Symbol, b.Quantity, b.Leverage, b.StopLoss, b.TakeProfits[0].Price)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("组合单开仓失败: %w", err)
+		}
+		b.Native = true
+	} else {
+		var err error
+		if b.Side == "long" {
+			_, err = m.trader.OpenLong(b.Symbol, b.Quantity, b.Leverage)
+		} else {
+			_, err = m.trader.OpenShort(b.Symbol, b.Quantity, b.Leverage)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("组合单开仓失败: %w", err)
+		}
+		if err := m.placeLegsLocked(b); err != nil {
+			log.Printf("⚠️ [Bracket] %s 挂保护单失败，立即平仓: %v", b.ID, err)
+			if closeErr := m.closePositionLocked(b); closeErr != nil {
+				log.Printf("❌ [Bracket] %s 平仓失败，持仓无保护: %v", b.ID, closeErr)
+			}
+			return nil, fmt.Errorf("组合单挂止盈止损失败: %w", err)
+		}
+	}
+
+	m.brackets[b.ID] = b
+	log.Printf("📦 [Bracket] %s 已开仓: %s %s 数量=%.4f SL=%.4f TP=%d个 (原生OCO=%v)",
+		b.ID, b.Symbol, b.Side, b.Quantity, b.StopLoss, len(b.TakeProfits), b.Native)
+	return b.snapshot(), nil
+}
+
+// Get 获取组合单（返回副本）
+func (m *BracketManager) Get(id string) (*BracketOrder, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.brackets[id]
+	if !ok {
+		return nil, fmt.Errorf("组合单不存在: %s", id)
+	}
+	return b.snapshot(), nil
+}
+
+// Active 返回所有活动组合单（按ID排序）
+func (m *BracketManager) Active() []*BracketOrder {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var out []*BracketOrder
+	for _, b := range m.brackets {
+		if b.State == BracketActive {
+			out = append(out, b.snapshot())
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// ModifyStopLoss 修改止损价（撤销全部保护单后按新止损和原止盈目标重挂）
+func (m *BracketManager) ModifyStopLoss(id string, stopLoss float64) error {
+	return m.modify(id, func(b *BracketOrder) (float64, []BracketTarget) {
+		return stopLoss, b.TakeProfits
+	})
+}
+
+// ModifyTakeProfits 替换剩余的止盈目标（撤销全部保护单后按原止损和新止盈目标重挂）
+func (m *BracketManager) ModifyTakeProfits(id string, targets []BracketTarget) error {
+	return m.modify(id, func(b *BracketOrder) (float64, []BracketTarget) {
+		targets = append([]BracketTarget(nil), targets...)
+		if len(targets) == 1 && targets[0].Quantity == 0 {
+			targets[0].Quantity = b.Remaining
+		}
+		return b.StopLoss, targets
+	})
+}
+
+func (m *BracketManager) modify(id string, change func(b *BracketOrder) (float64, []BracketTarget)) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.activeLocked(id)
+	if err != nil {
+		return err
+	}
+
+	stopLoss, targets := change(b)
+	if err := validateBracketLegs(b.Side, b.Remaining, stopLoss, targets); err != nil {
+		return fmt.Errorf("组合单参数无效: %w", err)
+	}
+	if err := m.trader.CancelStopOrders(b.Symbol); err != nil {
+		return fmt.Errorf("撤销原止盈止损失败: %w", err)
+	}
+
+	b.StopLoss = stopLoss
+	b.TakeProfits = sortTargets(b.Side, targets)
+	b.Native = false // 修改后由本管理器模拟OCO
+	if err := m.placeLegsLocked(b); err != nil {
+		return fmt.Errorf("重挂止盈止损失败: %w", err)
+	}
+	log.Printf("✏️ [Bracket] %s 已修改: SL=%.4f TP=%d个", b.ID, b.StopLoss, len(b.TakeProfits))
+	return nil
+}
+
+// Cancel 撤销组合单的止盈止损（不平仓）
+func (m *BracketManager) Cancel(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.activeLocked(id)
+	if err != nil {
+		return err
+	}
+	if err := m.trader.CancelStopOrders(b.Symbol); err != nil {
+		return fmt.Errorf("撤销止盈止损失败: %w", err)
+	}
+	b.State = BracketCancelled
+	log.Printf("🗑️ [Bracket] %s 已撤销止盈止损，持仓保留", b.ID)
+	return nil
+}
+
+// Close 撤销止盈止损并平掉剩余持仓
+func (m *BracketManager) Close(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, err := m.activeLocked(id)
+	if err != nil {
+		return err
+	}
+	if err := m.trader.CancelStopOrders(b.Symbol); err != nil {
+		return fmt.Errorf("撤销止盈止损失败: %w", err)
+	}
+	if err := m.closePositionLocked(b); err != nil {
+		return fmt.Errorf("平仓失败: %w", err)
+	}
+	b.Remaining = 0
+	b.State = BracketClosed
+	log.Printf("✓ [Bracket] %s 已平仓", b.ID)
+	return nil
+}
+
+// Sync 根据交易所持仓同步组合单状态，返回状态或数量发生变化的组合单
+//   - 持仓消失：止损或最后一个止盈已成交，撤销剩余挂单（模拟OCO）
+//   - 持仓减少：部分止盈已成交，移除已成交目标并按剩余数量重挂止损
+func (m *BracketManager) Sync() ([]*BracketOrder, error) {
+	positions, err := m.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	held := make(map[string]float64, len(positions))
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		amt, _ := pos["positionAmt"].(float64)
+		if amt < 0 {
+			amt = -amt
+		}
+		held[symbol+"_"+side] += amt
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var changed []*BracketOrder
+	for _, b := range m.brackets {
+		if b.State != BracketActive {
+			continue
+		}
+		qty := held[b.Symbol+"_"+b.Side]
+		const epsilon = 1e-9
+		switch {
+		case qty <= epsilon:
+			if err := m.trader.CancelStopOrders(b.Symbol); err != nil {
+				log.Printf("⚠️ [Bracket] %s 撤销剩余挂单失败: %v", b.ID, err)
+				continue
+			}
+			b.Remaining = 0
+			b.State = BracketClosed
+			log.Printf("✓ [Bracket] %s 持仓已平，剩余挂单已撤销", b.ID)
+			changed = append(changed, b.snapshot())
+		case qty < b.Remaining-epsilon:
+			b.consumeTargets(b.Remaining - qty)
+			b.Remaining = qty
+			if err := m.trader.CancelStopOrders(b.Symbol); err != nil {
+				log.Printf("⚠️ [Bracket] %s 撤销原保护单失败: %v", b.ID, err)
+			} else if err := m.placeLegsLocked(b); err != nil {
+				log.Printf("❌ [Bracket] %s 按剩余数量重挂保护单失败: %v", b.ID, err)
+			}
+			b.Native = false
+			log.Printf("🎯 [Bracket] %s 部分止盈成交，剩余数量 %.4f", b.ID, b.Remaining)
+			changed = append(changed, b.snapshot())
+		}
+	}
+	sort.Slice(changed, func(i, j int) bool { return changed[i].ID < changed[j].ID })
+	return changed, nil
+}
+
+// consumeTargets 按距离由近到远扣除已成交的止盈数量
+func (b *BracketOrder) consumeTargets(filled float64) {
+	kept := b.TakeProfits[:0]
+	for _, tp := range b.TakeProfits {
+		switch {
+		case filled <= 0:
+			kept = append(kept, tp)
+		case filled >= tp.Quantity:
+			filled -= tp.Quantity
+		default:
+			tp.Quantity -= filled
+			filled = 0
+			kept = append(kept, tp)
+		}
+	}
+	b.TakeProfits = kept
+}
+
+// placeLegsLocked 按剩余数量挂止损和全部止盈目标
+func (m *BracketManager) placeLegsLocked(b *BracketOrder) error {
+	positionSide := "LONG"
+	if b.Side == "short" {
+		positionSide = "SHORT"
+	}
+	if err := m.trader.SetStopLoss(b.Symbol, positionSide, b.Remaining, b.StopLoss); err != nil {
+		return fmt.Errorf("设置止损失败: %w", err)
+	}
+	for _, tp := range b.TakeProfits {
+		if err := m.trader.SetTakeProfit(b.Symbol, positionSide, tp.Quantity, tp.Price); err != nil {
+			return fmt.Errorf("设置止盈(%.4f)失败: %w", tp.Price, err)
+		}
+	}
+	return nil
+}
+
+func (m *BracketManager) closePositionLocked(b *BracketOrder) error {
+	var err error
+	if b.Side == "long" {
+		_, err = m.trader.CloseLong(b.Symbol, b.Remaining)
+	} else {
+		_, err = m.trader.CloseShort(b.Symbol, b.Remaining)
+	}
+	return err
+}
+
+func (m *BracketManager) activeLocked(id string) (*BracketOrder, error) {
+	b, ok := m.brackets[id]
+	if !ok {
+		return nil, fmt.Errorf("组合单不存在: %s", id)
+	}
+	if b.State != BracketActive {
+		return nil, fmt.Errorf("组合单 %s 已结束（%s）", id, b.State)
+	}
+	return b, nil
+}
+
+func (b *BracketOrder) snapshot() *BracketOrder {
+	c := *b
+	c.TakeProfits = append([]BracketTarget(nil), b.TakeProfits...)
+	return &c
+}
@@ -0,0 +1,181 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// bracketLeg 记录的止损/止盈挂单
+type bracketLeg struct {
+	Kind     string
+	Quantity float64
+	Price    float64
+}
+
+// bracketTrader 记录组合单相关操作的 MockTrader
+type bracketTrader struct {
+	MockTrader
+	legs          []bracketLeg
+	cancels       int
+	closed        []float64
+	takeProfitErr error
+}
+
+func (b *bracketTrader) SetStopLoss(symbol, positionSide string, quantity, stopPrice float64) error {
+	b.legs = append(b.legs, bracketLeg{"sl", quantity, stopPrice})
+	return nil
+}
+
+func (b *bracketTrader) SetTakeProfit(symbol, positionSide string, quantity, takeProfitPrice float64) error {
+	if b.takeProfitErr != nil {
+		return b.takeProfitErr
+	}
+	b.legs = append(b.legs, bracketLeg{"tp", quantity, takeProfitPrice})
+	return nil
+}
+
+func (b *bracketTrader) CancelStopOrders(symbol string) error {
+	b.cancels++
+	b.legs = nil
+	return nil
+}
+
+func (b *bracketTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	b.closed = append(b.closed, quantity)
+	return b.MockTrader.CloseLong(symbol, quantity)
+}
+
+// nativeBracketTrader 支持原生OCO开仓的交易器
+type nativeBracketTrader struct {
+	bracketTrader
+	protected int
+}
+
+func (n *nativeBracketTrader) OpenLongWithProtection(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error {
+	n.protected++
+	return nil
+}
+
+func (n *nativeBracketTrader) OpenShortWithProtection(symbol string, quantity float64, leverage int, stopLoss, takeProfit float64) error {
+	n.protected++
+	return nil
+}
+
+func TestBracketRequest_Validate(t *testing.T) {
+	m := NewBracketManager(&bracketTrader{})
+
+	_, err := m.Open(BracketRequest{Symbol: "BTCUSDT", Side: "long", Quantity: 1, TakeProfits: []BracketTarget{{Price: 110}}})
+	assert.Error(t, err, "缺少止损")
+	_, err = m.Open(BracketRequest{Symbol: "BTCUSDT", Side: "long", Quantity: 1, StopLoss: 90, TakeProfits: []BracketTarget{{Price: 80}}})
+	assert.Error(t, err, "做多止盈低于止损")
+	_, err = m.Open(BracketRequest{Symbol: "BTCUSDT", Side: "short", Quantity: 1, StopLoss: 90, TakeProfits: []BracketTarget{{Price: 95}}})
+	assert.Error(t, err, "做空止盈高于止损")
+	_, err = m.Open(BracketRequest{Symbol: "BTCUSDT", Side: "long", Quantity: 1, StopLoss: 90,
+		TakeProfits: []BracketTarget{{Price: 110, Quantity: 0.6}, {Price: 120, Quantity: 0.6}}})
+	assert.Error(t, err, "止盈总数量超过持仓")
+}
+
+func TestBracketManager_EmulatedOCO(t *testing.T) {
+	tr := &bracketTrader{}
+	m := NewBracketManager(tr)
+
+	b, err := m.Open(BracketRequest{
+		Symbol: "BTCUSDT", Side: "long", Quantity: 1, Leverage: 5, StopLoss: 90,
+		TakeProfits: []BracketTarget{{Price: 120, Quantity: 0.5}, {Price: 110, Quantity: 0.5}},
+	})
+	require.NoError(t, err)
+	assert.False(t, b.Native)
+	// 止盈目标按距离排序，止损覆盖全部数量
+	assert.Equal(t, []bracketLeg{{"sl", 1, 90}, {"tp", 0.5, 110}, {"tp", 0.5, 120}}, tr.legs)
+
+	// 同一交易对不允许第二个活动组合单
+	_, err = m.Open(BracketRequest{Symbol: "BTCUSDT", Side: "short", Quantity: 1, StopLoss: 110, TakeProfits: []BracketTarget{{Price: 90}}})
+	assert.Error(t, err)
+
+	// 第一个止盈成交：按剩余数量重挂止损和剩余止盈
+	tr.positions = []map[string]interface{}{{"symbol": "BTCUSDT", "side": "long", "positionAmt": 0.5}}
+	changed, err := m.Sync()
+	require.NoError(t, err)
+	require.Len(t, changed, 1)
+	assert.Equal(t, 0.5, changed[0].Remaining)
+	assert.Equal(t, []BracketTarget{{Price: 120, Quantity: 0.5}}, changed[0].TakeProfits)
+	assert.Equal(t, []bracketLeg{{"sl", 0.5, 90}, {"tp", 0.5, 120}}, tr.legs)
+
+	// 无变化时不重复处理
+	changed, err = m.Sync()
+	require.NoError(t, err)
+	assert.Empty(t, changed)
+
+	// 持仓消失：撤销剩余挂单
+	tr.positions = nil
+	cancelsBefore := tr.cancels
+	changed, err = m.Sync()
+	require.NoError(t, err)
+	require.Len(t, changed, 1)
+	assert.Equal(t, BracketClosed, changed[0].State)
+	assert.Equal(t, cancelsBefore+1, tr.cancels)
+	assert.Empty(t, m.Active())
+}
+
+func TestBracketManager_ModifyCancelClose(t *testing.T) {
+	tr := &bracketTrader{}
+	m := NewBracketManager(tr)
+	b, err := m.Open(BracketRequest{Symbol: "ETHUSDT", Side: "long", Quantity: 2, StopLoss: 90, TakeProfits: []BracketTarget{{Price: 120}}})
+	require.NoError(t, err)
+
+	// 修改止损：撤销后按新止损和原止盈重挂
+	require.NoError(t, m.ModifyStopLoss(b.ID, 95))
+	assert.Equal(t, []bracketLeg{{"sl", 2, 95}, {"tp", 2, 120}}, tr.legs)
+	assert.Error(t, m.ModifyStopLoss(b.ID, 130), "止损不能高于止盈")
+
+	// 修改止盈：单个目标数量为0表示全部剩余数量
+	require.NoError(t, m.ModifyTakeProfits(b.ID, []BracketTarget{{Price: 130}}))
+	assert.Equal(t, []bracketLeg{{"sl", 2, 95}, {"tp", 2, 130}}, tr.legs)
+
+	// 平仓：撤单并平掉剩余数量
+	require.NoError(t, m.Close(b.ID))
+	assert.Empty(t, tr.legs)
+	assert.Equal(t, []float64{2}, tr.closed)
+	got, err := m.Get(b.ID)
+	require.NoError(t, err)
+	assert.Equal(t, BracketClosed, got.State)
+	assert.Error(t, m.Cancel(b.ID), "已结束的组合单不能再撤销")
+
+	// 撤销：只撤止盈止损，不平仓
+	b2, err := m.Open(BracketRequest{Symbol: "ETHUSDT", Side: "long", Quantity: 1, StopLoss: 90, TakeProfits: []BracketTarget{{Price: 120}}})
+	require.NoError(t, err)
+	require.NoError(t, m.Cancel(b2.ID))
+	assert.Equal(t, []float64{2}, tr.closed)
+}
+
+func TestBracketManager_ClosesWhenLegsFail(t *testing.T) {
+	tr := &bracketTrader{takeProfitErr: errors.New("rejected")}
+	m := NewBracketManager(tr)
+	_, err := m.Open(BracketRequest{Symbol: "BTCUSDT", Side: "long", Quantity: 1, StopLoss: 90, TakeProfits: []BracketTarget{{Price: 110}}})
+	require.Error(t, err)
+	assert.Equal(t, []float64{1}, tr.closed, "挂保护单失败应立即平仓")
+	assert.Empty(t, m.Active())
+}
+
+func TestBracketManager_NativeOCO(t *testing.T) {
+	tr := &nativeBracketTrader{}
+	m := NewBracketManager(tr)
+
+	b, err := m.Open(BracketRequest{Symbol: "SOLUSDT", Side: "short", Quantity: 3, StopLoss: 110, TakeProfits: []BracketTarget{{Price: 90}}})
+	require.NoError(t, err)
+	assert.True(t, b.Native)
+	assert.Equal(t, 1, tr.protected)
+	assert.Empty(t, tr.legs, "原生OCO不单独挂保护单")
+
+	// 多个止盈目标时退回模拟模式
+	require.NoError(t, m.Cancel(b.ID))
+	b, err = m.Open(BracketRequest{Symbol: "SOLUSDT", Side: "short", Quantity: 2, StopLoss: 110,
+		TakeProfits: []BracketTarget{{Price: 90, Quantity: 1}, {Price: 80, Quantity: 1}}})
+	require.NoError(t, err)
+	assert.False(t, b.Native)
+	assert.Equal(t, 1, tr.protected)
+	assert.Equal(t, []bracketLeg{{"sl", 2, 110}, {"tp", 1, 90}, {"tp", 1, 80}}, tr.legs)
+}
@@ -0,0 +1,198 @@
+package trader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ChaosConfig 故障注入配置（仅用于验证重试、限频退避和保护单逻辑，不要在实盘启用）
+// 各故障概率独立判定，按 超时 → 429 → 5xx 的顺序，命中一个即不再判定后续
+type ChaosConfig struct {
+	Latency         time.Duration // 每个请求附加的固定延迟
+	LatencyJitter   time.Duration // 附加的随机延迟上限 [0, LatencyJitter)
+	TimeoutRate     float64       // 模拟超时的概率：请求不会发出，挂起 Timeout 后返回错误
+	Timeout         time.Duration // 模拟超时挂起的时长（<=0 时直到请求 ctx 取消）
+	RateLimitRate   float64       // 返回 429 的概率
+	RetryAfter      time.Duration // 429 响应的 Retry-After（<=0 时不带该头）
+	ServerErrorRate float64       // 返回 503 的概率
+	Paths           []string      // 只对这些路径前缀注入（空表示全部请求）
+	Seed            int64         // 随机种子（0 表示按当前时间），固定种子便于复现
+}
+
+// Enabled 是否注入任何故障
+func (c ChaosConfig) Enabled() bool {
+	return c.Latency > 0 || c.LatencyJitter > 0 || c.TimeoutRate > 0 || c.RateLimitRate > 0 || c.ServerErrorRate > 0
+}
+
+// ChaosStats 已注入的故障统计
+type ChaosStats struct {
+	Requests     int // 经过的请求数（含未命中路径的）
+	Delayed      int
+	Timeouts     int
+	RateLimited  int
+	ServerErrors int
+}
+
+// ChaosTransport 按 ChaosConfig 注入延迟、超时、429 和 5xx 的 http.RoundTripper
+// 未注入错误的请求转发给 next
+type ChaosTransport struct {
+	cfg  ChaosConfig
+	next http.RoundTripper
+
+	mu    sync.Mutex
+	rng   *rand.Rand
+	stats ChaosStats
+}
+
+// NewChaosTransport 创建故障注入transport（next 为nil时使用 http.DefaultTransport）
+func NewChaosTransport(cfg ChaosConfig, next http.RoundTripper) *ChaosTransport {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	seed := cfg.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	return &ChaosTransport{cfg: cfg, next: next, rng: rand.New(rand.NewSource(seed))}
+}
+
+// Stats 返回已注入的故障统计
+func (c *ChaosTransport) Stats() ChaosStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// chaosFault 一次请求的注入结果
+type chaosFault int
+
+const (
+	chaosNone chaosFault = iota
+	chaosTimeout
+	chaosRateLimit
+	chaosServerError
+)
+
+// roll 决定本次请求的延迟和故障
+func (c *ChaosTransport) roll(path string) (time.Duration, chaosFault) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.stats.Requests++
+	if !c.matches(path) {
+		return 0, chaosNone
+	}
+
+	delay := c.cfg.Latency
+	if c.cfg.LatencyJitter > 0 {
+		delay += time.Duration(c.rng.Int63n(int64(c.cfg.LatencyJitter)))
+	}
+	if delay > 0 {
+		c.stats.Delayed++
+	}
+
+	switch {
+	case c.rng.Float64() < c.cfg.TimeoutRate:
+		c.stats.Timeouts++
+		return delay, chaosTimeout
+	case c.rng.Float64() < c.cfg.RateLimitRate:
+		c.stats.RateLimited++
+		return delay, chaosRateLimit
+	case c.rng.Float64() < c.cfg.ServerErrorRate:
+		c.stats.ServerErrors++
+		return delay, chaosServerError
+	}
+	return delay, chaosNone
+}
+
+func (c *ChaosTransport) matches(path string) bool {
+	if len(c.cfg.Paths) == 0 {
+		return true
+	}
+	for _, prefix := range c.cfg.Paths {
+		if strings.HasPrefix(path, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (c *ChaosTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay, fault := c.roll(req.URL.Path)
+	if err := chaosSleep(req, delay); err != nil {
+		return nil, err
+	}
+
+	switch fault {
+	case chaosTimeout:
+		log.Printf("🧪 [Chaos] 模拟超时: %s %s", req.Method, req.URL.Path)
+		if c.cfg.Timeout > 0 {
+			if err := chaosSleep(req, c.cfg.Timeout); err != nil {
+				return nil, err
+			}
+		} else {
+			<-req.Context().Done()
+		}
+		return nil, &chaosTimeoutError{method: req.Method, path: req.URL.Path}
+	case chaosRateLimit:
+		log.Printf("🧪 [Chaos] 模拟限频 429: %s %s", req.Method, req.URL.Path)
+		resp := chaosResponse(req, http.StatusTooManyRequests, `{"code":"TOO_MANY_REQUESTS","message":"chaos: injected rate limit"}`)
+		if c.cfg.RetryAfter > 0 {
+			resp.Header.Set("Retry-After", strconv.FormatFloat(c.cfg.RetryAfter.Seconds(), 'f', -1, 64))
+		}
+		return resp, nil
+	case chaosServerError:
+		log.Printf("🧪 [Chaos] 模拟服务端错误 503: %s %s", req.Method, req.URL.Path)
+		return chaosResponse(req, http.StatusServiceUnavailable, `{"code":"SERVICE_UNAVAILABLE","message":"chaos: injected server error"}`), nil
+	}
+	return c.next.RoundTrip(req)
+}
+
+// chaosTimeoutError 模拟的超时错误，实现 net.Error 以便重试逻辑按网络超时处理
+type chaosTimeoutError struct {
+	method, path string
+}
+
+func (e *chaosTimeoutError) Error() string {
+	return fmt.Sprintf("chaos: 模拟请求超时: %s %s", e.method, e.path)
+}
+
+func (e *chaosTimeoutError) Timeout() bool   { return true }
+func (e *chaosTimeoutError) Temporary() bool { return true }
+
+// chaosSleep 等待 d，请求 ctx 取消时提前返回错误
+func chaosSleep(req *http.Request, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-req.Context().Done():
+		return req.Context().Err()
+	}
+}
+
+func chaosResponse(req *http.Request, status int, body string) *http.Response {
+	return &http.Response{
+		StatusCode:    status,
+		Status:        fmt.Sprintf("%d %s", status, http.StatusText(status)),
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        http.Header{"Content-Type": []string{"application/json"}},
+		Body:          io.NopCloser(bytes.NewBufferString(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
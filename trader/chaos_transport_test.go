@@ -0,0 +1,101 @@
+package trader
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newChaosBackpackTrader(t *testing.T, cfg ChaosConfig) (*BackpackTrader, *ChaosTransport) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"netEquity":"1000","netEquityAvailable":"900","pnlUnrealized":"0"}`))
+	}))
+	t.Cleanup(server.Close)
+	trader := newTestBackpackTrader(t, server.URL)
+	trader.SetResponseCache(ResponseCacheConfig{})
+	chaos := NewChaosTransport(cfg, nil)
+	trader.SetTransport(chaos)
+	return trader, chaos
+}
+
+func TestChaosTransport_RetryRecoversFromServerErrors(t *testing.T) {
+	trader, chaos := newChaosBackpackTrader(t, ChaosConfig{ServerErrorRate: 0.5, Seed: 1})
+	trader.SetRetryPolicy(RetryPolicy{MaxAttempts: 20, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	for i := 0; i < 10; i++ {
+		_, err := trader.GetBalance()
+		require.NoError(t, err)
+	}
+	stats := chaos.Stats()
+	assert.Greater(t, stats.ServerErrors, 0)
+	assert.Equal(t, 10+stats.ServerErrors, stats.Requests, "每次注入的错误都被重试")
+}
+
+func TestChaosTransport_ServerErrorWithoutRetry(t *testing.T) {
+	trader, _ := newChaosBackpackTrader(t, ChaosConfig{ServerErrorRate: 1})
+
+	_, err := trader.GetBalance()
+	var apiErr *APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+}
+
+func TestChaosTransport_RateLimit(t *testing.T) {
+	trader, chaos := newChaosBackpackTrader(t, ChaosConfig{RateLimitRate: 1, RetryAfter: 2 * time.Second})
+
+	_, err := trader.GetBalance()
+	var rateErr *RateLimitError
+	require.ErrorAs(t, err, &rateErr)
+	assert.Equal(t, 2*time.Second, rateErr.RetryAfter)
+	assert.Equal(t, 1, chaos.Stats().RateLimited)
+	assert.Greater(t, trader.backoffRemaining(), time.Duration(0), "429 触发全局退避")
+}
+
+func TestChaosTransport_TimeoutIsRetriedAsNetworkTimeout(t *testing.T) {
+	trader, chaos := newChaosBackpackTrader(t, ChaosConfig{TimeoutRate: 0.5, Timeout: 5 * time.Millisecond, Seed: 3})
+	trader.SetRetryPolicy(RetryPolicy{MaxAttempts: 20, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond})
+
+	for i := 0; i < 5; i++ {
+		_, err := trader.GetBalance()
+		require.NoError(t, err)
+	}
+	assert.Greater(t, chaos.Stats().Timeouts, 0)
+
+	// 不重试时超时错误返回给调用方
+	trader, _ = newChaosBackpackTrader(t, ChaosConfig{TimeoutRate: 1})
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := trader.AccountBalance(ctx)
+	require.Error(t, err)
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+}
+
+func TestChaosTransport_LatencyAndPaths(t *testing.T) {
+	trader, chaos := newChaosBackpackTrader(t, ChaosConfig{Latency: 30 * time.Millisecond, ServerErrorRate: 1,
+		Paths: []string{"/api/v1/capital"}})
+
+	start := time.Now()
+	_, err := trader.GetBalance()
+	require.Error(t, err)
+	assert.GreaterOrEqual(t, time.Since(start), 30*time.Millisecond)
+
+	chaos.cfg.Paths = []string{"/api/v1/order"}
+	_, err = trader.GetBalance()
+	require.NoError(t, err, "未命中路径的请求不注入")
+	assert.Equal(t, 1, chaos.Stats().Delayed)
+}
+
+func TestChaosConfig_Enabled(t *testing.T) {
+	assert.False(t, ChaosConfig{}.Enabled())
+	assert.False(t, ChaosConfig{Seed: 7, Paths: []string{"/api"}}.Enabled())
+	assert.True(t, ChaosConfig{TimeoutRate: 0.1}.Enabled())
+}
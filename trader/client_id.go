@@ -0,0 +1,147 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"nofx/market"
+)
+
+// 订单来源（策略）标识，编码进交易所订单的 clientId，崩溃重启后仍能从成交记录归因
+const (
+	StrategyManual      = "manual"
+	StrategyAI          = "ai"
+	StrategySignal      = "signal"
+	StrategyOpposing    = "opposing_signal"
+	StrategyBracket     = "bracket"
+	StrategyMirror      = "mirror"
+	StrategyDCA         = "dca"
+	StrategyMarketMaker = "market_maker"
+	StrategyFundingArb  = "funding_arb"
+	StrategyPairs       = "pairs"
+	StrategyHedger      = "hedger"
+	StrategyRebalancer  = "rebalancer"
+)
+
+// 编码表：下标即编码，0 表示未知。只能在末尾追加，修改顺序会导致历史订单归因错误
+var (
+	clientIDStrategies = []string{"", StrategyManual, StrategyAI, StrategySignal, StrategyOpposing, StrategyBracket,
+		StrategyMirror, StrategyDCA, StrategyMarketMaker, StrategyFundingArb, StrategyPairs, StrategyHedger, StrategyRebalancer}
+	clientIDSignalTypes = []market.SignalType{"", market.SignalBullishPinBar, market.SignalBearishPinBar,
+		market.SignalVolumeSpike, market.SignalEngulfing, market.SignalWilliamsR, market.SignalMomentum}
+	clientIDTimeFrames = []market.TimeFrame{"", market.TimeFrame5m, market.TimeFrame15m, market.TimeFrame30m,
+		market.TimeFrame1h, market.TimeFrame4h, market.TimeFrame1d}
+)
+
+// clientId 为 uint32，按位划分：策略(4) | 信号类型(4) | 周期(3) | 序号(21)
+const (
+	clientIDStrategyShift = 28
+	clientIDSignalShift   = 24
+	clientIDTFShift       = 21
+	clientIDSeqMask       = 1<<clientIDTFShift - 1
+)
+
+// clientIDSeq 序号从启动时间开始递增，避免重启后立即与旧订单重复
+var clientIDSeq = uint32(time.Now().Unix())
+
+// OrderTag 订单归因信息
+type OrderTag struct {
+	Strategy   string
+	SignalType market.SignalType // 触发开仓的检测器（没有时为空）
+	TimeFrame  market.TimeFrame  // 触发信号的周期（没有时为空）
+}
+
+func indexOf[T comparable](table []T, v T) uint32 {
+	for i, item := range table {
+		if item == v {
+			return uint32(i)
+		}
+	}
+	return 0
+}
+
+// EncodeClientID 将归因信息和自增序号编码为 clientId
+// 不在编码表中的策略/信号/周期编码为0（解码后为空）
+func EncodeClientID(tag OrderTag) uint32 {
+	seq := atomic.AddUint32(&clientIDSeq, 1) & clientIDSeqMask
+	return indexOf(clientIDStrategies, tag.Strategy)<<clientIDStrategyShift |
+		indexOf(clientIDSignalTypes, tag.SignalType)<<clientIDSignalShift |
+		indexOf(clientIDTimeFrames, tag.TimeFrame)<<clientIDTFShift |
+		seq
+}
+
+// DecodeClientID 从 clientId 解析归因信息；不是本系统下的订单（策略编码无效）时返回 false
+func DecodeClientID(id uint32) (OrderTag, bool) {
+	strategy := int(id >> clientIDStrategyShift)
+	signal := int(id >> clientIDSignalShift & 0xF)
+	tf := int(id >> clientIDTFShift & 0x7)
+	if strategy == 0 || strategy >= len(clientIDStrategies) || signal >= len(clientIDSignalTypes) || tf >= len(clientIDTimeFrames) {
+		return OrderTag{}, false
+	}
+	return OrderTag{
+		Strategy:   clientIDStrategies[strategy],
+		SignalType: clientIDSignalTypes[signal],
+		TimeFrame:  clientIDTimeFrames[tf],
+	}, true
+}
+
+// decodeClientIDNumber 解析交易所返回的 clientId 字段
+func decodeClientIDNumber(n json.Number) (OrderTag, bool) {
+	id, err := strconv.ParseUint(n.String(), 10, 32)
+	if err != nil {
+		return OrderTag{}, false
+	}
+	return DecodeClientID(uint32(id))
+}
+
+type orderTagKey struct{}
+
+// WithOrderTag 返回携带订单归因信息的 ctx，经该 ctx 发出的订单都会带上对应的 clientId
+func WithOrderTag(ctx context.Context, tag OrderTag) context.Context {
+	return context.WithValue(ctx, orderTagKey{}, tag)
+}
+
+type clientIDKey struct{}
+
+// WithClientID 返回指定 clientId 的 ctx，经该 ctx 发出的订单使用该 clientId（优先于 WithOrderTag）
+// 调用方事先用 EncodeClientID 生成并记录 clientId，下单超时后可用 GetOrderByClientID 确认订单是否已提交
+// 注意：该 ctx 只能用于一次下单，交易所不保证拒绝重复的 clientId
+func WithClientID(ctx context.Context, id uint32) context.Context {
+	return context.WithValue(ctx, clientIDKey{}, id)
+}
+
+// clientIDFromContext 读取 ctx 中的 clientId；未指定时按归因信息生成新的 clientId
+func clientIDFromContext(ctx context.Context) uint32 {
+	if id, ok := ctx.Value(clientIDKey{}).(uint32); ok {
+		return id
+	}
+	return EncodeClientID(orderTagFromContext(ctx))
+}
+
+// orderTagFromContext 读取 ctx 中的归因信息，未设置时视为手动下单
+func orderTagFromContext(ctx context.Context) OrderTag {
+	if tag, ok := ctx.Value(orderTagKey{}).(OrderTag); ok {
+		return tag
+	}
+	return OrderTag{Strategy: StrategyManual}
+}
+
+// orderTag 生成 AI 开仓的归因信息：优先使用交易限制选中的信号，其次取本周期同方向最强的信号
+func (at *AutoTrader) orderTag(symbol, side string, sig *tradeSignal) OrderTag {
+	if sig != nil {
+		return OrderTag{Strategy: StrategySignal, SignalType: sig.SignalType, TimeFrame: sig.TimeFrame}
+	}
+	var best *market.TradingSignal
+	for _, s := range at.recentSignals[symbol] {
+		if s.Direction == side && (best == nil || s.Confidence > best.Confidence) {
+			best = s
+		}
+	}
+	if best != nil {
+		return OrderTag{Strategy: StrategySignal, SignalType: best.SignalType, TimeFrame: best.TimeFrame}
+	}
+	return OrderTag{Strategy: StrategyAI}
+}
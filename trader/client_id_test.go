@@ -0,0 +1,159 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestClientID_RoundTrip(t *testing.T) {
+	tag := OrderTag{Strategy: StrategySignal, SignalType: market.SignalEngulfing, TimeFrame: market.TimeFrame4h}
+	first := EncodeClientID(tag)
+	second := EncodeClientID(tag)
+	assert.NotEqual(t, first, second, "序号递增，同一归因的订单 clientId 不重复")
+
+	decoded, ok := DecodeClientID(first)
+	require.True(t, ok)
+	assert.Equal(t, tag, decoded)
+
+	// 不在编码表中的信号/周期解码为空，策略仍可归因
+	decoded, ok = DecodeClientID(EncodeClientID(OrderTag{Strategy: StrategyAI, SignalType: "unknown", TimeFrame: "2h"}))
+	require.True(t, ok)
+	assert.Equal(t, OrderTag{Strategy: StrategyAI}, decoded)
+}
+
+func TestClientID_ForeignIDs(t *testing.T) {
+	_, ok := DecodeClientID(0)
+	assert.False(t, ok, "未设置 clientId")
+	_, ok = DecodeClientID(0xF0000000)
+	assert.False(t, ok, "策略编码超出编码表")
+	_, ok = DecodeClientID(EncodeClientID(OrderTag{Strategy: "other"}))
+	assert.False(t, ok, "未知策略")
+
+	_, ok = Fill{ClientID: json.Number("abc")}.Tag()
+	assert.False(t, ok)
+	_, ok = Fill{}.Tag()
+	assert.False(t, ok)
+}
+
+func TestBackpackTrader_OrderCarriesClientIDTag(t *testing.T) {
+	var body map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path == "/api/v1/order" && r.Method == "POST" {
+			d := json.NewDecoder(r.Body)
+			d.UseNumber()
+			require.NoError(t, d.Decode(&body))
+			w.Write([]byte(`{"id":"1","status":"Filled","clientId":` + string(body["clientId"].(json.Number)) + `}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+	trader := newTestBackpackTrader(t, server.URL)
+
+	tag := OrderTag{Strategy: StrategySignal, SignalType: market.SignalBullishPinBar, TimeFrame: market.TimeFrame1h}
+	order, err := trader.OpenLongOrder(WithOrderTag(context.Background(), tag), "SOLUSDT", 1, 5)
+	require.NoError(t, err)
+	decoded, ok := order.Tag()
+	require.True(t, ok, "交易所回传的 clientId 可解析")
+	assert.Equal(t, tag, decoded)
+
+	// 未指定归因时视为手动下单
+	_, err = trader.OpenLongOrder(context.Background(), "SOLUSDT", 1, 5)
+	require.NoError(t, err)
+	decoded, ok = decodeClientIDNumber(body["clientId"].(json.Number))
+	require.True(t, ok)
+	assert.Equal(t, StrategyManual, decoded.Strategy)
+}
+
+func TestAutoTrader_OrderTag(t *testing.T) {
+	at := &AutoTrader{recentSignals: map[string][]*market.TradingSignal{
+		"SOLUSDT": {
+			{Symbol: "SOLUSDT", Direction: "long", SignalType: market.SignalVolumeSpike, TimeFrame: market.TimeFrame15m, Confidence: 60},
+			{Symbol: "SOLUSDT", Direction: "long", SignalType: market.SignalMomentum, TimeFrame: market.TimeFrame1h, Confidence: 80},
+			{Symbol: "SOLUSDT", Direction: "short", SignalType: market.SignalEngulfing, TimeFrame: market.TimeFrame4h, Confidence: 95},
+		},
+	}}
+
+	selected := &tradeSignal{Symbol: "SOLUSDT", SignalType: market.SignalWilliamsR, TimeFrame: market.TimeFrame30m}
+	assert.Equal(t, OrderTag{Strategy: StrategySignal, SignalType: market.SignalWilliamsR, TimeFrame: market.TimeFrame30m},
+		at.orderTag("SOLUSDT", "long", selected), "交易限制选中的信号优先")
+	assert.Equal(t, OrderTag{Strategy: StrategySignal, SignalType: market.SignalMomentum, TimeFrame: market.TimeFrame1h},
+		at.orderTag("SOLUSDT", "long", nil), "同方向最强信号")
+	assert.Equal(t, OrderTag{Strategy: StrategyAI}, at.orderTag("ETHUSDT", "long", nil))
+}
+
+func TestBackpackTrader_WithClientID(t *testing.T) {
+	var sent json.Number
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ClientID json.Number `json:"clientId"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		sent = body.ClientID
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"id":"1","status":"Filled","clientId":` + string(body.ClientID) + `}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	id := EncodeClientID(OrderTag{Strategy: StrategyDCA})
+	ctx := WithOrderTag(WithClientID(context.Background(), id), OrderTag{Strategy: StrategyAI})
+	order, err := trader.OpenLongOrder(ctx, "SOLUSDT", 1, 5)
+	require.NoError(t, err)
+	assert.Equal(t, json.Number(strconv.FormatUint(uint64(id), 10)), sent, "指定的 clientId 优先于归因信息")
+	tag, ok := order.Tag()
+	require.True(t, ok)
+	assert.Equal(t, StrategyDCA, tag.Strategy)
+}
+
+func TestBackpackTrader_GetOrderByClientID(t *testing.T) {
+	var historyCalls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		q := r.URL.Query()
+		switch r.URL.Path {
+		case "/api/v1/order":
+			assert.Equal(t, "SOL_USDC_PERP", q.Get("symbol"))
+			if q.Get("clientId") == "11" {
+				w.Write([]byte(`{"id":"a","clientId":11,"status":"New"}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(`{"code":"RESOURCE_NOT_FOUND","message":"Order not found"}`))
+		case "/wapi/v1/history/orders":
+			historyCalls++
+			w.Write([]byte(`[{"id":"b","clientId":22,"status":"Filled"},{"id":"c","status":"Cancelled"}]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+
+	order, err := trader.GetOrderByClientID("SOLUSDT", 11)
+	require.NoError(t, err)
+	require.NotNil(t, order)
+	assert.Equal(t, "a", order.ID, "挂单中找到")
+	assert.Equal(t, 0, historyCalls)
+
+	order, err = trader.GetOrderByClientID("SOLUSDT", 22)
+	require.NoError(t, err)
+	require.NotNil(t, order)
+	assert.Equal(t, "b", order.ID, "已成交订单在历史中找到")
+	assert.Equal(t, "Filled", order.Status)
+
+	order, err = trader.GetOrderByClientID("SOLUSDT", 33)
+	require.NoError(t, err)
+	assert.Nil(t, order, "未提交的订单返回 nil")
+}
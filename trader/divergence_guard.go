@@ -0,0 +1,74 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+
+	"nofx/decision"
+)
+
+// ErrPriceDivergence 信号价格与执行交易所价格偏离过大
+var ErrPriceDivergence = errors.New("信号价格与交易所价格偏离过大")
+
+// DivergenceGuardConfig 数据源与执行交易所的价格偏离保护
+// 信号基于币安行情计算，在其他交易所执行前用该交易所实时价格核对：
+//   - 偏离 ≤ ResizeAbovePct：正常开仓
+//   - ResizeAbovePct < 偏离 ≤ SkipAbovePct：按交易所价格重算数量，并随偏离线性缩小仓位
+//   - 偏离 > SkipAbovePct：放弃开仓
+type DivergenceGuardConfig struct {
+	ResizeAbovePct float64 // 开始缩小仓位的偏离百分比（0 表示不缩仓，只在超过 SkipAbovePct 时放弃）
+	SkipAbovePct   float64 // 放弃开仓的偏离百分比（0 表示不启用检查）
+}
+
+// Enabled 是否启用偏离检查
+func (c DivergenceGuardConfig) Enabled() bool {
+	return c.SkipAbovePct > 0
+}
+
+// guardPriceDivergence 按偏离保护调整开仓数量
+// side: long/short；返回调整后的数量和偏离百分比
+func guardPriceDivergence(cfg DivergenceGuardConfig, side string, signalPrice, venuePrice, stopLoss, quantity float64) (float64, float64, error) {
+	if signalPrice <= 0 || venuePrice <= 0 {
+		return 0, 0, fmt.Errorf("无效的价格: 信号 %.8f 交易所 %.8f", signalPrice, venuePrice)
+	}
+	divergence := math.Abs(venuePrice-signalPrice) / signalPrice * 100
+
+	// 交易所价格已越过止损，开仓即触发止损
+	if stopLoss > 0 && ((side == "long" && venuePrice <= stopLoss) || (side == "short" && venuePrice >= stopLoss)) {
+		return 0, divergence, fmt.Errorf("%w: 交易所价格 %.4f 已越过止损 %.4f", ErrPriceDivergence, venuePrice, stopLoss)
+	}
+	if divergence > cfg.SkipAbovePct {
+		return 0, divergence, fmt.Errorf("%w: %.2f%% > %.2f%%（信号 %.4f，交易所 %.4f）",
+			ErrPriceDivergence, divergence, cfg.SkipAbovePct, signalPrice, venuePrice)
+	}
+	if cfg.ResizeAbovePct <= 0 || cfg.ResizeAbovePct >= cfg.SkipAbovePct || divergence <= cfg.ResizeAbovePct {
+		return quantity, divergence, nil
+	}
+
+	// 按交易所价格保持名义价值，再随偏离缩小
+	scale := (cfg.SkipAbovePct - divergence) / (cfg.SkipAbovePct - cfg.ResizeAbovePct)
+	return quantity * signalPrice / venuePrice * scale, divergence, nil
+}
+
+// applyDivergenceGuard 开仓前核对执行交易所的实时价格（币安本身即数据源，无需核对）
+func (at *AutoTrader) applyDivergenceGuard(d *decision.Decision, side string, signalPrice, quantity float64) (float64, error) {
+	cfg := at.config.DivergenceGuard
+	if !cfg.Enabled() || at.exchange == "binance" {
+		return quantity, nil
+	}
+	venuePrice, err := at.trader.GetMarketPrice(d.Symbol)
+	if err != nil {
+		return 0, fmt.Errorf("获取交易所实时价格失败: %w", err)
+	}
+	adjusted, divergence, err := guardPriceDivergence(cfg, side, signalPrice, venuePrice, d.StopLoss, quantity)
+	if err != nil {
+		return 0, fmt.Errorf("❌ %s 放弃开仓: %w", d.Symbol, err)
+	}
+	if adjusted != quantity {
+		log.Printf("  ⚖️ %s 信号价格 %.4f 与交易所价格 %.4f 偏离 %.2f%%，数量 %.4f → %.4f",
+			d.Symbol, signalPrice, venuePrice, divergence, quantity, adjusted)
+	}
+	return adjusted, nil
+}
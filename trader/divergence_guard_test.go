@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+
+	"nofx/decision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// venuePriceTrader 返回固定交易所价格的 MockTrader
+type venuePriceTrader struct {
+	MockTrader
+	price float64
+}
+
+func (v *venuePriceTrader) GetMarketPrice(symbol string) (float64, error) {
+	return v.price, nil
+}
+
+func TestGuardPriceDivergence(t *testing.T) {
+	cfg := DivergenceGuardConfig{ResizeAbovePct: 0.5, SkipAbovePct: 1.5}
+
+	// 偏离在缩仓阈值内：数量不变
+	qty, d, err := guardPriceDivergence(cfg, "long", 100, 100.3, 95, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, qty)
+	assert.InDelta(t, 0.3, d, 1e-9)
+
+	// 偏离 1%：按交易所价格保持名义价值，再缩小一半
+	qty, _, err = guardPriceDivergence(cfg, "long", 100, 101, 95, 10)
+	require.NoError(t, err)
+	assert.InDelta(t, 10*100.0/101*0.5, qty, 1e-9)
+
+	// 偏离超过放弃阈值
+	_, _, err = guardPriceDivergence(cfg, "short", 100, 98, 105, 10)
+	assert.True(t, errors.Is(err, ErrPriceDivergence))
+
+	// 交易所价格已越过止损
+	_, _, err = guardPriceDivergence(cfg, "long", 100, 99.6, 99.8, 10)
+	assert.True(t, errors.Is(err, ErrPriceDivergence))
+	_, _, err = guardPriceDivergence(cfg, "short", 100, 100.4, 100.2, 10)
+	assert.True(t, errors.Is(err, ErrPriceDivergence))
+
+	// 未设置缩仓阈值：放弃阈值以内不缩仓
+	qty, _, err = guardPriceDivergence(DivergenceGuardConfig{SkipAbovePct: 1.5}, "long", 100, 101, 95, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, qty)
+}
+
+func TestAutoTrader_ApplyDivergenceGuard(t *testing.T) {
+	tr := &venuePriceTrader{price: 103}
+	d := &decision.Decision{Symbol: "BTCUSDT", StopLoss: 95}
+
+	// 未启用：不请求交易所价格
+	at := &AutoTrader{trader: tr, exchange: "backpack"}
+	qty, err := at.applyDivergenceGuard(d, "long", 100, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, qty)
+
+	// 启用后偏离 3% 超过阈值
+	at.config.DivergenceGuard = DivergenceGuardConfig{ResizeAbovePct: 0.5, SkipAbovePct: 2}
+	_, err = at.applyDivergenceGuard(d, "long", 100, 10)
+	assert.True(t, errors.Is(err, ErrPriceDivergence))
+
+	// 币安本身即数据源，不检查
+	at.exchange = "binance"
+	qty, err = at.applyDivergenceGuard(d, "long", 100, 10)
+	require.NoError(t, err)
+	assert.Equal(t, 10.0, qty)
+}
@@ -0,0 +1,130 @@
+package trader
+
+import (
+	"log"
+	"sync"
+	"time"
+
+	"nofx/logger"
+)
+
+// ExchangeState 交易所运行状态
+type ExchangeState string
+
+const (
+	ExchangeStateOK          ExchangeState = "ok"
+	ExchangeStateDegraded    ExchangeState = "degraded"    // 撮合降级或状态接口不可用
+	ExchangeStateMaintenance ExchangeState = "maintenance" // 维护中
+)
+
+// ExchangeStatus 交易所状态快照
+type ExchangeStatus struct {
+	State     ExchangeState
+	Message   string
+	CheckedAt time.Time
+}
+
+// ExchangeStatusSource 支持查询运行状态的交易所
+type ExchangeStatusSource interface {
+	GetExchangeStatus() (*ExchangeStatus, error)
+}
+
+const (
+	defaultStatusCheckInterval   = time.Minute
+	defaultStatusFailureLimit    = 3
+	defaultStatusToleranceFactor = 2.0
+)
+
+// ExchangeStatusMonitor 交易所状态监控
+// 检测到维护或降级时暂停新开仓、放宽止损管理的容差并发出告警，恢复后自动解除
+type ExchangeStatusMonitor struct {
+	source          ExchangeStatusSource
+	interval        time.Duration
+	failureLimit    int     // 状态接口连续失败多少次视为降级
+	toleranceFactor float64 // 非正常状态下止损管理容差的放大倍数
+
+	mu       sync.RWMutex
+	status   ExchangeStatus
+	failures int
+}
+
+// NewExchangeStatusMonitor 创建状态监控（初始视为正常）
+func NewExchangeStatusMonitor(source ExchangeStatusSource) *ExchangeStatusMonitor {
+	return &ExchangeStatusMonitor{
+		source:          source,
+		interval:        defaultStatusCheckInterval,
+		failureLimit:    defaultStatusFailureLimit,
+		toleranceFactor: defaultStatusToleranceFactor,
+		status:          ExchangeStatus{State: ExchangeStateOK},
+	}
+}
+
+// Check 查询一次交易所状态并更新
+func (m *ExchangeStatusMonitor) Check() ExchangeStatus {
+	current, err := m.source.GetExchangeStatus()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	prev := m.status
+	if err != nil {
+		m.failures++
+		log.Printf("⚠️ 查询交易所状态失败 (%d/%d): %v", m.failures, m.failureLimit, err)
+		if m.failures < m.failureLimit {
+			return m.status
+		}
+		current = &ExchangeStatus{State: ExchangeStateDegraded, Message: "状态接口连续请求失败: " + err.Error()}
+	} else {
+		m.failures = 0
+	}
+	if current.CheckedAt.IsZero() {
+		current.CheckedAt = time.Now()
+	}
+	m.status = *current
+
+	if prev.State != current.State {
+		if current.State == ExchangeStateOK {
+			log.Printf("✅ 交易所状态恢复正常，恢复开仓")
+		} else {
+			logger.Alertf("交易所状态: %s（%s），暂停新开仓，止损管理容差放大 %.1f 倍",
+				current.State, current.Message, m.toleranceFactor)
+		}
+	}
+	return m.status
+}
+
+// Run 按周期检查状态，直到 stop 被关闭
+func (m *ExchangeStatusMonitor) Run(stop <-chan struct{}) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	m.Check()
+	for {
+		select {
+		case <-ticker.C:
+			m.Check()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// Status 最近一次检查的状态
+func (m *ExchangeStatusMonitor) Status() ExchangeStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.status
+}
+
+// EntriesPaused 是否应暂停新开仓
+func (m *ExchangeStatusMonitor) EntriesPaused() bool {
+	return m.Status().State != ExchangeStateOK
+}
+
+// ToleranceMultiplier 止损管理容差倍数：正常时为1，维护/降级时放大，避免在异常行情下被动止损
+func (m *ExchangeStatusMonitor) ToleranceMultiplier() float64 {
+	if m.EntriesPaused() {
+		return m.toleranceFactor
+	}
+	return 1
+}
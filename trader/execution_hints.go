@@ -0,0 +1,144 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+)
+
+// PlacementStyle 限价单挂单方式
+type PlacementStyle string
+
+const (
+	PlacementJoin    PlacementStyle = "join"    // 挂在本方最优价排队
+	PlacementImprove PlacementStyle = "improve" // 比本方最优价改善一个tick
+	PlacementCross   PlacementStyle = "cross"   // 直接吃对手方最优价
+)
+
+// HintConfig 挂单建议参数
+type HintConfig struct {
+	Levels              int     // 计算失衡度使用的档位数（0 表示默认5档）
+	CrossImbalance      float64 // 有利失衡度达到该值时吃单（0 表示默认0.6）
+	ImproveImbalance    float64 // 有利失衡度不低于该值时改善一个tick（0 表示默认-0.2，即仅在明显不利时排队）
+	MaxCrossSpreadTicks int     // 价差不超过该tick数才允许吃单（0 表示默认2）
+}
+
+// withDefaults 填充默认值
+func (c HintConfig) withDefaults() HintConfig {
+	if c.Levels <= 0 {
+		c.Levels = 5
+	}
+	if c.CrossImbalance == 0 {
+		c.CrossImbalance = 0.6
+	}
+	if c.ImproveImbalance == 0 {
+		c.ImproveImbalance = -0.2
+	}
+	if c.MaxCrossSpreadTicks <= 0 {
+		c.MaxCrossSpreadTicks = 2
+	}
+	return c
+}
+
+// ExecutionHint 限价单挂单建议
+type ExecutionHint struct {
+	Style       PlacementStyle
+	Price       float64
+	SpreadTicks int     // 价差（tick数）
+	Imbalance   float64 // 订单簿失衡度（正值买盘更厚）
+	Reason      string
+}
+
+// RecommendPlacement 根据价差和订单簿失衡度给出限价挂单建议
+//   - 失衡明显有利且价差很窄：吃对手方最优价，避免错过行情
+//   - 价差大于1个tick且失衡不明显不利：改善一个tick，争取优先成交
+//   - 其余情况：在本方最优价排队，赚取价差
+func RecommendPlacement(book *OrderBook, isBuy bool, tickSize float64, cfg HintConfig) (*ExecutionHint, error) {
+	if book == nil || len(book.Bids) == 0 || len(book.Asks) == 0 {
+		return nil, fmt.Errorf("订单簿为空，无法给出挂单建议")
+	}
+	if tickSize <= 0 {
+		return nil, fmt.Errorf("无效的价格步进: %v", tickSize)
+	}
+	cfg = cfg.withDefaults()
+
+	bestBid, bestAsk := book.BestBid(), book.BestAsk()
+	hint := &ExecutionHint{
+		SpreadTicks: int(math.Round(book.Spread() / tickSize)),
+		Imbalance:   book.Imbalance(cfg.Levels),
+	}
+
+	// 对买单而言买盘更厚是有利信号（价格倾向上行，需更积极），卖单相反
+	pressure := hint.Imbalance
+	if !isBuy {
+		pressure = -pressure
+	}
+
+	switch {
+	case pressure >= cfg.CrossImbalance && hint.SpreadTicks <= cfg.MaxCrossSpreadTicks:
+		hint.Style = PlacementCross
+		hint.Price = bestAsk
+		if !isBuy {
+			hint.Price = bestBid
+		}
+		hint.Reason = fmt.Sprintf("失衡度 %.2f 达到吃单阈值，价差 %d tick", pressure, hint.SpreadTicks)
+	case hint.SpreadTicks > 1 && pressure >= cfg.ImproveImbalance:
+		hint.Style = PlacementImprove
+		hint.Price = roundToTickSize(bestBid+tickSize, tickSize)
+		if !isBuy {
+			hint.Price = roundToTickSize(bestAsk-tickSize, tickSize)
+		}
+		hint.Reason = fmt.Sprintf("价差 %d tick，改善一个tick", hint.SpreadTicks)
+	default:
+		hint.Style = PlacementJoin
+		hint.Price = bestBid
+		if !isBuy {
+			hint.Price = bestAsk
+		}
+		hint.Reason = fmt.Sprintf("价差 %d tick，失衡度 %.2f，排队等待成交", hint.SpreadTicks, pressure)
+	}
+	return hint, nil
+}
+
+// OpenLongWithHint 按订单簿挂单建议以限价单开多仓（depth 为 nil 时直接查询订单簿）
+func (t *BackpackTrader) OpenLongWithHint(symbol string, quantity float64, depth *DepthCache, cfg HintConfig, opts LimitEntryOptions) (map[string]interface{}, error) {
+	return t.openWithHint(symbol, true, quantity, depth, cfg, opts)
+}
+
+// OpenShortWithHint 按订单簿挂单建议以限价单开空仓（depth 为 nil 时直接查询订单簿）
+func (t *BackpackTrader) OpenShortWithHint(symbol string, quantity float64, depth *DepthCache, cfg HintConfig, opts LimitEntryOptions) (map[string]interface{}, error) {
+	return t.openWithHint(symbol, false, quantity, depth, cfg, opts)
+}
+
+func (t *BackpackTrader) openWithHint(symbol string, isBuy bool, quantity float64, depth *DepthCache, cfg HintConfig, opts LimitEntryOptions) (map[string]interface{}, error) {
+	backpackSymbol := t.mapSymbol(symbol)
+
+	var book *OrderBook
+	var err error
+	if depth != nil {
+		book, err = depth.Get(backpackSymbol)
+	} else {
+		book, err = t.GetOrderBook(backpackSymbol)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	precision, err := t.getSymbolPrecision(context.Background(), backpackSymbol)
+	if err != nil {
+		return nil, err
+	}
+
+	hint, err := RecommendPlacement(book, isBuy, precision.TickSize, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	side := "Ask"
+	if isBuy {
+		side = "Bid"
+	}
+	log.Printf("🎯 [Backpack] %s %s 挂单建议: %s @ %.4f (%s)", backpackSymbol, side, hint.Style, hint.Price, hint.Reason)
+	return t.placeLimitEntry(backpackSymbol, side, quantity, hint.Price, opts)
+}
@@ -0,0 +1,86 @@
+package trader
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testBook(bidQty, askQty float64, bid, ask float64) *OrderBook {
+	return &OrderBook{
+		Symbol:    "SOL_USDC_PERP",
+		Bids:      []BookLevel{{Price: bid, Quantity: bidQty}},
+		Asks:      []BookLevel{{Price: ask, Quantity: askQty}},
+		UpdatedAt: time.Now(),
+	}
+}
+
+func TestRecommendPlacement(t *testing.T) {
+	tests := []struct {
+		name      string
+		book      *OrderBook
+		isBuy     bool
+		wantStyle PlacementStyle
+		wantPrice float64
+	}{
+		{"宽价差改善一个tick（买）", testBook(10, 10, 100.00, 100.05), true, PlacementImprove, 100.01},
+		{"宽价差改善一个tick（卖）", testBook(10, 10, 100.00, 100.05), false, PlacementImprove, 100.04},
+		{"宽价差但失衡不利时排队", testBook(2, 10, 100.00, 100.05), true, PlacementJoin, 100.00},
+		{"一个tick价差排队", testBook(10, 10, 100.00, 100.01), true, PlacementJoin, 100.00},
+		{"买盘明显更厚时买单吃单", testBook(50, 5, 100.00, 100.01), true, PlacementCross, 100.01},
+		{"卖盘明显更厚时卖单吃单", testBook(5, 50, 100.00, 100.01), false, PlacementCross, 100.00},
+		{"价差过宽不吃单", testBook(50, 5, 100.00, 100.10), true, PlacementImprove, 100.01},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hint, err := RecommendPlacement(tt.book, tt.isBuy, 0.01, HintConfig{})
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStyle, hint.Style)
+			assert.InDelta(t, tt.wantPrice, hint.Price, 1e-9)
+		})
+	}
+}
+
+func TestRecommendPlacement_EmptyBook(t *testing.T) {
+	_, err := RecommendPlacement(&OrderBook{Bids: []BookLevel{{Price: 1, Quantity: 1}}}, true, 0.01, HintConfig{})
+	assert.Error(t, err)
+}
+
+func TestOrderBook_Imbalance(t *testing.T) {
+	book := &OrderBook{
+		Bids: []BookLevel{{100, 3}, {99, 100}},
+		Asks: []BookLevel{{101, 1}, {102, 100}},
+	}
+	assert.InDelta(t, 0.5, book.Imbalance(1), 1e-9)
+	assert.InDelta(t, (103.0-101.0)/204.0, book.Imbalance(0), 1e-9)
+}
+
+func TestBackpackTrader_GetOrderBook(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/api/v1/depth", r.URL.Path)
+		assert.Equal(t, "SOL_USDC_PERP", r.URL.Query().Get("symbol"))
+		atomic.AddInt32(&requests, 1)
+		w.Write([]byte(`{"asks":[["150.3","2"],["150.2","1"]],"bids":[["150.0","4"],["150.1","3"]],"lastUpdateId":"1"}`))
+	}))
+	defer server.Close()
+
+	trader := newTestBackpackTrader(t, server.URL)
+	cache := NewDepthCache(trader, time.Minute)
+
+	book, err := cache.Get("SOL_USDC_PERP")
+	require.NoError(t, err)
+	assert.Equal(t, 150.1, book.BestBid())
+	assert.Equal(t, 150.2, book.BestAsk())
+	assert.InDelta(t, 0.1, book.Spread(), 1e-9)
+
+	_, err = cache.Get("SOL_USDC_PERP")
+	require.NoError(t, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests), "TTL 内应命中缓存")
+}
@@ -0,0 +1,308 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"nofx/market"
+)
+
+// defaultRouterAuditSize 路由器保留的最近路由决策数量
+const defaultRouterAuditSize = 200
+
+// Venue 可路由的交易场所
+type Venue struct {
+	Name         string
+	Trader       Trader
+	Depth        DepthSource // 订单簿来源（为空且 Trader 实现了 DepthSource 时使用 Trader；都没有时只按最新价比较，不评估深度）
+	TakerFeeRate float64     // 吃单费率（0.0005 = 0.05%）
+}
+
+// depth 场所的订单簿来源
+func (v Venue) depth() DepthSource {
+	if v.Depth != nil {
+		return v.Depth
+	}
+	if source, ok := v.Trader.(DepthSource); ok {
+		return source
+	}
+	return nil
+}
+
+// RouterConfig 最优执行路由配置
+type RouterConfig struct {
+	MinFillRatio float64 // 订单簿可成交数量至少覆盖订单数量的比例（默认1，即必须能全部成交）
+	AuditSize    int     // 保留的最近路由决策数量（默认200）
+}
+
+func (c RouterConfig) minFillRatio() float64 {
+	if c.MinFillRatio <= 0 {
+		return 1
+	}
+	return c.MinFillRatio
+}
+
+// VenueQuote 某个场所对一笔订单的估算报价
+type VenueQuote struct {
+	Venue          string  `json:"venue"`
+	Price          float64 `json:"price"`           // 估算成交均价（按订单簿逐档吃单；无深度时为最新价）
+	FeeRate        float64 `json:"fee_rate"`        // 吃单费率
+	EffectivePrice float64 `json:"effective_price"` // 含手续费的单位成本（买入）或单位收入（卖出）
+	Available      float64 `json:"available"`       // 订单簿同侧可成交数量（-1 表示未知）
+	Eligible       bool    `json:"eligible"`        // 是否参与比较
+	Note           string  `json:"note,omitempty"`  // 不参与比较的原因或估算说明
+}
+
+// RouteDecision 一次路由决策（审计记录）
+type RouteDecision struct {
+	Time     time.Time    `json:"time"`
+	Symbol   string       `json:"symbol"`
+	Side     string       `json:"side"` // long/short
+	Quantity float64      `json:"quantity"`
+	Signal   string       `json:"signal,omitempty"` // 触发路由的信号（周期/类型）
+	Venue    string       `json:"venue"`            // 选中的场所（无可用场所时为空）
+	Reason   string       `json:"reason"`           // 选择依据
+	Quotes   []VenueQuote `json:"quotes"`
+	OrderErr string       `json:"order_error,omitempty"`
+}
+
+// ExecutionRouter 多场所最优执行路由：比较各场所的最新价、手续费和订单簿深度，把订单发往含费成交价最优的场所
+type ExecutionRouter struct {
+	venues []Venue
+	cfg    RouterConfig
+
+	mu    sync.Mutex
+	audit []*RouteDecision
+}
+
+// NewExecutionRouter 创建路由器（场所名称不能重复）
+func NewExecutionRouter(cfg RouterConfig, venues ...Venue) (*ExecutionRouter, error) {
+	if len(venues) == 0 {
+		return nil, fmt.Errorf("至少需要一个交易场所")
+	}
+	seen := make(map[string]bool, len(venues))
+	for _, v := range venues {
+		if v.Name == "" || v.Trader == nil {
+			return nil, fmt.Errorf("交易场所必须有名称和交易器")
+		}
+		if seen[v.Name] {
+			return nil, fmt.Errorf("重复的交易场所: %s", v.Name)
+		}
+		if v.TakerFeeRate < 0 {
+			return nil, fmt.Errorf("%s 的手续费率不能为负数", v.Name)
+		}
+		seen[v.Name] = true
+	}
+	if cfg.AuditSize <= 0 {
+		cfg.AuditSize = defaultRouterAuditSize
+	}
+	return &ExecutionRouter{venues: venues, cfg: cfg}, nil
+}
+
+// Quote 并发向各场所询价，返回顺序与场所注册顺序一致
+func (r *ExecutionRouter) Quote(symbol, side string, quantity float64) []VenueQuote {
+	quotes := make([]VenueQuote, len(r.venues))
+	var wg sync.WaitGroup
+	for i, v := range r.venues {
+		wg.Add(1)
+		go func(i int, v Venue) {
+			defer wg.Done()
+			quotes[i] = r.quoteVenue(v, symbol, side == "long", quantity)
+		}(i, v)
+	}
+	wg.Wait()
+	return quotes
+}
+
+// quoteVenue 估算单个场所的成交价：有订单簿时逐档吃单，否则使用最新价
+func (r *ExecutionRouter) quoteVenue(v Venue, symbol string, isBuy bool, quantity float64) VenueQuote {
+	q := VenueQuote{Venue: v.Name, FeeRate: v.TakerFeeRate, Available: -1}
+
+	if source := v.depth(); source != nil {
+		book, err := source.GetOrderBook(symbol)
+		if err != nil {
+			q.Note = fmt.Sprintf("获取订单簿失败: %v", err)
+			return q
+		}
+		levels := book.Asks
+		if !isBuy {
+			levels = book.Bids
+		}
+		price, available := sweepLevels(levels, quantity)
+		q.Price, q.Available = price, available
+		if price <= 0 {
+			q.Note = "订单簿同侧无挂单"
+			return q
+		}
+		if available < quantity*r.cfg.minFillRatio() {
+			q.Note = fmt.Sprintf("深度不足: 可成交 %.6f / %.6f", available, quantity)
+			return q
+		}
+	} else {
+		price, err := v.Trader.GetMarketPrice(symbol)
+		if err != nil {
+			q.Note = fmt.Sprintf("获取价格失败: %v", err)
+			return q
+		}
+		if price <= 0 {
+			q.Note = "无有效价格"
+			return q
+		}
+		q.Price = price
+		q.Note = "无订单簿，按最新价估算"
+	}
+
+	if isBuy {
+		q.EffectivePrice = q.Price * (1 + v.TakerFeeRate)
+	} else {
+		q.EffectivePrice = q.Price * (1 - v.TakerFeeRate)
+	}
+	q.Eligible = true
+	return q
+}
+
+// sweepLevels 逐档吃单 quantity 的成交均价和同侧总挂单量（深度不足时均价只按可成交部分计算）
+func sweepLevels(levels []BookLevel, quantity float64) (avgPrice, available float64) {
+	var filled, notional float64
+	for _, level := range levels {
+		available += level.Quantity
+		if filled >= quantity {
+			continue
+		}
+		take := min(level.Quantity, quantity-filled)
+		filled += take
+		notional += take * level.Price
+	}
+	if filled == 0 {
+		return 0, available
+	}
+	return notional / filled, available
+}
+
+// Route 为一笔订单选择场所并记录决策（不下单）
+// 买入选择含费单位成本最低的场所，卖出选择含费单位收入最高的场所；没有可用场所时返回错误
+func (r *ExecutionRouter) Route(symbol, side string, quantity float64) (*RouteDecision, error) {
+	decision, _, err := r.route(symbol, side, quantity, "")
+	return decision, err
+}
+
+// route 询价并选择场所，返回决策和选中的场所
+func (r *ExecutionRouter) route(symbol, side string, quantity float64, signal string) (*RouteDecision, *Venue, error) {
+	if side != "long" && side != "short" {
+		return nil, nil, fmt.Errorf("无效的方向: %q（应为 long 或 short）", side)
+	}
+	if quantity <= 0 {
+		return nil, nil, fmt.Errorf("数量必须大于0: %.8f", quantity)
+	}
+
+	decision := &RouteDecision{Time: time.Now(), Symbol: symbol, Side: side, Quantity: quantity, Signal: signal,
+		Quotes: r.Quote(symbol, side, quantity)}
+
+	ranked := make([]int, 0, len(decision.Quotes))
+	for i, q := range decision.Quotes {
+		if q.Eligible {
+			ranked = append(ranked, i)
+		}
+	}
+	isBuy := side == "long"
+	sort.SliceStable(ranked, func(a, b int) bool {
+		qa, qb := decision.Quotes[ranked[a]], decision.Quotes[ranked[b]]
+		if isBuy {
+			return qa.EffectivePrice < qb.EffectivePrice
+		}
+		return qa.EffectivePrice > qb.EffectivePrice
+	})
+
+	if len(ranked) == 0 {
+		decision.Reason = "没有可用的交易场所: " + excludedVenues(decision.Quotes)
+		r.record(decision)
+		return decision, nil, fmt.Errorf("%s %s 路由失败: %s", symbol, side, decision.Reason)
+	}
+
+	best := decision.Quotes[ranked[0]]
+	decision.Venue = best.Venue
+	decision.Reason = routeReason(best, decision.Quotes, ranked, isBuy)
+	r.record(decision)
+	return decision, &r.venues[ranked[0]], nil
+}
+
+// routeReason 生成选择依据：与次优场所的含费价差，以及被排除的场所
+func routeReason(best VenueQuote, quotes []VenueQuote, ranked []int, isBuy bool) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "选择 %s：含费价 %.6f（成交价 %.6f，费率 %.3f%%）", best.Venue, best.EffectivePrice, best.Price, best.FeeRate*100)
+	if len(ranked) > 1 {
+		next := quotes[ranked[1]]
+		diff := next.EffectivePrice - best.EffectivePrice
+		if !isBuy {
+			diff = -diff
+		}
+		fmt.Fprintf(&sb, "，优于 %s %.6f（%.4f%%）", next.Venue, next.EffectivePrice, diff/best.EffectivePrice*100)
+	} else {
+		sb.WriteString("，唯一可用场所")
+	}
+	if excluded := excludedVenues(quotes); excluded != "" {
+		sb.WriteString("；排除 " + excluded)
+	}
+	return sb.String()
+}
+
+// excludedVenues 不参与比较的场所及原因
+func excludedVenues(quotes []VenueQuote) string {
+	var parts []string
+	for _, q := range quotes {
+		if !q.Eligible {
+			parts = append(parts, fmt.Sprintf("%s（%s）", q.Venue, q.Note))
+		}
+	}
+	return strings.Join(parts, "，")
+}
+
+// RouteSignal 按信号方向路由并在选中的场所市价开仓，下单结果一并写入审计记录
+func (r *ExecutionRouter) RouteSignal(sig *market.TradingSignal, quantity float64, leverage int) (*RouteDecision, map[string]interface{}, error) {
+	signal := fmt.Sprintf("%s %s", sig.TimeFrame, sig.SignalType)
+	decision, venue, err := r.route(sig.Symbol, sig.Direction, quantity, signal)
+	if err != nil {
+		return decision, nil, err
+	}
+	log.Printf("🧭 [Router] %s %s %.6f（%s）→ %s", sig.Symbol, sig.Direction, quantity, signal, decision.Reason)
+
+	var order map[string]interface{}
+	if sig.Direction == "long" {
+		order, err = venue.Trader.OpenLong(sig.Symbol, quantity, leverage)
+	} else {
+		order, err = venue.Trader.OpenShort(sig.Symbol, quantity, leverage)
+	}
+	if err != nil {
+		// 审计记录与返回的决策是同一份，下单结果在选择场所之后才知道
+		r.mu.Lock()
+		decision.OrderErr = err.Error()
+		r.mu.Unlock()
+		return decision, nil, fmt.Errorf("%s 下单失败: %w", venue.Name, err)
+	}
+	return decision, order, nil
+}
+
+// record 追加审计记录（超出容量时丢弃最旧的）
+func (r *ExecutionRouter) record(decision *RouteDecision) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.audit = append(r.audit, decision)
+	if len(r.audit) > r.cfg.AuditSize {
+		r.audit = r.audit[len(r.audit)-r.cfg.AuditSize:]
+	}
+}
+
+// Audit 最近的路由决策（按时间顺序）
+func (r *ExecutionRouter) Audit() []RouteDecision {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	decisions := make([]RouteDecision, len(r.audit))
+	for i, d := range r.audit {
+		decisions[i] = *d
+	}
+	return decisions
+}
@@ -0,0 +1,133 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// venueTrader 固定最新价、记录开仓场所的 MockTrader
+type venueTrader struct {
+	MockTrader
+	price    float64
+	priceErr error
+	openErr  error
+	opened   []string
+}
+
+func (v *venueTrader) GetMarketPrice(symbol string) (float64, error) {
+	return v.price, v.priceErr
+}
+
+func (v *venueTrader) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	v.opened = append(v.opened, "long")
+	return map[string]interface{}{"orderId": "1"}, v.openErr
+}
+
+func (v *venueTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	v.opened = append(v.opened, "short")
+	return map[string]interface{}{"orderId": "1"}, v.openErr
+}
+
+// staticDepth 固定订单簿
+type staticDepth struct {
+	book *OrderBook
+	err  error
+}
+
+func (s staticDepth) GetOrderBook(symbol string) (*OrderBook, error) {
+	return s.book, s.err
+}
+
+func TestSweepLevels(t *testing.T) {
+	levels := []BookLevel{{Price: 100, Quantity: 1}, {Price: 101, Quantity: 2}, {Price: 103, Quantity: 5}}
+	price, available := sweepLevels(levels, 2)
+	assert.InDelta(t, 100.5, price, 1e-9)
+	assert.InDelta(t, 8, available, 1e-9)
+
+	price, available = sweepLevels(levels[:1], 3)
+	assert.InDelta(t, 100, price, 1e-9, "深度不足时按可成交部分计算")
+	assert.InDelta(t, 1, available, 1e-9)
+
+	price, _ = sweepLevels(nil, 1)
+	assert.Zero(t, price)
+}
+
+func TestExecutionRouter_RoutesToBestEffectivePrice(t *testing.T) {
+	cheapButThin := &venueTrader{}
+	deep := &venueTrader{}
+	lowFee := &venueTrader{price: 100.3}
+	router, err := NewExecutionRouter(RouterConfig{},
+		Venue{Name: "thin", Trader: cheapButThin, TakerFeeRate: 0.0002, Depth: staticDepth{book: &OrderBook{
+			Asks: []BookLevel{{Price: 99.9, Quantity: 0.5}},
+			Bids: []BookLevel{{Price: 99.8, Quantity: 0.5}},
+		}}},
+		Venue{Name: "deep", Trader: deep, TakerFeeRate: 0.0005, Depth: staticDepth{book: &OrderBook{
+			Asks: []BookLevel{{Price: 100, Quantity: 1}, {Price: 100.2, Quantity: 5}},
+			Bids: []BookLevel{{Price: 99.9, Quantity: 10}},
+		}}},
+		Venue{Name: "ticker", Trader: lowFee, TakerFeeRate: 0.0001},
+	)
+	require.NoError(t, err)
+
+	// 买入 2：thin 深度不足；deep 均价 100.1 × 1.0005 = 100.15；ticker 100.3 × 1.0001
+	decision, order, err := router.RouteSignal(&market.TradingSignal{Symbol: "BTCUSDT", Direction: "long",
+		TimeFrame: market.TimeFrame15m, SignalType: market.SignalEngulfing}, 2, 5)
+	require.NoError(t, err)
+	assert.NotNil(t, order)
+	assert.Equal(t, "deep", decision.Venue)
+	assert.Equal(t, []string{"long"}, deep.opened)
+	assert.Empty(t, cheapButThin.opened)
+	assert.Equal(t, "15m engulfing", decision.Signal)
+	require.Len(t, decision.Quotes, 3)
+	assert.False(t, decision.Quotes[0].Eligible)
+	assert.InDelta(t, 100.1, decision.Quotes[1].Price, 1e-9)
+	assert.InDelta(t, -1, decision.Quotes[2].Available, 1e-9, "无订单簿时深度未知")
+	assert.Contains(t, decision.Reason, "选择 deep")
+	assert.Contains(t, decision.Reason, "优于 ticker")
+	assert.Contains(t, decision.Reason, "thin（深度不足")
+
+	// 卖出 0.5：thin 99.8 × 0.9998 = 99.78 低于 deep 99.9 × 0.9995 = 99.85；ticker 100.3 × 0.9999 最高
+	decision, err = router.Route("BTCUSDT", "short", 0.5)
+	require.NoError(t, err)
+	assert.Equal(t, "ticker", decision.Venue)
+	assert.Empty(t, lowFee.opened, "Route 只选择不下单")
+
+	audit := router.Audit()
+	require.Len(t, audit, 2)
+	assert.Equal(t, "deep", audit[0].Venue)
+	assert.Equal(t, "ticker", audit[1].Venue)
+}
+
+func TestExecutionRouter_NoEligibleVenueAndOrderError(t *testing.T) {
+	failing := &venueTrader{price: 100, openErr: errors.New("insufficient margin")}
+	router, err := NewExecutionRouter(RouterConfig{AuditSize: 1},
+		Venue{Name: "down", Trader: &venueTrader{priceErr: errors.New("timeout")}},
+		Venue{Name: "failing", Trader: failing},
+	)
+	require.NoError(t, err)
+
+	decision, _, err := router.RouteSignal(&market.TradingSignal{Symbol: "ETHUSDT", Direction: "short"}, 1, 3)
+	require.Error(t, err)
+	assert.Equal(t, "failing", decision.Venue)
+	assert.Contains(t, decision.Reason, "唯一可用场所")
+	audit := router.Audit()
+	require.Len(t, audit, 1)
+	assert.Equal(t, "insufficient margin", audit[0].OrderErr, "下单失败写入审计记录")
+
+	failing.priceErr = errors.New("timeout")
+	decision, err = router.Route("ETHUSDT", "long", 1)
+	require.Error(t, err)
+	assert.Empty(t, decision.Venue)
+	assert.Contains(t, decision.Reason, "down（获取价格失败")
+	assert.Len(t, router.Audit(), 1, "超出容量时丢弃最旧记录")
+
+	_, err = NewExecutionRouter(RouterConfig{}, Venue{Name: "a", Trader: failing}, Venue{Name: "a", Trader: failing})
+	assert.Error(t, err)
+	_, err = router.Route("ETHUSDT", "up", 1)
+	assert.Error(t, err)
+}
@@ -0,0 +1,130 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+
+	"nofx/decision"
+	"nofx/market"
+)
+
+const (
+	exposureBenchmark     = "BTCUSDT" // 贝塔加权的基准
+	exposureBetaKlines    = 20        // 计算贝塔使用的K线根数（与缓存容量一致）
+	exposureBetaTimeFrame = market.TimeFrame1h
+)
+
+// PositionExposure 单个持仓的方向性敞口
+type PositionExposure struct {
+	Symbol       string  `json:"symbol"`
+	Side         string  `json:"side"`
+	Notional     float64 `json:"notional"`      // 带符号名义价值（多仓为正，空仓为负）
+	Beta         float64 `json:"beta"`          // 相对BTC的贝塔（未加权时为1）
+	BetaWeighted float64 `json:"beta_weighted"` // 名义价值 × 贝塔
+}
+
+// NetExposure 账户净方向敞口
+type NetExposure struct {
+	NetDelta          float64            `json:"net_delta"`           // Σ 带符号名义价值
+	BetaWeightedDelta float64            `json:"beta_weighted_delta"` // Σ 贝塔加权名义价值（以BTC计的方向敞口）
+	LongNotional      float64            `json:"long_notional"`
+	ShortNotional     float64            `json:"short_notional"`
+	GrossNotional     float64            `json:"gross_notional"`
+	Positions         []PositionExposure `json:"positions"`
+}
+
+// computeNetExposure 汇总持仓的净敞口，beta 为 nil 时不做贝塔加权
+func computeNetExposure(positions []map[string]interface{}, beta func(symbol string) float64) NetExposure {
+	exposure := NetExposure{Positions: []PositionExposure{}}
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		markPrice, _ := pos["markPrice"].(float64)
+		quantity, _ := pos["positionAmt"].(float64)
+
+		notional := math.Abs(quantity) * markPrice
+		if notional == 0 {
+			continue
+		}
+		if side == "short" {
+			notional = -notional
+			exposure.ShortNotional += -notional
+		} else {
+			exposure.LongNotional += notional
+		}
+
+		b := 1.0
+		if beta != nil {
+			b = beta(symbol)
+		}
+		exposure.Positions = append(exposure.Positions, PositionExposure{
+			Symbol:       symbol,
+			Side:         side,
+			Notional:     notional,
+			Beta:         b,
+			BetaWeighted: notional * b,
+		})
+		exposure.NetDelta += notional
+		exposure.BetaWeightedDelta += notional * b
+	}
+	exposure.GrossNotional = exposure.LongNotional + exposure.ShortNotional
+	return exposure
+}
+
+// betaToBTC 用缓存的1小时K线估算相对BTC的贝塔，数据不足时按1处理
+func (at *AutoTrader) betaToBTC(symbol string) float64 {
+	if symbol == exposureBenchmark || at.klineCache == nil {
+		return 1
+	}
+	asset, err := at.klineCache.GetKlines(symbol, exposureBetaTimeFrame, exposureBetaKlines)
+	if err != nil {
+		return 1
+	}
+	benchmark, err := at.klineCache.GetKlines(exposureBenchmark, exposureBetaTimeFrame, exposureBetaKlines)
+	if err != nil {
+		return 1
+	}
+	if b := market.CalculateBeta(asset, benchmark); b != 0 {
+		return b
+	}
+	return 1
+}
+
+// GetNetExposure 计算当前持仓的净敞口（用于API），betaWeighted 为 true 时按BTC贝塔加权
+func (at *AutoTrader) GetNetExposure(betaWeighted bool) (*NetExposure, error) {
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+	var beta func(string) float64
+	if betaWeighted {
+		beta = at.betaToBTC
+	}
+	exposure := computeNetExposure(positions, beta)
+	return &exposure, nil
+}
+
+// updateExposure 按本周期持仓刷新净敞口快照（GetStatus 读取）
+func (at *AutoTrader) updateExposure(positions []decision.PositionInfo) {
+	raw := make([]map[string]interface{}, 0, len(positions))
+	for _, pos := range positions {
+		raw = append(raw, map[string]interface{}{
+			"symbol":      pos.Symbol,
+			"side":        pos.Side,
+			"markPrice":   pos.MarkPrice,
+			"positionAmt": pos.Quantity,
+		})
+	}
+	exposure := computeNetExposure(raw, at.betaToBTC)
+
+	at.exposureMu.Lock()
+	at.lastExposure = &exposure
+	at.exposureMu.Unlock()
+}
+
+// exposureSnapshot 最近一个周期的净敞口（尚未运行周期时返回 nil）
+func (at *AutoTrader) exposureSnapshot() *NetExposure {
+	at.exposureMu.RLock()
+	defer at.exposureMu.RUnlock()
+	return at.lastExposure
+}
@@ -0,0 +1,51 @@
+package trader
+
+import (
+	"testing"
+
+	"nofx/decision"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeNetExposure(t *testing.T) {
+	positions := []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "markPrice": 100000.0, "positionAmt": 0.1},
+		{"symbol": "ETHUSDT", "side": "short", "markPrice": 4000.0, "positionAmt": -2.0},
+		{"symbol": "SOLUSDT", "side": "long", "markPrice": 200.0, "positionAmt": 0.0},
+	}
+
+	exposure := computeNetExposure(positions, nil)
+	assert.Equal(t, 10000.0, exposure.LongNotional)
+	assert.Equal(t, 8000.0, exposure.ShortNotional)
+	assert.Equal(t, 18000.0, exposure.GrossNotional)
+	assert.Equal(t, 2000.0, exposure.NetDelta)
+	assert.Equal(t, exposure.NetDelta, exposure.BetaWeightedDelta, "未加权时与净敞口一致")
+	require.Len(t, exposure.Positions, 2, "空持仓不计入")
+
+	// ETH 贝塔 1.5：空 8000 相当于空 12000 的BTC
+	exposure = computeNetExposure(positions, func(symbol string) float64 {
+		if symbol == "ETHUSDT" {
+			return 1.5
+		}
+		return 1
+	})
+	assert.Equal(t, 2000.0, exposure.NetDelta)
+	assert.Equal(t, -2000.0, exposure.BetaWeightedDelta)
+	assert.Equal(t, -12000.0, exposure.Positions[1].BetaWeighted)
+}
+
+func TestAutoTrader_ExposureInStatus(t *testing.T) {
+	at := &AutoTrader{trader: &MockTrader{positions: []map[string]interface{}{
+		{"symbol": "BTCUSDT", "side": "long", "markPrice": 50000.0, "positionAmt": 0.2},
+	}}}
+	assert.NotContains(t, at.GetStatus(), "net_delta", "尚未运行周期时不输出")
+
+	exposure, err := at.GetNetExposure(true)
+	require.NoError(t, err)
+	assert.Equal(t, 10000.0, exposure.BetaWeightedDelta, "BTC 贝塔为1")
+
+	at.updateExposure([]decision.PositionInfo{{Symbol: "BTCUSDT", Side: "short", MarkPrice: 50000, Quantity: 0.1}})
+	assert.Equal(t, -5000.0, at.GetStatus()["net_delta"])
+}
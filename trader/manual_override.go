@@ -0,0 +1,185 @@
+package trader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+// manualActions 允许人工干预的操作
+var manualActions = map[string]bool{
+	"open_long":          true,
+	"open_short":         true,
+	"close_long":         true,
+	"close_short":        true,
+	"partial_close":      true,
+	"update_stop_loss":   true,
+	"update_take_profit": true,
+}
+
+// ManualOrderRequest 人工干预（break-glass）请求
+// 经由机器人执行而不是直接在交易所页面操作，止损止盈记录、交易日志和持仓对比状态都会同步更新
+type ManualOrderRequest struct {
+	Action          string  `json:"action"` // open_long/open_short/close_long/close_short/partial_close/update_stop_loss/update_take_profit
+	Symbol          string  `json:"symbol"`
+	PositionSizeUSD float64 `json:"position_size_usd,omitempty"` // 开仓金额
+	Leverage        int     `json:"leverage,omitempty"`
+	StopLoss        float64 `json:"stop_loss,omitempty"`        // 开仓止损；update_stop_loss 时为新止损
+	TakeProfit      float64 `json:"take_profit,omitempty"`      // 开仓止盈；update_take_profit 时为新止盈
+	ClosePercentage float64 `json:"close_percentage,omitempty"` // partial_close 的平仓比例 (0-100)
+	Operator        string  `json:"operator,omitempty"`         // 操作人（写入决策记录）
+	Reason          string  `json:"reason"`                     // 干预原因（必填，写入决策记录）
+
+	Metadata map[string]string `json:"metadata,omitempty"` // 本次订单的元数据标签（开仓时同时设置到持仓）
+}
+
+// toDecision 转换为与AI决策相同的执行参数
+func (r ManualOrderRequest) toDecision() (*decision.Decision, error) {
+	if !manualActions[r.Action] {
+		return nil, fmt.Errorf("不支持的人工操作: %q", r.Action)
+	}
+	if r.Symbol == "" {
+		return nil, fmt.Errorf("交易对不能为空")
+	}
+	if strings.TrimSpace(r.Reason) == "" {
+		return nil, fmt.Errorf("人工干预必须填写原因")
+	}
+
+	d := &decision.Decision{
+		Symbol:          strings.ToUpper(r.Symbol),
+		Action:          r.Action,
+		Leverage:        r.Leverage,
+		PositionSizeUSD: r.PositionSizeUSD,
+		StopLoss:        r.StopLoss,
+		TakeProfit:      r.TakeProfit,
+		ClosePercentage: r.ClosePercentage,
+		Reasoning:       "人工干预: " + r.Reason,
+	}
+	switch r.Action {
+	case "open_long", "open_short":
+		if r.PositionSizeUSD <= 0 || r.Leverage <= 0 || r.StopLoss <= 0 {
+			return nil, fmt.Errorf("开仓需要 position_size_usd、leverage 和 stop_loss")
+		}
+	case "update_stop_loss":
+		if r.StopLoss <= 0 {
+			return nil, fmt.Errorf("update_stop_loss 需要 stop_loss")
+		}
+		d.NewStopLoss = r.StopLoss
+	case "update_take_profit":
+		if r.TakeProfit <= 0 {
+			return nil, fmt.Errorf("update_take_profit 需要 take_profit")
+		}
+		d.NewTakeProfit = r.TakeProfit
+	case "partial_close":
+		if r.ClosePercentage <= 0 || r.ClosePercentage > 100 {
+			return nil, fmt.Errorf("close_percentage 必须在 0-100 之间")
+		}
+	}
+	return d, nil
+}
+
+// ExecuteManualOrder 执行人工干预操作
+// 与AI决策走同一执行路径（含开仓前的风控检查），与决策周期互斥，结果写入决策记录
+func (at *AutoTrader) ExecuteManualOrder(req ManualOrderRequest) (*logger.DecisionAction, error) {
+	d, err := req.toDecision()
+	if err != nil {
+		return nil, err
+	}
+
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	log.Printf("🛠️ [%s] 人工干预: %s %s (操作人: %s, 原因: %s)", at.name, d.Action, d.Symbol, req.Operator, req.Reason)
+	action := &logger.DecisionAction{
+		Action:    d.Action,
+		Symbol:    d.Symbol,
+		Leverage:  d.Leverage,
+		Timestamp: time.Now(),
+		Metadata:  req.Metadata,
+	}
+	execErr := at.executeDecisionWithRecord(d, action)
+	action.Success = execErr == nil
+	if execErr != nil {
+		action.Error = execErr.Error()
+		log.Printf("❌ [%s] 人工干预失败: %s %s: %v", at.name, d.Action, d.Symbol, execErr)
+	} else {
+		log.Printf("✅ [%s] 人工干预完成: %s %s", at.name, d.Action, d.Symbol)
+	}
+
+	at.logManualAction(req, []logger.DecisionAction{*action}, execErr)
+	return action, execErr
+}
+
+// CancelManualOrders 人工撤单：orderID 为空时撤销该交易对全部挂单（含止损止盈，并清除本地记录的止损止盈价格）
+func (at *AutoTrader) CancelManualOrders(symbol, orderID, operator, reason string) error {
+	if symbol == "" {
+		return fmt.Errorf("交易对不能为空")
+	}
+	if strings.TrimSpace(reason) == "" {
+		return fmt.Errorf("人工干预必须填写原因")
+	}
+	symbol = strings.ToUpper(symbol)
+
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	log.Printf("🛠️ [%s] 人工撤单: %s 订单=%q (操作人: %s, 原因: %s)", at.name, symbol, orderID, operator, reason)
+	var err error
+	if orderID == "" {
+		if err = at.trader.CancelAllOrders(symbol); err == nil {
+			for _, side := range []string{"long", "short"} {
+				delete(at.positionStopLoss, symbol+"_"+side)
+				delete(at.positionTakeProfit, symbol+"_"+side)
+			}
+		}
+	} else if canceler, ok := at.trader.(singleOrderCanceler); ok {
+		_, err = canceler.CancelOrder(symbol, orderID)
+	} else {
+		err = fmt.Errorf("当前交易所不支持按订单ID撤单")
+	}
+
+	action := logger.DecisionAction{Action: "cancel_orders", Symbol: symbol, Timestamp: time.Now(), Success: err == nil}
+	if err != nil {
+		action.Error = err.Error()
+	}
+	at.logManualAction(ManualOrderRequest{Action: action.Action, Symbol: symbol, Operator: operator, Reason: reason},
+		[]logger.DecisionAction{action}, err)
+	if err != nil {
+		return fmt.Errorf("人工撤单失败: %w", err)
+	}
+	return nil
+}
+
+// singleOrderCanceler 支持按订单ID撤单的交易器
+type singleOrderCanceler interface {
+	CancelOrder(symbol, orderID string) (*Order, error)
+}
+
+// logManualAction 将人工干预写入决策记录，便于事后审计
+func (at *AutoTrader) logManualAction(req ManualOrderRequest, actions []logger.DecisionAction, err error) {
+	if at.decisionLogger == nil {
+		return
+	}
+	reqJSON, _ := json.Marshal(req)
+	record := &logger.DecisionRecord{
+		Exchange:     at.config.Exchange,
+		CoTTrace:     fmt.Sprintf("人工干预（操作人: %s）: %s", req.Operator, req.Reason),
+		DecisionJSON: string(reqJSON),
+		Decisions:    actions,
+		Success:      err == nil,
+	}
+	if err != nil {
+		record.ErrorMessage = err.Error()
+		record.ExecutionLog = []string{fmt.Sprintf("❌ %s %s 失败（人工干预）: %v", req.Symbol, req.Action, err)}
+	} else {
+		record.ExecutionLog = []string{fmt.Sprintf("✓ %s %s 成功（人工干预）", req.Symbol, req.Action)}
+	}
+	if logErr := at.decisionLogger.LogDecision(record); logErr != nil {
+		log.Printf("⚠ 保存人工干预记录失败: %v", logErr)
+	}
+}
@@ -0,0 +1,100 @@
+package trader
+
+import (
+	"testing"
+
+	"nofx/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// manualCancelTrader 支持按订单ID撤单的 MockTrader
+type manualCancelTrader struct {
+	MockTrader
+	cancelledAll []string
+	cancelledIDs []string
+}
+
+func (m *manualCancelTrader) CancelAllOrders(symbol string) error {
+	m.cancelledAll = append(m.cancelledAll, symbol)
+	return nil
+}
+
+func (m *manualCancelTrader) CancelOrder(symbol, orderID string) (*Order, error) {
+	m.cancelledIDs = append(m.cancelledIDs, orderID)
+	return &Order{ID: orderID, Status: "Cancelled"}, nil
+}
+
+func TestManualOrderRequest_Validation(t *testing.T) {
+	cases := []struct {
+		name string
+		req  ManualOrderRequest
+	}{
+		{"未知操作", ManualOrderRequest{Action: "hold", Symbol: "BTCUSDT", Reason: "x"}},
+		{"缺少原因", ManualOrderRequest{Action: "close_long", Symbol: "BTCUSDT"}},
+		{"缺少交易对", ManualOrderRequest{Action: "close_long", Reason: "x"}},
+		{"开仓缺少止损", ManualOrderRequest{Action: "open_long", Symbol: "BTCUSDT", PositionSizeUSD: 100, Leverage: 2, Reason: "x"}},
+		{"平仓比例越界", ManualOrderRequest{Action: "partial_close", Symbol: "BTCUSDT", ClosePercentage: 120, Reason: "x"}},
+		{"调整止损缺少价格", ManualOrderRequest{Action: "update_stop_loss", Symbol: "BTCUSDT", Reason: "x"}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := tc.req.toDecision()
+			assert.Error(t, err)
+		})
+	}
+
+	d, err := ManualOrderRequest{Action: "update_stop_loss", Symbol: "btcusdt", StopLoss: 58000, Reason: "收紧止损"}.toDecision()
+	require.NoError(t, err)
+	assert.Equal(t, "BTCUSDT", d.Symbol)
+	assert.Equal(t, 58000.0, d.NewStopLoss)
+	assert.Contains(t, d.Reasoning, "收紧止损")
+}
+
+func TestExecuteManualOrder_InvalidRequestNotExecuted(t *testing.T) {
+	dir := t.TempDir()
+	at := &AutoTrader{trader: &MockTrader{}, decisionLogger: logger.NewDecisionLogger(dir)}
+
+	action, err := at.ExecuteManualOrder(ManualOrderRequest{Action: "open_long", Symbol: "BTCUSDT"})
+	require.Error(t, err)
+	assert.Nil(t, action)
+	records, err := at.decisionLogger.GetLatestRecords(10)
+	require.NoError(t, err)
+	assert.Empty(t, records, "参数无效时不写决策记录")
+}
+
+func TestCancelManualOrders(t *testing.T) {
+	tr := &manualCancelTrader{}
+	at := &AutoTrader{
+		trader:             tr,
+		decisionLogger:     logger.NewDecisionLogger(t.TempDir()),
+		positionStopLoss:   map[string]float64{"ETHUSDT_long": 2900, "BTCUSDT_long": 58000},
+		positionTakeProfit: map[string]float64{"ETHUSDT_long": 3300},
+	}
+
+	require.NoError(t, at.CancelManualOrders("ETHUSDT", "42", "ops@example.com", "撤掉挂错的单"))
+	assert.Equal(t, []string{"42"}, tr.cancelledIDs)
+	assert.Contains(t, at.positionStopLoss, "ETHUSDT_long", "撤单个订单不清除止损记录")
+
+	require.NoError(t, at.CancelManualOrders("ethusdt", "", "ops@example.com", "全部撤单"))
+	assert.Equal(t, []string{"ETHUSDT"}, tr.cancelledAll)
+	assert.NotContains(t, at.positionStopLoss, "ETHUSDT_long")
+	assert.NotContains(t, at.positionTakeProfit, "ETHUSDT_long")
+	assert.Contains(t, at.positionStopLoss, "BTCUSDT_long")
+
+	records, err := at.decisionLogger.GetLatestRecords(10)
+	require.NoError(t, err)
+	require.Len(t, records, 2, "每次人工干预都写入决策记录")
+	assert.Contains(t, records[1].CoTTrace, "ops@example.com")
+	assert.Equal(t, "cancel_orders", records[1].Decisions[0].Action)
+	assert.True(t, records[1].Success)
+
+	assert.Error(t, at.CancelManualOrders("ETHUSDT", "", "ops", " "), "必须填写原因")
+}
+
+func TestCancelManualOrders_ByIDUnsupported(t *testing.T) {
+	at := &AutoTrader{trader: &MockTrader{}}
+	err := at.CancelManualOrders("ETHUSDT", "42", "ops", "测试")
+	assert.Error(t, err)
+}
@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"nofx/logger"
+)
+
+// mergeMetadata 合并元数据标签（后面的覆盖前面的同名标签），全部为空时返回 nil
+func mergeMetadata(layers ...map[string]string) map[string]string {
+	var merged map[string]string
+	for _, layer := range layers {
+		for k, v := range layer {
+			if merged == nil {
+				merged = make(map[string]string)
+			}
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// metadataLabel 按键排序格式化为 "k1=v1,k2=v2"
+func metadataLabel(metadata map[string]string) string {
+	keys := make([]string, 0, len(metadata))
+	for k := range metadata {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + metadata[k]
+	}
+	return strings.Join(pairs, ",")
+}
+
+// SetPositionMetadata 为持仓设置元数据标签（与已有标签合并，值为空时删除该标签），平仓时写入交易日志
+func (at *AutoTrader) SetPositionMetadata(symbol, side string, metadata map[string]string) error {
+	if symbol == "" || (side != "long" && side != "short") {
+		return fmt.Errorf("无效的持仓: %s %s", symbol, side)
+	}
+	at.cycleMu.Lock()
+	defer at.cycleMu.Unlock()
+
+	at.tagPosition(strings.ToUpper(symbol)+"_"+side, metadata)
+	return nil
+}
+
+// tagPosition 合并持仓的元数据标签（值为空时删除该标签）
+func (at *AutoTrader) tagPosition(posKey string, metadata map[string]string) {
+	if at.positionJournalInfo == nil {
+		at.positionJournalInfo = make(map[string]journalEntryInfo)
+	}
+	info := at.positionJournalInfo[posKey]
+	info.Metadata = mergeMetadata(info.Metadata, metadata)
+	for k, v := range info.Metadata {
+		if v == "" {
+			delete(info.Metadata, k)
+		}
+	}
+	at.positionJournalInfo[posKey] = info
+}
+
+// alertf 发出带交易员名称和元数据标签的告警，便于区分并行运行的不同配置
+func (at *AutoTrader) alertf(format string, args ...interface{}) {
+	prefix := at.name
+	if label := metadataLabel(at.config.Metadata); label != "" {
+		prefix += " " + label
+	}
+	logger.Alertf("[%s] "+format, append([]interface{}{prefix}, args...)...)
+}
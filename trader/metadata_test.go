@@ -0,0 +1,41 @@
+package trader
+
+import (
+	"testing"
+
+	"nofx/decision"
+	"nofx/logger"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeMetadata(t *testing.T) {
+	assert.Nil(t, mergeMetadata(nil, map[string]string{}))
+	merged := mergeMetadata(map[string]string{"strategy": "scalp", "experiment": "a"}, map[string]string{"experiment": "b"})
+	assert.Equal(t, map[string]string{"strategy": "scalp", "experiment": "b"}, merged)
+	assert.Equal(t, "experiment=b,strategy=scalp", metadataLabel(merged))
+}
+
+func TestAutoTrader_PositionMetadataJournaled(t *testing.T) {
+	journal, err := logger.NewTradeJournal(t.TempDir())
+	require.NoError(t, err)
+	at := &AutoTrader{
+		config:  AutoTraderConfig{Metadata: map[string]string{"experiment": "b", "config_hash": "abc123"}},
+		journal: journal,
+	}
+
+	// 开仓订单的标签，加仓后保留，人工设置的标签合并（空值删除）
+	at.tagPosition("BTCUSDT_long", map[string]string{"strategy": "breakout", "tmp": "x"})
+	at.rememberJournalEntry("BTCUSDT_long", "突破", 95)
+	require.NoError(t, at.SetPositionMetadata("btcusdt", "long", map[string]string{"tmp": "", "operator": "alice"}))
+	assert.Error(t, at.SetPositionMetadata("BTCUSDT", "both", nil))
+
+	at.journalClosedTrade(decision.PositionInfo{Symbol: "BTCUSDT", Side: "long", Quantity: 1, EntryPrice: 100}, 110, "take_profit")
+	entries, err := journal.Query(logger.JournalQuery{Meta: map[string]string{"experiment": "b"}})
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+	assert.Equal(t, map[string]string{
+		"experiment": "b", "config_hash": "abc123", "strategy": "breakout", "operator": "alice",
+	}, entries[0].Metadata)
+}
@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+)
+
+// OpposingSignalConfig 反向强信号减仓：持仓方向出现高强度反向信号时主动减仓或平仓，而不是只等止盈止损
+type OpposingSignalConfig struct {
+	MinConfidence int     // 触发所需的最低信号强度（0 表示不启用）
+	ReducePct     float64 // 减仓百分比（0-100，100 或未设置表示全部平仓）
+}
+
+// reducePct 实际减仓百分比
+func (c OpposingSignalConfig) reducePct() float64 {
+	if c.ReducePct <= 0 || c.ReducePct > 100 {
+		return 100
+	}
+	return c.ReducePct
+}
+
+// signalKey 同一信号在K线收盘前会重复检测到，用周期/类型/价格去重
+func signalKey(sig *market.TradingSignal) string {
+	return fmt.Sprintf("%s_%s_%.8f", sig.TimeFrame, sig.SignalType, sig.Price)
+}
+
+// strongestOpposingSignal 交易对上强度不低于 minConfidence 的最强反向信号
+func strongestOpposingSignal(signals []*market.TradingSignal, symbol, side string, minConfidence int) *market.TradingSignal {
+	var opposing *market.TradingSignal
+	for _, sig := range signals {
+		if sig.Symbol != symbol || sig.Direction == side || sig.Confidence < minConfidence {
+			continue
+		}
+		if opposing == nil || sig.Confidence > opposing.Confidence {
+			opposing = sig
+		}
+	}
+	return opposing
+}
+
+// reactToOpposingSignals 对出现反向强信号的持仓减仓/平仓，返回执行记录
+// Backpack 禁止部分平仓，配置为部分减仓时跳过（不擅自扩大为全部平仓）
+func (at *AutoTrader) reactToOpposingSignals(signals []*market.TradingSignal) []logger.DecisionAction {
+	cfg := at.config.OpposingSignal
+	if cfg.MinConfidence <= 0 {
+		return nil
+	}
+
+	positions, err := at.trader.GetPositions()
+	if err != nil {
+		log.Printf("⚠️ 反向信号检查：获取持仓失败: %v", err)
+		return nil
+	}
+	if at.opposingHandled == nil {
+		at.opposingHandled = make(map[string]string)
+	}
+
+	open := make(map[string]bool, len(positions))
+	var actions []logger.DecisionAction
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		side, _ := pos["side"].(string)
+		posKey := symbol + "_" + side
+		open[posKey] = true
+
+		opposing := strongestOpposingSignal(signals, symbol, side, cfg.MinConfidence)
+		if opposing == nil || at.opposingHandled[posKey] == signalKey(opposing) {
+			continue
+		}
+
+		pct := cfg.reducePct()
+		d := decision.Decision{
+			Symbol:    symbol,
+			Reasoning: fmt.Sprintf("反向强信号 %s %s %s (强度%d%%)", opposing.TimeFrame, opposing.SignalType, opposing.Direction, opposing.Confidence),
+		}
+		switch {
+		case pct < 100:
+			if _, ok := at.trader.(*BackpackTrader); ok {
+				log.Printf("  ⚠️ %s %s 出现反向强信号，但 Backpack 禁止部分平仓，跳过减仓", symbol, side)
+				at.opposingHandled[posKey] = signalKey(opposing)
+				continue
+			}
+			d.Action = "partial_close"
+			d.ClosePercentage = pct
+		case side == "long":
+			d.Action = "close_long"
+		default:
+			d.Action = "close_short"
+		}
+
+		log.Printf("  🔁 %s %s 出现%s，减仓 %.0f%%", symbol, side, d.Reasoning, pct)
+		actionRecord := logger.DecisionAction{
+			Action:    d.Action,
+			Symbol:    symbol,
+			Timestamp: time.Now(),
+		}
+		if err := at.executeDecisionWithRecord(&d, &actionRecord); err != nil {
+			log.Printf("  ❌ 反向信号减仓失败 (%s): %v", symbol, err)
+			actionRecord.Error = err.Error()
+		} else {
+			actionRecord.Success = true
+			at.opposingHandled[posKey] = signalKey(opposing)
+			if d.Action != "partial_close" {
+				at.markJournalClose(posKey, actionRecord.Price, "opposing_signal")
+			}
+		}
+		actions = append(actions, actionRecord)
+	}
+
+	for posKey := range at.opposingHandled {
+		if !open[posKey] {
+			delete(at.opposingHandled, posKey)
+		}
+	}
+	return actions
+}
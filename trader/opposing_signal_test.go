@@ -0,0 +1,46 @@
+package trader
+
+import (
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStrongestOpposingSignal(t *testing.T) {
+	signals := []*market.TradingSignal{
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame5m, Direction: "short", Confidence: 85},
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame1h, Direction: "short", Confidence: 92},
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame4h, Direction: "long", Confidence: 99},
+		{Symbol: "ETHUSDT", TimeFrame: market.TimeFrame1h, Direction: "short", Confidence: 95},
+	}
+
+	sig := strongestOpposingSignal(signals, "BTCUSDT", "long", 80)
+	if assert.NotNil(t, sig) {
+		assert.Equal(t, market.TimeFrame1h, sig.TimeFrame)
+	}
+	assert.Nil(t, strongestOpposingSignal(signals, "BTCUSDT", "long", 95), "强度不足")
+	assert.Nil(t, strongestOpposingSignal(signals, "SOLUSDT", "long", 80))
+	assert.Equal(t, market.TimeFrame4h, strongestOpposingSignal(signals, "BTCUSDT", "short", 80).TimeFrame)
+}
+
+func TestOpposingSignalConfig_ReducePct(t *testing.T) {
+	assert.Equal(t, 100.0, OpposingSignalConfig{}.reducePct())
+	assert.Equal(t, 50.0, OpposingSignalConfig{ReducePct: 50}.reducePct())
+	assert.Equal(t, 100.0, OpposingSignalConfig{ReducePct: 150}.reducePct())
+}
+
+func TestAutoTrader_OpposingSignalSkipsHandled(t *testing.T) {
+	sig := &market.TradingSignal{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame1h, SignalType: market.SignalEngulfing, Direction: "short", Price: 100, Confidence: 90}
+	tr := &MockTrader{positions: []map[string]interface{}{{"symbol": "BTCUSDT", "side": "long", "positionAmt": 1.0}}}
+	at := &AutoTrader{trader: tr, config: AutoTraderConfig{OpposingSignal: OpposingSignalConfig{MinConfidence: 80}}}
+
+	// 未启用时不处理
+	assert.Nil(t, (&AutoTrader{trader: tr}).reactToOpposingSignals([]*market.TradingSignal{sig}))
+
+	// 同一信号已处理过：不重复减仓
+	at.opposingHandled = map[string]string{"BTCUSDT_long": signalKey(sig), "ETHUSDT_short": "x"}
+	assert.Empty(t, at.reactToOpposingSignals([]*market.TradingSignal{sig}))
+	assert.Equal(t, map[string]string{"BTCUSDT_long": signalKey(sig)}, at.opposingHandled, "已平仓的记录被清理")
+}
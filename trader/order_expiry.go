@@ -0,0 +1,102 @@
+package trader
+
+import (
+	"log"
+	"sort"
+	"sync"
+	"time"
+)
+
+// OrderExpiry 待到期撤销的订单
+type OrderExpiry struct {
+	Symbol   string
+	OrderID  string
+	ExpireAt time.Time
+}
+
+// ExpiryScheduler 本地订单到期调度器（Good-Till-Time）
+// 交易所不支持按时间过期的订单时，由调度器在到期时主动撤单，使挂单寿命与信号有效期对齐
+type ExpiryScheduler struct {
+	cancel  func(symbol, orderID string) error
+	pending map[string]OrderExpiry
+	timers  map[string]*time.Timer
+	mu      sync.Mutex
+}
+
+// NewExpiryScheduler 创建到期调度器，cancel 为到期时的撤单函数
+func NewExpiryScheduler(cancel func(symbol, orderID string) error) *ExpiryScheduler {
+	return &ExpiryScheduler{
+		cancel:  cancel,
+		pending: make(map[string]OrderExpiry),
+		timers:  make(map[string]*time.Timer),
+	}
+}
+
+// Schedule 登记订单在 expireAt 到期撤销（重复登记以最后一次为准，已过期的立即撤销）
+func (s *ExpiryScheduler) Schedule(symbol, orderID string, expireAt time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[orderID]; ok {
+		timer.Stop()
+	}
+	s.pending[orderID] = OrderExpiry{Symbol: symbol, OrderID: orderID, ExpireAt: expireAt}
+	s.timers[orderID] = time.AfterFunc(time.Until(expireAt), func() {
+		s.expire(orderID)
+	})
+}
+
+// Unschedule 取消订单的到期撤销（订单已成交或已被其他逻辑撤销时调用）
+func (s *ExpiryScheduler) Unschedule(orderID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if timer, ok := s.timers[orderID]; ok {
+		timer.Stop()
+	}
+	delete(s.timers, orderID)
+	delete(s.pending, orderID)
+}
+
+// Pending 返回尚未到期的订单，按到期时间排序
+func (s *ExpiryScheduler) Pending() []OrderExpiry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]OrderExpiry, 0, len(s.pending))
+	for _, expiry := range s.pending {
+		result = append(result, expiry)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ExpireAt.Before(result[j].ExpireAt) })
+	return result
+}
+
+// Stop 停止全部计时器（不撤销订单）
+func (s *ExpiryScheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for orderID, timer := range s.timers {
+		timer.Stop()
+		delete(s.timers, orderID)
+		delete(s.pending, orderID)
+	}
+}
+
+// expire 到期撤单
+func (s *ExpiryScheduler) expire(orderID string) {
+	s.mu.Lock()
+	expiry, ok := s.pending[orderID]
+	delete(s.pending, orderID)
+	delete(s.timers, orderID)
+	s.mu.Unlock()
+
+	if !ok {
+		return
+	}
+	if err := s.cancel(expiry.Symbol, orderID); err != nil {
+		log.Printf("⚠️ 订单 %s (%s) 到期撤单失败: %v", orderID, expiry.Symbol, err)
+		return
+	}
+	log.Printf("⏰ 订单 %s (%s) 已到期撤销", orderID, expiry.Symbol)
+}
@@ -0,0 +1,95 @@
+package trader
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpiryScheduler_CancelsAtExpiry(t *testing.T) {
+	var mu sync.Mutex
+	var cancelled []string
+	done := make(chan struct{}, 2)
+	scheduler := NewExpiryScheduler(func(symbol, orderID string) error {
+		mu.Lock()
+		cancelled = append(cancelled, symbol+"/"+orderID)
+		mu.Unlock()
+		done <- struct{}{}
+		return nil
+	})
+	defer scheduler.Stop()
+
+	now := time.Now()
+	scheduler.Schedule("SOL_USDC_PERP", "1", now.Add(20*time.Millisecond))
+	scheduler.Schedule("SOL_USDC_PERP", "2", now.Add(time.Hour))
+	scheduler.Schedule("BTC_USDC_PERP", "3", now.Add(10*time.Millisecond))
+	scheduler.Unschedule("3")
+
+	pending := scheduler.Pending()
+	require.Len(t, pending, 2)
+	assert.Equal(t, "1", pending[0].OrderID)
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("订单未按时到期撤销")
+	}
+	time.Sleep(30 * time.Millisecond)
+
+	mu.Lock()
+	assert.Equal(t, []string{"SOL_USDC_PERP/1"}, cancelled)
+	mu.Unlock()
+	assert.Len(t, scheduler.Pending(), 1)
+}
+
+func TestExpiryScheduler_CancelErrorDropsEntry(t *testing.T) {
+	done := make(chan struct{})
+	scheduler := NewExpiryScheduler(func(symbol, orderID string) error {
+		close(done)
+		return errors.New("order not found")
+	})
+	scheduler.Schedule("SOL_USDC_PERP", "1", time.Now())
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("已过期订单应立即撤销")
+	}
+	assert.Eventually(t, func() bool { return len(scheduler.Pending()) == 0 }, time.Second, 5*time.Millisecond)
+}
+
+func TestBackpackTrader_LimitEntryExpireAt(t *testing.T) {
+	var deletes int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/order" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			atomic.AddInt32(&deletes, 1)
+			w.Write([]byte(`{"id":"9","side":"Bid","status":"Cancelled","quantity":"1","executedQuantity":"0","executedQuoteQuantity":"0"}`))
+			return
+		}
+		w.Write([]byte(`{"id":"9","side":"Bid","status":"New","quantity":"1","executedQuantity":"0","executedQuoteQuantity":"0"}`))
+	}))
+	defer server.Close()
+	trader := newTestBackpackTrader(t, server.URL)
+
+	_, err := trader.OpenLongLimit("SOLUSDT", 1, 100, LimitEntryOptions{ExpireAt: time.Now().Add(-time.Second)})
+	assert.ErrorContains(t, err, "已过期")
+
+	_, err = trader.OpenLongLimit("SOLUSDT", 1, 100, LimitEntryOptions{ExpireAt: time.Now().Add(30 * time.Millisecond)})
+	require.NoError(t, err)
+	require.Len(t, trader.PendingExpiries(), 1)
+
+	assert.Eventually(t, func() bool { return atomic.LoadInt32(&deletes) == 1 }, time.Second, 5*time.Millisecond)
+	assert.Empty(t, trader.PendingExpiries())
+}
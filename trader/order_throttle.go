@@ -0,0 +1,188 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// ErrOrderThrottled 本地下单/撤单频率超过账户限额
+// 使用 errors.Is(err, ErrOrderThrottled) 判断，使用 errors.As 获取 *ThrottleError 中的等待时长
+var ErrOrderThrottled = errors.New("下单频率超限")
+
+// throttleWindow 频率统计窗口
+const throttleWindow = time.Minute
+
+// ThrottleKind 受限的操作类型
+type ThrottleKind string
+
+const (
+	ThrottleOrder  ThrottleKind = "order"  // 下单（含改单时的重新下单）
+	ThrottleCancel ThrottleKind = "cancel" // 撤单（含批量撤单）
+)
+
+// ThrottleMode 超限时的处理方式
+type ThrottleMode int
+
+const (
+	// ThrottleReject 超限立即返回 ErrOrderThrottled
+	ThrottleReject ThrottleMode = iota
+	// ThrottleQueue 超限时排队等待额度释放，等待超过 MaxQueueWait 仍返回错误
+	ThrottleQueue
+)
+
+// ThrottleConfig 账户级下单频率限制
+type ThrottleConfig struct {
+	MaxOrdersPerMinute  int           // 每分钟最多下单数（0 表示不限制）
+	MaxCancelsPerMinute int           // 每分钟最多撤单数（0 表示不限制）
+	Mode                ThrottleMode  // 超限处理方式
+	MaxQueueWait        time.Duration // 排队模式下的最长等待（0 表示默认10秒）
+}
+
+// ThrottleError 下单频率超限详情
+type ThrottleError struct {
+	Kind       ThrottleKind
+	Limit      int           // 每分钟限额
+	RetryAfter time.Duration // 额度释放前需要等待的时长
+}
+
+func (e *ThrottleError) Error() string {
+	return fmt.Sprintf("下单频率超限: %s 每分钟最多 %d 次，%.1f秒后重试", e.Kind, e.Limit, e.RetryAfter.Seconds())
+}
+
+// Unwrap 使 errors.Is(err, ErrOrderThrottled) 成立
+func (e *ThrottleError) Unwrap() error {
+	return ErrOrderThrottled
+}
+
+// OrderThrottle 账户级下单频率调控器（滑动窗口计数）
+// 用于保持在交易所反刷单规则之内：同一账户的所有下单/撤单共享额度
+type OrderThrottle struct {
+	cfg    ThrottleConfig
+	events map[ThrottleKind][]time.Time
+	mu     sync.Mutex
+
+	now   func() time.Time
+	sleep func(time.Duration)
+}
+
+// NewOrderThrottle 创建下单频率调控器
+func NewOrderThrottle(cfg ThrottleConfig) *OrderThrottle {
+	if cfg.MaxQueueWait <= 0 {
+		cfg.MaxQueueWait = 10 * time.Second
+	}
+	return &OrderThrottle{
+		cfg:    cfg,
+		events: make(map[ThrottleKind][]time.Time),
+		now:    time.Now,
+		sleep:  time.Sleep,
+	}
+}
+
+// limit 返回操作类型对应的每分钟限额
+func (t *OrderThrottle) limit(kind ThrottleKind) int {
+	if kind == ThrottleCancel {
+		return t.cfg.MaxCancelsPerMinute
+	}
+	return t.cfg.MaxOrdersPerMinute
+}
+
+// Acquire 申请一次操作额度，超限时按 Mode 排队或拒绝
+func (t *OrderThrottle) Acquire(kind ThrottleKind) error {
+	return t.AcquireContext(context.Background(), kind)
+}
+
+// AcquireContext 同 Acquire，排队等待期间 ctx 取消或超时时立即返回 ctx.Err()
+func (t *OrderThrottle) AcquireContext(ctx context.Context, kind ThrottleKind) error {
+	limit := t.limit(kind)
+	if limit <= 0 {
+		return nil
+	}
+
+	deadline := t.now().Add(t.cfg.MaxQueueWait)
+	for {
+		wait := t.tryAcquire(kind, limit)
+		if wait == 0 {
+			return nil
+		}
+		if t.cfg.Mode != ThrottleQueue || t.now().Add(wait).After(deadline) {
+			return &ThrottleError{Kind: kind, Limit: limit, RetryAfter: wait}
+		}
+		if err := t.wait(ctx, wait); err != nil {
+			return err
+		}
+	}
+}
+
+// wait 排队等待；ctx 不可取消时使用 t.sleep（便于测试注入时钟）
+func (t *OrderThrottle) wait(ctx context.Context, d time.Duration) error {
+	if ctx.Done() == nil {
+		t.sleep(d)
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// tryAcquire 有额度时记录并返回0，否则返回最早一次操作滑出窗口前的等待时长
+func (t *OrderThrottle) tryAcquire(kind ThrottleKind, limit int) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := t.now()
+	events := t.events[kind]
+	cutoff := now.Add(-throttleWindow)
+	i := 0
+	for i < len(events) && !events[i].After(cutoff) {
+		i++
+	}
+	events = events[i:]
+
+	if len(events) < limit {
+		t.events[kind] = append(events, now)
+		return 0
+	}
+	t.events[kind] = events
+	return events[0].Add(throttleWindow).Sub(now)
+}
+
+// Usage 返回当前窗口内各类操作的次数
+func (t *OrderThrottle) Usage() map[ThrottleKind]int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	cutoff := t.now().Add(-throttleWindow)
+	usage := make(map[ThrottleKind]int, len(t.events))
+	for kind, events := range t.events {
+		for _, ts := range events {
+			if ts.After(cutoff) {
+				usage[kind]++
+			}
+		}
+	}
+	return usage
+}
+
+// throttleKindForInstruction 将 Backpack 指令映射为受限操作类型（不受限返回空）
+func throttleKindForInstruction(instruction string) ThrottleKind {
+	switch instruction {
+	case "orderExecute":
+		return ThrottleOrder
+	case "orderCancel", "orderCancelAll":
+		return ThrottleCancel
+	}
+	return ""
+}
+
+// SetOrderThrottle 启用账户级下单频率限制（所有下单/撤单请求共享额度）
+func (t *BackpackTrader) SetOrderThrottle(cfg ThrottleConfig) {
+	t.throttle = NewOrderThrottle(cfg)
+}
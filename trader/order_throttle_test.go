@@ -0,0 +1,93 @@
+package trader
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock 可手动推进的时钟，sleep 直接推进时间
+type fakeClock struct{ now time.Time }
+
+func (c *fakeClock) Now() time.Time        { return c.now }
+func (c *fakeClock) Sleep(d time.Duration) { c.now = c.now.Add(d) }
+func newThrottleWithClock(cfg ThrottleConfig) (*OrderThrottle, *fakeClock) {
+	clock := &fakeClock{now: time.Unix(1700000000, 0)}
+	throttle := NewOrderThrottle(cfg)
+	throttle.now = clock.Now
+	throttle.sleep = clock.Sleep
+	return throttle, clock
+}
+
+func TestOrderThrottle_Reject(t *testing.T) {
+	throttle, clock := newThrottleWithClock(ThrottleConfig{MaxOrdersPerMinute: 2, MaxCancelsPerMinute: 1})
+
+	require.NoError(t, throttle.Acquire(ThrottleOrder))
+	clock.Sleep(10 * time.Second)
+	require.NoError(t, throttle.Acquire(ThrottleOrder))
+	require.NoError(t, throttle.Acquire(ThrottleCancel))
+
+	err := throttle.Acquire(ThrottleOrder)
+	assert.True(t, errors.Is(err, ErrOrderThrottled))
+	var throttleErr *ThrottleError
+	require.True(t, errors.As(err, &throttleErr))
+	assert.Equal(t, 50*time.Second, throttleErr.RetryAfter)
+	assert.Error(t, throttle.Acquire(ThrottleCancel))
+
+	// 最早一次下单滑出窗口后恢复额度
+	clock.Sleep(50 * time.Second)
+	assert.NoError(t, throttle.Acquire(ThrottleOrder))
+	assert.Equal(t, map[ThrottleKind]int{ThrottleOrder: 2, ThrottleCancel: 1}, throttle.Usage())
+}
+
+func TestOrderThrottle_Queue(t *testing.T) {
+	throttle, clock := newThrottleWithClock(ThrottleConfig{MaxOrdersPerMinute: 1, Mode: ThrottleQueue, MaxQueueWait: 30 * time.Second})
+	start := clock.now
+
+	require.NoError(t, throttle.Acquire(ThrottleOrder))
+	clock.Sleep(40 * time.Second)
+	require.NoError(t, throttle.Acquire(ThrottleOrder))
+	assert.Equal(t, start.Add(time.Minute), clock.now, "应排队等待到额度释放")
+
+	// 需要等待的时间超过 MaxQueueWait 时拒绝
+	assert.ErrorIs(t, throttle.Acquire(ThrottleOrder), ErrOrderThrottled)
+}
+
+func TestBackpackTrader_OrderThrottle(t *testing.T) {
+	var orders int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if r.URL.Path != "/api/v1/order" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		atomic.AddInt32(&orders, 1)
+		w.Write([]byte(`{"id":"1","side":"Bid","status":"Filled","quantity":"1","executedQuantity":"1","executedQuoteQuantity":"100"}`))
+	}))
+	defer server.Close()
+	trader := newTestBackpackTrader(t, server.URL)
+	trader.SetOrderThrottle(ThrottleConfig{MaxOrdersPerMinute: 1})
+
+	_, err := trader.createOrder(context.Background(), "SOL_USDC_PERP", "Bid", "Market", 1, nil, 0, 0, false)
+	require.NoError(t, err)
+	_, err = trader.createOrder(context.Background(), "SOL_USDC_PERP", "Bid", "Market", 1, nil, 0, 0, false)
+	assert.ErrorIs(t, err, ErrOrderThrottled)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&orders), "超限请求不应发出")
+}
+
+func TestOrderThrottle_QueueWaitHonorsContext(t *testing.T) {
+	throttle := NewOrderThrottle(ThrottleConfig{MaxOrdersPerMinute: 1, Mode: ThrottleQueue, MaxQueueWait: time.Minute})
+	require.NoError(t, throttle.Acquire(ThrottleOrder))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	err := throttle.AcquireContext(ctx, ThrottleOrder)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
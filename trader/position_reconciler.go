@@ -0,0 +1,219 @@
+package trader
+
+import (
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+
+	"nofx/market"
+)
+
+// PositionChangeKind 外部持仓变化类型
+type PositionChangeKind string
+
+const (
+	PositionOpenedExternally PositionChangeKind = "opened"     // 出现了机器人未开的持仓（如网页手动开仓）
+	PositionClosedExternally PositionChangeKind = "closed"     // 持仓在机器人之外被平掉（手动平仓、止损止盈触发等）
+	PositionResized          PositionChangeKind = "resized"    // 持仓数量在机器人之外发生变化
+	PositionLiquidated       PositionChangeKind = "liquidated" // 持仓消失且最后的标记价格已接近强平价
+)
+
+// liquidationProximity 最后标记价格距强平价在该比例内时，消失的持仓判定为被强平
+const liquidationProximity = 0.02
+
+// PositionState 某个方向持仓的状态快照
+type PositionState struct {
+	Symbol           string
+	Side             string
+	Quantity         float64
+	EntryPrice       float64
+	MarkPrice        float64
+	LiquidationPrice float64
+}
+
+// PositionChange 一次外部持仓变化
+type PositionChange struct {
+	Kind     PositionChangeKind
+	Symbol   string
+	Side     string
+	Expected PositionState // 变化前的预期状态（外部开仓时为零值）
+	Actual   PositionState // 当前实际状态（平仓/强平时为零值）
+}
+
+// PositionReconciler 对比交易所持仓与本地预期状态，找出机器人之外发生的变化
+// 预期状态为上一次对比时的快照；机器人自己操作过的交易对（MarkBotAction）在下一次对比时直接采纳实际状态，不产生事件
+type PositionReconciler struct {
+	tolerance float64 // 数量相对误差容忍度
+
+	mu          sync.Mutex
+	expected    map[string]PositionState
+	botTouched  map[string]bool
+	initialized bool
+}
+
+// NewPositionReconciler 创建持仓对比器
+func NewPositionReconciler() *PositionReconciler {
+	return &PositionReconciler{
+		tolerance:  0.001,
+		expected:   make(map[string]PositionState),
+		botTouched: make(map[string]bool),
+	}
+}
+
+// MarkBotAction 标记机器人即将操作某个交易对，下一次对比时该交易对的变化视为预期内
+func (r *PositionReconciler) MarkBotAction(symbol string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.botTouched[market.Normalize(symbol)] = true
+}
+
+// Diff 对比交易所持仓（GetPositions 的返回值）与预期状态，返回外部变化并以实际状态作为新的预期
+// 首次调用只记录快照，不产生事件
+func (r *PositionReconciler) Diff(positions []map[string]interface{}) []PositionChange {
+	actual := make(map[string]PositionState, len(positions))
+	for _, pos := range positions {
+		state := positionStateFromMap(pos)
+		if state.Quantity == 0 || (state.Side != "long" && state.Side != "short") {
+			continue
+		}
+		actual[state.Symbol+"_"+state.Side] = state
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var changes []PositionChange
+	if r.initialized {
+		for key, exp := range r.expected {
+			if r.botTouched[exp.Symbol] {
+				continue
+			}
+			act, exists := actual[key]
+			switch {
+			case !exists:
+				kind := PositionClosedExternally
+				if nearLiquidation(exp) {
+					kind = PositionLiquidated
+				}
+				changes = append(changes, PositionChange{Kind: kind, Symbol: exp.Symbol, Side: exp.Side, Expected: exp})
+			case math.Abs(act.Quantity-exp.Quantity) > exp.Quantity*r.tolerance:
+				changes = append(changes, PositionChange{Kind: PositionResized, Symbol: exp.Symbol, Side: exp.Side, Expected: exp, Actual: act})
+			}
+		}
+		for key, act := range actual {
+			if _, known := r.expected[key]; known || r.botTouched[act.Symbol] {
+				continue
+			}
+			changes = append(changes, PositionChange{Kind: PositionOpenedExternally, Symbol: act.Symbol, Side: act.Side, Actual: act})
+		}
+	}
+
+	r.expected = actual
+	r.botTouched = make(map[string]bool)
+	r.initialized = true
+
+	sort.Slice(changes, func(i, j int) bool {
+		if changes[i].Symbol != changes[j].Symbol {
+			return changes[i].Symbol < changes[j].Symbol
+		}
+		return changes[i].Side < changes[j].Side
+	})
+	return changes
+}
+
+// positionStateFromMap 从 GetPositions 的返回值解析持仓状态
+func positionStateFromMap(pos map[string]interface{}) PositionState {
+	symbol, _ := pos["symbol"].(string)
+	state := PositionState{Symbol: market.Normalize(symbol)}
+	state.Side, _ = pos["side"].(string)
+	state.Quantity, _ = pos["positionAmt"].(float64)
+	state.Quantity = math.Abs(state.Quantity)
+	state.EntryPrice, _ = pos["entryPrice"].(float64)
+	state.MarkPrice, _ = pos["markPrice"].(float64)
+	state.LiquidationPrice, _ = pos["liquidationPrice"].(float64)
+	return state
+}
+
+// nearLiquidation 最后一次看到的标记价格是否已接近强平价
+func nearLiquidation(s PositionState) bool {
+	if s.LiquidationPrice <= 0 || s.MarkPrice <= 0 {
+		return false
+	}
+	if s.Side == "long" {
+		return s.MarkPrice <= s.LiquidationPrice*(1+liquidationProximity)
+	}
+	return s.MarkPrice >= s.LiquidationPrice*(1-liquidationProximity)
+}
+
+// reconcilePositions 检测外部持仓变化并修正保护单和本地状态
+func (at *AutoTrader) reconcilePositions(positions []map[string]interface{}) {
+	if at.positionReconciler == nil {
+		return
+	}
+	changes := at.positionReconciler.Diff(positions)
+	if len(changes) == 0 {
+		return
+	}
+
+	// 仍有持仓的交易对（撤单时避免误撤另一方向的保护单）
+	openSymbols := make(map[string]bool)
+	for _, pos := range positions {
+		if state := positionStateFromMap(pos); state.Quantity > 0 {
+			openSymbols[state.Symbol] = true
+		}
+	}
+
+	for _, change := range changes {
+		posKey := change.Symbol + "_" + change.Side
+		switch change.Kind {
+		case PositionClosedExternally, PositionLiquidated:
+			at.alertf("持仓在机器人之外被平掉: %s %s %.4f（%s，最后标记价 %.4f）",
+				change.Symbol, change.Side, change.Expected.Quantity, change.Kind, change.Expected.MarkPrice)
+			if !openSymbols[change.Symbol] {
+				// 清理遗留的止盈止损单，避免之后误触发开出反向仓位
+				if err := at.trader.CancelAllOrders(change.Symbol); err != nil {
+					log.Printf("⚠️ 撤销 %s 遗留挂单失败: %v", change.Symbol, err)
+				}
+			}
+			at.ClearPeakPnLCache(change.Symbol, change.Side)
+
+		case PositionResized:
+			at.alertf("持仓数量在机器人之外发生变化: %s %s %.4f → %.4f",
+				change.Symbol, change.Side, change.Expected.Quantity, change.Actual.Quantity)
+			at.resizeProtection(change.Symbol, change.Side, change.Actual.Quantity,
+				at.positionStopLoss[posKey], at.positionTakeProfit[posKey])
+			// 数量变化后收益率基准不同，重新跟踪峰值
+			at.ClearPeakPnLCache(change.Symbol, change.Side)
+
+		case PositionOpenedExternally:
+			at.alertf("发现机器人之外开出的持仓: %s %s %.4f @ %.4f（没有机器人设置的止盈止损）",
+				change.Symbol, change.Side, change.Actual.Quantity, change.Actual.EntryPrice)
+		}
+	}
+}
+
+// resizeProtection 按新的持仓数量重挂止盈止损单（价格未知时跳过）
+func (at *AutoTrader) resizeProtection(symbol, side string, quantity, stopLoss, takeProfit float64) {
+	if stopLoss <= 0 && takeProfit <= 0 {
+		log.Printf("⚠️ %s %s 没有记录的止盈止损价格，跳过重挂", symbol, side)
+		return
+	}
+	if err := at.trader.CancelStopOrders(symbol); err != nil {
+		log.Printf("⚠️ 撤销 %s 止盈止损单失败: %v", symbol, err)
+	}
+
+	positionSide := strings.ToUpper(side)
+	if stopLoss > 0 {
+		if err := at.trader.SetStopLoss(symbol, positionSide, quantity, stopLoss); err != nil {
+			log.Printf("❌ 重挂 %s 止损失败: %v", symbol, err)
+		}
+	}
+	if takeProfit > 0 {
+		if err := at.trader.SetTakeProfit(symbol, positionSide, quantity, takeProfit); err != nil {
+			log.Printf("❌ 重挂 %s 止盈失败: %v", symbol, err)
+		}
+	}
+	log.Printf("🛡️ %s %s 已按新数量 %.4f 重挂止盈止损", symbol, side, quantity)
+}
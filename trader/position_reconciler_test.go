@@ -0,0 +1,121 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testPosition(symbol, side string, qty, mark, liq float64) map[string]interface{} {
+	return map[string]interface{}{
+		"symbol":           symbol,
+		"side":             side,
+		"positionAmt":      qty,
+		"entryPrice":       100.0,
+		"markPrice":        mark,
+		"liquidationPrice": liq,
+	}
+}
+
+func TestPositionReconciler_DetectsExternalChanges(t *testing.T) {
+	r := NewPositionReconciler()
+
+	// 首次对比只记录快照
+	assert.Empty(t, r.Diff([]map[string]interface{}{
+		testPosition("BTCUSDT", "long", 1, 100, 50),
+		testPosition("ETHUSDT", "short", 2, 100, 150),
+		testPosition("SOLUSDT", "long", 10, 52, 51),
+	}))
+
+	changes := r.Diff([]map[string]interface{}{
+		testPosition("BTCUSDT", "long", 0.4, 100, 50),
+		testPosition("DOGEUSDT", "long", 1000, 0.1, 0),
+	})
+	require.Len(t, changes, 4)
+
+	byKey := make(map[string]PositionChange)
+	for _, c := range changes {
+		byKey[c.Symbol+"_"+c.Side] = c
+	}
+	assert.Equal(t, PositionResized, byKey["BTCUSDT_long"].Kind)
+	assert.Equal(t, 1.0, byKey["BTCUSDT_long"].Expected.Quantity)
+	assert.Equal(t, 0.4, byKey["BTCUSDT_long"].Actual.Quantity)
+	assert.Equal(t, PositionOpenedExternally, byKey["DOGEUSDT_long"].Kind)
+	assert.Equal(t, PositionClosedExternally, byKey["ETHUSDT_short"].Kind)
+	assert.Equal(t, PositionLiquidated, byKey["SOLUSDT_long"].Kind, "最后标记价距强平价2%以内")
+
+	// 变化已被采纳为新的预期
+	assert.Empty(t, r.Diff([]map[string]interface{}{
+		testPosition("BTCUSDT", "long", 0.4, 100, 50),
+		testPosition("DOGEUSDT", "long", 1000, 0.1, 0),
+	}))
+}
+
+func TestPositionReconciler_IgnoresBotActions(t *testing.T) {
+	r := NewPositionReconciler()
+	r.Diff([]map[string]interface{}{testPosition("BTCUSDT", "long", 1, 100, 50)})
+
+	r.MarkBotAction("BTCUSDT")
+	r.MarkBotAction("ETHUSDT")
+	assert.Empty(t, r.Diff([]map[string]interface{}{
+		testPosition("BTCUSDT", "long", 2, 100, 50),
+		testPosition("ETHUSDT", "short", 1, 100, 150),
+	}))
+
+	// 标记只对下一次对比有效
+	changes := r.Diff([]map[string]interface{}{testPosition("BTCUSDT", "long", 2, 100, 50)})
+	require.Len(t, changes, 1)
+	assert.Equal(t, PositionClosedExternally, changes[0].Kind)
+	assert.Equal(t, "ETHUSDT", changes[0].Symbol)
+}
+
+// protectionTrader 记录保护单操作的 MockTrader
+type protectionTrader struct {
+	cleanupTrader
+	stopOrdersCancelled int
+	stopLosses          []float64
+	takeProfits         []float64
+}
+
+func (p *protectionTrader) CancelStopOrders(symbol string) error {
+	p.stopOrdersCancelled++
+	return nil
+}
+
+func (p *protectionTrader) SetStopLoss(symbol, positionSide string, quantity, stopPrice float64) error {
+	p.stopLosses = append(p.stopLosses, quantity)
+	return nil
+}
+
+func (p *protectionTrader) SetTakeProfit(symbol, positionSide string, quantity, takeProfitPrice float64) error {
+	p.takeProfits = append(p.takeProfits, quantity)
+	return nil
+}
+
+func TestAutoTrader_ReconcilePositions(t *testing.T) {
+	tr := &protectionTrader{}
+	at := &AutoTrader{
+		trader:             tr,
+		positionReconciler: NewPositionReconciler(),
+		positionStopLoss:   map[string]float64{"BTCUSDT_long": 90},
+		positionTakeProfit: map[string]float64{"BTCUSDT_long": 120},
+		peakPnLCache:       map[string]float64{"BTCUSDT_long": 5, "ETHUSDT_short": 3},
+	}
+
+	at.reconcilePositions([]map[string]interface{}{
+		testPosition("BTCUSDT", "long", 1, 100, 50),
+		testPosition("ETHUSDT", "short", 2, 100, 150),
+	})
+	at.reconcilePositions([]map[string]interface{}{
+		testPosition("BTCUSDT", "long", 0.5, 100, 50),
+	})
+
+	// 缩小的持仓按新数量重挂止盈止损
+	assert.Equal(t, 1, tr.stopOrdersCancelled)
+	assert.Equal(t, []float64{0.5}, tr.stopLosses)
+	assert.Equal(t, []float64{0.5}, tr.takeProfits)
+	// 被外部平掉的持仓撤销遗留挂单
+	assert.Equal(t, []string{"ETHUSDT"}, tr.cancelled)
+	assert.Empty(t, at.peakPnLCache)
+}
@@ -0,0 +1,125 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// defaultProtectionRetryDelay 止损止盈下单失败后首次重试前的等待
+const defaultProtectionRetryDelay = time.Second
+
+// ProtectionRetryConfig 开仓后止损止盈下单失败时的重试与升级处理
+// 重试用尽后发出告警；止损仍未设置时可选市价平仓（没有止损的杠杆仓位是最坏的情况）
+type ProtectionRetryConfig struct {
+	MaxAttempts    int           // 每个保护单最多尝试次数（含首次，<=1 表示不重试）
+	RetryDelay     time.Duration // 首次重试前的等待（<=0 时为1s），之后每次翻倍
+	EmergencyClose bool          // 止损重试用尽后市价平掉该仓位
+}
+
+// attempts 每个保护单的尝试次数
+func (c ProtectionRetryConfig) attempts() int {
+	if c.MaxAttempts < 1 {
+		return 1
+	}
+	return c.MaxAttempts
+}
+
+// ProtectionResult 设置止损止盈的结果
+type ProtectionResult struct {
+	StopLossErr     error // 止损最终失败的错误（nil 表示已设置或不需要设置）
+	TakeProfitErr   error // 止盈最终失败的错误
+	EmergencyClosed bool  // 因止损无法设置而紧急平仓
+}
+
+// retryProtection 按配置重试一个保护单，返回最后一次的错误
+func (at *AutoTrader) retryProtection(kind string, place func() error) error {
+	return at.config.ProtectionRetry.retryWithBackoff(kind, place)
+}
+
+// retryWithBackoff 按 cfg 重试 place（首次等待 RetryDelay，之后每次翻倍），返回最后一次的错误
+func (cfg ProtectionRetryConfig) retryWithBackoff(kind string, place func() error) error {
+	delay := cfg.RetryDelay
+	if delay <= 0 {
+		delay = defaultProtectionRetryDelay
+	}
+	var err error
+	for attempt := 1; attempt <= cfg.attempts(); attempt++ {
+		if err = place(); err == nil {
+			if attempt > 1 {
+				log.Printf("  ✓ 第%d次尝试设置%s成功", attempt, kind)
+			}
+			return nil
+		}
+		if attempt < cfg.attempts() {
+			log.Printf("  ⚠ 设置%s失败（第%d/%d次），%v 后重试: %v", kind, attempt, cfg.attempts(), delay, err)
+			time.Sleep(delay)
+			delay *= 2
+		}
+	}
+	return err
+}
+
+// placeProtection 为刚开出的仓位设置止损止盈（价格<=0 的一侧跳过）
+// 失败时按 ProtectionRetryConfig 重试；重试用尽后告警，止损失败且启用 EmergencyClose 时市价平仓
+func (at *AutoTrader) placeProtection(symbol, positionSide string, quantity, stopLoss, takeProfit float64) ProtectionResult {
+	var result ProtectionResult
+	if pair, ok := at.trader.(ProtectionPairSetter); ok && stopLoss > 0 && takeProfit > 0 {
+		// 交易所支持联动止盈止损时一起挂出，触发一个自动撤销另一个；失败时退回分别设置，保证至少有止损
+		err := at.retryProtection("联动止盈止损", func() error {
+			return pair.SetStopLossAndTakeProfit(symbol, positionSide, quantity, stopLoss, takeProfit)
+		})
+		if err == nil {
+			return result
+		}
+		log.Printf("  ⚠ 联动止盈止损设置失败，改为分别设置: %v", err)
+	}
+	if stopLoss > 0 {
+		result.StopLossErr = at.retryProtection("止损", func() error {
+			return at.trader.SetStopLoss(symbol, positionSide, quantity, stopLoss)
+		})
+	}
+	if takeProfit > 0 {
+		result.TakeProfitErr = at.retryProtection("止盈", func() error {
+			return at.trader.SetTakeProfit(symbol, positionSide, quantity, takeProfit)
+		})
+	}
+
+	if result.TakeProfitErr != nil {
+		at.alertf("%s %s 止盈单设置失败（%d次）: %v", symbol, positionSide, at.config.ProtectionRetry.attempts(), result.TakeProfitErr)
+	}
+	if result.StopLossErr == nil {
+		return result
+	}
+
+	if !at.config.ProtectionRetry.EmergencyClose {
+		at.alertf("%s %s %.4f 止损单设置失败（%d次），仓位没有止损保护: %v",
+			symbol, positionSide, quantity, at.config.ProtectionRetry.attempts(), result.StopLossErr)
+		return result
+	}
+
+	var err error
+	if strings.EqualFold(positionSide, "long") {
+		_, err = at.trader.CloseLong(symbol, 0)
+	} else {
+		_, err = at.trader.CloseShort(symbol, 0)
+	}
+	if err != nil {
+		at.alertf("%s %s 止损单设置失败且紧急平仓失败，需要人工处理！止损错误: %v，平仓错误: %v",
+			symbol, positionSide, result.StopLossErr, err)
+		return result
+	}
+	result.EmergencyClosed = true
+	at.trader.CancelAllOrders(symbol) // 撤销可能已挂出的止盈单
+	at.alertf("%s %s 止损单设置失败（%v），已紧急市价平仓", symbol, positionSide, result.StopLossErr)
+	return result
+}
+
+// protectionError 紧急平仓后返回给决策记录的错误
+func (r ProtectionResult) protectionError() error {
+	if !r.EmergencyClosed {
+		return nil
+	}
+	return fmt.Errorf("止损单设置失败，已紧急平仓: %w", r.StopLossErr)
+}
@@ -0,0 +1,142 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// flakyProtectionTrader 前若干次设置止损/止盈失败的 MockTrader
+type flakyProtectionTrader struct {
+	MockTrader
+	stopFailures, takeFailures int
+	stopCalls, takeCalls       int
+	closedLong, closedShort    int
+	cancelled                  int
+}
+
+func (f *flakyProtectionTrader) SetStopLoss(symbol, positionSide string, quantity, stopPrice float64) error {
+	f.stopCalls++
+	if f.stopCalls <= f.stopFailures {
+		return errors.New("stop rejected")
+	}
+	return nil
+}
+
+func (f *flakyProtectionTrader) SetTakeProfit(symbol, positionSide string, quantity, takeProfitPrice float64) error {
+	f.takeCalls++
+	if f.takeCalls <= f.takeFailures {
+		return errors.New("take profit rejected")
+	}
+	return nil
+}
+
+func (f *flakyProtectionTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	f.closedLong++
+	return map[string]interface{}{}, nil
+}
+
+func (f *flakyProtectionTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	f.closedShort++
+	return map[string]interface{}{}, nil
+}
+
+func (f *flakyProtectionTrader) CancelAllOrders(symbol string) error {
+	f.cancelled++
+	return nil
+}
+
+func TestPlaceProtection_RetriesUntilPlaced(t *testing.T) {
+	tr := &flakyProtectionTrader{stopFailures: 2, takeFailures: 1}
+	at := &AutoTrader{trader: tr, config: AutoTraderConfig{
+		ProtectionRetry: ProtectionRetryConfig{MaxAttempts: 3, RetryDelay: time.Millisecond},
+	}}
+
+	result := at.placeProtection("BTCUSDT", "LONG", 0.1, 49000, 52000)
+	assert.NoError(t, result.StopLossErr)
+	assert.NoError(t, result.TakeProfitErr)
+	assert.False(t, result.EmergencyClosed)
+	assert.NoError(t, result.protectionError())
+	assert.Equal(t, 3, tr.stopCalls)
+	assert.Equal(t, 2, tr.takeCalls)
+	assert.Zero(t, tr.closedLong)
+}
+
+func TestPlaceProtection_DefaultTriesOnceWithoutClosing(t *testing.T) {
+	tr := &flakyProtectionTrader{stopFailures: 5}
+	at := &AutoTrader{trader: tr}
+
+	result := at.placeProtection("BTCUSDT", "LONG", 0.1, 49000, 0)
+	assert.Error(t, result.StopLossErr)
+	assert.False(t, result.EmergencyClosed)
+	assert.NoError(t, result.protectionError(), "未启用紧急平仓时开仓仍视为成功")
+	assert.Equal(t, 1, tr.stopCalls)
+	assert.Zero(t, tr.takeCalls, "止盈价为0时跳过")
+	assert.Zero(t, tr.closedLong)
+}
+
+func TestPlaceProtection_EmergencyClose(t *testing.T) {
+	tr := &flakyProtectionTrader{stopFailures: 5}
+	at := &AutoTrader{trader: tr, config: AutoTraderConfig{
+		ProtectionRetry: ProtectionRetryConfig{MaxAttempts: 2, RetryDelay: time.Millisecond, EmergencyClose: true},
+	}}
+
+	result := at.placeProtection("ETHUSDT", "SHORT", 1, 3100, 2800)
+	assert.Error(t, result.StopLossErr)
+	assert.True(t, result.EmergencyClosed)
+	assert.Error(t, result.protectionError())
+	assert.Equal(t, 2, tr.stopCalls)
+	assert.Equal(t, 1, tr.closedShort)
+	assert.Zero(t, tr.closedLong)
+	assert.Equal(t, 1, tr.cancelled, "撤销已挂出的止盈单")
+
+	// 只有止盈失败不平仓
+	tr = &flakyProtectionTrader{takeFailures: 5}
+	at.trader = tr
+	result = at.placeProtection("ETHUSDT", "SHORT", 1, 3100, 2800)
+	assert.Error(t, result.TakeProfitErr)
+	assert.False(t, result.EmergencyClosed)
+	assert.Zero(t, tr.closedShort)
+}
+
+// pairProtectionTrader 支持联动止盈止损的 flakyProtectionTrader
+type pairProtectionTrader struct {
+	flakyProtectionTrader
+	pairFailures, pairCalls int
+}
+
+func (p *pairProtectionTrader) SetStopLossAndTakeProfit(symbol, positionSide string, quantity, stopPrice, takeProfitPrice float64) error {
+	p.pairCalls++
+	if p.pairCalls <= p.pairFailures {
+		return errors.New("pair rejected")
+	}
+	return nil
+}
+
+func TestPlaceProtection_PrefersLinkedPair(t *testing.T) {
+	tr := &pairProtectionTrader{}
+	at := &AutoTrader{trader: tr}
+	result := at.placeProtection("BTCUSDT", "LONG", 0.1, 49000, 52000)
+	assert.NoError(t, result.StopLossErr)
+	assert.NoError(t, result.TakeProfitErr)
+	assert.Equal(t, 1, tr.pairCalls)
+	assert.Zero(t, tr.stopCalls)
+	assert.Zero(t, tr.takeCalls)
+
+	// 只有一侧时单独设置
+	result = at.placeProtection("BTCUSDT", "LONG", 0.1, 49000, 0)
+	assert.NoError(t, result.StopLossErr)
+	assert.Equal(t, 1, tr.pairCalls)
+	assert.Equal(t, 1, tr.stopCalls)
+
+	// 联动失败时退回分别设置
+	tr = &pairProtectionTrader{pairFailures: 1}
+	at.trader = tr
+	result = at.placeProtection("BTCUSDT", "LONG", 0.1, 49000, 52000)
+	assert.NoError(t, result.StopLossErr)
+	assert.NoError(t, result.TakeProfitErr)
+	assert.Equal(t, 1, tr.stopCalls)
+	assert.Equal(t, 1, tr.takeCalls)
+}
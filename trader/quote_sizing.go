@@ -0,0 +1,83 @@
+package trader
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// QuantityStepper 可查询交易对数量步进值的交易器
+type QuantityStepper interface {
+	QuantityStep(symbol string) (float64, error)
+}
+
+// QuoteSize 按计价金额换算出的下单数量
+type QuoteSize struct {
+	Quantity float64 // 基础币数量（已按步进值向下取整）
+	Price    float64 // 换算使用的最新价
+	Notional float64 // 实际名义价值 = Quantity × Price（不超过请求金额）
+}
+
+// floorToStep 向下取整到 step 的整数倍（容忍浮点误差，避免 0.3/0.1 得到 2.9999）
+func floorToStep(value, step float64) float64 {
+	if step <= 0 {
+		return value
+	}
+	return math.Floor(value/step+1e-9) * step
+}
+
+// QuoteToQuantity 将计价金额（如 500 USDT）按最新价换算为下单数量
+// 交易器实现 QuantityStepper 时按步进值向下取整，保证名义价值不超过请求金额；否则按 FormatQuantity 的精度取整
+func QuoteToQuantity(t Trader, symbol string, quoteAmount float64) (*QuoteSize, error) {
+	if quoteAmount <= 0 {
+		return nil, fmt.Errorf("无效的下单金额: %.2f", quoteAmount)
+	}
+	price, err := t.GetMarketPrice(symbol)
+	if err != nil {
+		return nil, fmt.Errorf("获取最新价失败: %w", err)
+	}
+	if price <= 0 {
+		return nil, fmt.Errorf("无效的价格: %.8f", price)
+	}
+
+	qty := quoteAmount / price
+	if stepper, ok := t.(QuantityStepper); ok {
+		step, err := stepper.QuantityStep(symbol)
+		if err != nil {
+			return nil, fmt.Errorf("获取数量步进失败: %w", err)
+		}
+		qty = floorToStep(qty, step)
+	}
+	// 统一经过 FormatQuantity，去掉浮点误差
+	qtyStr, err := t.FormatQuantity(symbol, qty)
+	if err != nil {
+		return nil, fmt.Errorf("格式化数量失败: %w", err)
+	}
+	if qty, err = strconv.ParseFloat(qtyStr, 64); err != nil {
+		return nil, fmt.Errorf("解析数量失败: %w", err)
+	}
+	if qty <= 0 {
+		return nil, fmt.Errorf("金额 %.2f 按价格 %.8f 换算后不足一个最小下单单位", quoteAmount, price)
+	}
+	return &QuoteSize{Quantity: qty, Price: price, Notional: qty * price}, nil
+}
+
+// OpenLongQuote 按计价金额开多仓（如 "开 500 美元的 SOL 多仓"）
+func OpenLongQuote(t Trader, symbol string, quoteAmount float64, leverage int) (map[string]interface{}, *QuoteSize, error) {
+	size, err := QuoteToQuantity(t, symbol, quoteAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := t.OpenLong(symbol, size.Quantity, leverage)
+	return result, size, err
+}
+
+// OpenShortQuote 按计价金额开空仓
+func OpenShortQuote(t Trader, symbol string, quoteAmount float64, leverage int) (map[string]interface{}, *QuoteSize, error) {
+	size, err := QuoteToQuantity(t, symbol, quoteAmount)
+	if err != nil {
+		return nil, nil, err
+	}
+	result, err := t.OpenShort(symbol, size.Quantity, leverage)
+	return result, size, err
+}
@@ -0,0 +1,67 @@
+package trader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// steppedTrader 带数量步进的 MockTrader（价格固定为 50000）
+type steppedTrader struct {
+	MockTrader
+	step   float64
+	opened float64
+}
+
+func (s *steppedTrader) QuantityStep(symbol string) (float64, error) {
+	return s.step, nil
+}
+
+func (s *steppedTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	s.opened = quantity
+	return s.MockTrader.OpenShort(symbol, quantity, leverage)
+}
+
+func TestFloorToStep(t *testing.T) {
+	assert.InDelta(t, 0.3, floorToStep(0.3, 0.1), 1e-12)
+	assert.InDelta(t, 0.2, floorToStep(0.29, 0.1), 1e-12)
+	assert.InDelta(t, 12, floorToStep(12.9, 1), 1e-12)
+	assert.Equal(t, 1.2345, floorToStep(1.2345, 0))
+}
+
+func TestQuoteToQuantity_FloorsToStep(t *testing.T) {
+	tr := &steppedTrader{step: 0.001}
+
+	size, err := QuoteToQuantity(tr, "BTCUSDT", 500)
+	require.NoError(t, err)
+	assert.Equal(t, 0.01, size.Quantity)
+	assert.Equal(t, 50000.0, size.Price)
+	assert.InDelta(t, 500, size.Notional, 1e-9)
+
+	// 499/50000 = 0.00998，向下取整到 0.009，名义价值不超过请求金额
+	size, err = QuoteToQuantity(tr, "BTCUSDT", 499)
+	require.NoError(t, err)
+	assert.Equal(t, 0.009, size.Quantity)
+	assert.LessOrEqual(t, size.Notional, 499.0)
+
+	_, err = QuoteToQuantity(tr, "BTCUSDT", 10)
+	assert.ErrorContains(t, err, "最小下单单位")
+	_, err = QuoteToQuantity(tr, "BTCUSDT", 0)
+	assert.Error(t, err)
+}
+
+func TestQuoteToQuantity_WithoutStepUsesFormatQuantity(t *testing.T) {
+	size, err := QuoteToQuantity(&MockTrader{}, "BTCUSDT", 499)
+	require.NoError(t, err)
+	assert.Equal(t, 0.01, size.Quantity) // MockTrader 按4位小数格式化
+}
+
+func TestOpenShortQuote(t *testing.T) {
+	tr := &steppedTrader{step: 0.0001}
+	result, size, err := OpenShortQuote(tr, "BTCUSDT", 1234, 5)
+	require.NoError(t, err)
+	assert.NotNil(t, result)
+	assert.Equal(t, 0.0246, size.Quantity)
+	assert.Equal(t, 0.0246, tr.opened)
+}
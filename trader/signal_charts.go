@@ -0,0 +1,39 @@
+package trader
+
+import (
+	"log"
+
+	"nofx/market"
+)
+
+// signalChartCandles 信号图展示的K线根数
+const signalChartCandles = 40
+
+// dumpSignalCharts 为强信号输出K线图：SVG写入目录，ASCII图随告警发出，路径留待开仓时附加到交易日志
+func (at *AutoTrader) dumpSignalCharts(signals []*market.TradingSignal) {
+	if at.signalCharts == nil || at.klineCache == nil {
+		return
+	}
+	for _, sig := range signals {
+		klines, err := at.klineCache.GetKlines(sig.Symbol, sig.TimeFrame, signalChartCandles)
+		if err != nil {
+			log.Printf("⚠️ 获取 %s %s K线失败，跳过信号图: %v", sig.Symbol, sig.TimeFrame, err)
+			continue
+		}
+		path, ascii, err := at.signalCharts.Dump(sig, klines)
+		if err != nil {
+			log.Printf("⚠️ 输出信号图失败: %v", err)
+			continue
+		}
+		at.signalChartPaths[sig.Symbol] = append(at.signalChartPaths[sig.Symbol], path)
+		at.alertf("强信号 %s %s %s %s @ %.4f（图: %s）\n%s",
+			sig.Symbol, sig.TimeFrame, sig.SignalType, sig.Direction, sig.Price, path, ascii)
+	}
+}
+
+// takeSignalCharts 取出并清空交易对最近的信号图路径
+func (at *AutoTrader) takeSignalCharts(symbol string) []string {
+	charts := at.signalChartPaths[symbol]
+	delete(at.signalChartPaths, symbol)
+	return charts
+}
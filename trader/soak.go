@@ -0,0 +1,466 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"nofx/market"
+)
+
+const (
+	defaultSoakCacheKlines   = 100
+	defaultSoakEventBuffer   = 256
+	defaultSoakSampleEvery   = 24 * 60 // 每个模拟日采样一次
+	defaultSoakMaxHeapGrowth = 2.0
+	soakGoroutineSlack       = 5 // 允许的协程数波动（GC、计时器等）
+)
+
+// soakTimeFrames 浸泡测试默认生成的周期（更高周期要运行数月才能填满缓存）
+var soakTimeFrames = []market.TimeFrame{market.TimeFrame5m, market.TimeFrame15m, market.TimeFrame1h}
+
+// SoakConfig 浸泡测试配置：合成行情驱动 K线缓存 → 收盘事件 → 信号检测 → 路由下单 → 订单/持仓推送 的完整链路
+type SoakConfig struct {
+	Market        market.SyntheticConfig // 合成行情（未指定周期时为 5m/15m/1h）
+	Minutes       int                    // 模拟分钟数（0 表示运行到 ctx 取消）
+	TickInterval  time.Duration          // 每模拟一分钟的真实间隔（0 表示尽快运行，每分钟等收盘事件处理完再推进，结果可复现）
+	CacheKlines   int                    // 每个周期缓存的K线数量（默认100）
+	EventBuffer   int                    // 收盘事件队列长度（默认256，满了丢弃）
+	OrderQuantity float64                // 每个信号的下单数量（默认1）
+	SampleEvery   int                    // 每隔多少模拟分钟采样一次（默认1440，即每个模拟日）
+	WarmupMinutes int                    // 预热分钟数，之后的第一次采样作为内存基线（默认与 SampleEvery 相同）
+	MaxHeapGrowth float64                // 相对基线允许的堆内存增长倍数（默认2）
+}
+
+// SoakSample 一次采样（采样前先 GC，堆内存为存活对象）
+type SoakSample struct {
+	Minute         int       `json:"minute"`
+	SimTime        time.Time `json:"sim_time"`
+	HeapAlloc      uint64    `json:"heap_alloc"`
+	HeapObjects    uint64    `json:"heap_objects"`
+	Goroutines     int       `json:"goroutines"`
+	CachedKlines   int       `json:"cached_klines"`
+	QueuedEvents   int       `json:"queued_events"`
+	PendingOrders  int       `json:"pending_orders"`
+	Subscribers    int       `json:"subscribers"` // 订单推送订阅者（每个未成交订单一个）
+	Positions      int       `json:"positions"`   // 持仓跟踪中的交易对
+	AuditDecisions int       `json:"audit_decisions"`
+}
+
+// SoakReport 浸泡测试结果
+type SoakReport struct {
+	Started       time.Time    `json:"started"`
+	Elapsed       string       `json:"elapsed"`
+	Minutes       int          `json:"minutes"`
+	Events        int          `json:"events"`
+	DroppedEvents int          `json:"dropped_events"`
+	Signals       int          `json:"signals"`
+	Orders        int          `json:"orders"`
+	Fills         int          `json:"fills"`
+	OrderErrors   int          `json:"order_errors"`
+	Samples       []SoakSample `json:"samples"`
+	Violations    []string     `json:"violations,omitempty"`
+
+	cfg SoakConfig
+}
+
+// Check 检查内存与各组件规模是否稳定，有问题时返回全部违规项
+func (r *SoakReport) Check() error {
+	if len(r.Violations) == 0 {
+		return nil
+	}
+	return fmt.Errorf("浸泡测试发现 %d 个问题: %s", len(r.Violations), strings.Join(r.Violations, "; "))
+}
+
+// RunSoak 运行浸泡测试，直到完成 cfg.Minutes 个模拟分钟或 ctx 取消，不依赖任何交易所
+func RunSoak(ctx context.Context, cfg SoakConfig) (*SoakReport, error) {
+	if len(cfg.Market.TimeFrames) == 0 {
+		cfg.Market.TimeFrames = soakTimeFrames
+	}
+	if cfg.CacheKlines <= 0 {
+		cfg.CacheKlines = defaultSoakCacheKlines
+	}
+	if cfg.EventBuffer <= 0 {
+		cfg.EventBuffer = defaultSoakEventBuffer
+	}
+	if cfg.OrderQuantity <= 0 {
+		cfg.OrderQuantity = 1
+	}
+	if cfg.SampleEvery <= 0 {
+		cfg.SampleEvery = defaultSoakSampleEvery
+	}
+	if cfg.WarmupMinutes <= 0 {
+		cfg.WarmupMinutes = cfg.SampleEvery
+	}
+	if cfg.MaxHeapGrowth <= 0 {
+		cfg.MaxHeapGrowth = defaultSoakMaxHeapGrowth
+	}
+
+	m, err := market.NewSyntheticMarket(cfg.Market)
+	if err != nil {
+		return nil, err
+	}
+	kc := market.NewKlineCache()
+	detector := market.NewSignalDetectorWithCache(kc)
+	stream := newBackpackAccountStream(&BackpackTrader{}, "")
+	venue := newPaperVenue(stream)
+	router, err := NewExecutionRouter(RouterConfig{}, Venue{Name: "paper", Trader: venue})
+	if err != nil {
+		return nil, err
+	}
+
+	report := &SoakReport{Started: time.Now(), cfg: cfg}
+	var mu sync.Mutex // 保护 report 计数（消费协程与主循环共同更新）
+
+	// 收盘事件总线：缓存在锁外回调，满了丢弃并计数
+	events := make(chan market.CandleClose, cfg.EventBuffer)
+	var inflight sync.WaitGroup // 已入队未处理完的事件
+	kc.SetCandleCloseHandler(func(e market.CandleClose) {
+		inflight.Add(1)
+		select {
+		case events <- e:
+			mu.Lock()
+			report.Events++
+			mu.Unlock()
+		default:
+			inflight.Done()
+			mu.Lock()
+			report.DroppedEvents++
+			mu.Unlock()
+		}
+	})
+
+	consumerDone := make(chan struct{})
+	go func() {
+		defer close(consumerDone)
+		for e := range events {
+			for _, sig := range detector.DetectAllSignals(e.Symbol, []market.TimeFrame{e.TimeFrame}) {
+				_, _, err := router.RouteSignal(sig, cfg.OrderQuantity, 1)
+				mu.Lock()
+				report.Signals++
+				if err != nil {
+					report.OrderErrors++
+				} else {
+					report.Orders++
+				}
+				mu.Unlock()
+			}
+			inflight.Done()
+		}
+	}()
+
+	sample := func(minute int) SoakSample {
+		runtime.GC()
+		var mem runtime.MemStats
+		runtime.ReadMemStats(&mem)
+		s := SoakSample{
+			Minute:       minute,
+			SimTime:      m.Now(),
+			HeapAlloc:    mem.HeapAlloc,
+			HeapObjects:  mem.HeapObjects,
+			Goroutines:   runtime.NumGoroutine(),
+			QueuedEvents: len(events),
+			// 先读订阅数再读挂单数：消费协程在两次读取之间下单只会让挂单数偏大，不会误报订阅泄漏
+			Subscribers:    stream.orders.count(),
+			PendingOrders:  venue.pendingCount(),
+			Positions:      len(stream.positions.snapshot()),
+			AuditDecisions: len(router.Audit()),
+		}
+		for _, metric := range kc.Metrics() {
+			s.CachedKlines += metric.Size
+		}
+		return s
+	}
+
+	log.Printf("🧪 [Soak] 开始浸泡测试: %d 个交易对, 周期 %v, 模拟 %d 分钟", len(m.Symbols()), m.TimeFrames(), cfg.Minutes)
+	minute := 0
+	for ; cfg.Minutes == 0 || minute < cfg.Minutes; minute++ {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := m.Feed(kc, cfg.CacheKlines); err != nil {
+			close(events)
+			<-consumerDone
+			return nil, err
+		}
+		if cfg.TickInterval == 0 {
+			inflight.Wait()
+		}
+		for _, symbol := range m.Symbols() {
+			price, _ := m.Price(symbol)
+			venue.setPrice(symbol, price)
+		}
+		// 已提交的信号订单按最新价成交
+		fills := venue.fillPending()
+		mu.Lock()
+		report.Fills += fills
+		mu.Unlock()
+
+		if (minute+1)%cfg.SampleEvery == 0 {
+			s := sample(minute + 1)
+			report.Samples = append(report.Samples, s)
+			log.Printf("🧪 [Soak] %s 堆内存 %.1fMB 协程 %d K线 %d 挂单 %d 订阅 %d",
+				s.SimTime.Format("2006-01-02 15:04"), float64(s.HeapAlloc)/1024/1024, s.Goroutines, s.CachedKlines, s.PendingOrders, s.Subscribers)
+		}
+		if cfg.TickInterval > 0 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(cfg.TickInterval):
+			}
+		}
+	}
+
+	close(events)
+	<-consumerDone
+	// 最后一分钟的信号订单在收尾时成交，结束时不留挂单
+	report.Fills += venue.fillPending()
+	report.Minutes = minute
+	report.Elapsed = time.Since(report.Started).Round(time.Millisecond).String()
+	report.Violations = report.check(m, venue)
+	return report, nil
+}
+
+// check 采样结果与结构上限比对
+func (r *SoakReport) check(m *market.SyntheticMarket, venue *paperVenue) []string {
+	var violations []string
+	maxKlines := r.cfg.CacheKlines * len(m.TimeFrames()) * len(m.Symbols())
+	var baseline *SoakSample
+	for i := range r.Samples {
+		s := &r.Samples[i]
+		if s.CachedKlines > maxKlines {
+			violations = append(violations, fmt.Sprintf("第 %d 分钟缓存K线 %d 超过上限 %d", s.Minute, s.CachedKlines, maxKlines))
+		}
+		if s.Subscribers > s.PendingOrders {
+			violations = append(violations, fmt.Sprintf("第 %d 分钟订单推送订阅 %d 多于未成交订单 %d（订阅泄漏）", s.Minute, s.Subscribers, s.PendingOrders))
+		}
+		if s.Positions > len(m.Symbols()) {
+			violations = append(violations, fmt.Sprintf("第 %d 分钟持仓跟踪 %d 个交易对，超过 %d", s.Minute, s.Positions, len(m.Symbols())))
+		}
+		if s.AuditDecisions > defaultRouterAuditSize {
+			violations = append(violations, fmt.Sprintf("第 %d 分钟路由审计记录 %d 超过上限 %d", s.Minute, s.AuditDecisions, defaultRouterAuditSize))
+		}
+
+		if s.Minute < r.cfg.WarmupMinutes {
+			continue
+		}
+		if baseline == nil {
+			baseline = s
+			continue
+		}
+		if float64(s.HeapAlloc) > float64(baseline.HeapAlloc)*r.cfg.MaxHeapGrowth {
+			violations = append(violations, fmt.Sprintf("第 %d 分钟堆内存 %d 超过基线 %d 的 %.1f 倍", s.Minute, s.HeapAlloc, baseline.HeapAlloc, r.cfg.MaxHeapGrowth))
+		}
+		if s.Goroutines > baseline.Goroutines+soakGoroutineSlack {
+			violations = append(violations, fmt.Sprintf("第 %d 分钟协程 %d 多于基线 %d（协程泄漏）", s.Minute, s.Goroutines, baseline.Goroutines))
+		}
+	}
+	if pending := venue.pendingCount(); pending > 0 {
+		violations = append(violations, fmt.Sprintf("结束时仍有 %d 个未成交订单", pending))
+	}
+	return violations
+}
+
+// count 当前订阅者数量
+func (h *orderUpdateHub) count() int {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return len(h.subs)
+}
+
+// paperOrder 模拟交易所的未成交市价单
+type paperOrder struct {
+	id       string
+	symbol   string
+	side     string // Bid / Ask
+	quantity float64
+}
+
+// paperVenue 浸泡测试的模拟交易所：市价单在下一分钟按合成价格成交，
+// 成交和持仓变化以 Backpack 推送格式经账户推送分发，驱动订单订阅和持仓跟踪
+type paperVenue struct {
+	stream *BackpackAccountStream
+
+	mu        sync.Mutex
+	seq       int
+	prices    map[string]float64
+	pending   []paperOrder
+	positions map[string]float64 // 净持仓
+	entries   map[string]float64 // 持仓均价
+}
+
+func newPaperVenue(stream *BackpackAccountStream) *paperVenue {
+	return &paperVenue{
+		stream:    stream,
+		prices:    make(map[string]float64),
+		positions: make(map[string]float64),
+		entries:   make(map[string]float64),
+	}
+}
+
+func (v *paperVenue) setPrice(symbol string, price float64) {
+	v.mu.Lock()
+	v.prices[symbol] = price
+	v.mu.Unlock()
+}
+
+func (v *paperVenue) pendingCount() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return len(v.pending)
+}
+
+// submit 登记市价单并订阅其推送，成交后取消订阅（与 BackpackTrader 等待成交的订阅方式相同）
+func (v *paperVenue) submit(symbol, side string, quantity float64) (map[string]interface{}, error) {
+	if quantity <= 0 {
+		return nil, fmt.Errorf("无效的数量: %.8f", quantity)
+	}
+	symbol = market.Normalize(symbol)
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if _, ok := v.prices[symbol]; !ok {
+		return nil, fmt.Errorf("未知的交易对: %s", symbol)
+	}
+	v.seq++
+	order := paperOrder{id: strconv.Itoa(v.seq), symbol: symbol, side: side, quantity: quantity}
+
+	// 先订阅再登记：成交推送只会在登记之后发出，此时 unsubscribe 已赋值
+	var once sync.Once
+	var unsubscribe func()
+	unsubscribe = v.stream.orders.subscribe(func(u OrderUpdate) {
+		if u.OrderID == order.id && u.Status == "Filled" {
+			once.Do(unsubscribe)
+		}
+	})
+	v.pending = append(v.pending, order)
+	return map[string]interface{}{"orderId": order.id, "symbol": symbol, "status": "New"}, nil
+}
+
+// fillPending 按当前价格成交全部未成交订单，返回成交数量
+func (v *paperVenue) fillPending() int {
+	v.mu.Lock()
+	orders := v.pending
+	v.pending = nil
+	type fill struct {
+		order paperOrder
+		price float64
+		net   float64
+		entry float64
+		pnl   float64
+	}
+	fills := make([]fill, 0, len(orders))
+	for _, o := range orders {
+		price := v.prices[o.symbol]
+		delta := o.quantity
+		if o.side == "Ask" {
+			delta = -delta
+		}
+		prev := v.positions[o.symbol]
+		net := prev + delta
+		switch {
+		case net == 0:
+			delete(v.positions, o.symbol)
+			delete(v.entries, o.symbol)
+		case prev == 0 || (prev > 0) != (net > 0):
+			// 新开仓或反手，均价为成交价
+			v.positions[o.symbol], v.entries[o.symbol] = net, price
+		case (prev > 0) == (delta > 0):
+			v.entries[o.symbol] = (v.entries[o.symbol]*prev + price*delta) / net
+			v.positions[o.symbol] = net
+		default:
+			v.positions[o.symbol] = net
+		}
+		entry := v.entries[o.symbol]
+		fills = append(fills, fill{order: o, price: price, net: net, entry: entry, pnl: (price - entry) * net})
+	}
+	v.mu.Unlock()
+
+	// 推送在锁外分发（订阅回调可能调用 venue 方法）
+	for _, f := range fills {
+		v.publish("account.orderUpdate", OrderUpdate{
+			Event: "orderFill", EventTime: time.Now().UnixMicro(), Symbol: f.order.symbol, Side: f.order.side,
+			OrderType: "Market", Status: "Filled", OrderID: f.order.id,
+			Quantity: FlexFloat(f.order.quantity), ExecutedQuantity: FlexFloat(f.order.quantity),
+			ExecutedQuoteQuantity: FlexFloat(f.order.quantity * f.price),
+			FillQuantity:          FlexFloat(f.order.quantity), FillPrice: FlexFloat(f.price),
+		})
+		event := "positionAdjusted"
+		if f.net == 0 {
+			event = "positionClosed"
+		}
+		v.publish("account.positionUpdate", backpackPositionUpdate{
+			Event: event, Symbol: f.order.symbol, NetQuantity: FlexFloat(f.net), EntryPrice: FlexFloat(f.entry),
+			MarkPrice: FlexFloat(f.price), PnlUnrealized: FlexFloat(f.pnl), IMF: 1,
+		})
+	}
+	return len(fills)
+}
+
+// publish 按交易所推送格式编码后交给账户推送处理（与真实推送走相同的解析路径）
+func (v *paperVenue) publish(stream string, data interface{}) {
+	payload, err := json.Marshal(map[string]interface{}{"stream": stream, "data": data})
+	if err != nil {
+		log.Printf("⚠️ [Soak] 编码推送失败: %v", err)
+		return
+	}
+	v.stream.handleMessage(payload)
+}
+
+func (v *paperVenue) GetBalance() (map[string]interface{}, error) {
+	return map[string]interface{}{"totalWalletBalance": 0.0, "availableBalance": 0.0}, nil
+}
+
+func (v *paperVenue) GetPositions() ([]map[string]interface{}, error) {
+	return positionMaps(v.stream.positions.snapshot()), nil
+}
+
+func (v *paperVenue) OpenLong(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return v.submit(symbol, "Bid", quantity)
+}
+
+func (v *paperVenue) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	return v.submit(symbol, "Ask", quantity)
+}
+
+func (v *paperVenue) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	return v.submit(symbol, "Ask", quantity)
+}
+
+func (v *paperVenue) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	return v.submit(symbol, "Bid", quantity)
+}
+
+func (v *paperVenue) SetLeverage(symbol string, leverage int) error         { return nil }
+func (v *paperVenue) SetMarginMode(symbol string, isCrossMargin bool) error { return nil }
+
+func (v *paperVenue) GetMarketPrice(symbol string) (float64, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	price, ok := v.prices[market.Normalize(symbol)]
+	if !ok {
+		return 0, fmt.Errorf("未知的交易对: %s", symbol)
+	}
+	return price, nil
+}
+
+func (v *paperVenue) SetStopLoss(symbol string, positionSide string, quantity, stopPrice float64) error {
+	return nil
+}
+
+func (v *paperVenue) SetTakeProfit(symbol string, positionSide string, quantity, takeProfitPrice float64) error {
+	return nil
+}
+
+func (v *paperVenue) CancelStopLossOrders(symbol string) error   { return nil }
+func (v *paperVenue) CancelTakeProfitOrders(symbol string) error { return nil }
+func (v *paperVenue) CancelAllOrders(symbol string) error        { return nil }
+func (v *paperVenue) CancelStopOrders(symbol string) error       { return nil }
+
+func (v *paperVenue) FormatQuantity(symbol string, quantity float64) (string, error) {
+	return strconv.FormatFloat(quantity, 'f', -1, 64), nil
+}
@@ -0,0 +1,106 @@
+package trader
+
+import (
+	"context"
+	"io"
+	"log"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"nofx/market"
+)
+
+func TestRunSoak(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+
+	start := time.Now().UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	report, err := RunSoak(context.Background(), SoakConfig{
+		Market:      market.SyntheticConfig{Symbols: []string{"BTC_USDC", "ETHUSDT"}, Seed: 7, Start: start},
+		Minutes:     3 * 24 * 60,
+		CacheKlines: 50,
+		SampleEvery: 12 * 60,
+	})
+	require.NoError(t, err)
+	require.NoError(t, report.Check())
+
+	assert.Equal(t, 3*24*60, report.Minutes)
+	assert.Len(t, report.Samples, 6)
+	assert.Positive(t, report.Events)
+	assert.Zero(t, report.DroppedEvents)
+	assert.Positive(t, report.Signals)
+	assert.Positive(t, report.Orders)
+	assert.Zero(t, report.OrderErrors)
+	assert.Equal(t, report.Orders, report.Fills, "每笔订单都在下一分钟成交")
+
+	last := report.Samples[len(report.Samples)-1]
+	assert.Equal(t, start.Add(3*24*time.Hour), last.SimTime)
+	assert.LessOrEqual(t, last.CachedKlines, 50*3*2)
+	assert.LessOrEqual(t, last.Positions, 2)
+	assert.Equal(t, last.PendingOrders, last.Subscribers)
+}
+
+func TestRunSoakStopsOnCancel(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	report, err := RunSoak(ctx, SoakConfig{TickInterval: time.Millisecond})
+	require.NoError(t, err)
+	assert.Positive(t, report.Minutes)
+	assert.Less(t, report.Minutes, 1000)
+}
+
+func TestSoakReportCheck(t *testing.T) {
+	report := &SoakReport{
+		cfg: SoakConfig{CacheKlines: 10, WarmupMinutes: 10, MaxHeapGrowth: 2},
+		Samples: []SoakSample{
+			{Minute: 10, HeapAlloc: 1000, Goroutines: 3},
+			{Minute: 20, HeapAlloc: 2500, Goroutines: 20, Subscribers: 4, PendingOrders: 1},
+		},
+	}
+	m, err := market.NewSyntheticMarket(market.SyntheticConfig{TimeFrames: []market.TimeFrame{market.TimeFrame5m}})
+	require.NoError(t, err)
+	report.Violations = report.check(m, newPaperVenue(nil))
+
+	err = report.Check()
+	require.Error(t, err)
+	assert.Len(t, report.Violations, 3)
+	assert.Contains(t, err.Error(), "订阅泄漏")
+	assert.Contains(t, err.Error(), "协程泄漏")
+}
+
+func TestPaperVenueFillsThroughAccountStream(t *testing.T) {
+	output := log.Writer()
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(output)
+
+	stream := newBackpackAccountStream(&BackpackTrader{}, "")
+	venue := newPaperVenue(stream)
+	venue.setPrice("BTCUSDT", 100)
+
+	_, err := venue.OpenLong("BTCUSDT", 2, 1)
+	require.NoError(t, err)
+	_, err = venue.OpenLong("DOGEUSDT", 1, 1)
+	require.Error(t, err)
+	assert.Equal(t, 1, stream.orders.count())
+
+	venue.setPrice("BTCUSDT", 110)
+	assert.Equal(t, 1, venue.fillPending())
+	assert.Zero(t, stream.orders.count())
+	positions := stream.positions.snapshot()
+	require.Len(t, positions, 1)
+	assert.Equal(t, 2.0, positions[0].NetQuantity.Float64())
+	assert.Equal(t, 110.0, positions[0].EntryPrice.Float64())
+
+	_, err = venue.CloseLong("BTCUSDT", 2)
+	require.NoError(t, err)
+	assert.Equal(t, 1, venue.fillPending())
+	assert.Empty(t, stream.positions.snapshot())
+}
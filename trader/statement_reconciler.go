@@ -0,0 +1,274 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStatementTolerance = 1.0    // 默认允许的绝对偏差（USDC）
+	statementQuoteSymbol      = "USDC" // 对账的计价币种
+)
+
+// StatementReconcilerConfig 账户对账：定期用成交、资金费、手续费和充提记录推算权益，与交易所报告的 netEquity 比对
+// 偏差超过容差说明漏记了成交或解析有误
+type StatementReconcilerConfig struct {
+	Interval     time.Duration // 对账间隔（0 表示不启用）
+	Tolerance    float64       // 允许的绝对偏差（USDC，默认1）
+	TolerancePct float64       // 允许的相对偏差（占期初权益的百分比），与 Tolerance 取较大值
+}
+
+// Enabled 是否启用对账
+func (c StatementReconcilerConfig) Enabled() bool {
+	return c.Interval > 0
+}
+
+// tolerance 期初权益为 equity 时允许的偏差
+func (c StatementReconcilerConfig) tolerance(equity float64) float64 {
+	tol := c.Tolerance
+	if tol <= 0 {
+		tol = defaultStatementTolerance
+	}
+	return math.Max(tol, math.Abs(equity)*c.TolerancePct/100)
+}
+
+// statementSnapshot 某时刻的账户权益与持仓
+type statementSnapshot struct {
+	Time      time.Time
+	Equity    float64
+	Positions map[string]positionMark // 交易所格式交易对 -> 持仓
+}
+
+// positionMark 带符号持仓数量与标记价格
+type positionMark struct {
+	Quantity  float64
+	MarkPrice float64
+}
+
+// StatementReport 一个对账周期的结果
+// 预期权益 = 期初权益 + 交易盈亏 - 手续费 + 资金费 + 充值 - 提现
+// 交易盈亏按盯市计算：Σ(期末持仓×期末标记价 - 期初持仓×期初标记价) - Σ(带符号成交数量×成交价)
+type StatementReport struct {
+	From           time.Time `json:"from"`
+	To             time.Time `json:"to"`
+	StartEquity    float64   `json:"start_equity"`
+	ReportedEquity float64   `json:"reported_equity"` // 交易所报告的期末权益
+	ExpectedEquity float64   `json:"expected_equity"` // 按流水推算的期末权益
+	Discrepancy    float64   `json:"discrepancy"`     // 报告 - 推算
+	Tolerance      float64   `json:"tolerance"`
+	Flagged        bool      `json:"flagged"` // 偏差超过容差
+	TradingPnL     float64   `json:"trading_pnl"`
+	Fees           float64   `json:"fees"`
+	Funding        float64   `json:"funding"`
+	Deposits       float64   `json:"deposits"`
+	Withdrawals    float64   `json:"withdrawals"`
+	Fills          int       `json:"fills"`
+	Unpriced       int       `json:"unpriced"` // 无法折算为USDC而未计入的记录（现货成交、非USDC手续费和充提）
+}
+
+// computeStatement 按期初/期末快照和期间流水推算期末权益
+// 只计入永续合约成交；非USDC计价的手续费和充提无法折算，计入 Unpriced，其影响会体现在偏差中
+func computeStatement(start, end statementSnapshot, fills []Fill, funding []FundingPayment, deposits []Deposit, withdrawals []Withdrawal) StatementReport {
+	report := StatementReport{From: start.Time, To: end.Time, StartEquity: start.Equity, ReportedEquity: end.Equity}
+
+	for _, pos := range end.Positions {
+		report.TradingPnL += pos.Quantity * pos.MarkPrice
+	}
+	for _, pos := range start.Positions {
+		report.TradingPnL -= pos.Quantity * pos.MarkPrice
+	}
+	for _, f := range fills {
+		if !strings.HasSuffix(f.Symbol, "_PERP") {
+			report.Unpriced++
+			continue
+		}
+		report.Fills++
+		qty := f.Quantity.Float64()
+		if f.Side == "Ask" {
+			qty = -qty
+		}
+		report.TradingPnL -= qty * f.Price.Float64()
+		if f.FeeSymbol == "" || f.FeeSymbol == statementQuoteSymbol {
+			report.Fees += f.Fee.Float64()
+		} else if f.Fee.Float64() != 0 {
+			report.Unpriced++
+		}
+	}
+	for _, p := range funding {
+		report.Funding += p.Payment()
+	}
+	for _, d := range deposits {
+		if !strings.EqualFold(d.Status, "confirmed") {
+			continue
+		}
+		if d.Symbol != statementQuoteSymbol {
+			report.Unpriced++
+			continue
+		}
+		report.Deposits += d.Quantity.Float64()
+	}
+	for _, w := range withdrawals {
+		// 被拒绝或取消的提现不会扣款；提现手续费是否另扣因链而异，不计入
+		if status := strings.ToLower(w.Status); status == "declined" || status == "cancelled" || status == "refunded" {
+			continue
+		}
+		if w.Symbol != statementQuoteSymbol {
+			report.Unpriced++
+			continue
+		}
+		report.Withdrawals += w.Quantity.Float64()
+	}
+
+	report.ExpectedEquity = start.Equity + report.TradingPnL - report.Fees + report.Funding + report.Deposits - report.Withdrawals
+	report.Discrepancy = end.Equity - report.ExpectedEquity
+	return report
+}
+
+// StatementReconciler 定期对账（Backpack），每个周期以上次的期末快照为期初，偏差不会累积到后续周期
+type StatementReconciler struct {
+	t   *BackpackTrader
+	cfg StatementReconcilerConfig
+
+	mu       sync.Mutex
+	baseline *statementSnapshot
+	last     *StatementReport
+}
+
+// NewStatementReconciler 创建对账器
+func NewStatementReconciler(t *BackpackTrader, cfg StatementReconcilerConfig) *StatementReconciler {
+	return &StatementReconciler{t: t, cfg: cfg}
+}
+
+// Reconcile 拍摄当前快照并与上次快照之间的流水对账；首次调用只记录期初快照，返回 nil 报告
+func (r *StatementReconciler) Reconcile(ctx context.Context) (*StatementReport, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	end, err := r.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	start := r.baseline
+	if start == nil {
+		r.baseline = end
+		log.Printf("🧾 [对账] 记录期初权益 %.2f USDC", end.Equity)
+		return nil, nil
+	}
+
+	fills, err := r.t.GetFillsWithContext(ctx, "", start.Time, 0)
+	if err != nil {
+		return nil, err
+	}
+	funding, err := r.t.GetFundingPaymentsWithContext(ctx, "", start.Time, 0)
+	if err != nil {
+		return nil, err
+	}
+	deposits, err := r.t.GetDepositsWithContext(ctx, start.Time, end.Time)
+	if err != nil {
+		return nil, err
+	}
+	withdrawals, err := r.t.GetWithdrawalsWithContext(ctx, start.Time, end.Time)
+	if err != nil {
+		return nil, err
+	}
+
+	report := computeStatement(*start, *end, inWindow(fills, end.Time, Fill.Time), inWindow(funding, end.Time, FundingPayment.Time), deposits, withdrawals)
+	report.Tolerance = r.cfg.tolerance(start.Equity)
+	report.Flagged = math.Abs(report.Discrepancy) > report.Tolerance
+
+	r.baseline = end
+	r.last = &report
+	log.Printf("🧾 [对账] %s ~ %s 推算权益 %.2f，交易所 %.2f，偏差 %.2f（交易盈亏 %.2f 手续费 %.2f 资金费 %.2f 成交 %d 笔）",
+		report.From.Format("01-02 15:04"), report.To.Format("01-02 15:04"), report.ExpectedEquity, report.ReportedEquity,
+		report.Discrepancy, report.TradingPnL, report.Fees, report.Funding, report.Fills)
+	return &report, nil
+}
+
+// LastReport 最近一次对账结果（尚未完成对账时返回 nil）
+func (r *StatementReconciler) LastReport() *StatementReport {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.last
+}
+
+// snapshot 读取当前权益和持仓（不使用账户推送和响应缓存）
+func (r *StatementReconciler) snapshot(ctx context.Context) (*statementSnapshot, error) {
+	now := time.Now()
+	var balance Balance
+	if err := r.t.requestJSON(ctx, "GET", "/api/v1/capital/collateral", nil, nil, &balance); err != nil {
+		return nil, fmt.Errorf("获取权益失败: %w", err)
+	}
+	positions, err := r.t.OpenPositions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	snap := &statementSnapshot{Time: now, Equity: balance.NetEquity.Float64(), Positions: make(map[string]positionMark, len(positions))}
+	for _, pos := range positions {
+		snap.Positions[pos.Symbol] = positionMark{Quantity: pos.NetQuantity.Float64(), MarkPrice: pos.MarkPrice.Float64()}
+	}
+	return snap, nil
+}
+
+// inWindow 过滤掉 end 之后的记录（历史接口只按起始时间查询）
+func inWindow[T any](items []T, end time.Time, at func(T) time.Time) []T {
+	out := items[:0]
+	for _, item := range items {
+		if !at(item).After(end) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+// startStatementReconciler 启动定期对账（未配置或交易所不支持时跳过）
+func (at *AutoTrader) startStatementReconciler() {
+	if at.statementReconciler == nil {
+		return
+	}
+	at.monitorWg.Add(1)
+	go func() {
+		defer at.monitorWg.Done()
+		log.Printf("🧾 启动账户对账（每 %v 一次）", at.config.StatementReconcile.Interval)
+		at.reconcileStatement()
+
+		ticker := time.NewTicker(at.config.StatementReconcile.Interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				at.reconcileStatement()
+			case <-at.stopMonitorCh:
+				log.Println("⏹ 停止账户对账")
+				return
+			}
+		}
+	}()
+}
+
+// reconcileStatement 对账一次，偏差超过容差时告警
+func (at *AutoTrader) reconcileStatement() {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	report, err := at.statementReconciler.Reconcile(ctx)
+	if err != nil {
+		log.Printf("⚠️ 账户对账失败: %v", err)
+		return
+	}
+	if report != nil && report.Flagged {
+		at.alertf("🧾 账户对账偏差 %.2f USDC 超过容差 %.2f（推算 %.2f，交易所 %.2f），请检查是否漏记成交",
+			report.Discrepancy, report.Tolerance, report.ExpectedEquity, report.ReportedEquity)
+	}
+}
+
+// GetStatementReport 最近一次对账结果（用于API；未启用对账时返回错误）
+func (at *AutoTrader) GetStatementReport() (*StatementReport, error) {
+	if at.statementReconciler == nil {
+		return nil, fmt.Errorf("未启用账户对账")
+	}
+	return at.statementReconciler.LastReport(), nil
+}
@@ -0,0 +1,108 @@
+package trader
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeStatement(t *testing.T) {
+	start := statementSnapshot{Equity: 1000, Positions: map[string]positionMark{}}
+	end := statementSnapshot{Equity: 1044.3, Positions: map[string]positionMark{"SOL_USDC_PERP": {Quantity: 1, MarkPrice: 105}}}
+	fills := []Fill{
+		{Symbol: "SOL_USDC_PERP", Side: "Bid", Price: 100, Quantity: 2, Fee: 0.1, FeeSymbol: "USDC"},
+		{Symbol: "SOL_USDC_PERP", Side: "Ask", Price: 110, Quantity: 1, Fee: 0.1, FeeSymbol: "USDC"},
+		{Symbol: "SOL_USDC", Side: "Bid", Price: 100, Quantity: 1},
+	}
+	funding := []FundingPayment{{Symbol: "SOL_USDC_PERP", Quantity: -0.5}}
+	deposits := []Deposit{
+		{Symbol: "USDC", Quantity: 50, Status: "confirmed"},
+		{Symbol: "USDC", Quantity: 500, Status: "pending"},
+	}
+	withdrawals := []Withdrawal{
+		{Symbol: "USDC", Quantity: 20, Status: "confirmed"},
+		{Symbol: "USDC", Quantity: 70, Status: "declined"},
+	}
+
+	report := computeStatement(start, end, fills, funding, deposits, withdrawals)
+	// 已实现 10 + 未实现 5
+	assert.InDelta(t, 15, report.TradingPnL, 1e-9)
+	assert.InDelta(t, 0.2, report.Fees, 1e-9)
+	assert.InDelta(t, -0.5, report.Funding, 1e-9)
+	assert.InDelta(t, 50, report.Deposits, 1e-9)
+	assert.InDelta(t, 20, report.Withdrawals, 1e-9)
+	assert.InDelta(t, 1044.3, report.ExpectedEquity, 1e-9)
+	assert.InDelta(t, 0, report.Discrepancy, 1e-9)
+	assert.Equal(t, 2, report.Fills)
+	assert.Equal(t, 1, report.Unpriced, "现货成交无法按合约盯市计入")
+}
+
+func TestStatementReconcilerConfig_Tolerance(t *testing.T) {
+	assert.Equal(t, 1.0, StatementReconcilerConfig{}.tolerance(10000))
+	assert.Equal(t, 5.0, StatementReconcilerConfig{Tolerance: 2, TolerancePct: 0.05}.tolerance(10000))
+	assert.Equal(t, 2.0, StatementReconcilerConfig{Tolerance: 2, TolerancePct: 0.05}.tolerance(1000))
+}
+
+func TestStatementReconciler_Reconcile(t *testing.T) {
+	var mu sync.Mutex
+	equity, position, fills := "1000", "[]", "[]"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/api/v1/capital/collateral":
+			w.Write([]byte(`{"netEquity":"` + equity + `"}`))
+		case "/api/v1/position":
+			w.Write([]byte(position))
+		case "/wapi/v1/history/fills":
+			w.Write([]byte(fills))
+		case "/wapi/v1/history/funding", "/wapi/v1/capital/deposits", "/wapi/v1/capital/withdrawals":
+			w.Write([]byte(`[]`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+	r := NewStatementReconciler(newTestBackpackTrader(t, server.URL), StatementReconcilerConfig{Interval: time.Hour, Tolerance: 1})
+
+	// 首次只记录期初快照
+	report, err := r.Reconcile(context.Background())
+	require.NoError(t, err)
+	assert.Nil(t, report)
+	assert.Nil(t, r.LastReport())
+
+	time.Sleep(2 * time.Millisecond)
+	ts := strconv.FormatInt(time.Now().UnixMilli(), 10)
+	time.Sleep(2 * time.Millisecond)
+	mu.Lock()
+	fills = fmt.Sprintf(`[{"symbol":"SOL_USDC_PERP","side":"Bid","price":"100","quantity":"1","fee":"0.05","feeSymbol":"USDC","timestamp":"%s"}]`, ts)
+	position = `[{"symbol":"SOL_USDC_PERP","netQuantity":"1","markPrice":"103"}]`
+	equity = "1002.95"
+	mu.Unlock()
+
+	report, err = r.Reconcile(context.Background())
+	require.NoError(t, err)
+	require.NotNil(t, report)
+	assert.InDelta(t, 1002.95, report.ExpectedEquity, 1e-9)
+	assert.False(t, report.Flagged)
+	assert.Equal(t, report, r.LastReport())
+
+	// 漏记的成交：交易所权益与推算偏差超过容差
+	mu.Lock()
+	fills = `[]`
+	equity = "990"
+	mu.Unlock()
+	report, err = r.Reconcile(context.Background())
+	require.NoError(t, err)
+	assert.InDelta(t, -12.95, report.Discrepancy, 1e-9)
+	assert.True(t, report.Flagged)
+}
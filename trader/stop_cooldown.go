@@ -0,0 +1,58 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"nofx/market"
+)
+
+// StopCooldownConfig 止损后冷却：同一交易对同一方向在若干根K线内禁止再次开仓，避免在失败形态上立即报复性入场
+type StopCooldownConfig struct {
+	Candles   int              // 冷却K线根数（不含止损所在K线，0 表示不启用）
+	TimeFrame market.TimeFrame // 冷却计数的K线周期（默认5m，与决策周期一致）
+}
+
+// period 冷却K线周期时长
+func (c StopCooldownConfig) period() time.Duration {
+	minutes := market.TimeFrameMinutes[c.TimeFrame]
+	if minutes <= 0 {
+		minutes = market.TimeFrameMinutes[market.TimeFrame5m]
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// Until 在 stoppedAt 止损后，冷却结束的时间（止损所在K线之后再经过 Candles 根完整K线）
+func (c StopCooldownConfig) Until(stoppedAt time.Time) time.Time {
+	period := c.period()
+	return stoppedAt.Truncate(period).Add(time.Duration(c.Candles+1) * period)
+}
+
+// recordStopOut 记录止损离场，开始冷却
+func (at *AutoTrader) recordStopOut(symbol, side string, stoppedAt time.Time) {
+	cfg := at.config.StopCooldown
+	if cfg.Candles <= 0 {
+		return
+	}
+	if at.stopCooldowns == nil {
+		at.stopCooldowns = make(map[string]time.Time)
+	}
+	until := cfg.Until(stoppedAt)
+	at.stopCooldowns[symbol+"_"+side] = until
+	log.Printf("  🧊 %s %s 止损离场，%s 前禁止同方向开仓", symbol, side, until.Format("15:04"))
+}
+
+// checkStopCooldown 冷却期内拒绝同方向开仓
+func (at *AutoTrader) checkStopCooldown(symbol, side string, now time.Time) error {
+	posKey := symbol + "_" + side
+	until, ok := at.stopCooldowns[posKey]
+	if !ok {
+		return nil
+	}
+	if !now.Before(until) {
+		delete(at.stopCooldowns, posKey)
+		return nil
+	}
+	return fmt.Errorf("❌ %s %s 止损后冷却中（剩余 %.0f 分钟），拒绝开仓", symbol, side, until.Sub(now).Minutes())
+}
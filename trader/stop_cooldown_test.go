@@ -0,0 +1,40 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStopCooldownConfig_Until(t *testing.T) {
+	stoppedAt := time.Date(2026, 10, 15, 10, 7, 30, 0, time.UTC)
+
+	// 止损所在K线（10:05）之后再经过3根5分钟K线
+	cfg := StopCooldownConfig{Candles: 3}
+	assert.Equal(t, time.Date(2026, 10, 15, 10, 25, 0, 0, time.UTC), cfg.Until(stoppedAt))
+
+	cfg = StopCooldownConfig{Candles: 2, TimeFrame: market.TimeFrame1h}
+	assert.Equal(t, time.Date(2026, 10, 15, 13, 0, 0, 0, time.UTC), cfg.Until(stoppedAt))
+}
+
+func TestAutoTrader_StopCooldown(t *testing.T) {
+	stoppedAt := time.Date(2026, 10, 15, 10, 7, 0, 0, time.UTC)
+	at := &AutoTrader{config: AutoTraderConfig{StopCooldown: StopCooldownConfig{Candles: 2}}}
+	at.recordStopOut("BTCUSDT", "long", stoppedAt)
+
+	assert.Error(t, at.checkStopCooldown("BTCUSDT", "long", stoppedAt.Add(10*time.Minute)))
+	assert.NoError(t, at.checkStopCooldown("BTCUSDT", "short", stoppedAt.Add(10*time.Minute)), "反方向不受限制")
+	assert.NoError(t, at.checkStopCooldown("ETHUSDT", "long", stoppedAt.Add(10*time.Minute)))
+
+	// 10:20 冷却结束
+	assert.NoError(t, at.checkStopCooldown("BTCUSDT", "long", time.Date(2026, 10, 15, 10, 20, 0, 0, time.UTC)))
+	assert.Empty(t, at.stopCooldowns)
+
+	// 未启用时不记录
+	at = &AutoTrader{}
+	at.recordStopOut("BTCUSDT", "long", stoppedAt)
+	assert.NoError(t, at.checkStopCooldown("BTCUSDT", "long", stoppedAt))
+}
@@ -0,0 +1,73 @@
+package trader
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"nofx/market"
+)
+
+// CleanupSymbol 停止交易某个交易对前的清理：撤销全部挂单，closePositions 为 true 时市价平掉该交易对的持仓
+// 各步骤独立执行，失败不会中断后续步骤，最后合并返回错误
+func CleanupSymbol(t Trader, symbol string, closePositions bool) error {
+	var errs []error
+	if err := t.CancelAllOrders(symbol); err != nil {
+		errs = append(errs, fmt.Errorf("撤销挂单失败: %w", err))
+	}
+
+	if closePositions {
+		positions, err := t.GetPositions()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("获取持仓失败: %w", err))
+		}
+		normalized := market.Normalize(symbol)
+		for _, pos := range positions {
+			posSymbol, _ := pos["symbol"].(string)
+			if market.Normalize(posSymbol) != normalized {
+				continue
+			}
+			side, _ := pos["side"].(string)
+			switch side {
+			case "long":
+				_, err = t.CloseLong(symbol, 0)
+			case "short":
+				_, err = t.CloseShort(symbol, 0)
+			default:
+				continue
+			}
+			if err != nil {
+				errs = append(errs, fmt.Errorf("平%s仓失败: %w", side, err))
+				continue
+			}
+			log.Printf("🧹 [Cleanup] %s 已平%s仓", symbol, side)
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("清理 %s 失败: %w", symbol, errors.Join(errs...))
+	}
+	log.Printf("🧹 [Cleanup] %s 清理完成（平仓: %v）", symbol, closePositions)
+	return nil
+}
+
+// RemoveSymbol 将交易对移出交易范围：先清理挂单（及可选的持仓），再停止K线缓存
+// 清理失败时仍会移出缓存，错误返回给调用方处理（如告警后人工介入）
+func RemoveSymbol(t Trader, cache *market.KlineCache, symbol string, closePositions bool) error {
+	err := CleanupSymbol(t, symbol, closePositions)
+	if cache != nil {
+		cache.RemoveSymbol(symbol)
+	}
+	return err
+}
+
+// FollowScreener 筛选器剔除交易对时自动执行清理
+func FollowScreener(s *market.Screener, t Trader, closePositions bool) {
+	s.Subscribe(func(update market.ScreenerUpdate) {
+		for _, symbol := range update.Removed {
+			if err := CleanupSymbol(t, symbol, closePositions); err != nil {
+				log.Printf("⚠️ [Cleanup] %v", err)
+			}
+		}
+	})
+}
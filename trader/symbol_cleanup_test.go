@@ -0,0 +1,66 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// cleanupTrader 记录清理调用的 MockTrader
+type cleanupTrader struct {
+	MockTrader
+	cancelled []string
+	closed    []string
+	cancelErr error
+}
+
+func (c *cleanupTrader) CancelAllOrders(symbol string) error {
+	c.cancelled = append(c.cancelled, symbol)
+	return c.cancelErr
+}
+
+func (c *cleanupTrader) CloseLong(symbol string, quantity float64) (map[string]interface{}, error) {
+	c.closed = append(c.closed, symbol+"_long")
+	return c.MockTrader.CloseLong(symbol, quantity)
+}
+
+func (c *cleanupTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	c.closed = append(c.closed, symbol+"_short")
+	return c.MockTrader.CloseShort(symbol, quantity)
+}
+
+func newCleanupTrader() *cleanupTrader {
+	return &cleanupTrader{MockTrader: MockTrader{positions: []map[string]interface{}{
+		{"symbol": "SOLUSDT", "side": "long", "positionAmt": 10.0},
+		{"symbol": "SOLUSDT", "side": "short", "positionAmt": 2.0},
+		{"symbol": "ETHUSDT", "side": "long", "positionAmt": 1.0},
+	}}}
+}
+
+func TestCleanupSymbol_CancelOnly(t *testing.T) {
+	tr := newCleanupTrader()
+	require.NoError(t, CleanupSymbol(tr, "SOLUSDT", false))
+	assert.Equal(t, []string{"SOLUSDT"}, tr.cancelled)
+	assert.Empty(t, tr.closed)
+}
+
+func TestCleanupSymbol_ClosePositions(t *testing.T) {
+	tr := newCleanupTrader()
+	require.NoError(t, CleanupSymbol(tr, "SOLUSDT", true))
+	assert.Equal(t, []string{"SOLUSDT_long", "SOLUSDT_short"}, tr.closed)
+}
+
+func TestCleanupSymbol_ContinuesAfterErrors(t *testing.T) {
+	tr := newCleanupTrader()
+	tr.cancelErr = errors.New("cancel failed")
+	tr.shouldFailCloseLong = true
+
+	err := CleanupSymbol(tr, "SOLUSDT", true)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cancel failed")
+	assert.Contains(t, err.Error(), "failed to close long")
+	// 撤单和平多失败后仍尝试平空
+	assert.Equal(t, []string{"SOLUSDT_long", "SOLUSDT_short"}, tr.closed)
+}
@@ -0,0 +1,142 @@
+package trader
+
+import (
+	"log"
+	"strings"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+	"nofx/market"
+)
+
+const (
+	journalCandleTimeFrame = market.TimeFrame15m // 交易日志K线快照周期
+	journalCandlesBefore   = 20                  // 开仓前保留的K线根数
+	journalMaxCandles      = 200                 // 单条日志最多保存的K线根数
+)
+
+// journalEntryInfo 开仓时记录、平仓时写入交易日志的信息
+type journalEntryInfo struct {
+	Signal      string
+	SignalType  string            // 归属的强信号类型（AI自主开仓时为空）
+	TimeFrame   string            // 归属的强信号周期
+	Metadata    map[string]string // 持仓的元数据标签（交易员配置 + 开仓订单 + 人工设置）
+	InitialStop float64
+	ExitPrice   float64  // 主动平仓时的成交参考价
+	CloseReason string   // 主动平仓原因（为空表示被动平仓，按推断结果记录）
+	Charts      []string // 开仓前强信号的K线图文件
+}
+
+// rememberJournalEntry 开仓成功后记录开仓理由和初始止损
+func (at *AutoTrader) rememberJournalEntry(posKey, signal string, stopLoss float64) {
+	if at.positionJournalInfo == nil {
+		at.positionJournalInfo = make(map[string]journalEntryInfo)
+	}
+	symbol := posKey
+	if i := strings.LastIndex(posKey, "_"); i >= 0 {
+		symbol = posKey[:i]
+	}
+	// 加仓时保留持仓已有的元数据标签
+	at.positionJournalInfo[posKey] = journalEntryInfo{Signal: signal, InitialStop: stopLoss, Charts: at.takeSignalCharts(symbol),
+		Metadata: at.positionJournalInfo[posKey].Metadata}
+}
+
+// markJournalClose 主动平仓后记录平仓价和原因
+// 持仓消失会在下个周期由被动平仓检测发现，届时按这里记录的信息写入交易日志
+func (at *AutoTrader) markJournalClose(posKey string, exitPrice float64, reason string) {
+	if at.positionJournalInfo == nil {
+		at.positionJournalInfo = make(map[string]journalEntryInfo)
+	}
+	info := at.positionJournalInfo[posKey]
+	info.ExitPrice = exitPrice
+	info.CloseReason = reason
+	at.positionJournalInfo[posKey] = info
+}
+
+// journalClosedTrade 为已平仓的持仓生成交易日志条目
+// exitPrice/closeReason 为被动平仓推断的结果，主动平仓时以 markJournalClose 记录的为准
+func (at *AutoTrader) journalClosedTrade(pos decision.PositionInfo, exitPrice float64, closeReason string) {
+	posKey := pos.Symbol + "_" + pos.Side
+	info := at.positionJournalInfo[posKey]
+	delete(at.positionJournalInfo, posKey)
+	if at.journal == nil {
+		return
+	}
+	if info.CloseReason != "" {
+		exitPrice, closeReason = info.ExitPrice, info.CloseReason
+	}
+
+	initialStop := info.InitialStop
+	if initialStop <= 0 {
+		initialStop = pos.StopLoss
+	}
+
+	pnl := pos.Quantity * (exitPrice - pos.EntryPrice)
+	if pos.Side == "short" {
+		pnl = -pnl
+	}
+
+	closeTime := time.Now()
+	openTime := closeTime
+	if pos.UpdateTime > 0 {
+		openTime = time.UnixMilli(pos.UpdateTime)
+	}
+
+	entry := &logger.JournalEntry{
+		Symbol:      pos.Symbol,
+		Side:        pos.Side,
+		Quantity:    pos.Quantity,
+		Leverage:    pos.Leverage,
+		EntryPrice:  pos.EntryPrice,
+		ExitPrice:   exitPrice,
+		StopLoss:    initialStop,
+		PnL:         pnl,
+		Signal:      info.Signal,
+		SignalType:  info.SignalType,
+		TimeFrame:   info.TimeFrame,
+		CloseReason: closeReason,
+		OpenTime:    openTime,
+		CloseTime:   closeTime,
+		Candles:     at.journalCandles(pos.Symbol, openTime),
+		Charts:      info.Charts,
+		Metadata:    mergeMetadata(at.config.Metadata, info.Metadata),
+	}
+	if err := at.journal.Record(entry); err != nil {
+		log.Printf("⚠️ 记录交易日志失败 (%s): %v", posKey, err)
+	}
+}
+
+// journalCandles 截取开仓前若干根到当前的K线快照
+func (at *AutoTrader) journalCandles(symbol string, openTime time.Time) []logger.JournalCandle {
+	if at.klineCache == nil {
+		return nil
+	}
+	klines, err := at.klineCache.GetKlines(symbol, journalCandleTimeFrame, journalMaxCandles)
+	if err != nil {
+		return nil
+	}
+
+	period := time.Duration(market.TimeFrameMinutes[journalCandleTimeFrame]) * time.Minute
+	from := openTime.Add(-journalCandlesBefore * period).UnixMilli()
+	candles := make([]logger.JournalCandle, 0, len(klines))
+	for _, k := range klines {
+		if k.OpenTime < from {
+			continue
+		}
+		candles = append(candles, logger.JournalCandle{
+			OpenTime: k.OpenTime,
+			Open:     k.Open,
+			High:     k.High,
+			Low:      k.Low,
+			Close:    k.Close,
+			Volume:   k.Volume,
+		})
+	}
+	return candles
+}
+
+// GetTradeJournal 获取交易日志（未启用时返回nil）
+func (at *AutoTrader) GetTradeJournal() *logger.TradeJournal {
+	return at.journal
+}
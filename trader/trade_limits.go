@@ -0,0 +1,114 @@
+package trader
+
+import (
+	"fmt"
+	"sort"
+
+	"nofx/decision"
+	"nofx/market"
+)
+
+// TradeLimitsConfig 按信号来源限制同时持仓数量
+// 检测器会在六个周期上同时触发，统一在开仓时按触发信号的周期和检测器计数
+type TradeLimitsConfig struct {
+	MaxPerTimeFrame         map[market.TimeFrame]int  // 每个周期的信号同时持仓上限（如 5m: 2）
+	MaxPerDetectorPerSymbol map[market.SignalType]int // 每个交易对每种检测器的同时持仓上限（如 engulfing: 1）
+}
+
+// Enabled 是否配置了任何限制
+func (c TradeLimitsConfig) Enabled() bool {
+	return len(c.MaxPerTimeFrame) > 0 || len(c.MaxPerDetectorPerSymbol) > 0
+}
+
+// tradeSignal 持仓对应的开仓信号
+type tradeSignal struct {
+	Symbol     string
+	TimeFrame  market.TimeFrame
+	SignalType market.SignalType
+}
+
+// rememberStrongSignals 记录本周期的强信号，并清理已平仓持仓的信号归属
+func (at *AutoTrader) rememberStrongSignals(signals []*market.TradingSignal, positions []decision.PositionInfo) {
+	at.recentSignals = make(map[string][]*market.TradingSignal)
+	for _, sig := range signals {
+		at.recentSignals[sig.Symbol] = append(at.recentSignals[sig.Symbol], sig)
+	}
+
+	open := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		open[pos.Symbol+"_"+pos.Side] = true
+	}
+	for posKey := range at.positionSignals {
+		if !open[posKey] {
+			delete(at.positionSignals, posKey)
+		}
+	}
+}
+
+// allowed 该信号再开一仓是否仍在限制内
+func (c TradeLimitsConfig) allowed(sig tradeSignal, open map[string]tradeSignal) error {
+	perTF, perDetector := 0, 0
+	for _, s := range open {
+		if s.TimeFrame == sig.TimeFrame {
+			perTF++
+		}
+		if s.Symbol == sig.Symbol && s.SignalType == sig.SignalType {
+			perDetector++
+		}
+	}
+	if limit, ok := c.MaxPerTimeFrame[sig.TimeFrame]; ok && perTF >= limit {
+		return fmt.Errorf("%s 信号持仓已达上限 %d", sig.TimeFrame, limit)
+	}
+	if limit, ok := c.MaxPerDetectorPerSymbol[sig.SignalType]; ok && perDetector >= limit {
+		return fmt.Errorf("%s %s 信号持仓已达上限 %d", sig.Symbol, sig.SignalType, limit)
+	}
+	return nil
+}
+
+// checkTradeLimits 为开仓找到归属的强信号并检查持仓上限
+// 同一方向有多个信号时按强度从高到低尝试，取第一个仍有额度的；没有对应信号（AI自主开仓）时不受限制
+// 未启用持仓上限时直接归属到最强的信号（用于交易日志按信号归因）
+func (at *AutoTrader) checkTradeLimits(symbol, side string) (*tradeSignal, error) {
+	var candidates []*market.TradingSignal
+	for _, sig := range at.recentSignals[symbol] {
+		if sig.Direction == side {
+			candidates = append(candidates, sig)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Confidence > candidates[j].Confidence
+	})
+
+	cfg := at.config.TradeLimits
+	if !cfg.Enabled() {
+		return &tradeSignal{Symbol: symbol, TimeFrame: candidates[0].TimeFrame, SignalType: candidates[0].SignalType}, nil
+	}
+
+	var lastErr error
+	for _, sig := range candidates {
+		ts := tradeSignal{Symbol: symbol, TimeFrame: sig.TimeFrame, SignalType: sig.SignalType}
+		if lastErr = cfg.allowed(ts, at.positionSignals); lastErr == nil {
+			return &ts, nil
+		}
+	}
+	return nil, fmt.Errorf("❌ %s 拒绝开仓: %w", symbol, lastErr)
+}
+
+// rememberTradeSignal 开仓成功后记录持仓归属的信号
+func (at *AutoTrader) rememberTradeSignal(posKey string, sig *tradeSignal) {
+	if sig == nil {
+		return
+	}
+	if at.positionSignals == nil {
+		at.positionSignals = make(map[string]tradeSignal)
+	}
+	at.positionSignals[posKey] = *sig
+	if info, ok := at.positionJournalInfo[posKey]; ok {
+		info.SignalType = string(sig.SignalType)
+		info.TimeFrame = string(sig.TimeFrame)
+		at.positionJournalInfo[posKey] = info
+	}
+}
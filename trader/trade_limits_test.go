@@ -0,0 +1,90 @@
+package trader
+
+import (
+	"testing"
+
+	"nofx/decision"
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAutoTrader_TradeLimits(t *testing.T) {
+	at := &AutoTrader{config: AutoTraderConfig{TradeLimits: TradeLimitsConfig{
+		MaxPerTimeFrame:         map[market.TimeFrame]int{market.TimeFrame5m: 1},
+		MaxPerDetectorPerSymbol: map[market.SignalType]int{market.SignalEngulfing: 1},
+	}}}
+	at.rememberStrongSignals([]*market.TradingSignal{
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame5m, SignalType: market.SignalBullishPinBar, Direction: "long", Confidence: 90},
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame1h, SignalType: market.SignalEngulfing, Direction: "long", Confidence: 85},
+		{Symbol: "ETHUSDT", TimeFrame: market.TimeFrame5m, SignalType: market.SignalVolumeSpike, Direction: "short", Confidence: 80},
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame1h, SignalType: market.SignalEngulfing, Direction: "short", Confidence: 95},
+	}, nil)
+
+	// 最强的同方向信号归属
+	sig, err := at.checkTradeLimits("BTCUSDT", "long")
+	require.NoError(t, err)
+	require.NotNil(t, sig)
+	assert.Equal(t, market.TimeFrame5m, sig.TimeFrame)
+	at.rememberTradeSignal("BTCUSDT_long", sig)
+
+	// 5m 额度已满
+	_, err = at.checkTradeLimits("ETHUSDT", "short")
+	assert.Error(t, err)
+
+	// 没有对应信号（AI自主开仓）不受限制
+	sig, err = at.checkTradeLimits("SOLUSDT", "long")
+	assert.NoError(t, err)
+	assert.Nil(t, sig)
+
+	// 空方向：engulfing 每个交易对1个
+	sig, err = at.checkTradeLimits("BTCUSDT", "short")
+	require.NoError(t, err)
+	assert.Equal(t, market.SignalEngulfing, sig.SignalType)
+	at.rememberTradeSignal("BTCUSDT_short", sig)
+
+	// 平仓后释放额度
+	at.rememberStrongSignals([]*market.TradingSignal{
+		{Symbol: "ETHUSDT", TimeFrame: market.TimeFrame5m, SignalType: market.SignalVolumeSpike, Direction: "short", Confidence: 80},
+	}, []decision.PositionInfo{{Symbol: "BTCUSDT", Side: "short"}})
+	assert.Len(t, at.positionSignals, 1)
+	_, err = at.checkTradeLimits("ETHUSDT", "short")
+	assert.NoError(t, err)
+}
+
+func TestAutoTrader_TradeLimitsFallsBackToNextSignal(t *testing.T) {
+	at := &AutoTrader{config: AutoTraderConfig{TradeLimits: TradeLimitsConfig{
+		MaxPerTimeFrame: map[market.TimeFrame]int{market.TimeFrame5m: 0},
+	}}}
+	at.rememberStrongSignals([]*market.TradingSignal{
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame5m, SignalType: market.SignalMomentum, Direction: "long", Confidence: 95},
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame4h, SignalType: market.SignalMomentum, Direction: "long", Confidence: 80},
+	}, nil)
+
+	sig, err := at.checkTradeLimits("BTCUSDT", "long")
+	require.NoError(t, err)
+	assert.Equal(t, market.TimeFrame4h, sig.TimeFrame, "5m 无额度时归属到 4h 信号")
+}
+
+func TestAutoTrader_SignalAttributionWithoutLimits(t *testing.T) {
+	at := &AutoTrader{positionJournalInfo: map[string]journalEntryInfo{}}
+	at.rememberStrongSignals([]*market.TradingSignal{
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame15m, SignalType: market.SignalBullishPinBar, Direction: "long", Confidence: 80},
+		{Symbol: "BTCUSDT", TimeFrame: market.TimeFrame1h, SignalType: market.SignalEngulfing, Direction: "long", Confidence: 90},
+	}, nil)
+
+	// 未启用持仓上限时仍归属到最强信号
+	sig, err := at.checkTradeLimits("BTCUSDT", "long")
+	require.NoError(t, err)
+	require.NotNil(t, sig)
+	assert.Equal(t, market.SignalEngulfing, sig.SignalType)
+
+	// 归属写入交易日志信息
+	at.rememberJournalEntry("BTCUSDT_long", "engulfing", 95)
+	at.rememberTradeSignal("BTCUSDT_long", sig)
+	info := at.positionJournalInfo["BTCUSDT_long"]
+	assert.Equal(t, "engulfing", info.SignalType)
+	assert.Equal(t, "1h", info.TimeFrame)
+	assert.Equal(t, 95.0, info.InitialStop)
+}
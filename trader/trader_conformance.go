@@ -0,0 +1,246 @@
+package trader
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"testing"
+
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// 一致性检查项名称（用于 ConformanceConfig.KnownDeviations）
+const (
+	ConformanceSymbolMapping      = "SymbolMapping"
+	ConformanceRounding           = "Rounding"
+	ConformanceStopLossTakeProfit = "StopLossTakeProfit"
+	ConformanceCancelSeparately   = "CancelStopLossKeepsTakeProfit"
+	ConformanceErrorMapping       = "ErrorMapping"
+	ConformanceIdempotency        = "Idempotency"
+)
+
+// ConformanceConfig 交易器一致性测试配置
+type ConformanceConfig struct {
+	Symbol        string  // 标准格式交易对（如 "SOLUSDT"）
+	Quantity      float64 // 每次下单数量（需满足交易所最小下单量）
+	Leverage      int     // 开仓杠杆（<=0 时为1）
+	InvalidSymbol string  // 交易所不存在的交易对（空时为 "INVALIDUSDT"）
+
+	// StopOrders 查询该交易对挂着的止损、止盈单数量（可选，未提供时跳过挂单状态检查）
+	StopOrders func(symbol string) (stopLoss, takeProfit int, err error)
+	// InjectError 让下一次请求返回指定HTTP状态码（可选，通常只有模拟服务器支持）
+	InjectError func(status int)
+
+	// KnownDeviations 已知不满足的检查项 -> 原因，这些检查会被跳过并在输出中列出
+	KnownDeviations map[string]string
+}
+
+// RunConformance 对任意 Trader 实现运行一致性测试，新接入的交易所需要通过这些检查
+// 检查项：符号映射、数量取整、止损止盈、错误映射、幂等性
+// ⚠️ 会真实下单和平仓：连接测试网或实盘时请使用小额账户，并保证该交易对初始没有持仓和挂单
+//
+// 与 TraderTestSuite（gomonkey 打桩的接口冒烟测试）不同，这里只通过 Trader 接口观察行为，
+// 可以直接对模拟服务器或测试网运行
+func RunConformance(t *testing.T, tr Trader, cfg ConformanceConfig) {
+	if cfg.Leverage <= 0 {
+		cfg.Leverage = 1
+	}
+	if cfg.InvalidSymbol == "" {
+		cfg.InvalidSymbol = "INVALIDUSDT"
+	}
+	require.NotEmpty(t, cfg.Symbol, "未配置交易对")
+	require.Greater(t, cfg.Quantity, 0.0, "未配置下单数量")
+
+	c := &conformance{tr: tr, cfg: cfg}
+	c.run(t, ConformanceSymbolMapping, c.symbolMapping)
+	c.run(t, ConformanceRounding, c.rounding)
+	c.run(t, ConformanceStopLossTakeProfit, c.stopLossTakeProfit)
+	c.run(t, ConformanceCancelSeparately, c.cancelSeparately)
+	c.run(t, ConformanceErrorMapping, c.errorMapping)
+	c.run(t, ConformanceIdempotency, c.idempotency)
+}
+
+type conformance struct {
+	tr  Trader
+	cfg ConformanceConfig
+}
+
+func (c *conformance) run(t *testing.T, name string, check func(t *testing.T)) {
+	t.Run(name, func(t *testing.T) {
+		if reason, ok := c.cfg.KnownDeviations[name]; ok {
+			t.Skipf("已知偏差: %s", reason)
+		}
+		check(t)
+	})
+}
+
+// position 返回该交易对 side 方向的持仓（没有时为 nil）
+func (c *conformance) position(t *testing.T, side string) map[string]interface{} {
+	positions, err := c.tr.GetPositions()
+	require.NoError(t, err)
+	for _, pos := range positions {
+		if pos["symbol"] == c.cfg.Symbol && pos["side"] == side {
+			return pos
+		}
+	}
+	return nil
+}
+
+// openLong 开多仓并在测试结束时撤单、平仓
+// 两个方向都会平掉：不合规的止损单可能直接成交并把持仓变成反向，残留会影响后续检查
+func (c *conformance) openLong(t *testing.T) map[string]interface{} {
+	result, err := c.tr.OpenLong(c.cfg.Symbol, c.cfg.Quantity, c.cfg.Leverage)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		c.tr.CancelAllOrders(c.cfg.Symbol)
+		if pos := c.position(t, "long"); pos != nil {
+			c.tr.CloseLong(c.cfg.Symbol, 0)
+		}
+		if pos := c.position(t, "short"); pos != nil {
+			c.tr.CloseShort(c.cfg.Symbol, 0)
+		}
+	})
+	return result
+}
+
+// assertStopOrders 核对挂着的止损/止盈单数量（未提供 StopOrders 时跳过）
+func (c *conformance) assertStopOrders(t *testing.T, wantSL, wantTP int, msg string) {
+	if c.cfg.StopOrders == nil {
+		return
+	}
+	sl, tp, err := c.cfg.StopOrders(c.cfg.Symbol)
+	require.NoError(t, err)
+	assert.Equal(t, wantSL, sl, "%s: 止损单数量", msg)
+	assert.Equal(t, wantTP, tp, "%s: 止盈单数量", msg)
+}
+
+// symbolMapping 输入输出统一使用标准格式交易对（BTCUSDT），不暴露交易所内部格式
+func (c *conformance) symbolMapping(t *testing.T) {
+	price, err := c.tr.GetMarketPrice(c.cfg.Symbol)
+	require.NoError(t, err)
+	assert.Greater(t, price, 0.0)
+
+	result := c.openLong(t)
+	assert.Equal(t, c.cfg.Symbol, result["symbol"], "下单结果使用标准格式交易对")
+
+	positions, err := c.tr.GetPositions()
+	require.NoError(t, err)
+	for _, pos := range positions {
+		symbol, _ := pos["symbol"].(string)
+		assert.Equal(t, market.Normalize(symbol), symbol, "持仓使用标准格式交易对")
+	}
+	pos := c.position(t, "long")
+	require.NotNil(t, pos, "开仓后能按标准格式交易对查到持仓")
+	assert.InDelta(t, c.cfg.Quantity, pos["positionAmt"], c.cfg.Quantity*1e-6, "持仓数量为正数且等于开仓数量")
+
+	result, err = c.tr.CloseLong(c.cfg.Symbol, 0)
+	require.NoError(t, err)
+	assert.Equal(t, c.cfg.Symbol, result["symbol"])
+	assert.Nil(t, c.position(t, "long"), "全部平仓后不再有持仓")
+}
+
+// rounding FormatQuantity 结果为普通小数、偏差不超过一个最小单位，且重复格式化结果不变
+func (c *conformance) rounding(t *testing.T) {
+	for _, qty := range []float64{c.cfg.Quantity, c.cfg.Quantity * 1.23456789, 1.0 / 3} {
+		formatted, err := c.tr.FormatQuantity(c.cfg.Symbol, qty)
+		require.NoError(t, err)
+		assert.NotContains(t, strings.ToLower(formatted), "e", "不能使用科学计数法: %s", formatted)
+
+		value, err := strconv.ParseFloat(formatted, 64)
+		require.NoError(t, err, "结果可解析: %s", formatted)
+		decimals := 0
+		if i := strings.IndexByte(formatted, '.'); i >= 0 {
+			decimals = len(formatted) - i - 1
+		}
+		unit := math.Pow10(-decimals)
+		assert.LessOrEqual(t, math.Abs(value-qty), unit+1e-12, "%v 格式化为 %s 偏差超过一个最小单位", qty, formatted)
+
+		again, err := c.tr.FormatQuantity(c.cfg.Symbol, value)
+		require.NoError(t, err)
+		assert.Equal(t, formatted, again, "重复格式化结果不变")
+	}
+}
+
+// stopLossTakeProfit 止损止盈单挂出后不立即成交、不影响持仓，CancelStopOrders 撤销两者
+func (c *conformance) stopLossTakeProfit(t *testing.T) {
+	c.openLong(t)
+	price, err := c.tr.GetMarketPrice(c.cfg.Symbol)
+	require.NoError(t, err)
+
+	require.NoError(t, c.tr.SetStopLoss(c.cfg.Symbol, "LONG", c.cfg.Quantity, price*0.95))
+	require.NoError(t, c.tr.SetTakeProfit(c.cfg.Symbol, "LONG", c.cfg.Quantity, price*1.05))
+	assert.NotNil(t, c.position(t, "long"), "设置止损止盈不能平掉持仓")
+	c.assertStopOrders(t, 1, 1, "设置止损止盈后")
+
+	require.NoError(t, c.tr.CancelStopOrders(c.cfg.Symbol))
+	c.assertStopOrders(t, 0, 0, "CancelStopOrders 后")
+	assert.NotNil(t, c.position(t, "long"), "撤销止损止盈不影响持仓")
+}
+
+// cancelSeparately 单独撤销止损时保留止盈，反之亦然（需要 StopOrders）
+func (c *conformance) cancelSeparately(t *testing.T) {
+	if c.cfg.StopOrders == nil {
+		t.Skip("未提供 StopOrders，无法观察挂单")
+	}
+	c.openLong(t)
+	price, err := c.tr.GetMarketPrice(c.cfg.Symbol)
+	require.NoError(t, err)
+
+	require.NoError(t, c.tr.SetStopLoss(c.cfg.Symbol, "LONG", c.cfg.Quantity, price*0.95))
+	require.NoError(t, c.tr.SetTakeProfit(c.cfg.Symbol, "LONG", c.cfg.Quantity, price*1.05))
+	require.NoError(t, c.tr.CancelStopLossOrders(c.cfg.Symbol))
+	c.assertStopOrders(t, 0, 1, "CancelStopLossOrders 后")
+
+	require.NoError(t, c.tr.SetStopLoss(c.cfg.Symbol, "LONG", c.cfg.Quantity, price*0.95))
+	require.NoError(t, c.tr.CancelTakeProfitOrders(c.cfg.Symbol))
+	c.assertStopOrders(t, 1, 0, "CancelTakeProfitOrders 后")
+}
+
+// errorMapping 交易所错误以 error 返回（不返回零值结果），限频错误可用 errors.Is(err, ErrRateLimited) 判断
+func (c *conformance) errorMapping(t *testing.T) {
+	price, err := c.tr.GetMarketPrice(c.cfg.InvalidSymbol)
+	assert.Error(t, err, "无效交易对查询价格")
+	assert.Zero(t, price)
+
+	result, err := c.tr.OpenLong(c.cfg.InvalidSymbol, c.cfg.Quantity, c.cfg.Leverage)
+	assert.Error(t, err, "无效交易对下单")
+	assert.Nil(t, result)
+
+	if c.cfg.InjectError == nil {
+		return
+	}
+	c.cfg.InjectError(http.StatusTooManyRequests)
+	_, err = c.tr.GetMarketPrice(c.cfg.Symbol)
+	assert.True(t, errors.Is(err, ErrRateLimited), "429 映射为 ErrRateLimited: %v", err)
+
+	c.cfg.InjectError(http.StatusInternalServerError)
+	_, err = c.tr.GetMarketPrice(c.cfg.Symbol)
+	assert.Error(t, err, "5xx 返回错误")
+
+	// 错误不会残留影响后续请求
+	_, err = c.tr.GetMarketPrice(c.cfg.Symbol)
+	assert.NoError(t, err)
+}
+
+// idempotency 重复撤单、重复设置杠杆/保证金模式不报错；重复全部平仓不会反向开仓
+func (c *conformance) idempotency(t *testing.T) {
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, c.tr.CancelAllOrders(c.cfg.Symbol), "第%d次 CancelAllOrders", i+1)
+		assert.NoError(t, c.tr.CancelStopOrders(c.cfg.Symbol), "第%d次 CancelStopOrders", i+1)
+		assert.NoError(t, c.tr.SetLeverage(c.cfg.Symbol, c.cfg.Leverage), "第%d次 SetLeverage", i+1)
+		assert.NoError(t, c.tr.SetMarginMode(c.cfg.Symbol, true), "第%d次 SetMarginMode", i+1)
+	}
+
+	c.openLong(t)
+	_, err := c.tr.CloseLong(c.cfg.Symbol, 0)
+	require.NoError(t, err)
+	_, err = c.tr.CloseLong(c.cfg.Symbol, 0)
+	assert.Error(t, err, "没有持仓时全部平仓应返回错误")
+	assert.Nil(t, c.position(t, "long"))
+	assert.Nil(t, c.position(t, "short"), "重复平仓不能反向开仓")
+}
@@ -0,0 +1,264 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"nofx/decision"
+	"nofx/logger"
+)
+
+const (
+	defaultEntryFillTimeout  = 10 * time.Second
+	defaultEntryPollInterval = 500 * time.Millisecond
+)
+
+// TwoPhaseEntryConfig 两阶段入场配置（用于不支持原生OCO的交易所）
+// 第一阶段开仓并确认成交，第二阶段按成交数量挂止损止盈；保护单最终失败时平仓回滚
+type TwoPhaseEntryConfig struct {
+	Enabled      bool
+	FillTimeout  time.Duration         // 等待入场成交的最长时间（<=0 时为10s）
+	PollInterval time.Duration         // 查询持仓确认成交的间隔（<=0 时为500ms）
+	Protection   ProtectionRetryConfig // 保护单重试（EmergencyClose 不生效，失败总是回滚）
+}
+
+func (c TwoPhaseEntryConfig) fillTimeout() time.Duration {
+	if c.FillTimeout <= 0 {
+		return defaultEntryFillTimeout
+	}
+	return c.FillTimeout
+}
+
+func (c TwoPhaseEntryConfig) pollInterval() time.Duration {
+	if c.PollInterval <= 0 {
+		return defaultEntryPollInterval
+	}
+	return c.PollInterval
+}
+
+// TwoPhaseEntryRequest 两阶段入场请求
+type TwoPhaseEntryRequest struct {
+	Symbol     string
+	Side       string // long/short
+	Quantity   float64
+	Leverage   int
+	StopLoss   float64 // 必填
+	TakeProfit float64 // <=0 表示不挂止盈
+}
+
+// EntryAuditEvent 两阶段入场的审计记录
+type EntryAuditEvent struct {
+	Time   time.Time `json:"time"`
+	Phase  string    `json:"phase"` // entry/confirm/stop_loss/take_profit/rollback/done
+	Detail string    `json:"detail"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// TwoPhaseEntryResult 两阶段入场结果
+type TwoPhaseEntryResult struct {
+	Request      TwoPhaseEntryRequest
+	Entry        map[string]interface{} // 开仓订单响应
+	FilledQty    float64                // 确认成交的数量（持仓增量）
+	EntryPrice   float64                // 成交后的持仓均价
+	Protected    bool                   // 止损止盈均已挂出
+	RolledBack   bool                   // 保护单失败后已平仓
+	StopLossID   string                 // 本次挂出的止损单ID（交易器不返回订单ID时为空）
+	TakeProfitID string                 // 本次挂出的止盈单ID
+	RollbackErr  error                  // 回滚平仓失败的错误（持仓可能无保护，需要人工处理）
+	Audit        []EntryAuditEvent
+}
+
+// protectionOrderPlacer 挂止损止盈时返回订单ID、并能按ID撤销的交易器
+// 两阶段入场回滚时只撤销本次挂出的保护单，不影响同一交易对上其他持仓的挂单
+type protectionOrderPlacer interface {
+	PlaceStopLoss(symbol, positionSide string, quantity, stopPrice float64) (string, error)
+	PlaceTakeProfit(symbol, positionSide string, quantity, takeProfitPrice float64) (string, error)
+	CancelProtectionOrder(symbol, orderID string) error
+}
+
+// record 追加审计记录并写日志
+func (r *TwoPhaseEntryResult) record(phase, detail string, err error) {
+	event := EntryAuditEvent{Time: time.Now(), Phase: phase, Detail: detail}
+	if err != nil {
+		event.Error = err.Error()
+		log.Printf("🧾 [TwoPhase] %s %s %s: %s (错误: %v)", r.Request.Symbol, r.Request.Side, phase, detail, err)
+	} else {
+		log.Printf("🧾 [TwoPhase] %s %s %s: %s", r.Request.Symbol, r.Request.Side, phase, detail)
+	}
+	r.Audit = append(r.Audit, event)
+}
+
+// OpenTwoPhase 两阶段入场：开仓 → 确认成交 → 挂止损止盈 → 失败时平仓回滚
+// 返回错误时 result 仍包含已执行步骤的审计记录；未能确认成交时不挂保护单也不平仓
+func OpenTwoPhase(t Trader, req TwoPhaseEntryRequest, cfg TwoPhaseEntryConfig) (*TwoPhaseEntryResult, error) {
+	result := &TwoPhaseEntryResult{Request: req}
+	if req.Side != "long" && req.Side != "short" {
+		return result, fmt.Errorf("无效的方向: %q（应为 long 或 short）", req.Side)
+	}
+	if req.Quantity <= 0 || req.StopLoss <= 0 {
+		return result, fmt.Errorf("无效的两阶段入场参数: 数量=%.8f 止损=%.8f", req.Quantity, req.StopLoss)
+	}
+
+	pos, err := findPosition(t, req.Symbol, req.Side)
+	if err != nil {
+		result.record("entry", "查询开仓前持仓失败", err)
+		return result, fmt.Errorf("查询开仓前持仓失败: %w", err)
+	}
+
+	// 第一阶段：开仓并确认成交
+	if req.Side == "long" {
+		result.Entry, err = t.OpenLong(req.Symbol, req.Quantity, req.Leverage)
+	} else {
+		result.Entry, err = t.OpenShort(req.Symbol, req.Quantity, req.Leverage)
+	}
+	if err != nil {
+		result.record("entry", fmt.Sprintf("开仓 %.8f 失败", req.Quantity), err)
+		return result, fmt.Errorf("开仓失败: %w", err)
+	}
+	result.record("entry", fmt.Sprintf("已提交开仓 %.8f 订单ID=%v", req.Quantity, result.Entry["orderId"]), nil)
+
+	if err := result.confirmFill(t, positionQuantity(pos), cfg); err != nil {
+		result.record("confirm", "未能确认成交", err)
+		logger.Alertf("%s %s 两阶段入场未能确认成交，未挂止损止盈，需要人工检查: %v", req.Symbol, req.Side, err)
+		return result, fmt.Errorf("确认入场成交失败: %w", err)
+	}
+	result.record("confirm", fmt.Sprintf("成交 %.8f 持仓均价 %.8f", result.FilledQty, result.EntryPrice), nil)
+
+	// 第二阶段：按成交数量挂止损止盈
+	positionSide := strings.ToUpper(req.Side)
+	placer, hasIDs := t.(protectionOrderPlacer)
+	protectErr := cfg.Protection.retryWithBackoff("止损", func() error {
+		if hasIDs {
+			var err error
+			result.StopLossID, err = placer.PlaceStopLoss(req.Symbol, positionSide, result.FilledQty, req.StopLoss)
+			return err
+		}
+		return t.SetStopLoss(req.Symbol, positionSide, result.FilledQty, req.StopLoss)
+	})
+	result.record("stop_loss", fmt.Sprintf("止损 %.8f 订单ID=%s", req.StopLoss, result.StopLossID), protectErr)
+	stopPlaced := protectErr == nil
+	if protectErr == nil && req.TakeProfit > 0 {
+		protectErr = cfg.Protection.retryWithBackoff("止盈", func() error {
+			if hasIDs {
+				var err error
+				result.TakeProfitID, err = placer.PlaceTakeProfit(req.Symbol, positionSide, result.FilledQty, req.TakeProfit)
+				return err
+			}
+			return t.SetTakeProfit(req.Symbol, positionSide, result.FilledQty, req.TakeProfit)
+		})
+		result.record("take_profit", fmt.Sprintf("止盈 %.8f 订单ID=%s", req.TakeProfit, result.TakeProfitID), protectErr)
+	}
+	if protectErr == nil {
+		result.Protected = true
+		result.record("done", "入场完成，止损止盈已挂出", nil)
+		return result, nil
+	}
+
+	// 保护单失败：撤销本次已挂出的止损并平掉本次成交的数量
+	if stopPlaced {
+		var err error
+		if hasIDs {
+			err = placer.CancelProtectionOrder(req.Symbol, result.StopLossID)
+		} else {
+			// 交易器不返回订单ID时只能撤销该交易对的止损单
+			err = t.CancelStopLossOrders(req.Symbol)
+		}
+		if err != nil {
+			result.record("rollback", fmt.Sprintf("撤销已挂出的止损 %s 失败", result.StopLossID), err)
+		}
+	}
+	if req.Side == "long" {
+		_, result.RollbackErr = t.CloseLong(req.Symbol, result.FilledQty)
+	} else {
+		_, result.RollbackErr = t.CloseShort(req.Symbol, result.FilledQty)
+	}
+	if result.RollbackErr != nil {
+		result.record("rollback", fmt.Sprintf("平仓 %.8f 失败", result.FilledQty), result.RollbackErr)
+		logger.Alertf("%s %s 两阶段入场挂保护单失败且回滚平仓失败，持仓无保护，需要人工处理！保护单错误: %v，平仓错误: %v",
+			req.Symbol, req.Side, protectErr, result.RollbackErr)
+		return result, fmt.Errorf("挂止损止盈失败且回滚平仓失败: %w", protectErr)
+	}
+	result.RolledBack = true
+	result.record("rollback", fmt.Sprintf("已平仓 %.8f", result.FilledQty), nil)
+	logger.Alertf("%s %s 两阶段入场挂保护单失败，已回滚平仓: %v", req.Symbol, req.Side, protectErr)
+	return result, fmt.Errorf("挂止损止盈失败，已回滚平仓: %w", protectErr)
+}
+
+// confirmFill 轮询持仓直到持仓数量增加，超时前只成交部分时按已成交数量继续
+func (r *TwoPhaseEntryResult) confirmFill(t Trader, before float64, cfg TwoPhaseEntryConfig) error {
+	deadline := time.Now().Add(cfg.fillTimeout())
+	for {
+		pos, err := findPosition(t, r.Request.Symbol, r.Request.Side)
+		if qty := positionQuantity(pos); err == nil && qty > before {
+			r.FilledQty = qty - before
+			r.EntryPrice, _ = pos["entryPrice"].(float64)
+			if r.FilledQty >= r.Request.Quantity*0.999 {
+				return nil
+			}
+		}
+		if time.Now().After(deadline) {
+			if r.FilledQty > 0 {
+				log.Printf("⚠️ [TwoPhase] %s 等待成交超时，按部分成交 %.8f/%.8f 继续", r.Request.Symbol, r.FilledQty, r.Request.Quantity)
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("%v 内未发现新增持仓", cfg.fillTimeout())
+		}
+		time.Sleep(cfg.pollInterval())
+	}
+}
+
+// findPosition 查询交易对某方向的持仓（无持仓时返回 nil）
+func findPosition(t Trader, symbol, side string) (map[string]interface{}, error) {
+	positions, err := t.GetPositions()
+	if err != nil {
+		return nil, err
+	}
+	for _, pos := range positions {
+		if pos["symbol"] == symbol && pos["side"] == side {
+			return pos, nil
+		}
+	}
+	return nil, nil
+}
+
+// positionQuantity 持仓数量的绝对值（nil 为0）
+func positionQuantity(pos map[string]interface{}) float64 {
+	qty, _ := pos["positionAmt"].(float64)
+	if qty < 0 {
+		qty = -qty
+	}
+	return qty
+}
+
+// openTwoPhase AutoTrader 标准流程中的两阶段入场，审计记录写入日志
+func (at *AutoTrader) openTwoPhase(d *decision.Decision, side string, quantity float64, posKey string, tradeSig *tradeSignal, actionRecord *logger.DecisionAction) error {
+	result, err := OpenTwoPhase(at.trader, TwoPhaseEntryRequest{
+		Symbol:     d.Symbol,
+		Side:       side,
+		Quantity:   quantity,
+		Leverage:   d.Leverage,
+		StopLoss:   d.StopLoss,
+		TakeProfit: d.TakeProfit,
+	}, at.config.TwoPhaseEntry)
+	if orderID, ok := result.Entry["orderId"].(int64); ok {
+		actionRecord.OrderID = orderID
+	}
+	if err != nil {
+		return err
+	}
+
+	actionRecord.Quantity = result.FilledQty
+	at.positionStopLoss[posKey] = d.StopLoss
+	if d.TakeProfit > 0 {
+		at.positionTakeProfit[posKey] = d.TakeProfit
+	}
+	at.rememberJournalEntry(posKey, d.Reasoning, d.StopLoss)
+	at.rememberTradeSignal(posKey, tradeSig)
+	log.Printf("  ✓ 开仓成功（两阶段入场），成交数量: %.4f", result.FilledQty)
+	return nil
+}
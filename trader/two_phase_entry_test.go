@@ -0,0 +1,192 @@
+package trader
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// twoPhaseTrader 开仓后经过若干次持仓查询才出现持仓的 MockTrader
+type twoPhaseTrader struct {
+	MockTrader
+	fillAfter    int     // 第几次持仓查询开始出现持仓
+	fillQty      float64 // 出现的持仓数量（0 表示按下单数量）
+	opened       float64
+	polls        int
+	stopErr      error
+	takeErr      error
+	stops, takes []float64 // 挂单数量
+	cancelled    int
+	closed       []float64
+	closeErr     error
+}
+
+func (f *twoPhaseTrader) OpenShort(symbol string, quantity float64, leverage int) (map[string]interface{}, error) {
+	f.opened = quantity
+	return f.MockTrader.OpenShort(symbol, quantity, leverage)
+}
+
+func (f *twoPhaseTrader) GetPositions() ([]map[string]interface{}, error) {
+	if f.opened == 0 {
+		return nil, nil
+	}
+	f.polls++
+	if f.polls < f.fillAfter {
+		return nil, nil
+	}
+	qty := f.fillQty
+	if qty == 0 {
+		qty = f.opened
+	}
+	return []map[string]interface{}{
+		{"symbol": "ETHUSDT", "side": "short", "positionAmt": -qty, "entryPrice": 3000.0},
+	}, nil
+}
+
+func (f *twoPhaseTrader) SetStopLoss(symbol, positionSide string, quantity, stopPrice float64) error {
+	f.stops = append(f.stops, quantity)
+	return f.stopErr
+}
+
+func (f *twoPhaseTrader) SetTakeProfit(symbol, positionSide string, quantity, takeProfitPrice float64) error {
+	f.takes = append(f.takes, quantity)
+	return f.takeErr
+}
+
+func (f *twoPhaseTrader) CancelStopLossOrders(symbol string) error {
+	f.cancelled++
+	return nil
+}
+
+func (f *twoPhaseTrader) CloseShort(symbol string, quantity float64) (map[string]interface{}, error) {
+	f.closed = append(f.closed, quantity)
+	return map[string]interface{}{}, f.closeErr
+}
+
+var fastTwoPhase = TwoPhaseEntryConfig{
+	Enabled:      true,
+	FillTimeout:  50 * time.Millisecond,
+	PollInterval: time.Millisecond,
+	Protection:   ProtectionRetryConfig{MaxAttempts: 2, RetryDelay: time.Millisecond},
+}
+
+func twoPhaseRequest() TwoPhaseEntryRequest {
+	return TwoPhaseEntryRequest{Symbol: "ETHUSDT", Side: "short", Quantity: 2, Leverage: 5, StopLoss: 3100, TakeProfit: 2800}
+}
+
+func auditPhases(r *TwoPhaseEntryResult) []string {
+	phases := make([]string, len(r.Audit))
+	for i, e := range r.Audit {
+		phases[i] = e.Phase
+	}
+	return phases
+}
+
+func TestOpenTwoPhase_ConfirmsFillThenProtects(t *testing.T) {
+	tr := &twoPhaseTrader{fillAfter: 3}
+	result, err := OpenTwoPhase(tr, twoPhaseRequest(), fastTwoPhase)
+	require.NoError(t, err)
+	assert.True(t, result.Protected)
+	assert.False(t, result.RolledBack)
+	assert.Equal(t, 2.0, result.FilledQty)
+	assert.Equal(t, 3000.0, result.EntryPrice)
+	assert.Equal(t, []float64{2}, tr.stops)
+	assert.Equal(t, []float64{2}, tr.takes)
+	assert.Equal(t, []string{"entry", "confirm", "stop_loss", "take_profit", "done"}, auditPhases(result))
+}
+
+func TestOpenTwoPhase_PartialFillProtectsFilledQuantity(t *testing.T) {
+	tr := &twoPhaseTrader{fillAfter: 1, fillQty: 1.5}
+	result, err := OpenTwoPhase(tr, twoPhaseRequest(), fastTwoPhase)
+	require.NoError(t, err)
+	assert.Equal(t, 1.5, result.FilledQty)
+	assert.Equal(t, []float64{1.5}, tr.stops, "按实际成交数量挂止损")
+}
+
+func TestOpenTwoPhase_NoFillSkipsProtection(t *testing.T) {
+	tr := &twoPhaseTrader{fillAfter: 1 << 30}
+	result, err := OpenTwoPhase(tr, twoPhaseRequest(), fastTwoPhase)
+	require.Error(t, err)
+	assert.Zero(t, result.FilledQty)
+	assert.Empty(t, tr.stops)
+	assert.Empty(t, tr.closed)
+	assert.Equal(t, []string{"entry", "confirm"}, auditPhases(result))
+	assert.NotEmpty(t, result.Audit[1].Error)
+}
+
+func TestOpenTwoPhase_RollsBackWhenProtectionFails(t *testing.T) {
+	tr := &twoPhaseTrader{fillAfter: 1, takeErr: errors.New("take profit rejected")}
+	result, err := OpenTwoPhase(tr, twoPhaseRequest(), fastTwoPhase)
+	require.Error(t, err)
+	assert.True(t, result.RolledBack)
+	assert.False(t, result.Protected)
+	assert.Len(t, tr.takes, 2, "按重试次数尝试")
+	assert.Equal(t, 1, tr.cancelled, "撤销已挂出的止损")
+	assert.Empty(t, result.StopLossID, "交易器不返回订单ID")
+	assert.Equal(t, []float64{2}, tr.closed)
+	assert.Equal(t, []string{"entry", "confirm", "stop_loss", "take_profit", "rollback"}, auditPhases(result))
+
+	// 回滚平仓也失败时报告，持仓需要人工处理
+	tr = &twoPhaseTrader{fillAfter: 1, stopErr: errors.New("stop rejected"), closeErr: errors.New("close rejected")}
+	result, err = OpenTwoPhase(tr, twoPhaseRequest(), fastTwoPhase)
+	require.Error(t, err)
+	assert.False(t, result.RolledBack)
+	assert.Error(t, result.RollbackErr)
+	assert.Empty(t, tr.takes, "止损失败后不再挂止盈")
+	assert.Zero(t, tr.cancelled, "止损未挂出时无需撤单")
+}
+
+// idTwoPhaseTrader 挂保护单时返回订单ID的 twoPhaseTrader
+type idTwoPhaseTrader struct {
+	twoPhaseTrader
+	cancelledIDs []string
+}
+
+func (f *idTwoPhaseTrader) PlaceStopLoss(symbol, positionSide string, quantity, stopPrice float64) (string, error) {
+	if err := f.SetStopLoss(symbol, positionSide, quantity, stopPrice); err != nil {
+		return "", err
+	}
+	return "sl-1", nil
+}
+
+func (f *idTwoPhaseTrader) PlaceTakeProfit(symbol, positionSide string, quantity, takeProfitPrice float64) (string, error) {
+	if err := f.SetTakeProfit(symbol, positionSide, quantity, takeProfitPrice); err != nil {
+		return "", err
+	}
+	return "tp-1", nil
+}
+
+func (f *idTwoPhaseTrader) CancelProtectionOrder(symbol, orderID string) error {
+	f.cancelledIDs = append(f.cancelledIDs, orderID)
+	return nil
+}
+
+func TestOpenTwoPhase_RollbackCancelsOnlyOwnOrders(t *testing.T) {
+	tr := &idTwoPhaseTrader{twoPhaseTrader: twoPhaseTrader{fillAfter: 1, takeErr: errors.New("take profit rejected")}}
+	result, err := OpenTwoPhase(tr, twoPhaseRequest(), fastTwoPhase)
+	require.Error(t, err)
+	assert.True(t, result.RolledBack)
+	assert.Equal(t, "sl-1", result.StopLossID)
+	assert.Empty(t, result.TakeProfitID)
+	assert.Equal(t, []string{"sl-1"}, tr.cancelledIDs, "只撤销本次挂出的止损")
+	assert.Zero(t, tr.cancelled, "不撤销该交易对的其他止损")
+
+	tr = &idTwoPhaseTrader{twoPhaseTrader: twoPhaseTrader{fillAfter: 1}}
+	result, err = OpenTwoPhase(tr, twoPhaseRequest(), fastTwoPhase)
+	require.NoError(t, err)
+	assert.Equal(t, "sl-1", result.StopLossID)
+	assert.Equal(t, "tp-1", result.TakeProfitID)
+	assert.Empty(t, tr.cancelledIDs)
+}
+
+func TestOpenTwoPhase_InvalidRequest(t *testing.T) {
+	tr := &twoPhaseTrader{}
+	req := twoPhaseRequest()
+	req.StopLoss = 0
+	_, err := OpenTwoPhase(tr, req, fastTwoPhase)
+	assert.Error(t, err)
+	assert.Zero(t, tr.opened)
+}
@@ -0,0 +1,252 @@
+package trader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// VCRMode 录制/回放模式
+type VCRMode int
+
+const (
+	// VCRReplay 只从录制文件回放，不访问网络
+	VCRReplay VCRMode = iota
+	// VCRRecord 访问真实接口并录制响应
+	VCRRecord
+)
+
+// vcrVolatileParams 每次请求都会变化的查询参数，不参与匹配也不写入录制文件
+var vcrVolatileParams = map[string]bool{
+	"timestamp":  true,
+	"signature":  true,
+	"recvWindow": true,
+	"window":     true,
+}
+
+// defaultVCRSanitizeKeys 录制时需要脱敏的响应字段
+var defaultVCRSanitizeKeys = []string{"address", "apiKey", "secret", "signature", "userId", "email"}
+
+// vcrRedacted 脱敏后的占位值
+const vcrRedacted = "REDACTED"
+
+// VCRInteraction 一次录制的请求与响应
+// 请求头（含API Key和签名）不会被录制
+type VCRInteraction struct {
+	Method     string          `json:"method"`
+	Path       string          `json:"path"`
+	Query      string          `json:"query,omitempty"` // 去掉易变参数后按key排序
+	StatusCode int             `json:"status"`
+	Header     http.Header     `json:"header,omitempty"`   // 仅保留 Content-Type 和 Retry-After
+	Response   json.RawMessage `json:"response,omitempty"` // JSON响应
+	RawBody    string          `json:"raw_body,omitempty"` // 非JSON响应
+}
+
+// VCRCassette 录制文件
+type VCRCassette struct {
+	Interactions []VCRInteraction `json:"interactions"`
+}
+
+// VCRTransport 录制/回放交易所HTTP响应的 http.RoundTripper
+// 录制模式下转发到真实接口并保存脱敏后的响应；回放模式下按 方法+路径+查询参数 依次匹配录制内容，
+// 同一请求被录制多次时按顺序返回，用尽后重复返回最后一次（适配轮询）
+type VCRTransport struct {
+	path         string
+	mode         VCRMode
+	next         http.RoundTripper
+	sanitizeKeys map[string]bool
+
+	mu       sync.Mutex
+	cassette VCRCassette
+	used     []bool
+}
+
+// NewVCRTransport 创建录制/回放transport
+// 回放模式会立即加载 path；录制模式下 next 为nil时使用 http.DefaultTransport
+func NewVCRTransport(path string, mode VCRMode, next http.RoundTripper) (*VCRTransport, error) {
+	v := &VCRTransport{path: path, mode: mode, next: next, sanitizeKeys: make(map[string]bool)}
+	for _, k := range defaultVCRSanitizeKeys {
+		v.sanitizeKeys[k] = true
+	}
+
+	if mode == VCRReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("读取录制文件失败: %w", err)
+		}
+		if err := json.Unmarshal(data, &v.cassette); err != nil {
+			return nil, fmt.Errorf("解析录制文件失败: %w", err)
+		}
+		v.used = make([]bool, len(v.cassette.Interactions))
+	} else if v.next == nil {
+		v.next = http.DefaultTransport
+	}
+	return v, nil
+}
+
+// SanitizeKeys 追加需要脱敏的响应字段
+func (v *VCRTransport) SanitizeKeys(keys ...string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for _, k := range keys {
+		v.sanitizeKeys[k] = true
+	}
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (v *VCRTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if v.mode == VCRReplay {
+		return v.replay(req)
+	}
+	return v.record(req)
+}
+
+// replay 返回匹配的录制响应
+func (v *VCRTransport) replay(req *http.Request) (*http.Response, error) {
+	query := vcrQuery(req.URL)
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	last := -1
+	for i, it := range v.cassette.Interactions {
+		if it.Method != req.Method || it.Path != req.URL.Path || it.Query != query {
+			continue
+		}
+		last = i
+		if !v.used[i] {
+			v.used[i] = true
+			return it.toResponse(req), nil
+		}
+	}
+	if last >= 0 {
+		return v.cassette.Interactions[last].toResponse(req), nil
+	}
+	return nil, fmt.Errorf("VCR: 录制文件 %s 中没有匹配的请求: %s %s?%s", v.path, req.Method, req.URL.Path, query)
+}
+
+// record 转发请求并录制脱敏后的响应
+func (v *VCRTransport) record(req *http.Request) (*http.Response, error) {
+	resp, err := v.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("VCR: 读取响应失败: %w", err)
+	}
+	// 调用方拿到的是未脱敏的原始响应
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	it := VCRInteraction{
+		Method:     req.Method,
+		Path:       req.URL.Path,
+		Query:      vcrQuery(req.URL),
+		StatusCode: resp.StatusCode,
+		Header:     http.Header{},
+	}
+	for _, h := range []string{"Content-Type", "Retry-After"} {
+		if value := resp.Header.Get(h); value != "" {
+			it.Header.Set(h, value)
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var parsed interface{}
+	if json.Unmarshal(body, &parsed) == nil {
+		sanitized, err := json.Marshal(v.sanitize(parsed))
+		if err != nil {
+			return nil, fmt.Errorf("VCR: 序列化响应失败: %w", err)
+		}
+		it.Response = sanitized
+	} else {
+		it.RawBody = string(body)
+	}
+	v.cassette.Interactions = append(v.cassette.Interactions, it)
+	return resp, nil
+}
+
+// sanitize 递归替换敏感字段（调用方需持有 v.mu）
+func (v *VCRTransport) sanitize(value interface{}) interface{} {
+	switch val := value.(type) {
+	case map[string]interface{}:
+		for k, child := range val {
+			if v.sanitizeKeys[k] {
+				val[k] = vcrRedacted
+				continue
+			}
+			val[k] = v.sanitize(child)
+		}
+	case []interface{}:
+		for i, child := range val {
+			val[i] = v.sanitize(child)
+		}
+	}
+	return value
+}
+
+// Save 将录制内容写入文件（仅录制模式）
+func (v *VCRTransport) Save() error {
+	if v.mode != VCRRecord {
+		return nil
+	}
+	v.mu.Lock()
+	data, err := json.MarshalIndent(v.cassette, "", "  ")
+	v.mu.Unlock()
+	if err != nil {
+		return fmt.Errorf("序列化录制内容失败: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(v.path), 0755); err != nil {
+		return fmt.Errorf("创建录制目录失败: %w", err)
+	}
+	if err := os.WriteFile(v.path, data, 0644); err != nil {
+		return fmt.Errorf("写入录制文件失败: %w", err)
+	}
+	return nil
+}
+
+// toResponse 构造回放响应
+func (it VCRInteraction) toResponse(req *http.Request) *http.Response {
+	body := []byte(it.RawBody)
+	if len(it.Response) > 0 {
+		body = it.Response
+	}
+	header := it.Header.Clone()
+	if header == nil {
+		header = http.Header{}
+	}
+	if len(it.Response) > 0 && header.Get("Content-Type") == "" {
+		header.Set("Content-Type", "application/json")
+	}
+	return &http.Response{
+		StatusCode:    it.StatusCode,
+		Status:        fmt.Sprintf("%d %s", it.StatusCode, http.StatusText(it.StatusCode)),
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+	}
+}
+
+// vcrQuery 去掉易变参数后的规范化查询字符串
+func vcrQuery(u *url.URL) string {
+	values := u.Query()
+	for k := range values {
+		if vcrVolatileParams[k] {
+			values.Del(k)
+		}
+	}
+	return values.Encode()
+}
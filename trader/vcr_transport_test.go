@@ -0,0 +1,141 @@
+package trader
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newReplayBackpackTrader 创建从录制文件回放响应的 Backpack 交易器
+func newReplayBackpackTrader(t *testing.T, cassette string) *BackpackTrader {
+	vcr, err := NewVCRTransport(filepath.Join("testdata", "vcr", cassette), VCRReplay, nil)
+	require.NoError(t, err)
+	trader := newTestBackpackTrader(t, "http://backpack.vcr")
+	trader.SetTransport(vcr)
+	return trader
+}
+
+func TestVCRReplay_BackpackBalance(t *testing.T) {
+	trader := newReplayBackpackTrader(t, "backpack_account.json")
+
+	balance, err := trader.GetBalance()
+	require.NoError(t, err)
+	// 交易所以字符串返回 netEquity 等字段，抵押品明细只用于缺少净值时的兜底
+	assert.InDelta(t, 1013.0, balance["totalWalletBalance"].(float64), 1e-9)
+	assert.InDelta(t, 812.75, balance["availableBalance"].(float64), 1e-9)
+}
+
+func TestVCRReplay_BackpackPositions(t *testing.T) {
+	trader := newReplayBackpackTrader(t, "backpack_account.json")
+
+	positions, err := trader.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 2, "零持仓应被过滤")
+
+	sol := positions[0]
+	assert.Equal(t, "SOLUSDT", sol["symbol"])
+	assert.Equal(t, "long", sol["side"])
+	assert.InDelta(t, 10, sol["positionAmt"].(float64), 1e-9)
+	assert.InDelta(t, 150, sol["entryPrice"].(float64), 1e-9)
+	assert.InDelta(t, 62.5, sol["unRealizedProfit"].(float64), 1e-9)
+	assert.InDelta(t, 10, sol["leverage"].(float64), 1e-9)
+
+	eth := positions[1]
+	assert.Equal(t, "ETHUSDT", eth["symbol"])
+	assert.Equal(t, "short", eth["side"])
+	assert.InDelta(t, 0.13, eth["positionAmt"].(float64), 1e-9)
+	assert.InDelta(t, 3540, eth["liquidationPrice"].(float64), 1e-9)
+	assert.InDelta(t, 386.75, eth["notional"].(float64), 1e-9)
+}
+
+func TestVCRReplay_BackpackFills(t *testing.T) {
+	trader := newReplayBackpackTrader(t, "backpack_account.json")
+
+	fills, err := trader.FetchFillHistory("SOLUSDT", 0)
+	require.NoError(t, err)
+	require.Len(t, fills, 2)
+
+	assert.Equal(t, "3250001", fills[0].TradeID.String())
+	assert.Equal(t, "", fills[0].ClientID.String(), "clientId 为 null")
+	assert.Equal(t, "Bid", fills[0].Side)
+	assert.InDelta(t, 0.375, fills[0].Fee.Float64(), 1e-9)
+	assert.False(t, fills[0].IsMaker)
+	assert.Equal(t, 2024, fills[0].Time().Year())
+
+	assert.Equal(t, "1718000001", fills[1].ClientID.String())
+	assert.True(t, fills[1].IsMaker)
+}
+
+func TestVCRReplay_BackpackMarkets(t *testing.T) {
+	trader := newReplayBackpackTrader(t, "backpack_account.json")
+
+	qty, err := trader.FormatQuantity("SOLUSDT", 1.23456)
+	require.NoError(t, err)
+	assert.Equal(t, "1.23", qty)
+
+	precision, err := trader.getSymbolPrecision(context.Background(), "BTC_USDC_PERP")
+	require.NoError(t, err)
+	assert.Equal(t, 5, precision.QuantityPrecision)
+	assert.Equal(t, 1, precision.PricePrecision)
+	assert.Equal(t, 0.1, precision.TickSize)
+}
+
+func TestVCRReplay_UnmatchedRequest(t *testing.T) {
+	trader := newReplayBackpackTrader(t, "backpack_account.json")
+
+	_, err := trader.FetchFillHistory("BTCUSDT", 0)
+	assert.ErrorContains(t, err, "没有匹配的请求")
+}
+
+func TestVCRRecord_SanitizesAndReplays(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		assert.NotEmpty(t, r.Header.Get("X-API-Key"), "录制时请求应正常签名")
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Request-Id", "abc")
+		w.Write([]byte(`[{"symbol":"SOL_USDC_PERP","netQuantity":"1","userId":12345,"address":"0xdeadbeef"}]`))
+	}))
+	defer server.Close()
+
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	recorder, err := NewVCRTransport(path, VCRRecord, nil)
+	require.NoError(t, err)
+	recorder.SanitizeKeys("positionId")
+
+	trader := newTestBackpackTrader(t, server.URL)
+	trader.SetTransport(recorder)
+	positions, err := trader.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	require.NoError(t, recorder.Save())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.NotContains(t, string(data), "12345")
+	assert.NotContains(t, string(data), "0xdeadbeef")
+	assert.NotContains(t, string(data), "test-api-key")
+	assert.NotContains(t, string(data), "X-Request-Id")
+
+	var cassette VCRCassette
+	require.NoError(t, json.Unmarshal(data, &cassette))
+	require.Len(t, cassette.Interactions, 1)
+	assert.Equal(t, "/api/v1/position", cassette.Interactions[0].Path)
+
+	// 回放不再访问服务器
+	replayer, err := NewVCRTransport(path, VCRReplay, nil)
+	require.NoError(t, err)
+	trader.SetTransport(replayer)
+	positions, err = trader.GetPositions()
+	require.NoError(t, err)
+	require.Len(t, positions, 1)
+	assert.Equal(t, "SOLUSDT", positions[0]["symbol"])
+	assert.Equal(t, 1, requests)
+}
@@ -0,0 +1,220 @@
+package trader
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"nofx/decision"
+	"nofx/market"
+)
+
+const (
+	defaultVolatilityRecentCandles   = 3
+	defaultVolatilityBaselineCandles = 14 // 近期+基准窗口需在K线缓存容量（20根）以内
+	defaultVolatilityCooldown        = 30 * time.Minute
+	volatilityMinuteCandles          = 3 // 检查极端1分钟K线的根数
+)
+
+// VolatilityCircuitConfig 波动熔断：新闻等突发行情下ATR/实现波动率急剧放大或出现极端1分钟K线时暂停开仓
+// 信号检测器的固定阈值在闪崩/急拉中大量误报，熔断期内拒绝开仓，可选收紧已有持仓的止损
+type VolatilityCircuitConfig struct {
+	TimeFrame        market.TimeFrame // 计算ATR和实现波动率的K线周期（默认5m）
+	RecentCandles    int              // 近期窗口K线根数（默认3）
+	BaselineCandles  int              // 基准窗口K线根数（默认14）
+	ATRExpansion     float64          // 近期ATR/基准ATR 达到该倍数时熔断（0 表示不检查）
+	VolExpansion     float64          // 近期实现波动率/基准实现波动率 达到该倍数时熔断（0 表示不检查）
+	ExtremeCandlePct float64          // 最近1分钟K线振幅（最高-最低）/开盘价 达到该百分比时熔断（0 表示不检查）
+	Cooldown         time.Duration    // 最后一次触发后暂停开仓的时长（默认30分钟）
+	// 同时熔断的交易对达到该数量时暂停全部开仓（0 表示只暂停触发的交易对）
+	// 贝塔基准（BTC）熔断时所有持仓的方向敞口都会受影响，总是暂停全部开仓
+	MarketWideSymbols int
+	TightenStopPct    float64 // 熔断时把所在交易对持仓的止损距离缩小该比例（0.3 = 缩小30%，0 表示不调整），熔断结束后不恢复
+}
+
+// Enabled 是否启用波动熔断
+func (c VolatilityCircuitConfig) Enabled() bool {
+	return c.ATRExpansion > 0 || c.VolExpansion > 0 || c.ExtremeCandlePct > 0
+}
+
+func (c VolatilityCircuitConfig) timeFrame() market.TimeFrame {
+	if c.TimeFrame == "" {
+		return market.TimeFrame5m
+	}
+	return c.TimeFrame
+}
+
+func (c VolatilityCircuitConfig) windows() (recent, baseline int) {
+	recent, baseline = c.RecentCandles, c.BaselineCandles
+	if recent < 2 {
+		recent = defaultVolatilityRecentCandles
+	}
+	if baseline < 2 {
+		baseline = defaultVolatilityBaselineCandles
+	}
+	return recent, baseline
+}
+
+func (c VolatilityCircuitConfig) cooldown() time.Duration {
+	if c.Cooldown <= 0 {
+		return defaultVolatilityCooldown
+	}
+	return c.Cooldown
+}
+
+// Evaluate 检查K线是否出现异常波动，返回触发原因
+// klines 为检测周期的K线（最后 RecentCandles 根为近期窗口，之前 BaselineCandles+1 根为基准窗口），minute 为最近的1分钟K线
+func (c VolatilityCircuitConfig) Evaluate(klines, minute []market.Kline) (string, bool) {
+	recent, baseline := c.windows()
+	if n := len(klines); n >= recent+baseline+1 {
+		base := klines[n-recent-baseline-1 : n-recent]
+		latest := klines[n-recent-1:]
+
+		if c.ATRExpansion > 0 {
+			if baseATR := market.CalculateATR(base, baseline); baseATR > 0 {
+				if ratio := market.CalculateATR(latest, recent) / baseATR; ratio >= c.ATRExpansion {
+					return fmt.Sprintf("ATR扩张 %.1fx", ratio), true
+				}
+			}
+		}
+		if c.VolExpansion > 0 {
+			if baseVol := market.CalculateRealizedVolatility(base, baseline); baseVol > 0 {
+				if ratio := market.CalculateRealizedVolatility(latest, recent) / baseVol; ratio >= c.VolExpansion {
+					return fmt.Sprintf("实现波动率扩张 %.1fx", ratio), true
+				}
+			}
+		}
+	}
+
+	if c.ExtremeCandlePct > 0 {
+		for _, k := range minute {
+			if k.Open <= 0 {
+				continue
+			}
+			if rangePct := (k.High - k.Low) / k.Open * 100; rangePct >= c.ExtremeCandlePct {
+				return fmt.Sprintf("1分钟K线振幅 %.2f%%", rangePct), true
+			}
+		}
+	}
+	return "", false
+}
+
+// minuteKlineFunc 获取最近 limit 根1分钟K线
+type minuteKlineFunc func(symbol string, limit int) ([]market.Kline, error)
+
+// volatilityHalt 某交易对的熔断状态
+type volatilityHalt struct {
+	Reason string
+	Until  time.Time
+}
+
+// updateVolatilityCircuit 检查候选币种、持仓币种和贝塔基准的波动，触发时暂停开仓并收紧持仓止损
+func (at *AutoTrader) updateVolatilityCircuit(symbols []string, positions []decision.PositionInfo, now time.Time) {
+	cfg := at.config.VolatilityCircuit
+	if !cfg.Enabled() {
+		return
+	}
+	if at.volatilityHalts == nil {
+		at.volatilityHalts = make(map[string]volatilityHalt)
+	}
+
+	check := make(map[string]bool, len(symbols)+len(positions)+1)
+	for _, symbol := range symbols {
+		check[symbol] = true
+	}
+	for _, pos := range positions {
+		check[pos.Symbol] = true
+	}
+	check[exposureBenchmark] = true
+
+	recent, baseline := cfg.windows()
+	for symbol := range check {
+		var klines, minute []market.Kline
+		if at.klineCache != nil {
+			klines, _ = at.klineCache.GetKlines(symbol, cfg.timeFrame(), recent+baseline+1)
+		}
+		if cfg.ExtremeCandlePct > 0 && at.minuteKlines != nil {
+			var err error
+			if minute, err = at.minuteKlines(symbol, volatilityMinuteCandles); err != nil {
+				log.Printf("⚠️  波动熔断：获取 %s 1分钟K线失败: %v", symbol, err)
+			}
+		}
+		reason, tripped := cfg.Evaluate(klines, minute)
+		if !tripped {
+			continue
+		}
+		if _, halted := at.volatilityHalts[symbol]; !halted {
+			at.alertf("⚡ %s 波动熔断（%s），%v 内暂停开仓", symbol, reason, cfg.cooldown())
+		}
+		at.volatilityHalts[symbol] = volatilityHalt{Reason: reason, Until: now.Add(cfg.cooldown())}
+	}
+
+	for symbol, halt := range at.volatilityHalts {
+		if !now.Before(halt.Until) {
+			delete(at.volatilityHalts, symbol)
+			log.Printf("  ✓ %s 波动熔断结束，恢复开仓", symbol)
+		}
+	}
+
+	at.tightenVolatilityStops(positions)
+}
+
+// tightenVolatilityStops 熔断交易对的持仓止损距离缩小 TightenStopPct（每次熔断只收紧一次）
+func (at *AutoTrader) tightenVolatilityStops(positions []decision.PositionInfo) {
+	cfg := at.config.VolatilityCircuit
+	if at.volatilityStops == nil {
+		at.volatilityStops = make(map[string]string)
+	}
+
+	open := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		posKey := pos.Symbol + "_" + pos.Side
+		open[posKey] = true
+		if _, halted := at.volatilityHalts[pos.Symbol]; !halted || cfg.TightenStopPct <= 0 || at.volatilityStops[posKey] != "" {
+			continue
+		}
+		stop := at.positionStopLoss[posKey]
+		if stop <= 0 || pos.MarkPrice <= 0 {
+			continue
+		}
+		// 价格已越过止损时交由止损单处理
+		if (pos.Side == "long" && pos.MarkPrice <= stop) || (pos.Side == "short" && pos.MarkPrice >= stop) {
+			continue
+		}
+		newStop := widenedStop(pos.Side, pos.MarkPrice, stop, -cfg.TightenStopPct)
+		if err := at.replaceStopLoss(pos, newStop); err != nil {
+			log.Printf("  ⚠ %s %s 波动熔断收紧止损失败: %v", pos.Symbol, pos.Side, err)
+			continue
+		}
+		at.volatilityStops[posKey] = pos.Symbol
+		log.Printf("  ⚡ %s %s 波动熔断，止损 %.4f → %.4f", pos.Symbol, pos.Side, stop, newStop)
+	}
+
+	// 熔断结束或已平仓后，下次熔断可再次收紧
+	for posKey, symbol := range at.volatilityStops {
+		if _, halted := at.volatilityHalts[symbol]; !halted || !open[posKey] {
+			delete(at.volatilityStops, posKey)
+		}
+	}
+}
+
+// checkVolatilityCircuit 熔断期内拒绝开仓
+func (at *AutoTrader) checkVolatilityCircuit(symbol string, now time.Time) error {
+	cfg := at.config.VolatilityCircuit
+	active := 0
+	for _, halt := range at.volatilityHalts {
+		if now.Before(halt.Until) {
+			active++
+		}
+	}
+	if halt, ok := at.volatilityHalts[exposureBenchmark]; ok && now.Before(halt.Until) {
+		return fmt.Errorf("❌ %s 波动熔断中（%s），暂停全部开仓", exposureBenchmark, halt.Reason)
+	}
+	if cfg.MarketWideSymbols > 0 && active >= cfg.MarketWideSymbols {
+		return fmt.Errorf("❌ %d 个交易对同时波动熔断，暂停全部开仓", active)
+	}
+	if halt, ok := at.volatilityHalts[symbol]; ok && now.Before(halt.Until) {
+		return fmt.Errorf("❌ %s 波动熔断中（%s，剩余 %.0f 分钟），拒绝开仓", symbol, halt.Reason, halt.Until.Sub(now).Minutes())
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package trader
+
+import (
+	"testing"
+	"time"
+
+	"nofx/decision"
+	"nofx/market"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// volatilityKlines 生成 calm 根振幅1的K线，再接 spike 根振幅 spikeRange 的K线
+func volatilityKlines(calm, spike int, spikeRange float64) []market.Kline {
+	var klines []market.Kline
+	price := 100.0
+	for i := 0; i < calm+spike; i++ {
+		r := 1.0
+		if i >= calm {
+			r = spikeRange
+		}
+		// 收盘价交替涨跌，使实现波动率随振幅放大
+		next := price + r/2
+		if i%2 == 1 {
+			next = price - r/2
+		}
+		klines = append(klines, market.Kline{Open: price, High: price + r/2, Low: price - r/2, Close: next})
+		price = next
+	}
+	return klines
+}
+
+func TestVolatilityCircuitConfig_Evaluate(t *testing.T) {
+	cfg := VolatilityCircuitConfig{ATRExpansion: 3}
+	_, tripped := cfg.Evaluate(volatilityKlines(18, 0, 0), nil)
+	assert.False(t, tripped, "平稳行情不熔断")
+
+	reason, tripped := cfg.Evaluate(volatilityKlines(15, 3, 5), nil)
+	assert.True(t, tripped)
+	assert.Contains(t, reason, "ATR扩张")
+
+	_, tripped = cfg.Evaluate(volatilityKlines(10, 3, 5), nil)
+	assert.False(t, tripped, "K线不足时不判断")
+
+	cfg = VolatilityCircuitConfig{VolExpansion: 3}
+	reason, tripped = cfg.Evaluate(volatilityKlines(15, 3, 8), nil)
+	assert.True(t, tripped)
+	assert.Contains(t, reason, "实现波动率扩张")
+
+	cfg = VolatilityCircuitConfig{ExtremeCandlePct: 2}
+	minute := []market.Kline{{Open: 100, High: 100.5, Low: 99.8}, {Open: 100, High: 101.5, Low: 99.2}}
+	reason, tripped = cfg.Evaluate(nil, minute)
+	assert.True(t, tripped)
+	assert.Contains(t, reason, "1分钟K线振幅 2.30%")
+	_, tripped = cfg.Evaluate(nil, minute[:1])
+	assert.False(t, tripped)
+
+	assert.False(t, VolatilityCircuitConfig{}.Enabled())
+}
+
+func TestAutoTrader_VolatilityCircuit(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	spiking := map[string]bool{"SOLUSDT": true}
+	tr := &stopRecordingTrader{}
+	at := &AutoTrader{
+		trader: tr,
+		config: AutoTraderConfig{VolatilityCircuit: VolatilityCircuitConfig{
+			ExtremeCandlePct:  2,
+			Cooldown:          20 * time.Minute,
+			MarketWideSymbols: 2,
+			TightenStopPct:    0.5,
+		}},
+		minuteKlines: func(symbol string, limit int) ([]market.Kline, error) {
+			if spiking[symbol] {
+				return []market.Kline{{Open: 100, High: 104, Low: 99}}, nil
+			}
+			return []market.Kline{{Open: 100, High: 100.2, Low: 99.9}}, nil
+		},
+		positionStopLoss:   map[string]float64{"SOLUSDT_long": 90, "ETHUSDT_short": 110},
+		positionTakeProfit: map[string]float64{},
+	}
+	positions := []decision.PositionInfo{
+		{Symbol: "SOLUSDT", Side: "long", MarkPrice: 100, Quantity: 1},
+		{Symbol: "ETHUSDT", Side: "short", MarkPrice: 100, Quantity: 1},
+	}
+	symbols := []string{"SOLUSDT", "ETHUSDT", "XRPUSDT"}
+
+	// SOL 熔断：只暂停 SOL 开仓，SOL 持仓止损距离缩小一半
+	at.updateVolatilityCircuit(symbols, positions, now)
+	require.Error(t, at.checkVolatilityCircuit("SOLUSDT", now.Add(time.Minute)))
+	assert.NoError(t, at.checkVolatilityCircuit("ETHUSDT", now.Add(time.Minute)))
+	assert.Equal(t, []float64{95}, tr.stops)
+	assert.Equal(t, 95.0, at.positionStopLoss["SOLUSDT_long"])
+
+	// 熔断期内不重复收紧
+	at.updateVolatilityCircuit(symbols, positions, now.Add(5*time.Minute))
+	assert.Len(t, tr.stops, 1)
+
+	// 两个交易对同时熔断：暂停全部开仓
+	spiking["XRPUSDT"] = true
+	at.updateVolatilityCircuit(symbols, positions, now.Add(6*time.Minute))
+	assert.Error(t, at.checkVolatilityCircuit("ETHUSDT", now.Add(7*time.Minute)))
+
+	// 冷却结束后恢复
+	spiking = map[string]bool{}
+	at.updateVolatilityCircuit(symbols, positions, now.Add(30*time.Minute))
+	assert.NoError(t, at.checkVolatilityCircuit("SOLUSDT", now.Add(30*time.Minute)))
+	assert.Empty(t, at.volatilityHalts)
+	assert.Empty(t, at.volatilityStops)
+
+	// 贝塔基准熔断：暂停全部开仓
+	spiking[exposureBenchmark] = true
+	at.updateVolatilityCircuit(symbols, nil, now.Add(time.Hour))
+	assert.Error(t, at.checkVolatilityCircuit("ETHUSDT", now.Add(time.Hour)))
+}